@@ -0,0 +1,70 @@
+// anonymize turns a backup archive (see cmd/backup and /admin/backups)
+// into a privacy-safe dataset for staging and test environments: usernames
+// and emails are replaced by keyed pseudonyms, and every user's password
+// hash by that of -password.  The football data is public and kept.
+//
+//	go run ./cmd/backup dump | go run ./cmd/anonymize > staging.json.gz
+//	DATABASE_URL=$STAGING_URL go run ./cmd/backup restore staging.json.gz
+//
+// ANONYMIZE_KEY keys the pseudonyms, so that refreshing staging with the
+// same key keeps each user's pseudonym; keep it secret, as with it a
+// pseudonym can be confirmed against a guessed username.  Without it a
+// random key is used and pseudonyms change on every run.
+package main
+
+import (
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/backup"
+)
+
+func main() {
+	password := flag.String("password", "password", "password every anonymised user is given")
+	flag.Parse()
+	if flag.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, "usage: anonymize [-password p] [archive.json.gz] > anonymized.json.gz")
+		os.Exit(2)
+	}
+
+	var in io.Reader = os.Stdin
+	if flag.NArg() == 1 {
+		f, err := os.Open(flag.Arg(0))
+		if err != nil {
+			fail(err)
+		}
+		defer f.Close()
+		in = f
+	}
+	a, err := backup.Read(in)
+	if err != nil {
+		fail(err)
+	}
+
+	key := []byte(os.Getenv("ANONYMIZE_KEY"))
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		rand.Read(key)
+		fmt.Fprintln(os.Stderr, "ANONYMIZE_KEY not set; pseudonyms will differ from earlier runs")
+	}
+	hash, err := auth.NewPasswordHasher(auth.Argon2Params{}).Hash(*password)
+	if err != nil {
+		fail(err)
+	}
+	anon, err := backup.NewAnonymizer(key, hash).Archive(a)
+	if err != nil {
+		fail(err)
+	}
+	if err := backup.Write(os.Stdout, anon); err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
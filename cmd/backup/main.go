@@ -0,0 +1,93 @@
+// backup dumps the users and football data of the database at
+// DATABASE_URL to a gzipped JSON archive, or restores one, for deployments
+// without DBA tooling:
+//
+//	go run ./cmd/backup dump > backup.json.gz
+//	go run ./cmd/backup restore -dry-run backup.json.gz
+//	go run ./cmd/backup restore backup.json.gz
+//
+// Archives are the same as those taken through /admin/backups, so one
+// downloaded from there can be restored here.  Restore replaces the
+// archived tables and ends every session; -dry-run validates the archive
+// against the schema and changes nothing.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/backup"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/postgres"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	db, err := postgres.ConnectFromEnv()
+	if err != nil {
+		fail(err)
+	}
+	if db == nil {
+		fail(fmt.Errorf("DATABASE_URL is not set"))
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	switch os.Args[1] {
+	case "dump":
+		a, err := backup.Dump(ctx, db)
+		if err != nil {
+			fail(err)
+		}
+		if err := backup.Write(os.Stdout, a); err != nil {
+			fail(err)
+		}
+	case "restore":
+		fs := flag.NewFlagSet("restore", flag.ExitOnError)
+		dryRun := fs.Bool("dry-run", false, "validate the archive and roll back")
+		fs.Parse(os.Args[2:])
+		if fs.NArg() != 1 {
+			usage()
+		}
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			fail(err)
+		}
+		a, err := backup.Read(f)
+		f.Close()
+		if err != nil {
+			fail(err)
+		}
+		counts, err := backup.Restore(ctx, db, a, *dryRun)
+		if err != nil {
+			fail(err)
+		}
+		report(os.Stdout, counts, *dryRun)
+	default:
+		usage()
+	}
+}
+
+func report(w io.Writer, counts []models.TableCount, dryRun bool) {
+	for _, c := range counts {
+		fmt.Fprintf(w, "%-24s %d\n", c.Table, c.Rows)
+	}
+	if dryRun {
+		fmt.Fprintln(w, "dry run: nothing was changed")
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: backup dump > archive.json.gz\n       backup restore [-dry-run] archive.json.gz")
+	os.Exit(2)
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
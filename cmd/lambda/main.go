@@ -0,0 +1,121 @@
+// lambda runs the API on AWS Lambda behind API Gateway (REST or HTTP API)
+// or an Application Load Balancer.  Build it for the provided.al2023
+// runtime, which runs an executable named bootstrap:
+//
+//	GOOS=linux GOARCH=arm64 go build -o bootstrap ./cmd/lambda
+//	zip api.zip bootstrap
+//
+// Secrets are read as by cmd/server, typically from Secrets Manager
+// (AWS_SECRET_ID) or Parameter Store (SSM_PARAMETER_PATH) using the
+// function's role.  The server, including its database pool, is built once
+// per execution environment and reused by every invocation it serves.
+//
+// Only the settings that make sense per invocation are read: JWT_SECRET,
+// DATABASE_URL, ADMIN_USERS, LOG_LEVEL, LOG_PII, LOG_REDACT_FIELDS,
+// LOG_PSEUDONYM_KEY, FIELD_ENCRYPTION_KEYS and VERSION_HEADER.  Listener, TLS and process-level
+// options do not apply.
+package main
+
+import (
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/app"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/config"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/crypt"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/lambda"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/logging"
+	"github.com/sc23bd/COMP3011_Coursework1/pkg/server"
+)
+
+// maxDBConns bounds each execution environment's pool.  An environment
+// serves one invocation at a time, and every concurrent environment holds
+// its own pool, so a small value keeps the database's connection count
+// proportional to the function's concurrency.
+const maxDBConns = 2
+
+func main() {
+	secrets := config.SecretsFromEnv()
+	secret := func(name string) string {
+		v, err := secrets.Secret(name)
+		if err != nil {
+			log.Fatalf("failed to load %s: %v", name, err)
+		}
+		return v
+	}
+
+	jwtSecret := secret("JWT_SECRET")
+	if jwtSecret == "" {
+		log.Fatal("JWT_SECRET is required but not set")
+	}
+	logKey := []byte(secret("LOG_PSEUDONYM_KEY"))
+	if len(logKey) == 0 {
+		logKey = config.DeriveKey(jwtSecret, config.PurposeLogPseudonyms)
+	}
+	var fieldKeys *crypt.Keyring
+	if v := secret("FIELD_ENCRYPTION_KEYS"); v != "" {
+		var err error
+		if fieldKeys, err = crypt.ParseKeys(v); err != nil {
+			log.Fatalf("invalid FIELD_ENCRYPTION_KEYS: %v", err)
+		}
+	}
+	logLevel := slog.LevelInfo
+	if v := os.Getenv("LOG_LEVEL"); v != "" {
+		var err error
+		if logLevel, err = logging.ParseLevel(v); err != nil {
+			log.Fatalf("LOG_LEVEL must be debug, info, warn or error, got %q", v)
+		}
+	}
+
+	srv, err := server.New(server.Config{
+		DatabaseURL: secret("DATABASE_URL"),
+		Router: server.RouterConfig{
+			JWTSecret:       jwtSecret,
+			AdminUsers:      config.Usernames(os.Getenv("ADMIN_USERS")),
+			LogLevel:        logging.NewLevel(logLevel),
+			LogPII:          os.Getenv("LOG_PII") == "true",
+			LogRedactFields: splitList(os.Getenv("LOG_REDACT_FIELDS")),
+			LogPseudonymKey: logKey,
+			FieldKeys:       fieldKeys,
+			VersionHeader:   os.Getenv("VERSION_HEADER") == "true",
+			PrivateReads:    os.Getenv("PRIVATE_READS") == "true",
+			Deletes: server.DeleteOptions{
+				Idempotent: os.Getenv("DELETE_IDEMPOTENT") == "true",
+				Tombstones: os.Getenv("DELETE_TOMBSTONES") == "true",
+			},
+			Terms: server.TermsConfig{
+				Version: os.Getenv("TOS_VERSION"),
+				Enforce: os.Getenv("TOS_ENFORCE") == "true",
+			},
+			CORS: server.CORSConfig{
+				ReadOrigins: splitList(os.Getenv("CORS_READ_ORIGINS")),
+				Origins:     splitList(os.Getenv("CORS_ORIGINS")),
+				Credentials: os.Getenv("CORS_CREDENTIALS") == "true",
+			},
+			Plugins: app.Default.Plugins(),
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if db := srv.DB(); db != nil {
+		db.SetMaxOpenConns(maxDBConns)
+		db.SetMaxIdleConns(maxDBConns)
+	}
+
+	if err := lambda.Start(srv.Handler()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
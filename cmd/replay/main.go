@@ -0,0 +1,152 @@
+// replay sends the requests of a recording (see PUT /admin/recording) to a
+// local instance and reports every response whose status differs from the
+// recorded one, to reproduce client-reported bugs:
+//
+//	go run ./cmd/replay -url http://localhost:8080 -token "$TOKEN" recording-20240101T120000Z.jsonl
+//
+// Credentials are redacted in recordings, so requests that were
+// authenticated are sent with -token instead.  Usernames and emails are
+// pseudonymised, so replayed logins fail unless the local database has
+// matching users.  It exits 1 when any response differs.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/recording"
+)
+
+func main() {
+	baseURL := flag.String("url", "http://localhost:8080", "base URL of the instance to replay against")
+	token := flag.String("token", "", "bearer token sent in place of redacted Authorization headers")
+	prefix := flag.String("path", "", "only replay requests whose path starts with this prefix")
+	compareBody := flag.Bool("body", false, "also report responses whose JSON body differs")
+	flag.Parse()
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: replay [flags] recording.jsonl...")
+		os.Exit(2)
+	}
+
+	r := replayer{
+		base:        strings.TrimRight(*baseURL, "/"),
+		token:       *token,
+		prefix:      *prefix,
+		compareBody: *compareBody,
+		client:      http.DefaultClient,
+		out:         os.Stdout,
+	}
+	diffs := 0
+	for _, name := range flag.Args() {
+		f, err := os.Open(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		exchanges, err := recording.Load(f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			os.Exit(2)
+		}
+		diffs += r.replay(exchanges)
+	}
+	if diffs > 0 {
+		fmt.Printf("%d response(s) differ\n", diffs)
+		os.Exit(1)
+	}
+}
+
+type replayer struct {
+	base        string
+	token       string
+	prefix      string
+	compareBody bool
+	client      *http.Client
+	out         io.Writer
+}
+
+// skipHeaders are not copied from the recorded request.
+var skipHeaders = map[string]bool{"Content-Length": true, "Connection": true, "X-Request-Id": true}
+
+// replay sends each exchange in order and returns how many responses
+// differed from the recording.
+func (r replayer) replay(exchanges []recording.Exchange) int {
+	diffs := 0
+	for _, e := range exchanges {
+		if !strings.HasPrefix(e.Path, r.prefix) {
+			continue
+		}
+		if msg := r.one(e); msg != "" {
+			diffs++
+			fmt.Fprintf(r.out, "DIFF %s %s: %s\n", e.Method, e.Path, msg)
+		} else {
+			fmt.Fprintf(r.out, "ok   %s %s %d\n", e.Method, e.Path, e.Status)
+		}
+	}
+	return diffs
+}
+
+// one replays e and describes how the response differs, or returns "".
+func (r replayer) one(e recording.Exchange) string {
+	req, err := http.NewRequest(e.Method, r.base+e.Path, bytes.NewReader(requestBody(e)))
+	if err != nil {
+		return err.Error()
+	}
+	for name, values := range e.RequestHeader {
+		if skipHeaders[http.CanonicalHeaderKey(name)] {
+			continue
+		}
+		for _, v := range values {
+			if v != recording.Redacted {
+				req.Header.Add(name, v)
+			}
+		}
+	}
+	if e.RequestHeader.Get("Authorization") == recording.Redacted && r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return err.Error()
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+
+	if res.StatusCode != e.Status {
+		return fmt.Sprintf("status %d, recorded %d: %s", res.StatusCode, e.Status, bytes.TrimSpace(body))
+	}
+	if r.compareBody && !sameJSON(body, e.ResponseBody) {
+		return fmt.Sprintf("body %s, recorded %s", bytes.TrimSpace(body), e.ResponseBody)
+	}
+	return ""
+}
+
+// requestBody returns the body to send.  Recordings keep non-JSON bodies as
+// a JSON string, which is unwrapped here.
+func requestBody(e recording.Exchange) []byte {
+	if len(e.RequestBody) == 0 {
+		return nil
+	}
+	var s string
+	if !strings.Contains(e.RequestHeader.Get("Content-Type"), "json") && json.Unmarshal(e.RequestBody, &s) == nil {
+		return []byte(s)
+	}
+	return e.RequestBody
+}
+
+func sameJSON(a, b []byte) bool {
+	var x, y interface{}
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return bytes.Equal(bytes.TrimSpace(a), bytes.TrimSpace(b))
+	}
+	return reflect.DeepEqual(x, y)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/recording"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/redact"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/router"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/testsupport"
+)
+
+func TestReplay_ReproducesRecordedSession(t *testing.T) {
+	rec := recording.New(t.TempDir(), redact.New(nil, nil, false))
+	recorded := testsupport.New(t, func(cfg *router.Config) { cfg.Recording = rec })
+	state, err := rec.Start(0)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	alice := recorded.As("alice")
+	alice.Do(http.MethodPost, "/api/v1/football/teams", models.CreateTeamRequest{Name: "England"})
+	alice.Do(http.MethodGet, "/api/v1/football/teams/1", nil)
+	alice.Do(http.MethodGet, "/api/v1/football/teams/99", nil)
+	rec.Stop()
+
+	f, err := os.Open(state.File)
+	if err != nil {
+		t.Fatalf("open recording: %v", err)
+	}
+	defer f.Close()
+	exchanges, err := recording.Load(f)
+	if err != nil || len(exchanges) != 3 {
+		t.Fatalf("expected 3 exchanges, got %d (%v)", len(exchanges), err)
+	}
+
+	fresh := testsupport.New(t)
+	srv := httptest.NewServer(fresh.Handler)
+	defer srv.Close()
+	var out bytes.Buffer
+	r := replayer{base: srv.URL, token: fresh.Token("alice"), client: srv.Client(), out: &out}
+	if diffs := r.replay(exchanges); diffs != 0 {
+		t.Fatalf("expected a faithful replay, got %d diffs:\n%s", diffs, out.String())
+	}
+
+	// Without credentials the write is refused, which replay reports.
+	out.Reset()
+	r = replayer{base: srv.URL, client: srv.Client(), out: &out, prefix: "/api/v1/football/teams"}
+	if diffs := r.replay(exchanges[:1]); diffs != 1 || !strings.Contains(out.String(), "status 401, recorded 201") {
+		t.Fatalf("expected a 401 diff, got %d:\n%s", diffs, out.String())
+	}
+}
@@ -17,22 +17,83 @@
 package main
 
 import (
+	"context"
 	"crypto/rand"
+	"crypto/tls"
 	"encoding/base64"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
+	"maps"
 	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
 
+	"github.com/sc23bd/COMP3011_Coursework1/internal/alert"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/app"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/classify"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/config"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/crypt"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/db/postgres"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/flags"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/logging"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/middleware"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/notify"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/preflight"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/recording"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/redact"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/router"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/scheduler"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/systemd"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/upgrade"
+	"github.com/sc23bd/COMP3011_Coursework1/pkg/server"
 )
 
+// shutdownTimeout bounds how long in-flight requests may run after SIGTERM.
+const shutdownTimeout = 15 * time.Second
+
+// upgradeTimeout bounds how long a new process started on SIGUSR2 may take
+// to start serving before the upgrade is abandoned.
+const upgradeTimeout = 30 * time.Second
+
 func main() {
+	check := flag.Bool("check", false, "validate configuration, database and migrations, print a report and exit")
+	pluginSQL := flag.Bool("plugin-sql", false, "print the SQL migrations of compiled-in plugins and exit")
+	flag.Parse()
+
+	if *pluginSQL {
+		for _, m := range app.Default.Migrations() {
+			fmt.Printf("-- %s\n%s\n", m.Name, m.SQL)
+		}
+		return
+	}
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
-	jwtSecret := os.Getenv("JWT_SECRET")
+	// Secrets may come from the environment, *_FILE mounts or Vault.
+	secrets := config.SecretsFromEnv()
+	if *check {
+		os.Exit(runChecks(secrets))
+	}
+	secret := func(name string) string {
+		v, err := secrets.Secret(name)
+		if err != nil {
+			log.Fatalf("failed to load %s: %v", name, err)
+		}
+		return v
+	}
+
+	jwtSecret := secret("JWT_SECRET")
 	if jwtSecret == "" {
 		// In development, allow falling back to a random secret when DEV_MODE is explicitly enabled.
 		if os.Getenv("DEV_MODE") == "true" {
@@ -43,26 +104,550 @@ func main() {
 			jwtSecret = base64.StdEncoding.EncodeToString(randomBytes)
 			log.Println("WARNING: Using randomly generated JWT_SECRET because DEV_MODE=true. Do not use this configuration in production; set the JWT_SECRET environment variable instead.")
 		} else {
-			log.Fatal("JWT_SECRET environment variable is required but not set. Refusing to start without a stable JWT secret.")
+			log.Fatal("JWT_SECRET (or JWT_SECRET_FILE) is required but not set. Refusing to start without a stable JWT secret.")
 		}
 	}
 
-	// Connect to PostgreSQL.
-	db, err := postgres.ConnectFromEnv()
+	logKey := []byte(secret("LOG_PSEUDONYM_KEY"))
+	if len(logKey) == 0 {
+		logKey = config.DeriveKey(jwtSecret, config.PurposeLogPseudonyms)
+	}
+	var fieldKeys *crypt.Keyring
+	if v := secret("FIELD_ENCRYPTION_KEYS"); v != "" {
+		var err error
+		if fieldKeys, err = crypt.ParseKeys(v); err != nil {
+			log.Fatalf("invalid FIELD_ENCRYPTION_KEYS: %v", err)
+		}
+	}
+
+	hmacKeys, err := parsePairs(secret("HMAC_KEYS"))
 	if err != nil {
-		log.Fatalf("failed to connect to database: %v", err)
+		log.Fatalf("invalid HMAC_KEYS: %v", err)
 	}
-	if db != nil {
-		log.Println("Connected to PostgreSQL database")
-		defer db.Close()
-	} else {
+	certSubjects, err := parsePairs(os.Getenv("CLIENT_CERT_SUBJECTS"))
+	if err != nil {
+		log.Fatalf("invalid CLIENT_CERT_SUBJECTS: %v", err)
+	}
+	config.NormalizeIdentities(certSubjects)
+	schedules, err := parseSchedules(os.Getenv("JOB_SCHEDULES"))
+	if err != nil {
+		log.Fatalf("invalid JOB_SCHEDULES: %v", err)
+	}
+	featureFlags, err := loadFeatureFlags()
+	if err != nil {
+		log.Fatalf("invalid feature flags: %v", err)
+	}
+
+	concurrency := router.ConcurrencyConfig{
+		Global:       envInt("MAX_CONCURRENT_REQUESTS", 0),
+		Auth:         envInt("MAX_CONCURRENT_AUTH_REQUESTS", 0),
+		Football:     envInt("MAX_CONCURRENT_FOOTBALL_REQUESTS", 0),
+		QueueTimeout: envDuration("CONCURRENCY_QUEUE_TIMEOUT", 100*time.Millisecond),
+	}
+
+	logLevel := slog.LevelInfo
+	if v := os.Getenv("LOG_LEVEL"); v != "" {
+		if logLevel, err = logging.ParseLevel(v); err != nil {
+			log.Fatalf("LOG_LEVEL must be debug, info, warn or error, got %q", v)
+		}
+	}
+
+	isolation, err := postgres.ParseIsolation(os.Getenv("DB_TX_ISOLATION"))
+	if err != nil {
+		log.Fatalf("DB_TX_ISOLATION must be read-committed, repeatable-read or serializable: %v", err)
+	}
+
+	cfg := server.Config{
+		Addr:        ":" + port,
+		DatabaseURL: secret("DATABASE_URL"),
+		SlowQueries: postgres.SlowQueryLog{
+			Threshold: envDuration("SLOW_QUERY_THRESHOLD", 0),
+			ShowArgs:  os.Getenv("LOG_PII") == "true",
+			Key:       logKey,
+		},
+		TLSCertFile:     os.Getenv("TLS_CERT_FILE"),
+		TLSKeyFile:      os.Getenv("TLS_KEY_FILE"),
+		AdminAddr:       os.Getenv("ADMIN_ADDR"),
+		DiagnosticsAddr: os.Getenv("DIAGNOSTICS_ADDR"),
+		DisableHTTP2:    os.Getenv("HTTP2") == "false",
+		H2C:             os.Getenv("H2C") == "true",
+		HTTP3:           os.Getenv("HTTP3") == "true",
+		DrainDelay:      envDuration("DRAIN_DELAY", 0),
+		MigrateOnStart:  os.Getenv("MIGRATE_ON_START") == "true",
+		SlowDependency:  envDuration("READYZ_SLOW_THRESHOLD", 250*time.Millisecond),
+		Schedules:       schedules,
+		FeatureFlags:    featureFlags,
+		SMTP: notify.SMTPConfig{
+			Addr:     os.Getenv("SMTP_ADDR"),
+			From:     os.Getenv("SMTP_FROM"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: secret("SMTP_PASSWORD"),
+		},
+		AlertEmails:  splitList(os.Getenv("ALERT_EMAILS")),
+		ReportEmails: splitList(os.Getenv("REPORT_EMAILS")),
+		Metering: server.MeteringConfig{
+			Enabled:   os.Getenv("METERING") == "true",
+			ExportURL: os.Getenv("METERING_EXPORT_URL"),
+		},
+		Analytics: server.AnalyticsConfig{
+			Enabled:   os.Getenv("ANALYTICS") == "true",
+			Key:       []byte(secret("ANALYTICS_KEY")),
+			Retention: time.Duration(envInt("ANALYTICS_RETENTION_DAYS", 90)) * 24 * time.Hour,
+		},
+		BackupDir: os.Getenv("BACKUP_DIR"),
+	}
+	if cfg.DatabaseURL == "" {
 		log.Println("No DATABASE_URL set — running without a database connection")
 	}
+	if cfg.TLSCertFile != "" && cfg.TLSKeyFile != "" {
+		if cfg.TLSConfig, err = clientTLSConfig(os.Getenv("TLS_CLIENT_CA_FILE"), os.Getenv("TLS_CLIENT_AUTH")); err != nil {
+			log.Fatalf("invalid TLS client authentication settings: %v", err)
+		}
+	}
+	cfg.Router = router.Config{
+		JWTSecret:          jwtSecret,
+		HMACKeys:           hmacKeys,
+		HMACMaxBody:        int64(envInt("HMAC_MAX_BODY", 0)),
+		ClientCertSubjects: certSubjects,
+		AdminUsers:         config.Usernames(os.Getenv("ADMIN_USERS")),
+		Concurrency:        concurrency,
+		LogLevel:           logging.NewLevel(logLevel),
+		LogPII:             os.Getenv("LOG_PII") == "true",
+		LogRedactFields:    splitList(os.Getenv("LOG_REDACT_FIELDS")),
+		LogPseudonymKey:    logKey,
+		FieldKeys:          fieldKeys,
+		VersionHeader:      os.Getenv("VERSION_HEADER") == "true",
+		PrivateReads:       os.Getenv("PRIVATE_READS") == "true",
+		CORS: router.CORSConfig{
+			ReadOrigins: splitList(os.Getenv("CORS_READ_ORIGINS")),
+			Origins:     splitList(os.Getenv("CORS_ORIGINS")),
+			Credentials: os.Getenv("CORS_CREDENTIALS") == "true",
+			MaxAge:      envDuration("CORS_MAX_AGE", 0),
+		},
+		Deletes: server.DeleteOptions{
+			Idempotent: os.Getenv("DELETE_IDEMPOTENT") == "true",
+			Tombstones: os.Getenv("DELETE_TOMBSTONES") == "true",
+		},
+		Terms: router.TermsConfig{
+			Version: os.Getenv("TOS_VERSION"),
+			Enforce: os.Getenv("TOS_ENFORCE") == "true",
+		},
+		IntrospectRateLimit: envInt("INTROSPECT_RATE_LIMIT", 600),
+		MonthlyRequestQuota: int64(envInt("QUOTA_MONTHLY_REQUESTS", 0)),
+		QuotaUpgradeURL:     os.Getenv("QUOTA_UPGRADE_URL"),
+		AsyncOperations:     envInt("ASYNC_MAX_OPERATIONS", 0),
+		FreshAuthMaxAge:     envDuration("FRESH_AUTH_MAX_AGE", 0),
+		Plugins:             app.Default.Plugins(),
+		Transactions: postgres.TxOptions{
+			Isolation:   isolation,
+			MaxAttempts: envInt("DB_TX_MAX_ATTEMPTS", 0),
+		},
+		PasswordHashing: auth.Argon2Params{
+			Memory:      uint32(envInt("ARGON2_MEMORY_KIB", 0)),
+			Iterations:  uint32(envInt("ARGON2_ITERATIONS", 0)),
+			Parallelism: uint8(envInt("ARGON2_PARALLELISM", 0)),
+		},
+	}
+
+	if cfg.Router.CORS.Credentials && slices.Contains(cfg.Router.CORS.Origins, "*") {
+		log.Fatal("CORS_CREDENTIALS=true needs CORS_ORIGINS to name each origin; \"*\" would trust every site with users' credentials")
+	}
 
-	r := router.New(jwtSecret, db)
+	if dir := os.Getenv("RECORDING_DIR"); dir != "" {
+		if len(cfg.Router.AdminUsers) == 0 {
+			log.Println("WARNING: RECORDING_DIR is set but ADMIN_USERS is empty; recording cannot be started")
+		}
+		cfg.Router.Recording = recording.New(dir, redact.New(logKey, cfg.Router.LogRedactFields, cfg.Router.LogPII))
+	}
+	if os.Getenv("CHAOS_MODE") == "true" {
+		cfg.Router.Chaos = &middleware.ChaosConfig{
+			LatencyPercent: envInt("CHAOS_LATENCY_PERCENT", 10),
+			Latency:        envDuration("CHAOS_LATENCY", 2*time.Second),
+			DropPercent:    envInt("CHAOS_DROP_PERCENT", 1),
+			ErrorPercent:   envInt("CHAOS_ERROR_PERCENT", 5),
+		}
+		log.Printf("WARNING: CHAOS_MODE=true — injecting faults (latency %d%% of %v, drops %d%%, errors %d%%). Never enable this in production.",
+			cfg.Router.Chaos.LatencyPercent, cfg.Router.Chaos.Latency, cfg.Router.Chaos.DropPercent, cfg.Router.Chaos.ErrorPercent)
+	}
+	if cfg.Router.Classifier, err = contentClassifier(); err != nil {
+		log.Fatalf("invalid content classifier settings: %v", err)
+	}
+	if webhook := secret("ALERT_WEBHOOK_URL"); webhook != "" {
+		host, _ := os.Hostname()
+		cfg.Router.Alerts, err = alert.New(alert.Config{
+			WebhookURL:  webhook,
+			Template:    os.Getenv("ALERT_TEMPLATE"),
+			MinInterval: envDuration("ALERT_MIN_INTERVAL", 0),
+			Host:        host,
+		})
+		if err != nil {
+			log.Fatalf("invalid alert settings: %v", err)
+		}
+		cfg.Router.ErrorAlertThreshold = envInt("ALERT_5XX_THRESHOLD", 20)
+	}
+
+	srv, err := server.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if srv.DB() != nil {
+		log.Println("Connected to PostgreSQL database")
+	}
+	for _, p := range app.Default.Plugins() {
+		log.Printf("Loaded plugin %s", p.Name)
+	}
+	// Listening sockets come from a previous process during an upgrade,
+	// from systemd under socket activation, or are bound here.
+	inherited, err := upgrade.Inherited()
+	if err != nil {
+		log.Fatal(err)
+	}
+	activated, err := systemd.Listeners()
+	if err != nil {
+		log.Fatal(err)
+	}
+	switch {
+	case len(inherited) > 0:
+		err = srv.ServeInherited(inherited)
+	case len(activated) > 0:
+		for _, extra := range activated[1:] {
+			log.Printf("WARNING: ignoring extra activated socket %s", extra.Addr())
+			extra.Close()
+		}
+		err = srv.Serve(activated[0])
+	default:
+		err = srv.Start()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := upgrade.Ready(); err != nil {
+		log.Printf("WARNING: %v", err)
+	}
+	if _, err := systemd.Notify(systemd.Ready); err != nil {
+		log.Printf("WARNING: %v", err)
+	}
 
-	log.Printf("Starting server on :%s", port)
-	if err := r.Run(":" + port); err != nil {
+	// Drain in-flight requests on SIGINT/SIGTERM instead of dropping them.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go systemd.RunWatchdog(ctx, systemd.WatchdogInterval(), srv.Healthy)
+	// On SIGUSR2, hand the sockets to a new copy of the binary and drain.
+	var upgraded atomic.Bool
+	go func() {
+		usr2 := make(chan os.Signal, 1)
+		signal.Notify(usr2, syscall.SIGUSR2)
+		for range usr2 {
+			if err := handOver(srv); err != nil {
+				log.Printf("upgrade failed, still serving: %v", err)
+				continue
+			}
+			upgraded.Store(true)
+			stop()
+			return
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		log.Println("Shutting down")
+		if !upgraded.Load() {
+			_, _ = systemd.Notify(systemd.Stopping)
+		}
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout+cfg.DrainDelay)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("shutdown: %v", err)
+		}
+	}()
+	if err := srv.Wait(); err != nil {
 		log.Fatalf("server error: %v", err)
 	}
 }
+
+// handOver starts the binary on disk as a new process serving srv's
+// sockets and, once it is ready, makes it systemd's main process.
+func handOver(srv *server.Server) error {
+	files, err := srv.ListenerFiles()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	log.Println("Upgrading: starting new process")
+	proc, err := upgrade.Spawn(files, upgradeTimeout)
+	if err != nil {
+		return err
+	}
+	log.Printf("Upgrading: process %d is serving; draining", proc.Pid)
+	_, _ = systemd.Notify("MAINPID=" + strconv.Itoa(proc.Pid))
+	return nil
+}
+
+// runChecks performs the -check preflight: it validates the same settings the
+// server reads at startup, connects to the database and verifies that the
+// migrations have been applied.  The report is printed to stdout and the
+// returned exit code is non-zero if any check failed.
+func runChecks(secrets config.SecretsProvider) int {
+	var report preflight.Report
+	secret := func(name string) string {
+		v, err := secrets.Secret(name)
+		if err != nil {
+			report.Fail("secret "+name, err.Error())
+		}
+		return v
+	}
+
+	report.CheckJWTSecret(secret("JWT_SECRET"), os.Getenv("DEV_MODE") == "true")
+
+	if _, err := parsePairs(secret("HMAC_KEYS")); err != nil {
+		report.Fail("hmac keys", err.Error())
+	}
+	if v := secret("FIELD_ENCRYPTION_KEYS"); v != "" {
+		if _, err := crypt.ParseKeys(v); err != nil {
+			report.Fail("field encryption keys", err.Error())
+		}
+	}
+	if _, err := parsePairs(os.Getenv("CLIENT_CERT_SUBJECTS")); err != nil {
+		report.Fail("client cert subjects", err.Error())
+	}
+	if _, err := parseSchedules(os.Getenv("JOB_SCHEDULES")); err != nil {
+		report.Fail("job schedules", err.Error())
+	}
+	if os.Getenv("QUOTA_MONTHLY_REQUESTS") != "" && os.Getenv("METERING") != "true" {
+		report.Warn("quota", "QUOTA_MONTHLY_REQUESTS is set without METERING=true; nothing is enforced")
+	}
+	if os.Getenv("TOS_ENFORCE") == "true" && os.Getenv("TOS_VERSION") == "" {
+		report.Warn("terms", "TOS_ENFORCE is set without TOS_VERSION; nothing is enforced")
+	}
+	if config, err := loadFeatureFlags(); err != nil {
+		report.Fail("feature flags", err.Error())
+	} else if _, err := flags.New(config, nil); err != nil {
+		report.Fail("feature flags", err.Error())
+	}
+	if _, err := contentClassifier(); err != nil {
+		report.Fail("content classifier", err.Error())
+	}
+	if webhook := secret("ALERT_WEBHOOK_URL"); webhook != "" {
+		if _, err := alert.New(alert.Config{WebhookURL: webhook, Template: os.Getenv("ALERT_TEMPLATE")}); err != nil {
+			report.Fail("alerts", err.Error())
+		}
+	}
+
+	certFile, keyFile := os.Getenv("TLS_CERT_FILE"), os.Getenv("TLS_KEY_FILE")
+	if certFile == "" || keyFile == "" {
+		report.Warn("tls", "TLS_CERT_FILE/TLS_KEY_FILE not set; serving plain HTTP")
+	} else if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+		report.Fail("tls", err.Error())
+	} else if _, err := clientTLSConfig(os.Getenv("TLS_CLIENT_CA_FILE"), os.Getenv("TLS_CLIENT_AUTH")); err != nil {
+		report.Fail("tls", err.Error())
+	} else {
+		report.OK("tls", "certificate and key loaded")
+	}
+
+	dsn := secret("DATABASE_URL")
+	if dsn == "" {
+		report.Warn("database", "DATABASE_URL not set; running without a database")
+	} else if db, err := postgres.Connect(dsn); err != nil {
+		report.Fail("database", err.Error())
+	} else {
+		defer db.Close()
+		report.OK("database", "connected")
+
+		pluginTables := app.Default.Tables()
+		missing, err := postgres.MissingTables(db, pluginTables...)
+		switch {
+		case err != nil:
+			report.Fail("migrations", err.Error())
+		case len(missing) > 0:
+			report.Fail("migrations", "missing tables: "+strings.Join(missing, ", "))
+		default:
+			report.OK("migrations", fmt.Sprintf("all %d tables present", len(postgres.RequiredTables)+len(pluginTables)))
+		}
+
+		missing, err = postgres.MissingIndexes(db)
+		switch {
+		case err != nil:
+			report.Warn("indexes", err.Error())
+		case len(missing) > 0:
+			report.Warn("indexes", "missing indexes: "+strings.Join(missing, ", "))
+		default:
+			report.OK("indexes", fmt.Sprintf("all %d indexes present", len(postgres.ExpectedIndexes)))
+		}
+	}
+
+	if err := report.Write(os.Stdout); err != nil || report.Failed() {
+		return 1
+	}
+	return 0
+}
+
+// clientTLSConfig builds the TLS settings for client-certificate
+// authentication.  With no CA bundle, client certificates are not requested.
+// Otherwise mode selects between "optional" (verify a certificate if one is
+// presented; callers without one fall back to JWT) and "require" (reject the
+// handshake without a valid certificate).
+func clientTLSConfig(caFile, mode string) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile == "" {
+		return cfg, nil
+	}
+
+	pool, err := auth.LoadClientCAs(caFile)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ClientCAs = pool
+
+	switch mode {
+	case "", "optional":
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	case "require":
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("TLS_CLIENT_AUTH must be \"optional\" or \"require\", got %q", mode)
+	}
+	return cfg, nil
+}
+
+// parsePairs reads a comma-separated list of key:value pairs, as used by the
+// HMAC_KEYS (keyId:secret) and CLIENT_CERT_SUBJECTS (commonName:username)
+// environment variables, into a map.  An empty string yields an empty map.
+func parsePairs(s string) (map[string]string, error) {
+	keys := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		id, secret, ok := strings.Cut(pair, ":")
+		if !ok || id == "" || secret == "" {
+			return nil, fmt.Errorf("entry %q must have the form key:value", pair)
+		}
+		keys[id] = secret
+	}
+	return keys, nil
+}
+
+// parseSchedules reads JOB_SCHEDULES: semicolon-separated name=spec pairs
+// such as "session-cleanup=*/30 * * * *;tombstone-purge=off".  Cron
+// expressions contain commas and spaces, hence the different separators
+// from parsePairs.
+func parseSchedules(s string) (map[string]string, error) {
+	out := make(map[string]string)
+	for _, pair := range strings.Split(s, ";") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, spec, ok := strings.Cut(pair, "=")
+		name, spec = strings.TrimSpace(name), strings.TrimSpace(spec)
+		if !ok || name == "" || spec == "" {
+			return nil, fmt.Errorf("entry %q must have the form job=schedule", pair)
+		}
+		if spec != "off" {
+			if _, err := scheduler.Parse(spec); err != nil {
+				return nil, err
+			}
+		}
+		out[name] = spec
+	}
+	return out, nil
+}
+
+// loadFeatureFlags reads FEATURE_FLAGS_FILE, a JSON object of flag names to
+// booleans, and then FEATURE_FLAGS, whose name=on|off pairs take
+// precedence.
+func loadFeatureFlags() (map[string]bool, error) {
+	out := make(map[string]bool)
+	if path := os.Getenv("FEATURE_FLAGS_FILE"); path != "" {
+		file, err := flags.LoadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(out, file)
+	}
+	env, err := flags.Parse(os.Getenv("FEATURE_FLAGS"))
+	if err != nil {
+		return nil, err
+	}
+	maps.Copy(out, env)
+	return out, nil
+}
+
+// envInt reads an integer environment variable, returning def when it is
+// unset.  An unparsable value is fatal so misconfiguration is caught at boot.
+func envInt(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Fatalf("%s must be an integer, got %q", name, v)
+	}
+	return n
+}
+
+// envDuration reads a time.Duration environment variable (e.g. "250ms"),
+// returning def when it is unset.  An unparsable value is fatal.
+func envDuration(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Fatalf("%s must be a duration such as 250ms, got %q", name, v)
+	}
+	return d
+}
+
+// splitList splits a comma-separated environment value into its trimmed,
+// non-empty elements.
+// contentClassifier builds the classifier that screens submitted text from
+// CONTENT_DENYLIST, CONTENT_DENYLIST_FILE and CONTENT_CLASSIFIER_URL.  It
+// returns nil when none of them is set.
+func contentClassifier() (classify.Classifier, error) {
+	var chain classify.Chain
+	terms := splitList(os.Getenv("CONTENT_DENYLIST"))
+	if path := os.Getenv("CONTENT_DENYLIST_FILE"); path != "" {
+		more, err := classify.ReadTerms(path)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, more...)
+	}
+	if len(terms) > 0 {
+		action := classify.Action(os.Getenv("CONTENT_DENYLIST_ACTION"))
+		if action == "" {
+			action = classify.Reject
+		}
+		denylist, err := classify.NewDenylist(terms, action)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, denylist)
+	}
+	if url := os.Getenv("CONTENT_CLASSIFIER_URL"); url != "" {
+		chain = append(chain, classify.NewHTTP(url,
+			envDuration("CONTENT_CLASSIFIER_TIMEOUT", 0),
+			os.Getenv("CONTENT_CLASSIFIER_FAIL_OPEN") == "true"))
+	}
+	if len(chain) == 0 {
+		return nil, nil
+	}
+	return chain, nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,303 @@
+// smoketest exercises a deployed API end to end and exits non-zero on the
+// first failure, so it can gate a deployment:
+//
+//	go run ./cmd/smoketest -url https://api.example.com
+//
+// It registers a throwaway user, logs in, creates, reads, updates and
+// deletes a pair of teams and (when the database has a tournament) a match
+// between them, checking status codes and the Location, Cache-Control and
+// Last-Modified headers along the way.  Everything it creates is deleted
+// again; the throwaway user remains, as the API cannot delete accounts.
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+func main() {
+	baseURL := flag.String("url", envOr("SMOKETEST_URL", "http://localhost:8080"), "base URL of the deployment (env SMOKETEST_URL)")
+	timeout := flag.Duration("timeout", time.Minute, "overall time limit")
+	flag.Parse()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	if err := run(ctx, *baseURL, http.DefaultClient, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "FAIL: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("PASS")
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// smoke holds the state of one run against one deployment.
+type smoke struct {
+	ctx    context.Context
+	base   string
+	client *http.Client
+	out    io.Writer
+	token  string
+	// cleanup holds paths to DELETE when the run ends, newest first.
+	cleanup []string
+}
+
+// run performs every check against baseURL, logging each step to out.
+func run(ctx context.Context, baseURL string, client *http.Client, out io.Writer) (err error) {
+	s := &smoke{ctx: ctx, base: strings.TrimRight(baseURL, "/") + "/api/v1", client: client, out: out}
+	defer func() {
+		for _, path := range s.cleanup {
+			res, cerr := s.do(http.MethodDelete, path, nil, nil)
+			if cerr == nil {
+				res.Body.Close()
+				if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusNotFound {
+					cerr = fmt.Errorf("status %d", res.StatusCode)
+				}
+			}
+			if cerr != nil && err == nil {
+				err = fmt.Errorf("cleanup: DELETE %s: %w", path, cerr)
+			}
+		}
+	}()
+
+	suffix, err := randomSuffix()
+	if err != nil {
+		return err
+	}
+	if err := s.login("smoke-"+suffix, "smoke-"+suffix+"-password"); err != nil {
+		return err
+	}
+	home, err := s.createTeam("Smoke " + suffix + " Home")
+	if err != nil {
+		return err
+	}
+	away, err := s.createTeam("Smoke " + suffix + " Away")
+	if err != nil {
+		return err
+	}
+	if err := s.renameTeam(home, "Smoke "+suffix+" Renamed"); err != nil {
+		return err
+	}
+	return s.matchLifecycle(home, away)
+}
+
+func (s *smoke) login(username, password string) error {
+	creds := models.RegisterRequest{Username: username, Password: password}
+	if _, err := s.expect(http.MethodPost, "/auth/register", creds, http.StatusCreated, nil); err != nil {
+		return fmt.Errorf("register: %w", err)
+	}
+	var login models.LoginResponse
+	if _, err := s.expect(http.MethodPost, "/auth/login", models.LoginRequest{Username: username, Password: password}, http.StatusOK, &login); err != nil {
+		return fmt.Errorf("login: %w", err)
+	}
+	if login.Token == "" {
+		return errors.New("login: no token in response")
+	}
+	s.token = login.Token
+	s.logf("registered and logged in as %s", username)
+
+	if _, err := s.expect(http.MethodGet, "/me/sessions", nil, http.StatusOK, nil); err != nil {
+		return fmt.Errorf("list sessions: %w", err)
+	}
+	return nil
+}
+
+func (s *smoke) createTeam(name string) (int, error) {
+	var team models.TeamResponse
+	h, err := s.expect(http.MethodPost, "/football/teams", models.CreateTeamRequest{Name: name}, http.StatusCreated, &team)
+	if err != nil {
+		return 0, fmt.Errorf("create team: %w", err)
+	}
+	path := "/football/teams/" + strconv.Itoa(team.ID)
+	s.cleanup = append([]string{path}, s.cleanup...)
+	if err := wantHeader(h, "Location", "/api/v1"+path); err != nil {
+		return 0, fmt.Errorf("create team: %w", err)
+	}
+	if err := wantHeader(h, "Cache-Control", "no-store"); err != nil {
+		return 0, fmt.Errorf("create team: %w", err)
+	}
+
+	h, err = s.expect(http.MethodGet, path, nil, http.StatusOK, &team)
+	if err != nil {
+		return 0, fmt.Errorf("get team: %w", err)
+	}
+	if team.Name != name {
+		return 0, fmt.Errorf("get team: name = %q, want %q", team.Name, name)
+	}
+	if err := cacheableWithLastModified(h); err != nil {
+		return 0, fmt.Errorf("get team: %w", err)
+	}
+	s.logf("created team %d", team.ID)
+	return team.ID, nil
+}
+
+func (s *smoke) renameTeam(id int, name string) error {
+	path := "/football/teams/" + strconv.Itoa(id)
+	var team models.TeamResponse
+	if _, err := s.expect(http.MethodPut, path, models.UpdateTeamRequest{Name: name}, http.StatusOK, &team); err != nil {
+		return fmt.Errorf("update team: %w", err)
+	}
+	if team.Name != name {
+		return fmt.Errorf("update team: name = %q, want %q", team.Name, name)
+	}
+	s.logf("renamed team %d", id)
+	return nil
+}
+
+// matchLifecycle creates, patches and deletes a match between home and
+// away.  It is skipped when the deployment has no tournaments, since the
+// API cannot create one.
+func (s *smoke) matchLifecycle(home, away int) error {
+	var tournaments models.TournamentsResponse
+	if _, err := s.expect(http.MethodGet, "/football/tournaments", nil, http.StatusOK, &tournaments); err != nil {
+		return fmt.Errorf("list tournaments: %w", err)
+	}
+	if len(tournaments.Data) == 0 {
+		s.logf("no tournaments; skipping the match checks")
+		return nil
+	}
+
+	req := models.CreateMatchRequest{
+		Date:         time.Now().UTC().Truncate(24 * time.Hour),
+		HomeTeamID:   home,
+		AwayTeamID:   away,
+		TournamentID: tournaments.Data[0].ID,
+		City:         "Leeds",
+		Country:      "England",
+	}
+	var match models.MatchResponse
+	h, err := s.expect(http.MethodPost, "/football/matches", req, http.StatusCreated, &match)
+	if err != nil {
+		return fmt.Errorf("create match: %w", err)
+	}
+	path := "/football/matches/" + strconv.Itoa(match.ID)
+	s.cleanup = append([]string{path}, s.cleanup...)
+	if err := wantHeader(h, "Location", "/api/v1"+path); err != nil {
+		return fmt.Errorf("create match: %w", err)
+	}
+
+	h, err = s.expect(http.MethodGet, path, nil, http.StatusOK, &match)
+	if err != nil {
+		return fmt.Errorf("get match: %w", err)
+	}
+	if err := cacheableWithLastModified(h); err != nil {
+		return fmt.Errorf("get match: %w", err)
+	}
+
+	patch := []byte(`{"homeScore": 2}`)
+	if _, err := s.send(http.MethodPatch, path, "application/merge-patch+json", patch, http.StatusOK, &match); err != nil {
+		return fmt.Errorf("patch match: %w", err)
+	}
+	if match.HomeScore != 2 {
+		return fmt.Errorf("patch match: homeScore = %d, want 2", match.HomeScore)
+	}
+
+	s.cleanup = s.cleanup[1:]
+	if _, err := s.expect(http.MethodDelete, path, nil, http.StatusNoContent, nil); err != nil {
+		return fmt.Errorf("delete match: %w", err)
+	}
+	if _, err := s.expect(http.MethodGet, path, nil, http.StatusNotFound, nil); err != nil {
+		return fmt.Errorf("get deleted match: %w", err)
+	}
+	s.logf("created, patched and deleted match %d", match.ID)
+	return nil
+}
+
+// expect sends body as JSON and fails unless the response has status want.
+// The response body is decoded into into when it is non-nil.
+func (s *smoke) expect(method, path string, body interface{}, want int, into interface{}) (http.Header, error) {
+	var raw []byte
+	if body != nil {
+		var err error
+		if raw, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+	return s.send(method, path, "application/json", raw, want, into)
+}
+
+func (s *smoke) send(method, path, contentType string, body []byte, want int, into interface{}) (http.Header, error) {
+	res, err := s.do(method, path, body, func(r *http.Request) {
+		if body != nil {
+			r.Header.Set("Content-Type", contentType)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != want {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return nil, fmt.Errorf("%s %s: status %d, want %d: %s", method, path, res.StatusCode, want, bytes.TrimSpace(msg))
+	}
+	if into != nil {
+		if err := json.NewDecoder(res.Body).Decode(into); err != nil {
+			return nil, fmt.Errorf("%s %s: decode response: %w", method, path, err)
+		}
+	}
+	return res.Header, nil
+}
+
+func (s *smoke) do(method, path string, body []byte, prepare func(*http.Request)) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(s.ctx, method, s.base+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	if prepare != nil {
+		prepare(req)
+	}
+	return s.client.Do(req)
+}
+
+func (s *smoke) logf(format string, args ...interface{}) {
+	fmt.Fprintf(s.out, "ok   "+format+"\n", args...)
+}
+
+func wantHeader(h http.Header, key, want string) error {
+	if got := h.Get(key); got != want {
+		return fmt.Errorf("%s = %q, want %q", key, got, want)
+	}
+	return nil
+}
+
+// cacheableWithLastModified checks the headers of a successful read.
+func cacheableWithLastModified(h http.Header) error {
+	if cc := h.Get("Cache-Control"); !strings.HasPrefix(cc, "public") {
+		return fmt.Errorf("Cache-Control = %q, want a public max-age", cc)
+	}
+	if _, err := http.ParseTime(h.Get("Last-Modified")); err != nil {
+		return fmt.Errorf("Last-Modified = %q: %w", h.Get("Last-Modified"), err)
+	}
+	return nil
+}
+
+func randomSuffix() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
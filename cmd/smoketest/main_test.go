@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/testsupport"
+)
+
+func TestRun_AgainstInProcessAPI(t *testing.T) {
+	api := testsupport.New(t)
+	api.Store.Football().AddTournament("Friendly")
+	srv := httptest.NewServer(api.Handler)
+	defer srv.Close()
+
+	var out bytes.Buffer
+	if err := run(context.Background(), srv.URL, srv.Client(), &out); err != nil {
+		t.Fatalf("run: %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "deleted match") {
+		t.Fatalf("match checks did not run:\n%s", out.String())
+	}
+	teams, _ := api.Store.Football().ListTeams(models.TimeFilter{})
+	if len(teams) != 0 {
+		t.Fatalf("smoke test left %d teams behind", len(teams))
+	}
+}
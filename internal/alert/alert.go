@@ -0,0 +1,198 @@
+// Package alert posts operational alerts — panics, bursts of server errors,
+// missing migrations, failed jobs — to a Slack or Discord incoming webhook,
+// so that operators hear about trouble without watching the logs.  Alerts
+// of the same kind are rate limited, and the ones suppressed are counted in
+// the next alert that gets through.
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	openmetrics "github.com/sc23bd/COMP3011_Coursework1/internal/metrics"
+)
+
+// Kinds of alert.
+const (
+	Panic     = "panic"
+	ErrorRate = "error-rate"
+	Migration = "migration"
+	JobFailed = "job-failed"
+)
+
+// Defaults used by New.
+const (
+	DefaultMinInterval = 5 * time.Minute
+	sendTimeout        = 10 * time.Second
+)
+
+// DefaultTemplate formats alerts when no template is configured.
+const DefaultTemplate = `:rotating_light: *{{.Title}}* ({{.Kind}}, {{.Host}})
+{{.Text}}{{if .Suppressed}}
+_{{.Suppressed}} similar alert(s) suppressed since the last one._{{end}}`
+
+var metrics = expvar.NewMap("alerts")
+
+// Alert is one notification, as seen by the message template.
+type Alert struct {
+	Kind  string
+	Title string
+	Text  string
+	At    time.Time
+	// Host is the instance that raised the alert.
+	Host string
+	// Suppressed counts alerts of this kind dropped by rate limiting since
+	// the last one sent.
+	Suppressed int
+}
+
+// Config configures an Alerter.
+type Config struct {
+	// WebhookURL is a Slack or Discord incoming-webhook URL.  Discord is
+	// recognised by its host; anything else receives Slack's format.
+	WebhookURL string
+	// Template is a text/template over Alert; empty uses DefaultTemplate.
+	Template string
+	// MinInterval is the least time between two alerts of the same kind;
+	// zero uses DefaultMinInterval.
+	MinInterval time.Duration
+	// Host identifies this instance in messages.
+	Host string
+}
+
+// Alerter posts alerts to a webhook in the background.  A nil *Alerter is
+// valid and discards every alert, so callers need not check whether alerting
+// is configured.
+type Alerter struct {
+	cfg     Config
+	tmpl    *template.Template
+	discord bool
+	client  *http.Client
+
+	mu         sync.Mutex
+	last       map[string]time.Time
+	suppressed map[string]int
+
+	wg sync.WaitGroup
+}
+
+// New returns an Alerter for cfg, or an error if the webhook URL or
+// template is invalid.
+func New(cfg Config) (*Alerter, error) {
+	u, err := url.Parse(cfg.WebhookURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("alert: webhook URL must be an http(s) URL")
+	}
+	if cfg.Template == "" {
+		cfg.Template = DefaultTemplate
+	}
+	tmpl, err := template.New("alert").Parse(cfg.Template)
+	if err != nil {
+		return nil, fmt.Errorf("alert: template: %w", err)
+	}
+	if cfg.MinInterval <= 0 {
+		cfg.MinInterval = DefaultMinInterval
+	}
+	host := strings.ToLower(u.Hostname())
+	return &Alerter{
+		cfg:        cfg,
+		tmpl:       tmpl,
+		discord:    host == "discord.com" || host == "discordapp.com",
+		client:     &http.Client{Timeout: sendTimeout},
+		last:       make(map[string]time.Time),
+		suppressed: make(map[string]int),
+	}, nil
+}
+
+// Send posts an alert of the given kind in the background, unless one of
+// the same kind was sent within MinInterval.
+func (a *Alerter) Send(kind, title, text string) {
+	if a == nil {
+		return
+	}
+	now := time.Now()
+	a.mu.Lock()
+	if last, ok := a.last[kind]; ok && now.Sub(last) < a.cfg.MinInterval {
+		a.suppressed[kind]++
+		a.mu.Unlock()
+		metrics.Add(kind+".suppressed", 1)
+		return
+	}
+	a.last[kind] = now
+	suppressed := a.suppressed[kind]
+	delete(a.suppressed, kind)
+	a.mu.Unlock()
+
+	al := Alert{Kind: kind, Title: title, Text: text, At: now.UTC(), Host: a.cfg.Host, Suppressed: suppressed}
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		if err := a.post(al); err != nil {
+			metrics.Add(kind+".failed", 1)
+			openmetrics.WebhookDeliveries.Inc("alert", "failed")
+			log.Printf("alert %s: %v", kind, err)
+			return
+		}
+		metrics.Add(kind+".sent", 1)
+		openmetrics.WebhookDeliveries.Inc("alert", "sent")
+	}()
+}
+
+// Wait blocks until every alert sent so far has been posted or has failed.
+func (a *Alerter) Wait() {
+	if a != nil {
+		a.wg.Wait()
+	}
+}
+
+func (a *Alerter) post(al Alert) error {
+	var msg bytes.Buffer
+	if err := a.tmpl.Execute(&msg, al); err != nil {
+		return fmt.Errorf("template: %w", err)
+	}
+	payload := map[string]string{"text": msg.String()}
+	if a.discord {
+		// Discord caps content at 2000 characters.
+		content := msg.String()
+		if len(content) > 2000 {
+			content = strings.ToValidUTF8(content[:1997], "") + "..."
+		}
+		payload = map[string]string{"content": content}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.cfg.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := a.client.Do(req)
+	if err != nil {
+		// The URL carries the webhook's secret; do not log it.
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return fmt.Errorf("post webhook: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("post webhook: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package alert_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/alert"
+)
+
+// webhook records the JSON bodies posted to it.
+type webhook struct {
+	mu     sync.Mutex
+	bodies []map[string]string
+}
+
+func (w *webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	var body map[string]string
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	w.mu.Lock()
+	w.bodies = append(w.bodies, body)
+	w.mu.Unlock()
+}
+
+func TestAlerter_SlackAndRateLimit(t *testing.T) {
+	hook := &webhook{}
+	srv := httptest.NewServer(hook)
+	defer srv.Close()
+
+	a, err := alert.New(alert.Config{WebhookURL: srv.URL, MinInterval: time.Hour, Host: "api-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Send(alert.Panic, "Panic in GET /x", "boom")
+	a.Send(alert.Panic, "Panic in GET /x", "boom again") // suppressed
+	a.Send(alert.Migration, "Migrations missing", "job_runs")
+	a.Wait()
+
+	if len(hook.bodies) != 2 {
+		t.Fatalf("expected 2 posts, got %d: %v", len(hook.bodies), hook.bodies)
+	}
+	for _, b := range hook.bodies {
+		if !strings.Contains(b["text"], "api-1") {
+			t.Errorf("expected Slack text naming the host, got %v", b)
+		}
+	}
+}
+
+func TestAlerter_SuppressedCountAndTemplate(t *testing.T) {
+	hook := &webhook{}
+	srv := httptest.NewServer(hook)
+	defer srv.Close()
+
+	a, err := alert.New(alert.Config{
+		WebhookURL:  srv.URL,
+		Template:    "{{.Kind}}: {{.Title}} (+{{.Suppressed}})",
+		MinInterval: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Send(alert.ErrorRate, "spike", "")
+	a.Send(alert.ErrorRate, "spike", "")
+	a.Send(alert.ErrorRate, "spike", "")
+	time.Sleep(60 * time.Millisecond)
+	a.Send(alert.ErrorRate, "spike", "")
+	a.Wait()
+
+	if len(hook.bodies) != 2 || hook.bodies[1]["text"] != "error-rate: spike (+2)" {
+		t.Errorf("unexpected posts %v", hook.bodies)
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	if _, err := alert.New(alert.Config{WebhookURL: "not a url"}); err == nil {
+		t.Error("expected an error for an invalid URL")
+	}
+	if _, err := alert.New(alert.Config{WebhookURL: "https://hooks.slack.com/x", Template: "{{.Nope"}); err == nil {
+		t.Error("expected an error for an invalid template")
+	}
+	var a *alert.Alerter
+	a.Send(alert.Panic, "ignored", "") // a nil Alerter discards alerts
+	a.Wait()
+}
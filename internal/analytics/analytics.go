@@ -0,0 +1,277 @@
+// Package analytics aggregates API usage per UTC day, route and user into
+// the usage_analytics table, for operators to see which endpoints are used
+// and by how many people.  It is off unless enabled, and built to keep as
+// little about users as it can:
+//
+//   - Users are stored as a keyed hash of their username that changes every
+//     day, so a day's figures cannot be joined to another day's, nor turned
+//     back into usernames without the key.
+//   - Only the route template (/football/teams/:id) is kept, never the path,
+//     query or body.
+//   - Requests carrying DNT: 1 or Sec-GPC: 1 are not counted, and users who
+//     set the analytics preference to false are left out.
+//   - Rows older than the retention period are deleted.
+//
+// Counts are gathered in memory by Record and flushed every minute, so
+// usernames are held for at most that long.  Nothing is sent to third
+// parties.
+package analytics
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/async"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/prefs"
+)
+
+// DateLayout formats days.
+const DateLayout = "2006-01-02"
+
+// Anonymous is the subject recorded for unauthenticated requests.
+const Anonymous = "anonymous"
+
+// DefaultRetention is how long rows are kept when Config.Retention is zero.
+const DefaultRetention = 90 * 24 * time.Hour
+
+// flushInterval is how often counts are written to usage_analytics.
+const flushInterval = time.Minute
+
+// Config configures Analytics.
+type Config struct {
+	// Enabled turns usage analytics on.
+	Enabled bool
+	// Key pseudonymises usernames.  It must be the same on every instance,
+	// or one user is counted once per instance.
+	Key []byte
+	// Retention is how long daily rows are kept; zero uses
+	// DefaultRetention.
+	Retention time.Duration
+}
+
+type usage struct {
+	day, user, method, route string
+}
+
+// Analytics records and reports usage.  Create one with New.
+type Analytics struct {
+	db        *sql.DB
+	key       []byte
+	retention time.Duration
+	prefs     db.PreferencesRepository
+	clock     clock.Clock
+
+	mu      sync.Mutex
+	pending map[usage]int64 // not yet flushed
+}
+
+// New returns an Analytics backed by db.
+func New(db *sql.DB, cfg Config) *Analytics {
+	retention := cfg.Retention
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+	return &Analytics{db: db, key: cfg.Key, retention: retention, clock: clock.System{}, pending: make(map[usage]int64)}
+}
+
+// SetPreferences lets users opt out through the analytics preference.
+func (a *Analytics) SetPreferences(repo db.PreferencesRepository) {
+	a.prefs = repo
+}
+
+// SetClock replaces the wall clock used to date requests.
+func (a *Analytics) SetClock(c clock.Clock) {
+	a.clock = clock.Or(c)
+}
+
+// Record counts each request that matched a route, under the caller's
+// username once authentication has run, unless the client sent a
+// Do Not Track or Global Privacy Control signal.  The background copy of
+// a Prefer: respond-async request is not counted again.
+func (a *Analytics) Record() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if async.Replayed(c.Request.Context()) {
+			// Counted when the request was accepted.
+			return
+		}
+		route := c.FullPath()
+		if route == "" || c.GetHeader("DNT") == "1" || c.GetHeader("Sec-GPC") == "1" {
+			return
+		}
+		u := usage{
+			day:    a.clock.Now().UTC().Format(DateLayout),
+			user:   c.GetString("username"),
+			method: c.Request.Method,
+			route:  route,
+		}
+		a.mu.Lock()
+		a.pending[u]++
+		a.mu.Unlock()
+	}
+}
+
+// Subject returns the pseudonym stored for username on day: a truncated
+// HMAC of both, so the same user has a different subject every day.
+func Subject(key []byte, day, username string) string {
+	if username == "" {
+		return Anonymous
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(day + "\x00" + username))
+	return "u-" + hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// optedOut reports whether username set the analytics preference to false.
+func (a *Analytics) optedOut(username string) (bool, error) {
+	if a.prefs == nil || username == "" {
+		return false, nil
+	}
+	p, err := a.prefs.GetPreferences(username)
+	if err != nil {
+		return false, err
+	}
+	on, ok := prefs.Bool(p, prefs.Analytics)
+	return ok && !on, nil
+}
+
+// Flush pseudonymises the counts gathered since the last flush and adds
+// them to usage_analytics, then deletes rows past the retention period.
+// Counts that fail to write are kept for the next attempt.
+func (a *Analytics) Flush(ctx context.Context) error {
+	a.mu.Lock()
+	pending := a.pending
+	a.pending = make(map[usage]int64)
+	a.mu.Unlock()
+
+	var errs []error
+	optOuts := make(map[string]bool)
+	for u, n := range pending {
+		out, seen := optOuts[u.user]
+		if !seen {
+			var err error
+			if out, err = a.optedOut(u.user); err != nil {
+				// Leave the user out rather than count someone who may
+				// have opted out.
+				errs = append(errs, err)
+				out = true
+			}
+			optOuts[u.user] = out
+		}
+		if out {
+			continue
+		}
+		_, err := a.db.ExecContext(ctx, `
+			INSERT INTO usage_analytics (day, subject, method, route, requests) VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (day, subject, method, route) DO UPDATE SET
+				requests = usage_analytics.requests + EXCLUDED.requests`,
+			u.day, Subject(a.key, u.day, u.user), u.method, u.route, n)
+		if err != nil {
+			errs = append(errs, err)
+			a.mu.Lock()
+			a.pending[u] += n
+			a.mu.Unlock()
+		}
+	}
+
+	cutoff := a.clock.Now().UTC().Add(-a.retention).Format(DateLayout)
+	if _, err := a.db.ExecContext(ctx, `DELETE FROM usage_analytics WHERE day < $1`, cutoff); err != nil {
+		errs = append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("analytics: flush: %w", err)
+	}
+	return nil
+}
+
+// RunFlusher flushes counts every minute until ctx is done, then once
+// more.
+func (a *Analytics) RunFlusher(ctx context.Context) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := a.Flush(context.WithoutCancel(ctx)); err != nil {
+				log.Print(err)
+			}
+			return
+		case <-ticker.C:
+			if err := a.Flush(ctx); err != nil {
+				log.Print(err)
+			}
+		}
+	}
+}
+
+// Endpoints returns, for each day from from to to inclusive, the requests
+// made to each route and the number of distinct signed-in users who made
+// them, busiest first.
+func (a *Analytics) Endpoints(ctx context.Context, from, to time.Time) ([]models.EndpointUsage, error) {
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT to_char(day, 'YYYY-MM-DD'), method, route, SUM(requests),
+		       COUNT(*) FILTER (WHERE subject <> $3)
+		FROM usage_analytics
+		WHERE day BETWEEN $1 AND $2
+		GROUP BY day, method, route
+		ORDER BY day, SUM(requests) DESC, route, method`,
+		from.UTC().Format(DateLayout), to.UTC().Format(DateLayout), Anonymous)
+	if err != nil {
+		return nil, fmt.Errorf("analytics: endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	out := []models.EndpointUsage{}
+	for rows.Next() {
+		var e models.EndpointUsage
+		if err := rows.Scan(&e.Date, &e.Method, &e.Route, &e.Requests, &e.Users); err != nil {
+			return nil, fmt.Errorf("analytics: endpoints scan: %w", err)
+		}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("analytics: endpoints rows: %w", err)
+	}
+	return out, nil
+}
+
+// Users returns each pseudonymous subject's requests on day, most active
+// first, at most limit of them.
+func (a *Analytics) Users(ctx context.Context, day time.Time, limit int) ([]models.SubjectUsage, error) {
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT subject, SUM(requests), COUNT(*)
+		FROM usage_analytics
+		WHERE day = $1
+		GROUP BY subject
+		ORDER BY SUM(requests) DESC, subject
+		LIMIT $2`, day.UTC().Format(DateLayout), limit)
+	if err != nil {
+		return nil, fmt.Errorf("analytics: users: %w", err)
+	}
+	defer rows.Close()
+
+	out := []models.SubjectUsage{}
+	for rows.Next() {
+		var s models.SubjectUsage
+		if err := rows.Scan(&s.Subject, &s.Requests, &s.Endpoints); err != nil {
+			return nil, fmt.Errorf("analytics: users scan: %w", err)
+		}
+		out = append(out, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("analytics: users rows: %w", err)
+	}
+	return out, nil
+}
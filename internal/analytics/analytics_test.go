@@ -0,0 +1,98 @@
+package analytics_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/analytics"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/postgres"
+	"github.com/sc23bd/COMP3011_Coursework1/pkg/repository/fake"
+)
+
+func TestSubject(t *testing.T) {
+	key := []byte("k")
+	a := analytics.Subject(key, "2024-03-15", "alice")
+	if a != analytics.Subject(key, "2024-03-15", "alice") {
+		t.Fatal("expected a stable subject within a day")
+	}
+	for _, other := range []string{
+		analytics.Subject(key, "2024-03-16", "alice"),
+		analytics.Subject(key, "2024-03-15", "bob"),
+		analytics.Subject([]byte("other"), "2024-03-15", "alice"),
+	} {
+		if other == a {
+			t.Fatalf("expected %q to differ from %q", other, a)
+		}
+	}
+	if got := analytics.Subject(key, "2024-03-15", ""); got != analytics.Anonymous {
+		t.Fatalf("expected %q for no user, got %q", analytics.Anonymous, got)
+	}
+}
+
+func TestAnalytics_Postgres(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	conn, err := postgres.Connect(dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer conn.Close()
+
+	// A day far in the past, so that real traffic does not interfere.
+	day := time.Date(2001, 1, 2, 12, 0, 0, 0, time.UTC)
+	_, _ = conn.Exec(`DELETE FROM usage_analytics WHERE day = '2001-01-02'`)
+
+	a := analytics.New(conn, analytics.Config{Enabled: true, Key: []byte("test")})
+	a.SetClock(clock.NewFake(day))
+	a.SetPreferences(&fake.Preferences{GetPreferencesFunc: func(username string) (map[string]any, error) {
+		return map[string]any{"analytics": username != "dave"}, nil
+	}})
+
+	e := gin.New()
+	e.Use(a.Record())
+	e.Use(func(c *gin.Context) { c.Set("username", c.GetHeader("X-User")) })
+	e.GET("/teams/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	send := func(path, user, header string) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-User", user)
+		if header != "" {
+			req.Header.Set(header, "1")
+		}
+		e.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	send("/teams/1", "alice", "")
+	send("/teams/2", "alice", "")
+	send("/teams/1", "bob", "")
+	send("/teams/1", "", "")
+	send("/teams/1", "carol", "DNT")
+	send("/teams/1", "carol", "Sec-GPC")
+	send("/teams/1", "dave", "")
+	send("/missing", "alice", "")
+
+	ctx := context.Background()
+	if err := a.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	endpoints, err := a.Endpoints(ctx, day, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(endpoints) != 1 || endpoints[0].Route != "/teams/:id" || endpoints[0].Requests != 4 || endpoints[0].Users != 2 {
+		t.Fatalf("unexpected endpoint usage %+v", endpoints)
+	}
+	users, err := a.Users(ctx, day, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 3 || users[0].Subject != analytics.Subject([]byte("test"), "2001-01-02", "alice") || users[0].Requests != 2 {
+		t.Fatalf("unexpected user usage %+v", users)
+	}
+}
@@ -0,0 +1,148 @@
+// Package app is the extension point for modules compiled into the server.
+// A module — a coursework variant, or a downstream feature — describes the
+// routes, middleware and schema it needs as a Plugin and registers it from an
+// init function:
+//
+//	func init() {
+//		app.Register(app.Plugin{
+//			Name:   "fixtures",
+//			Routes: func(rc app.RouteContext) { … },
+//		})
+//	}
+//
+// Importing the module's package (a blank import in cmd/server is enough)
+// installs it; router.New never needs to change.
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/codec"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
+)
+
+// Plugin describes one extension module.  Every field except Name is
+// optional.
+type Plugin struct {
+	// Name identifies the plugin in logs and must be unique.
+	Name string
+
+	// Middleware runs on every request, after the built-in global middleware
+	// and in registration order.
+	Middleware []gin.HandlerFunc
+
+	// MediaTypes lists request body types other than application/json that
+	// the plugin's routes read.  Others are refused with 415 before
+	// reaching any handler.
+	MediaTypes []string
+
+	// Codecs add response and request body encodings alongside JSON and
+	// the built-in codec.Default ones, negotiated through Accept and
+	// Content-Type for every route.
+	Codecs []codec.Codec
+
+	// Routes adds the plugin's endpoints.  It is only called when the server
+	// has a database.
+	Routes func(rc RouteContext)
+
+	// Migrations are the plugin's schema changes.  Like the files in
+	// migrations/ they are applied outside the server; `server -plugin-sql`
+	// prints them for psql.
+	Migrations []Migration
+}
+
+// Migration is one idempotent SQL script contributed by a plugin.
+type Migration struct {
+	// Name orders the plugin's migrations, e.g. "001_fixtures.sql".
+	Name string
+	SQL  string
+	// Tables lists the tables the script creates.  The -check preflight
+	// reports any that are missing.
+	Tables []string
+}
+
+// RouteContext gives a plugin's Routes function what it needs to serve
+// requests alongside the built-in routes.
+type RouteContext struct {
+	// API is the /api/v1 route group.
+	API *gin.RouterGroup
+	// DB is the server's database connection pool.
+	DB *sql.DB
+	// RequireAuth authenticates the caller exactly as the built-in protected
+	// routes do.
+	RequireAuth gin.HandlerFunc
+	// RequireRead guards read-only routes: RequireAuth in a private
+	// deployment; otherwise it only identifies callers presenting a valid
+	// Bearer token.
+	RequireRead gin.HandlerFunc
+	// Events is the server's event bus, or nil.
+	Events *events.Bus
+}
+
+// Registry holds registered plugins.  The zero value is empty and ready to
+// use.
+type Registry struct {
+	mu      sync.Mutex
+	plugins []Plugin
+}
+
+// Register adds p.  It fails if p has no name or its name is taken.
+func (r *Registry) Register(p Plugin) error {
+	if p.Name == "" {
+		return fmt.Errorf("app: plugin has no name")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.plugins {
+		if existing.Name == p.Name {
+			return fmt.Errorf("app: plugin %q registered twice", p.Name)
+		}
+	}
+	r.plugins = append(r.plugins, p)
+	return nil
+}
+
+// Plugins returns the registered plugins in registration order.
+func (r *Registry) Plugins() []Plugin {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Plugin(nil), r.plugins...)
+}
+
+// Tables returns every table the registered plugins' migrations create.
+func (r *Registry) Tables() []string {
+	var tables []string
+	for _, p := range r.Plugins() {
+		for _, m := range p.Migrations {
+			tables = append(tables, m.Tables...)
+		}
+	}
+	return tables
+}
+
+// Migrations returns every plugin migration, grouped by plugin in
+// registration order and sorted by name within each plugin.
+func (r *Registry) Migrations() []Migration {
+	var out []Migration
+	for _, p := range r.Plugins() {
+		ms := append([]Migration(nil), p.Migrations...)
+		sort.Slice(ms, func(i, j int) bool { return ms[i].Name < ms[j].Name })
+		out = append(out, ms...)
+	}
+	return out
+}
+
+// Default is the registry the server binary installs plugins from.
+var Default Registry
+
+// Register adds p to Default and panics if that fails, so a misconfigured
+// build fails at startup rather than silently dropping a plugin.
+func Register(p Plugin) {
+	if err := Default.Register(p); err != nil {
+		panic(err)
+	}
+}
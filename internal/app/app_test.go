@@ -0,0 +1,46 @@
+package app_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/app"
+)
+
+func TestRegistry_RejectsDuplicatesAndUnnamed(t *testing.T) {
+	var r app.Registry
+	if err := r.Register(app.Plugin{Name: "fixtures"}); err != nil {
+		t.Fatalf("first registration failed: %v", err)
+	}
+	if err := r.Register(app.Plugin{Name: "fixtures"}); err == nil {
+		t.Fatal("expected duplicate name to be rejected")
+	}
+	if err := r.Register(app.Plugin{}); err == nil {
+		t.Fatal("expected unnamed plugin to be rejected")
+	}
+	if n := len(r.Plugins()); n != 1 {
+		t.Fatalf("expected 1 plugin, got %d", n)
+	}
+}
+
+func TestRegistry_MigrationsAndTables(t *testing.T) {
+	var r app.Registry
+	_ = r.Register(app.Plugin{Name: "b", Migrations: []app.Migration{
+		{Name: "002_b.sql", Tables: []string{"b2"}},
+		{Name: "001_b.sql", Tables: []string{"b1"}},
+	}})
+	_ = r.Register(app.Plugin{Name: "a", Migrations: []app.Migration{
+		{Name: "001_a.sql", Tables: []string{"a1"}},
+	}})
+
+	var names []string
+	for _, m := range r.Migrations() {
+		names = append(names, m.Name)
+	}
+	if want := []string{"001_b.sql", "002_b.sql", "001_a.sql"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("migrations = %v, want %v", names, want)
+	}
+	if got, want := r.Tables(), []string{"b2", "b1", "a1"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("tables = %v, want %v", got, want)
+	}
+}
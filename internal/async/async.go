@@ -0,0 +1,205 @@
+// Package async runs API requests in the background, for clients that send
+// Prefer: respond-async (RFC 7240).  Such a request is answered at once with
+// 202 Accepted and the URL of an Operation, while a copy of it is served by
+// the API in a goroutine and its response kept for the client to collect.
+//
+// Operations live in the memory of the instance that accepted them, so
+// behind a load balancer the client must reach the same instance to see
+// the result, and they are lost on restart.
+package async
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// ErrFull is returned by Start when limit operations are already running.
+var ErrFull = errors.New("async: too many operations running")
+
+// Retention is how long a finished operation's result is kept.
+const Retention = 15 * time.Minute
+
+type operation struct {
+	models.Operation
+	done chan struct{}
+}
+
+// Queue runs requests through handler in the background and keeps their
+// results for Retention.
+type Queue struct {
+	handler http.Handler
+	limit   int
+	clock   clock.Clock
+
+	mu      sync.Mutex
+	ops     map[string]*operation
+	running int
+}
+
+// New returns a Queue serving requests with handler, normally the API's own
+// engine, running at most limit at a time.
+func New(handler http.Handler, limit int) *Queue {
+	return &Queue{handler: handler, limit: limit, clock: clock.System{}, ops: map[string]*operation{}}
+}
+
+// SetClock makes the queue stamp and expire operations with c.
+func (q *Queue) SetClock(c clock.Clock) { q.clock = clock.Or(c) }
+
+type identityKey struct{}
+
+// WithIdentity marks ctx as that of a background copy of a request whose
+// caller was authenticated, and counted, when it was accepted: identity
+// holds the values authentication attached to the original request.  The
+// copy is served through the whole API again, so middleware that
+// authenticates, meters or counts requests checks Identity to let it
+// through without doing so a second time — a signed request's signature,
+// in particular, cannot be verified twice.
+func WithIdentity(ctx context.Context, identity map[string]any) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// Identity returns the identity of a background copy of a request, or
+// false if the request is not one.  Clients cannot set it: only
+// WithIdentity can.
+func Identity(ctx context.Context) (map[string]any, bool) {
+	identity, ok := ctx.Value(identityKey{}).(map[string]any)
+	return identity, ok
+}
+
+// Replayed reports whether ctx is that of a background copy of a request.
+func Replayed(ctx context.Context) bool {
+	_, ok := Identity(ctx)
+	return ok
+}
+
+// Start serves r in the background on behalf of owner and returns the
+// operation tracking it.  r's body must be safe to read after the caller
+// returns, and its context is detached from the caller's cancellation.
+func (q *Queue) Start(owner string, r *http.Request) (models.Operation, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.prune()
+	if q.running >= q.limit {
+		return models.Operation{}, ErrFull
+	}
+	id := make([]byte, 16)
+	rand.Read(id)
+	op := &operation{
+		Operation: models.Operation{
+			ID:        hex.EncodeToString(id),
+			Status:    models.OperationRunning,
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			Owner:     owner,
+			CreatedAt: q.clock.Now().UTC(),
+		},
+		done: make(chan struct{}),
+	}
+	q.ops[op.ID] = op
+	q.running++
+
+	r = r.WithContext(context.WithoutCancel(r.Context()))
+	go q.run(op, r)
+	return op.Operation, nil
+}
+
+func (q *Queue) run(op *operation, r *http.Request) {
+	w := &recorder{header: http.Header{}}
+	q.handler.ServeHTTP(w, r)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := q.clock.Now().UTC()
+	op.Status, op.CompletedAt, op.Response = models.OperationDone, &now, w.result()
+	q.running--
+	close(op.done)
+}
+
+// Get returns owner's operation id, or false if there is none: it does not
+// exist, has expired or belongs to someone else.
+func (q *Queue) Get(owner, id string) (models.Operation, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.prune()
+	op, ok := q.ops[id]
+	if !ok || op.Owner != owner {
+		return models.Operation{}, false
+	}
+	return op.Operation, true
+}
+
+// Wait waits up to d for operation id to finish, and returns it and whether
+// it has.
+func (q *Queue) Wait(id string, d time.Duration) (models.Operation, bool) {
+	q.mu.Lock()
+	op, ok := q.ops[id]
+	q.mu.Unlock()
+	if !ok {
+		return models.Operation{}, false
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-op.done:
+	case <-t.C:
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return op.Operation, op.Status == models.OperationDone
+}
+
+// prune forgets operations finished more than Retention ago.
+func (q *Queue) prune() {
+	cutoff := q.clock.Now().Add(-Retention)
+	for id, op := range q.ops {
+		if op.CompletedAt != nil && op.CompletedAt.Before(cutoff) {
+			delete(q.ops, id)
+		}
+	}
+}
+
+// recorder is the http.ResponseWriter a background request is served to.
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *recorder) Header() http.Header { return w.header }
+
+func (w *recorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *recorder) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(b)
+}
+
+// Flush implements http.Flusher, which handlers that stream assume.
+func (w *recorder) Flush() {}
+
+func (w *recorder) result() *models.OperationResult {
+	res := &models.OperationResult{Status: w.status, Location: w.header.Get("Location")}
+	if res.Status == 0 {
+		res.Status = http.StatusOK
+	}
+	mediaType, _, _ := mime.ParseMediaType(w.header.Get("Content-Type"))
+	if mediaType == "application/json" && json.Valid(w.body.Bytes()) {
+		res.Body = json.RawMessage(w.body.Bytes())
+	}
+	return res
+}
@@ -0,0 +1,95 @@
+package async_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/async"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+func TestQueue_RunsRequestAndKeepsResult(t *testing.T) {
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/teams/7")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":7}`))
+	})
+	q := async.New(handler, 1)
+
+	op, err := q.Start("alice", httptest.NewRequest(http.MethodPost, "/teams", strings.NewReader(`{}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if op.Status != models.OperationRunning {
+		t.Errorf("expected running, got %q", op.Status)
+	}
+	if _, err := q.Start("alice", httptest.NewRequest(http.MethodPost, "/teams", nil)); err != async.ErrFull {
+		t.Errorf("expected ErrFull over the limit, got %v", err)
+	}
+	if _, ok := q.Get("bob", op.ID); ok {
+		t.Error("expected another user not to see the operation")
+	}
+
+	close(release)
+	done, ok := q.Wait(op.ID, time.Second)
+	if !ok {
+		t.Fatal("expected the operation to finish")
+	}
+	res := done.Response
+	if res == nil || res.Status != http.StatusCreated || res.Location != "/teams/7" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	var body map[string]int
+	if err := json.Unmarshal(res.Body, &body); err != nil || body["id"] != 7 {
+		t.Errorf("unexpected body %s", res.Body)
+	}
+	if got, ok := q.Get("alice", op.ID); !ok || got.Status != models.OperationDone {
+		t.Errorf("expected alice to see the finished operation, got %+v", got)
+	}
+}
+
+func TestQueue_ForgetsResultsAfterRetention(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	q := async.New(http.NotFoundHandler(), 1)
+	q.SetClock(clk)
+
+	op, err := q.Start("alice", httptest.NewRequest(http.MethodDelete, "/teams/1", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := q.Wait(op.ID, time.Second); !ok {
+		t.Fatal("expected the operation to finish")
+	}
+	clk.Advance(async.Retention + time.Second)
+	if _, ok := q.Get("alice", op.ID); ok {
+		t.Error("expected the operation to have expired")
+	}
+}
+
+func TestParsePreferences(t *testing.T) {
+	h := http.Header{}
+	h.Add("Prefer", `Respond-Async, wait=10`)
+	h.Add("Prefer", `return=minimal; foo="bar", wait=5`)
+	prefs := async.ParsePreferences(h)
+
+	if !prefs.Has(async.RespondAsync) {
+		t.Error("expected respond-async, case-insensitively")
+	}
+	if prefs[async.Return] != async.ReturnMinimal {
+		t.Errorf("expected return=minimal, got %q", prefs[async.Return])
+	}
+	if prefs[async.Wait] != "10" {
+		t.Errorf("expected the first wait to win, got %q", prefs[async.Wait])
+	}
+	if got := prefs.Without(async.RespondAsync); got != "return=minimal, wait=10" {
+		t.Errorf("unexpected remainder %q", got)
+	}
+}
@@ -0,0 +1,68 @@
+package async
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Preference names understood by the API (RFC 7240).
+const (
+	RespondAsync = "respond-async"
+	Return       = "return"
+	Wait         = "wait"
+)
+
+// Values of the return preference.
+const (
+	ReturnMinimal        = "minimal"
+	ReturnRepresentation = "representation"
+)
+
+// Preferences are the preferences of a request's Prefer headers, by
+// lower-cased name.  A preference given without a value maps to "";
+// parameters after a ";" are ignored, as none of the API's take any.
+type Preferences map[string]string
+
+// ParsePreferences reads the Prefer headers of h.  When a preference is
+// repeated the first occurrence wins, as RFC 7240 requires.
+func ParsePreferences(h http.Header) Preferences {
+	prefs := Preferences{}
+	for _, line := range h.Values("Prefer") {
+		for _, item := range strings.Split(line, ",") {
+			item, _, _ = strings.Cut(item, ";")
+			name, value, _ := strings.Cut(item, "=")
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if _, seen := prefs[name]; !seen {
+				prefs[name] = strings.Trim(strings.TrimSpace(value), `"`)
+			}
+		}
+	}
+	return prefs
+}
+
+// Has reports whether the preference name was given.
+func (p Preferences) Has(name string) bool {
+	_, ok := p[name]
+	return ok
+}
+
+// Without returns the Prefer header value for p minus the preference
+// name, or "" if nothing is left.
+func (p Preferences) Without(name string) string {
+	var items []string
+	for k, v := range p {
+		if k == name {
+			continue
+		}
+		if v != "" {
+			k += "=" + v
+		}
+		items = append(items, k)
+	}
+	slices.Sort(items)
+	return strings.Join(items, ", ")
+}
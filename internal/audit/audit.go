@@ -0,0 +1,142 @@
+// Package audit keeps a durable record of who changed what through the
+// API.  Log subscribes to the events bus and writes every committed change,
+// and every data export, to the audit_log table before the response is
+// sent; Iterate reads it back in id order in fixed-size batches, so that
+// exports of millions of rows neither hold a long transaction open nor
+// buffer the result in memory.
+//
+// Actors and impersonators are pseudonymised with the same redactor as
+// request logs, so usernames are stored in plain text only when PII logging
+// is enabled.  Filters name users in plain text and are pseudonymised the
+// same way, so one user's changes can still be found.
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/redact"
+)
+
+// batchSize is how many rows Iterate fetches per query.
+const batchSize = 1000
+
+// Filter selects audit entries.  Zero fields match everything.
+type Filter struct {
+	// From and To bound At: From inclusive, To exclusive.
+	From, To time.Time
+	Actor    string
+	Action   string
+	// Impersonator selects the changes an administrator made while
+	// impersonating another user.
+	Impersonator string
+}
+
+// Log is the PostgreSQL-backed audit log.
+type Log struct {
+	db     *sql.DB
+	redact *redact.Redactor
+}
+
+// New returns a Log backed by db that records users as r's "username"
+// field.
+func New(db *sql.DB, r *redact.Redactor) *Log { return &Log{db: db, redact: r} }
+
+// user returns how name is stored in the actor and impersonator columns.
+func (l *Log) user(name string) string { return l.redact.Field("username", name) }
+
+// Subscribe records every event published on bus from now on.
+func (l *Log) Subscribe(bus *events.Bus) {
+	bus.Subscribe(events.BeforeResponse, l)
+}
+
+// HandleEvent implements events.Subscriber.  The fields an update changed
+// are recorded with it.
+func (l *Log) HandleEvent(ctx context.Context, e events.Event) error {
+	var changes []byte
+	if u, ok := e.Data.(events.Update); ok && len(u.Changes) > 0 {
+		var err error
+		if changes, err = json.Marshal(u.Changes); err != nil {
+			return fmt.Errorf("audit: encode changes to %s %s: %w", e.Type, e.ID, err)
+		}
+	}
+	_, err := l.db.ExecContext(ctx,
+		`INSERT INTO audit_log (at, actor, action, resource_id, impersonator, changes) VALUES ($1, $2, $3, $4, $5, $6)`,
+		e.At, l.user(e.Actor), string(e.Type), e.ID, l.user(e.Impersonator), changes)
+	if err != nil {
+		return fmt.Errorf("audit: record %s %s: %w", e.Type, e.ID, err)
+	}
+	return nil
+}
+
+// Iterate calls fn for each entry matching f, oldest first, stopping at the
+// first error.
+func (l *Log) Iterate(ctx context.Context, f Filter, fn func(models.AuditEntry) error) error {
+	where := []string{"id > $1"}
+	args := []any{int64(0)}
+	add := func(cond string, v any) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if !f.From.IsZero() {
+		add("at >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		add("at < $%d", f.To)
+	}
+	if f.Actor != "" {
+		add("actor = $%d", l.user(f.Actor))
+	}
+	if f.Action != "" {
+		add("action = $%d", f.Action)
+	}
+	if f.Impersonator != "" {
+		add("impersonator = $%d", l.user(f.Impersonator))
+	}
+	query := `SELECT id, at, actor, action, resource_id, impersonator, changes FROM audit_log WHERE ` +
+		strings.Join(where, " AND ") + fmt.Sprintf(` ORDER BY id LIMIT %d`, batchSize)
+
+	for {
+		n, last, err := l.batch(ctx, query, args, fn)
+		if err != nil {
+			return err
+		}
+		if n < batchSize {
+			return nil
+		}
+		args[0] = last
+	}
+}
+
+// batch runs one page of query and returns how many rows it passed to fn
+// and the last id.
+func (l *Log) batch(ctx context.Context, query string, args []any, fn func(models.AuditEntry) error) (int, int64, error) {
+	rows, err := l.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, 0, fmt.Errorf("audit: query: %w", err)
+	}
+	defer rows.Close()
+	var n int
+	var last int64
+	for rows.Next() {
+		var e models.AuditEntry
+		var changes []byte
+		if err := rows.Scan(&e.ID, &e.At, &e.Actor, &e.Action, &e.ResourceID, &e.Impersonator, &changes); err != nil {
+			return 0, 0, fmt.Errorf("audit: scan: %w", err)
+		}
+		if changes != nil {
+			e.Changes = json.RawMessage(changes)
+		}
+		if err := fn(e); err != nil {
+			return 0, 0, err
+		}
+		n, last = n+1, e.ID
+	}
+	return n, last, rows.Err()
+}
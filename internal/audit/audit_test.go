@@ -0,0 +1,89 @@
+package audit_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/audit"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/postgres"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/redact"
+)
+
+func TestLog_Postgres(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	conn, err := postgres.Connect(dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer conn.Close()
+
+	log := audit.New(conn, redact.New([]byte("key"), nil, false))
+	bus := events.NewBus(nil)
+	log.Subscribe(bus)
+
+	// A unique actor keeps this run's entries apart from others.
+	actor := "audit" + strconv.FormatInt(time.Now().UnixNano()%1e9, 10)
+	ctx := context.Background()
+	start := time.Now().UTC().Add(-time.Second)
+	const n = 2500 // more than one batch
+	rename := events.Update{Changes: []models.FieldChange{{Field: "name", Old: "Brazil", New: "Brasil"}}}
+	for i := range n {
+		e := events.Event{Type: events.TeamUpdated, ID: strconv.Itoa(i), Actor: actor, Data: rename}
+		if i%2 == 0 {
+			e = events.Event{Type: events.MatchDeleted, ID: strconv.Itoa(i), Actor: actor}
+		}
+		bus.Publish(ctx, e)
+	}
+
+	var got []models.AuditEntry
+	err = log.Iterate(ctx, audit.Filter{From: start, Actor: actor}, func(e models.AuditEntry) error {
+		got = append(got, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != n {
+		t.Fatalf("got %d entries, want %d", len(got), n)
+	}
+	if got[0].Actor != redact.Pseudonym([]byte("key"), actor) {
+		t.Errorf("expected the actor pseudonymised, got %q", got[0].Actor)
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i].ID <= got[i-1].ID {
+			t.Fatalf("entries out of order at %d", i)
+		}
+	}
+
+	deleted := 0
+	_ = log.Iterate(ctx, audit.Filter{Actor: actor, Action: string(events.MatchDeleted)}, func(models.AuditEntry) error {
+		deleted++
+		return nil
+	})
+	if deleted != n/2 {
+		t.Errorf("action filter matched %d, want %d", deleted, n/2)
+	}
+
+	for _, e := range got[:2] {
+		var changes []models.FieldChange
+		switch e.Action {
+		case string(events.MatchDeleted):
+			if e.Changes != nil {
+				t.Errorf("deletion recorded changes %s", e.Changes)
+			}
+		default:
+			if err := json.Unmarshal(e.Changes, &changes); err != nil || len(changes) != 1 || changes[0].New != "Brasil" {
+				t.Errorf("update recorded changes %s", e.Changes)
+			}
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package auth_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+)
+
+// verifiedState returns a connection state whose verified chain has a leaf
+// certificate with the given common name.
+func verifiedState(cn string) *tls.ConnectionState {
+	leaf := &x509.Certificate{Subject: pkix.Name{CommonName: cn}}
+	return &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{leaf}}}
+}
+
+func TestClientCertMapper_MappedSubject(t *testing.T) {
+	m := auth.NewClientCertMapper(map[string]string{"importer.internal": "svc-importer"})
+	identity, ok := m.Identify(verifiedState("importer.internal"))
+	if !ok || identity != "svc-importer" {
+		t.Fatalf("expected svc-importer, got %q (ok=%v)", identity, ok)
+	}
+}
+
+func TestClientCertMapper_UnmappedSubject(t *testing.T) {
+	m := auth.NewClientCertMapper(map[string]string{"importer.internal": "svc-importer"})
+	if _, ok := m.Identify(verifiedState("someone-else")); ok {
+		t.Fatal("expected unmapped subject to be rejected")
+	}
+}
+
+func TestClientCertMapper_Unverified(t *testing.T) {
+	m := auth.NewClientCertMapper(map[string]string{"importer.internal": "svc-importer"})
+	// A presented but unverified certificate must not authenticate.
+	state := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "importer.internal"}}}}
+	if _, ok := m.Identify(state); ok {
+		t.Fatal("expected unverified certificate to be rejected")
+	}
+	if _, ok := m.Identify(nil); ok {
+		t.Fatal("expected plain-HTTP request to be rejected")
+	}
+}
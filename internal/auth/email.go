@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// EmailVerificationTTL is how long an email verification token may be
+// used.
+const EmailVerificationTTL = 24 * time.Hour
+
+// emailType is the JWT "typ" header of email verification tokens.
+const emailType = "email+jwt"
+
+// EmailVerification is what an email verification token stands for: that
+// Username asked for mail to be sent to Email.  Tokens are emailed to that
+// address, so presenting one proves the user receives mail there.
+type EmailVerification struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// GenerateEmailVerification signs a token, valid for EmailVerificationTTL,
+// confirming that username receives mail at email.
+func (s *JWTService) GenerateEmailVerification(username, email string) (string, error) {
+	now := s.clock.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, EmailVerification{
+		Username: username,
+		Email:    email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(EmailVerificationTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    s.issuer,
+		},
+	})
+	token.Header["typ"] = emailType
+	return token.SignedString(s.secretKey)
+}
+
+// ValidateEmailVerification verifies a token from
+// GenerateEmailVerification, returning ErrExpiredToken once it has expired.
+func (s *JWTService) ValidateEmailVerification(tokenString string) (*EmailVerification, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &EmailVerification{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || token.Header["typ"] != emailType {
+			return nil, ErrInvalidToken
+		}
+		return s.secretKey, nil
+	}, jwt.WithTimeFunc(s.clock.Now))
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, ErrExpiredToken
+	}
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	claims, ok := token.Claims.(*EmailVerification)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
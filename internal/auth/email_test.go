@@ -0,0 +1,38 @@
+package auth_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+)
+
+func TestEmailVerification(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+	jwt := auth.NewJWTService("test-secret", "COMP3011_API")
+	jwt.SetClock(clk)
+
+	token, err := jwt.GenerateEmailVerification("alice", "alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := jwt.ValidateEmailVerification(token)
+	if err != nil || got.Username != "alice" || got.Email != "alice@example.com" {
+		t.Fatalf("got %+v, %v", got, err)
+	}
+	// A verification token is not an access token, nor a token one.
+	if _, err := jwt.ValidateToken(token); err == nil {
+		t.Error("verification token accepted as an access token")
+	}
+	access, _ := jwt.GenerateToken("alice")
+	if _, err := jwt.ValidateEmailVerification(access); err == nil {
+		t.Error("access token accepted as a verification token")
+	}
+
+	clk.Advance(auth.EmailVerificationTTL + time.Second)
+	if _, err := jwt.ValidateEmailVerification(token); !errors.Is(err, auth.ErrExpiredToken) {
+		t.Errorf("expired token: got %v", err)
+	}
+}
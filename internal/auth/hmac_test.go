@@ -0,0 +1,114 @@
+package auth_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/replay"
+)
+
+// signedRequest builds a request signed with secret under keyID at signedAt
+// and returns it with the matching Authorization credentials.
+func signedRequest(method, target, body, keyID, secret string, signedAt time.Time) (*http.Request, string) {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	date := signedAt.UTC().Format(time.RFC3339)
+	r.Header.Set(auth.HMACDateHeader, date)
+	sig := auth.Sign(secret, auth.StringToSign(method, r.URL.RequestURI(), date, []byte(body)))
+	return r, "KeyId=" + keyID + ",Signature=" + sig
+}
+
+func newVerifier() *auth.HMACVerifier {
+	return auth.NewHMACVerifier(map[string]string{"hook": "s3cret"}, 5*time.Minute)
+}
+
+func TestHMACVerify_Valid(t *testing.T) {
+	req, creds := signedRequest("POST", "/api/v1/football/teams?x=1", `{"name":"Wales"}`, "hook", "s3cret", time.Now())
+	keyID, err := newVerifier().Verify(req, creds, []byte(`{"name":"Wales"}`))
+	if err != nil {
+		t.Fatalf("expected valid signature, got %v", err)
+	}
+	if keyID != "hook" {
+		t.Fatalf("expected key id 'hook', got %q", keyID)
+	}
+}
+
+func TestHMACVerify_TamperedBody(t *testing.T) {
+	req, creds := signedRequest("POST", "/api/v1/football/teams", `{"name":"Wales"}`, "hook", "s3cret", time.Now())
+	_, err := newVerifier().Verify(req, creds, []byte(`{"name":"Spain"}`))
+	if !errors.Is(err, auth.ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature, got %v", err)
+	}
+}
+
+func TestHMACVerify_WrongSecret(t *testing.T) {
+	req, creds := signedRequest("DELETE", "/api/v1/football/teams/1", "", "hook", "guess", time.Now())
+	_, err := newVerifier().Verify(req, creds, nil)
+	if !errors.Is(err, auth.ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature, got %v", err)
+	}
+}
+
+func TestHMACVerify_UnknownKey(t *testing.T) {
+	req, creds := signedRequest("DELETE", "/api/v1/football/teams/1", "", "other", "s3cret", time.Now())
+	_, err := newVerifier().Verify(req, creds, nil)
+	if !errors.Is(err, auth.ErrUnknownKey) {
+		t.Fatalf("expected ErrUnknownKey, got %v", err)
+	}
+}
+
+func TestHMACVerify_StaleDate(t *testing.T) {
+	req, creds := signedRequest("DELETE", "/api/v1/football/teams/1", "", "hook", "s3cret", time.Now().Add(-time.Hour))
+	_, err := newVerifier().Verify(req, creds, nil)
+	if !errors.Is(err, auth.ErrStaleSignature) {
+		t.Fatalf("expected ErrStaleSignature, got %v", err)
+	}
+}
+
+func TestHMACVerify_InjectedClock(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	v := newVerifier()
+	v.SetClock(clock.NewFake(now))
+
+	req, creds := signedRequest("DELETE", "/api/v1/football/teams/1", "", "hook", "s3cret", now)
+	if _, err := v.Verify(req, creds, nil); err != nil {
+		t.Fatalf("request dated at the verifier's clock: %v", err)
+	}
+	req, creds = signedRequest("DELETE", "/api/v1/football/teams/1", "", "hook", "s3cret", time.Now())
+	if _, err := v.Verify(req, creds, nil); !errors.Is(err, auth.ErrStaleSignature) {
+		t.Fatalf("expected ErrStaleSignature, got %v", err)
+	}
+}
+
+func TestParseHMACAuthorization_Malformed(t *testing.T) {
+	for _, creds := range []string{"", "KeyId=hook", "Signature=abc", "garbage"} {
+		if _, _, err := auth.ParseHMACAuthorization(creds); err == nil {
+			t.Errorf("expected error for %q", creds)
+		}
+	}
+}
+
+func TestHMACVerify_Replayed(t *testing.T) {
+	v := newVerifier()
+	v.SetLedger(replay.NewLocal())
+	signedAt := time.Now()
+
+	req, creds := signedRequest("DELETE", "/api/v1/football/teams/1", "", "hook", "s3cret", signedAt)
+	if _, err := v.Verify(req, creds, nil); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	req, creds = signedRequest("DELETE", "/api/v1/football/teams/1", "", "hook", "s3cret", signedAt)
+	if _, err := v.Verify(req, creds, nil); !errors.Is(err, auth.ErrReplayedRequest) {
+		t.Fatalf("expected ErrReplayedRequest, got %v", err)
+	}
+	// Signed again a second later, the same request is a new one.
+	req, creds = signedRequest("DELETE", "/api/v1/football/teams/1", "", "hook", "s3cret", signedAt.Add(time.Second))
+	if _, err := v.Verify(req, creds, nil); err != nil {
+		t.Fatalf("re-signed request: %v", err)
+	}
+}
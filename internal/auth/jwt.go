@@ -4,10 +4,15 @@
 package auth
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
 )
 
 var (
@@ -15,16 +20,45 @@ var (
 	ErrExpiredToken = errors.New("token has expired")
 )
 
+// TokenTTL is how long an issued token remains valid.
+const TokenTTL = 24 * time.Hour
+
+// ImpersonationTTL is how long an impersonation token remains valid.
+const ImpersonationTTL = 15 * time.Minute
+
 // Claims represents the JWT claims stored in each token.
 type Claims struct {
 	Username string `json:"username"`
+	// SessionID links the token to a revocable login session.  Empty for
+	// tokens issued outside a login.
+	SessionID string `json:"sid,omitempty"`
+	// Act names the administrator acting as Username, for impersonation
+	// tokens; nil otherwise.
+	Act *Actor `json:"act,omitempty"`
+	// AuthTime is when the user last proved their password: at login, or
+	// when they confirmed it again for a token with a fresh AuthTime.
+	// Impersonation tokens have none.
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	// ClientID names the third-party application the token was issued
+	// to, which may use it within Scope while the grant GrantID stands;
+	// all three are empty for the user's own tokens.
+	ClientID string `json:"client_id,omitempty"`
+	GrantID  string `json:"gid,omitempty"`
+	Scope    string `json:"scope,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// Actor is the RFC 8693 "act" claim: the party acting on behalf of the
+// token's subject.
+type Actor struct {
+	Subject string `json:"sub"`
+}
+
 // JWTService handles token generation and validation.
 type JWTService struct {
 	secretKey []byte
 	issuer    string
+	clock     clock.Clock
 }
 
 // NewJWTService creates a new JWT service with the provided secret key.
@@ -32,34 +66,78 @@ func NewJWTService(secretKey, issuer string) *JWTService {
 	return &JWTService{
 		secretKey: []byte(secretKey),
 		issuer:    issuer,
+		clock:     clock.System{},
 	}
 }
 
+// SetClock makes the service issue and check expiry against c instead of
+// the wall clock.
+func (s *JWTService) SetClock(c clock.Clock) {
+	s.clock = clock.Or(c)
+}
+
 // GenerateToken creates a new JWT token for the given username.
-// Token expires in 24 hours.
+// Token expires after TokenTTL.
 func (s *JWTService) GenerateToken(username string) (string, error) {
-	claims := Claims{
-		Username: username,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    s.issuer,
-		},
+	return s.GenerateSessionToken(username, "")
+}
+
+// GenerateSessionToken creates a JWT token bound to a login session, so that
+// revoking the session invalidates the token.  Every token has a random ID
+// (jti), reported by introspection, that identifies it in logs and ledgers.
+// It is to be called once the user has proved their password, which is
+// recorded as the token's auth_time.
+func (s *JWTService) GenerateSessionToken(username, sessionID string) (string, error) {
+	claims := Claims{Username: username, SessionID: sessionID, AuthTime: jwt.NewNumericDate(s.clock.Now())}
+	token, _, err := s.sign(claims, TokenTTL)
+	return token, err
+}
+
+// GenerateImpersonationToken creates a token that lets admin act as
+// username for ImpersonationTTL, returning it with its expiry.  The token
+// names admin in its act claim and is bound to no session.
+func (s *JWTService) GenerateImpersonationToken(username, admin string) (string, time.Time, error) {
+	return s.sign(Claims{Username: username, Act: &Actor{Subject: admin}}, ImpersonationTTL)
+}
+
+// sign fills in the registered claims of a token valid for ttl and signs
+// it.
+func (s *JWTService) sign(claims Claims, ttl time.Duration) (string, time.Time, error) {
+	now := s.clock.Now()
+	id, err := NewSessionID()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expires := now.Add(ttl)
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(expires),
+		IssuedAt:  jwt.NewNumericDate(now),
+		Issuer:    s.issuer,
+		ID:        id,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.secretKey)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secretKey)
+	return token, expires, err
 }
 
-// ValidateToken verifies the token signature and checks expiration.
+// ValidateToken verifies the token signature and checks expiration,
+// returning ErrExpiredToken for a well-signed token that has expired.
 func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, ErrInvalidToken
 		}
+		// Authorization codes and email verification tokens are signed
+		// with the same key.
+		if typ := token.Header["typ"]; typ == codeType || typ == emailType {
+			return nil, ErrInvalidToken
+		}
 		return s.secretKey, nil
-	})
+	}, jwt.WithTimeFunc(s.clock.Now))
 
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, ErrExpiredToken
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -69,9 +147,46 @@ func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
 		return nil, ErrInvalidToken
 	}
 
-	if claims.ExpiresAt != nil && claims.ExpiresAt.Before(time.Now()) {
+	if claims.ExpiresAt != nil && claims.ExpiresAt.Before(s.clock.Now()) {
 		return nil, ErrExpiredToken
 	}
 
 	return claims, nil
 }
+
+// NewSessionID returns a random, URL-safe session identifier.
+func NewSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// IDGenerator produces unique opaque identifiers, such as session IDs.
+type IDGenerator interface {
+	NewID() (string, error)
+}
+
+// RandomIDs generates identifiers with NewSessionID.
+type RandomIDs struct{}
+
+// NewID returns NewSessionID().
+func (RandomIDs) NewID() (string, error) { return NewSessionID() }
+
+// SequentialIDs generates Prefix followed by 1, 2, 3 … so that tests can
+// predict identifiers.  It is safe for concurrent use.
+type SequentialIDs struct {
+	Prefix string
+
+	mu sync.Mutex
+	n  int
+}
+
+// NewID returns the next identifier in the sequence.
+func (g *SequentialIDs) NewID() (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.n++
+	return g.Prefix + strconv.Itoa(g.n), nil
+}
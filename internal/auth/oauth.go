@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// The scopes users can grant third-party applications.  Football data —
+// teams and matches with their goals and shootouts — is the only data
+// applications can reach; account, admin and authentication endpoints
+// refuse their tokens.
+const (
+	ScopeFootballRead  = "football:read"
+	ScopeFootballWrite = "football:write"
+)
+
+// Scopes describes every scope, for consent screens.
+var Scopes = map[string]string{
+	ScopeFootballRead:  "Read teams, matches and rankings, even when reads are private",
+	ScopeFootballWrite: "Create and edit teams, matches, goals and shootouts on your behalf",
+}
+
+// AuthorizationCodeTTL is how long an authorization code may be exchanged
+// for a token.
+const AuthorizationCodeTTL = 5 * time.Minute
+
+// DelegatedTokenTTL is how long a token issued to an application remains
+// valid.  Applications send the user through the consent flow again for a
+// new one; a user who has already granted the scopes is not asked twice.
+const DelegatedTokenTTL = time.Hour
+
+// codeType is the JWT "typ" header of authorization codes, which keeps
+// them from being accepted as access tokens (RFC 8725 explicit typing).
+const codeType = "code+jwt"
+
+// ErrInvalidScope is returned by ParseScope for a scope that does not
+// exist.
+var ErrInvalidScope = errors.New("invalid scope")
+
+// ParseScope splits a space-separated OAuth scope parameter into its
+// scopes, sorted and without duplicates, returning ErrInvalidScope if one
+// of them is unknown.
+func ParseScope(scope string) ([]string, error) {
+	seen := map[string]bool{}
+	var out []string
+	for _, s := range strings.Fields(scope) {
+		if _, ok := Scopes[s]; !ok {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidScope, s)
+		}
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// HasScope reports whether the space-separated scope includes want.
+func HasScope(scope, want string) bool {
+	for _, s := range strings.Fields(scope) {
+		if s == want {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidRedirectURI reports whether uri may be registered as an OAuth
+// redirect URI: an absolute https URL, or http on a loopback host for
+// native applications, without a fragment.
+func ValidRedirectURI(uri string) bool {
+	u, err := url.Parse(uri)
+	if err != nil || u.Host == "" || u.Fragment != "" || u.User != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "https":
+		return true
+	case "http":
+		host := u.Hostname()
+		return host == "localhost" || host == "127.0.0.1" || host == "::1"
+	}
+	return false
+}
+
+// NewClientSecret returns a random OAuth client secret.
+func NewClientSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashClientSecret returns the hash under which a client secret is
+// stored.  Secrets are random and long, so a fast hash suffices.
+func HashClientSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyClientSecret reports, in constant time, whether secret matches
+// hash.
+func VerifyClientSecret(secret, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashClientSecret(secret)), []byte(hash)) == 1
+}
+
+// VerifyPKCE reports whether verifier matches an RFC 7636 S256
+// code_challenge.
+func VerifyPKCE(verifier, challenge string) bool {
+	sum := sha256.Sum256([]byte(verifier))
+	return subtle.ConstantTimeCompare([]byte(base64.RawURLEncoding.EncodeToString(sum[:])), []byte(challenge)) == 1
+}
+
+// AuthorizationCode is what an OAuth authorization code stands for: the
+// user's consent to ClientID using Scope, to be exchanged for a token by a
+// request that repeats RedirectURI and, if CodeChallenge is set, proves
+// it with the PKCE code verifier.  Codes are signed rather than stored;
+// the replay ledger makes each one usable once.
+type AuthorizationCode struct {
+	Username      string `json:"username"`
+	ClientID      string `json:"client_id"`
+	GrantID       string `json:"gid"`
+	RedirectURI   string `json:"redirect_uri"`
+	Scope         string `json:"scope"`
+	CodeChallenge string `json:"code_challenge,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// GenerateAuthorizationCode signs code, valid for AuthorizationCodeTTL.
+func (s *JWTService) GenerateAuthorizationCode(code AuthorizationCode) (string, error) {
+	now := s.clock.Now()
+	id, err := NewSessionID()
+	if err != nil {
+		return "", err
+	}
+	code.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(now.Add(AuthorizationCodeTTL)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		Issuer:    s.issuer,
+		ID:        id,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, code)
+	token.Header["typ"] = codeType
+	return token.SignedString(s.secretKey)
+}
+
+// ValidateAuthorizationCode verifies a code from GenerateAuthorizationCode,
+// returning ErrExpiredToken once it has expired.  It does not check that
+// the code is unused.
+func (s *JWTService) ValidateAuthorizationCode(code string) (*AuthorizationCode, error) {
+	token, err := jwt.ParseWithClaims(code, &AuthorizationCode{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || token.Header["typ"] != codeType {
+			return nil, ErrInvalidToken
+		}
+		return s.secretKey, nil
+	}, jwt.WithTimeFunc(s.clock.Now))
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, ErrExpiredToken
+	}
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	claims, ok := token.Claims.(*AuthorizationCode)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// GenerateDelegatedToken creates a token with which the application
+// clientID acts for username within scope, for DelegatedTokenTTL,
+// returning it with its expiry.  The token stands only while the grant
+// grantID does.
+func (s *JWTService) GenerateDelegatedToken(username, clientID, grantID, scope string) (string, time.Time, error) {
+	return s.sign(Claims{Username: username, ClientID: clientID, GrantID: grantID, Scope: scope}, DelegatedTokenTTL)
+}
@@ -0,0 +1,88 @@
+package auth_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+)
+
+func TestParseScope(t *testing.T) {
+	got, err := auth.ParseScope("football:write  football:read football:write")
+	if err != nil || !reflect.DeepEqual(got, []string{auth.ScopeFootballRead, auth.ScopeFootballWrite}) {
+		t.Fatalf("got %v, %v", got, err)
+	}
+	if _, err := auth.ParseScope("football:read admin"); !errors.Is(err, auth.ErrInvalidScope) {
+		t.Fatalf("unknown scope: got %v", err)
+	}
+}
+
+func TestValidRedirectURI(t *testing.T) {
+	for uri, want := range map[string]bool{
+		"https://app.example/callback":    true,
+		"http://127.0.0.1:8123/cb":        true,
+		"http://localhost/cb":             true,
+		"http://app.example/callback":     false,
+		"https://app.example/cb#fragment": false,
+		"https://user@app.example/cb":     false,
+		"/callback":                       false,
+		"javascript:alert(1)":             false,
+	} {
+		if got := auth.ValidRedirectURI(uri); got != want {
+			t.Errorf("%q: got %v, want %v", uri, got, want)
+		}
+	}
+}
+
+func TestAuthorizationCode(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+	jwt := auth.NewJWTService("test-secret", "COMP3011_API")
+	jwt.SetClock(clk)
+
+	code, err := jwt.GenerateAuthorizationCode(auth.AuthorizationCode{
+		Username: "alice", ClientID: "app", RedirectURI: "https://app.example/cb", Scope: auth.ScopeFootballRead,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := jwt.ValidateAuthorizationCode(code)
+	if err != nil || got.Username != "alice" || got.ClientID != "app" || got.ID == "" {
+		t.Fatalf("got %+v, %v", got, err)
+	}
+	// A code is not an access token, nor a token a code.
+	if _, err := jwt.ValidateToken(code); err == nil {
+		t.Error("code accepted as an access token")
+	}
+	token, _ := jwt.GenerateToken("alice")
+	if _, err := jwt.ValidateAuthorizationCode(token); err == nil {
+		t.Error("access token accepted as a code")
+	}
+
+	clk.Advance(auth.AuthorizationCodeTTL + time.Second)
+	if _, err := jwt.ValidateAuthorizationCode(code); !errors.Is(err, auth.ErrExpiredToken) {
+		t.Errorf("expired code: got %v", err)
+	}
+}
+
+func TestClientSecretAndPKCE(t *testing.T) {
+	secret, err := auth.NewClientSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := auth.HashClientSecret(secret)
+	if !auth.VerifyClientSecret(secret, hash) || auth.VerifyClientSecret(secret+"x", hash) {
+		t.Error("client secret verification is wrong")
+	}
+
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+	if !auth.VerifyPKCE(verifier, challenge) || auth.VerifyPKCE("other", challenge) {
+		t.Error("PKCE verification is wrong")
+	}
+}
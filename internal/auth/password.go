@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrUnknownHashScheme is returned for a stored hash whose scheme prefix is
+// not recognised.
+var ErrUnknownHashScheme = errors.New("unknown password hash scheme")
+
+// argon2idPrefix starts every argon2id hash.  Hashes are stored in the PHC
+// string format, so the scheme and its parameters travel with each password:
+//
+//	$argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>
+//
+// Legacy bcrypt hashes carry their own "$2a$"/"$2b$" prefix, so both schemes
+// coexist in the same column.
+const argon2idPrefix = "$argon2id$"
+
+// Argon2Params tunes argon2id.  Memory is in KiB.
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params follows the OWASP Password Storage Cheat Sheet minimum
+// for argon2id: 19 MiB of memory, 2 iterations, 1 degree of parallelism.
+var DefaultArgon2Params = Argon2Params{
+	Memory:      19 * 1024,
+	Iterations:  2,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// PasswordHasher hashes new passwords with argon2id and verifies both argon2id
+// and legacy bcrypt hashes.
+type PasswordHasher struct {
+	params Argon2Params
+}
+
+// NewPasswordHasher creates a hasher using params; zero fields take their
+// value from DefaultArgon2Params.
+func NewPasswordHasher(params Argon2Params) *PasswordHasher {
+	if params.Memory == 0 {
+		params.Memory = DefaultArgon2Params.Memory
+	}
+	if params.Iterations == 0 {
+		params.Iterations = DefaultArgon2Params.Iterations
+	}
+	if params.Parallelism == 0 {
+		params.Parallelism = DefaultArgon2Params.Parallelism
+	}
+	if params.SaltLength == 0 {
+		params.SaltLength = DefaultArgon2Params.SaltLength
+	}
+	if params.KeyLength == 0 {
+		params.KeyLength = DefaultArgon2Params.KeyLength
+	}
+	return &PasswordHasher{params: params}
+}
+
+// Hash returns the argon2id hash of password in PHC string format.
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	p := h.params
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix, argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify reports whether password matches the stored hash.  needsRehash is
+// true when the password matched but the hash uses a legacy scheme or
+// parameters other than the hasher's, so the caller should store a fresh
+// Hash while the plain-text password is at hand.
+func (h *PasswordHasher) Verify(password, stored string) (ok, needsRehash bool, err error) {
+	switch {
+	case strings.HasPrefix(stored, argon2idPrefix):
+		p, salt, key, err := decodeArgon2id(stored)
+		if err != nil {
+			return false, false, err
+		}
+		got := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, uint32(len(key)))
+		if subtle.ConstantTimeCompare(got, key) != 1 {
+			return false, false, nil
+		}
+		stale := p.Memory != h.params.Memory || p.Iterations != h.params.Iterations ||
+			p.Parallelism != h.params.Parallelism || uint32(len(key)) != h.params.KeyLength
+		return true, stale, nil
+
+	case strings.HasPrefix(stored, "$2"):
+		if err := bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)); err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return false, false, nil
+			}
+			return false, false, err
+		}
+		return true, true, nil
+
+	default:
+		return false, false, ErrUnknownHashScheme
+	}
+}
+
+// decodeArgon2id parses a PHC-format argon2id hash.
+func decodeArgon2id(encoded string) (Argon2Params, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=…,t=…,p=…", salt, key
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return Argon2Params{}, nil, nil, fmt.Errorf("auth: malformed argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2Params{}, nil, nil, fmt.Errorf("auth: unsupported argon2 version %q", parts[2])
+	}
+
+	var p Argon2Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("auth: malformed argon2id parameters: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("auth: malformed argon2id salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("auth: malformed argon2id key: %w", err)
+	}
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+	return p, salt, key, nil
+}
@@ -0,0 +1,56 @@
+package auth_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fastParams keeps argon2id cheap in tests.
+var fastParams = auth.Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1}
+
+func TestPasswordHasher_Argon2RoundTrip(t *testing.T) {
+	h := auth.NewPasswordHasher(fastParams)
+	hash, err := h.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Fatalf("unexpected hash format: %s", hash)
+	}
+
+	ok, rehash, err := h.Verify("correct horse", hash)
+	if err != nil || !ok || rehash {
+		t.Fatalf("expected match without rehash, got ok=%v rehash=%v err=%v", ok, rehash, err)
+	}
+	if ok, _, _ := h.Verify("wrong", hash); ok {
+		t.Fatal("expected mismatch for wrong password")
+	}
+}
+
+func TestPasswordHasher_LegacyBcryptNeedsRehash(t *testing.T) {
+	legacy, _ := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+
+	ok, rehash, err := auth.NewPasswordHasher(fastParams).Verify("correct horse", string(legacy))
+	if err != nil || !ok || !rehash {
+		t.Fatalf("expected bcrypt match needing rehash, got ok=%v rehash=%v err=%v", ok, rehash, err)
+	}
+}
+
+func TestPasswordHasher_ChangedParamsNeedRehash(t *testing.T) {
+	hash, _ := auth.NewPasswordHasher(fastParams).Hash("correct horse")
+
+	stronger := auth.NewPasswordHasher(auth.Argon2Params{Memory: 128, Iterations: 1, Parallelism: 1})
+	ok, rehash, err := stronger.Verify("correct horse", hash)
+	if err != nil || !ok || !rehash {
+		t.Fatalf("expected match needing rehash, got ok=%v rehash=%v err=%v", ok, rehash, err)
+	}
+}
+
+func TestPasswordHasher_UnknownScheme(t *testing.T) {
+	if _, _, err := auth.NewPasswordHasher(fastParams).Verify("x", "plaintext"); err == nil {
+		t.Fatal("expected error for unknown scheme")
+	}
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MinUsernameLength and MaxUsernameLength bound the length of a normalised
+// username, in characters.
+const (
+	MinUsernameLength = 3
+	MaxUsernameLength = 50
+)
+
+var (
+	ErrInvalidUsername  = errors.New("username contains invalid characters")
+	ErrReservedUsername = errors.New("username is reserved")
+	ErrUsernameTooShort = errors.New("username must be at least 3 characters")
+	ErrUsernameTooLong  = errors.New("username must be at most 50 characters")
+)
+
+// reservedUsernames may not be registered: they could be mistaken for the
+// service itself or for privileged accounts.
+var reservedUsernames = map[string]bool{
+	"admin":         true,
+	"administrator": true,
+	"root":          true,
+	"api":           true,
+	"system":        true,
+	"support":       true,
+	"security":      true,
+	"me":            true,
+	"null":          true,
+	"undefined":     true,
+}
+
+// scripts are the writing systems checked for mixing in one username.  A
+// name combining, say, Latin and Cyrillic letters ("аdmin" with a Cyrillic
+// "а") is almost always an attempt to impersonate another account.
+var scripts = []*unicode.RangeTable{
+	unicode.Latin, unicode.Cyrillic, unicode.Greek, unicode.Armenian,
+	unicode.Hebrew, unicode.Arabic, unicode.Han, unicode.Hiragana,
+	unicode.Katakana, unicode.Hangul, unicode.Devanagari, unicode.Thai,
+}
+
+// NormalizeUsername returns the canonical form of a username: surrounding
+// space trimmed, Unicode NFC, then lower-cased, so that visually identical
+// spellings map to one account.  Usernames are compared and stored in this
+// form everywhere.
+func NormalizeUsername(s string) string {
+	return strings.ToLower(norm.NFC.String(strings.TrimSpace(s)))
+}
+
+// ValidateUsername checks a normalised username for registration, so the
+// length checked is that of the name as it will be stored.  It rejects names
+// shorter than MinUsernameLength or longer than MaxUsernameLength,
+// whitespace, control and symbol characters other than '.', '-' and '_',
+// letters from more than one script, and reserved names.
+func ValidateUsername(name string) error {
+	switch n := utf8.RuneCountInString(name); {
+	case n < MinUsernameLength:
+		return ErrUsernameTooShort
+	case n > MaxUsernameLength:
+		return ErrUsernameTooLong
+	}
+	var script *unicode.RangeTable
+	for _, r := range name {
+		switch {
+		case r == '.' || r == '-' || r == '_' || unicode.IsDigit(r):
+		case unicode.IsLetter(r) || unicode.Is(unicode.Mn, r):
+			for _, t := range scripts {
+				if unicode.Is(t, r) {
+					if script != nil && script != t {
+						return ErrInvalidUsername
+					}
+					script = t
+					break
+				}
+			}
+		default:
+			return ErrInvalidUsername
+		}
+	}
+	if reservedUsernames[strings.Trim(name, ".-_")] {
+		return ErrReservedUsername
+	}
+	return nil
+}
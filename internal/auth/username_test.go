@@ -0,0 +1,48 @@
+package auth_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+)
+
+func TestNormalizeUsername(t *testing.T) {
+	cases := map[string]string{
+		"  Alice ":        "alice",
+		"JOSÉ":           "josé", // decomposed é composes under NFC
+		"josé":            "josé",
+		"already_lower.1": "already_lower.1",
+	}
+	for in, want := range cases {
+		if got := auth.NormalizeUsername(in); got != want {
+			t.Errorf("NormalizeUsername(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestValidateUsername(t *testing.T) {
+	cases := []struct {
+		name string
+		want error
+	}{
+		{"alice", nil},
+		{"josé_99", nil},
+		{"иван", nil}, // all-Cyrillic "иван"
+		{"admin", auth.ErrReservedUsername},
+		{"_root_", auth.ErrReservedUsername},
+		{"аdmin", auth.ErrInvalidUsername}, // Cyrillic "а" + Latin
+		{"bob smith", auth.ErrInvalidUsername},
+		{"bob​", auth.ErrInvalidUsername}, // zero-width space
+		{"<script>", auth.ErrInvalidUsername},
+		{"ab", auth.ErrUsernameTooShort},
+		{"ééé", nil},
+		{strings.Repeat("é", 51), auth.ErrUsernameTooLong},
+	}
+	for _, tc := range cases {
+		if err := auth.ValidateUsername(tc.name); !errors.Is(err, tc.want) {
+			t.Errorf("ValidateUsername(%q) = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
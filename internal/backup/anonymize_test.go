@@ -0,0 +1,58 @@
+package backup_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/backup"
+)
+
+func TestAnonymizer(t *testing.T) {
+	a := backup.Archive{
+		Version: backup.FormatVersion,
+		Tables: map[string][]json.RawMessage{
+			"users":             {json.RawMessage(`{"username":"alice","password_hash":"secret","created_at":"2024-01-01T00:00:00Z"}`)},
+			"user_preferences":  {json.RawMessage(`{"username":"alice","prefs":{"email":"alice@example.com","pageSize":25}}`)},
+			"terms_acceptances": {json.RawMessage(`{"username":"alice","version":"2024-01"}`)},
+			"football_teams":    {json.RawMessage(`{"id":1,"name":"England"}`)},
+		},
+	}
+	an := backup.NewAnonymizer([]byte("key"), "new-hash")
+	got, err := an.Archive(a)
+	if err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+
+	alice := an.Username("alice")
+	if alice == "alice" || alice != backup.NewAnonymizer([]byte("key"), "").Username("alice") {
+		t.Fatalf("expected a deterministic pseudonym, got %q", alice)
+	}
+	if backup.NewAnonymizer([]byte("other"), "").Username("alice") == alice {
+		t.Error("expected a different key to give a different pseudonym")
+	}
+
+	var user map[string]any
+	json.Unmarshal(got.Tables["users"][0], &user)
+	if user["username"] != alice || user["password_hash"] != "new-hash" || user["created_at"] != "2024-01-01T00:00:00Z" {
+		t.Errorf("unexpected user %v", user)
+	}
+	var prefs struct {
+		Username string         `json:"username"`
+		Prefs    map[string]any `json:"prefs"`
+	}
+	json.Unmarshal(got.Tables["user_preferences"][0], &prefs)
+	email, _ := prefs.Prefs["email"].(string)
+	if prefs.Username != alice || !strings.HasSuffix(email, "@example.invalid") || strings.Contains(email, "alice") || prefs.Prefs["pageSize"] != 25.0 {
+		t.Errorf("unexpected preferences %+v", prefs)
+	}
+	if !strings.Contains(string(got.Tables["terms_acceptances"][0]), alice) {
+		t.Errorf("expected the terms acceptance to reference the pseudonym, got %s", got.Tables["terms_acceptances"][0])
+	}
+	if string(got.Tables["football_teams"][0]) != `{"id":1,"name":"England"}` {
+		t.Errorf("expected football data unchanged, got %s", got.Tables["football_teams"][0])
+	}
+	if !strings.Contains(string(a.Tables["users"][0]), `"alice"`) {
+		t.Error("expected the original archive to be left unchanged")
+	}
+}
@@ -0,0 +1,240 @@
+// Package backup takes logical backups of the users and football data as
+// portable gzipped JSON archives, and restores them, for deployments
+// without DBA tooling.  An archive holds each table's rows as JSON objects
+// keyed by column, so it can be inspected with jq and restored into any
+// database migrated to the same schema.
+//
+// Restoring replaces the archived tables' contents in one transaction.
+// Rows in other tables that reference them, such as sessions and
+// notifications, are deleted with them, so everyone signs in again.  A dry
+// run performs the whole restore and rolls it back, to check an archive
+// against the schema before committing to it.
+package backup
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// FormatVersion is written to archives; Read refuses archives of another
+// version.
+const FormatVersion = 1
+
+// Tables lists the tables an archive holds, parents before the tables whose
+// foreign keys reference them.  Sessions are left out deliberately.
+var Tables = []string{
+	"users",
+	"user_preferences",
+	"terms_acceptances",
+	"football_tournaments",
+	"football_teams",
+	"football_former_names",
+	"football_team_aliases",
+	"football_matches",
+	"football_goalscorers",
+	"football_shootouts",
+	"football_elo_config",
+}
+
+// ErrInvalid wraps the reason an archive cannot be restored.
+var ErrInvalid = errors.New("invalid backup")
+
+// Archive is a logical backup.
+type Archive struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Tables holds each table's rows, keyed by table name.
+	Tables map[string][]json.RawMessage `json:"tables"`
+}
+
+// Dump reads every table in Tables from db in one repeatable-read
+// transaction, so the archive is a consistent snapshot.
+func Dump(ctx context.Context, db *sql.DB) (Archive, error) {
+	a := Archive{Version: FormatVersion, CreatedAt: time.Now().UTC(), Tables: map[string][]json.RawMessage{}}
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return a, fmt.Errorf("backup: %w", err)
+	}
+	defer tx.Rollback()
+	for _, table := range Tables {
+		rows, err := tx.QueryContext(ctx, `SELECT row_to_json(t) FROM `+pq.QuoteIdentifier(table)+` t ORDER BY 1`)
+		if err != nil {
+			return a, fmt.Errorf("backup: %s: %w", table, err)
+		}
+		out := []json.RawMessage{}
+		for rows.Next() {
+			var row []byte
+			if err := rows.Scan(&row); err != nil {
+				rows.Close()
+				return a, fmt.Errorf("backup: %s: %w", table, err)
+			}
+			out = append(out, row)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return a, fmt.Errorf("backup: %s: %w", table, err)
+		}
+		a.Tables[table] = out
+	}
+	return a, nil
+}
+
+// Restore replaces the contents of the tables in Tables with the archive's
+// rows and advances their sequences past the restored ids.  With dryRun it
+// rolls everything back once done, so only errors are reported.  It returns
+// the number of rows restored per table, in restore order; a row the
+// schema refuses is reported as ErrInvalid.
+func Restore(ctx context.Context, db *sql.DB, a Archive, dryRun bool) ([]models.TableCount, error) {
+	if err := a.validate(); err != nil {
+		return nil, err
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("backup: %w", err)
+	}
+	defer tx.Rollback()
+
+	quoted := make([]string, len(Tables))
+	for i, t := range Tables {
+		quoted[i] = pq.QuoteIdentifier(t)
+	}
+	if _, err := tx.ExecContext(ctx, `TRUNCATE `+strings.Join(quoted, ", ")+` CASCADE`); err != nil {
+		return nil, fmt.Errorf("backup: %w", err)
+	}
+	counts := make([]models.TableCount, 0, len(Tables))
+	for i, table := range Tables {
+		rows := a.Tables[table]
+		if rows == nil {
+			rows = []json.RawMessage{}
+		}
+		data, err := json.Marshal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("backup: %w", err)
+		}
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO `+quoted[i]+` SELECT * FROM json_populate_recordset(NULL::`+quoted[i]+`, $1::json)`, string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalid, table, err)
+		}
+		n, _ := res.RowsAffected()
+		counts = append(counts, models.TableCount{Table: table, Rows: n})
+	}
+	if err := resetSequences(ctx, tx); err != nil {
+		return nil, err
+	}
+	if err := resetHistory(ctx, tx); err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return counts, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("backup: %w", err)
+	}
+	return counts, nil
+}
+
+// resetHistory starts the revision history of migration 027, if applied,
+// again from now: the restore recorded every row as inserted, and the
+// earlier revisions were of the replaced data.
+func resetHistory(ctx context.Context, tx *sql.Tx) error {
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `SELECT to_regclass('football_revisions') IS NOT NULL`).Scan(&exists); err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	if !exists {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, `TRUNCATE football_revisions`); err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE football_revisions_start SET started_at = NOW()`); err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	return nil
+}
+
+// resetSequences points each serial column's sequence past the largest
+// restored value, so new rows do not collide with restored ones.
+func resetSequences(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT table_name, column_name FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = ANY($1) AND column_default LIKE 'nextval(%'`,
+		pq.Array(Tables))
+	if err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	var serials [][2]string
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			rows.Close()
+			return fmt.Errorf("backup: %w", err)
+		}
+		serials = append(serials, [2]string{table, column})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	for _, s := range serials {
+		table, column := pq.QuoteIdentifier(s[0]), pq.QuoteIdentifier(s[1])
+		if _, err := tx.ExecContext(ctx,
+			`SELECT setval(pg_get_serial_sequence($1, $2), COALESCE(MAX(`+column+`), 0) + 1, false) FROM `+table,
+			s[0], s[1]); err != nil {
+			return fmt.Errorf("backup: %s.%s sequence: %w", s[0], s[1], err)
+		}
+	}
+	return nil
+}
+
+// validate checks the parts of an archive that do not need the database.
+func (a Archive) validate() error {
+	if a.Version != FormatVersion {
+		return fmt.Errorf("%w: format version %d, want %d", ErrInvalid, a.Version, FormatVersion)
+	}
+	for table := range a.Tables {
+		if !slices.Contains(Tables, table) {
+			return fmt.Errorf("%w: unknown table %q", ErrInvalid, table)
+		}
+	}
+	return nil
+}
+
+// Write writes a to w as gzipped JSON.
+func Write(w io.Writer, a Archive) error {
+	zw := gzip.NewWriter(w)
+	if err := json.NewEncoder(zw).Encode(a); err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	return nil
+}
+
+// Read reads an archive written by Write, reporting one that is not
+// gzipped JSON, or is of another version, as ErrInvalid.
+func Read(r io.Reader) (Archive, error) {
+	var a Archive
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return a, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	defer zr.Close()
+	if err := json.NewDecoder(zr).Decode(&a); err != nil {
+		return a, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return a, a.validate()
+}
@@ -0,0 +1,117 @@
+package backup_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/backup"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/postgres"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+func TestWriteRead(t *testing.T) {
+	a := backup.Archive{
+		Version:   backup.FormatVersion,
+		CreatedAt: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		Tables:    map[string][]json.RawMessage{"football_teams": {json.RawMessage(`{"id":1,"name":"England"}`)}},
+	}
+	var buf bytes.Buffer
+	if err := backup.Write(&buf, a); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	got, err := backup.Read(&buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if !got.CreatedAt.Equal(a.CreatedAt) || string(got.Tables["football_teams"][0]) != `{"id":1,"name":"England"}` {
+		t.Errorf("round trip changed the archive: %+v", got)
+	}
+
+	for _, bad := range []backup.Archive{
+		{Version: 2},
+		{Version: backup.FormatVersion, Tables: map[string][]json.RawMessage{"user_sessions": nil}},
+	} {
+		buf.Reset()
+		backup.Write(&buf, bad)
+		if _, err := backup.Read(&buf); !errors.Is(err, backup.ErrInvalid) {
+			t.Errorf("Read(%+v) = %v, want ErrInvalid", bad, err)
+		}
+	}
+	if _, err := backup.Read(bytes.NewReader([]byte("not gzip"))); !errors.Is(err, backup.ErrInvalid) {
+		t.Errorf("expected ErrInvalid for a file that is not gzip, got %v", err)
+	}
+}
+
+func TestDir(t *testing.T) {
+	dir := backup.Dir(filepath.Join(t.TempDir(), "backups"))
+	ctx := context.Background()
+	if list, err := dir.List(ctx); err != nil || len(list) != 0 {
+		t.Fatalf("List of a missing directory = %v, %v", list, err)
+	}
+	for _, name := range []string{"backup-20240101T120000Z.json.gz", "backup-20240102T120000Z.json.gz"} {
+		if _, err := dir.Put(ctx, name, bytes.NewReader([]byte("x"))); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+	os.WriteFile(filepath.Join(string(dir), "notes.txt"), []byte("ignored"), 0o600)
+
+	s := backup.New(nil, dir)
+	list, err := s.List(ctx)
+	if err != nil || len(list) != 2 || list[0].Name != "backup-20240102T120000Z.json.gz" {
+		t.Fatalf("List = %+v, %v; want both archives, newest first", list, err)
+	}
+	if want := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC); !list[0].CreatedAt.Equal(want) {
+		t.Errorf("CreatedAt = %v, want %v from the name", list[0].CreatedAt, want)
+	}
+	for _, name := range []string{"notes.txt", "../backups/notes.txt", "backup-20240103T120000Z.json.gz"} {
+		if _, err := s.Open(ctx, name); !errors.Is(err, models.ErrNotFound) {
+			t.Errorf("Open(%q) = %v, want ErrNotFound", name, err)
+		}
+	}
+}
+
+// TestDumpRestore backs up TEST_DATABASE_URL and restores the archive,
+// first as a dry run.
+func TestDumpRestore(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := postgres.Connect(dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	a, err := backup.Dump(ctx, db)
+	if err != nil {
+		t.Fatalf("Dump: %v", err)
+	}
+	for _, dryRun := range []bool{true, false} {
+		counts, err := backup.Restore(ctx, db, a, dryRun)
+		if err != nil {
+			t.Fatalf("Restore(dryRun=%v): %v", dryRun, err)
+		}
+		for _, c := range counts {
+			if c.Rows != int64(len(a.Tables[c.Table])) {
+				t.Errorf("%s: restored %d rows, archive has %d", c.Table, c.Rows, len(a.Tables[c.Table]))
+			}
+		}
+	}
+
+	// A goal for a match that does not exist fails the foreign key.
+	bad := a
+	bad.Tables = map[string][]json.RawMessage{
+		"football_goalscorers": {json.RawMessage(`{"id":1,"match_id":-1,"team_id":-1,"scorer":"X","minute":1,"own_goal":false,"penalty":false}`)},
+	}
+	if _, err := backup.Restore(ctx, db, bad, true); !errors.Is(err, backup.ErrInvalid) {
+		t.Errorf("expected ErrInvalid, got %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// nameLayout formats the time in archive names.
+const nameLayout = "20060102T150405Z"
+
+// namePattern matches the names Service gives archives, and so every name
+// Storage is asked for.
+var namePattern = regexp.MustCompile(`^backup-\d{8}T\d{6}Z\.json\.gz$`)
+
+// Object describes a stored archive.
+type Object struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+// Storage keeps archives by name.  Dir stores them in a local directory;
+// an object store can be used instead by implementing it.
+type Storage interface {
+	Put(ctx context.Context, name string, r io.Reader) (Object, error)
+	// Get returns models.ErrNotFound for an unknown name.
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+	List(ctx context.Context) ([]Object, error)
+}
+
+// Dir is a Storage that keeps archives as files in a directory, created on
+// first use.
+type Dir string
+
+// Put implements Storage.  The file is written under a temporary name and
+// renamed, so a failed backup never leaves a partial archive.
+func (d Dir) Put(_ context.Context, name string, r io.Reader) (Object, error) {
+	if err := os.MkdirAll(string(d), 0o700); err != nil {
+		return Object{}, err
+	}
+	f, err := os.CreateTemp(string(d), ".tmp-")
+	if err != nil {
+		return Object{}, err
+	}
+	defer os.Remove(f.Name())
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return Object{}, err
+	}
+	if err := f.Close(); err != nil {
+		return Object{}, err
+	}
+	path := filepath.Join(string(d), name)
+	if err := os.Rename(f.Name(), path); err != nil {
+		return Object{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return Object{}, err
+	}
+	return Object{Name: name, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// Get implements Storage.
+func (d Dir) Get(_ context.Context, name string) (io.ReadCloser, error) {
+	f, err := os.Open(filepath.Join(string(d), name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, models.ErrNotFound
+	}
+	return f, err
+}
+
+// List implements Storage.
+func (d Dir) List(context.Context) ([]Object, error) {
+	entries, err := os.ReadDir(string(d))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out []Object
+	for _, e := range entries {
+		if !namePattern.MatchString(e.Name()) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, Object{Name: e.Name(), Size: info.Size(), ModTime: info.ModTime()})
+	}
+	return out, nil
+}
+
+// Service takes, stores and restores backups of a database.
+type Service struct {
+	db    *sql.DB
+	store Storage
+}
+
+// New returns a Service backing up db into store.
+func New(db *sql.DB, store Storage) *Service {
+	return &Service{db: db, store: store}
+}
+
+// Create dumps the database and stores the archive, named after the time
+// it was taken.
+func (s *Service) Create(ctx context.Context) (models.Backup, error) {
+	a, err := Dump(ctx, s.db)
+	if err != nil {
+		return models.Backup{}, err
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, a); err != nil {
+		return models.Backup{}, err
+	}
+	name := "backup-" + a.CreatedAt.Format(nameLayout) + ".json.gz"
+	obj, err := s.store.Put(ctx, name, &buf)
+	if err != nil {
+		return models.Backup{}, fmt.Errorf("backup: store %s: %w", name, err)
+	}
+	b := backupOf(obj)
+	b.CreatedAt = a.CreatedAt
+	for _, t := range Tables {
+		b.Tables = append(b.Tables, models.TableCount{Table: t, Rows: int64(len(a.Tables[t]))})
+	}
+	return b, nil
+}
+
+// List returns the stored backups, newest first.
+func (s *Service) List(ctx context.Context) ([]models.Backup, error) {
+	objs, err := s.store.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("backup: %w", err)
+	}
+	out := make([]models.Backup, 0, len(objs))
+	for _, o := range objs {
+		out = append(out, backupOf(o))
+	}
+	slices.SortFunc(out, func(a, b models.Backup) int { return strings.Compare(b.Name, a.Name) })
+	return out, nil
+}
+
+// Open returns the stored archive name, or models.ErrNotFound.
+func (s *Service) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	if !namePattern.MatchString(name) {
+		return nil, models.ErrNotFound
+	}
+	return s.store.Get(ctx, name)
+}
+
+// Restore restores the stored archive name; see the package function.
+func (s *Service) Restore(ctx context.Context, name string, dryRun bool) ([]models.TableCount, error) {
+	r, err := s.Open(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	a, err := Read(r)
+	if err != nil {
+		return nil, err
+	}
+	return Restore(ctx, s.db, a, dryRun)
+}
+
+// backupOf describes a stored archive, taking its creation time from the
+// name, which unlike the file's modification time survives copying.
+func backupOf(o Object) models.Backup {
+	b := models.Backup{Name: o.Name, Size: o.Size, CreatedAt: o.ModTime.UTC()}
+	stamp := strings.TrimSuffix(strings.TrimPrefix(o.Name, "backup-"), ".json.gz")
+	if t, err := time.Parse(nameLayout, stamp); err == nil {
+		b.CreatedAt = t
+	}
+	return b
+}
@@ -0,0 +1,251 @@
+// Package classify screens the text users submit — team names, match venues,
+// goal scorers — before it is stored.  A Classifier looks at the fields of a
+// write and lets it through, rejects it with a reason code, or accepts it but
+// asks for it to be quarantined for review by a moderator.  A built-in
+// denylist and a client for an external HTTP classifier are provided; Chain
+// runs several of them in turn.
+package classify
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Actions a classifier can take on content.
+const (
+	Allow      Action = "allow"
+	Reject     Action = "reject"
+	Quarantine Action = "quarantine"
+)
+
+// ReasonDenylisted is the reason code given by a Denylist.
+const ReasonDenylisted = "denylisted_term"
+
+// DefaultTimeout bounds calls to an HTTP classifier when no timeout is set.
+const DefaultTimeout = 2 * time.Second
+
+var metrics = expvar.NewMap("classifier")
+
+// Action is what should happen to screened content.
+type Action string
+
+// Content is one write to screen.
+type Content struct {
+	// Kind is the type of resource written, e.g. models.ContentTeam.
+	Kind string `json:"kind"`
+	// Fields maps the JSON names of the free-text fields to their values.
+	Fields map[string]string `json:"fields"`
+}
+
+// Verdict is a classifier's decision.  The zero Verdict allows the content.
+type Verdict struct {
+	Action Action `json:"action"`
+	// Reason is a short machine-readable code, e.g. "denylisted_term".
+	Reason string `json:"reason,omitempty"`
+	// Field names the offending field, when the classifier knows it.
+	Field string `json:"field,omitempty"`
+}
+
+// Allowed reports whether v lets the content through unreviewed.
+func (v Verdict) Allowed() bool {
+	return v.Action == "" || v.Action == Allow
+}
+
+// Classifier screens content.  An error means the content could not be
+// screened; it says nothing about the content itself.
+type Classifier interface {
+	Classify(ctx context.Context, c Content) (Verdict, error)
+}
+
+// Chain runs its classifiers in order.  The first rejection wins; otherwise
+// the first quarantine does, so that a later classifier can still reject
+// content an earlier one only wanted reviewed.
+type Chain []Classifier
+
+// Classify implements Classifier.
+func (ch Chain) Classify(ctx context.Context, c Content) (Verdict, error) {
+	var out Verdict
+	for _, cl := range ch {
+		v, err := cl.Classify(ctx, c)
+		if err != nil {
+			metrics.Add("errors", 1)
+			return Verdict{}, err
+		}
+		switch v.Action {
+		case Reject:
+			metrics.Add("rejected", 1)
+			return v, nil
+		case Quarantine:
+			if out.Allowed() {
+				out = v
+			}
+		}
+	}
+	if out.Action == Quarantine {
+		metrics.Add("quarantined", 1)
+	}
+	return out, nil
+}
+
+// Denylist matches whole words and phrases, ignoring case and punctuation,
+// so "Scunthorpe United" is not caught by the term "thorpe".
+type Denylist struct {
+	terms  []string
+	action Action
+}
+
+// NewDenylist returns a Denylist that answers action, Reject or Quarantine,
+// for content containing any of terms.  Blank terms are ignored.
+func NewDenylist(terms []string, action Action) (*Denylist, error) {
+	if action != Reject && action != Quarantine {
+		return nil, fmt.Errorf("classify: denylist action must be %q or %q, not %q", Reject, Quarantine, action)
+	}
+	d := &Denylist{action: action}
+	for _, t := range terms {
+		if t = normalise(t); t != " " {
+			d.terms = append(d.terms, t)
+		}
+	}
+	return d, nil
+}
+
+// Len returns the number of terms in the list.
+func (d *Denylist) Len() int {
+	return len(d.terms)
+}
+
+// Classify implements Classifier.
+func (d *Denylist) Classify(_ context.Context, c Content) (Verdict, error) {
+	for _, field := range slices.Sorted(maps.Keys(c.Fields)) {
+		text := normalise(c.Fields[field])
+		for _, t := range d.terms {
+			if strings.Contains(text, t) {
+				return Verdict{Action: d.action, Reason: ReasonDenylisted, Field: field}, nil
+			}
+		}
+	}
+	return Verdict{}, nil
+}
+
+// normalise lowercases s and collapses every run of characters other than
+// letters and digits into one space, padding the result with spaces so that
+// terms match on word boundaries.
+func normalise(s string) string {
+	var b strings.Builder
+	b.WriteByte(' ')
+	space := true
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			space = false
+		} else if !space {
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	if !space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// ReadTerms reads a denylist file: one term or phrase per line, with blank
+// lines and lines starting with # ignored.
+func ReadTerms(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var terms []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			terms = append(terms, line)
+		}
+	}
+	return terms, sc.Err()
+}
+
+// HTTP asks an external service to classify content.  Each write is POSTed
+// to the URL as a JSON Content, and the service answers 200 with a JSON
+// Verdict.
+type HTTP struct {
+	url    string
+	client *http.Client
+	// failOpen allows content when the service cannot be reached.
+	failOpen bool
+}
+
+// NewHTTP returns a classifier that calls the service at url, waiting at
+// most timeout (zero uses DefaultTimeout).  With failOpen, content is
+// allowed, and the failure logged, when the service errors or times out;
+// otherwise the error is returned and the write refused.
+func NewHTTP(url string, timeout time.Duration, failOpen bool) *HTTP {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &HTTP{url: url, client: &http.Client{Timeout: timeout}, failOpen: failOpen}
+}
+
+// Classify implements Classifier.
+func (h *HTTP) Classify(ctx context.Context, c Content) (Verdict, error) {
+	v, err := h.call(ctx, c)
+	if err != nil && h.failOpen {
+		metrics.Add("failedOpen", 1)
+		log.Printf("classify: %v; allowing %s content", err, c.Kind)
+		return Verdict{}, nil
+	}
+	return v, err
+}
+
+func (h *HTTP) call(ctx context.Context, c Content) (Verdict, error) {
+	body, err := json.Marshal(c)
+	if err != nil {
+		return Verdict{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return Verdict{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("classifier request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return Verdict{}, fmt.Errorf("classifier returned %s", resp.Status)
+	}
+
+	var v Verdict
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&v); err != nil {
+		return Verdict{}, fmt.Errorf("classifier response: %w", err)
+	}
+	switch v.Action {
+	case "", Allow:
+		return Verdict{}, nil
+	case Reject, Quarantine:
+		if v.Reason == "" {
+			v.Reason = "classifier"
+		}
+		return v, nil
+	}
+	return Verdict{}, errors.New("classifier response: unknown action " + string(v.Action))
+}
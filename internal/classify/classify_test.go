@@ -0,0 +1,108 @@
+package classify_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/classify"
+)
+
+func TestDenylist(t *testing.T) {
+	d, err := classify.NewDenylist([]string{"thorpe", "Bad Word", " "}, classify.Quarantine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Len() != 2 {
+		t.Fatalf("expected blank terms to be dropped, got %d", d.Len())
+	}
+	cases := []struct {
+		fields map[string]string
+		field  string
+	}{
+		{map[string]string{"name": "Scunthorpe United"}, ""},
+		{map[string]string{"name": "Badword Rovers"}, ""},
+		{map[string]string{"city": "Leeds", "country": "a BAD-word here"}, "country"},
+		{map[string]string{"name": "THORPE!"}, "name"},
+	}
+	for _, tc := range cases {
+		v, err := d.Classify(context.Background(), classify.Content{Kind: "team", Fields: tc.fields})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.field == "" && !v.Allowed() {
+			t.Errorf("%v: expected allowed, got %+v", tc.fields, v)
+		}
+		if tc.field != "" && (v.Action != classify.Quarantine || v.Field != tc.field || v.Reason != classify.ReasonDenylisted) {
+			t.Errorf("%v: expected quarantine of %s, got %+v", tc.fields, tc.field, v)
+		}
+	}
+
+	if _, err := classify.NewDenylist(nil, classify.Allow); err == nil {
+		t.Fatal("expected an error for an allow denylist")
+	}
+}
+
+func TestReadTerms(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deny.txt")
+	if err := os.WriteFile(path, []byte("# comment\none\n\n  two words  \n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	terms, err := classify.ReadTerms(path)
+	if err != nil || len(terms) != 2 || terms[1] != "two words" {
+		t.Fatalf("unexpected terms %q (%v)", terms, err)
+	}
+}
+
+func TestChain(t *testing.T) {
+	quarantine, _ := classify.NewDenylist([]string{"maybe"}, classify.Quarantine)
+	reject, _ := classify.NewDenylist([]string{"no"}, classify.Reject)
+	chain := classify.Chain{quarantine, reject}
+
+	v, _ := chain.Classify(context.Background(), classify.Content{Fields: map[string]string{"a": "maybe no"}})
+	if v.Action != classify.Reject {
+		t.Fatalf("expected a later rejection to win, got %+v", v)
+	}
+	v, _ = chain.Classify(context.Background(), classify.Content{Fields: map[string]string{"a": "maybe"}})
+	if v.Action != classify.Quarantine {
+		t.Fatalf("expected quarantine, got %+v", v)
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var c classify.Content
+		if err := json.NewDecoder(r.Body).Decode(&c); err != nil || c.Kind != "match" {
+			t.Errorf("unexpected request %+v (%v)", c, err)
+		}
+		w.WriteHeader(status)
+		if c.Fields["city"] == "spam" {
+			_, _ = w.Write([]byte(`{"action":"reject","reason":"spam","field":"city"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"action":"allow"}`))
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	cl := classify.NewHTTP(srv.URL, 0, false)
+	if v, err := cl.Classify(ctx, classify.Content{Kind: "match", Fields: map[string]string{"city": "spam"}}); err != nil || v.Action != classify.Reject || v.Reason != "spam" {
+		t.Fatalf("expected rejection, got %+v (%v)", v, err)
+	}
+	if v, err := cl.Classify(ctx, classify.Content{Kind: "match", Fields: map[string]string{"city": "Turin"}}); err != nil || !v.Allowed() {
+		t.Fatalf("expected allowed, got %+v (%v)", v, err)
+	}
+
+	status = http.StatusBadGateway
+	if _, err := cl.Classify(ctx, classify.Content{Kind: "match"}); err == nil {
+		t.Fatal("expected an error from a failing classifier")
+	}
+	if v, err := classify.NewHTTP(srv.URL, 0, true).Classify(ctx, classify.Content{Kind: "match"}); err != nil || !v.Allowed() {
+		t.Fatalf("fail open: expected allowed, got %+v (%v)", v, err)
+	}
+}
@@ -0,0 +1,34 @@
+package clock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := clock.NewFake(start)
+	if !c.Now().Equal(start) {
+		t.Fatalf("Now = %v, want %v", c.Now(), start)
+	}
+	c.Advance(time.Hour)
+	if want := start.Add(time.Hour); !c.Now().Equal(want) {
+		t.Fatalf("after Advance, Now = %v, want %v", c.Now(), want)
+	}
+	c.Set(start)
+	if !c.Now().Equal(start) {
+		t.Fatalf("after Set, Now = %v, want %v", c.Now(), start)
+	}
+}
+
+func TestOr(t *testing.T) {
+	if _, ok := clock.Or(nil).(clock.System); !ok {
+		t.Fatal("Or(nil) should be the system clock")
+	}
+	f := clock.NewFake(time.Time{})
+	if clock.Or(f) != f {
+		t.Fatal("Or(f) should return f")
+	}
+}
@@ -0,0 +1,107 @@
+// Package codec converts the API's JSON to and from other encodings, so
+// that clients which prefer a binary format can be served by handlers that
+// only read and write JSON.  MessagePack and CBOR are schema-free: a
+// document is decoded into maps, slices and scalars and encoded again, so
+// any JSON the API produces has an equivalent.  Protocol Buffers needs the
+// message of each document, which is looked up by its schema name.
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+
+	"github.com/ugorji/go/codec"
+)
+
+// ErrUnsupported is returned by a codec that has no encoding for a
+// document's schema.
+var ErrUnsupported = errors.New("codec: schema not supported")
+
+// Codec is one encoding, identified by its media type.  Documents are
+// passed with the name of their schema, as listed by /api/v1/schemas.
+type Codec interface {
+	// MediaType is the type negotiated through Accept and Content-Type,
+	// such as "application/msgpack".
+	MediaType() string
+	// Encode converts the JSON document data to the codec's encoding.
+	Encode(schema string, data []byte) ([]byte, error)
+	// Decode converts data, in the codec's encoding, to JSON.
+	Decode(schema string, data []byte) ([]byte, error)
+}
+
+// Default returns the built-in codecs: MessagePack, CBOR and, for the
+// team and match endpoints, Protocol Buffers.
+func Default() []Codec {
+	return []Codec{MsgPack(), CBOR(), Protobuf()}
+}
+
+// numbers replaces the json.Numbers in v with int64 where they are whole
+// and in range, and float64 otherwise, so that codecs encode them as
+// numbers rather than strings.
+func numbers(v any) any {
+	switch x := v.(type) {
+	case json.Number:
+		if n, err := x.Int64(); err == nil {
+			return n
+		}
+		f, _ := x.Float64()
+		return f
+	case map[string]any:
+		for k, item := range x {
+			x[k] = numbers(item)
+		}
+	case []any:
+		for i, item := range x {
+			x[i] = numbers(item)
+		}
+	}
+	return v
+}
+
+// handleCodec adapts a ugorji/go handle.
+type handleCodec struct {
+	mediaType string
+	handle    codec.Handle
+}
+
+func (h handleCodec) MediaType() string { return h.mediaType }
+
+func (h handleCodec) Encode(_ string, data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	var out []byte
+	err := codec.NewEncoderBytes(&out, h.handle).Encode(numbers(v))
+	return out, err
+}
+
+func (h handleCodec) Decode(_ string, data []byte) ([]byte, error) {
+	var v any
+	if err := codec.NewDecoderBytes(data, h.handle).Decode(&v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+var mapType = reflect.TypeOf(map[string]any(nil))
+
+// MsgPack returns the MessagePack codec, application/msgpack.  Strings are
+// encoded in the current spec's str types.
+func MsgPack() Codec {
+	h := &codec.MsgpackHandle{WriteExt: true}
+	h.MapType = mapType
+	h.RawToString = true
+	return handleCodec{mediaType: "application/msgpack", handle: h}
+}
+
+// CBOR returns the CBOR codec, application/cbor (RFC 8949).
+func CBOR() Codec {
+	h := &codec.CborHandle{}
+	h.MapType = mapType
+	return handleCodec{mediaType: "application/cbor", handle: h}
+}
@@ -0,0 +1,63 @@
+package codec_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/codec"
+)
+
+func roundTrip(t *testing.T, c codec.Codec, schema, doc string) {
+	t.Helper()
+	encoded, err := c.Encode(schema, []byte(doc))
+	if err != nil {
+		t.Fatalf("%s: %v", c.MediaType(), err)
+	}
+	if json.Valid(encoded) {
+		t.Errorf("%s: expected a binary encoding, got JSON", c.MediaType())
+	}
+	back, err := c.Decode(schema, encoded)
+	if err != nil {
+		t.Fatalf("%s: %v", c.MediaType(), err)
+	}
+	var want, got any
+	json.Unmarshal([]byte(doc), &want)
+	json.Unmarshal(back, &got)
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("%s: round trip gave %s", c.MediaType(), back)
+	}
+}
+
+func TestRoundTrip_SchemaFree(t *testing.T) {
+	doc := `{"data":[{"id":1,"name":"Italy","rating":1834.5,"merged":false,"alias":null}],"links":[{"rel":"self"}]}`
+	for _, c := range []codec.Codec{codec.MsgPack(), codec.CBOR()} {
+		roundTrip(t, c, "", doc)
+	}
+}
+
+func TestRoundTrip_Protobuf(t *testing.T) {
+	c := codec.Protobuf()
+	roundTrip(t, c, "TeamsResponse", `{
+		"data": [{"id": 1, "name": "Italy", "slug": "italy", "createdAt": "2026-01-02T03:04:05Z", "updatedAt": "2026-01-02T03:04:05Z",
+			"changes": [{"field": "name", "old": "Itlay", "new": "Italy"}],
+			"links": [{"rel": "self", "href": "/api/v1/football/teams/1", "method": "GET"}]}],
+		"links": [{"rel": "self", "href": "/api/v1/football/teams", "method": "GET"}]
+	}`)
+	roundTrip(t, c, "CreateMatchRequest", `{"date": "2026-06-11T19:00:00Z", "homeTeamId": 1, "awayTeamId": 2, "homeScore": 2, "tournamentId": 3, "neutral": true}`)
+}
+
+func TestProtobuf_UnknownSchema(t *testing.T) {
+	if _, err := codec.Protobuf().Encode("EloRating", []byte(`{}`)); !errors.Is(err, codec.ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+}
+
+func TestDecode_RejectsGarbage(t *testing.T) {
+	for _, c := range codec.Default() {
+		if _, err := c.Decode("ErrorResponse", []byte{0xc1, 0xff}); err == nil {
+			t.Errorf("%s: expected an error for an invalid document", c.MediaType())
+		}
+	}
+}
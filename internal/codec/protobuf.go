@@ -0,0 +1,84 @@
+package codec
+
+import (
+	"context"
+	_ "embed"
+	"sync"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// FootballProto is the source of the messages the Protobuf codec uses,
+// served to clients that generate their types from it.
+//
+//go:embed proto/football.proto
+var FootballProto string
+
+// footballFile compiles FootballProto once.
+var footballFile = sync.OnceValues(func() (protoreflect.FileDescriptor, error) {
+	c := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{"football.proto": FootballProto}),
+		}),
+	}
+	files, err := c.Compile(context.Background(), "football.proto")
+	if err != nil {
+		return nil, err
+	}
+	return files[0], nil
+})
+
+// protobufCodec converts JSON documents to and from the message of
+// FootballProto named like their schema, through the proto3 JSON mapping.
+type protobufCodec struct {
+	file protoreflect.FileDescriptor
+}
+
+// Protobuf returns the Protocol Buffers codec, application/x-protobuf.  It
+// covers the documents FootballProto has a message for — the team and
+// match endpoints and errors — and returns ErrUnsupported for others.
+// JSON members the message lacks, such as the current resource of a 409,
+// are dropped.
+func Protobuf() Codec {
+	file, err := footballFile()
+	if err != nil {
+		panic("codec: compiling football.proto: " + err.Error())
+	}
+	return protobufCodec{file: file}
+}
+
+func (protobufCodec) MediaType() string { return "application/x-protobuf" }
+
+func (p protobufCodec) message(schema string) (*dynamicpb.Message, error) {
+	md := p.file.Messages().ByName(protoreflect.Name(schema))
+	if md == nil {
+		return nil, ErrUnsupported
+	}
+	return dynamicpb.NewMessage(md), nil
+}
+
+func (p protobufCodec) Encode(schema string, data []byte) ([]byte, error) {
+	msg, err := p.message(schema)
+	if err != nil {
+		return nil, err
+	}
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+	return proto.Marshal(msg)
+}
+
+func (p protobufCodec) Decode(schema string, data []byte) ([]byte, error) {
+	msg, err := p.message(schema)
+	if err != nil {
+		return nil, err
+	}
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+	return protojson.Marshal(msg)
+}
@@ -0,0 +1,220 @@
+package config
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AWSCredentials sign requests to AWS APIs.  On Lambda, ECS and EC2 with
+// an instance role exported to the environment they come from the standard
+// AWS_* variables; see AWSCredentialsFromEnv.
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Region          string
+}
+
+// AWSCredentialsFromEnv reads AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY,
+// AWS_SESSION_TOKEN and AWS_REGION.
+func AWSCredentialsFromEnv() AWSCredentials {
+	return AWSCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		Region:          os.Getenv("AWS_REGION"),
+	}
+}
+
+// AWSProvider reads secrets from AWS Secrets Manager or SSM Parameter
+// Store.  Like VaultProvider it fetches everything once, on first use.
+// Create one with NewSecretsManagerProvider or NewParameterStoreProvider.
+type AWSProvider struct {
+	// Endpoint overrides the regional service URL, for VPC endpoints and
+	// tests.
+	Endpoint string
+
+	creds   AWSCredentials
+	service string
+	client  *http.Client
+	fetch   func(p *AWSProvider) (map[string]string, error)
+
+	once sync.Once
+	data map[string]string
+	err  error
+}
+
+// NewSecretsManagerProvider reads the secret secretID, whose value is a
+// JSON object keyed by secret name ({"JWT_SECRET": "…", "DATABASE_URL": "…"}).
+func NewSecretsManagerProvider(creds AWSCredentials, secretID string) *AWSProvider {
+	return newAWSProvider(creds, "secretsmanager", func(p *AWSProvider) (map[string]string, error) {
+		var out struct {
+			SecretString string
+		}
+		if err := p.call("secretsmanager.GetSecretValue", map[string]string{"SecretId": secretID}, &out); err != nil {
+			return nil, err
+		}
+		var data map[string]string
+		if err := json.Unmarshal([]byte(out.SecretString), &data); err != nil {
+			return nil, fmt.Errorf("config: secret %s is not a JSON object of strings: %w", secretID, err)
+		}
+		return data, nil
+	})
+}
+
+// NewParameterStoreProvider reads the parameters directly under prefix
+// (e.g. "/football-api/prod"), decrypting SecureStrings; each parameter's
+// last path element is the secret name.
+func NewParameterStoreProvider(creds AWSCredentials, prefix string) *AWSProvider {
+	return newAWSProvider(creds, "ssm", func(p *AWSProvider) (map[string]string, error) {
+		data := make(map[string]string)
+		in := map[string]interface{}{"Path": prefix, "WithDecryption": true}
+		for {
+			var out struct {
+				Parameters []struct {
+					Name  string
+					Value string
+				}
+				NextToken string
+			}
+			if err := p.call("AmazonSSM.GetParametersByPath", in, &out); err != nil {
+				return nil, err
+			}
+			for _, param := range out.Parameters {
+				data[path.Base(param.Name)] = param.Value
+			}
+			if out.NextToken == "" {
+				return data, nil
+			}
+			in["NextToken"] = out.NextToken
+		}
+	})
+}
+
+func newAWSProvider(creds AWSCredentials, service string, fetch func(*AWSProvider) (map[string]string, error)) *AWSProvider {
+	return &AWSProvider{
+		creds:   creds,
+		service: service,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		fetch:   fetch,
+	}
+}
+
+// Secret implements SecretsProvider.
+func (p *AWSProvider) Secret(name string) (string, error) {
+	p.once.Do(func() { p.data, p.err = p.fetch(p) })
+	if p.err != nil {
+		return "", p.err
+	}
+	return p.data[name], nil
+}
+
+// call invokes an AWS JSON 1.1 API action such as
+// "secretsmanager.GetSecretValue".
+func (p *AWSProvider) call(target string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		if p.creds.Region == "" {
+			return errors.New("config: AWS_REGION is required to read secrets from AWS")
+		}
+		endpoint = "https://" + p.service + "." + p.creds.Region + ".amazonaws.com/"
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("config: %s request: %w", p.service, err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", target)
+	p.creds.sign(req, p.service, body, time.Now())
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("config: %s request: %w", p.service, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Type    string `json:"__type"`
+			Message string
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		return fmt.Errorf("config: %s returned %s: %s %s", target, resp.Status, apiErr.Type, apiErr.Message)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("config: decode %s response: %w", target, err)
+	}
+	return nil
+}
+
+// sign adds an AWS Signature Version 4 Authorization header to req.
+func (c AWSCredentials) sign(req *http.Request, service string, body []byte, now time.Time) {
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return
+	}
+	amzDate := now.UTC().Format("20060102T150405Z")
+	day := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if c.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.SessionToken)
+	}
+
+	names := []string{"content-type", "host", "x-amz-date"}
+	if c.SessionToken != "" {
+		names = append(names, "x-amz-security-token")
+	}
+	names = append(names, "x-amz-target")
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		v := req.Header.Get(name)
+		if name == "host" {
+			v = req.URL.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(v) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	uri := req.URL.EscapedPath()
+	if uri == "" {
+		uri = "/"
+	}
+	canonical := strings.Join([]string{
+		req.Method, uri, req.URL.RawQuery,
+		canonicalHeaders.String(), signedHeaders, sha256Hex(body),
+	}, "\n")
+	scope := day + "/" + c.Region + "/" + service + "/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonical))
+
+	key := []byte("AWS4" + c.SecretAccessKey)
+	for _, part := range []string{day, c.Region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
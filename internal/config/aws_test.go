@@ -0,0 +1,79 @@
+package config_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/config"
+)
+
+var testAWS = config.AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "session", Region: "eu-west-2"}
+
+// awsServer answers one X-Amz-Target with the responses in order, checking
+// that each request is signed.
+func awsServer(t *testing.T, target string, responses ...string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/eu-west-2/") ||
+			r.Header.Get("X-Amz-Security-Token") != "session" {
+			t.Errorf("unsigned request: Authorization=%q", auth)
+		}
+		if got := r.Header.Get("X-Amz-Target"); got != target {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"UnknownOperationException"}`))
+			return
+		}
+		if len(responses) == 0 {
+			t.Error("unexpected extra request")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(responses[0]))
+		responses = responses[1:]
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSecretsManagerProvider(t *testing.T) {
+	value, _ := json.Marshal(map[string]string{"JWT_SECRET": "from-secrets-manager"})
+	resp, _ := json.Marshal(map[string]string{"SecretString": string(value)})
+	srv := awsServer(t, "secretsmanager.GetSecretValue", string(resp))
+
+	p := config.NewSecretsManagerProvider(testAWS, "football-api")
+	p.Endpoint = srv.URL
+	if got, err := p.Secret("JWT_SECRET"); err != nil || got != "from-secrets-manager" {
+		t.Fatalf("expected from-secrets-manager, got %q, %v", got, err)
+	}
+	if got, _ := p.Secret("DATABASE_URL"); got != "" {
+		t.Fatalf("expected no DATABASE_URL, got %q", got)
+	}
+}
+
+func TestParameterStoreProvider_Pages(t *testing.T) {
+	srv := awsServer(t, "AmazonSSM.GetParametersByPath",
+		`{"Parameters":[{"Name":"/api/prod/JWT_SECRET","Value":"jwt"}],"NextToken":"more"}`,
+		`{"Parameters":[{"Name":"/api/prod/DATABASE_URL","Value":"postgres://ssm"}]}`)
+
+	p := config.NewParameterStoreProvider(testAWS, "/api/prod")
+	p.Endpoint = srv.URL
+	if got, _ := p.Secret("JWT_SECRET"); got != "jwt" {
+		t.Fatalf("expected jwt, got %q", got)
+	}
+	if got, _ := p.Secret("DATABASE_URL"); got != "postgres://ssm" {
+		t.Fatalf("expected the second page's value, got %q", got)
+	}
+}
+
+func TestAWSProvider_ErrorStatus(t *testing.T) {
+	srv := awsServer(t, "secretsmanager.GetSecretValue")
+	p := config.NewParameterStoreProvider(testAWS, "/api/prod")
+	p.Endpoint = srv.URL
+	if _, err := p.Secret("JWT_SECRET"); err == nil || !strings.Contains(err.Error(), "UnknownOperationException") {
+		t.Fatalf("expected the AWS error type, got %v", err)
+	}
+}
@@ -0,0 +1,22 @@
+package config
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+)
+
+// Purposes of keys derived with DeriveKey.  Each gives a different key, so
+// that one exposed key reveals nothing about the others.
+const (
+	PurposeLogPseudonyms   = "log-pseudonyms"
+	PurposeUsageAnalytics  = "usage-analytics"
+	PurposeFieldEncryption = "field-encryption"
+)
+
+// DeriveKey derives a 32-byte key for purpose from secret, for deployments
+// that configure only the JWT secret rather than a key for each purpose.
+func DeriveKey(secret, purpose string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,21 @@
+package config_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/config"
+)
+
+func TestDeriveKey(t *testing.T) {
+	a := config.DeriveKey("secret", config.PurposeLogPseudonyms)
+	if len(a) != 32 || !bytes.Equal(a, config.DeriveKey("secret", config.PurposeLogPseudonyms)) {
+		t.Fatalf("expected a stable 32-byte key, got %x", a)
+	}
+	if bytes.Equal(a, config.DeriveKey("secret", config.PurposeUsageAnalytics)) {
+		t.Error("expected keys for different purposes to differ")
+	}
+	if bytes.Equal(a, config.DeriveKey("other", config.PurposeLogPseudonyms)) {
+		t.Error("expected keys from different secrets to differ")
+	}
+}
@@ -0,0 +1,153 @@
+// Package config loads operational settings and secrets for the server.
+// Secrets are resolved through a SecretsProvider so that they can come from
+// environment variables, mounted files (Docker / Kubernetes secrets) or an
+// external store such as HashiCorp Vault without the rest of the program
+// knowing which.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SecretsProvider resolves a named secret such as "JWT_SECRET".  It returns
+// an empty string and a nil error when the provider has no value for name, so
+// that providers can be chained.
+type SecretsProvider interface {
+	Secret(name string) (string, error)
+}
+
+// EnvProvider reads secrets from the environment.  For a secret NAME it first
+// checks NAME_FILE and, if set, returns the contents of that file with
+// trailing newlines removed; otherwise it returns the value of NAME.  This
+// matches the convention used by Docker and Kubernetes secret mounts.
+type EnvProvider struct{}
+
+// Secret implements SecretsProvider.
+func (EnvProvider) Secret(name string) (string, error) {
+	if path := os.Getenv(name + "_FILE"); path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("config: read %s_FILE: %w", name, err)
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	}
+	return os.Getenv(name), nil
+}
+
+// Chain consults each provider in order and returns the first non-empty value.
+type Chain []SecretsProvider
+
+// Secret implements SecretsProvider.
+func (c Chain) Secret(name string) (string, error) {
+	for _, p := range c {
+		v, err := p.Secret(name)
+		if err != nil {
+			return "", err
+		}
+		if v != "" {
+			return v, nil
+		}
+	}
+	return "", nil
+}
+
+// VaultProvider reads secrets from a single HashiCorp Vault KV version 2
+// entry, whose keys are the secret names (e.g. JWT_SECRET, DATABASE_URL).
+// The entry is fetched once, on first use.
+type VaultProvider struct {
+	addr   string
+	token  string
+	path   string
+	client *http.Client
+
+	once sync.Once
+	data map[string]string
+	err  error
+}
+
+// NewVaultProvider creates a provider for the KV v2 entry at path (e.g.
+// "secret/data/football-api") on the Vault server at addr.
+func NewVaultProvider(addr, token, path string) *VaultProvider {
+	return &VaultProvider{
+		addr:   strings.TrimRight(addr, "/"),
+		token:  token,
+		path:   strings.Trim(path, "/"),
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Secret implements SecretsProvider.
+func (v *VaultProvider) Secret(name string) (string, error) {
+	v.once.Do(v.load)
+	if v.err != nil {
+		return "", v.err
+	}
+	return v.data[name], nil
+}
+
+// load fetches and decodes the KV v2 entry.
+func (v *VaultProvider) load() {
+	req, err := http.NewRequest(http.MethodGet, v.addr+"/v1/"+v.path, nil)
+	if err != nil {
+		v.err = fmt.Errorf("config: vault request: %w", err)
+		return
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		v.err = fmt.Errorf("config: vault request: %w", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		v.err = fmt.Errorf("config: vault returned %s for %s", resp.Status, v.path)
+		return
+	}
+
+	var body struct {
+		Data struct {
+			Data map[string]string `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		v.err = fmt.Errorf("config: decode vault response: %w", err)
+		return
+	}
+	if body.Data.Data == nil {
+		v.err = errors.New("config: vault entry has no data")
+		return
+	}
+	v.data = body.Data.Data
+}
+
+// SecretsFromEnv returns the provider chain configured by the environment:
+// Vault when VAULT_ADDR, VAULT_TOKEN and VAULT_SECRET_PATH are all set, AWS
+// Secrets Manager when AWS_SECRET_ID is set and SSM Parameter Store when
+// SSM_PARAMETER_PATH is set, in that order, then environment variables and
+// *_FILE mounts.
+func SecretsFromEnv() SecretsProvider {
+	var chain Chain
+	addr, token, path := os.Getenv("VAULT_ADDR"), os.Getenv("VAULT_TOKEN"), os.Getenv("VAULT_SECRET_PATH")
+	if addr != "" && token != "" && path != "" {
+		chain = append(chain, NewVaultProvider(addr, token, path))
+	}
+	if id := os.Getenv("AWS_SECRET_ID"); id != "" {
+		chain = append(chain, NewSecretsManagerProvider(AWSCredentialsFromEnv(), id))
+	}
+	if prefix := os.Getenv("SSM_PARAMETER_PATH"); prefix != "" {
+		chain = append(chain, NewParameterStoreProvider(AWSCredentialsFromEnv(), prefix))
+	}
+	if len(chain) == 0 {
+		return EnvProvider{}
+	}
+	return append(chain, EnvProvider{})
+}
@@ -0,0 +1,75 @@
+package config_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/config"
+)
+
+func TestEnvProvider_PrefersFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwt")
+	if err := os.WriteFile(path, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_SECRET", "from-env")
+	t.Setenv("TEST_SECRET_FILE", path)
+
+	got, err := config.EnvProvider{}.Secret("TEST_SECRET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "from-file" {
+		t.Fatalf("expected from-file, got %q", got)
+	}
+}
+
+func TestEnvProvider_FallsBackToEnv(t *testing.T) {
+	t.Setenv("TEST_SECRET", "from-env")
+	got, _ := config.EnvProvider{}.Secret("TEST_SECRET")
+	if got != "from-env" {
+		t.Fatalf("expected from-env, got %q", got)
+	}
+}
+
+func TestEnvProvider_MissingFile(t *testing.T) {
+	t.Setenv("TEST_SECRET_FILE", filepath.Join(t.TempDir(), "absent"))
+	if _, err := (config.EnvProvider{}).Secret("TEST_SECRET"); err == nil {
+		t.Fatal("expected error for unreadable secret file")
+	}
+}
+
+func TestVaultProvider_ReadsKVv2(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "tok" || r.URL.Path != "/v1/secret/data/api" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"data":{"JWT_SECRET":"vaulted"}}}`))
+	}))
+	defer srv.Close()
+
+	p := config.Chain{config.NewVaultProvider(srv.URL, "tok", "secret/data/api"), config.EnvProvider{}}
+	t.Setenv("DATABASE_URL", "postgres://env")
+
+	if got, _ := p.Secret("JWT_SECRET"); got != "vaulted" {
+		t.Fatalf("expected vaulted, got %q", got)
+	}
+	if got, _ := p.Secret("DATABASE_URL"); got != "postgres://env" {
+		t.Fatalf("expected env fallback, got %q", got)
+	}
+}
+
+func TestVaultProvider_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	if _, err := config.NewVaultProvider(srv.URL, "bad", "secret/data/api").Secret("JWT_SECRET"); err == nil {
+		t.Fatal("expected error on vault 403")
+	}
+}
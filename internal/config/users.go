@@ -0,0 +1,29 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+)
+
+// Usernames splits a comma-separated list of usernames, such as
+// ADMIN_USERS, and normalises each as accounts are, so that they match
+// however they were written.
+func Usernames(s string) []string {
+	var out []string
+	for _, name := range strings.Split(s, ",") {
+		if name = auth.NormalizeUsername(name); name != "" {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// NormalizeIdentities normalises the usernames m maps to, such as the
+// identities CLIENT_CERT_SUBJECTS gives certificate subjects, in place.
+func NormalizeIdentities(m map[string]string) map[string]string {
+	for k, name := range m {
+		m[k] = auth.NormalizeUsername(name)
+	}
+	return m
+}
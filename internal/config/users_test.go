@@ -0,0 +1,25 @@
+package config_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/config"
+)
+
+func TestUsernames(t *testing.T) {
+	got := config.Usernames(" Alice, ,BOB ,carol")
+	if want := []string{"alice", "bob", "carol"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if got := config.Usernames(""); got != nil {
+		t.Fatalf("expected nil for an empty list, got %q", got)
+	}
+}
+
+func TestNormalizeIdentities(t *testing.T) {
+	got := config.NormalizeIdentities(map[string]string{"Importer": "Svc-Importer", "ops": " Alice"})
+	if want := map[string]string{"Importer": "svc-importer", "ops": "alice"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
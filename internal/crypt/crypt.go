@@ -0,0 +1,129 @@
+// Package crypt encrypts individual values, such as a user's email address,
+// before they are stored, with AES-256-GCM.  A Keyring holds one current key,
+// used for everything sealed from now on, and any number of retired keys
+// that can still open what was sealed before a rotation.
+package crypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// prefix marks a sealed value: "enc:<key id>:<base64 nonce and ciphertext>".
+const prefix = "enc:"
+
+// ErrUnknownKey is returned by Open for a value sealed with a key that is
+// not on the keyring.
+var ErrUnknownKey = errors.New("crypt: value sealed with an unknown key")
+
+// Keyring seals values with its current key and opens values sealed with
+// any of its keys.
+type Keyring struct {
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+// ParseKeys reads a comma-separated list of id:key pairs, each key 32
+// bytes in standard base64.  The first key is the current one; the rest
+// are retired and kept only to open older values.
+func ParseKeys(s string) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]cipher.AEAD)}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(pair, ":")
+		if !ok || id == "" || encoded == "" {
+			return nil, fmt.Errorf("crypt: entry %q must have the form id:key", pair)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("crypt: key %q must be 32 bytes in base64", id)
+		}
+		if err := k.add(id, key); err != nil {
+			return nil, err
+		}
+	}
+	if k.current == "" {
+		return nil, errors.New("crypt: no keys")
+	}
+	return k, nil
+}
+
+// NewKeyring returns a keyring holding only key, a 32-byte key named id.
+func NewKeyring(id string, key []byte) (*Keyring, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("crypt: key %q must be 32 bytes", id)
+	}
+	k := &Keyring{keys: make(map[string]cipher.AEAD)}
+	if err := k.add(id, key); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+func (k *Keyring) add(id string, key []byte) error {
+	if _, dup := k.keys[id]; dup || strings.Contains(id, ":") {
+		return fmt.Errorf("crypt: key id %q is repeated or contains ':'", id)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return fmt.Errorf("crypt: key %q: %w", id, err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return fmt.Errorf("crypt: key %q: %w", id, err)
+	}
+	k.keys[id] = aead
+	if k.current == "" {
+		k.current = id
+	}
+	return nil
+}
+
+// Seal encrypts plaintext with the current key.
+func (k *Keyring) Seal(plaintext string) (string, error) {
+	aead := k.keys[k.current]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("crypt: nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return prefix + k.current + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value from Seal.  Values that were never sealed, such as
+// those stored before encryption was introduced, are returned unchanged.
+func (k *Keyring) Open(value string) (string, error) {
+	rest, ok := strings.CutPrefix(value, prefix)
+	if !ok {
+		return value, nil
+	}
+	id, encoded, _ := strings.Cut(rest, ":")
+	aead, ok := k.keys[id]
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownKey, id)
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("crypt: malformed value sealed with %q", id)
+	}
+	n := aead.NonceSize()
+	plaintext, err := aead.Open(nil, sealed[:n], sealed[n:], nil)
+	if err != nil {
+		return "", fmt.Errorf("crypt: open value sealed with %q: %w", id, err)
+	}
+	return string(plaintext), nil
+}
+
+// Stale reports whether value should be sealed again: it was never sealed,
+// or was sealed with a key other than the current one.
+func (k *Keyring) Stale(value string) bool {
+	return !strings.HasPrefix(value, prefix+k.current+":")
+}
@@ -0,0 +1,88 @@
+package crypt_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/crypt"
+)
+
+func key(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), 32)))
+}
+
+func TestKeyring_SealOpen(t *testing.T) {
+	k, err := crypt.ParseKeys("v1:" + key('a'))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := k.Seal("alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(sealed, "alice") || k.Stale(sealed) {
+		t.Fatalf("unexpected sealed value %q", sealed)
+	}
+	if again, _ := k.Seal("alice@example.com"); again == sealed {
+		t.Error("expected a fresh nonce for each seal")
+	}
+	if got, err := k.Open(sealed); err != nil || got != "alice@example.com" {
+		t.Fatalf("Open: got %q, %v", got, err)
+	}
+	if got, err := k.Open("plain@example.com"); err != nil || got != "plain@example.com" {
+		t.Fatalf("expected unsealed values unchanged, got %q, %v", got, err)
+	}
+	if !k.Stale("plain@example.com") {
+		t.Error("expected an unsealed value to be stale")
+	}
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	old, _ := crypt.ParseKeys("v1:" + key('a'))
+	sealed, _ := old.Seal("alice@example.com")
+
+	rotated, err := crypt.ParseKeys("v2:" + key('b') + ", v1:" + key('a'))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := rotated.Open(sealed); err != nil || got != "alice@example.com" {
+		t.Fatalf("expected a retired key to open old values, got %q, %v", got, err)
+	}
+	if !rotated.Stale(sealed) {
+		t.Error("expected a value sealed with a retired key to be stale")
+	}
+	resealed, _ := rotated.Seal("alice@example.com")
+	if !strings.HasPrefix(resealed, "enc:v2:") {
+		t.Errorf("expected the current key to seal, got %q", resealed)
+	}
+	if _, err := old.Open(resealed); !errors.Is(err, crypt.ErrUnknownKey) {
+		t.Errorf("expected ErrUnknownKey, got %v", err)
+	}
+}
+
+func TestParseKeys_Invalid(t *testing.T) {
+	for _, s := range []string{"", "v1", "v1:short", "v1:" + key('a') + ",v1:" + key('b')} {
+		if _, err := crypt.ParseKeys(s); err == nil {
+			t.Errorf("ParseKeys(%q): expected an error", s)
+		}
+	}
+}
+
+func TestKeyring_TamperedValue(t *testing.T) {
+	k, err := crypt.NewKeyring("v1", []byte(strings.Repeat("a", 32)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, _ := k.Seal("alice@example.com")
+	tampered := []byte(sealed)
+	if i := len(tampered) - 5; tampered[i] == 'A' {
+		tampered[i] = 'B'
+	} else {
+		tampered[i] = 'A'
+	}
+	if _, err := k.Open(string(tampered)); err == nil {
+		t.Error("expected a tampered value to be rejected")
+	}
+}
@@ -0,0 +1,807 @@
+package memory
+
+import (
+	"cmp"
+	"database/sql"
+	"fmt"
+	"maps"
+	"sort"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/elo"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/slug"
+)
+
+// FootballRepo implements db.FootballRepository on a Store.
+type FootballRepo struct{ s *Store }
+
+// AddTournament stores a tournament.  The API has no endpoint that creates
+// tournaments, so tests seed them directly.
+func (r *FootballRepo) AddTournament(name string) models.Tournament {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	t := models.Tournament{ID: r.s.id(), Name: name, CreatedAt: r.s.now()}
+	r.s.tournaments[t.ID] = t
+	return t
+}
+
+// AddFormerName records a former name of a team.
+func (r *FootballRepo) AddFormerName(fn models.FormerName) models.FormerName {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	fn.ID = r.s.id()
+	r.s.formerNames = append(r.s.formerNames, fn)
+	return fn
+}
+
+// --- Teams -------------------------------------------------------------------
+
+// ListTeams returns the teams matching f ordered alphabetically.
+func (r *FootballRepo) ListTeams(f models.TimeFilter) ([]models.Team, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	rows := r.s.teams
+	if f.AsOf != nil {
+		rows = r.s.teamHistory.asOf(rows, *f.AsOf)
+	}
+	var teams []models.Team
+	for _, t := range rows {
+		if f.Matches(t.CreatedAt, t.UpdatedAt) {
+			teams = append(teams, t)
+		}
+	}
+	sort.Slice(teams, func(i, j int) bool { return teams[i].Name < teams[j].Name })
+	return teams, nil
+}
+
+// GetTeamByID returns the team with the given ID.
+func (r *FootballRepo) GetTeamByID(id int) (models.Team, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	t, ok := r.s.teams[id]
+	if !ok {
+		return models.Team{}, models.ErrNotFound
+	}
+	return t, nil
+}
+
+// GetTeamAsOf returns team id as it was at at.
+func (r *FootballRepo) GetTeamAsOf(id int, at time.Time) (models.Team, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	t, ok := r.s.teamHistory.asOf(r.s.teams, at)[id]
+	if !ok {
+		return models.Team{}, models.ErrNotFound
+	}
+	return t, nil
+}
+
+// GetTeamByName returns the team with the given name.
+func (r *FootballRepo) GetTeamByName(name string) (models.Team, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, t := range r.s.teams {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	return models.Team{}, models.ErrNotFound
+}
+
+// GetTeamBySlug returns the team with the given slug.
+func (r *FootballRepo) GetTeamBySlug(slug string) (models.Team, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, t := range r.s.teams {
+		if t.Slug == slug {
+			return t, nil
+		}
+	}
+	return models.Team{}, models.ErrNotFound
+}
+
+// GetTeamHistory returns the former names recorded for a team, oldest first
+// and those without a start date last.
+func (r *FootballRepo) GetTeamHistory(teamID int) ([]models.FormerName, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var history []models.FormerName
+	for _, fn := range r.s.formerNames {
+		if fn.TeamID == teamID {
+			history = append(history, fn)
+		}
+	}
+	sort.SliceStable(history, func(i, j int) bool {
+		a, b := history[i].StartDate, history[j].StartDate
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return a.Before(*b)
+	})
+	return history, nil
+}
+
+// CreateTeam stores a new team.  Names are unique.
+func (r *FootballRepo) CreateTeam(name string) (models.Team, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if r.s.teamNameTaken(name, 0) {
+		return models.Team{}, models.ErrConflict
+	}
+	ts := r.s.now()
+	t := models.Team{ID: r.s.id(), Name: name, Slug: r.s.teamSlug(name, 0, ""), CreatedAt: ts, UpdatedAt: ts}
+	r.s.teamHistory.put(r.s.teams, t.ID, t, ts)
+	return t, nil
+}
+
+// UpdateTeam renames an existing team.
+func (r *FootballRepo) UpdateTeam(id int, name string) (models.Team, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	t, ok := r.s.teams[id]
+	if !ok {
+		return models.Team{}, models.ErrNotFound
+	}
+	if r.s.teamNameTaken(name, id) {
+		return models.Team{}, models.ErrConflict
+	}
+	t.Name, t.Slug, t.UpdatedAt = name, r.s.teamSlug(name, id, t.Slug), r.s.now()
+	r.s.teamHistory.put(r.s.teams, id, t, t.UpdatedAt)
+	return t, nil
+}
+
+// DeleteTeam removes a team.  Like the foreign keys in PostgreSQL, it
+// refuses to delete a team that matches, goals or shootouts still refer to.
+func (r *FootballRepo) DeleteTeam(id int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.teams[id]; !ok {
+		return models.ErrNotFound
+	}
+	for _, m := range r.s.matches {
+		if m.HomeTeamID == id || m.AwayTeamID == id {
+			return fmt.Errorf("memory.DeleteTeam: team %d is referenced by match %d", id, m.ID)
+		}
+	}
+	for _, g := range r.s.goals {
+		if g.TeamID == id {
+			return fmt.Errorf("memory.DeleteTeam: team %d is referenced by goal %d", id, g.ID)
+		}
+	}
+	for _, sh := range r.s.shootouts {
+		if sh.WinnerID == id {
+			return fmt.Errorf("memory.DeleteTeam: team %d is referenced by a shootout", id)
+		}
+	}
+	r.s.teamHistory.delete(r.s.teams, id, r.s.now())
+	for k := range r.s.eloCache {
+		if k.teamID == id {
+			delete(r.s.eloCache, k)
+		}
+	}
+	return nil
+}
+
+// TeamTombstone returns when team id was deleted, or ErrNotFound.
+func (r *FootballRepo) TeamTombstone(id int) (models.Tombstone, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	at, ok := r.s.teamHistory.deletedAt(r.s.teams, id)
+	if !ok {
+		return models.Tombstone{}, models.ErrNotFound
+	}
+	return models.Tombstone{ID: id, DeletedAt: at}, nil
+}
+
+// SetTeamTranslation sets the name of team id in language lang.
+func (r *FootballRepo) SetTeamTranslation(id int, lang, name string) (models.Team, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	t, ok := r.s.teams[id]
+	if !ok {
+		return models.Team{}, models.ErrNotFound
+	}
+	// Copy rather than modify the map, which earlier reads may share.
+	t.Translations = maps.Clone(t.Translations)
+	if t.Translations == nil {
+		t.Translations = map[string]string{}
+	}
+	t.Translations[lang], t.UpdatedAt = name, r.s.now()
+	r.s.teamHistory.put(r.s.teams, id, t, t.UpdatedAt)
+	return t, nil
+}
+
+// DeleteTeamTranslation removes the name of team id in language lang.
+func (r *FootballRepo) DeleteTeamTranslation(id int, lang string) (models.Team, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	t, ok := r.s.teams[id]
+	if !ok {
+		return models.Team{}, models.ErrNotFound
+	}
+	if _, ok := t.Translations[lang]; !ok {
+		return models.Team{}, models.ErrNotFound
+	}
+	t.Translations = maps.Clone(t.Translations)
+	delete(t.Translations, lang)
+	t.UpdatedAt = r.s.now()
+	r.s.teamHistory.put(r.s.teams, id, t, t.UpdatedAt)
+	return t, nil
+}
+
+func (s *Store) teamNameTaken(name string, except int) bool {
+	for _, t := range s.teams {
+		if t.Name == name && t.ID != except {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveTeamAlias returns the team that team id was merged into.
+func (r *FootballRepo) ResolveTeamAlias(id int) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	target, ok := r.s.teamAliases[id]
+	if !ok {
+		return 0, models.ErrNotFound
+	}
+	return target, nil
+}
+
+// MergeTeam folds team id into target.  See repository.Football.
+func (r *FootballRepo) MergeTeam(id, target int) (models.Team, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	src, ok := r.s.teams[id]
+	if !ok {
+		return models.Team{}, models.ErrNotFound
+	}
+	t, ok := r.s.teams[target]
+	if !ok {
+		return models.Team{}, models.ErrNotFound
+	}
+
+	// Work out every match's teams after the merge and refuse it before
+	// changing anything if two would collide.
+	remap := func(team int) int {
+		if team == id {
+			return target
+		}
+		return team
+	}
+	type fixture struct {
+		date       time.Time
+		home, away int
+	}
+	seen := make(map[fixture]bool, len(r.s.matches))
+	for _, m := range r.s.matches {
+		f := fixture{m.Date.UTC(), remap(m.HomeTeamID), remap(m.AwayTeamID)}
+		if f.home == f.away || seen[f] {
+			return models.Team{}, models.ErrConflict
+		}
+		seen[f] = true
+	}
+
+	ts := r.s.now()
+	for mid, m := range r.s.matches {
+		if m.HomeTeamID == id || m.AwayTeamID == id {
+			m.HomeTeamID, m.AwayTeamID, m.UpdatedAt = remap(m.HomeTeamID), remap(m.AwayTeamID), ts
+			r.s.matchHistory.put(r.s.matches, mid, m, ts)
+		}
+	}
+	for gid, g := range r.s.goals {
+		if g.TeamID == id {
+			g.TeamID = target
+			r.s.goals[gid] = g
+		}
+	}
+	for mid, sh := range r.s.shootouts {
+		if sh.WinnerID == id {
+			sh.WinnerID = target
+			r.s.shootouts[mid] = sh
+		}
+	}
+	for i := range r.s.formerNames {
+		if r.s.formerNames[i].TeamID == id {
+			r.s.formerNames[i].TeamID = target
+		}
+	}
+	r.s.formerNames = append(r.s.formerNames, models.FormerName{ID: r.s.id(), TeamID: target, FormerName: src.Name})
+	for from, to := range r.s.teamAliases {
+		if to == id {
+			r.s.teamAliases[from] = target
+		}
+	}
+	r.s.teamAliases[id] = target
+	r.s.teamHistory.delete(r.s.teams, id, ts)
+	// Ratings depend on every match, so the whole cache is stale.
+	clear(r.s.eloCache)
+
+	t.UpdatedAt = ts
+	r.s.teamHistory.put(r.s.teams, target, t, ts)
+	return t, nil
+}
+
+// teamSlug returns the slug for team id named name: its current slug if
+// name still yields it, or else the first free one.
+func (s *Store) teamSlug(name string, id int, current string) string {
+	base := cmp.Or(slug.Make(name), "team")
+	if current != "" && slug.Of(current, base) {
+		return current
+	}
+	return slug.Unique(base, func(sl string) bool {
+		for _, t := range s.teams {
+			if t.Slug == sl && t.ID != id {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// --- Tournaments -------------------------------------------------------------
+
+// GetTournamentByID returns the tournament with the given ID.
+func (r *FootballRepo) GetTournamentByID(id int) (models.Tournament, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	t, ok := r.s.tournaments[id]
+	if !ok {
+		return models.Tournament{}, models.ErrNotFound
+	}
+	return t, nil
+}
+
+// ListTournaments returns every tournament ordered by name.
+func (r *FootballRepo) ListTournaments() ([]models.Tournament, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var out []models.Tournament
+	for _, t := range r.s.tournaments {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// --- Matches -----------------------------------------------------------------
+
+// ListMatches returns a page of the matches matching f, newest first.
+func (r *FootballRepo) ListMatches(limit, offset int, f models.TimeFilter) ([]models.Match, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	keep := func(m models.Match) bool { return f.Matches(m.CreatedAt, m.UpdatedAt) }
+	var matches []models.Match
+	if f.AsOf != nil {
+		teams := r.s.teamHistory.asOf(r.s.teams, *f.AsOf)
+		for _, m := range r.s.matchHistory.asOf(r.s.matches, *f.AsOf) {
+			if keep(m) {
+				matches = append(matches, r.s.namesFrom(teams, m))
+			}
+		}
+	} else {
+		matches = r.s.filterMatches(keep)
+	}
+	sortByDateDesc(matches)
+	if offset >= len(matches) {
+		return nil, nil
+	}
+	matches = matches[offset:]
+	if limit >= 0 && limit < len(matches) {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// GetMatchByID returns the match with the given ID.
+func (r *FootballRepo) GetMatchByID(id int) (models.Match, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	m, ok := r.s.matches[id]
+	if !ok {
+		return models.Match{}, models.ErrNotFound
+	}
+	return r.s.withNames(m), nil
+}
+
+// GetMatchAsOf returns match id as it was at at, with the team names of
+// the time.
+func (r *FootballRepo) GetMatchAsOf(id int, at time.Time) (models.Match, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	m, ok := r.s.matchHistory.asOf(r.s.matches, at)[id]
+	if !ok {
+		return models.Match{}, models.ErrNotFound
+	}
+	return r.s.namesFrom(r.s.teamHistory.asOf(r.s.teams, at), m), nil
+}
+
+// GetHeadToHead returns every match between two teams, newest first.
+func (r *FootballRepo) GetHeadToHead(teamA, teamB int) ([]models.Match, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	matches := r.s.filterMatches(func(m models.Match) bool {
+		return (m.HomeTeamID == teamA && m.AwayTeamID == teamB) ||
+			(m.HomeTeamID == teamB && m.AwayTeamID == teamA)
+	})
+	sortByDateDesc(matches)
+	return matches, nil
+}
+
+// CreateMatch stores a new match.  A second match between the same home and
+// away teams on the same date is a conflict.
+func (r *FootballRepo) CreateMatch(m models.Match) (models.Match, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if err := r.s.checkMatch(m, 0); err != nil {
+		return models.Match{}, err
+	}
+	ts := r.s.now()
+	m.ID, m.CreatedAt, m.UpdatedAt = r.s.id(), ts, ts
+	r.s.matchHistory.put(r.s.matches, m.ID, m, ts)
+	return r.s.withNames(m), nil
+}
+
+// UpdateMatch replaces the fields of an existing match.
+func (r *FootballRepo) UpdateMatch(id int, m models.Match) (models.Match, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	old, ok := r.s.matches[id]
+	if !ok {
+		return models.Match{}, models.ErrNotFound
+	}
+	if err := r.s.checkMatch(m, id); err != nil {
+		return models.Match{}, err
+	}
+	m.ID, m.CreatedAt, m.UpdatedAt = id, old.CreatedAt, r.s.now()
+	r.s.matchHistory.put(r.s.matches, id, m, m.UpdatedAt)
+	return r.s.withNames(m), nil
+}
+
+// DeleteMatch removes a match, leaving a tombstone for sync clients and
+// pruning tombstones older than db.TombstoneRetention.
+func (r *FootballRepo) DeleteMatch(id int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.matches[id]; !ok {
+		return models.ErrNotFound
+	}
+	for _, g := range r.s.goals {
+		if g.MatchID == id {
+			return fmt.Errorf("memory.DeleteMatch: match %d is referenced by goal %d", id, g.ID)
+		}
+	}
+	if _, ok := r.s.shootouts[id]; ok {
+		return fmt.Errorf("memory.DeleteMatch: match %d is referenced by a shootout", id)
+	}
+	ts := r.s.now()
+	r.s.matchHistory.delete(r.s.matches, id, ts)
+	r.s.tombstones[id] = ts
+	for mid, at := range r.s.tombstones {
+		if at.Before(ts.Add(-db.TombstoneRetention)) {
+			delete(r.s.tombstones, mid)
+		}
+	}
+	return nil
+}
+
+// MatchTombstone returns when match id was deleted, or ErrNotFound.
+func (r *FootballRepo) MatchTombstone(id int) (models.Tombstone, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	at, ok := r.s.matchHistory.deletedAt(r.s.matches, id)
+	if !ok {
+		return models.Tombstone{}, models.ErrNotFound
+	}
+	return models.Tombstone{ID: id, DeletedAt: at}, nil
+}
+
+// MatchChangesSince returns the matches created or updated, and the
+// tombstones of those deleted, at or after since, oldest change first.
+func (r *FootballRepo) MatchChangesSince(since time.Time) (models.MatchChanges, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	ch := models.MatchChanges{AsOf: r.s.now()}
+	ch.Changed = r.s.filterMatches(func(m models.Match) bool { return !m.UpdatedAt.Before(since) })
+	sort.Slice(ch.Changed, func(i, j int) bool {
+		a, b := ch.Changed[i], ch.Changed[j]
+		if !a.UpdatedAt.Equal(b.UpdatedAt) {
+			return a.UpdatedAt.Before(b.UpdatedAt)
+		}
+		return a.ID < b.ID
+	})
+	for id, at := range r.s.tombstones {
+		if !at.Before(since) {
+			ch.Deleted = append(ch.Deleted, models.Tombstone{ID: id, DeletedAt: at})
+		}
+	}
+	sort.Slice(ch.Deleted, func(i, j int) bool {
+		a, b := ch.Deleted[i], ch.Deleted[j]
+		if !a.DeletedAt.Equal(b.DeletedAt) {
+			return a.DeletedAt.Before(b.DeletedAt)
+		}
+		return a.ID < b.ID
+	})
+	return ch, nil
+}
+
+// checkMatch enforces the foreign keys and the (date, home, away) unique
+// constraint on m, ignoring the match being replaced.
+func (s *Store) checkMatch(m models.Match, except int) error {
+	for _, id := range []int{m.HomeTeamID, m.AwayTeamID} {
+		if _, ok := s.teams[id]; !ok {
+			return fmt.Errorf("memory: team %d does not exist", id)
+		}
+	}
+	if _, ok := s.tournaments[m.TournamentID]; !ok {
+		return fmt.Errorf("memory: tournament %d does not exist", m.TournamentID)
+	}
+	for _, other := range s.matches {
+		if other.ID != except && other.HomeTeamID == m.HomeTeamID &&
+			other.AwayTeamID == m.AwayTeamID && other.Date.Equal(m.Date) {
+			return models.ErrConflict
+		}
+	}
+	return nil
+}
+
+// filterMatches returns the matches satisfying keep with team and
+// tournament names filled in, in no particular order.
+func (s *Store) filterMatches(keep func(models.Match) bool) []models.Match {
+	var out []models.Match
+	for _, m := range s.matches {
+		if keep(m) {
+			out = append(out, s.withNames(m))
+		}
+	}
+	return out
+}
+
+// withNames fills in the names PostgreSQL would join in.
+func (s *Store) withNames(m models.Match) models.Match {
+	return s.namesFrom(s.teams, m)
+}
+
+// namesFrom fills in the names PostgreSQL would join in, taking team names
+// from teams.
+func (s *Store) namesFrom(teams map[int]models.Team, m models.Match) models.Match {
+	m.HomeTeam = teams[m.HomeTeamID].Name
+	m.AwayTeam = teams[m.AwayTeamID].Name
+	m.Tournament = s.tournaments[m.TournamentID].Name
+	return m
+}
+
+// sortByDateDesc orders matches newest first.  Ties, which PostgreSQL leaves
+// unordered, are broken by ID so results are deterministic.
+func sortByDateDesc(matches []models.Match) {
+	sort.Slice(matches, func(i, j int) bool {
+		if !matches[i].Date.Equal(matches[j].Date) {
+			return matches[i].Date.After(matches[j].Date)
+		}
+		return matches[i].ID < matches[j].ID
+	})
+}
+
+// --- Goals & shootouts -------------------------------------------------------
+
+// GetMatchGoals returns the goals of a match in the order they were recorded.
+func (r *FootballRepo) GetMatchGoals(matchID int) ([]models.Goal, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return r.s.filterGoals(func(g models.Goal) bool { return g.MatchID == matchID }, func(a, b models.Goal) bool {
+		return a.ID < b.ID
+	}), nil
+}
+
+// GetMatchShootout returns the shootout of a match.
+func (r *FootballRepo) GetMatchShootout(matchID int) (models.Shootout, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	sh, ok := r.s.shootouts[matchID]
+	if !ok {
+		return models.Shootout{}, models.ErrNotFound
+	}
+	sh.Winner = r.s.teams[sh.WinnerID].Name
+	return sh, nil
+}
+
+// GetPlayerGoals returns every goal scored by the named player, by match.
+func (r *FootballRepo) GetPlayerGoals(scorer string) ([]models.Goal, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return r.s.filterGoals(func(g models.Goal) bool { return g.Scorer == scorer }, func(a, b models.Goal) bool {
+		if a.MatchID != b.MatchID {
+			return a.MatchID < b.MatchID
+		}
+		return a.ID < b.ID
+	}), nil
+}
+
+// CreateGoal records a goal.
+func (r *FootballRepo) CreateGoal(g models.Goal) (models.Goal, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.matches[g.MatchID]; !ok {
+		return models.Goal{}, fmt.Errorf("memory: match %d does not exist", g.MatchID)
+	}
+	if _, ok := r.s.teams[g.TeamID]; !ok {
+		return models.Goal{}, fmt.Errorf("memory: team %d does not exist", g.TeamID)
+	}
+	for _, other := range r.s.goals {
+		if other.MatchID == g.MatchID && other.TeamID == g.TeamID && other.Scorer == g.Scorer &&
+			other.OwnGoal == g.OwnGoal && other.Penalty == g.Penalty {
+			return models.Goal{}, fmt.Errorf("memory: duplicate goal %d", other.ID)
+		}
+	}
+	g.ID = r.s.id()
+	r.s.goals[g.ID] = g
+	return g, nil
+}
+
+// DeleteGoal removes a goal.
+func (r *FootballRepo) DeleteGoal(id int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.goals[id]; !ok {
+		return models.ErrNotFound
+	}
+	delete(r.s.goals, id)
+	return nil
+}
+
+// CreateShootout records the shootout of a match; a match has at most one.
+func (r *FootballRepo) CreateShootout(sh models.Shootout) (models.Shootout, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.shootouts[sh.MatchID]; ok {
+		return models.Shootout{}, models.ErrConflict
+	}
+	if _, ok := r.s.matches[sh.MatchID]; !ok {
+		return models.Shootout{}, fmt.Errorf("memory: match %d does not exist", sh.MatchID)
+	}
+	if _, ok := r.s.teams[sh.WinnerID]; !ok {
+		return models.Shootout{}, fmt.Errorf("memory: team %d does not exist", sh.WinnerID)
+	}
+	sh.ID = r.s.id()
+	r.s.shootouts[sh.MatchID] = sh
+	return sh, nil
+}
+
+// DeleteShootout removes the shootout of a match.
+func (r *FootballRepo) DeleteShootout(matchID int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.shootouts[matchID]; !ok {
+		return models.ErrNotFound
+	}
+	delete(r.s.shootouts, matchID)
+	return nil
+}
+
+func (s *Store) filterGoals(keep func(models.Goal) bool, less func(a, b models.Goal) bool) []models.Goal {
+	var out []models.Goal
+	for _, g := range s.goals {
+		if keep(g) {
+			g.Team = s.teams[g.TeamID].Name
+			out = append(out, g)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return less(out[i], out[j]) })
+	return out
+}
+
+// --- Elo ---------------------------------------------------------------------
+
+// GetMatchesChronological returns the matches involving teamID (every match
+// when teamID is 0) up to and including endDate, oldest first.
+func (r *FootballRepo) GetMatchesChronological(teamID int, endDate time.Time) ([]elo.MatchResult, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	matches := r.s.filterMatches(func(m models.Match) bool {
+		return !m.Date.After(endDate) && (teamID == 0 || m.HomeTeamID == teamID || m.AwayTeamID == teamID)
+	})
+	sort.Slice(matches, func(i, j int) bool {
+		if !matches[i].Date.Equal(matches[j].Date) {
+			return matches[i].Date.Before(matches[j].Date)
+		}
+		return matches[i].ID < matches[j].ID
+	})
+	results := make([]elo.MatchResult, 0, len(matches))
+	for _, m := range matches {
+		results = append(results, elo.MatchResult{
+			MatchID:    m.ID,
+			Date:       m.Date,
+			HomeTeamID: m.HomeTeamID,
+			AwayTeamID: m.AwayTeamID,
+			HomeScore:  m.HomeScore,
+			AwayScore:  m.AwayScore,
+			Tournament: m.Tournament,
+			Neutral:    m.Neutral,
+		})
+	}
+	return results, nil
+}
+
+// GetEloRankings returns a page of the cached ratings for exactly asOf,
+// highest first.  region is ignored, as in PostgreSQL.
+func (r *FootballRepo) GetEloRankings(asOf time.Time, _ string, limit, offset int) ([]elo.RankingEntry, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var entries []elo.RankingEntry
+	for k, snap := range r.s.eloCache {
+		if k.asOf.Equal(asOf) {
+			entries = append(entries, elo.RankingEntry{
+				Rank:     snap.rank,
+				TeamID:   k.teamID,
+				TeamName: r.s.teams[k.teamID].Name,
+				Elo:      snap.rating,
+			})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Elo != entries[j].Elo {
+			return entries[i].Elo > entries[j].Elo
+		}
+		return entries[i].TeamID < entries[j].TeamID
+	})
+	if offset >= len(entries) {
+		return nil, nil
+	}
+	entries = entries[offset:]
+	if limit >= 0 && limit < len(entries) {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// GetTeamCachedElo returns the latest cached rating for a team on or before
+// asOf, or sql.ErrNoRows when there is none.
+func (r *FootballRepo) GetTeamCachedElo(teamID int, asOf time.Time) (float64, int, int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	snap, ok := r.s.latestElo(teamID, asOf, false)
+	if !ok {
+		return 0, 0, 0, sql.ErrNoRows
+	}
+	return snap.rating, snap.rank, snap.matchesPlayed, nil
+}
+
+// GetTeamCachedRank returns the latest cached rank for a team on or before
+// asOf, or 0 when there is none.
+func (r *FootballRepo) GetTeamCachedRank(teamID int, asOf time.Time) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	snap, _ := r.s.latestElo(teamID, asOf, true)
+	return snap.rank, nil
+}
+
+// SaveEloSnapshot upserts the cached rating of a team on a date.
+func (r *FootballRepo) SaveEloSnapshot(teamID int, asOf time.Time, rating float64, rank int, matchesPlayed int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.teams[teamID]; !ok {
+		return fmt.Errorf("memory: team %d does not exist", teamID)
+	}
+	r.s.eloCache[eloKey{teamID, asOf}] = eloSnapshot{rating: rating, rank: rank, matchesPlayed: matchesPlayed}
+	return nil
+}
+
+func (s *Store) latestElo(teamID int, asOf time.Time, ranked bool) (eloSnapshot, bool) {
+	var (
+		best   eloSnapshot
+		bestAt time.Time
+		found  bool
+	)
+	for k, snap := range s.eloCache {
+		if k.teamID != teamID || k.asOf.After(asOf) || (ranked && snap.rank == 0) {
+			continue
+		}
+		if !found || k.asOf.After(bestAt) {
+			best, bestAt, found = snap, k.asOf, true
+		}
+	}
+	return best, found
+}
@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"maps"
+	"time"
+)
+
+// revision records a row as it was before a change at at; existed is false
+// when the change inserted it.
+type revision[T any] struct {
+	id      int
+	at      time.Time
+	old     T
+	existed bool
+}
+
+// history is the revision history of one table, oldest first, standing in
+// for the football_revisions triggers.  The store starts empty, so unlike in
+// PostgreSQL every earlier state can be reconstructed.
+type history[T any] []revision[T]
+
+// put records the change of row id in rows at at and makes it v.
+func (h *history[T]) put(rows map[int]T, id int, v T, at time.Time) {
+	h.record(rows, id, at)
+	rows[id] = v
+}
+
+// delete records the deletion of row id from rows at at and deletes it.
+func (h *history[T]) delete(rows map[int]T, id int, at time.Time) {
+	h.record(rows, id, at)
+	delete(rows, id)
+}
+
+// deletedAt returns when row id, which is not in rows, was deleted, or
+// false if no deletion of it is recorded.
+func (h history[T]) deletedAt(rows map[int]T, id int) (time.Time, bool) {
+	if _, ok := rows[id]; ok {
+		return time.Time{}, false
+	}
+	for i := len(h) - 1; i >= 0; i-- {
+		if h[i].id == id {
+			return h[i].at, h[i].existed
+		}
+	}
+	return time.Time{}, false
+}
+
+func (h *history[T]) record(rows map[int]T, id int, at time.Time) {
+	old, ok := rows[id]
+	*h = append(*h, revision[T]{id: id, at: at, old: old, existed: ok})
+}
+
+// asOf returns rows as they were at at, by undoing the later changes
+// newest first.
+func (h history[T]) asOf(rows map[int]T, at time.Time) map[int]T {
+	out := maps.Clone(rows)
+	for i := len(h) - 1; i >= 0; i-- {
+		if r := h[i]; !r.at.After(at) {
+			continue
+		} else if r.existed {
+			out[r.id] = r.old
+		} else {
+			delete(out, r.id)
+		}
+	}
+	return out
+}
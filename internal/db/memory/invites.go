@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"sort"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// InviteRepo implements db.InviteRepository on a Store.
+type InviteRepo struct{ s *Store }
+
+// CreateInvite stores an unused invite.
+func (r *InviteRepo) CreateInvite(inv models.Invite) (models.Invite, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.invites[inv.Code]; ok {
+		return models.Invite{}, models.ErrConflict
+	}
+	inv.Uses, inv.CreatedAt = 0, r.s.now()
+	r.s.invites[inv.Code] = inv
+	return inv, nil
+}
+
+// ListInvites returns every invite, newest first.
+func (r *InviteRepo) ListInvites() ([]models.Invite, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	invites := make([]models.Invite, 0, len(r.s.invites))
+	for _, inv := range r.s.invites {
+		invites = append(invites, inv)
+	}
+	sort.Slice(invites, func(i, j int) bool {
+		if !invites[i].CreatedAt.Equal(invites[j].CreatedAt) {
+			return invites[i].CreatedAt.After(invites[j].CreatedAt)
+		}
+		return invites[i].Code < invites[j].Code
+	})
+	return invites, nil
+}
+
+// RedeemInvite takes one use of an unexpired invite with uses left.
+func (r *InviteRepo) RedeemInvite(code string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	inv, ok := r.s.invites[code]
+	if !ok || !inv.ExpiresAt.After(r.s.now()) || inv.Uses >= inv.MaxUses {
+		return models.ErrNotFound
+	}
+	inv.Uses++
+	r.s.invites[code] = inv
+	return nil
+}
+
+// ReleaseInvite gives back one use of the invite.
+func (r *InviteRepo) ReleaseInvite(code string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	inv, ok := r.s.invites[code]
+	if !ok {
+		return models.ErrNotFound
+	}
+	if inv.Uses > 0 {
+		inv.Uses--
+		r.s.invites[code] = inv
+	}
+	return nil
+}
+
+// DeleteInvite removes an invite.
+func (r *InviteRepo) DeleteInvite(code string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.invites[code]; !ok {
+		return models.ErrNotFound
+	}
+	delete(r.s.invites, code)
+	return nil
+}
@@ -0,0 +1,136 @@
+// Package memory provides in-memory implementations of the repository
+// interfaces in the db package.  They mirror the PostgreSQL repositories'
+// observable behaviour — ordering, conflict and not-found errors, timestamps
+// — closely enough to back handler and end-to-end tests without a database.
+// They are not intended for production use.
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// Store holds every table in memory behind one lock.  The zero value is not
+// usable; create one with New.
+type Store struct {
+	mu    sync.Mutex
+	clock clock.Clock
+
+	teams        map[int]models.Team
+	teamHistory  history[models.Team]
+	teamAliases  map[int]int // merged team ID → surviving team ID
+	formerNames  []models.FormerName
+	tournaments  map[int]models.Tournament
+	matches      map[int]models.Match
+	matchHistory history[models.Match]
+	tombstones   map[int]time.Time
+	goals        map[int]models.Goal
+	shootouts    map[int]models.Shootout // keyed by match ID
+	eloCache     map[eloKey]eloSnapshot
+
+	users         map[string]models.User // keyed by normalised username
+	sessions      map[string]models.Session
+	logins        map[string][]models.Login // username → sign-ins, oldest first
+	invites       map[string]models.Invite
+	oauthClients  map[string]models.OAuthClient
+	oauthGrants   map[string]models.OAuthGrant    // keyed by grant ID
+	terms         map[string]map[string]time.Time // username → version → accepted at
+	prefs         map[string]map[string]any
+	inbox         map[string][]models.Notification // username → notifications
+	announcements map[int]models.Announcement
+	moderation    map[moderationKey]models.ModerationItem
+	reports       []models.ContentReport
+	usage         map[usageKey]models.UsageRecord
+
+	nextID int
+}
+
+type eloKey struct {
+	teamID int
+	asOf   time.Time
+}
+
+type eloSnapshot struct {
+	rating        float64
+	rank          int
+	matchesPlayed int
+}
+
+// New returns an empty Store.
+func New() *Store {
+	return &Store{
+		clock:         clock.System{},
+		teams:         map[int]models.Team{},
+		teamAliases:   map[int]int{},
+		tournaments:   map[int]models.Tournament{},
+		matches:       map[int]models.Match{},
+		tombstones:    map[int]time.Time{},
+		goals:         map[int]models.Goal{},
+		shootouts:     map[int]models.Shootout{},
+		eloCache:      map[eloKey]eloSnapshot{},
+		users:         map[string]models.User{},
+		sessions:      map[string]models.Session{},
+		logins:        map[string][]models.Login{},
+		invites:       map[string]models.Invite{},
+		oauthClients:  map[string]models.OAuthClient{},
+		oauthGrants:   map[string]models.OAuthGrant{},
+		terms:         map[string]map[string]time.Time{},
+		prefs:         map[string]map[string]any{},
+		inbox:         map[string][]models.Notification{},
+		announcements: map[int]models.Announcement{},
+		moderation:    map[moderationKey]models.ModerationItem{},
+		usage:         map[usageKey]models.UsageRecord{},
+	}
+}
+
+// Repositories returns the store as the repository set the router serves
+// from.
+func (s *Store) Repositories() *db.Repositories {
+	return &db.Repositories{
+		Football:      s.Football(),
+		Users:         &UserRepo{s},
+		Sessions:      &SessionRepo{s},
+		Logins:        &LoginRepo{s},
+		Invites:       &InviteRepo{s},
+		OAuth:         &OAuthRepo{s},
+		Terms:         &TermsRepo{s},
+		Preferences:   &PreferencesRepo{s},
+		Notifications: &NotificationRepo{s},
+		Announcements: &AnnouncementRepo{s},
+		Moderation:    &ModerationRepo{s},
+		Metering:      &MeteringRepo{s},
+	}
+}
+
+// Football returns the store's football repository.
+func (s *Store) Football() *FootballRepo { return &FootballRepo{s} }
+
+// id returns the next identifier.  IDs are unique across tables, which is
+// harmless and makes mix-ups between them show up in tests.
+func (s *Store) id() int {
+	s.nextID++
+	return s.nextID
+}
+
+// SetClock makes the store stamp records with c instead of the wall clock,
+// so tests can assert exact timestamps.  Call it before using the store.
+func (s *Store) SetClock(c clock.Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = clock.Or(c)
+}
+
+// now returns the current time in UTC, as the PostgreSQL repositories read
+// timestamps back.
+func (s *Store) now() time.Time { return s.clock.Now().UTC() }
+
+// Compile-time interface checks.
+var (
+	_ db.FootballRepository = (*FootballRepo)(nil)
+	_ db.UserRepository     = (*UserRepo)(nil)
+	_ db.SessionRepository  = (*SessionRepo)(nil)
+)
@@ -0,0 +1,346 @@
+package memory_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/memory"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+func TestFootballRepo_TeamConflicts(t *testing.T) {
+	repo := memory.New().Football()
+	eng, _ := repo.CreateTeam("England")
+	ger, _ := repo.CreateTeam("Germany")
+
+	if _, err := repo.CreateTeam("England"); !errors.Is(err, models.ErrConflict) {
+		t.Fatalf("duplicate create: expected ErrConflict, got %v", err)
+	}
+	if _, err := repo.UpdateTeam(ger.ID, "England"); !errors.Is(err, models.ErrConflict) {
+		t.Fatalf("rename onto existing: expected ErrConflict, got %v", err)
+	}
+	if _, err := repo.UpdateTeam(eng.ID, "England"); err != nil {
+		t.Fatalf("rename to own name: %v", err)
+	}
+	if _, err := repo.UpdateTeam(999, "Spain"); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("missing team: expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestFootballRepo_TeamSlugs(t *testing.T) {
+	repo := memory.New().Football()
+	civ, _ := repo.CreateTeam("C\u00f4te d'Ivoire")
+	dup, _ := repo.CreateTeam("Cote d'Ivoire")
+	if civ.Slug != "cote-d-ivoire" || dup.Slug != "cote-d-ivoire-2" {
+		t.Fatalf("slugs = %q, %q; want cote-d-ivoire, cote-d-ivoire-2", civ.Slug, dup.Slug)
+	}
+
+	// A rename that yields the same slug keeps it; another takes a new one.
+	dup, _ = repo.UpdateTeam(dup.ID, "COTE D'IVOIRE")
+	if dup.Slug != "cote-d-ivoire-2" {
+		t.Fatalf("slug after case change = %q, want cote-d-ivoire-2", dup.Slug)
+	}
+	dup, _ = repo.UpdateTeam(dup.ID, "Ivory Coast")
+	if dup.Slug != "ivory-coast" {
+		t.Fatalf("slug after rename = %q, want ivory-coast", dup.Slug)
+	}
+	if got, err := repo.GetTeamBySlug("ivory-coast"); err != nil || got.ID != dup.ID {
+		t.Fatalf("GetTeamBySlug = %+v, %v", got, err)
+	}
+	if _, err := repo.GetTeamBySlug("cote-d-ivoire-2"); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("old slug: expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestFootballRepo_MatchesOrderAndTombstones(t *testing.T) {
+	repo := memory.New().Football()
+	eng, _ := repo.CreateTeam("England")
+	ger, _ := repo.CreateTeam("Germany")
+	cup := repo.AddTournament("FIFA World Cup")
+	day := func(d int) time.Time { return time.Date(1990, 7, d, 0, 0, 0, 0, time.UTC) }
+
+	first, _ := repo.CreateMatch(models.Match{Date: day(1), HomeTeamID: eng.ID, AwayTeamID: ger.ID, TournamentID: cup.ID})
+	second, _ := repo.CreateMatch(models.Match{Date: day(4), HomeTeamID: eng.ID, AwayTeamID: ger.ID, TournamentID: cup.ID})
+	if _, err := repo.CreateMatch(models.Match{Date: day(4), HomeTeamID: eng.ID, AwayTeamID: ger.ID, TournamentID: cup.ID}); !errors.Is(err, models.ErrConflict) {
+		t.Fatalf("duplicate match: expected ErrConflict, got %v", err)
+	}
+
+	list, _ := repo.ListMatches(10, 0, models.TimeFilter{})
+	if len(list) != 2 || list[0].ID != second.ID || list[0].HomeTeam != "England" {
+		t.Fatalf("expected newest first with names, got %+v", list)
+	}
+
+	since := time.Now().UTC()
+	if err := repo.DeleteMatch(first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.DeleteTeam(eng.ID); err == nil {
+		t.Fatal("expected deleting a referenced team to fail")
+	}
+	ch, _ := repo.MatchChangesSince(since)
+	if len(ch.Deleted) != 1 || ch.Deleted[0].ID != first.ID {
+		t.Fatalf("expected a tombstone for match %d, got %+v", first.ID, ch.Deleted)
+	}
+}
+
+func TestFootballRepo_MergeTeam(t *testing.T) {
+	repo := memory.New().Football()
+	fed, _ := repo.CreateTeam("West Germany")
+	ger, _ := repo.CreateTeam("Germany")
+	eng, _ := repo.CreateTeam("England")
+	old, _ := repo.CreateTeam("Germany FR")
+	cup := repo.AddTournament("FIFA World Cup")
+	day := func(d int) time.Time { return time.Date(1990, 7, d, 0, 0, 0, 0, time.UTC) }
+	semi, _ := repo.CreateMatch(models.Match{Date: day(4), HomeTeamID: fed.ID, AwayTeamID: eng.ID, TournamentID: cup.ID})
+	if _, err := repo.CreateGoal(models.Goal{MatchID: semi.ID, TeamID: fed.ID, Scorer: "Brehme"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repo.MergeTeam(old.ID, fed.ID); err != nil {
+		t.Fatalf("first merge: %v", err)
+	}
+	merged, err := repo.MergeTeam(fed.ID, ger.ID)
+	if err != nil || merged.ID != ger.ID {
+		t.Fatalf("MergeTeam = %+v, %v", merged, err)
+	}
+	if m, _ := repo.GetMatchByID(semi.ID); m.HomeTeamID != ger.ID || m.HomeTeam != "Germany" {
+		t.Fatalf("match not moved: %+v", m)
+	}
+	if goals, _ := repo.GetMatchGoals(semi.ID); len(goals) != 1 || goals[0].TeamID != ger.ID {
+		t.Fatalf("goal not moved: %+v", goals)
+	}
+	history, _ := repo.GetTeamHistory(ger.ID)
+	if len(history) != 2 {
+		t.Fatalf("expected both merged names in the history, got %+v", history)
+	}
+	// The alias from the first merge follows the second, one hop.
+	for _, id := range []int{fed.ID, old.ID} {
+		if target, err := repo.ResolveTeamAlias(id); err != nil || target != ger.ID {
+			t.Fatalf("ResolveTeamAlias(%d) = %d, %v; want %d", id, target, err, ger.ID)
+		}
+	}
+	if _, err := repo.GetTeamByID(fed.ID); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("merged team: expected ErrNotFound, got %v", err)
+	}
+
+	if _, err := repo.MergeTeam(eng.ID, ger.ID); !errors.Is(err, models.ErrConflict) {
+		t.Fatalf("teams that played each other: expected ErrConflict, got %v", err)
+	}
+	if _, err := repo.MergeTeam(eng.ID, fed.ID); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("merged-away target: expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestFootballRepo_TeamTranslations(t *testing.T) {
+	repo := memory.New().Football()
+	ger, _ := repo.CreateTeam("Germany")
+
+	got, err := repo.SetTeamTranslation(ger.ID, "de", "Deutschland")
+	if err != nil || got.Translations["de"] != "Deutschland" {
+		t.Fatalf("SetTeamTranslation = %+v, %v", got, err)
+	}
+	// Later writes leave the translations of earlier reads alone.
+	if _, err := repo.SetTeamTranslation(ger.ID, "fr", "Allemagne"); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Translations) != 1 {
+		t.Fatalf("earlier read changed: %+v", got.Translations)
+	}
+	if team, _ := repo.GetTeamByID(ger.ID); len(team.Translations) != 2 {
+		t.Fatalf("expected two stored translations, got %+v", team.Translations)
+	}
+
+	if _, err := repo.DeleteTeamTranslation(ger.ID, "it"); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("missing translation: expected ErrNotFound, got %v", err)
+	}
+	if team, err := repo.DeleteTeamTranslation(ger.ID, "de"); err != nil || len(team.Translations) != 1 {
+		t.Fatalf("DeleteTeamTranslation = %+v, %v", team, err)
+	}
+	if _, err := repo.SetTeamTranslation(999, "de", "x"); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("unknown team: expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestFootballRepo_AsOf(t *testing.T) {
+	store := memory.New()
+	clk := clock.NewFake(time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
+	store.SetClock(clk)
+	repo := store.Football()
+	cup := repo.AddTournament("FIFA World Cup")
+
+	t0 := clk.Now()
+	clk.Advance(time.Hour)
+	fed, _ := repo.CreateTeam("West Germany")
+	eng, _ := repo.CreateTeam("England")
+	semi, _ := repo.CreateMatch(models.Match{Date: t0, HomeTeamID: fed.ID, AwayTeamID: eng.ID, TournamentID: cup.ID, HomeScore: 1})
+	t1 := clk.Now()
+	clk.Advance(time.Hour)
+	if _, err := repo.UpdateTeam(fed.ID, "Germany"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.UpdateMatch(semi.ID, models.Match{Date: t0, HomeTeamID: fed.ID, AwayTeamID: eng.ID, TournamentID: cup.ID, HomeScore: 2}); err != nil {
+		t.Fatal(err)
+	}
+	t2 := clk.Now()
+	clk.Advance(time.Hour)
+	if err := repo.DeleteMatch(semi.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repo.GetTeamAsOf(fed.ID, t0); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("before creation: expected ErrNotFound, got %v", err)
+	}
+	if team, _ := repo.GetTeamAsOf(fed.ID, t1); team.Name != "West Germany" {
+		t.Fatalf("GetTeamAsOf(t1) = %+v", team)
+	}
+	if m, err := repo.GetMatchAsOf(semi.ID, t1); err != nil || m.HomeScore != 1 || m.HomeTeam != "West Germany" {
+		t.Fatalf("GetMatchAsOf(t1) = %+v, %v", m, err)
+	}
+	if m, _ := repo.GetMatchAsOf(semi.ID, t2); m.HomeScore != 2 || m.HomeTeam != "Germany" {
+		t.Fatalf("GetMatchAsOf(t2) = %+v", m)
+	}
+	if _, err := repo.GetMatchAsOf(semi.ID, clk.Now()); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("after deletion: expected ErrNotFound, got %v", err)
+	}
+	if matches, _ := repo.ListMatches(10, 0, models.TimeFilter{AsOf: &t2}); len(matches) != 1 {
+		t.Fatalf("ListMatches(asOf t2) = %+v", matches)
+	}
+	if teams, _ := repo.ListTeams(models.TimeFilter{AsOf: &t0}); len(teams) != 0 {
+		t.Fatalf("ListTeams(asOf t0) = %+v", teams)
+	}
+}
+
+func TestFootballRepo_Tombstones(t *testing.T) {
+	store := memory.New()
+	clk := clock.NewFake(time.Date(1992, 1, 1, 0, 0, 0, 0, time.UTC))
+	store.SetClock(clk)
+	repo := store.Football()
+	ussr, _ := repo.CreateTeam("Soviet Union")
+
+	if _, err := repo.TeamTombstone(ussr.ID); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("existing team: expected ErrNotFound, got %v", err)
+	}
+	clk.Advance(time.Hour)
+	if err := repo.DeleteTeam(ussr.ID); err != nil {
+		t.Fatal(err)
+	}
+	if ts, err := repo.TeamTombstone(ussr.ID); err != nil || !ts.DeletedAt.Equal(clk.Now()) {
+		t.Fatalf("TeamTombstone = %+v, %v", ts, err)
+	}
+	if _, err := repo.MatchTombstone(ussr.ID); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("never-created match: expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestInviteRepo_Redeem(t *testing.T) {
+	repo := memory.New().Repositories().Invites
+	if _, err := repo.CreateInvite(models.Invite{Code: "twice", MaxUses: 2, ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CreateInvite(models.Invite{Code: "old", MaxUses: 1, ExpiresAt: time.Now().Add(-time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := repo.RedeemInvite("twice"); err != nil {
+			t.Fatalf("use %d: %v", i+1, err)
+		}
+	}
+	if err := repo.RedeemInvite("twice"); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("used up: expected ErrNotFound, got %v", err)
+	}
+	if err := repo.ReleaseInvite("twice"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.RedeemInvite("twice"); err != nil {
+		t.Fatalf("after release: %v", err)
+	}
+	if err := repo.RedeemInvite("old"); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("expired: expected ErrNotFound, got %v", err)
+	}
+	if err := repo.DeleteInvite("old"); err != nil {
+		t.Fatal(err)
+	}
+	if invites, _ := repo.ListInvites(); len(invites) != 1 || invites[0].Uses != 2 {
+		t.Fatalf("unexpected invites %+v", invites)
+	}
+}
+
+func TestLoginRepo_NewDevicesAndLocations(t *testing.T) {
+	repo := memory.New().Repositories().Logins
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	record := func(day int, fingerprint, network string) models.Login {
+		t.Helper()
+		at := start.AddDate(0, 0, day)
+		l, err := repo.RecordLogin(models.Login{Username: "alice", At: at, Fingerprint: fingerprint, Network: network}, at.AddDate(0, 0, -30))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return l
+	}
+
+	if l := record(0, "laptop", "home"); l.NewDevice || l.NewLocation {
+		t.Errorf("first login flagged %+v", l)
+	}
+	if l := record(1, "laptop", "cafe"); l.NewDevice || !l.NewLocation {
+		t.Errorf("new network not flagged alone %+v", l)
+	}
+	if l := record(2, "phone", "home"); !l.NewDevice || l.NewLocation {
+		t.Errorf("new device not flagged alone %+v", l)
+	}
+	// Sign-ins before the retention window are forgotten, so this one is
+	// as good as the first.
+	if l := record(40, "phone", "home"); l.NewDevice || l.NewLocation {
+		t.Errorf("only sign-in kept flagged %+v", l)
+	}
+	if l := record(41, "laptop", "home"); !l.NewDevice {
+		t.Errorf("forgotten device not flagged %+v", l)
+	}
+
+	logins, err := repo.ListLogins("alice", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logins) != 2 || logins[0].Fingerprint != "laptop" || logins[1].Fingerprint != "phone" {
+		t.Errorf("unexpected history %+v", logins)
+	}
+}
+
+func TestOAuthRepo_GrantsMergeAndCascade(t *testing.T) {
+	repo := memory.New().Repositories().OAuth
+	if _, err := repo.GrantAccess(models.OAuthGrant{ID: "g0", Username: "alice", ClientID: "app"}); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("unknown client: expected ErrNotFound, got %v", err)
+	}
+	if _, err := repo.CreateClient(models.OAuthClient{ID: "app", Name: "App"}); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := repo.GrantAccess(models.OAuthGrant{ID: "g1", Username: "alice", ClientID: "app", Scopes: []string{"football:read"}})
+	if err != nil || first.ClientName != "App" {
+		t.Fatalf("got %+v, %v", first, err)
+	}
+	// Consenting again widens the grant but keeps its ID, so tokens
+	// already issued stay valid.
+	again, err := repo.GrantAccess(models.OAuthGrant{ID: "g2", Username: "alice", ClientID: "app", Scopes: []string{"football:write", "football:read"}})
+	if err != nil || again.ID != "g1" || len(again.Scopes) != 2 {
+		t.Fatalf("got %+v, %v", again, err)
+	}
+	if err := repo.TouchGrant("g1"); err != nil {
+		t.Fatal(err)
+	}
+	if g, _ := repo.GetGrant("alice", "app"); g.LastUsedAt == nil {
+		t.Error("use not recorded")
+	}
+
+	if err := repo.DeleteClient("app"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.TouchGrant("g1"); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("grant outlived its client: %v", err)
+	}
+	if grants, _ := repo.ListGrants("alice"); len(grants) != 0 {
+		t.Fatalf("unexpected grants %+v", grants)
+	}
+}
@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"sort"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+type usageKey struct {
+	date, username, key string
+}
+
+// MeteringRepo implements db.MeteringRepository on a Store.
+type MeteringRepo struct{ s *Store }
+
+// AddUsage adds the records to the stored totals.
+func (r *MeteringRepo) AddUsage(records []models.UsageRecord) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, rec := range records {
+		k := usageKey{rec.Date, rec.Username, rec.Key}
+		cur, ok := r.s.usage[k]
+		if !ok {
+			cur = models.UsageRecord{Date: rec.Date, Username: rec.Username, Key: rec.Key}
+		}
+		cur.Requests += rec.Requests
+		cur.BytesIn += rec.BytesIn
+		cur.BytesOut += rec.BytesOut
+		r.s.usage[k] = cur
+	}
+	return nil
+}
+
+// ListUsage returns username's records in the range, oldest first.
+func (r *MeteringRepo) ListUsage(username, from, to string) ([]models.UsageRecord, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	out := []models.UsageRecord{}
+	for k, rec := range r.s.usage {
+		if k.username == username && k.date >= from && k.date <= to {
+			out = append(out, rec)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Date != out[j].Date {
+			return out[i].Date < out[j].Date
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out, nil
+}
+
+// UsageTotals sums the range per username and key, most requests first.
+func (r *MeteringRepo) UsageTotals(from, to string, limit, offset int) ([]models.UsageTotal, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	totals := map[[2]string]*models.UsageTotal{}
+	for k, rec := range r.s.usage {
+		if k.date < from || k.date > to {
+			continue
+		}
+		t := totals[[2]string{k.username, k.key}]
+		if t == nil {
+			t = &models.UsageTotal{Username: k.username, Key: k.key}
+			totals[[2]string{k.username, k.key}] = t
+		}
+		t.Requests += rec.Requests
+		t.BytesIn += rec.BytesIn
+		t.BytesOut += rec.BytesOut
+	}
+	out := make([]models.UsageTotal, 0, len(totals))
+	for _, t := range totals {
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		switch {
+		case a.Requests != b.Requests:
+			return a.Requests > b.Requests
+		case a.Username != b.Username:
+			return a.Username < b.Username
+		}
+		return a.Key < b.Key
+	})
+	if offset >= len(out) {
+		return []models.UsageTotal{}, nil
+	}
+	return out[offset:min(offset+limit, len(out))], nil
+}
@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"sort"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+type moderationKey struct {
+	kind string
+	id   int
+}
+
+// ModerationRepo implements db.ModerationRepository on a Store.
+type ModerationRepo struct{ s *Store }
+
+// ReportContent records r and opens or reopens the content's item.
+func (r *ModerationRepo) ReportContent(rep models.ContentReport) (models.ContentReport, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, x := range r.s.reports {
+		if x.Kind == rep.Kind && x.ResourceID == rep.ResourceID && x.Reporter == rep.Reporter {
+			return models.ContentReport{}, models.ErrConflict
+		}
+	}
+	rep.ID = r.s.id()
+	rep.CreatedAt = r.s.now()
+	r.s.reports = append(r.s.reports, rep)
+
+	key := moderationKey{rep.Kind, rep.ResourceID}
+	item, ok := r.s.moderation[key]
+	if !ok {
+		item = models.ModerationItem{Kind: rep.Kind, ResourceID: rep.ResourceID, State: models.ModerationOpen, FirstReportedAt: rep.CreatedAt}
+	}
+	if item.State == models.ModerationDismissed {
+		item.State = models.ModerationOpen
+	}
+	item.ReportCount++
+	item.LastReportedAt = rep.CreatedAt
+	r.s.moderation[key] = item
+	return rep, nil
+}
+
+// ListReportsBy returns the reports reporter filed, oldest first.
+func (r *ModerationRepo) ListReportsBy(reporter string) ([]models.ContentReport, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var out []models.ContentReport
+	for _, rep := range r.s.reports {
+		if rep.Reporter == reporter {
+			out = append(out, rep)
+		}
+	}
+	return out, nil
+}
+
+// QuarantineContent opens or reopens the content's item with reason.
+func (r *ModerationRepo) QuarantineContent(kind string, id int, reason string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	key := moderationKey{kind, id}
+	item, ok := r.s.moderation[key]
+	if !ok {
+		now := r.s.now()
+		item = models.ModerationItem{Kind: kind, ResourceID: id, FirstReportedAt: now, LastReportedAt: now}
+	}
+	item.State = models.ModerationOpen
+	item.QuarantineReason = reason
+	r.s.moderation[key] = item
+	return nil
+}
+
+// ListModerationItems returns a page of items in state, most reported
+// first.
+func (r *ModerationRepo) ListModerationItems(state string, limit, offset int) ([]models.ModerationItem, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var items []models.ModerationItem
+	for _, item := range r.s.moderation {
+		if item.State == state {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		switch {
+		case a.ReportCount != b.ReportCount:
+			return a.ReportCount > b.ReportCount
+		case !a.FirstReportedAt.Equal(b.FirstReportedAt):
+			return a.FirstReportedAt.Before(b.FirstReportedAt)
+		case a.Kind != b.Kind:
+			return a.Kind < b.Kind
+		}
+		return a.ResourceID < b.ResourceID
+	})
+	if offset >= len(items) {
+		return nil, nil
+	}
+	items = items[offset:min(offset+limit, len(items))]
+	for i := range items {
+		for _, rep := range r.s.reports {
+			if rep.Kind == items[i].Kind && rep.ResourceID == items[i].ResourceID {
+				items[i].Reports = append(items[i].Reports, rep)
+			}
+		}
+	}
+	return items, nil
+}
+
+// GetModerationItem returns the item for the content.
+func (r *ModerationRepo) GetModerationItem(kind string, id int) (models.ModerationItem, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	item, ok := r.s.moderation[moderationKey{kind, id}]
+	if !ok {
+		return models.ModerationItem{}, models.ErrNotFound
+	}
+	return item, nil
+}
+
+// ResolveModerationItem moves the item to state.
+func (r *ModerationRepo) ResolveModerationItem(kind string, id int, state, reviewer, note string) (models.ModerationItem, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	key := moderationKey{kind, id}
+	item, ok := r.s.moderation[key]
+	if !ok {
+		return models.ModerationItem{}, models.ErrNotFound
+	}
+	ts := r.s.now()
+	item.State, item.ReviewedBy, item.ReviewedAt, item.Resolution = state, reviewer, &ts, note
+	r.s.moderation[key] = item
+	return item, nil
+}
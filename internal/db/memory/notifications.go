@@ -0,0 +1,188 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// NotificationRepo implements db.NotificationRepository on a Store.
+type NotificationRepo struct{ s *Store }
+
+// AddNotification stores n in the user's inbox.
+func (r *NotificationRepo) AddNotification(username string, n models.Notification) (models.Notification, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.users[username]; !ok {
+		return models.Notification{}, models.ErrNotFound
+	}
+	n.ID = r.s.id()
+	n.CreatedAt = r.s.now()
+	n.ReadAt = nil
+	n.Links = nil
+	r.s.inbox[username] = append(r.s.inbox[username], n)
+	return n, nil
+}
+
+// BroadcastNotification copies n into every user's inbox.
+func (r *NotificationRepo) BroadcastNotification(n models.Notification) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = r.s.now()
+	}
+	n.ReadAt = nil
+	n.Links = nil
+	for username := range r.s.users {
+		n.ID = r.s.id()
+		r.s.inbox[username] = append(r.s.inbox[username], n)
+	}
+	return len(r.s.users), nil
+}
+
+// visible reports whether n is in the inbox at now.
+func visible(n models.Notification, now time.Time) bool {
+	return !n.CreatedAt.After(now) && (n.ExpiresAt == nil || n.ExpiresAt.After(now))
+}
+
+// ListNotifications returns a page of the user's visible notifications,
+// newest first.
+func (r *NotificationRepo) ListNotifications(username string, limit, offset int, unreadOnly bool) ([]models.Notification, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	now := r.s.now()
+	var all []models.Notification
+	for _, n := range r.s.inbox[username] {
+		if visible(n, now) && (!unreadOnly || n.ReadAt == nil) {
+			all = append(all, n)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if !all[i].CreatedAt.Equal(all[j].CreatedAt) {
+			return all[i].CreatedAt.After(all[j].CreatedAt)
+		}
+		return all[i].ID > all[j].ID
+	})
+	if offset >= len(all) {
+		return nil, nil
+	}
+	return all[offset:min(offset+limit, len(all))], nil
+}
+
+// CountUnreadNotifications counts the user's visible unread notifications.
+func (r *NotificationRepo) CountUnreadNotifications(username string) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	now := r.s.now()
+	n := 0
+	for _, x := range r.s.inbox[username] {
+		if visible(x, now) && x.ReadAt == nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// MarkNotificationRead marks one visible notification read.
+func (r *NotificationRepo) MarkNotificationRead(username string, id int) (models.Notification, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	now := r.s.now()
+	inbox := r.s.inbox[username]
+	for i := range inbox {
+		if inbox[i].ID != id || !visible(inbox[i], now) {
+			continue
+		}
+		if inbox[i].ReadAt == nil {
+			inbox[i].ReadAt = &now
+		}
+		return inbox[i], nil
+	}
+	return models.Notification{}, models.ErrNotFound
+}
+
+// MarkAllNotificationsRead marks every visible unread notification read.
+func (r *NotificationRepo) MarkAllNotificationsRead(username string) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	now := r.s.now()
+	inbox := r.s.inbox[username]
+	n := 0
+	for i := range inbox {
+		if visible(inbox[i], now) && inbox[i].ReadAt == nil {
+			inbox[i].ReadAt = &now
+			n++
+		}
+	}
+	return n, nil
+}
+
+// AnnouncementRepo implements db.AnnouncementRepository on a Store.
+type AnnouncementRepo struct{ s *Store }
+
+// CreateAnnouncement stores a.
+func (r *AnnouncementRepo) CreateAnnouncement(a models.Announcement) (models.Announcement, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	a.ID = r.s.id()
+	a.CreatedAt = r.s.now()
+	if a.PublishAt.IsZero() {
+		a.PublishAt = a.CreatedAt
+	}
+	a.Links = nil
+	r.s.announcements[a.ID] = a
+	return a, nil
+}
+
+// ListAnnouncements returns every announcement, newest first.
+func (r *AnnouncementRepo) ListAnnouncements() ([]models.Announcement, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return r.sorted(func(models.Announcement) bool { return true }), nil
+}
+
+// ActiveBanners returns the banner announcements live at at.
+func (r *AnnouncementRepo) ActiveBanners(at time.Time) ([]models.Announcement, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return r.sorted(func(a models.Announcement) bool {
+		return a.Banner && !a.PublishAt.After(at) && (a.ExpiresAt == nil || a.ExpiresAt.After(at))
+	}), nil
+}
+
+func (r *AnnouncementRepo) sorted(keep func(models.Announcement) bool) []models.Announcement {
+	out := []models.Announcement{}
+	for _, a := range r.s.announcements {
+		if keep(a) {
+			out = append(out, a)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].PublishAt.Equal(out[j].PublishAt) {
+			return out[i].PublishAt.After(out[j].PublishAt)
+		}
+		return out[i].ID > out[j].ID
+	})
+	return out
+}
+
+// DeleteAnnouncement removes an announcement and its inbox copies.
+func (r *AnnouncementRepo) DeleteAnnouncement(id int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.announcements[id]; !ok {
+		return models.ErrNotFound
+	}
+	delete(r.s.announcements, id)
+	for username, inbox := range r.s.inbox {
+		kept := inbox[:0]
+		for _, n := range inbox {
+			if n.AnnouncementID != id {
+				kept = append(kept, n)
+			}
+		}
+		r.s.inbox[username] = kept
+	}
+	return nil
+}
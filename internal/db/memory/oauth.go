@@ -0,0 +1,175 @@
+package memory
+
+import (
+	"sort"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// OAuthRepo implements db.OAuthRepository on a Store.
+type OAuthRepo struct{ s *Store }
+
+// CreateClient stores a client.
+func (r *OAuthRepo) CreateClient(c models.OAuthClient) (models.OAuthClient, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.oauthClients[c.ID]; ok {
+		return models.OAuthClient{}, models.ErrConflict
+	}
+	c.RedirectURIs = append([]string(nil), c.RedirectURIs...)
+	c.Scopes = append([]string(nil), c.Scopes...)
+	c.CreatedAt = r.s.now()
+	r.s.oauthClients[c.ID] = c
+	return c, nil
+}
+
+// GetClient returns a client by ID.
+func (r *OAuthRepo) GetClient(id string) (models.OAuthClient, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	c, ok := r.s.oauthClients[id]
+	if !ok {
+		return models.OAuthClient{}, models.ErrNotFound
+	}
+	return c, nil
+}
+
+// ListClients returns every client, newest first.
+func (r *OAuthRepo) ListClients() ([]models.OAuthClient, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	clients := make([]models.OAuthClient, 0, len(r.s.oauthClients))
+	for _, c := range r.s.oauthClients {
+		clients = append(clients, c)
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		if !clients[i].CreatedAt.Equal(clients[j].CreatedAt) {
+			return clients[i].CreatedAt.After(clients[j].CreatedAt)
+		}
+		return clients[i].ID < clients[j].ID
+	})
+	return clients, nil
+}
+
+// DeleteClient removes a client and the grants to it.
+func (r *OAuthRepo) DeleteClient(id string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.oauthClients[id]; !ok {
+		return models.ErrNotFound
+	}
+	delete(r.s.oauthClients, id)
+	for gid, g := range r.s.oauthGrants {
+		if g.ClientID == id {
+			delete(r.s.oauthGrants, gid)
+		}
+	}
+	return nil
+}
+
+// GrantAccess records consent, adding scopes to an existing grant.
+func (r *OAuthRepo) GrantAccess(g models.OAuthGrant) (models.OAuthGrant, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	c, ok := r.s.oauthClients[g.ClientID]
+	if !ok {
+		return models.OAuthGrant{}, models.ErrNotFound
+	}
+	if old, ok := r.grant(g.Username, g.ClientID); ok {
+		old.Scopes = mergeScopes(old.Scopes, g.Scopes)
+		r.s.oauthGrants[old.ID] = old
+		return r.withClient(old, c), nil
+	}
+	g.Scopes = mergeScopes(nil, g.Scopes)
+	g.CreatedAt, g.LastUsedAt = r.s.now(), nil
+	r.s.oauthGrants[g.ID] = g
+	return r.withClient(g, c), nil
+}
+
+// GetGrant returns the user's grant to a client.
+func (r *OAuthRepo) GetGrant(username, clientID string) (models.OAuthGrant, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	g, ok := r.grant(username, clientID)
+	if !ok {
+		return models.OAuthGrant{}, models.ErrNotFound
+	}
+	return r.withClient(g, r.s.oauthClients[clientID]), nil
+}
+
+// ListGrants returns the user's grants, newest first.
+func (r *OAuthRepo) ListGrants(username string) ([]models.OAuthGrant, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	grants := []models.OAuthGrant{}
+	for _, g := range r.s.oauthGrants {
+		if g.Username == username {
+			grants = append(grants, r.withClient(g, r.s.oauthClients[g.ClientID]))
+		}
+	}
+	sort.Slice(grants, func(i, j int) bool {
+		if !grants[i].CreatedAt.Equal(grants[j].CreatedAt) {
+			return grants[i].CreatedAt.After(grants[j].CreatedAt)
+		}
+		return grants[i].ClientID < grants[j].ClientID
+	})
+	return grants, nil
+}
+
+// TouchGrant records use of a grant.
+func (r *OAuthRepo) TouchGrant(id string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	g, ok := r.s.oauthGrants[id]
+	if !ok {
+		return models.ErrNotFound
+	}
+	ts := r.s.now()
+	if g.LastUsedAt == nil || ts.Sub(*g.LastUsedAt) >= sessionTouchInterval {
+		g.LastUsedAt = &ts
+		r.s.oauthGrants[id] = g
+	}
+	return nil
+}
+
+// RevokeGrant removes the user's grant to a client.
+func (r *OAuthRepo) RevokeGrant(username, clientID string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	g, ok := r.grant(username, clientID)
+	if !ok {
+		return models.ErrNotFound
+	}
+	delete(r.s.oauthGrants, g.ID)
+	return nil
+}
+
+// grant finds the user's grant to a client.  The caller holds the lock.
+func (r *OAuthRepo) grant(username, clientID string) (models.OAuthGrant, bool) {
+	for _, g := range r.s.oauthGrants {
+		if g.Username == username && g.ClientID == clientID {
+			return g, true
+		}
+	}
+	return models.OAuthGrant{}, false
+}
+
+func (r *OAuthRepo) withClient(g models.OAuthGrant, c models.OAuthClient) models.OAuthGrant {
+	g.ClientName = c.Name
+	g.Scopes = append([]string(nil), g.Scopes...)
+	return g
+}
+
+// mergeScopes returns the sorted union of two scope lists.
+func mergeScopes(a, b []string) []string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, s := range append(append([]string(nil), a...), b...) {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
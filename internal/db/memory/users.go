@@ -0,0 +1,244 @@
+package memory
+
+import (
+	"maps"
+	"sort"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// sessionTouchInterval matches the PostgreSQL repository: last-used times
+// are only rewritten when older than this.
+const sessionTouchInterval = time.Minute
+
+// UserRepo implements db.UserRepository on a Store.
+type UserRepo struct{ s *Store }
+
+// GetUser returns the user, comparing usernames in normalised form.
+func (r *UserRepo) GetUser(username string) (models.User, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	u, ok := r.s.users[auth.NormalizeUsername(username)]
+	if !ok {
+		return models.User{}, models.ErrNotFound
+	}
+	return u, nil
+}
+
+// CreateUser stores a user under the normalised username.
+func (r *UserRepo) CreateUser(username, passwordHash string) (models.User, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	username = auth.NormalizeUsername(username)
+	if _, ok := r.s.users[username]; ok {
+		return models.User{}, models.ErrConflict
+	}
+	u := models.User{Username: username, PasswordHash: passwordHash, CreatedAt: r.s.now()}
+	r.s.users[username] = u
+	return u, nil
+}
+
+// UpdatePasswordHash replaces a user's password hash.
+func (r *UserRepo) UpdatePasswordHash(username, passwordHash string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	key := auth.NormalizeUsername(username)
+	u, ok := r.s.users[key]
+	if !ok {
+		return models.ErrNotFound
+	}
+	u.PasswordHash = passwordHash
+	r.s.users[key] = u
+	return nil
+}
+
+// SessionRepo implements db.SessionRepository on a Store.
+type SessionRepo struct{ s *Store }
+
+// CreateSession stores a session and purges the user's expired ones.
+func (r *SessionRepo) CreateSession(sess models.Session) (models.Session, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	ts := r.s.now()
+	for id, old := range r.s.sessions {
+		if old.Username == sess.Username && !old.ExpiresAt.After(ts) {
+			delete(r.s.sessions, id)
+		}
+	}
+	sess.CreatedAt, sess.LastUsedAt = ts, ts
+	r.s.sessions[sess.ID] = sess
+	return sess, nil
+}
+
+// ListSessions returns the user's unexpired sessions, most recently used
+// first.
+func (r *SessionRepo) ListSessions(username string) ([]models.Session, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	ts := r.s.now()
+	sessions := []models.Session{}
+	for _, sess := range r.s.sessions {
+		if sess.Username == username && sess.ExpiresAt.After(ts) {
+			sessions = append(sessions, sess)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt) })
+	return sessions, nil
+}
+
+// TouchSession records use of an active session.
+func (r *SessionRepo) TouchSession(id string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	sess, ok := r.s.sessions[id]
+	ts := r.s.now()
+	if !ok || !sess.ExpiresAt.After(ts) {
+		return models.ErrNotFound
+	}
+	if ts.Sub(sess.LastUsedAt) >= sessionTouchInterval {
+		sess.LastUsedAt = ts
+		r.s.sessions[id] = sess
+	}
+	return nil
+}
+
+// CheckSession reports whether a session is active without recording use.
+func (r *SessionRepo) CheckSession(id string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	sess, ok := r.s.sessions[id]
+	if !ok || !sess.ExpiresAt.After(r.s.now()) {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+// RevokeSession deletes one of the user's sessions.
+func (r *SessionRepo) RevokeSession(username, id string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	sess, ok := r.s.sessions[id]
+	if !ok || sess.Username != username {
+		return models.ErrNotFound
+	}
+	delete(r.s.sessions, id)
+	return nil
+}
+
+// LoginRepo implements db.LoginRepository on a Store.
+type LoginRepo struct{ s *Store }
+
+// RecordLogin stores a sign-in, forgetting the user's sign-ins from before
+// retain, and marks it new if its device or network is.
+func (r *LoginRepo) RecordLogin(l models.Login, retain time.Time) (models.Login, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var kept []models.Login
+	for _, old := range r.s.logins[l.Username] {
+		if !old.At.Before(retain) {
+			kept = append(kept, old)
+		}
+	}
+	l.NewDevice, l.NewLocation = len(kept) > 0, len(kept) > 0
+	for _, old := range kept {
+		if old.Fingerprint == l.Fingerprint {
+			l.NewDevice = false
+		}
+		if old.Network == l.Network {
+			l.NewLocation = false
+		}
+	}
+	l.ID = int64(r.s.id())
+	r.s.logins[l.Username] = append(kept, l)
+	return l, nil
+}
+
+// ListLogins returns the user's most recent sign-ins, newest first.
+func (r *LoginRepo) ListLogins(username string, limit int) ([]models.Login, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	all := r.s.logins[username]
+	out := []models.Login{}
+	for i := len(all) - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, all[i])
+	}
+	return out, nil
+}
+
+// TermsRepo implements db.TermsRepository on a Store.
+type TermsRepo struct{ s *Store }
+
+// AcceptTerms records that the user accepted version now.
+func (r *TermsRepo) AcceptTerms(username, version string) (models.TermsAcceptance, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.users[username]; !ok {
+		return models.TermsAcceptance{}, models.ErrNotFound
+	}
+	if r.s.terms[username] == nil {
+		r.s.terms[username] = map[string]time.Time{}
+	}
+	ts := r.s.now()
+	r.s.terms[username][version] = ts
+	return models.TermsAcceptance{Version: version, AcceptedAt: ts}, nil
+}
+
+// LatestTerms returns the user's most recent acceptance.
+func (r *TermsRepo) LatestTerms(username string) (models.TermsAcceptance, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var latest models.TermsAcceptance
+	for version, at := range r.s.terms[username] {
+		if latest.Version == "" || at.After(latest.AcceptedAt) || at.Equal(latest.AcceptedAt) && version > latest.Version {
+			latest = models.TermsAcceptance{Version: version, AcceptedAt: at}
+		}
+	}
+	if latest.Version == "" {
+		return models.TermsAcceptance{}, models.ErrNotFound
+	}
+	return latest, nil
+}
+
+// ListTerms returns every version the user has accepted, most recent first.
+func (r *TermsRepo) ListTerms(username string) ([]models.TermsAcceptance, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	out := []models.TermsAcceptance{}
+	for version, at := range r.s.terms[username] {
+		out = append(out, models.TermsAcceptance{Version: version, AcceptedAt: at})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].AcceptedAt.Equal(out[j].AcceptedAt) {
+			return out[i].AcceptedAt.After(out[j].AcceptedAt)
+		}
+		return out[i].Version > out[j].Version
+	})
+	return out, nil
+}
+
+// PreferencesRepo implements db.PreferencesRepository on a Store.
+type PreferencesRepo struct{ s *Store }
+
+// GetPreferences returns a copy of the user's preferences.
+func (r *PreferencesRepo) GetPreferences(username string) (map[string]any, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	prefs := maps.Clone(r.s.prefs[username])
+	if prefs == nil {
+		prefs = map[string]any{}
+	}
+	return prefs, nil
+}
+
+// PutPreferences replaces the user's preferences.
+func (r *PreferencesRepo) PutPreferences(username string, prefs map[string]any) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.users[username]; !ok {
+		return models.ErrNotFound
+	}
+	r.s.prefs[username] = maps.Clone(prefs)
+	return nil
+}
@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// AnnouncementRepo is a PostgreSQL-backed implementation of
+// db.AnnouncementRepository.
+type AnnouncementRepo struct {
+	db *sql.DB
+}
+
+// NewAnnouncementRepo constructs an AnnouncementRepo backed by the provided
+// *sql.DB.
+func NewAnnouncementRepo(db *sql.DB) *AnnouncementRepo {
+	return &AnnouncementRepo{db: db}
+}
+
+const announcementColumns = `id, title, body, banner, publish_at, expires_at, created_by, created_at`
+
+func scanAnnouncement(row interface{ Scan(...any) error }) (models.Announcement, error) {
+	var a models.Announcement
+	var expiresAt sql.NullTime
+	if err := row.Scan(&a.ID, &a.Title, &a.Body, &a.Banner, &a.PublishAt, &expiresAt, &a.CreatedBy, &a.CreatedAt); err != nil {
+		return models.Announcement{}, err
+	}
+	if expiresAt.Valid {
+		a.ExpiresAt = &expiresAt.Time
+	}
+	return a, nil
+}
+
+// CreateAnnouncement stores a.  A zero PublishAt means now.
+func (r *AnnouncementRepo) CreateAnnouncement(a models.Announcement) (models.Announcement, error) {
+	const q = `
+		INSERT INTO announcements (title, body, banner, publish_at, expires_at, created_by)
+		VALUES ($1, $2, $3, COALESCE($4, NOW()), $5, $6)
+		RETURNING ` + announcementColumns
+
+	var publishAt, expiresAt sql.NullTime
+	if !a.PublishAt.IsZero() {
+		publishAt = sql.NullTime{Time: a.PublishAt, Valid: true}
+	}
+	if a.ExpiresAt != nil {
+		expiresAt = sql.NullTime{Time: *a.ExpiresAt, Valid: true}
+	}
+	out, err := scanAnnouncement(r.db.QueryRow(q, a.Title, a.Body, a.Banner, publishAt, expiresAt, a.CreatedBy))
+	if err != nil {
+		return models.Announcement{}, fmt.Errorf("announcementRepo.CreateAnnouncement: %w", err)
+	}
+	return out, nil
+}
+
+// ListAnnouncements returns every announcement, newest first.
+func (r *AnnouncementRepo) ListAnnouncements() ([]models.Announcement, error) {
+	const q = `SELECT ` + announcementColumns + ` FROM announcements ORDER BY publish_at DESC, id DESC`
+	return r.list("ListAnnouncements", q)
+}
+
+// ActiveBanners returns the banner announcements live at at, newest first.
+func (r *AnnouncementRepo) ActiveBanners(at time.Time) ([]models.Announcement, error) {
+	const q = `
+		SELECT ` + announcementColumns + `
+		FROM announcements
+		WHERE banner AND publish_at <= $1 AND (expires_at IS NULL OR expires_at > $1)
+		ORDER BY publish_at DESC, id DESC`
+	return r.list("ActiveBanners", q, at)
+}
+
+func (r *AnnouncementRepo) list(method, q string, args ...any) ([]models.Announcement, error) {
+	rows, err := r.db.Query(q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("announcementRepo.%s: %w", method, err)
+	}
+	defer rows.Close()
+
+	out := []models.Announcement{}
+	for rows.Next() {
+		a, err := scanAnnouncement(rows)
+		if err != nil {
+			return nil, fmt.Errorf("announcementRepo.%s scan: %w", method, err)
+		}
+		out = append(out, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("announcementRepo.%s rows: %w", method, err)
+	}
+	return out, nil
+}
+
+// DeleteAnnouncement removes an announcement; its inbox copies go with it
+// (ON DELETE CASCADE).  Returns models.ErrNotFound when it does not exist.
+func (r *AnnouncementRepo) DeleteAnnouncement(id int) error {
+	res, err := r.db.Exec(`DELETE FROM announcements WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("announcementRepo.DeleteAnnouncement: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("announcementRepo.DeleteAnnouncement: %w", err)
+	}
+	if n == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
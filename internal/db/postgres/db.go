@@ -7,7 +7,9 @@ package postgres
 import (
 	"database/sql"
 	"fmt"
+	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	// Register the lib/pq PostgreSQL driver as a side-effect import.
@@ -24,12 +26,20 @@ import (
 //     connection-setup latency.
 //   - ConnMaxLifetime: recycles connections periodically so that load-balancer
 //     or firewall idle-connection limits are not hit.
+//
+// Sessions run with TimeZone=UTC (see utcDSN) so that TIMESTAMPTZ values are
+// read back in UTC whatever the server's default zone.
 func Connect(dsn string) (*sql.DB, error) {
-	db, err := sql.Open("postgres", dsn)
+	db, err := sql.Open("postgres", utcDSN(dsn))
 	if err != nil {
 		return nil, fmt.Errorf("postgres: open: %w", err)
 	}
+	return configure(db)
+}
 
+// configure applies the pool settings described on Connect and verifies the
+// connection.
+func configure(db *sql.DB) (*sql.DB, error) {
 	db.SetMaxOpenConns(25)
 	db.SetMaxIdleConns(5)
 	db.SetConnMaxLifetime(5 * time.Minute)
@@ -41,6 +51,29 @@ func Connect(dsn string) (*sql.DB, error) {
 	return db, nil
 }
 
+// utcDSN adds timezone=UTC to dsn unless it already sets a time zone.  lib/pq
+// passes unrecognised connection parameters to the server as session
+// settings, so every connection reports timestamps in UTC and the API
+// serialises them as RFC 3339 with a Z suffix.
+func utcDSN(dsn string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return dsn // let the driver report the malformed URL
+		}
+		q := u.Query()
+		if q.Get("timezone") == "" {
+			q.Set("timezone", "UTC")
+			u.RawQuery = q.Encode()
+		}
+		return u.String()
+	}
+	if strings.Contains(strings.ToLower(dsn), "timezone=") {
+		return dsn
+	}
+	return strings.TrimSpace(dsn + " timezone=UTC")
+}
+
 // ConnectFromEnv is a convenience wrapper that reads the DATABASE_URL
 // environment variable and calls Connect.  Returns (nil, nil) when the
 // variable is not set so callers can fall back to an in-memory store.
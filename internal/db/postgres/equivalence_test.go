@@ -0,0 +1,250 @@
+package postgres_test
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/memory"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/postgres"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+var equivalenceSeed = flag.Int64("equivalence.seed", 0, "seed for TestFootballRepo_MemoryEquivalence (0 = random)")
+
+// scratchDB connects to the database named by TEST_SCRATCH_DATABASE_URL,
+// which must have the migrations applied and hold nothing worth keeping:
+// every football table is truncated.  Tests are skipped without it.
+func scratchDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_SCRATCH_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_SCRATCH_DATABASE_URL not set")
+	}
+	conn, err := postgres.Connect(dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func truncateFootball(t *testing.T, conn *sql.DB) {
+	t.Helper()
+	_, err := conn.Exec(`TRUNCATE football_teams, football_tournaments, football_match_tombstones, football_revisions RESTART IDENTITY CASCADE`)
+	if err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+}
+
+// TestFootballRepo_MemoryEquivalence runs random sequences of team and
+// match operations against the in-memory store and PostgreSQL side by side
+// and fails on the first observable difference: a different error class, a
+// different result, or a different listing afterwards.  IDs are compared by
+// the step that created them, since the two stores number rows differently.
+//
+// Reproduce a failure with -equivalence.seed=<seed from the log>.
+func TestFootballRepo_MemoryEquivalence(t *testing.T) {
+	conn := scratchDB(t)
+
+	seed := *equivalenceSeed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	t.Logf("seed %d", seed)
+	rng := rand.New(rand.NewSource(seed)) //nolint:gosec
+
+	const (
+		sequences = 25
+		steps     = 40
+	)
+	for i := 0; i < sequences; i++ {
+		truncateFootball(t, conn)
+		h := newEquivalenceHarness(t, conn)
+		for j := 0; j < steps; j++ {
+			h.step(rng)
+			if t.Failed() {
+				t.Fatalf("sequence %d diverged after:\n  %s", i, strings.Join(h.log, "\n  "))
+			}
+		}
+	}
+	truncateFootball(t, conn)
+}
+
+// equivalenceHarness applies the same operation to both repositories.
+// teams and matches hold {memory ID, postgres ID} pairs, including those of
+// rows since deleted, so that operations also hit missing rows.
+type equivalenceHarness struct {
+	t       *testing.T
+	mem     db.FootballRepository
+	pg      db.FootballRepository
+	teams   [][2]int
+	matches [][2]int
+	cup     [2]int
+	log     []string
+}
+
+func newEquivalenceHarness(t *testing.T, conn *sql.DB) *equivalenceHarness {
+	store := memory.New()
+	h := &equivalenceHarness{
+		t:   t,
+		mem: store.Football(),
+		pg:  postgres.NewFootballRepo(conn, postgres.TxOptions{}),
+	}
+	h.cup[0] = store.Football().AddTournament("Friendly").ID
+	if err := conn.QueryRow(`INSERT INTO football_tournaments (name) VALUES ('Friendly') RETURNING id`).Scan(&h.cup[1]); err != nil {
+		t.Fatalf("seed tournament: %v", err)
+	}
+	return h
+}
+
+var teamNames = []string{"England", "Germany", "Brazil", "Italy", "Spain"}
+
+// pick returns a random known ID pair, or {0, 0} (a row neither store has)
+// when there are none or one time in eight.
+func pick(rng *rand.Rand, ids [][2]int) [2]int {
+	if len(ids) == 0 || rng.Intn(8) == 0 {
+		return [2]int{}
+	}
+	return ids[rng.Intn(len(ids))]
+}
+
+func (h *equivalenceHarness) match(rng *rand.Rand, home, away [2]int) [2]models.Match {
+	base := models.Match{
+		Date:      time.Date(2000, 1, 1+rng.Intn(4), 0, 0, 0, 0, time.UTC),
+		HomeScore: rng.Intn(4),
+		AwayScore: rng.Intn(4),
+		City:      "London",
+		Country:   "England",
+		Neutral:   rng.Intn(2) == 0,
+	}
+	mem, pg := base, base
+	mem.HomeTeamID, mem.AwayTeamID, mem.TournamentID = home[0], away[0], h.cup[0]
+	pg.HomeTeamID, pg.AwayTeamID, pg.TournamentID = home[1], away[1], h.cup[1]
+	return [2]models.Match{mem, pg}
+}
+
+func (h *equivalenceHarness) step(rng *rand.Rand) {
+	switch rng.Intn(8) {
+	case 0, 1:
+		name := teamNames[rng.Intn(len(teamNames))]
+		h.logf("CreateTeam(%q)", name)
+		a, errA := h.mem.CreateTeam(name)
+		b, errB := h.pg.CreateTeam(name)
+		if h.sameOutcome(errA, errB, a.Name, b.Name) && errA == nil {
+			h.teams = append(h.teams, [2]int{a.ID, b.ID})
+		}
+	case 2:
+		id, name := pick(rng, h.teams), teamNames[rng.Intn(len(teamNames))]
+		h.logf("UpdateTeam(%v, %q)", id, name)
+		a, errA := h.mem.UpdateTeam(id[0], name)
+		b, errB := h.pg.UpdateTeam(id[1], name)
+		h.sameOutcome(errA, errB, a.Name, b.Name)
+	case 3:
+		id := pick(rng, h.teams)
+		h.logf("DeleteTeam(%v)", id)
+		h.sameOutcome(h.mem.DeleteTeam(id[0]), h.pg.DeleteTeam(id[1]), nil, nil)
+	case 4, 5:
+		home, away := pick(rng, h.teams), pick(rng, h.teams)
+		m := h.match(rng, home, away)
+		h.logf("CreateMatch(home %v, away %v, %s)", home, away, m[0].Date.Format("2006-01-02"))
+		a, errA := h.mem.CreateMatch(m[0])
+		b, errB := h.pg.CreateMatch(m[1])
+		if h.sameOutcome(errA, errB, describe(a), describe(b)) && errA == nil {
+			h.matches = append(h.matches, [2]int{a.ID, b.ID})
+		}
+	case 6:
+		id := pick(rng, h.matches)
+		home, away := pick(rng, h.teams), pick(rng, h.teams)
+		m := h.match(rng, home, away)
+		h.logf("UpdateMatch(%v, home %v, away %v, %s)", id, home, away, m[0].Date.Format("2006-01-02"))
+		a, errA := h.mem.UpdateMatch(id[0], m[0])
+		b, errB := h.pg.UpdateMatch(id[1], m[1])
+		h.sameOutcome(errA, errB, describe(a), describe(b))
+	case 7:
+		id := pick(rng, h.matches)
+		h.logf("DeleteMatch(%v)", id)
+		h.sameOutcome(h.mem.DeleteMatch(id[0]), h.pg.DeleteMatch(id[1]), nil, nil)
+	}
+	h.sameListings()
+}
+
+func (h *equivalenceHarness) logf(format string, args ...interface{}) {
+	h.log = append(h.log, fmt.Sprintf(format, args...))
+}
+
+// sameOutcome reports a difference in error class or, when both succeeded,
+// in the results a and b.  It returns whether the outcomes agreed.
+func (h *equivalenceHarness) sameOutcome(errA, errB error, a, b interface{}) bool {
+	h.t.Helper()
+	if ca, cb := errClass(errA), errClass(errB); ca != cb {
+		h.t.Errorf("memory returned %s (%v), postgres %s (%v)", ca, errA, cb, errB)
+		return false
+	}
+	if errA == nil && !reflect.DeepEqual(a, b) {
+		h.t.Errorf("memory returned %v, postgres %v", a, b)
+		return false
+	}
+	return true
+}
+
+func (h *equivalenceHarness) sameListings() {
+	h.t.Helper()
+	ta, errA := h.mem.ListTeams(models.TimeFilter{})
+	tb, errB := h.pg.ListTeams(models.TimeFilter{})
+	if errA != nil || errB != nil {
+		h.t.Fatalf("ListTeams: memory %v, postgres %v", errA, errB)
+	}
+	h.sameOutcome(nil, nil, teamList(ta), teamList(tb))
+
+	ma, errA := h.mem.ListMatches(1000, 0, models.TimeFilter{})
+	mb, errB := h.pg.ListMatches(1000, 0, models.TimeFilter{})
+	if errA != nil || errB != nil {
+		h.t.Fatalf("ListMatches: memory %v, postgres %v", errA, errB)
+	}
+	h.sameOutcome(nil, nil, matchList(ma), matchList(mb))
+}
+
+func errClass(err error) string {
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, models.ErrNotFound):
+		return "ErrNotFound"
+	case errors.Is(err, models.ErrConflict):
+		return "ErrConflict"
+	default:
+		return "an error"
+	}
+}
+
+// describe renders the store-independent parts of a match.
+func describe(m models.Match) string {
+	return fmt.Sprintf("%s %s %d-%d %s (%s) %s, %s, neutral=%t",
+		m.Date.Format("2006-01-02"), m.HomeTeam, m.HomeScore, m.AwayScore, m.AwayTeam,
+		m.Tournament, m.City, m.Country, m.Neutral)
+}
+
+func teamList(teams []models.Team) []string {
+	names := make([]string, 0, len(teams))
+	for _, t := range teams {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+func matchList(matches []models.Match) []string {
+	out := make([]string, 0, len(matches))
+	for _, m := range matches {
+		out = append(out, describe(m))
+	}
+	return out
+}
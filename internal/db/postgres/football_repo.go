@@ -1,34 +1,64 @@
 package postgres
 
 import (
+	"cmp"
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/lib/pq"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/slug"
 )
 
 // FootballRepo is a PostgreSQL-backed implementation of db.FootballRepository.
 // All queries use parameterised placeholders ($1, $2, …) to prevent SQL injection.
+// Mutations run through RunInTx with the repo's TxOptions.  Statements on the
+// hot single-record paths are prepared once and reused.
 type FootballRepo struct {
-	db *sql.DB
+	db    *sql.DB
+	tx    TxOptions
+	stmts *stmtCache
 }
 
 // NewFootballRepo constructs a FootballRepo backed by the provided *sql.DB.
-func NewFootballRepo(db *sql.DB) *FootballRepo {
-	return &FootballRepo{db: db}
+// Zero fields of tx are taken from DefaultTxOptions.
+func NewFootballRepo(db *sql.DB, tx TxOptions) *FootballRepo {
+	return &FootballRepo{db: db, tx: tx, stmts: newStmtCache(db)}
 }
 
-// ListTeams returns all teams ordered alphabetically.
-func (r *FootballRepo) ListTeams() ([]models.Team, error) {
-	const q = `
-		SELECT id, name, created_at
-		FROM football_teams
+// rowQuerier is satisfied by *sql.DB, *sql.Tx and the statement cache, so
+// that reads can run inside the transaction that preceded them.
+type rowQuerier interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// inTx runs fn as a retryable unit of work.
+func (r *FootballRepo) inTx(fn func(*sql.Tx) error) error {
+	return RunInTx(context.Background(), r.db, r.tx, fn)
+}
+
+// ListTeams returns the teams matching f ordered alphabetically.
+func (r *FootballRepo) ListTeams(f models.TimeFilter) ([]models.Team, error) {
+	from, args := "football_teams", []any{f.CreatedAfter, f.UpdatedSince}
+	if f.AsOf != nil {
+		if err := r.checkHistory(*f.AsOf); err != nil {
+			return nil, err
+		}
+		from, args = "football_teams_as_of($3)", append(args, *f.AsOf)
+	}
+	q := `
+		SELECT ` + teamColumns + `
+		FROM ` + from + `
+		WHERE ($1::timestamptz IS NULL OR created_at > $1)
+		  AND ($2::timestamptz IS NULL OR updated_at >= $2)
 		ORDER BY name ASC`
 
-	rows, err := r.db.Query(q)
+	rows, err := r.db.Query(q, args...)
 	if err != nil {
 		return nil, fmt.Errorf("footballRepo.ListTeams: %w", err)
 	}
@@ -37,7 +67,7 @@ func (r *FootballRepo) ListTeams() ([]models.Team, error) {
 	var teams []models.Team
 	for rows.Next() {
 		var t models.Team
-		if err := rows.Scan(&t.ID, &t.Name, &t.CreatedAt); err != nil {
+		if err := rows.Scan(teamFields(&t)...); err != nil {
 			return nil, fmt.Errorf("footballRepo.ListTeams scan: %w", err)
 		}
 		teams = append(teams, t)
@@ -51,10 +81,10 @@ func (r *FootballRepo) ListTeams() ([]models.Team, error) {
 // GetTeamByID returns the team with the given ID.
 // Returns ErrNotFound when no matching row exists.
 func (r *FootballRepo) GetTeamByID(id int) (models.Team, error) {
-	const q = `SELECT id, name, created_at FROM football_teams WHERE id = $1`
+	const q = `SELECT ` + teamColumns + ` FROM football_teams WHERE id = $1`
 
 	var t models.Team
-	err := r.db.QueryRow(q, id).Scan(&t.ID, &t.Name, &t.CreatedAt)
+	err := r.stmts.QueryRow(q, id).Scan(teamFields(&t)...)
 	if errors.Is(err, sql.ErrNoRows) {
 		return models.Team{}, models.ErrNotFound
 	}
@@ -64,6 +94,71 @@ func (r *FootballRepo) GetTeamByID(id int) (models.Team, error) {
 	return t, nil
 }
 
+// GetTeamAsOf returns the team with the given ID as it was at at.
+// Returns ErrNotFound when it did not exist then, or ErrNoHistory.
+func (r *FootballRepo) GetTeamAsOf(id int, at time.Time) (models.Team, error) {
+	if err := r.checkHistory(at); err != nil {
+		return models.Team{}, err
+	}
+	const q = `SELECT ` + teamColumns + ` FROM football_teams_as_of($2) WHERE id = $1`
+
+	var t models.Team
+	err := r.db.QueryRow(q, id, at).Scan(teamFields(&t)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Team{}, models.ErrNotFound
+	}
+	if err != nil {
+		return models.Team{}, fmt.Errorf("footballRepo.GetTeamAsOf: %w", err)
+	}
+	return t, nil
+}
+
+// checkHistory returns models.ErrNoHistory if the revision history began
+// after at, so that the football_*_as_of functions cannot reconstruct it.
+func (r *FootballRepo) checkHistory(at time.Time) error {
+	var start time.Time
+	err := r.db.QueryRow(`SELECT MIN(started_at) FROM football_revisions_start`).Scan(&start)
+	if err != nil {
+		return fmt.Errorf("footballRepo.checkHistory: %w", err)
+	}
+	if at.Before(start) {
+		return models.ErrNoHistory
+	}
+	return nil
+}
+
+// GetTeamByName returns the team with the given name.
+// Returns ErrNotFound when no matching row exists.
+func (r *FootballRepo) GetTeamByName(name string) (models.Team, error) {
+	const q = `SELECT ` + teamColumns + ` FROM football_teams WHERE name = $1`
+
+	var t models.Team
+	err := r.db.QueryRow(q, name).Scan(teamFields(&t)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Team{}, models.ErrNotFound
+	}
+	if err != nil {
+		return models.Team{}, fmt.Errorf("footballRepo.GetTeamByName: %w", err)
+	}
+	return t, nil
+}
+
+// GetTeamBySlug returns the team with the given slug.
+// Returns ErrNotFound when no matching row exists.
+func (r *FootballRepo) GetTeamBySlug(slug string) (models.Team, error) {
+	const q = `SELECT ` + teamColumns + ` FROM football_teams WHERE slug = $1`
+
+	var t models.Team
+	err := r.stmts.QueryRow(q, slug).Scan(teamFields(&t)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Team{}, models.ErrNotFound
+	}
+	if err != nil {
+		return models.Team{}, fmt.Errorf("footballRepo.GetTeamBySlug: %w", err)
+	}
+	return t, nil
+}
+
 // GetTeamHistory returns the former names recorded for a team.
 func (r *FootballRepo) GetTeamHistory(teamID int) ([]models.FormerName, error) {
 	const q = `
@@ -140,24 +235,37 @@ func (r *FootballRepo) ListTournaments() ([]models.Tournament, error) {
 	return tournaments, nil
 }
 
-// ListMatches returns a paginated list of matches ordered by date descending.
-func (r *FootballRepo) ListMatches(limit, offset int) ([]models.Match, error) {
-	const q = `
+// ListMatches returns a paginated list of the matches matching f ordered by
+// date descending.
+func (r *FootballRepo) ListMatches(limit, offset int, f models.TimeFilter) ([]models.Match, error) {
+	matches, teams := "football_matches", "football_teams"
+	args := []any{limit, offset, f.CreatedAfter, f.UpdatedSince}
+	if f.AsOf != nil {
+		if err := r.checkHistory(*f.AsOf); err != nil {
+			return nil, err
+		}
+		matches, teams = "football_matches_as_of($5)", "football_teams_as_of($5)"
+		args = append(args, *f.AsOf)
+	}
+	q := `
 		SELECT
 			m.id, m.match_date,
 			ht.id, ht.name,
 			at.id, at.name,
 			m.home_score, m.away_score,
 			t.id, t.name,
-			m.city, m.country, m.neutral
-		FROM football_matches m
-		JOIN football_teams ht      ON ht.id = m.home_team_id
-		JOIN football_teams at      ON at.id = m.away_team_id
+			m.city, m.country, m.neutral,
+			m.created_at, m.updated_at
+		FROM ` + matches + ` m
+		JOIN ` + teams + ` ht ON ht.id = m.home_team_id
+		JOIN ` + teams + ` at ON at.id = m.away_team_id
 		JOIN football_tournaments t ON t.id  = m.tournament_id
-		ORDER BY m.match_date DESC
+		WHERE ($3::timestamptz IS NULL OR m.created_at > $3)
+		  AND ($4::timestamptz IS NULL OR m.updated_at >= $4)
+		ORDER BY m.match_date DESC, m.id ASC
 		LIMIT $1 OFFSET $2`
 
-	rows, err := r.db.Query(q, limit, offset)
+	rows, err := r.db.Query(q, args...)
 	if err != nil {
 		return nil, fmt.Errorf("footballRepo.ListMatches: %w", err)
 	}
@@ -169,14 +277,19 @@ func (r *FootballRepo) ListMatches(limit, offset int) ([]models.Match, error) {
 // GetMatchByID returns the match with the given ID.
 // Returns ErrNotFound when no matching row exists.
 func (r *FootballRepo) GetMatchByID(id int) (models.Match, error) {
-	const q = `
+	return getMatchByID(r.stmts, id)
+}
+
+func getMatchByID(q rowQuerier, id int) (models.Match, error) {
+	const query = `
 		SELECT
 			m.id, m.match_date,
 			ht.id, ht.name,
 			at.id, at.name,
 			m.home_score, m.away_score,
 			t.id, t.name,
-			m.city, m.country, m.neutral
+			m.city, m.country, m.neutral,
+			m.created_at, m.updated_at
 		FROM football_matches m
 		JOIN football_teams ht      ON ht.id = m.home_team_id
 		JOIN football_teams at      ON at.id = m.away_team_id
@@ -185,13 +298,14 @@ func (r *FootballRepo) GetMatchByID(id int) (models.Match, error) {
 
 	var m models.Match
 	var matchDate time.Time
-	err := r.db.QueryRow(q, id).Scan(
+	err := q.QueryRow(query, id).Scan(
 		&m.ID, &matchDate,
 		&m.HomeTeamID, &m.HomeTeam,
 		&m.AwayTeamID, &m.AwayTeam,
 		&m.HomeScore, &m.AwayScore,
 		&m.TournamentID, &m.Tournament,
 		&m.City, &m.Country, &m.Neutral,
+		&m.CreatedAt, &m.UpdatedAt,
 	)
 	if errors.Is(err, sql.ErrNoRows) {
 		return models.Match{}, models.ErrNotFound
@@ -203,6 +317,43 @@ func (r *FootballRepo) GetMatchByID(id int) (models.Match, error) {
 	return m, nil
 }
 
+// GetMatchAsOf returns the match with the given ID as it was at at, with
+// the team names of the time.  Returns ErrNotFound when it did not exist
+// then, or ErrNoHistory.
+func (r *FootballRepo) GetMatchAsOf(id int, at time.Time) (models.Match, error) {
+	if err := r.checkHistory(at); err != nil {
+		return models.Match{}, err
+	}
+	const q = `
+		SELECT
+			m.id, m.match_date,
+			ht.id, ht.name,
+			at.id, at.name,
+			m.home_score, m.away_score,
+			t.id, t.name,
+			m.city, m.country, m.neutral,
+			m.created_at, m.updated_at
+		FROM football_matches_as_of($2) m
+		JOIN football_teams_as_of($2) ht ON ht.id = m.home_team_id
+		JOIN football_teams_as_of($2) at ON at.id = m.away_team_id
+		JOIN football_tournaments t      ON t.id  = m.tournament_id
+		WHERE m.id = $1`
+
+	rows, err := r.db.Query(q, id, at)
+	if err != nil {
+		return models.Match{}, fmt.Errorf("footballRepo.GetMatchAsOf: %w", err)
+	}
+	defer rows.Close()
+	matches, err := scanMatchRows(rows)
+	if err != nil {
+		return models.Match{}, err
+	}
+	if len(matches) == 0 {
+		return models.Match{}, models.ErrNotFound
+	}
+	return matches[0], nil
+}
+
 // GetHeadToHead returns all matches between two teams ordered by date descending.
 func (r *FootballRepo) GetHeadToHead(teamA, teamB int) ([]models.Match, error) {
 	const q = `
@@ -212,14 +363,15 @@ func (r *FootballRepo) GetHeadToHead(teamA, teamB int) ([]models.Match, error) {
 			at.id, at.name,
 			m.home_score, m.away_score,
 			t.id, t.name,
-			m.city, m.country, m.neutral
+			m.city, m.country, m.neutral,
+			m.created_at, m.updated_at
 		FROM football_matches m
 		JOIN football_teams ht      ON ht.id = m.home_team_id
 		JOIN football_teams at      ON at.id = m.away_team_id
 		JOIN football_tournaments t ON t.id  = m.tournament_id
 		WHERE (m.home_team_id = $1 AND m.away_team_id = $2)
 		   OR (m.home_team_id = $2 AND m.away_team_id = $1)
-		ORDER BY m.match_date DESC`
+		ORDER BY m.match_date DESC, m.id ASC`
 
 	rows, err := r.db.Query(q, teamA, teamB)
 	if err != nil {
@@ -291,12 +443,20 @@ func (r *FootballRepo) GetPlayerGoals(scorer string) ([]models.Goal, error) {
 // CreateTeam inserts a new national team and returns the populated record.
 func (r *FootballRepo) CreateTeam(name string) (models.Team, error) {
 	const q = `
-		INSERT INTO football_teams (name)
-		VALUES ($1)
-		RETURNING id, name, created_at`
+		INSERT INTO football_teams (name, slug)
+		VALUES ($1, $2)
+		RETURNING ` + teamColumns
 
 	var t models.Team
-	err := r.db.QueryRow(q, name).Scan(&t.ID, &t.Name, &t.CreatedAt)
+	err := withSlugRetry(func() error {
+		return r.inTx(func(tx *sql.Tx) error {
+			sl, err := teamSlug(tx, name, 0, "")
+			if err != nil {
+				return err
+			}
+			return r.stmts.tx(tx).QueryRow(q, name, sl).Scan(teamFields(&t)...)
+		})
+	})
 	if err != nil {
 		if isUniqueViolation(err) {
 			return models.Team{}, models.ErrConflict
@@ -311,12 +471,24 @@ func (r *FootballRepo) CreateTeam(name string) (models.Team, error) {
 func (r *FootballRepo) UpdateTeam(id int, name string) (models.Team, error) {
 	const q = `
 		UPDATE football_teams
-		SET name = $2
+		SET name = $2, slug = $3, updated_at = NOW()
 		WHERE id = $1
-		RETURNING id, name, created_at`
+		RETURNING ` + teamColumns
 
 	var t models.Team
-	err := r.db.QueryRow(q, id, name).Scan(&t.ID, &t.Name, &t.CreatedAt)
+	err := withSlugRetry(func() error {
+		return r.inTx(func(tx *sql.Tx) error {
+			var current string
+			if err := tx.QueryRow(`SELECT slug FROM football_teams WHERE id = $1 FOR UPDATE`, id).Scan(&current); err != nil {
+				return err
+			}
+			sl, err := teamSlug(tx, name, id, current)
+			if err != nil {
+				return err
+			}
+			return r.stmts.tx(tx).QueryRow(q, id, name, sl).Scan(teamFields(&t)...)
+		})
+	})
 	if errors.Is(err, sql.ErrNoRows) {
 		return models.Team{}, models.ErrNotFound
 	}
@@ -329,6 +501,147 @@ func (r *FootballRepo) UpdateTeam(id int, name string) (models.Team, error) {
 	return t, nil
 }
 
+// SetTeamTranslation sets the name of team id in language lang.
+// Returns ErrNotFound when no matching row exists.
+func (r *FootballRepo) SetTeamTranslation(id int, lang, name string) (models.Team, error) {
+	const q = `
+		UPDATE football_teams
+		SET translations = translations || jsonb_build_object($2::text, $3::text), updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + teamColumns
+
+	var t models.Team
+	err := r.db.QueryRow(q, id, lang, name).Scan(teamFields(&t)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Team{}, models.ErrNotFound
+	}
+	if err != nil {
+		return models.Team{}, fmt.Errorf("footballRepo.SetTeamTranslation: %w", err)
+	}
+	return t, nil
+}
+
+// DeleteTeamTranslation removes the name of team id in language lang.
+// Returns ErrNotFound when the team or the translation does not exist.
+func (r *FootballRepo) DeleteTeamTranslation(id int, lang string) (models.Team, error) {
+	const q = `
+		UPDATE football_teams
+		SET translations = translations - $2::text, updated_at = NOW()
+		WHERE id = $1 AND translations ? $2::text
+		RETURNING ` + teamColumns
+
+	var t models.Team
+	err := r.db.QueryRow(q, id, lang).Scan(teamFields(&t)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Team{}, models.ErrNotFound
+	}
+	if err != nil {
+		return models.Team{}, fmt.Errorf("footballRepo.DeleteTeamTranslation: %w", err)
+	}
+	return t, nil
+}
+
+// ResolveTeamAlias returns the team that the team with the given ID was
+// merged into.  Returns ErrNotFound when it was not merged.
+func (r *FootballRepo) ResolveTeamAlias(id int) (int, error) {
+	const q = `SELECT target_id FROM football_team_aliases WHERE team_id = $1`
+
+	var target int
+	err := r.db.QueryRow(q, id).Scan(&target)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, models.ErrNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("footballRepo.ResolveTeamAlias: %w", err)
+	}
+	return target, nil
+}
+
+// MergeTeam folds team id into target in one transaction.  See
+// repository.Football.
+func (r *FootballRepo) MergeTeam(id, target int) (models.Team, error) {
+	var t models.Team
+	err := r.inTx(func(tx *sql.Tx) error {
+		// Lock both rows, in ID order so that concurrent merges cannot
+		// deadlock, and read the name that becomes a former name.
+		rows, err := tx.Query(`SELECT id, name FROM football_teams WHERE id IN ($1, $2) ORDER BY id FOR UPDATE`, id, target)
+		if err != nil {
+			return err
+		}
+		var name string
+		found := 0
+		for rows.Next() {
+			var tid int
+			var n string
+			if err := rows.Scan(&tid, &n); err != nil {
+				rows.Close()
+				return err
+			}
+			if tid == id {
+				name = n
+			}
+			found++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if found != 2 {
+			return models.ErrNotFound
+		}
+
+		var played bool
+		if err := tx.QueryRow(`
+			SELECT EXISTS (SELECT 1 FROM football_matches
+			               WHERE (home_team_id = $1 AND away_team_id = $2)
+			                  OR (home_team_id = $2 AND away_team_id = $1))`, id, target).Scan(&played); err != nil {
+			return err
+		}
+		if played {
+			return models.ErrConflict
+		}
+
+		for _, q := range []string{
+			`UPDATE football_matches SET home_team_id = $2, updated_at = NOW() WHERE home_team_id = $1`,
+			`UPDATE football_matches SET away_team_id = $2, updated_at = NOW() WHERE away_team_id = $1`,
+			`UPDATE football_goalscorers SET team_id = $2 WHERE team_id = $1`,
+			`UPDATE football_shootouts SET winner_id = $2 WHERE winner_id = $1`,
+			`UPDATE football_former_names SET team_id = $2 WHERE team_id = $1`,
+			`UPDATE football_team_aliases SET target_id = $2 WHERE target_id = $1`,
+		} {
+			if _, err := tx.Exec(q, id, target); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec(`INSERT INTO football_former_names (team_id, former_name) VALUES ($1, $2)`, target, name); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO football_team_aliases (team_id, target_id, name) VALUES ($1, $2, $3)`, id, target, name); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM football_teams WHERE id = $1`, id); err != nil {
+			return err
+		}
+		// Ratings depend on every match, so the whole cache is stale.
+		if _, err := tx.Exec(`DELETE FROM football_elo_cache`); err != nil {
+			return err
+		}
+		return tx.QueryRow(`
+			UPDATE football_teams SET updated_at = NOW() WHERE id = $1
+			RETURNING `+teamColumns, target).Scan(teamFields(&t)...)
+	})
+	if errors.Is(err, models.ErrNotFound) || errors.Is(err, models.ErrConflict) {
+		return models.Team{}, err
+	}
+	if err != nil {
+		if isUniqueViolation(err) {
+			return models.Team{}, models.ErrConflict
+		}
+		return models.Team{}, fmt.Errorf("footballRepo.MergeTeam: %w", err)
+	}
+	return t, nil
+}
+
 // DeleteTeam removes the team with the given ID.
 // Returns ErrNotFound when no matching row exists.
 func (r *FootballRepo) DeleteTeam(id int) error {
@@ -357,19 +670,28 @@ func (r *FootballRepo) CreateMatch(m models.Match) (models.Match, error) {
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id`
 
-	var id int
-	err := r.db.QueryRow(q,
-		m.Date, m.HomeTeamID, m.AwayTeamID,
-		m.HomeScore, m.AwayScore, m.TournamentID,
-		m.City, m.Country, m.Neutral,
-	).Scan(&id)
+	var created models.Match
+	err := r.inTx(func(tx *sql.Tx) error {
+		stmts := r.stmts.tx(tx)
+		var id int
+		if err := stmts.QueryRow(q,
+			m.Date, m.HomeTeamID, m.AwayTeamID,
+			m.HomeScore, m.AwayScore, m.TournamentID,
+			m.City, m.Country, m.Neutral,
+		).Scan(&id); err != nil {
+			return err
+		}
+		var err error
+		created, err = getMatchByID(stmts, id)
+		return err
+	})
 	if err != nil {
 		if isUniqueViolation(err) {
 			return models.Match{}, models.ErrConflict
 		}
 		return models.Match{}, fmt.Errorf("footballRepo.CreateMatch: %w", err)
 	}
-	return r.GetMatchByID(id)
+	return created, nil
 }
 
 // UpdateMatch replaces the fields of an existing match.
@@ -379,48 +701,202 @@ func (r *FootballRepo) UpdateMatch(id int, m models.Match) (models.Match, error)
 		UPDATE football_matches
 		SET match_date=$2, home_team_id=$3, away_team_id=$4,
 		    home_score=$5, away_score=$6, tournament_id=$7,
-		    city=$8, country=$9, neutral=$10
+		    city=$8, country=$9, neutral=$10, updated_at=NOW()
 		WHERE id=$1`
 
-	result, err := r.db.Exec(q,
-		id,
-		m.Date, m.HomeTeamID, m.AwayTeamID,
-		m.HomeScore, m.AwayScore, m.TournamentID,
-		m.City, m.Country, m.Neutral,
-	)
+	var updated models.Match
+	err := r.inTx(func(tx *sql.Tx) error {
+		stmts := r.stmts.tx(tx)
+		result, err := stmts.Exec(q,
+			id,
+			m.Date, m.HomeTeamID, m.AwayTeamID,
+			m.HomeScore, m.AwayScore, m.TournamentID,
+			m.City, m.Country, m.Neutral,
+		)
+		if err != nil {
+			return err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return models.ErrNotFound
+		}
+		updated, err = getMatchByID(stmts, id)
+		return err
+	})
+	if errors.Is(err, models.ErrNotFound) {
+		return models.Match{}, models.ErrNotFound
+	}
 	if err != nil {
 		if isUniqueViolation(err) {
 			return models.Match{}, models.ErrConflict
 		}
 		return models.Match{}, fmt.Errorf("footballRepo.UpdateMatch: %w", err)
 	}
-	n, err := result.RowsAffected()
-	if err != nil {
-		return models.Match{}, fmt.Errorf("footballRepo.UpdateMatch rowsAffected: %w", err)
-	}
-	if n == 0 {
-		return models.Match{}, models.ErrNotFound
-	}
-	return r.GetMatchByID(id)
+	return updated, nil
 }
 
-// DeleteMatch removes the match with the given ID.
+// DeleteMatch removes the match with the given ID and records a tombstone
+// for incremental sync, pruning tombstones older than db.TombstoneRetention.
 // Returns ErrNotFound when no matching row exists.
 func (r *FootballRepo) DeleteMatch(id int) error {
-	const q = `DELETE FROM football_matches WHERE id = $1`
+	const (
+		del  = `DELETE FROM football_matches WHERE id = $1`
+		tomb = `
+			INSERT INTO football_match_tombstones (match_id, deleted_at)
+			VALUES ($1, NOW())
+			ON CONFLICT (match_id) DO UPDATE SET deleted_at = EXCLUDED.deleted_at`
+		prune = `DELETE FROM football_match_tombstones WHERE deleted_at < NOW() - make_interval(secs => $1)`
+	)
 
-	result, err := r.db.Exec(q, id)
+	err := r.inTx(func(tx *sql.Tx) error {
+		result, err := tx.Exec(del, id)
+		if err != nil {
+			return err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return models.ErrNotFound
+		}
+		if _, err := tx.Exec(tomb, id); err != nil {
+			return err
+		}
+		_, err = tx.Exec(prune, db.TombstoneRetention.Seconds())
+		return err
+	})
+	if errors.Is(err, models.ErrNotFound) {
+		return models.ErrNotFound
+	}
 	if err != nil {
 		return fmt.Errorf("footballRepo.DeleteMatch: %w", err)
 	}
-	n, err := result.RowsAffected()
+	return nil
+}
+
+// MatchTombstone returns when the match with the given ID was deleted, from
+// the revision history.  Returns ErrNotFound when it exists or no deletion
+// is recorded.
+func (r *FootballRepo) MatchTombstone(id int) (models.Tombstone, error) {
+	t, err := r.tombstone("football_matches", id)
+	if err != nil && !errors.Is(err, models.ErrNotFound) {
+		return t, fmt.Errorf("footballRepo.MatchTombstone: %w", err)
+	}
+	return t, err
+}
+
+// TeamTombstone returns when the team with the given ID was deleted; see
+// MatchTombstone.
+func (r *FootballRepo) TeamTombstone(id int) (models.Tombstone, error) {
+	t, err := r.tombstone("football_teams", id)
+	if err != nil && !errors.Is(err, models.ErrNotFound) {
+		return t, fmt.Errorf("footballRepo.TeamTombstone: %w", err)
+	}
+	return t, err
+}
+
+// tombstone reads the deletion of row id of table from football_revisions:
+// the latest revision of a row that no longer exists is its deletion.
+func (r *FootballRepo) tombstone(table string, id int) (models.Tombstone, error) {
+	q := `
+		SELECT at FROM football_revisions
+		WHERE table_name = $1 AND row_id = $2 AND old IS NOT NULL
+		  AND NOT EXISTS (SELECT 1 FROM ` + table + ` WHERE id = $2)
+		ORDER BY at DESC, id DESC
+		LIMIT 1`
+
+	t := models.Tombstone{ID: id}
+	err := r.db.QueryRow(q, table, id).Scan(&t.DeletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Tombstone{}, models.ErrNotFound
+	}
+	return t, err
+}
+
+// PurgeTombstones removes tombstones older than db.TombstoneRetention and
+// returns how many were deleted.  DeleteMatch prunes as it goes; this catches
+// up when no match has been deleted for a while.
+func (r *FootballRepo) PurgeTombstones(ctx context.Context) (int64, error) {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM football_match_tombstones WHERE deleted_at < NOW() - make_interval(secs => $1)`,
+		db.TombstoneRetention.Seconds())
 	if err != nil {
-		return fmt.Errorf("footballRepo.DeleteMatch rowsAffected: %w", err)
+		return 0, fmt.Errorf("footballRepo.PurgeTombstones: %w", err)
 	}
-	if n == 0 {
-		return models.ErrNotFound
+	return res.RowsAffected()
+}
+
+// MatchChangesSince returns the matches created or updated at or after since
+// and the tombstones of matches deleted at or after since, both read from
+// one read-only REPEATABLE READ snapshot so that they agree with each other.
+func (r *FootballRepo) MatchChangesSince(since time.Time) (models.MatchChanges, error) {
+	const (
+		changed = `
+			SELECT
+				m.id, m.match_date,
+				ht.id, ht.name,
+				at.id, at.name,
+				m.home_score, m.away_score,
+				t.id, t.name,
+				m.city, m.country, m.neutral,
+				m.created_at, m.updated_at
+			FROM football_matches m
+			JOIN football_teams ht      ON ht.id = m.home_team_id
+			JOIN football_teams at      ON at.id = m.away_team_id
+			JOIN football_tournaments t ON t.id  = m.tournament_id
+			WHERE m.updated_at >= $1
+			ORDER BY m.updated_at ASC, m.id ASC`
+		deleted = `
+			SELECT match_id, deleted_at
+			FROM football_match_tombstones
+			WHERE deleted_at >= $1
+			ORDER BY deleted_at ASC, match_id ASC`
+	)
+
+	tx, err := r.db.BeginTx(context.Background(), &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  true,
+	})
+	if err != nil {
+		return models.MatchChanges{}, fmt.Errorf("footballRepo.MatchChangesSince: %w", err)
 	}
-	return nil
+	defer tx.Rollback()
+
+	var ch models.MatchChanges
+	if err := tx.QueryRow(`SELECT NOW()`).Scan(&ch.AsOf); err != nil {
+		return models.MatchChanges{}, fmt.Errorf("footballRepo.MatchChangesSince now: %w", err)
+	}
+
+	rows, err := tx.Query(changed, since)
+	if err != nil {
+		return models.MatchChanges{}, fmt.Errorf("footballRepo.MatchChangesSince: %w", err)
+	}
+	ch.Changed, err = scanMatchRows(rows)
+	rows.Close()
+	if err != nil {
+		return models.MatchChanges{}, fmt.Errorf("footballRepo.MatchChangesSince: %w", err)
+	}
+
+	rows, err = tx.Query(deleted, since)
+	if err != nil {
+		return models.MatchChanges{}, fmt.Errorf("footballRepo.MatchChangesSince tombstones: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t models.Tombstone
+		if err := rows.Scan(&t.ID, &t.DeletedAt); err != nil {
+			return models.MatchChanges{}, fmt.Errorf("footballRepo.MatchChangesSince tombstones scan: %w", err)
+		}
+		ch.Deleted = append(ch.Deleted, t)
+	}
+	if err := rows.Err(); err != nil {
+		return models.MatchChanges{}, fmt.Errorf("footballRepo.MatchChangesSince tombstones rows: %w", err)
+	}
+	return ch, tx.Commit()
 }
 
 // CreateGoal inserts a new goal record and returns the populated Goal.
@@ -501,6 +977,77 @@ func isUniqueViolation(err error) bool {
 	return errors.As(err, &pqErr) && pqErr.Code == "23505"
 }
 
+// teamColumns are the football_teams columns teamFields scans.
+const teamColumns = `id, name, slug, translations, created_at, updated_at`
+
+// teamFields returns the scan destinations for teamColumns.
+func teamFields(t *models.Team) []any {
+	return []any{&t.ID, &t.Name, &t.Slug, (*translations)(&t.Translations), &t.CreatedAt, &t.UpdatedAt}
+}
+
+// translations scans the JSONB translations column, leaving the map nil
+// when it is empty.
+type translations map[string]string
+
+func (tr *translations) Scan(src any) error {
+	b, ok := src.([]byte)
+	if !ok {
+		return fmt.Errorf("translations: unexpected %T", src)
+	}
+	var m map[string]string
+	if err := json.Unmarshal(b, &m); err != nil {
+		return fmt.Errorf("translations: %w", err)
+	}
+	if len(m) == 0 {
+		m = nil
+	}
+	*tr = m
+	return nil
+}
+
+// teamSlug returns the slug for team id named name: current if name still
+// yields it, or else the first that no other team has.  A concurrent write
+// may claim the same slug first, which the unique index refuses; see
+// withSlugRetry.
+func teamSlug(tx *sql.Tx, name string, id int, current string) (string, error) {
+	base := cmp.Or(slug.Make(name), "team")
+	if current != "" && slug.Of(current, base) {
+		return current, nil
+	}
+	rows, err := tx.Query(`
+		SELECT slug FROM football_teams
+		WHERE (slug = $1 OR slug LIKE $1 || '-%') AND id <> $2`, base, id)
+	if err != nil {
+		return "", fmt.Errorf("teamSlug: %w", err)
+	}
+	defer rows.Close()
+	taken := make(map[string]bool)
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return "", fmt.Errorf("teamSlug scan: %w", err)
+		}
+		taken[s] = true
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("teamSlug rows: %w", err)
+	}
+	return slug.Unique(base, func(s string) bool { return taken[s] }), nil
+}
+
+// withSlugRetry runs fn again, up to three times in all, while it fails
+// because another team took the slug it chose in the meantime.
+func withSlugRetry(fn func() error) error {
+	var err error
+	for range 3 {
+		var pqErr *pq.Error
+		if err = fn(); !errors.As(err, &pqErr) || pqErr.Constraint != "football_teams_slug_key" {
+			return err
+		}
+	}
+	return err
+}
+
 // scanMatchRows reads Match rows from a *sql.Rows cursor.
 func scanMatchRows(rows *sql.Rows) ([]models.Match, error) {
 	var matches []models.Match
@@ -514,6 +1061,7 @@ func scanMatchRows(rows *sql.Rows) ([]models.Match, error) {
 			&m.HomeScore, &m.AwayScore,
 			&m.TournamentID, &m.Tournament,
 			&m.City, &m.Country, &m.Neutral,
+			&m.CreatedAt, &m.UpdatedAt,
 		); err != nil {
 			return nil, fmt.Errorf("scanMatchRows: %w", err)
 		}
@@ -0,0 +1,84 @@
+package postgres_test
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/postgres"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// benchDB connects to the database named by TEST_DATABASE_URL, which must
+// hold at least one imported match.  Benchmarks are skipped without it.
+func benchDB(b *testing.B) (*sql.DB, int) {
+	b.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		b.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := postgres.Connect(dsn)
+	if err != nil {
+		b.Fatalf("connect: %v", err)
+	}
+	b.Cleanup(func() { db.Close() })
+
+	var id int
+	if err := db.QueryRow(`SELECT id FROM football_matches LIMIT 1`).Scan(&id); err != nil {
+		b.Skipf("no match to read: %v", err)
+	}
+	return db, id
+}
+
+// BenchmarkGetMatchByID_Prepared reads a match through the repo, which reuses
+// one prepared statement across calls.
+func BenchmarkGetMatchByID_Prepared(b *testing.B) {
+	db, id := benchDB(b)
+	repo := postgres.NewFootballRepo(db, postgres.TxOptions{})
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := repo.GetMatchByID(id); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkGetMatchByID_Unprepared is the baseline: the same query sent with
+// db.QueryRow, which lib/pq parses and plans afresh on every call.
+func BenchmarkGetMatchByID_Unprepared(b *testing.B) {
+	db, id := benchDB(b)
+	const q = `
+		SELECT
+			m.id, m.match_date,
+			ht.id, ht.name,
+			at.id, at.name,
+			m.home_score, m.away_score,
+			t.id, t.name,
+			m.city, m.country, m.neutral,
+			m.created_at, m.updated_at
+		FROM football_matches m
+		JOIN football_teams ht      ON ht.id = m.home_team_id
+		JOIN football_teams at      ON at.id = m.away_team_id
+		JOIN football_tournaments t ON t.id  = m.tournament_id
+		WHERE m.id = $1`
+
+	b.RunParallel(func(pb *testing.PB) {
+		var m models.Match
+		for pb.Next() {
+			err := db.QueryRow(q, id).Scan(
+				&m.ID, &m.Date,
+				&m.HomeTeamID, &m.HomeTeam,
+				&m.AwayTeamID, &m.AwayTeam,
+				&m.HomeScore, &m.AwayScore,
+				&m.TournamentID, &m.Tournament,
+				&m.City, &m.Country, &m.Neutral,
+				&m.CreatedAt, &m.UpdatedAt,
+			)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,216 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/redact"
+)
+
+// slowQueries counts queries slower than the configured threshold.  It is
+// published through expvar, so it appears at /debug/vars.
+var slowQueries = expvar.NewInt("db_slow_queries")
+
+// SlowQueryLog configures query instrumentation.
+type SlowQueryLog struct {
+	// Threshold is the duration above which a query is logged and counted.
+	// Zero disables instrumentation.
+	Threshold time.Duration
+	// Logger receives slow-query records; nil uses slog.Default().
+	Logger *slog.Logger
+	// ShowArgs logs string arguments in plain text instead of pseudonyms.
+	// Intended for local debugging only.
+	ShowArgs bool
+	// Key keys the pseudonyms; see redact.Pseudonym.
+	Key []byte
+}
+
+// ConnectInstrumented behaves like Connect but, when log.Threshold is set,
+// wraps the driver so that every query, exec and prepared-statement call
+// slower than the threshold is logged at warn level with its SQL, duration
+// and redacted arguments, and counted in the db_slow_queries metric.
+// Repeated slow entries for the same statement usually point to a missing
+// index.
+func ConnectInstrumented(dsn string, log SlowQueryLog) (*sql.DB, error) {
+	if log.Threshold <= 0 {
+		return Connect(dsn)
+	}
+	if log.Logger == nil {
+		log.Logger = slog.Default()
+	}
+
+	connector, err := pq.NewConnector(utcDSN(dsn))
+	if err != nil {
+		return nil, fmt.Errorf("postgres: open: %w", err)
+	}
+	return configure(sql.OpenDB(instrumentedConnector{Connector: connector, log: log}))
+}
+
+// observe logs and counts a call that took longer than the threshold.
+func (l SlowQueryLog) observe(query string, args []driver.NamedValue, start time.Time) {
+	elapsed := time.Since(start)
+	if elapsed < l.Threshold {
+		return
+	}
+	slowQueries.Add(1)
+	l.Logger.Warn("slow query",
+		"duration", elapsed,
+		"query", strings.Join(strings.Fields(query), " "),
+		"args", l.redactArgs(args),
+	)
+}
+
+// redactArgs renders query arguments for logging.  Strings and byte slices
+// can hold personal data or credentials, so they are replaced with stable
+// pseudonyms; numbers, booleans and times are logged as-is.
+func (l SlowQueryLog) redactArgs(args []driver.NamedValue) []string {
+	out := make([]string, len(args))
+	for i, a := range args {
+		switch v := a.Value.(type) {
+		case string:
+			if !l.ShowArgs {
+				v = redact.Pseudonym(l.Key, v)
+			}
+			out[i] = v
+		case []byte:
+			if l.ShowArgs {
+				out[i] = string(v)
+			} else {
+				out[i] = redact.Pseudonym(l.Key, string(v))
+			}
+		case nil:
+			out[i] = "NULL"
+		default:
+			out[i] = fmt.Sprint(v)
+		}
+	}
+	return out
+}
+
+// instrumentedConnector wraps the lib/pq connector so that each connection
+// it opens is timed.
+type instrumentedConnector struct {
+	*pq.Connector
+	log SlowQueryLog
+}
+
+func (c instrumentedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedConn{Conn: cn, log: c.log}, nil
+}
+
+// instrumentedConn times queries run directly on a connection and wraps the
+// statements it prepares.  Optional driver interfaces are forwarded when the
+// underlying connection implements them.
+type instrumentedConn struct {
+	driver.Conn
+	log SlowQueryLog
+}
+
+func (c *instrumentedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	defer c.log.observe(query, args, time.Now())
+	return q.QueryContext(ctx, query, args)
+}
+
+func (c *instrumentedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	defer c.log.observe(query, args, time.Now())
+	return e.ExecContext(ctx, query, args)
+}
+
+func (c *instrumentedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var (
+		st  driver.Stmt
+		err error
+	)
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		st, err = p.PrepareContext(ctx, query)
+	} else {
+		st, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedStmt{Stmt: st, query: query, log: c.log}, nil
+}
+
+func (c *instrumentedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *instrumentedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *instrumentedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// instrumentedStmt times executions of a prepared statement, including the
+// statements behind FootballRepo's statement cache and COPY FROM.
+type instrumentedStmt struct {
+	driver.Stmt
+	query string
+	log   SlowQueryLog
+}
+
+func (s *instrumentedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	defer s.log.observe(s.query, args, time.Now())
+	if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		return q.QueryContext(ctx, args)
+	}
+	return s.Stmt.Query(namedValues(args))
+}
+
+func (s *instrumentedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	defer s.log.observe(s.query, args, time.Now())
+	if e, ok := s.Stmt.(driver.StmtExecContext); ok {
+		return e.ExecContext(ctx, args)
+	}
+	return s.Stmt.Exec(namedValues(args))
+}
+
+func namedValues(args []driver.NamedValue) []driver.Value {
+	vals := make([]driver.Value, len(args))
+	for i, a := range args {
+		vals[i] = a.Value
+	}
+	return vals
+}
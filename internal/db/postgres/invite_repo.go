@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// InviteRepo is a PostgreSQL-backed implementation of db.InviteRepository.
+type InviteRepo struct {
+	db *sql.DB
+}
+
+// NewInviteRepo constructs an InviteRepo backed by the provided *sql.DB.
+func NewInviteRepo(db *sql.DB) *InviteRepo {
+	return &InviteRepo{db: db}
+}
+
+// CreateInvite stores a new invite.  Returns models.ErrConflict when the
+// code is already taken.
+func (r *InviteRepo) CreateInvite(inv models.Invite) (models.Invite, error) {
+	const q = `
+		INSERT INTO invites (code, max_uses, expires_at, created_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING uses, created_at`
+
+	err := r.db.QueryRow(q, inv.Code, inv.MaxUses, inv.ExpiresAt, inv.CreatedBy).Scan(&inv.Uses, &inv.CreatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return models.Invite{}, models.ErrConflict
+		}
+		return models.Invite{}, fmt.Errorf("inviteRepo.CreateInvite: %w", err)
+	}
+	return inv, nil
+}
+
+// ListInvites returns every invite, newest first.
+func (r *InviteRepo) ListInvites() ([]models.Invite, error) {
+	rows, err := r.db.Query(`
+		SELECT code, max_uses, uses, expires_at, created_by, created_at
+		FROM invites
+		ORDER BY created_at DESC, code`)
+	if err != nil {
+		return nil, fmt.Errorf("inviteRepo.ListInvites: %w", err)
+	}
+	defer rows.Close()
+
+	invites := []models.Invite{}
+	for rows.Next() {
+		var inv models.Invite
+		if err := rows.Scan(&inv.Code, &inv.MaxUses, &inv.Uses, &inv.ExpiresAt, &inv.CreatedBy, &inv.CreatedAt); err != nil {
+			return nil, fmt.Errorf("inviteRepo.ListInvites: scan: %w", err)
+		}
+		invites = append(invites, inv)
+	}
+	return invites, rows.Err()
+}
+
+// RedeemInvite takes one use of the invite in a single conditional update,
+// so concurrent registrations cannot exceed max_uses.  Returns
+// models.ErrNotFound when the invite is unknown, expired or used up.
+func (r *InviteRepo) RedeemInvite(code string) error {
+	res, err := r.db.Exec(`
+		UPDATE invites SET uses = uses + 1
+		WHERE code = $1 AND expires_at > NOW() AND uses < max_uses`, code)
+	if err != nil {
+		return fmt.Errorf("inviteRepo.RedeemInvite: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+// ReleaseInvite gives back one use of the invite.
+func (r *InviteRepo) ReleaseInvite(code string) error {
+	res, err := r.db.Exec(`UPDATE invites SET uses = GREATEST(uses - 1, 0) WHERE code = $1`, code)
+	if err != nil {
+		return fmt.Errorf("inviteRepo.ReleaseInvite: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+// DeleteInvite removes an invite.  Returns models.ErrNotFound when there is
+// no such invite.
+func (r *InviteRepo) DeleteInvite(code string) error {
+	res, err := r.db.Exec(`DELETE FROM invites WHERE code = $1`, code)
+	if err != nil {
+		return fmt.Errorf("inviteRepo.DeleteInvite: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// LoginRepo is a PostgreSQL-backed implementation of db.LoginRepository.
+type LoginRepo struct {
+	db *sql.DB
+}
+
+// NewLoginRepo constructs a LoginRepo backed by the provided *sql.DB.
+func NewLoginRepo(db *sql.DB) *LoginRepo {
+	return &LoginRepo{db: db}
+}
+
+// RecordLogin stores a sign-in and, while at it, removes the user's
+// sign-ins from before retain.  Whether the device and network are new is
+// judged against the sign-ins kept.
+func (r *LoginRepo) RecordLogin(l models.Login, retain time.Time) (models.Login, error) {
+	if _, err := r.db.Exec(
+		`DELETE FROM login_activity WHERE username = $1 AND at < $2`, l.Username, retain,
+	); err != nil {
+		return models.Login{}, fmt.Errorf("loginRepo.RecordLogin: purge: %w", err)
+	}
+
+	const q = `
+		WITH prior AS (
+			SELECT fingerprint, network FROM login_activity WHERE username = $1
+		)
+		INSERT INTO login_activity
+			(username, session_id, at, ip_address, user_agent, device, fingerprint, network, new_device, new_location)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8,
+			EXISTS (SELECT 1 FROM prior) AND NOT EXISTS (SELECT 1 FROM prior WHERE fingerprint = $7),
+			EXISTS (SELECT 1 FROM prior) AND NOT EXISTS (SELECT 1 FROM prior WHERE network = $8)
+		RETURNING id, new_device, new_location`
+
+	err := r.db.QueryRow(q, l.Username, l.SessionID, l.At, l.IPAddress, l.UserAgent, l.Device, l.Fingerprint, l.Network).
+		Scan(&l.ID, &l.NewDevice, &l.NewLocation)
+	if err != nil {
+		return models.Login{}, fmt.Errorf("loginRepo.RecordLogin: %w", err)
+	}
+	return l, nil
+}
+
+// ListLogins returns the user's most recent sign-ins, newest first.
+func (r *LoginRepo) ListLogins(username string, limit int) ([]models.Login, error) {
+	const q = `
+		SELECT id, session_id, at, ip_address, user_agent, device, fingerprint, network, new_device, new_location
+		FROM login_activity
+		WHERE username = $1
+		ORDER BY at DESC, id DESC
+		LIMIT $2`
+
+	rows, err := r.db.Query(q, username, limit)
+	if err != nil {
+		return nil, fmt.Errorf("loginRepo.ListLogins: %w", err)
+	}
+	defer rows.Close()
+
+	logins := []models.Login{}
+	for rows.Next() {
+		l := models.Login{Username: username}
+		if err := rows.Scan(&l.ID, &l.SessionID, &l.At, &l.IPAddress, &l.UserAgent, &l.Device,
+			&l.Fingerprint, &l.Network, &l.NewDevice, &l.NewLocation); err != nil {
+			return nil, fmt.Errorf("loginRepo.ListLogins: scan: %w", err)
+		}
+		logins = append(logins, l)
+	}
+	return logins, rows.Err()
+}
+
+// DeleteExpired removes every user's sign-ins older than
+// db.LoginRetention and returns how many were deleted.  RecordLogin only
+// purges the user signing in, so the history of users who never return is
+// left to this scheduled cleanup.
+func (r *LoginRepo) DeleteExpired(ctx context.Context) (int64, error) {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM login_activity WHERE at < NOW() - make_interval(secs => $1)`,
+		db.LoginRetention.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("loginRepo.DeleteExpired: %w", err)
+	}
+	return res.RowsAffected()
+}
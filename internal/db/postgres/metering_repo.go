@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// MeteringRepo is a PostgreSQL-backed implementation of
+// db.MeteringRepository.
+type MeteringRepo struct {
+	db *sql.DB
+}
+
+// NewMeteringRepo constructs a MeteringRepo backed by the provided *sql.DB.
+func NewMeteringRepo(db *sql.DB) *MeteringRepo {
+	return &MeteringRepo{db: db}
+}
+
+// AddUsage adds the records to usage_meter in one transaction, so a batch
+// is counted entirely or not at all.
+func (r *MeteringRepo) AddUsage(records []models.UsageRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	err := RunInTx(context.Background(), r.db, TxOptions{}, func(tx *sql.Tx) error {
+		for _, rec := range records {
+			if _, err := tx.Exec(`
+				INSERT INTO usage_meter (day, username, api_key, requests, bytes_in, bytes_out)
+				VALUES ($1, $2, $3, $4, $5, $6)
+				ON CONFLICT (username, day, api_key) DO UPDATE SET
+					requests = usage_meter.requests + EXCLUDED.requests,
+					bytes_in = usage_meter.bytes_in + EXCLUDED.bytes_in,
+					bytes_out = usage_meter.bytes_out + EXCLUDED.bytes_out`,
+				rec.Date, rec.Username, rec.Key, rec.Requests, rec.BytesIn, rec.BytesOut); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("meteringRepo.AddUsage: %w", err)
+	}
+	return nil
+}
+
+// ListUsage returns username's records in the range, oldest first.
+func (r *MeteringRepo) ListUsage(username, from, to string) ([]models.UsageRecord, error) {
+	const q = `
+		SELECT to_char(day, 'YYYY-MM-DD'), username, api_key, requests, bytes_in, bytes_out
+		FROM usage_meter
+		WHERE username = $1 AND day BETWEEN $2 AND $3
+		ORDER BY day, api_key`
+
+	rows, err := r.db.Query(q, username, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("meteringRepo.ListUsage: %w", err)
+	}
+	defer rows.Close()
+
+	out := []models.UsageRecord{}
+	for rows.Next() {
+		var rec models.UsageRecord
+		if err := rows.Scan(&rec.Date, &rec.Username, &rec.Key, &rec.Requests, &rec.BytesIn, &rec.BytesOut); err != nil {
+			return nil, fmt.Errorf("meteringRepo.ListUsage scan: %w", err)
+		}
+		out = append(out, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("meteringRepo.ListUsage rows: %w", err)
+	}
+	return out, nil
+}
+
+// UsageTotals sums the range per username and key, most requests first.
+func (r *MeteringRepo) UsageTotals(from, to string, limit, offset int) ([]models.UsageTotal, error) {
+	const q = `
+		SELECT username, api_key, SUM(requests), SUM(bytes_in), SUM(bytes_out)
+		FROM usage_meter
+		WHERE day BETWEEN $1 AND $2
+		GROUP BY username, api_key
+		ORDER BY SUM(requests) DESC, username, api_key
+		LIMIT $3 OFFSET $4`
+
+	rows, err := r.db.Query(q, from, to, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("meteringRepo.UsageTotals: %w", err)
+	}
+	defer rows.Close()
+
+	out := []models.UsageTotal{}
+	for rows.Next() {
+		var t models.UsageTotal
+		if err := rows.Scan(&t.Username, &t.Key, &t.Requests, &t.BytesIn, &t.BytesOut); err != nil {
+			return nil, fmt.Errorf("meteringRepo.UsageTotals scan: %w", err)
+		}
+		out = append(out, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("meteringRepo.UsageTotals rows: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/lock"
+)
+
+// migrateLock is the advisory lock held while migrations are applied, so
+// that replicas starting together apply each script once, in turn.
+const migrateLock = "schema-migrations"
+
+// Migration is a SQL script applied at most once, recorded by name in the
+// schema_migrations table.
+type Migration struct {
+	Name string
+	SQL  string
+}
+
+// MigrateResult summarises a Migrate run.
+type MigrateResult struct {
+	// Applied lists the migrations this instance applied, in order.
+	Applied []string
+	// Waited is how long the instance waited for another to release the
+	// migration lock.
+	Waited time.Duration
+	// Took is how long applying them took, excluding the wait.
+	Took time.Duration
+}
+
+// Migrate applies the migrations not yet recorded in schema_migrations, in
+// order, each in its own transaction.  It holds a session-level advisory
+// lock while it does, waiting up to wait for an instance already migrating,
+// so that concurrent replicas do not race: the first applies the scripts
+// and the rest find them recorded.  instance names this process in the
+// table and the log.
+func Migrate(ctx context.Context, db *sql.DB, instance string, wait time.Duration, migrations []Migration) (MigrateResult, error) {
+	var res MigrateResult
+	start := time.Now()
+	release, err := lock.NewPostgres(db).Acquire(ctx, migrateLock, wait)
+	if err != nil {
+		return res, fmt.Errorf("migrate: %w", err)
+	}
+	defer release()
+	res.Waited = time.Since(start)
+	start = time.Now()
+
+	if _, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			name        TEXT PRIMARY KEY,
+			applied_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			applied_by  TEXT NOT NULL,
+			duration_ms INTEGER NOT NULL
+		)`); err != nil {
+		return res, fmt.Errorf("migrate: create schema_migrations: %w", err)
+	}
+	applied := map[string]bool{}
+	rows, err := db.QueryContext(ctx, `SELECT name FROM schema_migrations`)
+	if err != nil {
+		return res, fmt.Errorf("migrate: %w", err)
+	}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return res, fmt.Errorf("migrate: %w", err)
+		}
+		applied[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return res, fmt.Errorf("migrate: %w", err)
+	}
+
+	for _, m := range migrations {
+		if applied[m.Name] {
+			continue
+		}
+		took, err := apply(ctx, db, instance, m)
+		if err != nil {
+			res.Took = time.Since(start)
+			return res, fmt.Errorf("migrate: %s: %w", m.Name, err)
+		}
+		log.Printf("migrate: %s applied %s in %v", instance, m.Name, took.Round(time.Millisecond))
+		res.Applied = append(res.Applied, m.Name)
+	}
+	res.Took = time.Since(start)
+	return res, nil
+}
+
+// apply runs m and records it in one transaction, so that a script that
+// fails part-way leaves nothing behind and is retried on the next start.
+func apply(ctx context.Context, db *sql.DB, instance string, m Migration) (time.Duration, error) {
+	start := time.Now()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, m.SQL); err != nil {
+		return 0, err
+	}
+	took := time.Since(start)
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO schema_migrations (name, applied_by, duration_ms) VALUES ($1, $2, $3)`,
+		m.Name, instance, took.Milliseconds()); err != nil {
+		return 0, err
+	}
+	return took, tx.Commit()
+}
@@ -0,0 +1,62 @@
+package postgres_test
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/postgres"
+)
+
+// TestMigrate_Concurrent starts several instances migrating at once and
+// checks that the script ran, and was recorded, exactly once.
+func TestMigrate_Concurrent(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := postgres.Connect(dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	cleanup := func() {
+		db.Exec(`DROP TABLE IF EXISTS migrate_test`)
+		db.Exec(`DELETE FROM schema_migrations WHERE name LIKE 'migrate-test/%'`)
+	}
+	cleanup()
+	t.Cleanup(cleanup)
+
+	// Not idempotent: a second run would fail on the existing table.
+	ms := []postgres.Migration{{Name: "migrate-test/001_create.sql", SQL: `CREATE TABLE migrate_test (id INTEGER)`}}
+	results := make([]postgres.MigrateResult, 4)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Go(func() {
+			res, err := postgres.Migrate(ctx, db, "test-"+string(rune('a'+i)), 30*time.Second, ms)
+			if err != nil {
+				t.Errorf("Migrate: %v", err)
+			}
+			results[i] = res
+		})
+	}
+	wg.Wait()
+
+	applied := 0
+	for _, res := range results {
+		applied += len(res.Applied)
+	}
+	if applied != 1 {
+		t.Fatalf("expected the migration applied once, got %d", applied)
+	}
+	var by string
+	if err := db.QueryRow(`SELECT applied_by FROM schema_migrations WHERE name = $1`, ms[0].Name).Scan(&by); err != nil {
+		t.Fatalf("schema_migrations: %v", err)
+	}
+	if by == "" {
+		t.Error("expected the applying instance recorded")
+	}
+}
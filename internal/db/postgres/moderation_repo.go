@@ -0,0 +1,211 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// ModerationRepo is a PostgreSQL-backed implementation of
+// db.ModerationRepository.
+type ModerationRepo struct {
+	db *sql.DB
+}
+
+// NewModerationRepo constructs a ModerationRepo backed by the provided
+// *sql.DB.
+func NewModerationRepo(db *sql.DB) *ModerationRepo {
+	return &ModerationRepo{db: db}
+}
+
+const moderationColumns = `kind, resource_id, state, report_count, first_reported_at, last_reported_at,
+	COALESCE(reviewed_by, ''), reviewed_at, resolution, quarantine_reason`
+
+func scanModerationItem(row interface{ Scan(...any) error }) (models.ModerationItem, error) {
+	var item models.ModerationItem
+	var reviewedAt sql.NullTime
+	err := row.Scan(&item.Kind, &item.ResourceID, &item.State, &item.ReportCount,
+		&item.FirstReportedAt, &item.LastReportedAt, &item.ReviewedBy, &reviewedAt, &item.Resolution, &item.QuarantineReason)
+	if err != nil {
+		return models.ModerationItem{}, err
+	}
+	if reviewedAt.Valid {
+		item.ReviewedAt = &reviewedAt.Time
+	}
+	return item, nil
+}
+
+// ReportContent records r and opens or reopens the content's item in one
+// transaction.  Returns models.ErrConflict when the reporter already
+// reported the content (unique_violation error code 23505).
+func (r *ModerationRepo) ReportContent(rep models.ContentReport) (models.ContentReport, error) {
+	out := rep
+	err := RunInTx(context.Background(), r.db, TxOptions{}, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`
+			INSERT INTO moderation_items (kind, resource_id)
+			VALUES ($1, $2)
+			ON CONFLICT (kind, resource_id) DO NOTHING`, rep.Kind, rep.ResourceID); err != nil {
+			return err
+		}
+		if err := tx.QueryRow(`
+			INSERT INTO content_reports (kind, resource_id, reporter, reason, note)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, created_at`, rep.Kind, rep.ResourceID, rep.Reporter, rep.Reason, rep.Note,
+		).Scan(&out.ID, &out.CreatedAt); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`
+			UPDATE moderation_items
+			SET report_count = report_count + 1,
+			    last_reported_at = NOW(),
+			    state = CASE WHEN state = 'dismissed' THEN 'open' ELSE state END
+			WHERE kind = $1 AND resource_id = $2`, rep.Kind, rep.ResourceID)
+		return err
+	})
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return models.ContentReport{}, models.ErrConflict
+		}
+		return models.ContentReport{}, fmt.Errorf("moderationRepo.ReportContent: %w", err)
+	}
+	return out, nil
+}
+
+// QuarantineContent opens the content's item, or reopens it, with reason.
+func (r *ModerationRepo) QuarantineContent(kind string, id int, reason string) error {
+	const q = `
+		INSERT INTO moderation_items (kind, resource_id, quarantine_reason)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (kind, resource_id) DO UPDATE
+		SET state = 'open', quarantine_reason = EXCLUDED.quarantine_reason`
+
+	if _, err := r.db.Exec(q, kind, id, reason); err != nil {
+		return fmt.Errorf("moderationRepo.QuarantineContent: %w", err)
+	}
+	return nil
+}
+
+// ListModerationItems returns a page of items in state, most reported
+// first, each with its reports.
+func (r *ModerationRepo) ListModerationItems(state string, limit, offset int) ([]models.ModerationItem, error) {
+	const q = `
+		SELECT ` + moderationColumns + `
+		FROM moderation_items
+		WHERE state = $1
+		ORDER BY report_count DESC, first_reported_at, kind, resource_id
+		LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.Query(q, state, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("moderationRepo.ListModerationItems: %w", err)
+	}
+	defer rows.Close()
+
+	var items []models.ModerationItem
+	for rows.Next() {
+		item, err := scanModerationItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("moderationRepo.ListModerationItems scan: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("moderationRepo.ListModerationItems rows: %w", err)
+	}
+
+	for i := range items {
+		if items[i].Reports, err = r.reports(items[i].Kind, items[i].ResourceID); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+func (r *ModerationRepo) reports(kind string, id int) ([]models.ContentReport, error) {
+	const q = `
+		SELECT id, kind, resource_id, reporter, reason, note, created_at
+		FROM content_reports
+		WHERE kind = $1 AND resource_id = $2
+		ORDER BY created_at, id`
+
+	out, err := r.queryReports(q, kind, id)
+	if err != nil {
+		return nil, fmt.Errorf("moderationRepo.reports: %w", err)
+	}
+	return out, nil
+}
+
+// ListReportsBy returns the reports reporter filed, oldest first.
+func (r *ModerationRepo) ListReportsBy(reporter string) ([]models.ContentReport, error) {
+	const q = `
+		SELECT id, kind, resource_id, reporter, reason, note, created_at
+		FROM content_reports
+		WHERE reporter = $1
+		ORDER BY created_at, id`
+
+	out, err := r.queryReports(q, reporter)
+	if err != nil {
+		return nil, fmt.Errorf("moderationRepo.ListReportsBy: %w", err)
+	}
+	return out, nil
+}
+
+func (r *ModerationRepo) queryReports(q string, args ...any) ([]models.ContentReport, error) {
+	rows, err := r.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.ContentReport
+	for rows.Next() {
+		var rep models.ContentReport
+		if err := rows.Scan(&rep.ID, &rep.Kind, &rep.ResourceID, &rep.Reporter, &rep.Reason, &rep.Note, &rep.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		out = append(out, rep)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+	return out, nil
+}
+
+// GetModerationItem returns the item for the content.  Returns
+// models.ErrNotFound when it was never reported.
+func (r *ModerationRepo) GetModerationItem(kind string, id int) (models.ModerationItem, error) {
+	const q = `SELECT ` + moderationColumns + ` FROM moderation_items WHERE kind = $1 AND resource_id = $2`
+
+	item, err := scanModerationItem(r.db.QueryRow(q, kind, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ModerationItem{}, models.ErrNotFound
+	}
+	if err != nil {
+		return models.ModerationItem{}, fmt.Errorf("moderationRepo.GetModerationItem: %w", err)
+	}
+	return item, nil
+}
+
+// ResolveModerationItem moves the item to state.  Returns
+// models.ErrNotFound when it was never reported.
+func (r *ModerationRepo) ResolveModerationItem(kind string, id int, state, reviewer, note string) (models.ModerationItem, error) {
+	const q = `
+		UPDATE moderation_items
+		SET state = $3, reviewed_by = $4, reviewed_at = NOW(), resolution = $5
+		WHERE kind = $1 AND resource_id = $2
+		RETURNING ` + moderationColumns
+
+	item, err := scanModerationItem(r.db.QueryRow(q, kind, id, state, reviewer, note))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ModerationItem{}, models.ErrNotFound
+	}
+	if err != nil {
+		return models.ModerationItem{}, fmt.Errorf("moderationRepo.ResolveModerationItem: %w", err)
+	}
+	return item, nil
+}
@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// NotificationRepo is a PostgreSQL-backed implementation of
+// db.NotificationRepository.
+type NotificationRepo struct {
+	db *sql.DB
+}
+
+// NewNotificationRepo constructs a NotificationRepo backed by the provided
+// *sql.DB.
+func NewNotificationRepo(db *sql.DB) *NotificationRepo {
+	return &NotificationRepo{db: db}
+}
+
+const notificationColumns = `id, type, title, body, href, created_at, expires_at, read_at`
+
+// notificationVisible restricts a query to notifications in the inbox now:
+// published and not yet expired.
+const notificationVisible = `created_at <= NOW() AND (expires_at IS NULL OR expires_at > NOW())`
+
+func scanNotification(row interface{ Scan(...any) error }) (models.Notification, error) {
+	var n models.Notification
+	var expiresAt, readAt sql.NullTime
+	if err := row.Scan(&n.ID, &n.Type, &n.Title, &n.Body, &n.Href, &n.CreatedAt, &expiresAt, &readAt); err != nil {
+		return models.Notification{}, err
+	}
+	if expiresAt.Valid {
+		n.ExpiresAt = &expiresAt.Time
+	}
+	if readAt.Valid {
+		n.ReadAt = &readAt.Time
+	}
+	return n, nil
+}
+
+// AddNotification stores n in the user's inbox.  Returns models.ErrNotFound
+// when the user does not exist (foreign_key_violation error code 23503).
+func (r *NotificationRepo) AddNotification(username string, n models.Notification) (models.Notification, error) {
+	const q = `
+		INSERT INTO notifications (username, type, title, body, href)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + notificationColumns
+
+	out, err := scanNotification(r.db.QueryRow(q, username, n.Type, n.Title, n.Body, n.Href))
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return models.Notification{}, models.ErrNotFound
+		}
+		return models.Notification{}, fmt.Errorf("notificationRepo.AddNotification: %w", err)
+	}
+	return out, nil
+}
+
+// BroadcastNotification copies n into every user's inbox in one statement.
+// A zero n.CreatedAt means now.
+func (r *NotificationRepo) BroadcastNotification(n models.Notification) (int, error) {
+	const q = `
+		INSERT INTO notifications (username, type, title, body, href, created_at, expires_at, announcement_id)
+		SELECT username, $1, $2, $3, $4, COALESCE($5, NOW()), $6, $7
+		FROM users`
+
+	var createdAt, expiresAt sql.NullTime
+	if !n.CreatedAt.IsZero() {
+		createdAt = sql.NullTime{Time: n.CreatedAt, Valid: true}
+	}
+	if n.ExpiresAt != nil {
+		expiresAt = sql.NullTime{Time: *n.ExpiresAt, Valid: true}
+	}
+	announcementID := sql.NullInt64{Int64: int64(n.AnnouncementID), Valid: n.AnnouncementID != 0}
+
+	res, err := r.db.Exec(q, n.Type, n.Title, n.Body, n.Href, createdAt, expiresAt, announcementID)
+	if err != nil {
+		return 0, fmt.Errorf("notificationRepo.BroadcastNotification: %w", err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("notificationRepo.BroadcastNotification: %w", err)
+	}
+	return int(count), nil
+}
+
+// ListNotifications returns a page of the user's visible notifications,
+// newest first.
+func (r *NotificationRepo) ListNotifications(username string, limit, offset int, unreadOnly bool) ([]models.Notification, error) {
+	const q = `
+		SELECT ` + notificationColumns + `
+		FROM notifications
+		WHERE username = $1 AND (NOT $2 OR read_at IS NULL) AND ` + notificationVisible + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3 OFFSET $4`
+
+	rows, err := r.db.Query(q, username, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("notificationRepo.ListNotifications: %w", err)
+	}
+	defer rows.Close()
+
+	var out []models.Notification
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, fmt.Errorf("notificationRepo.ListNotifications scan: %w", err)
+		}
+		out = append(out, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("notificationRepo.ListNotifications rows: %w", err)
+	}
+	return out, nil
+}
+
+// CountUnreadNotifications counts the user's unread notifications.
+func (r *NotificationRepo) CountUnreadNotifications(username string) (int, error) {
+	const q = `SELECT COUNT(*) FROM notifications WHERE username = $1 AND read_at IS NULL AND ` + notificationVisible
+
+	var n int
+	if err := r.db.QueryRow(q, username).Scan(&n); err != nil {
+		return 0, fmt.Errorf("notificationRepo.CountUnreadNotifications: %w", err)
+	}
+	return n, nil
+}
+
+// MarkNotificationRead marks one notification read.  Returns
+// models.ErrNotFound when it is not in the user's inbox.
+func (r *NotificationRepo) MarkNotificationRead(username string, id int) (models.Notification, error) {
+	const q = `
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, NOW())
+		WHERE username = $1 AND id = $2 AND ` + notificationVisible + `
+		RETURNING ` + notificationColumns
+
+	n, err := scanNotification(r.db.QueryRow(q, username, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Notification{}, models.ErrNotFound
+	}
+	if err != nil {
+		return models.Notification{}, fmt.Errorf("notificationRepo.MarkNotificationRead: %w", err)
+	}
+	return n, nil
+}
+
+// MarkAllNotificationsRead marks every unread notification read.
+func (r *NotificationRepo) MarkAllNotificationsRead(username string) (int, error) {
+	const q = `UPDATE notifications SET read_at = NOW() WHERE username = $1 AND read_at IS NULL AND ` + notificationVisible
+
+	res, err := r.db.Exec(q, username)
+	if err != nil {
+		return 0, fmt.Errorf("notificationRepo.MarkAllNotificationsRead: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("notificationRepo.MarkAllNotificationsRead: %w", err)
+	}
+	return int(n), nil
+}
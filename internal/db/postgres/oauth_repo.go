@@ -0,0 +1,208 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// OAuthRepo is a PostgreSQL-backed implementation of db.OAuthRepository.
+type OAuthRepo struct {
+	db *sql.DB
+}
+
+// NewOAuthRepo constructs an OAuthRepo backed by the provided *sql.DB.
+func NewOAuthRepo(db *sql.DB) *OAuthRepo {
+	return &OAuthRepo{db: db}
+}
+
+// CreateClient registers a client.  Returns models.ErrConflict when the
+// ID is taken.
+func (r *OAuthRepo) CreateClient(c models.OAuthClient) (models.OAuthClient, error) {
+	const q = `
+		INSERT INTO oauth_clients (id, name, redirect_uris, scopes, secret_hash, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at`
+
+	err := r.db.QueryRow(q, c.ID, c.Name, pq.Array(c.RedirectURIs), pq.Array(c.Scopes), c.SecretHash, c.CreatedBy).
+		Scan(&c.CreatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return models.OAuthClient{}, models.ErrConflict
+		}
+		return models.OAuthClient{}, fmt.Errorf("oauthRepo.CreateClient: %w", err)
+	}
+	return c, nil
+}
+
+// GetClient returns a client by ID, or models.ErrNotFound.
+func (r *OAuthRepo) GetClient(id string) (models.OAuthClient, error) {
+	var c models.OAuthClient
+	err := r.db.QueryRow(`
+		SELECT id, name, redirect_uris, scopes, secret_hash, created_by, created_at
+		FROM oauth_clients WHERE id = $1`, id).
+		Scan(&c.ID, &c.Name, pq.Array(&c.RedirectURIs), pq.Array(&c.Scopes), &c.SecretHash, &c.CreatedBy, &c.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.OAuthClient{}, models.ErrNotFound
+	}
+	if err != nil {
+		return models.OAuthClient{}, fmt.Errorf("oauthRepo.GetClient: %w", err)
+	}
+	return c, nil
+}
+
+// ListClients returns every client, newest first.
+func (r *OAuthRepo) ListClients() ([]models.OAuthClient, error) {
+	rows, err := r.db.Query(`
+		SELECT id, name, redirect_uris, scopes, secret_hash, created_by, created_at
+		FROM oauth_clients
+		ORDER BY created_at DESC, id`)
+	if err != nil {
+		return nil, fmt.Errorf("oauthRepo.ListClients: %w", err)
+	}
+	defer rows.Close()
+
+	clients := []models.OAuthClient{}
+	for rows.Next() {
+		var c models.OAuthClient
+		if err := rows.Scan(&c.ID, &c.Name, pq.Array(&c.RedirectURIs), pq.Array(&c.Scopes), &c.SecretHash, &c.CreatedBy, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("oauthRepo.ListClients: scan: %w", err)
+		}
+		clients = append(clients, c)
+	}
+	return clients, rows.Err()
+}
+
+// DeleteClient removes a client; its grants go with it by cascade.
+// Returns models.ErrNotFound when there is no such client.
+func (r *OAuthRepo) DeleteClient(id string) error {
+	res, err := r.db.Exec(`DELETE FROM oauth_clients WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("oauthRepo.DeleteClient: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+// GrantAccess inserts a grant or, when the user already has one for the
+// client, adds the scopes to it, in one statement.  Returns
+// models.ErrNotFound when the client does not exist.
+func (r *OAuthRepo) GrantAccess(g models.OAuthGrant) (models.OAuthGrant, error) {
+	const q = `
+		INSERT INTO oauth_grants (id, username, client_id, scopes)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (username, client_id) DO UPDATE
+			SET scopes = ARRAY(
+				SELECT DISTINCT s FROM unnest(oauth_grants.scopes || EXCLUDED.scopes) AS s ORDER BY s)
+		RETURNING id, scopes, created_at, last_used_at`
+
+	var lastUsed sql.NullTime
+	err := r.db.QueryRow(q, g.ID, g.Username, g.ClientID, pq.Array(g.Scopes)).
+		Scan(&g.ID, pq.Array(&g.Scopes), &g.CreatedAt, &lastUsed)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return models.OAuthGrant{}, models.ErrNotFound
+		}
+		return models.OAuthGrant{}, fmt.Errorf("oauthRepo.GrantAccess: %w", err)
+	}
+	if lastUsed.Valid {
+		g.LastUsedAt = &lastUsed.Time
+	}
+	if err := r.db.QueryRow(`SELECT name FROM oauth_clients WHERE id = $1`, g.ClientID).Scan(&g.ClientName); err != nil {
+		return models.OAuthGrant{}, fmt.Errorf("oauthRepo.GrantAccess: client: %w", err)
+	}
+	return g, nil
+}
+
+// GetGrant returns the user's grant to the client, or models.ErrNotFound.
+func (r *OAuthRepo) GetGrant(username, clientID string) (models.OAuthGrant, error) {
+	grants, err := r.grants(`g.username = $1 AND g.client_id = $2`, username, clientID)
+	if err != nil {
+		return models.OAuthGrant{}, fmt.Errorf("oauthRepo.GetGrant: %w", err)
+	}
+	if len(grants) == 0 {
+		return models.OAuthGrant{}, models.ErrNotFound
+	}
+	return grants[0], nil
+}
+
+// ListGrants returns the user's grants, newest first.
+func (r *OAuthRepo) ListGrants(username string) ([]models.OAuthGrant, error) {
+	grants, err := r.grants(`g.username = $1`, username)
+	if err != nil {
+		return nil, fmt.Errorf("oauthRepo.ListGrants: %w", err)
+	}
+	return grants, nil
+}
+
+func (r *OAuthRepo) grants(where string, args ...interface{}) ([]models.OAuthGrant, error) {
+	rows, err := r.db.Query(`
+		SELECT g.id, g.username, g.client_id, c.name, g.scopes, g.created_at, g.last_used_at
+		FROM oauth_grants g JOIN oauth_clients c ON c.id = g.client_id
+		WHERE `+where+`
+		ORDER BY g.created_at DESC, g.client_id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grants := []models.OAuthGrant{}
+	for rows.Next() {
+		var g models.OAuthGrant
+		var lastUsed sql.NullTime
+		if err := rows.Scan(&g.ID, &g.Username, &g.ClientID, &g.ClientName, pq.Array(&g.Scopes), &g.CreatedAt, &lastUsed); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		if lastUsed.Valid {
+			g.LastUsedAt = &lastUsed.Time
+		}
+		grants = append(grants, g)
+	}
+	return grants, rows.Err()
+}
+
+// TouchGrant confirms that a grant stands and records its use, writing
+// last_used_at at most once per sessionTouchInterval.  Returns
+// models.ErrNotFound when the grant was revoked.
+func (r *OAuthRepo) TouchGrant(id string) error {
+	var fresh bool
+	err := r.db.QueryRow(
+		`SELECT COALESCE(last_used_at > NOW() - make_interval(secs => $2), FALSE)
+		 FROM oauth_grants WHERE id = $1`,
+		id, sessionTouchInterval.Seconds(),
+	).Scan(&fresh)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("oauthRepo.TouchGrant: %w", err)
+	}
+
+	if fresh {
+		return nil
+	}
+	if _, err := r.db.Exec(`UPDATE oauth_grants SET last_used_at = NOW() WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("oauthRepo.TouchGrant: update: %w", err)
+	}
+	return nil
+}
+
+// RevokeGrant removes the user's grant to the client.  Returns
+// models.ErrNotFound when there is none.
+func (r *OAuthRepo) RevokeGrant(username, clientID string) error {
+	res, err := r.db.Exec(`DELETE FROM oauth_grants WHERE username = $1 AND client_id = $2`, username, clientID)
+	if err != nil {
+		return fmt.Errorf("oauthRepo.RevokeGrant: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
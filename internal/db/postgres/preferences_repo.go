@@ -0,0 +1,193 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/crypt"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/prefs"
+)
+
+// PreferencesRepo is a PostgreSQL-backed implementation of
+// db.PreferencesRepository.  Preferences are stored as one JSONB object per
+// user, with the values of prefs.Sealed encrypted.
+type PreferencesRepo struct {
+	db   *sql.DB
+	keys *crypt.Keyring
+}
+
+// NewPreferencesRepo constructs a PreferencesRepo backed by the provided
+// *sql.DB that encrypts sensitive values with keys.  A nil keyring stores
+// them in plain text.
+func NewPreferencesRepo(db *sql.DB, keys *crypt.Keyring) *PreferencesRepo {
+	return &PreferencesRepo{db: db, keys: keys}
+}
+
+// GetPreferences returns the user's preferences, empty when they have set
+// none.
+func (r *PreferencesRepo) GetPreferences(username string) (map[string]any, error) {
+	const q = `SELECT prefs FROM user_preferences WHERE username = $1`
+
+	var raw []byte
+	err := r.db.QueryRow(q, username).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return map[string]any{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("preferencesRepo.GetPreferences: %w", err)
+	}
+	p, err := r.open(raw)
+	if err != nil {
+		return nil, fmt.Errorf("preferencesRepo.GetPreferences: %w", err)
+	}
+	return p, nil
+}
+
+// PutPreferences replaces the user's preferences.  Returns
+// models.ErrNotFound when the user does not exist (foreign_key_violation
+// error code 23503).
+func (r *PreferencesRepo) PutPreferences(username string, p map[string]any) error {
+	const q = `
+		INSERT INTO user_preferences (username, prefs)
+		VALUES ($1, $2)
+		ON CONFLICT (username) DO UPDATE SET prefs = $2, updated_at = NOW()`
+
+	raw, err := r.seal(p)
+	if err != nil {
+		return fmt.Errorf("preferencesRepo.PutPreferences: %w", err)
+	}
+	if _, err := r.db.Exec(q, username, raw); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return models.ErrNotFound
+		}
+		return fmt.Errorf("preferencesRepo.PutPreferences: %w", err)
+	}
+	return nil
+}
+
+// rekeyBatch is how many users' preferences Rekey reads per query.
+const rekeyBatch = 1000
+
+// Rekey encrypts again every sealed value that is stored in plain text or
+// under a retired key, and returns how many users' preferences it rewrote.
+func (r *PreferencesRepo) Rekey(ctx context.Context) (int64, error) {
+	if r.keys == nil {
+		return 0, nil
+	}
+	var n int64
+	for last := ""; ; {
+		stale, next, more, err := r.staleBatch(ctx, last)
+		if err != nil {
+			return n, fmt.Errorf("preferencesRepo.Rekey: %w", err)
+		}
+		for username, raw := range stale {
+			p, err := r.open(raw)
+			if err != nil {
+				return n, fmt.Errorf("preferencesRepo.Rekey: %s: %w", username, err)
+			}
+			sealed, err := r.seal(p)
+			if err != nil {
+				return n, fmt.Errorf("preferencesRepo.Rekey: %w", err)
+			}
+			// Preferences the user changed meanwhile are left alone.
+			res, err := r.db.ExecContext(ctx,
+				`UPDATE user_preferences SET prefs = $3::jsonb WHERE username = $1 AND prefs = $2::jsonb`,
+				username, raw, sealed)
+			if err != nil {
+				return n, fmt.Errorf("preferencesRepo.Rekey: %w", err)
+			}
+			changed, _ := res.RowsAffected()
+			n += changed
+		}
+		if !more {
+			return n, nil
+		}
+		last = next
+	}
+}
+
+// staleBatch reads the preferences of up to rekeyBatch users after last, in
+// username order, and returns those Rekey should rewrite, the last username
+// read and whether there may be more.
+func (r *PreferencesRepo) staleBatch(ctx context.Context, last string) (map[string][]byte, string, bool, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT username, prefs FROM user_preferences WHERE username > $1 ORDER BY username LIMIT $2`, last, rekeyBatch)
+	if err != nil {
+		return nil, "", false, err
+	}
+	defer rows.Close()
+	stale := map[string][]byte{}
+	read := 0
+	for rows.Next() {
+		var raw []byte
+		if err := rows.Scan(&last, &raw); err != nil {
+			return nil, "", false, err
+		}
+		read++
+		if r.stale(raw) {
+			stale[last] = raw
+		}
+	}
+	return stale, last, read == rekeyBatch, rows.Err()
+}
+
+// seal encodes p for storage, encrypting the values of prefs.Sealed.
+func (r *PreferencesRepo) seal(p map[string]any) ([]byte, error) {
+	if r.keys != nil {
+		out := make(map[string]any, len(p))
+		for k, v := range p {
+			out[k] = v
+		}
+		for _, k := range prefs.Sealed {
+			if s, ok := out[k].(string); ok {
+				var err error
+				if out[k], err = r.keys.Seal(s); err != nil {
+					return nil, err
+				}
+			}
+		}
+		p = out
+	}
+	return json.Marshal(p)
+}
+
+// open decodes stored preferences, decrypting the values of prefs.Sealed.
+func (r *PreferencesRepo) open(raw []byte) (map[string]any, error) {
+	p := map[string]any{}
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, err
+	}
+	if r.keys == nil {
+		return p, nil
+	}
+	for _, k := range prefs.Sealed {
+		if s, ok := p[k].(string); ok {
+			var err error
+			if p[k], err = r.keys.Open(s); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return p, nil
+}
+
+// stale reports whether stored preferences hold a value that Rekey should
+// encrypt again.
+func (r *PreferencesRepo) stale(raw []byte) bool {
+	var p map[string]any
+	if json.Unmarshal(raw, &p) != nil {
+		return false
+	}
+	for _, k := range prefs.Sealed {
+		if s, ok := p[k].(string); ok && r.keys.Stale(s) {
+			return true
+		}
+	}
+	return false
+}
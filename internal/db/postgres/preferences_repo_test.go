@@ -0,0 +1,66 @@
+package postgres_test
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/crypt"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/postgres"
+)
+
+// TestPreferencesRepo_EncryptsEmail checks that email addresses are stored
+// encrypted and that Rekey moves them onto the current key.
+func TestPreferencesRepo_EncryptsEmail(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	conn, err := postgres.Connect(dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer conn.Close()
+
+	username := "prefs" + strconv.FormatInt(time.Now().UnixNano()%1e9, 10)
+	t.Cleanup(func() { conn.Exec(`DELETE FROM users WHERE username = $1`, username) })
+	if _, err := conn.Exec(`INSERT INTO users (username, password_hash) VALUES ($1, 'x')`, username); err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	stored := func() string {
+		var raw string
+		if err := conn.QueryRow(`SELECT prefs->>'email' FROM user_preferences WHERE username = $1`, username).Scan(&raw); err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+	key := func(b byte) string { return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), 32))) }
+
+	v1, _ := crypt.ParseKeys("v1:" + key('a'))
+	repo := postgres.NewPreferencesRepo(conn, v1)
+	if err := repo.PutPreferences(username, map[string]any{"email": "alice@example.com", "locale": "fr"}); err != nil {
+		t.Fatal(err)
+	}
+	if raw := stored(); !strings.HasPrefix(raw, "enc:v1:") {
+		t.Fatalf("expected the email encrypted, stored %q", raw)
+	}
+	if p, err := repo.GetPreferences(username); err != nil || p["email"] != "alice@example.com" || p["locale"] != "fr" {
+		t.Fatalf("got %v, %v", p, err)
+	}
+
+	rotated, _ := crypt.ParseKeys("v2:" + key('b') + ",v1:" + key('a'))
+	repo = postgres.NewPreferencesRepo(conn, rotated)
+	if _, err := repo.Rekey(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if raw := stored(); !strings.HasPrefix(raw, "enc:v2:") {
+		t.Fatalf("expected the email under the new key, stored %q", raw)
+	}
+	if p, err := repo.GetPreferences(username); err != nil || p["email"] != "alice@example.com" {
+		t.Fatalf("got %v, %v", p, err)
+	}
+}
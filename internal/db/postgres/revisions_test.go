@@ -0,0 +1,68 @@
+package postgres_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/postgres"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+func TestFootballRepo_AsOf(t *testing.T) {
+	conn := scratchDB(t)
+	truncateFootball(t, conn)
+	t.Cleanup(func() { truncateFootball(t, conn) })
+	repo := postgres.NewFootballRepo(conn, postgres.TxOptions{})
+	now := func() time.Time {
+		var ts time.Time
+		if err := conn.QueryRow(`SELECT clock_timestamp()`).Scan(&ts); err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+
+	var cup int
+	if err := conn.QueryRow(`INSERT INTO football_tournaments (name) VALUES ('Friendly') RETURNING id`).Scan(&cup); err != nil {
+		t.Fatal(err)
+	}
+	t0 := now()
+	fed, _ := repo.CreateTeam("West Germany")
+	eng, _ := repo.CreateTeam("England")
+	match, err := repo.CreateMatch(models.Match{Date: t0, HomeTeamID: fed.ID, AwayTeamID: eng.ID, TournamentID: cup, HomeScore: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t1 := now()
+	if _, err := repo.UpdateTeam(fed.ID, "Germany"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.DeleteMatch(match.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repo.GetTeamAsOf(fed.ID, t0); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("before creation: expected ErrNotFound, got %v", err)
+	}
+	if team, err := repo.GetTeamAsOf(fed.ID, t1); err != nil || team.Name != "West Germany" {
+		t.Fatalf("GetTeamAsOf(t1) = %+v, %v", team, err)
+	}
+	if m, err := repo.GetMatchAsOf(match.ID, t1); err != nil || m.HomeTeam != "West Germany" || m.HomeScore != 1 {
+		t.Fatalf("GetMatchAsOf(t1) = %+v, %v", m, err)
+	}
+	if matches, err := repo.ListMatches(10, 0, models.TimeFilter{AsOf: &t1}); err != nil || len(matches) != 1 {
+		t.Fatalf("ListMatches(asOf t1) = %+v, %v", matches, err)
+	}
+	if teams, err := repo.ListTeams(models.TimeFilter{AsOf: &t0}); err != nil || len(teams) != 0 {
+		t.Fatalf("ListTeams(asOf t0) = %+v, %v", teams, err)
+	}
+	if _, err := repo.GetTeamAsOf(fed.ID, time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)); !errors.Is(err, models.ErrNoHistory) {
+		t.Fatalf("before the history: expected ErrNoHistory, got %v", err)
+	}
+	if ts, err := repo.MatchTombstone(match.ID); err != nil || ts.ID != match.ID || ts.DeletedAt.Before(t1) {
+		t.Fatalf("MatchTombstone = %+v, %v", ts, err)
+	}
+	if _, err := repo.TeamTombstone(fed.ID); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("existing team: expected ErrNotFound, got %v", err)
+	}
+}
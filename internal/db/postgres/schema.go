@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// RequiredTables lists the tables created by the files in migrations/ that the
+// repositories depend on.  Migrations are applied outside the application, so
+// this is how the server tells whether a database is ready to use.
+var RequiredTables = []string{
+	"users",
+	"user_sessions",
+	"football_teams",
+	"football_tournaments",
+	"football_matches",
+	"football_goalscorers",
+	"football_shootouts",
+	"football_former_names",
+	"football_elo_cache",
+	"football_elo_config",
+	"football_match_tombstones",
+	"job_runs",
+	"daily_traffic",
+	"daily_reports",
+	"audit_log",
+	"feature_flags",
+	"invites",
+	"terms_acceptances",
+	"user_preferences",
+	"notifications",
+	"announcements",
+	"moderation_items",
+	"content_reports",
+	"usage_analytics",
+	"usage_meter",
+	"football_team_aliases",
+	"football_revisions",
+	"football_revisions_start",
+	"consumed_tokens",
+	"login_activity",
+	"oauth_clients",
+	"oauth_grants",
+}
+
+// MissingTables returns the entries of RequiredTables, followed by any extra
+// tables (such as those plugins require), that do not exist in the connected
+// database, in order.  An empty result means every migration the application
+// needs has been applied.
+func MissingTables(db *sql.DB, extra ...string) ([]string, error) {
+	var missing []string
+	for _, table := range append(RequiredTables[:len(RequiredTables):len(RequiredTables)], extra...) {
+		var exists bool
+		if err := db.QueryRow(`SELECT to_regclass($1) IS NOT NULL`, table).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("postgres: check table %s: %w", table, err)
+		}
+		if !exists {
+			missing = append(missing, table)
+		}
+	}
+	return missing, nil
+}
+
+// ExpectedIndexes lists the secondary indexes created by migrations/ that the
+// repositories' list and lookup queries rely on.  Their absence does not
+// break anything, but turns indexed lookups into sequential scans.
+var ExpectedIndexes = []string{
+	"football_matches_date_idx",
+	"football_matches_home_team_idx",
+	"football_matches_away_team_idx",
+	"football_matches_tournament_idx",
+	"football_matches_head_to_head_idx",
+	"football_matches_created_at_idx",
+	"football_matches_updated_at_idx",
+	"football_teams_updated_at_idx",
+	"football_match_tombstones_deleted_at_idx",
+	"job_runs_job_started_idx",
+	"audit_log_at_idx",
+	"audit_log_actor_idx",
+	"audit_log_action_idx",
+	"audit_log_impersonator_idx",
+	"football_goalscorers_match_idx",
+	"football_goalscorers_scorer_idx",
+	"football_former_names_team_idx",
+	"idx_elo_cache_team_date",
+	"idx_user_sessions_username",
+	"idx_user_sessions_username_last_used",
+	"users_username_lower_key",
+	"notifications_username_created_idx",
+	"notifications_announcement_idx",
+	"moderation_items_queue_idx",
+	"usage_meter_day_idx",
+	"football_teams_slug_key",
+	"football_team_aliases_target_idx",
+	"football_revisions_at_idx",
+	"login_activity_username_at_idx",
+	"login_activity_at_idx",
+	"oauth_grants_client_idx",
+}
+
+// MissingIndexes returns the entries of ExpectedIndexes that do not exist in
+// the connected database, in order.
+func MissingIndexes(db *sql.DB) ([]string, error) {
+	var missing []string
+	for _, index := range ExpectedIndexes {
+		var exists bool
+		if err := db.QueryRow(`SELECT to_regclass($1) IS NOT NULL`, index).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("postgres: check index %s: %w", index, err)
+		}
+		if !exists {
+			missing = append(missing, index)
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// sessionTouchInterval limits how often TouchSession writes last_used_at, so
+// that authenticating a request costs a read rather than a write.
+const sessionTouchInterval = time.Minute
+
+// SessionRepo is a PostgreSQL-backed implementation of db.SessionRepository.
+type SessionRepo struct {
+	db *sql.DB
+}
+
+// NewSessionRepo constructs a SessionRepo backed by the provided *sql.DB.
+func NewSessionRepo(db *sql.DB) *SessionRepo {
+	return &SessionRepo{db: db}
+}
+
+// CreateSession stores a new session and, while at it, removes the user's
+// expired sessions.
+func (r *SessionRepo) CreateSession(s models.Session) (models.Session, error) {
+	if _, err := r.db.Exec(
+		`DELETE FROM user_sessions WHERE username = $1 AND expires_at <= NOW()`, s.Username,
+	); err != nil {
+		return models.Session{}, fmt.Errorf("sessionRepo.CreateSession: purge: %w", err)
+	}
+
+	const q = `
+		INSERT INTO user_sessions (id, username, device_label, ip_address, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at, last_used_at`
+
+	err := r.db.QueryRow(q, s.ID, s.Username, s.DeviceLabel, s.IPAddress, s.ExpiresAt).
+		Scan(&s.CreatedAt, &s.LastUsedAt)
+	if err != nil {
+		return models.Session{}, fmt.Errorf("sessionRepo.CreateSession: %w", err)
+	}
+	return s, nil
+}
+
+// ListSessions returns the user's unexpired sessions, most recently used first.
+func (r *SessionRepo) ListSessions(username string) ([]models.Session, error) {
+	const q = `
+		SELECT id, device_label, ip_address, created_at, last_used_at, expires_at
+		FROM user_sessions
+		WHERE username = $1 AND expires_at > NOW()
+		ORDER BY last_used_at DESC`
+
+	rows, err := r.db.Query(q, username)
+	if err != nil {
+		return nil, fmt.Errorf("sessionRepo.ListSessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		s := models.Session{Username: username}
+		if err := rows.Scan(&s.ID, &s.DeviceLabel, &s.IPAddress, &s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("sessionRepo.ListSessions: scan: %w", err)
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// TouchSession confirms that a session is still active and records its use.
+// Returns models.ErrNotFound when the session was revoked or has expired.
+func (r *SessionRepo) TouchSession(id string) error {
+	// Staleness is judged against the database clock, the same clock that
+	// wrote last_used_at.
+	var fresh bool
+	err := r.db.QueryRow(
+		`SELECT last_used_at > NOW() - make_interval(secs => $2)
+		 FROM user_sessions WHERE id = $1 AND expires_at > NOW()`,
+		id, sessionTouchInterval.Seconds(),
+	).Scan(&fresh)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("sessionRepo.TouchSession: %w", err)
+	}
+
+	if fresh {
+		return nil
+	}
+	if _, err := r.db.Exec(`UPDATE user_sessions SET last_used_at = NOW() WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("sessionRepo.TouchSession: update: %w", err)
+	}
+	return nil
+}
+
+// CheckSession confirms that a session is still active without recording
+// its use.  Returns models.ErrNotFound when the session was revoked or has
+// expired.
+func (r *SessionRepo) CheckSession(id string) error {
+	var one int
+	err := r.db.QueryRow(`SELECT 1 FROM user_sessions WHERE id = $1 AND expires_at > NOW()`, id).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("sessionRepo.CheckSession: %w", err)
+	}
+	return nil
+}
+
+// RevokeSession deletes one of the user's sessions.  Returns
+// models.ErrNotFound when no such session belongs to the user.
+func (r *SessionRepo) RevokeSession(username, id string) error {
+	res, err := r.db.Exec(`DELETE FROM user_sessions WHERE id = $1 AND username = $2`, id, username)
+	if err != nil {
+		return fmt.Errorf("sessionRepo.RevokeSession: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+// DeleteExpired removes every user's expired sessions and returns how many
+// were deleted.  CreateSession only purges the user logging in, so sessions
+// of users who never return are left to this scheduled cleanup.
+func (r *SessionRepo) DeleteExpired(ctx context.Context) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM user_sessions WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("sessionRepo.DeleteExpired: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"database/sql"
+	"sync"
+)
+
+// stmtCache prepares each distinct query once and reuses the statement on
+// later calls, so hot paths skip re-parsing and re-planning the SQL on every
+// request.  A query that fails to prepare (for example while the database is
+// unreachable) falls back to an unprepared call, which reports the error in
+// the usual way; preparation is retried on the next call.
+type stmtCache struct {
+	db    *sql.DB
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt
+}
+
+func newStmtCache(db *sql.DB) *stmtCache {
+	return &stmtCache{db: db, stmts: make(map[string]*sql.Stmt)}
+}
+
+func (c *stmtCache) prepare(query string) (*sql.Stmt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if stmt, ok := c.stmts[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := c.db.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	c.stmts[query] = stmt
+	return stmt, nil
+}
+
+// QueryRow runs query through its cached statement.
+func (c *stmtCache) QueryRow(query string, args ...any) *sql.Row {
+	stmt, err := c.prepare(query)
+	if err != nil {
+		return c.db.QueryRow(query, args...)
+	}
+	return stmt.QueryRow(args...)
+}
+
+// tx returns a view of the cache whose statements run inside tx.
+func (c *stmtCache) tx(tx *sql.Tx) txStmts {
+	return txStmts{tx: tx, cache: c}
+}
+
+// txStmts binds cached statements to a transaction.
+type txStmts struct {
+	tx    *sql.Tx
+	cache *stmtCache
+}
+
+func (t txStmts) QueryRow(query string, args ...any) *sql.Row {
+	stmt, err := t.cache.prepare(query)
+	if err != nil {
+		return t.tx.QueryRow(query, args...)
+	}
+	return t.tx.Stmt(stmt).QueryRow(args...)
+}
+
+func (t txStmts) Exec(query string, args ...any) (sql.Result, error) {
+	stmt, err := t.cache.prepare(query)
+	if err != nil {
+		return t.tx.Exec(query, args...)
+	}
+	return t.tx.Stmt(stmt).Exec(args...)
+}
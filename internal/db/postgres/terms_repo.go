@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// TermsRepo is a PostgreSQL-backed implementation of db.TermsRepository.
+type TermsRepo struct {
+	db *sql.DB
+}
+
+// NewTermsRepo constructs a TermsRepo backed by the provided *sql.DB.
+func NewTermsRepo(db *sql.DB) *TermsRepo {
+	return &TermsRepo{db: db}
+}
+
+// AcceptTerms records that the user accepted version now.  Returns
+// models.ErrNotFound when the user does not exist (foreign_key_violation
+// error code 23503).
+func (r *TermsRepo) AcceptTerms(username, version string) (models.TermsAcceptance, error) {
+	const q = `
+		INSERT INTO terms_acceptances (username, version)
+		VALUES ($1, $2)
+		ON CONFLICT (username, version) DO UPDATE SET accepted_at = NOW()
+		RETURNING accepted_at`
+
+	a := models.TermsAcceptance{Version: version}
+	err := r.db.QueryRow(q, username, version).Scan(&a.AcceptedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return models.TermsAcceptance{}, models.ErrNotFound
+		}
+		return models.TermsAcceptance{}, fmt.Errorf("termsRepo.AcceptTerms: %w", err)
+	}
+	return a, nil
+}
+
+// LatestTerms returns the user's most recent acceptance.  Returns
+// models.ErrNotFound when they have never accepted any version.
+func (r *TermsRepo) LatestTerms(username string) (models.TermsAcceptance, error) {
+	const q = `
+		SELECT version, accepted_at
+		FROM terms_acceptances
+		WHERE username = $1
+		ORDER BY accepted_at DESC, version DESC
+		LIMIT 1`
+
+	var a models.TermsAcceptance
+	err := r.db.QueryRow(q, username).Scan(&a.Version, &a.AcceptedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.TermsAcceptance{}, models.ErrNotFound
+	}
+	if err != nil {
+		return models.TermsAcceptance{}, fmt.Errorf("termsRepo.LatestTerms: %w", err)
+	}
+	return a, nil
+}
+
+// ListTerms returns every version the user has accepted, most recent first.
+func (r *TermsRepo) ListTerms(username string) ([]models.TermsAcceptance, error) {
+	const q = `
+		SELECT version, accepted_at
+		FROM terms_acceptances
+		WHERE username = $1
+		ORDER BY accepted_at DESC, version DESC`
+
+	rows, err := r.db.Query(q, username)
+	if err != nil {
+		return nil, fmt.Errorf("termsRepo.ListTerms: %w", err)
+	}
+	defer rows.Close()
+
+	out := []models.TermsAcceptance{}
+	for rows.Next() {
+		var a models.TermsAcceptance
+		if err := rows.Scan(&a.Version, &a.AcceptedAt); err != nil {
+			return nil, fmt.Errorf("termsRepo.ListTerms: scan: %w", err)
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// TxOptions configures RunInTx.
+type TxOptions struct {
+	// Isolation is the transaction isolation level.
+	Isolation sql.IsolationLevel
+	// MaxAttempts bounds how many times a transaction is run when it fails
+	// with a serialization failure or deadlock.
+	MaxAttempts int
+	// BaseDelay is the backoff before the second attempt; it doubles on each
+	// further attempt, with full jitter.
+	BaseDelay time.Duration
+}
+
+// DefaultTxOptions runs at PostgreSQL's default READ COMMITTED level with up
+// to three attempts.
+var DefaultTxOptions = TxOptions{
+	Isolation:   sql.LevelReadCommitted,
+	MaxAttempts: 3,
+	BaseDelay:   10 * time.Millisecond,
+}
+
+// withDefaults fills zero fields from DefaultTxOptions.  LevelDefault (zero)
+// is kept as the zero isolation: it means "the server default".
+func (o TxOptions) withDefaults() TxOptions {
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = DefaultTxOptions.MaxAttempts
+	}
+	if o.BaseDelay <= 0 {
+		o.BaseDelay = DefaultTxOptions.BaseDelay
+	}
+	return o
+}
+
+// RunInTx runs fn inside a transaction — a unit of work — committing if fn
+// returns nil and rolling back otherwise.  When the transaction fails with a
+// serialization failure or deadlock (SQLSTATE 40001 / 40P01), which
+// PostgreSQL expects clients to retry, the whole unit is run again after a
+// jittered backoff, up to opts.MaxAttempts times.  fn must therefore be safe
+// to repeat and must not have side effects outside tx.
+func RunInTx(ctx context.Context, db *sql.DB, opts TxOptions, fn func(*sql.Tx) error) error {
+	opts = opts.withDefaults()
+
+	var err error
+	for attempt := 0; attempt < opts.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			backoff := time.Duration(rand.Int64N(int64(opts.BaseDelay) << (attempt - 1)))
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		err = runOnce(ctx, db, opts.Isolation, fn)
+		if !IsRetryable(err) {
+			return err
+		}
+	}
+	return fmt.Errorf("postgres: transaction failed after %d attempts: %w", opts.MaxAttempts, err)
+}
+
+func runOnce(ctx context.Context, db *sql.DB, isolation sql.IsolationLevel, fn func(*sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: isolation})
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// IsRetryable reports whether err is a PostgreSQL serialization failure or
+// deadlock, after which the transaction can safely be retried.
+func IsRetryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "40001" || pqErr.Code == "40P01"
+}
+
+// ParseIsolation maps a level name such as "read-committed",
+// "repeatable-read" or "serializable" to an sql.IsolationLevel.  An empty
+// string yields sql.LevelDefault.
+func ParseIsolation(s string) (sql.IsolationLevel, error) {
+	switch strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), "_", "-") {
+	case "":
+		return sql.LevelDefault, nil
+	case "read-committed":
+		return sql.LevelReadCommitted, nil
+	case "repeatable-read":
+		return sql.LevelRepeatableRead, nil
+	case "serializable":
+		return sql.LevelSerializable, nil
+	default:
+		return 0, fmt.Errorf("unknown isolation level %q", s)
+	}
+}
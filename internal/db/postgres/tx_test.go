@@ -0,0 +1,48 @@
+package postgres_test
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/postgres"
+)
+
+func TestIsRetryable(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{&pq.Error{Code: "40001"}, true},
+		{&pq.Error{Code: "40P01"}, true},
+		{fmt.Errorf("footballRepo.UpdateMatch: %w", &pq.Error{Code: "40001"}), true},
+		{&pq.Error{Code: "23505"}, false},
+		{errors.New("connection refused"), false},
+		{nil, false},
+	}
+	for _, tc := range cases {
+		if got := postgres.IsRetryable(tc.err); got != tc.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestParseIsolation(t *testing.T) {
+	cases := map[string]sql.IsolationLevel{
+		"":                sql.LevelDefault,
+		"read-committed":  sql.LevelReadCommitted,
+		"REPEATABLE_READ": sql.LevelRepeatableRead,
+		"serializable":    sql.LevelSerializable,
+	}
+	for in, want := range cases {
+		got, err := postgres.ParseIsolation(in)
+		if err != nil || got != want {
+			t.Errorf("ParseIsolation(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := postgres.ParseIsolation("snapshot"); err == nil {
+		t.Error("expected error for unknown level")
+	}
+}
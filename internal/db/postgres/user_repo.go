@@ -7,12 +7,13 @@ import (
 	"time"
 
 	"github.com/lib/pq"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
 )
 
 // UserRepo is a PostgreSQL-backed implementation of db.UserRepository.
-// Passwords are stored exclusively as bcrypt hashes — plain-text passwords
-// never touch the database layer.
+// Passwords are stored exclusively as hashes (argon2id, or bcrypt for legacy
+// accounts) — plain-text passwords never touch the database layer.
 type UserRepo struct {
 	db *sql.DB
 }
@@ -22,20 +23,21 @@ func NewUserRepo(db *sql.DB) *UserRepo {
 	return &UserRepo{db: db}
 }
 
-// GetUser retrieves the user record for the given username.
+// GetUser retrieves the user record for the given username, compared in
+// normalised form so that lookups are case-insensitive.
 // Returns models.ErrNotFound when the username does not exist.
 func (r *UserRepo) GetUser(username string) (models.User, error) {
 	const q = `
 		SELECT username, password_hash, created_at
 		FROM users
-		WHERE username = $1`
+		WHERE LOWER(username) = $1`
 
 	var (
 		uname        string
 		passwordHash string
 		createdAt    time.Time
 	)
-	err := r.db.QueryRow(q, username).Scan(&uname, &passwordHash, &createdAt)
+	err := r.db.QueryRow(q, auth.NormalizeUsername(username)).Scan(&uname, &passwordHash, &createdAt)
 	if errors.Is(err, sql.ErrNoRows) {
 		return models.User{}, models.ErrNotFound
 	}
@@ -50,10 +52,12 @@ func (r *UserRepo) GetUser(username string) (models.User, error) {
 	}, nil
 }
 
-// CreateUser inserts a new user with the given bcrypt-hashed password.
-// Returns models.ErrConflict when the username is already taken (PostgreSQL
-// unique_violation error code 23505).
+// CreateUser inserts a new user with the given hashed password, storing the
+// username in normalised form.
+// Returns models.ErrConflict when the username is already taken in any
+// letter case (PostgreSQL unique_violation error code 23505).
 func (r *UserRepo) CreateUser(username, passwordHash string) (models.User, error) {
+	username = auth.NormalizeUsername(username)
 	const q = `
 		INSERT INTO users (username, password_hash)
 		VALUES ($1, $2)
@@ -75,3 +79,17 @@ func (r *UserRepo) CreateUser(username, passwordHash string) (models.User, error
 		CreatedAt:    createdAt,
 	}, nil
 }
+
+// UpdatePasswordHash replaces the stored password hash for username.
+// Returns models.ErrNotFound when the username does not exist.
+func (r *UserRepo) UpdatePasswordHash(username, passwordHash string) error {
+	res, err := r.db.Exec(`UPDATE users SET password_hash = $2 WHERE LOWER(username) = $1`,
+		auth.NormalizeUsername(username), passwordHash)
+	if err != nil {
+		return fmt.Errorf("userRepo.UpdatePasswordHash: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
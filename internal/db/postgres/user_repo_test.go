@@ -0,0 +1,64 @@
+package postgres_test
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/postgres"
+)
+
+// TestUserRepo_NormalisesExistingUsernames checks that migration 033
+// rewrites an account created before usernames were normalised, with the
+// rows that name it, so that it signs in under the normalised name.
+func TestUserRepo_NormalisesExistingUsernames(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	conn, err := postgres.Connect(dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer conn.Close()
+	migration, err := os.ReadFile("../../../migrations/033_normalise_usernames.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	suffix := strconv.FormatInt(time.Now().UnixNano()%1e9, 10)
+	legacy, normal := "Legacy"+suffix, "legacy"+suffix
+	t.Cleanup(func() {
+		conn.Exec(`DELETE FROM users WHERE LOWER(username) = $1`, normal)
+		conn.Exec(`DELETE FROM audit_log WHERE LOWER(actor) = $1`, normal)
+	})
+	if _, err := conn.Exec(`INSERT INTO users (username, password_hash) VALUES ($1, 'x')`, legacy); err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	if _, err := conn.Exec(`INSERT INTO user_sessions (id, username, expires_at) VALUES ($1, $2, NOW() + INTERVAL '1 hour')`,
+		"session-"+suffix, legacy); err != nil {
+		t.Fatalf("insert session: %v", err)
+	}
+	if _, err := conn.Exec(`INSERT INTO audit_log (at, actor, action, resource_id) VALUES (NOW(), $1, 'team.created', '1')`, legacy); err != nil {
+		t.Fatalf("insert audit entry: %v", err)
+	}
+
+	if _, err := conn.Exec(string(migration)); err != nil {
+		t.Fatalf("migration 033: %v", err)
+	}
+
+	user, err := postgres.NewUserRepo(conn).GetUser(legacy)
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if user.Username != normal {
+		t.Errorf("expected %q, got %q", normal, user.Username)
+	}
+	var sessions, entries int
+	conn.QueryRow(`SELECT COUNT(*) FROM user_sessions WHERE username = $1`, normal).Scan(&sessions)
+	conn.QueryRow(`SELECT COUNT(*) FROM audit_log WHERE actor = $1`, normal).Scan(&entries)
+	if sessions != 1 || entries != 1 {
+		t.Errorf("expected the session and audit entry renamed, got %d and %d", sessions, entries)
+	}
+}
@@ -1,78 +1,62 @@
 // Package db provides repository interfaces for data access.
-// Implementations are provided by the postgres subpackage.
+// Implementations are provided by the postgres and memory subpackages.
 package db
 
 import (
 	"time"
 
-	"github.com/sc23bd/COMP3011_Coursework1/internal/elo"
-	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/pkg/repository"
 )
 
-// FootballRepository abstracts the data-access layer for the football feature.
-// It is currently implemented by the PostgreSQL repository.
-type FootballRepository interface {
-	// Teams - read
-	ListTeams() ([]models.Team, error)
-	GetTeamByID(id int) (models.Team, error)
-	GetTeamHistory(teamID int) ([]models.FormerName, error)
-
-	// Tournaments - read
-	GetTournamentByID(id int) (models.Tournament, error)
-	ListTournaments() ([]models.Tournament, error)
-
-	// Teams - write
-	CreateTeam(name string) (models.Team, error)
-	UpdateTeam(id int, name string) (models.Team, error)
-	DeleteTeam(id int) error
-
-	// Matches - read
-	ListMatches(limit, offset int) ([]models.Match, error)
-	GetMatchByID(id int) (models.Match, error)
-	GetHeadToHead(teamA, teamB int) ([]models.Match, error)
-
-	// Matches - write
-	CreateMatch(m models.Match) (models.Match, error)
-	UpdateMatch(id int, m models.Match) (models.Match, error)
-	DeleteMatch(id int) error
-
-	// Goals & Shootouts - read
-	GetMatchGoals(matchID int) ([]models.Goal, error)
-	GetMatchShootout(matchID int) (models.Shootout, error)
-
-	// Goals - write
-	CreateGoal(g models.Goal) (models.Goal, error)
-	DeleteGoal(id int) error
-
-	// Shootouts - write
-	CreateShootout(s models.Shootout) (models.Shootout, error)
-	DeleteShootout(matchID int) error
-
-	// Players
-	GetPlayerGoals(scorer string) ([]models.Goal, error)
-
-	// Elo – read
-	// GetMatchesChronological returns all matches involving teamID up to and
-	// including endDate, ordered oldest-first.  Pass teamID = 0 to fetch all matches.
-	GetMatchesChronological(teamID int, endDate time.Time) ([]elo.MatchResult, error)
-	// GetEloRankings returns a paginated global Elo ranking snapshot.
-	// region is an optional filter (empty = all regions); limit/offset control pagination.
-	GetEloRankings(asOf time.Time, region string, limit, offset int) ([]elo.RankingEntry, error)
-	// GetTeamCachedElo returns the cached Elo rating and rank for a team on or
-	// before asOf. Returns sql.ErrNoRows if no cached entry exists.
-	GetTeamCachedElo(teamID int, asOf time.Time) (rating float64, rank int, matchesPlayed int, err error)
-	// GetTeamCachedRank returns the most-recently cached global rank for a team
-	// on or before asOf. Returns 0 if no cached rank exists.
-	GetTeamCachedRank(teamID int, asOf time.Time) (int, error)
-
-	// Elo – write
-	// SaveEloSnapshot upserts a cached Elo rating for one team on one date.
-	SaveEloSnapshot(teamID int, asOf time.Time, rating float64, rank int, matchesPlayed int) error
-}
+// TombstoneRetention is how long deletions are remembered for incremental
+// sync.  A client whose last sync is older than this must download the full
+// data set again.
+const TombstoneRetention = 30 * 24 * time.Hour
+
+// LoginRetention is how long sign-ins are kept in login activity, and so
+// how long a device or network stays familiar after it was last used.
+const LoginRetention = 90 * 24 * time.Hour
+
+// The repository interfaces are defined in pkg/repository so that they can be
+// implemented outside this module; these aliases keep the internal names.
+type (
+	FootballRepository     = repository.Football
+	UserRepository         = repository.Users
+	SessionRepository      = repository.Sessions
+	LoginRepository        = repository.Logins
+	InviteRepository       = repository.Invites
+	OAuthRepository        = repository.OAuth
+	TermsRepository        = repository.Terms
+	PreferencesRepository  = repository.Preferences
+	NotificationRepository = repository.Notifications
+	AnnouncementRepository = repository.Announcements
+	ModerationRepository   = repository.Moderation
+	MeteringRepository     = repository.Metering
+)
 
-// UserRepository abstracts the data-access layer for users.
-// The PostgreSQL UserRepo satisfies this interface.
-type UserRepository interface {
-	GetUser(username string) (models.User, error)
-	CreateUser(username, passwordHash string) (models.User, error)
+// Repositories is the set of repositories the API is served from.
+type Repositories struct {
+	Football FootballRepository
+	Users    UserRepository
+	Sessions SessionRepository
+	// Logins records sign-ins for /me/logins and new-device alerts.  Nil
+	// disables both; every sign-in is then notified.
+	Logins LoginRepository
+	// Invites backs invite-only registration.  Nil disables it.
+	Invites InviteRepository
+	// OAuth holds third-party applications and users' grants to them.  Nil
+	// disables delegated authorisation.
+	OAuth OAuthRepository
+	// Terms records terms-of-service acceptances.  Nil disables tracking.
+	Terms TermsRepository
+	// Preferences stores per-user settings.  Nil disables /me/preferences.
+	Preferences PreferencesRepository
+	// Notifications holds users' inboxes.  Nil disables /me/notifications.
+	Notifications NotificationRepository
+	// Announcements backs /admin/announcements.  Nil disables them.
+	Announcements AnnouncementRepository
+	// Moderation holds content reports.  Nil disables reporting.
+	Moderation ModerationRepository
+	// Metering stores API usage.  Nil disables /me/usage.
+	Metering MeteringRepository
 }
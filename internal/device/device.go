@@ -0,0 +1,100 @@
+// Package device recognises the devices and networks users sign in from.
+// A device is identified by the browser (or client) and operating system
+// named in its User-Agent, ignoring versions, so that an upgrade does not
+// make a familiar device look new; a network by the /24 (IPv4) or /48 (IPv6)
+// prefix of its address, a coarse stand-in for location that needs no
+// geolocation database.
+package device
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/netip"
+	"strings"
+)
+
+// Unknown describes a User-Agent that names no recognised client.
+const Unknown = "Unknown device"
+
+// browsers and systems are matched in order against the User-Agent; the
+// first match names the client.  Order matters: Edge and Opera also claim
+// to be Chrome, and Chrome to be Safari.
+var (
+	browsers = []struct{ token, name string }{
+		{"edg/", "Edge"},
+		{"opr/", "Opera"},
+		{"firefox/", "Firefox"},
+		{"chrome/", "Chrome"},
+		{"crios/", "Chrome"},
+		{"safari/", "Safari"},
+		{"curl/", "curl"},
+		{"postmanruntime/", "Postman"},
+		{"python-requests/", "Python"},
+		{"go-http-client/", "Go"},
+	}
+	systems = []struct{ token, name string }{
+		{"android", "Android"},
+		{"iphone", "iOS"},
+		{"ipad", "iOS"},
+		{"windows", "Windows"},
+		{"mac os x", "macOS"},
+		{"cros", "ChromeOS"},
+		{"linux", "Linux"},
+	}
+)
+
+// Describe names the client and operating system of a User-Agent, e.g.
+// "Firefox on Windows", or returns Unknown.
+func Describe(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	var browser, system string
+	for _, b := range browsers {
+		if strings.Contains(ua, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	for _, s := range systems {
+		if strings.Contains(ua, s.token) {
+			system = s.name
+			break
+		}
+	}
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return "Browser on " + system
+	}
+	return Unknown
+}
+
+// Fingerprint identifies the device of a User-Agent: equal for every
+// User-Agent Describe names alike.  Unrecognised User-Agents are told apart
+// by their exact text.
+func Fingerprint(userAgent string) string {
+	key := Describe(userAgent)
+	if key == Unknown {
+		key += "\x00" + userAgent
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Network returns the prefix of ip that stands for its location, e.g.
+// "203.0.113.0/24", or ip itself if it cannot be parsed.
+func Network(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ip
+	}
+	addr = addr.Unmap()
+	bits := 48
+	if addr.Is4() {
+		bits = 24
+	}
+	prefix, _ := addr.Prefix(bits)
+	return prefix.String()
+}
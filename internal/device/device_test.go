@@ -0,0 +1,55 @@
+package device_test
+
+import (
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/device"
+)
+
+const (
+	firefoxWindows = "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:125.0) Gecko/20100101 Firefox/125.0"
+	edgeWindows    = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.0.0"
+	safariIPhone   = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1"
+)
+
+func TestDescribe(t *testing.T) {
+	cases := map[string]string{
+		firefoxWindows: "Firefox on Windows",
+		edgeWindows:    "Edge on Windows",
+		safariIPhone:   "Safari on iOS",
+		"curl/8.5.0":   "curl",
+		"":             device.Unknown,
+	}
+	for ua, want := range cases {
+		if got := device.Describe(ua); got != want {
+			t.Errorf("Describe(%q) = %q, want %q", ua, got, want)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	upgraded := "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:126.0) Gecko/20100101 Firefox/126.0"
+	if device.Fingerprint(firefoxWindows) != device.Fingerprint(upgraded) {
+		t.Error("a browser upgrade changed the fingerprint")
+	}
+	if device.Fingerprint(firefoxWindows) == device.Fingerprint(edgeWindows) {
+		t.Error("different browsers share a fingerprint")
+	}
+	if device.Fingerprint("bot/1") == device.Fingerprint("bot/2") {
+		t.Error("unrecognised User-Agents share a fingerprint")
+	}
+}
+
+func TestNetwork(t *testing.T) {
+	cases := map[string]string{
+		"203.0.113.57":        "203.0.113.0/24",
+		"::ffff:203.0.113.57": "203.0.113.0/24",
+		"2001:db8:1:2::1":     "2001:db8:1::/48",
+		"not-an-ip":           "not-an-ip",
+	}
+	for ip, want := range cases {
+		if got := device.Network(ip); got != want {
+			t.Errorf("Network(%q) = %q, want %q", ip, got, want)
+		}
+	}
+}
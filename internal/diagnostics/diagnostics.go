@@ -0,0 +1,39 @@
+// Package diagnostics serves the Go runtime's profiling and introspection
+// endpoints so that CPU, memory and goroutine problems can be investigated in
+// a running deployment without redeploying:
+//
+//   - /debug/pprof/           index of net/http/pprof profiles (heap, allocs,
+//     block, mutex, threadcreate, goroutine — ?debug=2 for a full dump)
+//   - /debug/pprof/profile    CPU profile (?seconds=N)
+//   - /debug/pprof/trace      execution trace
+//   - /debug/vars             expvar counters, including memstats
+//   - /debug/metrics          business counters in the OpenMetrics format,
+//     also served as /metrics for Prometheus' default scrape path
+//
+// The handler carries no authentication of its own; it must be mounted behind
+// admin auth or bound to a private diagnostics port.
+package diagnostics
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/metrics"
+)
+
+// Handler returns an http.Handler serving the /debug endpoints.  It uses its
+// own ServeMux rather than http.DefaultServeMux, so importing this package
+// never exposes profiles on a server that did not ask for them.
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.Handle("/debug/metrics", metrics.Handler())
+	mux.Handle("/metrics", metrics.Handler())
+	return mux
+}
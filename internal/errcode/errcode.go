@@ -0,0 +1,310 @@
+// Package errcode defines the stable, machine-readable codes carried in the
+// "code" member of every error response, so that clients can branch on
+// them rather than on the wording of "error", which may change:
+//
+//	{"error": "team not found", "code": "TEAM_NOT_FOUND"}
+//
+// A code, once published, keeps its meaning and is never reused; new ones
+// may be added.  Catalogue lists them all, and is served at
+// GET /api/v1/errors.
+package errcode
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/sanitize"
+)
+
+// Request errors.
+const (
+	BadRequest          = "BAD_REQUEST"
+	InvalidID           = "INVALID_ID"
+	InvalidParameter    = "INVALID_PARAMETER"
+	MalformedBody       = "MALFORMED_BODY"
+	ValidationFailed    = "VALIDATION_FAILED"
+	FieldRequired       = "FIELD_REQUIRED"
+	FieldTooLong        = "FIELD_TOO_LONG"
+	FieldTooShort       = "FIELD_TOO_SHORT"
+	FieldOutOfRange     = "FIELD_OUT_OF_RANGE"
+	FieldInvalid        = "FIELD_INVALID"
+	InvisibleCharacters = "INVISIBLE_CHARACTERS"
+	UsernameInvalid     = "USERNAME_INVALID"
+	SameTeams           = "SAME_TEAMS"
+	ReferenceNotFound   = "REFERENCE_NOT_FOUND"
+	InvalidPatch        = "INVALID_PATCH"
+	CookiesNotSupported = "COOKIES_NOT_SUPPORTED"
+	InvalidRedirectURI  = "INVALID_REDIRECT_URI"
+	InvalidScope        = "INVALID_SCOPE"
+	InvalidGrant        = "INVALID_GRANT"
+	UnsupportedGrant    = "UNSUPPORTED_GRANT_TYPE"
+	EmailTokenInvalid   = "EMAIL_TOKEN_INVALID"
+)
+
+// Authentication and authorisation errors.
+const (
+	AuthRequired           = "AUTH_REQUIRED"
+	AuthHeaderMalformed    = "AUTH_HEADER_MALFORMED"
+	TokenInvalid           = "TOKEN_INVALID"
+	TokenExpired           = "TOKEN_EXPIRED"
+	SessionRevoked         = "SESSION_REVOKED"
+	FreshAuthRequired      = "FRESH_AUTH_REQUIRED"
+	SignatureInvalid       = "SIGNATURE_INVALID"
+	CredentialReplayed     = "CREDENTIAL_REPLAYED"
+	InvalidCredentials     = "INVALID_CREDENTIALS"
+	Forbidden              = "FORBIDDEN"
+	AdminRequired          = "ADMIN_REQUIRED"
+	ServiceAccountRequired = "SERVICE_ACCOUNT_REQUIRED"
+	TermsNotAccepted       = "TERMS_NOT_ACCEPTED"
+	RegistrationClosed     = "REGISTRATION_CLOSED"
+	InviteRequired         = "INVITE_REQUIRED"
+	InviteInvalid          = "INVITE_INVALID"
+	ImpersonationForbidden = "IMPERSONATION_FORBIDDEN"
+	InvalidClient          = "INVALID_CLIENT"
+	AppAccessRevoked       = "APP_ACCESS_REVOKED"
+	InsufficientScope      = "INSUFFICIENT_SCOPE"
+	OriginNotAllowed       = "ORIGIN_NOT_ALLOWED"
+)
+
+// Errors for resources that do not exist.
+const (
+	NotFound             = "NOT_FOUND"
+	EndpointDisabled     = "ENDPOINT_DISABLED"
+	TeamNotFound         = "TEAM_NOT_FOUND"
+	MatchNotFound        = "MATCH_NOT_FOUND"
+	GoalNotFound         = "GOAL_NOT_FOUND"
+	ShootoutNotFound     = "SHOOTOUT_NOT_FOUND"
+	TranslationNotFound  = "TRANSLATION_NOT_FOUND"
+	AccountNotFound      = "ACCOUNT_NOT_FOUND"
+	UserNotFound         = "USER_NOT_FOUND"
+	SessionNotFound      = "SESSION_NOT_FOUND"
+	InviteNotFound       = "INVITE_NOT_FOUND"
+	ClientNotFound       = "CLIENT_NOT_FOUND"
+	AppNotFound          = "APP_NOT_FOUND"
+	NotificationNotFound = "NOTIFICATION_NOT_FOUND"
+	AnnouncementNotFound = "ANNOUNCEMENT_NOT_FOUND"
+	ReportNotFound       = "REPORT_NOT_FOUND"
+	BackupNotFound       = "BACKUP_NOT_FOUND"
+	OperationNotFound    = "OPERATION_NOT_FOUND"
+	SchemaNotFound       = "SCHEMA_NOT_FOUND"
+	FlagNotFound         = "FLAG_NOT_FOUND"
+	ResourceDeleted      = "RESOURCE_DELETED"
+	CursorExpired        = "CURSOR_EXPIRED"
+)
+
+// Errors for writes that collide with the current state.
+const (
+	Conflict          = "CONFLICT"
+	TeamExists        = "TEAM_EXISTS"
+	MatchExists       = "MATCH_EXISTS"
+	ShootoutExists    = "SHOOTOUT_EXISTS"
+	UsernameTaken     = "USERNAME_TAKEN"
+	TeamsShareFixture = "TEAMS_SHARE_FIXTURE"
+	AlreadyReported   = "ALREADY_REPORTED"
+	AlreadyTakenDown  = "ALREADY_TAKEN_DOWN"
+	PatchTestFailed   = "PATCH_TEST_FAILED"
+)
+
+// Errors for requests that are well formed but cannot be carried out.
+const (
+	Unprocessable        = "UNPROCESSABLE"
+	UnsupportedMediaType = "UNSUPPORTED_MEDIA_TYPE"
+	BodyTooLarge         = "BODY_TOO_LARGE"
+	HistoryUnavailable   = "HISTORY_UNAVAILABLE"
+	PatchPathNotFound    = "PATCH_PATH_NOT_FOUND"
+	ContentRejected      = "CONTENT_REJECTED"
+	BackupInvalid        = "BACKUP_INVALID"
+)
+
+// Errors for requests turned away for now; see package retry.
+const (
+	QuotaExhausted       = "QUOTA_EXHAUSTED"
+	RateLimited          = "RATE_LIMITED"
+	RecalculationRunning = "RECALCULATION_RUNNING"
+	RecalculationTooSoon = "RECALCULATION_TOO_SOON"
+	TooManySimulations   = "TOO_MANY_SIMULATIONS"
+	ServerBusy           = "SERVER_BUSY"
+	ScreeningUnavailable = "SCREENING_UNAVAILABLE"
+	ServiceUnavailable   = "SERVICE_UNAVAILABLE"
+	Internal             = "INTERNAL_ERROR"
+	Unknown              = "UNKNOWN_ERROR"
+)
+
+var catalogue = []models.ErrorCode{
+	{Code: BadRequest, Status: http.StatusBadRequest, Description: "The request is malformed in a way no more specific code covers."},
+	{Code: InvalidID, Status: http.StatusBadRequest, Description: "An ID in the path is not a positive integer."},
+	{Code: InvalidParameter, Status: http.StatusBadRequest, Description: "A query or path parameter is missing or malformed; error names it."},
+	{Code: MalformedBody, Status: http.StatusBadRequest, Description: "The request body could not be read or is not valid JSON of the expected shape."},
+	{Code: ValidationFailed, Status: http.StatusBadRequest, Description: "A field of the request body breaks a rule no more specific code covers."},
+	{Code: FieldRequired, Status: http.StatusBadRequest, Description: "A required field of the request body is missing or empty."},
+	{Code: FieldTooLong, Status: http.StatusBadRequest, Description: "A text field or list in the request body is longer than allowed."},
+	{Code: FieldTooShort, Status: http.StatusBadRequest, Description: "A text field or list in the request body is shorter than allowed."},
+	{Code: FieldOutOfRange, Status: http.StatusBadRequest, Description: "A number in the request body is outside its allowed range."},
+	{Code: FieldInvalid, Status: http.StatusBadRequest, Description: "A field of the request body is not one of its allowed values or not in its required format."},
+	{Code: InvisibleCharacters, Status: http.StatusBadRequest, Description: "A text field contains control or invisible characters."},
+	{Code: UsernameInvalid, Status: http.StatusBadRequest, Description: "The username contains invalid characters or is reserved."},
+	{Code: SameTeams, Status: http.StatusBadRequest, Description: "The two teams of a match, simulation or merge are the same team."},
+	{Code: ReferenceNotFound, Status: http.StatusBadRequest, Description: "A team or tournament the request refers to does not exist."},
+	{Code: InvalidPatch, Status: http.StatusBadRequest, Description: "The JSON Patch or merge patch is malformed, or the patched resource is invalid."},
+	{Code: CookiesNotSupported, Status: http.StatusBadRequest, Description: "The request carries cookies; the API is stateless and takes none."},
+	{Code: InvalidRedirectURI, Status: http.StatusBadRequest, Description: "The redirect URI is not registered for the OAuth client, or cannot be registered."},
+	{Code: InvalidScope, Status: http.StatusBadRequest, Description: "An OAuth scope is unknown or more than the client may ask for."},
+	{Code: InvalidGrant, Status: http.StatusBadRequest, Description: "The authorization code is invalid, expired, already used, issued to another client or redirect URI, or its PKCE verifier is wrong."},
+	{Code: UnsupportedGrant, Status: http.StatusBadRequest, Description: "The token endpoint only accepts grant_type=authorization_code."},
+	{Code: EmailTokenInvalid, Status: http.StatusBadRequest, Description: "The email verification token is invalid, expired, or for another user or address than the email preference."},
+
+	{Code: AuthRequired, Status: http.StatusUnauthorized, Description: "The endpoint requires authentication and none was given."},
+	{Code: AuthHeaderMalformed, Status: http.StatusUnauthorized, Description: "The Authorization header is not of the form 'Bearer {token}'."},
+	{Code: TokenInvalid, Status: http.StatusUnauthorized, Description: "The bearer token is not one the API issued."},
+	{Code: TokenExpired, Status: http.StatusUnauthorized, Description: "The bearer token has expired; refresh it or sign in again."},
+	{Code: SessionRevoked, Status: http.StatusUnauthorized, Description: "The session the token belongs to has been revoked."},
+	{Code: FreshAuthRequired, Status: http.StatusUnauthorized, Description: "The operation needs a recent sign-in; confirm the password through POST /auth/reauthenticate and retry with the new token."},
+	{Code: SignatureInvalid, Status: http.StatusUnauthorized, Description: "The HMAC request signature is invalid, stale or made with an unknown key."},
+	{Code: CredentialReplayed, Status: http.StatusUnauthorized, Description: "The signed request or one-shot token was already used; sign the request again with a new X-Date."},
+	{Code: InvalidCredentials, Status: http.StatusUnauthorized, Description: "The username or password is wrong."},
+	{Code: Forbidden, Status: http.StatusForbidden, Description: "The caller may not do this, for a reason no more specific code covers."},
+	{Code: AdminRequired, Status: http.StatusForbidden, Description: "The endpoint is restricted to administrators."},
+	{Code: ServiceAccountRequired, Status: http.StatusForbidden, Description: "The endpoint is restricted to service accounts."},
+	{Code: TermsNotAccepted, Status: http.StatusForbidden, Description: "The current terms of service must be accepted through PUT /me/terms first."},
+	{Code: RegistrationClosed, Status: http.StatusForbidden, Description: "Registration is closed on this deployment."},
+	{Code: InviteRequired, Status: http.StatusForbidden, Description: "Registration requires an invite code."},
+	{Code: InviteInvalid, Status: http.StatusForbidden, Description: "The invite code is unknown, used up or expired."},
+	{Code: ImpersonationForbidden, Status: http.StatusForbidden, Description: "Administrators cannot be impersonated, and impersonation tokens cannot be used to impersonate, to reauthenticate or to authorise applications."},
+	{Code: InvalidClient, Status: http.StatusUnauthorized, Description: "The OAuth client ID or secret is wrong."},
+	{Code: AppAccessRevoked, Status: http.StatusUnauthorized, Description: "The user has revoked the application's access; send them through the consent flow again."},
+	{Code: InsufficientScope, Status: http.StatusForbidden, Description: "The application's token lacks the scope the endpoint needs, or the endpoint does not accept application tokens at all."},
+	{Code: OriginNotAllowed, Status: http.StatusForbidden, Description: "A CORS preflight came from an origin the endpoint does not admit for that method."},
+
+	{Code: NotFound, Status: http.StatusNotFound, Description: "No such endpoint or resource."},
+	{Code: EndpointDisabled, Status: http.StatusNotFound, Description: "The endpoint is switched off by a feature flag."},
+	{Code: TeamNotFound, Status: http.StatusNotFound, Description: "No team has this ID, or had it at asOf."},
+	{Code: MatchNotFound, Status: http.StatusNotFound, Description: "No match has this ID, or had it at asOf."},
+	{Code: GoalNotFound, Status: http.StatusNotFound, Description: "No goal has this ID."},
+	{Code: ShootoutNotFound, Status: http.StatusNotFound, Description: "No penalty shootout is recorded for the match."},
+	{Code: TranslationNotFound, Status: http.StatusNotFound, Description: "The team has no name in this language."},
+	{Code: AccountNotFound, Status: http.StatusNotFound, Description: "The caller's account no longer exists."},
+	{Code: UserNotFound, Status: http.StatusNotFound, Description: "No user has this username."},
+	{Code: SessionNotFound, Status: http.StatusNotFound, Description: "The caller has no session with this ID."},
+	{Code: InviteNotFound, Status: http.StatusNotFound, Description: "No invite has this code."},
+	{Code: ClientNotFound, Status: http.StatusNotFound, Description: "No OAuth client has this ID."},
+	{Code: AppNotFound, Status: http.StatusNotFound, Description: "The caller has not authorised an application with this client ID."},
+	{Code: NotificationNotFound, Status: http.StatusNotFound, Description: "The caller has no notification with this ID."},
+	{Code: AnnouncementNotFound, Status: http.StatusNotFound, Description: "No announcement has this ID."},
+	{Code: ReportNotFound, Status: http.StatusNotFound, Description: "The team or match has not been reported."},
+	{Code: BackupNotFound, Status: http.StatusNotFound, Description: "No backup has this ID."},
+	{Code: OperationNotFound, Status: http.StatusNotFound, Description: "The caller has no background operation with this ID, or it has expired."},
+	{Code: SchemaNotFound, Status: http.StatusNotFound, Description: "No schema has this name."},
+	{Code: FlagNotFound, Status: http.StatusNotFound, Description: "No feature flag has this name."},
+	{Code: ResourceDeleted, Status: http.StatusGone, Description: "The team or match was deleted; the body says when."},
+	{Code: CursorExpired, Status: http.StatusGone, Description: "The sync cursor is older than deletions are remembered; sync again from scratch."},
+
+	{Code: Conflict, Status: http.StatusConflict, Description: "The write collides with the current state, for a reason no more specific code covers."},
+	{Code: TeamExists, Status: http.StatusConflict, Description: "Another team already has this name; current holds it."},
+	{Code: MatchExists, Status: http.StatusConflict, Description: "The same teams already play on this date; current holds the match."},
+	{Code: ShootoutExists, Status: http.StatusConflict, Description: "The match already has a shootout; current holds it."},
+	{Code: UsernameTaken, Status: http.StatusConflict, Description: "The username is already registered."},
+	{Code: TeamsShareFixture, Status: http.StatusConflict, Description: "The teams to merge played each other or share a fixture."},
+	{Code: AlreadyReported, Status: http.StatusConflict, Description: "The caller has already reported this team or match."},
+	{Code: AlreadyTakenDown, Status: http.StatusConflict, Description: "The team or match has already been taken down."},
+	{Code: PatchTestFailed, Status: http.StatusConflict, Description: "A JSON Patch test operation failed."},
+
+	{Code: BodyTooLarge, Status: http.StatusRequestEntityTooLarge, Description: "The request body is larger than the endpoint reads."},
+	{Code: UnsupportedMediaType, Status: http.StatusUnsupportedMediaType, Description: "The request body's Content-Type is not one the endpoint reads; supported lists those it does."},
+	{Code: Unprocessable, Status: http.StatusUnprocessableEntity, Description: "The request cannot be carried out, for a reason no more specific code covers."},
+	{Code: HistoryUnavailable, Status: http.StatusUnprocessableEntity, Description: "asOf is before the revision history began."},
+	{Code: PatchPathNotFound, Status: http.StatusUnprocessableEntity, Description: "A JSON Patch operation refers to a path the resource does not have."},
+	{Code: ContentRejected, Status: http.StatusUnprocessableEntity, Description: "The content classifier rejected a text field; field and reason say which and why."},
+	{Code: BackupInvalid, Status: http.StatusUnprocessableEntity, Description: "The backup is corrupt or from an incompatible version."},
+
+	{Code: QuotaExhausted, Status: http.StatusPaymentRequired, Description: "The caller's monthly request quota is used up."},
+	{Code: RateLimited, Status: http.StatusTooManyRequests, Description: "The caller made too many requests; retry after Retry-After seconds."},
+	{Code: RecalculationRunning, Status: http.StatusTooManyRequests, Description: "An Elo recalculation is already running."},
+	{Code: RecalculationTooSoon, Status: http.StatusTooManyRequests, Description: "Elo ratings were recalculated less than five minutes ago."},
+	{Code: TooManySimulations, Status: http.StatusTooManyRequests, Description: "Too many match simulations are running."},
+	{Code: Internal, Status: http.StatusInternalServerError, Description: "The server failed; the request ID identifies it in the logs."},
+	{Code: ServerBusy, Status: http.StatusServiceUnavailable, Description: "The server is at capacity; retry after Retry-After seconds."},
+	{Code: ScreeningUnavailable, Status: http.StatusServiceUnavailable, Description: "The content classifier is unavailable, so text cannot be accepted."},
+	{Code: ServiceUnavailable, Status: http.StatusServiceUnavailable, Description: "The server or a dependency is unavailable."},
+	{Code: Unknown, Status: 0, Description: "An error with a status no other code covers; status tells its kind."},
+}
+
+// Catalogue returns every code, grouped by status.
+func Catalogue() []models.ErrorCode {
+	return append([]models.ErrorCode(nil), catalogue...)
+}
+
+// ForStatus returns the general code for an error response with status,
+// for errors no more specific code covers.
+func ForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return BadRequest
+	case http.StatusUnauthorized:
+		return AuthRequired
+	case http.StatusPaymentRequired:
+		return QuotaExhausted
+	case http.StatusForbidden:
+		return Forbidden
+	case http.StatusNotFound:
+		return NotFound
+	case http.StatusConflict:
+		return Conflict
+	case http.StatusRequestEntityTooLarge:
+		return BodyTooLarge
+	case http.StatusUnsupportedMediaType:
+		return UnsupportedMediaType
+	case http.StatusUnprocessableEntity:
+		return Unprocessable
+	case http.StatusTooManyRequests:
+		return RateLimited
+	case http.StatusInternalServerError:
+		return Internal
+	case http.StatusServiceUnavailable:
+		return ServiceUnavailable
+	}
+	return Unknown
+}
+
+// Binding returns the code for err, returned by binding a request body: a
+// FIELD_* code for the first rule a field broke, INVISIBLE_CHARACTERS for
+// text sanitize refused, or MALFORMED_BODY when the body could not be
+// decoded at all.
+func Binding(err error) string {
+	var fieldErr *sanitize.FieldError
+	if errors.As(err, &fieldErr) {
+		if errors.Is(fieldErr.Err, sanitize.ErrInvisible) {
+			return InvisibleCharacters
+		}
+		return FieldInvalid
+	}
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) || len(verrs) == 0 {
+		return MalformedBody
+	}
+	fe := verrs[0]
+	sized := false
+	switch fe.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		sized = true
+	}
+	switch fe.Tag() {
+	case "required", "required_if", "required_with", "required_without":
+		return FieldRequired
+	case "max", "lte", "lt":
+		if sized {
+			return FieldTooLong
+		}
+		return FieldOutOfRange
+	case "min", "gte", "gt":
+		if sized {
+			return FieldTooShort
+		}
+		return FieldOutOfRange
+	case "oneof", "email", "url", "uri", "len", "datetime", "uuid":
+		return FieldInvalid
+	}
+	return ValidationFailed
+}
@@ -0,0 +1,63 @@
+package errcode_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/sanitize"
+)
+
+func TestCatalogue(t *testing.T) {
+	seen := map[string]bool{}
+	for _, ec := range errcode.Catalogue() {
+		if seen[ec.Code] {
+			t.Errorf("%s listed twice", ec.Code)
+		}
+		seen[ec.Code] = true
+		if ec.Description == "" {
+			t.Errorf("%s has no description", ec.Code)
+		}
+	}
+	for _, status := range []int{400, 401, 402, 403, 404, 409, 410, 415, 418, 422, 429, 500, 503} {
+		if code := errcode.ForStatus(status); !seen[code] {
+			t.Errorf("ForStatus(%d) = %s, which is not in the catalogue", status, code)
+		}
+	}
+}
+
+func TestBinding(t *testing.T) {
+	type body struct {
+		Name  string   `validate:"required,max=5"`
+		Tags  []string `validate:"min=1"`
+		Score int      `validate:"max=10"`
+		Kind  string   `validate:"omitempty,oneof=a b"`
+	}
+	v := validator.New()
+	for _, tc := range []struct {
+		in   body
+		want string
+	}{
+		{body{Tags: []string{"x"}}, errcode.FieldRequired},
+		{body{Name: "Uruguay", Tags: []string{"x"}}, errcode.FieldTooLong},
+		{body{Name: "Peru"}, errcode.FieldTooShort},
+		{body{Name: "Peru", Tags: []string{"x"}, Score: 11}, errcode.FieldOutOfRange},
+		{body{Name: "Peru", Tags: []string{"x"}, Kind: "c"}, errcode.FieldInvalid},
+	} {
+		if got := errcode.Binding(v.Struct(tc.in)); got != tc.want {
+			t.Errorf("%+v: got %s, want %s", tc.in, got, tc.want)
+		}
+	}
+
+	if got := errcode.Binding(&sanitize.FieldError{Field: "name", Err: sanitize.ErrInvisible}); got != errcode.InvisibleCharacters {
+		t.Errorf("invisible characters: got %s", got)
+	}
+	if got := errcode.Binding(errors.New("unexpected EOF")); got != errcode.MalformedBody {
+		t.Errorf("decode error: got %s", got)
+	}
+	if got := errcode.ForStatus(http.StatusTeapot); got != errcode.Unknown {
+		t.Errorf("ForStatus(418) = %s, want %s", got, errcode.Unknown)
+	}
+}
@@ -0,0 +1,216 @@
+// Package events lets code that embeds the server react to domain events —
+// teams, matches, goals and shootouts changing, users registering and
+// signing in — with in-process Go callbacks, without forking the handlers
+// that cause them.
+//
+// Events are published only after the change has been committed, so a
+// subscriber never sees a write that is later rolled back.  A subscriber
+// chooses when it runs:
+//
+//   - BeforeResponse subscribers run synchronously in the request goroutine,
+//     so the HTTP response is not sent until they return.  Keep them fast.
+//   - Background subscribers run in their own goroutine after Publish
+//     returns and may be slow.
+//
+// A subscriber cannot veto the change; errors and panics are logged and the
+// remaining subscribers still run.
+package events
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// Type identifies the kind of event.
+type Type string
+
+// The events published by the API.
+const (
+	TeamCreated         Type = "team.created"
+	TeamUpdated         Type = "team.updated"
+	TeamDeleted         Type = "team.deleted"
+	TeamMerged          Type = "team.merged"
+	MatchCreated        Type = "match.created"
+	MatchUpdated        Type = "match.updated"
+	MatchDeleted        Type = "match.deleted"
+	GoalCreated         Type = "goal.created"
+	GoalDeleted         Type = "goal.deleted"
+	ShootoutCreated     Type = "shootout.created"
+	ShootoutDeleted     Type = "shootout.deleted"
+	UserRegistered      Type = "user.registered"
+	UserImpersonated    Type = "user.impersonated"
+	SessionCreated      Type = "session.created"
+	SessionRevoked      Type = "session.revoked"
+	PreferencesUpdated  Type = "preferences.updated"
+	TermsAccepted       Type = "terms.accepted"
+	NotificationRead    Type = "notification.read"
+	DataExported        Type = "data.exported"
+	UnfamiliarLogin     Type = "login.unfamiliar"
+	AnnouncementCreated Type = "announcement.created"
+	AppAuthorized       Type = "app.authorized"
+	AppRevoked          Type = "app.revoked"
+)
+
+// Event describes one committed change, or a user's data being exported.
+type Event struct {
+	Type Type
+	// ID identifies the affected resource: a team, match, goal or
+	// announcement ID, the match ID for shootout events, the session ID for
+	// session.revoked, the notification ID for notification.read (or the
+	// username when all were read), a username for other user, session,
+	// preference, terms and export events, or the client ID for app events.
+	ID string
+	// Actor is the authenticated caller that made the change, or empty for
+	// unauthenticated requests such as registration.
+	Actor string
+	// Impersonator is the administrator who made the change as Actor with
+	// an impersonation token, or empty.
+	Impersonator string
+	At           time.Time
+	// Data is the resource after the change (models.Team, models.Match,
+	// models.Goal, models.Shootout, models.User, models.Session,
+	// models.Login, models.TermsAcceptance, models.Notification or
+	// models.Announcement), or nil for deletions.  For team.updated and
+	// match.updated it is an Update holding the team or match and the
+	// fields that changed.  For team.merged, ID is the merged team and
+	// Data the team it was merged into.  For user.impersonated, Actor is
+	// the administrator who was issued a token for user ID, and Data is
+	// nil.  For login.unfamiliar, ID is the user who signed in.  For
+	// preferences.updated, Data is the new preferences map; for
+	// session.revoked, data.exported and notification.read of every
+	// notification it is nil.
+	Data interface{}
+}
+
+// Update is the Data of team.updated and match.updated events.
+type Update struct {
+	// Resource is the team or match after the change.
+	Resource interface{}
+	// Changes lists the fields the change altered, or is nil when they
+	// could not be compared.
+	Changes []models.FieldChange
+}
+
+// Subscriber receives events.
+type Subscriber interface {
+	HandleEvent(ctx context.Context, e Event) error
+}
+
+// SubscriberFunc adapts a function to the Subscriber interface.
+type SubscriberFunc func(ctx context.Context, e Event) error
+
+// HandleEvent calls f(ctx, e).
+func (f SubscriberFunc) HandleEvent(ctx context.Context, e Event) error { return f(ctx, e) }
+
+// Phase selects when a subscriber runs relative to the HTTP response.
+type Phase int
+
+const (
+	// BeforeResponse runs the subscriber synchronously before the response
+	// is written.
+	BeforeResponse Phase = iota
+	// Background runs the subscriber in its own goroutine.
+	Background
+)
+
+type subscription struct {
+	sub   Subscriber
+	types map[Type]bool // nil means every type
+}
+
+// Bus delivers published events to subscribers.  A nil *Bus is valid and
+// discards every event, so publishers need not check whether one is set.
+type Bus struct {
+	log *slog.Logger
+
+	mu   sync.RWMutex
+	subs [2][]subscription
+
+	wg sync.WaitGroup
+}
+
+// NewBus returns an empty Bus that logs subscriber failures to log, or to
+// slog.Default when log is nil.
+func NewBus(log *slog.Logger) *Bus {
+	if log == nil {
+		log = slog.Default()
+	}
+	return &Bus{log: log}
+}
+
+// Subscribe registers s to run in phase p for events of the given types, or
+// for every event when no types are given.
+func (b *Bus) Subscribe(p Phase, s Subscriber, types ...Type) {
+	sub := subscription{sub: s}
+	if len(types) > 0 {
+		sub.types = make(map[Type]bool, len(types))
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[p] = append(b.subs[p], sub)
+}
+
+// Publish delivers e to every matching subscriber.  BeforeResponse
+// subscribers have run when Publish returns; Background subscribers are
+// started with a context that is not cancelled when ctx is.
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	if b == nil {
+		return
+	}
+	if e.At.IsZero() {
+		e.At = time.Now().UTC()
+	}
+
+	b.mu.RLock()
+	inline, background := b.subs[BeforeResponse], b.subs[Background]
+	b.mu.RUnlock()
+
+	for _, s := range inline {
+		if s.wants(e.Type) {
+			b.deliver(ctx, s.sub, e)
+		}
+	}
+
+	bgCtx := context.WithoutCancel(ctx)
+	for _, s := range background {
+		if !s.wants(e.Type) {
+			continue
+		}
+		b.wg.Add(1)
+		go func(sub Subscriber) {
+			defer b.wg.Done()
+			b.deliver(bgCtx, sub, e)
+		}(s.sub)
+	}
+}
+
+// Wait blocks until every Background delivery started so far has finished.
+// Call it during shutdown so in-flight integrations are not cut off.
+func (b *Bus) Wait() {
+	if b != nil {
+		b.wg.Wait()
+	}
+}
+
+func (s subscription) wants(t Type) bool {
+	return s.types == nil || s.types[t]
+}
+
+func (b *Bus) deliver(ctx context.Context, s Subscriber, e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.log.Error("event subscriber panicked", "event", e.Type, "id", e.ID, "panic", fmt.Sprint(r))
+		}
+	}()
+	if err := s.HandleEvent(ctx, e); err != nil {
+		b.log.Warn("event subscriber failed", "event", e.Type, "id", e.ID, "error", err)
+	}
+}
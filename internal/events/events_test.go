@@ -0,0 +1,83 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
+)
+
+func TestBus_DeliversToMatchingSubscribers(t *testing.T) {
+	bus := events.NewBus(nil)
+	var all, matches []events.Type
+	bus.Subscribe(events.BeforeResponse, events.SubscriberFunc(func(_ context.Context, e events.Event) error {
+		all = append(all, e.Type)
+		return nil
+	}))
+	bus.Subscribe(events.BeforeResponse, events.SubscriberFunc(func(_ context.Context, e events.Event) error {
+		matches = append(matches, e.Type)
+		return nil
+	}), events.MatchCreated, events.MatchDeleted)
+
+	bus.Publish(context.Background(), events.Event{Type: events.TeamCreated, ID: "1"})
+	bus.Publish(context.Background(), events.Event{Type: events.MatchCreated, ID: "2"})
+
+	if len(all) != 2 {
+		t.Fatalf("unfiltered subscriber saw %v", all)
+	}
+	if len(matches) != 1 || matches[0] != events.MatchCreated {
+		t.Fatalf("filtered subscriber saw %v", matches)
+	}
+}
+
+func TestBus_FailuresDoNotStopDelivery(t *testing.T) {
+	bus := events.NewBus(nil)
+	bus.Subscribe(events.BeforeResponse, events.SubscriberFunc(func(context.Context, events.Event) error {
+		panic("boom")
+	}))
+	bus.Subscribe(events.BeforeResponse, events.SubscriberFunc(func(context.Context, events.Event) error {
+		return errors.New("failed")
+	}))
+	called := false
+	bus.Subscribe(events.BeforeResponse, events.SubscriberFunc(func(context.Context, events.Event) error {
+		called = true
+		return nil
+	}))
+
+	bus.Publish(context.Background(), events.Event{Type: events.TeamDeleted})
+	if !called {
+		t.Fatal("later subscriber was not called")
+	}
+}
+
+func TestBus_BackgroundOutlivesRequestContext(t *testing.T) {
+	bus := events.NewBus(nil)
+	var mu sync.Mutex
+	var got []error
+	bus.Subscribe(events.Background, events.SubscriberFunc(func(ctx context.Context, e events.Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, ctx.Err())
+		if e.At.IsZero() {
+			t.Error("event time not set")
+		}
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	bus.Publish(ctx, events.Event{Type: events.UserRegistered, ID: "alice"})
+	cancel()
+	bus.Wait()
+
+	if len(got) != 1 || got[0] != nil {
+		t.Fatalf("expected one delivery with a live context, got %v", got)
+	}
+}
+
+func TestBus_NilIsNoOp(t *testing.T) {
+	var bus *events.Bus
+	bus.Publish(context.Background(), events.Event{Type: events.TeamCreated})
+	bus.Wait()
+}
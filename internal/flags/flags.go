@@ -0,0 +1,218 @@
+// Package flags holds the feature flags that switch endpoints and
+// behaviours on and off at runtime.  Each flag starts at its built-in
+// default, which FEATURE_FLAGS or a flags file may override; flips made
+// through /admin/flags are persisted in a Store, when there is one, and
+// picked up by every instance on its next refresh.
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Known flags.
+const (
+	// Registration opens POST /auth/register.
+	Registration = "registration"
+	// InviteOnly makes registration require an invite code.
+	InviteOnly = "invite-only"
+	// MatchSimulation enables POST /football/matches/simulate.
+	MatchSimulation = "match-simulation"
+	// EloRecalculation enables POST /football/rankings/elo/recalculate.
+	EloRecalculation = "elo-recalculation"
+)
+
+// Defaults are the known flags and their built-in values.
+var Defaults = map[string]bool{
+	Registration:     true,
+	InviteOnly:       false,
+	MatchSimulation:  true,
+	EloRecalculation: true,
+}
+
+// Where a flag's value came from.
+const (
+	SourceDefault = "default"
+	SourceConfig  = "config"
+	SourceRuntime = "runtime"
+)
+
+// refreshInterval is how often RunRefresher reloads flags from the Store.
+const refreshInterval = 30 * time.Second
+
+// ErrUnknown is returned for a flag name not in Defaults.
+var ErrUnknown = errors.New("flags: unknown flag")
+
+// Override is a flag value set at runtime.
+type Override struct {
+	Enabled   bool
+	UpdatedAt time.Time
+	UpdatedBy string
+}
+
+// Store persists runtime overrides.
+type Store interface {
+	Load(ctx context.Context) (map[string]Override, error)
+	Save(ctx context.Context, name string, o Override) error
+}
+
+// Flag is a flag's current value.
+type Flag struct {
+	Name    string
+	Enabled bool
+	// Source is SourceDefault, SourceConfig or SourceRuntime.
+	Source string
+	// UpdatedAt and UpdatedBy are set for runtime overrides.
+	UpdatedAt time.Time
+	UpdatedBy string
+}
+
+// Flags answers whether features are enabled.  A nil *Flags reports every
+// flag at its default.
+type Flags struct {
+	config map[string]bool
+	store  Store
+
+	mu        sync.RWMutex
+	overrides map[string]Override
+}
+
+// New returns Flags with config overriding the defaults and runtime flips
+// saved to store, which may be nil to keep them in memory only.
+func New(config map[string]bool, store Store) (*Flags, error) {
+	for name := range config {
+		if _, ok := Defaults[name]; !ok {
+			return nil, fmt.Errorf("%w %q", ErrUnknown, name)
+		}
+	}
+	return &Flags{config: config, store: store, overrides: make(map[string]Override)}, nil
+}
+
+// Enabled reports whether the named flag is on.  Unknown flags are off.
+func (f *Flags) Enabled(name string) bool {
+	if f == nil {
+		return Defaults[name]
+	}
+	return f.get(name).Enabled
+}
+
+func (f *Flags) get(name string) Flag {
+	f.mu.RLock()
+	o, ok := f.overrides[name]
+	f.mu.RUnlock()
+	if ok {
+		return Flag{Name: name, Enabled: o.Enabled, Source: SourceRuntime, UpdatedAt: o.UpdatedAt, UpdatedBy: o.UpdatedBy}
+	}
+	if v, ok := f.config[name]; ok {
+		return Flag{Name: name, Enabled: v, Source: SourceConfig}
+	}
+	return Flag{Name: name, Enabled: Defaults[name], Source: SourceDefault}
+}
+
+// All returns every known flag, ordered by name.
+func (f *Flags) All() []Flag {
+	out := make([]Flag, 0, len(Defaults))
+	for name := range Defaults {
+		if f == nil {
+			out = append(out, Flag{Name: name, Enabled: Defaults[name], Source: SourceDefault})
+		} else {
+			out = append(out, f.get(name))
+		}
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].Name < out[b].Name })
+	return out
+}
+
+// Set turns the named flag on or off on behalf of actor, saving the change
+// to the Store.
+func (f *Flags) Set(ctx context.Context, name string, enabled bool, actor string, at time.Time) (Flag, error) {
+	if _, ok := Defaults[name]; !ok {
+		return Flag{}, fmt.Errorf("%w %q", ErrUnknown, name)
+	}
+	o := Override{Enabled: enabled, UpdatedAt: at, UpdatedBy: actor}
+	if f.store != nil {
+		if err := f.store.Save(ctx, name, o); err != nil {
+			return Flag{}, err
+		}
+	}
+	f.mu.Lock()
+	f.overrides[name] = o
+	f.mu.Unlock()
+	return f.get(name), nil
+}
+
+// Refresh reloads the runtime overrides from the Store, picking up flips
+// made on other instances.
+func (f *Flags) Refresh(ctx context.Context) error {
+	if f.store == nil {
+		return nil
+	}
+	overrides, err := f.store.Load(ctx)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.overrides = overrides
+	f.mu.Unlock()
+	return nil
+}
+
+// RunRefresher calls Refresh periodically until ctx is done.
+func (f *Flags) RunRefresher(ctx context.Context) {
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := f.Refresh(ctx); err != nil && ctx.Err() == nil {
+				log.Print(err)
+			}
+		}
+	}
+}
+
+// Parse reads a comma-separated list of name=on|off pairs, as in
+// FEATURE_FLAGS.
+func Parse(s string) (map[string]bool, error) {
+	out := make(map[string]bool)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case "on", "true", "1":
+			out[name] = true
+		case "off", "false", "0":
+			out[name] = false
+		default:
+			return nil, fmt.Errorf("flags: %q: want name=on or name=off", pair)
+		}
+	}
+	return out, nil
+}
+
+// LoadFile reads flags from a JSON object of names to booleans.
+func LoadFile(path string) (map[string]bool, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]bool
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("flags: %s: %w", path, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,93 @@
+package flags_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/flags"
+)
+
+type memStore map[string]flags.Override
+
+func (s memStore) Load(context.Context) (map[string]flags.Override, error) {
+	out := make(map[string]flags.Override, len(s))
+	for k, v := range s {
+		out[k] = v
+	}
+	return out, nil
+}
+
+func (s memStore) Save(_ context.Context, name string, o flags.Override) error {
+	s[name] = o
+	return nil
+}
+
+func TestFlags_Precedence(t *testing.T) {
+	store := memStore{}
+	f, err := flags.New(map[string]bool{flags.MatchSimulation: false}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.Enabled(flags.Registration) || f.Enabled(flags.MatchSimulation) {
+		t.Fatal("expected the default for registration and the config value for simulation")
+	}
+	if f.Enabled("nope") {
+		t.Fatal("expected unknown flags to be off")
+	}
+
+	at := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	got, err := f.Set(context.Background(), flags.MatchSimulation, true, "alice", at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Enabled || got.Source != flags.SourceRuntime || got.UpdatedBy != "alice" || !got.UpdatedAt.Equal(at) {
+		t.Fatalf("unexpected flag %+v", got)
+	}
+	if !store[flags.MatchSimulation].Enabled {
+		t.Fatal("expected the flip to be saved")
+	}
+
+	// Another instance sees the flip after a refresh.
+	other, _ := flags.New(map[string]bool{flags.MatchSimulation: false}, store)
+	if other.Enabled(flags.MatchSimulation) {
+		t.Fatal("expected the config value before refreshing")
+	}
+	if err := other.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !other.Enabled(flags.MatchSimulation) {
+		t.Fatal("expected the stored value after refreshing")
+	}
+}
+
+func TestFlags_Unknown(t *testing.T) {
+	if _, err := flags.New(map[string]bool{"nope": true}, nil); !errors.Is(err, flags.ErrUnknown) {
+		t.Fatalf("expected ErrUnknown, got %v", err)
+	}
+	f, _ := flags.New(nil, nil)
+	if _, err := f.Set(context.Background(), "nope", true, "", time.Now()); !errors.Is(err, flags.ErrUnknown) {
+		t.Fatalf("expected ErrUnknown, got %v", err)
+	}
+}
+
+func TestFlags_Nil(t *testing.T) {
+	var f *flags.Flags
+	if !f.Enabled(flags.Registration) || len(f.All()) != len(flags.Defaults) {
+		t.Fatal("expected a nil *Flags to report the defaults")
+	}
+}
+
+func TestParse(t *testing.T) {
+	got, err := flags.Parse("registration=off, match-simulation=on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[flags.Registration] || !got[flags.MatchSimulation] || len(got) != 2 {
+		t.Fatalf("unexpected flags %v", got)
+	}
+	if _, err := flags.Parse("registration"); err == nil {
+		t.Fatal("expected an error for a missing value")
+	}
+}
@@ -0,0 +1,51 @@
+package flags
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// PostgresStore keeps runtime overrides in the feature_flags table
+// (migration 014), so a flip applies to every instance and survives
+// restarts.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore returns a Store backed by db.
+func NewPostgresStore(db *sql.DB) *PostgresStore { return &PostgresStore{db: db} }
+
+// Load implements Store.
+func (s *PostgresStore) Load(ctx context.Context) (map[string]Override, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT name, enabled, updated_at, updated_by FROM feature_flags`)
+	if err != nil {
+		return nil, fmt.Errorf("flags: load: %w", err)
+	}
+	defer rows.Close()
+	out := make(map[string]Override)
+	for rows.Next() {
+		var name string
+		var o Override
+		if err := rows.Scan(&name, &o.Enabled, &o.UpdatedAt, &o.UpdatedBy); err != nil {
+			return nil, fmt.Errorf("flags: load: %w", err)
+		}
+		out[name] = o
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("flags: load: %w", err)
+	}
+	return out, nil
+}
+
+// Save implements Store.
+func (s *PostgresStore) Save(ctx context.Context, name string, o Override) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO feature_flags (name, enabled, updated_at, updated_by) VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (name) DO UPDATE SET enabled = $2, updated_at = $3, updated_by = $4`,
+		name, o.Enabled, o.UpdatedAt, o.UpdatedBy)
+	if err != nil {
+		return fmt.Errorf("flags: save %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,441 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/audit"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// exportFormatVersion identifies the layout of the data-export archive so that
+// consumers can detect future changes.
+const exportFormatVersion = 1
+
+// defaultLoginLimit and maxLoginLimit bound the sign-ins GET /me/logins
+// returns.
+const (
+	defaultLoginLimit = 20
+	maxLoginLimit     = 100
+)
+
+// exportPageSize is how many notifications the data export reads at a time.
+const exportPageSize = 100
+
+// maxExportRows bounds each file of a data export, so that an archive is
+// always small enough to build within the request.  Files cut short are
+// listed in the manifest.
+const maxExportRows = 10000
+
+// errExportFull stops reading a source once a file holds maxExportRows.
+var errExportFull = errors.New("export file full")
+
+// AccountHandler serves the /me endpoints through which an authenticated user
+// manages the data held about their own account.
+type AccountHandler struct {
+	users         db.UserRepository
+	sessions      db.SessionRepository
+	logins        db.LoginRepository
+	preferences   db.PreferencesRepository
+	notifications db.NotificationRepository
+	terms         db.TermsRepository
+	grants        db.OAuthRepository
+	moderation    db.ModerationRepository
+	usage         db.MeteringRepository
+	audit         AuditLog
+	events        *events.Bus
+	clock         clock.Clock
+}
+
+// NewAccountHandler constructs an AccountHandler.
+func NewAccountHandler(users db.UserRepository, sessions db.SessionRepository) *AccountHandler {
+	return &AccountHandler{users: users, sessions: sessions, clock: clock.System{}}
+}
+
+// SetLogins serves the caller's login activity from logins and adds it to
+// the data export.
+func (h *AccountHandler) SetLogins(logins db.LoginRepository) {
+	h.logins = logins
+}
+
+// SetPreferences adds the caller's preferences to the data export.
+func (h *AccountHandler) SetPreferences(prefs db.PreferencesRepository) {
+	h.preferences = prefs
+}
+
+// SetNotifications adds the caller's notifications to the data export.
+func (h *AccountHandler) SetNotifications(notifications db.NotificationRepository) {
+	h.notifications = notifications
+}
+
+// SetTerms adds the terms versions the caller accepted to the data export.
+func (h *AccountHandler) SetTerms(terms db.TermsRepository) {
+	h.terms = terms
+}
+
+// SetGrants adds the applications the caller authorised to the data export.
+func (h *AccountHandler) SetGrants(grants db.OAuthRepository) {
+	h.grants = grants
+}
+
+// SetModeration adds the content reports the caller filed to the data
+// export.
+func (h *AccountHandler) SetModeration(moderation db.ModerationRepository) {
+	h.moderation = moderation
+}
+
+// SetUsage adds the caller's metered API usage to the data export.
+func (h *AccountHandler) SetUsage(usage db.MeteringRepository) {
+	h.usage = usage
+}
+
+// SetAudit adds the audit entries recording the caller's changes to the
+// data export.
+func (h *AccountHandler) SetAudit(log AuditLog) {
+	h.audit = log
+}
+
+// SetEvents publishes session revocations and data exports to bus.
+func (h *AccountHandler) SetEvents(bus *events.Bus) {
+	h.events = bus
+}
+
+// SetClock stamps data exports with c's time instead of the wall clock.
+func (h *AccountHandler) SetClock(c clock.Clock) {
+	h.clock = clock.Or(c)
+}
+
+// currentUser loads the account of the authenticated caller and writes a
+// 404/500 response if it cannot.  Returns false when the response has been
+// written.
+func (h *AccountHandler) currentUser(c *gin.Context) (models.User, bool) {
+	user, err := h.users.GetUser(c.GetString("username"))
+	if errors.Is(err, models.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "account not found", Code: errcode.AccountNotFound})
+		return models.User{}, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return models.User{}, false
+	}
+	return user, true
+}
+
+// ExportData handles GET /api/v1/me/export
+// Returns a ZIP archive containing every piece of personal data the service
+// holds about the caller, in machine-readable JSON (GDPR right of access and
+// data portability).
+//
+// Alongside the profile and sessions the archive holds, when each is kept,
+// the caller's login activity, preferences, notifications, terms
+// acceptances, application grants, content reports, metered usage and the
+// audit entries recording their changes.  It is produced synchronously;
+// each file holds at most maxExportRows rows, so that the archive can
+// always be built within the request, and the manifest lists any file that
+// was cut short.
+//
+//	@Summary		Export my data
+//	@Description	Download a ZIP archive of all personal data held about the authenticated user
+//	@Tags			account
+//	@Produce		application/zip
+//	@Success		200	{file}		binary					"ZIP archive"
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		404	{object}	models.ErrorResponse	"Account not found"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/me/export [get]
+func (h *AccountHandler) ExportData(c *gin.Context) {
+	// Personal data must never be stored by shared caches.
+	c.Header("Cache-Control", "no-store")
+
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	files, truncated, err := h.exportFiles(c.Request.Context(), user)
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+
+	manifest := models.ExportManifest{
+		FormatVersion: exportFormatVersion,
+		Username:      user.Username,
+		ExportedAt:    h.clock.Now().UTC(),
+		Files:         []string{},
+		Truncated:     truncated,
+	}
+	for _, f := range files {
+		manifest.Files = append(manifest.Files, f.Name)
+	}
+
+	archive, err := buildExportArchive(append([]exportFile{{Name: "manifest.json", Data: manifest}}, files...))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "failed to build export", Code: errcode.Internal})
+		return
+	}
+
+	publish(c, h.events, events.DataExported, user.Username, nil)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "export-" + user.Username + ".zip"}))
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+// ListSessions handles GET /api/v1/me/sessions
+// Lists the caller's active login sessions with the device, IP address and
+// last use of each, so that unfamiliar sessions can be spotted and revoked.
+//
+//	@Summary		List my sessions
+//	@Description	List the authenticated user's active login sessions
+//	@Tags			account
+//	@Produce		json
+//	@Success		200	{object}	models.SessionListResponse
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/me/sessions [get]
+func (h *AccountHandler) ListSessions(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+
+	sessions, err := h.sessions.ListSessions(c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+
+	current := c.GetString("sessionID")
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current
+		sessions[i].Links = []models.Link{
+			{Rel: "revoke", Href: "/api/v1/me/sessions/" + sessions[i].ID, Method: http.MethodDelete},
+		}
+	}
+
+	c.JSON(http.StatusOK, models.SessionListResponse{
+		Sessions: sessions,
+		Links: []models.Link{
+			{Rel: "self", Href: "/api/v1/me/sessions", Method: http.MethodGet},
+		},
+	})
+}
+
+// ListLogins handles GET /api/v1/me/logins
+// Lists the caller's recent sign-ins, newest first, with the device and
+// network of each and whether either was new to them, so that sign-ins
+// they do not recognise can be spotted.  Sign-ins are kept for 90 days.
+//
+//	@Summary		My login activity
+//	@Description	The authenticated user's recent sign-ins, newest first, flagging new devices and locations
+//	@Tags			account
+//	@Produce		json
+//	@Param			limit	query		int		false	"Number of sign-ins (default 20, max 100)"
+//	@Success		200		{object}	models.LoginListResponse
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid limit"
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/me/logins [get]
+func (h *AccountHandler) ListLogins(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+
+	limit := defaultLoginLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxLoginLimit {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "limit must be an integer from 1 to 100", Code: errcode.InvalidParameter})
+			return
+		}
+		limit = n
+	}
+
+	logins, err := h.logins.ListLogins(c.GetString("username"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	c.JSON(http.StatusOK, models.LoginListResponse{
+		Logins: logins,
+		Links: []models.Link{
+			{Rel: "self", Href: "/api/v1/me/logins", Method: http.MethodGet},
+			{Rel: "sessions", Href: "/api/v1/me/sessions", Method: http.MethodGet},
+		},
+	})
+}
+
+// RevokeSession handles DELETE /api/v1/me/sessions/:id
+// Ends one of the caller's sessions; tokens issued for it stop working
+// immediately.
+//
+//	@Summary		Revoke a session
+//	@Description	Revoke one of the authenticated user's login sessions
+//	@Tags			account
+//	@Param			id	path	string	true	"Session ID"
+//	@Success		204	"Session revoked"
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		404	{object}	models.ErrorResponse	"Session not found"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/me/sessions/{id} [delete]
+func (h *AccountHandler) RevokeSession(c *gin.Context) {
+	err := h.sessions.RevokeSession(c.GetString("username"), c.Param("id"))
+	if errors.Is(err, models.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "session not found", Code: errcode.SessionNotFound})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	publish(c, h.events, events.SessionRevoked, c.Param("id"), nil)
+	c.Status(http.StatusNoContent)
+}
+
+// exportFile is one JSON document inside a data-export archive.
+type exportFile struct {
+	Name string
+	Data interface{}
+}
+
+// exportFiles gathers the personal data held about user, one file for each
+// kind of data kept, and returns the names of those cut short at
+// maxExportRows.
+func (h *AccountHandler) exportFiles(ctx context.Context, user models.User) ([]exportFile, []string, error) {
+	var files []exportFile
+	var truncated []string
+	add := func(name string, data any, full bool) {
+		files = append(files, exportFile{Name: name, Data: data})
+		if full {
+			truncated = append(truncated, name)
+		}
+	}
+
+	sessions, err := h.sessions.ListSessions(user.Username)
+	if err != nil {
+		return nil, nil, err
+	}
+	add("profile.json", user, false)
+	sessions, full := capRows(sessions)
+	add("sessions.json", sessions, full)
+
+	if h.logins != nil {
+		logins, err := h.logins.ListLogins(user.Username, maxExportRows+1)
+		if err != nil {
+			return nil, nil, err
+		}
+		logins, full := capRows(logins)
+		add("logins.json", logins, full)
+	}
+	if h.preferences != nil {
+		prefs, err := h.preferences.GetPreferences(user.Username)
+		if err != nil {
+			return nil, nil, err
+		}
+		add("preferences.json", prefs, false)
+	}
+	if h.notifications != nil {
+		notifications := []models.Notification{}
+		for len(notifications) <= maxExportRows {
+			page, err := h.notifications.ListNotifications(user.Username, exportPageSize, len(notifications), false)
+			if err != nil {
+				return nil, nil, err
+			}
+			notifications = append(notifications, page...)
+			if len(page) < exportPageSize {
+				break
+			}
+		}
+		notifications, full := capRows(notifications)
+		add("notifications.json", notifications, full)
+	}
+	if h.terms != nil {
+		terms, err := h.terms.ListTerms(user.Username)
+		if err != nil {
+			return nil, nil, err
+		}
+		terms, full := capRows(terms)
+		add("terms.json", terms, full)
+	}
+	if h.grants != nil {
+		grants, err := h.grants.ListGrants(user.Username)
+		if err != nil {
+			return nil, nil, err
+		}
+		grants, full := capRows(grants)
+		add("apps.json", grants, full)
+	}
+	if h.moderation != nil {
+		reports, err := h.moderation.ListReportsBy(user.Username)
+		if err != nil {
+			return nil, nil, err
+		}
+		reports, full := capRows(reports)
+		add("reports.json", reports, full)
+	}
+	if h.usage != nil {
+		records, err := h.usage.ListUsage(user.Username, time.Time{}.Format(time.DateOnly), h.clock.Now().UTC().Format(time.DateOnly))
+		if err != nil {
+			return nil, nil, err
+		}
+		records, full := capRows(records)
+		add("usage.json", records, full)
+	}
+	if h.audit != nil {
+		entries := []models.AuditEntry{}
+		err := h.audit.Iterate(ctx, audit.Filter{Actor: user.Username}, func(e models.AuditEntry) error {
+			if len(entries) == maxExportRows {
+				return errExportFull
+			}
+			entries = append(entries, e)
+			return nil
+		})
+		if err != nil && !errors.Is(err, errExportFull) {
+			return nil, nil, err
+		}
+		add("audit.json", entries, err != nil)
+	}
+	return files, truncated, nil
+}
+
+// capRows returns the first maxExportRows of rows and whether there were
+// more.
+func capRows[T any](rows []T) ([]T, bool) {
+	if len(rows) > maxExportRows {
+		return rows[:maxExportRows], true
+	}
+	return rows, false
+}
+
+// buildExportArchive writes each file as indented JSON into a ZIP archive, in
+// order, and returns the archive bytes.
+func buildExportArchive(files []exportFile) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.Create(f.Name)
+		if err != nil {
+			return nil, err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,333 @@
+package handlers_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/memory"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/handlers"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// ---------------------------------------------------------------------------
+// userMock is a minimal in-test stub that implements db.UserRepository.
+// ---------------------------------------------------------------------------
+
+type userMock struct {
+	users map[string]models.User
+}
+
+func newUserMock() *userMock {
+	return &userMock{users: make(map[string]models.User)}
+}
+
+func (m *userMock) addUser(username string) models.User {
+	u := models.User{Username: username, PasswordHash: "hash", CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	m.users[username] = u
+	return u
+}
+
+func (m *userMock) GetUser(username string) (models.User, error) {
+	u, ok := m.users[username]
+	if !ok {
+		return models.User{}, models.ErrNotFound
+	}
+	return u, nil
+}
+
+func (m *userMock) CreateUser(username, passwordHash string) (models.User, error) {
+	if _, ok := m.users[username]; ok {
+		return models.User{}, models.ErrConflict
+	}
+	u := models.User{Username: username, PasswordHash: passwordHash}
+	m.users[username] = u
+	return u, nil
+}
+
+func (m *userMock) UpdatePasswordHash(username, passwordHash string) error {
+	u, ok := m.users[username]
+	if !ok {
+		return models.ErrNotFound
+	}
+	u.PasswordHash = passwordHash
+	m.users[username] = u
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// sessionMock is a minimal in-test stub that implements db.SessionRepository.
+// ---------------------------------------------------------------------------
+
+type sessionMock struct {
+	sessions map[string]models.Session
+}
+
+func newSessionMock() *sessionMock {
+	return &sessionMock{sessions: make(map[string]models.Session)}
+}
+
+func (m *sessionMock) CreateSession(s models.Session) (models.Session, error) {
+	s.CreatedAt = time.Now()
+	s.LastUsedAt = s.CreatedAt
+	m.sessions[s.ID] = s
+	return s, nil
+}
+
+func (m *sessionMock) ListSessions(username string) ([]models.Session, error) {
+	out := []models.Session{}
+	for _, s := range m.sessions {
+		if s.Username == username {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func (m *sessionMock) TouchSession(id string) error {
+	if _, ok := m.sessions[id]; !ok {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+func (m *sessionMock) CheckSession(id string) error {
+	return m.TouchSession(id)
+}
+
+func (m *sessionMock) RevokeSession(username, id string) error {
+	s, ok := m.sessions[id]
+	if !ok || s.Username != username {
+		return models.ErrNotFound
+	}
+	delete(m.sessions, id)
+	return nil
+}
+
+// newAccountRouter builds a router for the /me endpoints where every request
+// is treated as authenticated as username using session "current".
+func newAccountRouter(username string) (*gin.Engine, *userMock, *sessionMock) {
+	users, sessions := newUserMock(), newSessionMock()
+	ah := handlers.NewAccountHandler(users, sessions)
+
+	asUser := func(c *gin.Context) {
+		c.Set("username", username)
+		c.Set("sessionID", "current")
+		c.Next()
+	}
+
+	r := gin.New()
+	me := r.Group("/api/v1/me", asUser)
+	{
+		me.GET("/export", ah.ExportData)
+		me.GET("/sessions", ah.ListSessions)
+		me.DELETE("/sessions/:id", ah.RevokeSession)
+	}
+	return r, users, sessions
+}
+
+// --- ExportData --------------------------------------------------------------
+
+func TestExportData_Success(t *testing.T) {
+	r, mock, _ := newAccountRouter("alice")
+	mock.addUser("alice")
+
+	w := doRequest(r, http.MethodGet, "/api/v1/me/export", nil)
+	assertStatus(t, w, http.StatusOK)
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/zip" {
+		t.Fatalf("expected application/zip, got %q", ct)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "no-store" {
+		t.Fatalf("expected Cache-Control no-store, got %q", cc)
+	}
+
+	files := readExport(t, w.Body.Bytes())
+	var profile map[string]interface{}
+	if err := json.Unmarshal(files["profile.json"], &profile); err != nil {
+		t.Fatalf("profile.json: %v", err)
+	}
+	if profile["username"] != "alice" {
+		t.Fatalf("expected username alice, got %v", profile["username"])
+	}
+	if _, leaked := profile["passwordHash"]; leaked {
+		t.Fatal("password hash must not be exported")
+	}
+
+	var manifest models.ExportManifest
+	if err := json.Unmarshal(files["manifest.json"], &manifest); err != nil {
+		t.Fatalf("manifest.json: %v", err)
+	}
+	if manifest.Username != "alice" || len(manifest.Files) == 0 {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+}
+
+func TestExportData_AllSections(t *testing.T) {
+	repos := memory.New().Repositories()
+	if _, err := repos.Users.CreateUser("alice", "hash"); err != nil {
+		t.Fatal(err)
+	}
+	repos.Logins.RecordLogin(models.Login{Username: "alice", UserAgent: "curl/8.0"}, time.Time{})
+	repos.Preferences.PutPreferences("alice", map[string]any{"timezone": "Europe/London"})
+	repos.Notifications.AddNotification("alice", models.Notification{Type: "welcome", Title: "Welcome"})
+	repos.Terms.AcceptTerms("alice", "2024-01")
+	repos.Terms.AcceptTerms("alice", "2024-06")
+	repos.OAuth.CreateClient(models.OAuthClient{ID: "app", Name: "App"})
+	repos.OAuth.GrantAccess(models.OAuthGrant{ID: "g1", Username: "alice", ClientID: "app", Scopes: []string{"football:read"}})
+	repos.Moderation.ReportContent(models.ContentReport{Kind: models.ContentTeam, ResourceID: 1, Reporter: "alice", Reason: "spam"})
+	repos.Moderation.ReportContent(models.ContentReport{Kind: models.ContentTeam, ResourceID: 1, Reporter: "bob", Reason: "spam"})
+	repos.Metering.AddUsage([]models.UsageRecord{{Date: "2025-03-01", Username: "alice", Requests: 3}})
+	log := &fakeAuditLog{entries: []models.AuditEntry{
+		{ID: 1, Actor: "alice", Action: "team.created", ResourceID: "1"},
+		{ID: 2, Actor: "bob", Action: "team.deleted", ResourceID: "1"},
+	}}
+
+	now := time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC)
+	ah := handlers.NewAccountHandler(repos.Users, repos.Sessions)
+	ah.SetLogins(repos.Logins)
+	ah.SetPreferences(repos.Preferences)
+	ah.SetNotifications(repos.Notifications)
+	ah.SetTerms(repos.Terms)
+	ah.SetGrants(repos.OAuth)
+	ah.SetModeration(repos.Moderation)
+	ah.SetUsage(repos.Metering)
+	ah.SetAudit(log)
+	ah.SetClock(clock.NewFake(now))
+	r := gin.New()
+	r.GET("/api/v1/me/export", func(c *gin.Context) { c.Set("username", "alice") }, ah.ExportData)
+
+	w := doRequest(r, http.MethodGet, "/api/v1/me/export", nil)
+	assertStatus(t, w, http.StatusOK)
+	files := readExport(t, w.Body.Bytes())
+
+	var manifest models.ExportManifest
+	if err := json.Unmarshal(files["manifest.json"], &manifest); err != nil {
+		t.Fatalf("manifest.json: %v", err)
+	}
+	if !manifest.ExportedAt.Equal(now) || len(manifest.Files) != 10 || manifest.Truncated != nil {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+	for _, name := range manifest.Files {
+		if _, ok := files[name]; !ok {
+			t.Errorf("%s listed but missing", name)
+		}
+	}
+	for name, want := range map[string]int{
+		"logins.json": 1, "notifications.json": 1, "terms.json": 2, "apps.json": 1,
+		"reports.json": 1, "usage.json": 1, "audit.json": 1,
+	} {
+		var list []map[string]any
+		if err := json.Unmarshal(files[name], &list); err != nil || len(list) != want {
+			t.Errorf("%s: got %s", name, files[name])
+		}
+	}
+	var prefs map[string]any
+	if err := json.Unmarshal(files["preferences.json"], &prefs); err != nil || prefs["timezone"] != "Europe/London" {
+		t.Errorf("preferences.json: got %s", files["preferences.json"])
+	}
+}
+
+// readExport unpacks a data-export archive into its files by name.
+func readExport(t *testing.T, body []byte) map[string][]byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("invalid zip: %v", err)
+	}
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		files[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+	return files
+}
+
+func TestExportData_BoundsFiles(t *testing.T) {
+	repos := memory.New().Repositories()
+	if _, err := repos.Users.CreateUser("zoë", "hash"); err != nil {
+		t.Fatal(err)
+	}
+	// More audit entries than one file holds.
+	log := &fakeAuditLog{}
+	for i := range 10001 {
+		log.entries = append(log.entries, models.AuditEntry{ID: int64(i + 1), Actor: "zoë", Action: "team.updated"})
+	}
+	ah := handlers.NewAccountHandler(repos.Users, repos.Sessions)
+	ah.SetAudit(log)
+	r := gin.New()
+	r.GET("/api/v1/me/export", func(c *gin.Context) { c.Set("username", "zoë") }, ah.ExportData)
+
+	w := doRequest(r, http.MethodGet, "/api/v1/me/export", nil)
+	assertStatus(t, w, http.StatusOK)
+	if got := w.Header().Get("Content-Disposition"); got != "attachment; filename*=utf-8''export-zo%C3%AB.zip" {
+		t.Errorf("Content-Disposition: got %q", got)
+	}
+	files := readExport(t, w.Body.Bytes())
+	var manifest models.ExportManifest
+	if err := json.Unmarshal(files["manifest.json"], &manifest); err != nil {
+		t.Fatalf("manifest.json: %v", err)
+	}
+	if len(manifest.Truncated) != 1 || manifest.Truncated[0] != "audit.json" {
+		t.Fatalf("expected audit.json truncated, got %+v", manifest)
+	}
+	var entries []models.AuditEntry
+	if err := json.Unmarshal(files["audit.json"], &entries); err != nil || len(entries) != 10000 {
+		t.Fatalf("audit.json: %d entries, %v", len(entries), err)
+	}
+}
+
+func TestExportData_AccountNotFound(t *testing.T) {
+	r, _, _ := newAccountRouter("ghost")
+	w := doRequest(r, http.MethodGet, "/api/v1/me/export", nil)
+	assertStatus(t, w, http.StatusNotFound)
+}
+
+// --- Sessions ----------------------------------------------------------------
+
+func TestListSessions_MarksCurrent(t *testing.T) {
+	r, _, sessions := newAccountRouter("alice")
+	sessions.CreateSession(models.Session{ID: "current", Username: "alice", DeviceLabel: "laptop"})
+	sessions.CreateSession(models.Session{ID: "phone", Username: "alice", DeviceLabel: "phone"})
+	sessions.CreateSession(models.Session{ID: "other", Username: "bob"})
+
+	w := doRequest(r, http.MethodGet, "/api/v1/me/sessions", nil)
+	assertStatus(t, w, http.StatusOK)
+
+	var resp models.SessionListResponse
+	decodeJSON(t, w, &resp)
+	if len(resp.Sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(resp.Sessions))
+	}
+	for _, s := range resp.Sessions {
+		if s.Current != (s.ID == "current") {
+			t.Errorf("session %s: unexpected current=%v", s.ID, s.Current)
+		}
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	r, _, sessions := newAccountRouter("alice")
+	sessions.CreateSession(models.Session{ID: "phone", Username: "alice"})
+	sessions.CreateSession(models.Session{ID: "other", Username: "bob"})
+
+	w := doRequest(r, http.MethodDelete, "/api/v1/me/sessions/phone", nil)
+	assertStatus(t, w, http.StatusNoContent)
+	if _, ok := sessions.sessions["phone"]; ok {
+		t.Fatal("expected session to be revoked")
+	}
+
+	// Another user's session cannot be revoked.
+	w = doRequest(r, http.MethodDelete, "/api/v1/me/sessions/other", nil)
+	assertStatus(t, w, http.StatusNotFound)
+}
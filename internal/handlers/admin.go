@@ -0,0 +1,536 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/analytics"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/flags"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/logging"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/recording"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/report"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/scheduler"
+)
+
+// defaultLogLevelRevert is how long a log-level change lasts when the request
+// does not say.
+const defaultLogLevelRevert = 15 * time.Minute
+
+// defaultRecordingDuration is how long a recording runs when the request
+// does not say.
+const defaultRecordingDuration = 10 * time.Minute
+
+// AdminHandler serves the /admin endpoints used by operators to adjust the
+// running server.
+type AdminHandler struct {
+	level     *logging.Level
+	recorder  *recording.Recorder
+	sched     *scheduler.Scheduler
+	reports   DailyReports
+	analytics UsageAnalytics
+	flags     *flags.Flags
+	backups   Backups
+}
+
+// DailyReports looks up daily activity reports; *report.Reporter implements
+// it.
+type DailyReports interface {
+	Daily(ctx context.Context, day time.Time) (models.DailyReport, error)
+}
+
+// UsageAnalytics reports aggregated API usage; *analytics.Analytics
+// implements it.
+type UsageAnalytics interface {
+	Endpoints(ctx context.Context, from, to time.Time) ([]models.EndpointUsage, error)
+	Users(ctx context.Context, day time.Time, limit int) ([]models.SubjectUsage, error)
+}
+
+// NewAdminHandler constructs an AdminHandler.
+func NewAdminHandler(level *logging.Level) *AdminHandler {
+	return &AdminHandler{level: level}
+}
+
+// SetRecorder enables the /admin/recording endpoints.
+func (h *AdminHandler) SetRecorder(rec *recording.Recorder) {
+	h.recorder = rec
+}
+
+// SetScheduler enables the /admin/jobs endpoint.
+func (h *AdminHandler) SetScheduler(s *scheduler.Scheduler) {
+	h.sched = s
+}
+
+// SetReports enables the /admin/reports/daily endpoint.
+func (h *AdminHandler) SetReports(r DailyReports) {
+	h.reports = r
+}
+
+// SetAnalytics enables the /admin/analytics endpoints.
+func (h *AdminHandler) SetAnalytics(a UsageAnalytics) {
+	h.analytics = a
+}
+
+// SetBackups enables the /admin/backups endpoints.
+func (h *AdminHandler) SetBackups(b Backups) {
+	h.backups = b
+}
+
+// SetFlags enables the /admin/flags endpoints.
+func (h *AdminHandler) SetFlags(f *flags.Flags) {
+	h.flags = f
+}
+
+// GetLogLevel handles GET /api/v1/admin/log-level
+//
+//	@Summary		Get log level
+//	@Description	Returns the current runtime log level and when any temporary change reverts
+//	@Tags			admin
+//	@Produce		json
+//	@Success		200	{object}	models.LogLevelResponse
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	models.ErrorResponse	"Admin privileges required"
+//	@Security		Bearer
+//	@Router			/admin/log-level [get]
+func (h *AdminHandler) GetLogLevel(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, h.logLevelResponse())
+}
+
+// SetLogLevel handles PUT /api/v1/admin/log-level
+// Changes the request logger's level without a restart.  Any level other than
+// the configured default reverts automatically after revertAfter (default
+// 15m, at most 24h) so that debug logging is never left on by mistake.
+//
+//	@Summary		Set log level
+//	@Description	Change the runtime log level; non-default levels revert automatically
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		models.LogLevelRequest	true	"New level"
+//	@Success		200		{object}	models.LogLevelResponse
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid level or duration"
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	models.ErrorResponse	"Admin privileges required"
+//	@Security		Bearer
+//	@Router			/admin/log-level [put]
+func (h *AdminHandler) SetLogLevel(c *gin.Context) {
+	var req models.LogLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: errcode.Binding(err)})
+		return
+	}
+
+	level, err := logging.ParseLevel(req.Level)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "level must be one of debug, info, warn, error", Code: errcode.FieldInvalid})
+		return
+	}
+
+	revertAfter := defaultLogLevelRevert
+	if req.RevertAfter != "" {
+		revertAfter, err = time.ParseDuration(req.RevertAfter)
+		if err != nil || revertAfter <= 0 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "revertAfter must be a positive duration such as 15m", Code: errcode.FieldInvalid})
+			return
+		}
+	}
+
+	h.level.Set(level, revertAfter)
+	c.JSON(http.StatusOK, h.logLevelResponse())
+}
+
+func (h *AdminHandler) logLevelResponse() models.LogLevelResponse {
+	resp := models.LogLevelResponse{
+		Level:        strings.ToLower(h.level.Level().String()),
+		DefaultLevel: strings.ToLower(h.level.Default().String()),
+		Links: []models.Link{
+			{Rel: "self", Href: "/api/v1/admin/log-level", Method: "GET"},
+			{Rel: "update", Href: "/api/v1/admin/log-level", Method: "PUT"},
+		},
+	}
+	if at := h.level.RevertAt(); !at.IsZero() {
+		resp.RevertAt = &at
+	}
+	return resp
+}
+
+// GetRecording handles GET /api/v1/admin/recording
+//
+//	@Summary		Get request recording
+//	@Description	Reports whether request/response pairs are being recorded, and to which file
+//	@Tags			admin
+//	@Produce		json
+//	@Success		200	{object}	models.RecordingResponse
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	models.ErrorResponse	"Admin privileges required"
+//	@Security		Bearer
+//	@Router			/admin/recording [get]
+func (h *AdminHandler) GetRecording(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, recordingResponse(h.recorder.State()))
+}
+
+// SetRecording handles PUT /api/v1/admin/recording
+// Starts a new recording of sanitised request/response pairs, or stops the
+// current one.  A recording stops on its own after duration (default 10m,
+// at most 1h).
+//
+//	@Summary		Start or stop request recording
+//	@Description	Record sanitised request/response pairs to disk for cmd/replay; recordings stop automatically
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		models.RecordingRequest	true	"Enable or disable"
+//	@Success		200		{object}	models.RecordingResponse
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid duration"
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	models.ErrorResponse	"Admin privileges required"
+//	@Failure		500		{object}	models.ErrorResponse	"Recording could not be started"
+//	@Security		Bearer
+//	@Router			/admin/recording [put]
+func (h *AdminHandler) SetRecording(c *gin.Context) {
+	var req models.RecordingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: errcode.Binding(err)})
+		return
+	}
+	if !req.Enabled {
+		h.recorder.Stop()
+		c.JSON(http.StatusOK, recordingResponse(recording.State{}))
+		return
+	}
+
+	d := defaultRecordingDuration
+	if req.Duration != "" {
+		var err error
+		d, err = time.ParseDuration(req.Duration)
+		if err != nil || d <= 0 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "duration must be a positive duration such as 10m", Code: errcode.FieldInvalid})
+			return
+		}
+	}
+	state, err := h.recorder.Start(d)
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	c.JSON(http.StatusOK, recordingResponse(state))
+}
+
+func recordingResponse(s recording.State) models.RecordingResponse {
+	resp := models.RecordingResponse{
+		Enabled: s.File != "",
+		File:    s.File,
+		Links: []models.Link{
+			{Rel: "self", Href: "/api/v1/admin/recording", Method: "GET"},
+			{Rel: "update", Href: "/api/v1/admin/recording", Method: "PUT"},
+		},
+	}
+	if !s.Until.IsZero() {
+		resp.Until = &s.Until
+	}
+	return resp
+}
+
+// GetJobs handles GET /api/v1/admin/jobs
+//
+//	@Summary		List scheduled jobs
+//	@Description	Lists the background jobs with their schedules, next run times and most recent run
+//	@Tags			admin
+//	@Produce		json
+//	@Success		200	{object}	models.JobsResponse
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	models.ErrorResponse	"Admin privileges required"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/admin/jobs [get]
+func (h *AdminHandler) GetJobs(c *gin.Context) {
+	jobs, err := h.sched.Jobs(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	resp := models.JobsResponse{
+		Data:  make([]models.Job, 0, len(jobs)),
+		Links: []models.Link{{Rel: "self", Href: "/api/v1/admin/jobs", Method: "GET"}},
+	}
+	for _, j := range jobs {
+		job := models.Job{Name: j.Name, Schedule: j.Schedule, Running: j.Running}
+		if !j.NextRun.IsZero() {
+			next := j.NextRun
+			job.NextRun = &next
+		}
+		if r := j.LastRun; r != nil {
+			job.LastRun = &models.JobRun{Started: r.Started, Finished: r.Finished, Status: r.Status, Error: r.Error}
+		}
+		resp.Data = append(resp.Data, job)
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetDailyReport handles GET /api/v1/admin/reports/daily
+// Returns the activity report for one UTC day (default yesterday) as JSON
+// or, with format=html, as an HTML page; download=true serves either as an
+// attachment.  Past days are generated once and then returned as stored.
+//
+//	@Summary		Daily activity report
+//	@Description	New and changed records, new and active users and the server error rate for one UTC day
+//	@Tags			admin
+//	@Produce		json,html
+//	@Param			date		query		string	false	"Day as YYYY-MM-DD (default yesterday)"
+//	@Param			format		query		string	false	"json (default) or html"
+//	@Param			download	query		bool	false	"Serve as an attachment"
+//	@Success		200			{object}	models.DailyReport
+//	@Failure		400			{object}	models.ErrorResponse	"Invalid date or format"
+//	@Failure		401			{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403			{object}	models.ErrorResponse	"Admin privileges required"
+//	@Failure		500			{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/admin/reports/daily [get]
+func (h *AdminHandler) GetDailyReport(c *gin.Context) {
+	day := time.Now().UTC().AddDate(0, 0, -1)
+	if v := c.Query("date"); v != "" {
+		var err error
+		if day, err = time.Parse(report.DateLayout, v); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "date must be YYYY-MM-DD", Code: errcode.InvalidParameter})
+			return
+		}
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "html" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "format must be json or html", Code: errcode.InvalidParameter})
+		return
+	}
+
+	rep, err := h.reports.Daily(c.Request.Context(), day)
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	if c.Query("download") == "true" {
+		c.Header("Content-Disposition", `attachment; filename="report-`+rep.Date+`.`+format+`"`)
+	}
+	c.Header("Cache-Control", "no-store")
+
+	if format == "html" {
+		page, err := report.HTML(rep)
+		if err != nil {
+			_ = c.Error(err)
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(page))
+		return
+	}
+	self := "/api/v1/admin/reports/daily?date=" + rep.Date
+	rep.Links = []models.Link{
+		{Rel: "self", Href: self, Method: "GET"},
+		{Rel: "html", Href: self + "&format=html", Method: "GET"},
+	}
+	c.JSON(http.StatusOK, rep)
+}
+
+// GetFlags handles GET /api/v1/admin/flags
+//
+//	@Summary		List feature flags
+//	@Description	Returns every feature flag, its value and where the value came from
+//	@Tags			admin
+//	@Produce		json
+//	@Success		200	{object}	models.FeatureFlagsResponse
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	models.ErrorResponse	"Admin privileges required"
+//	@Security		Bearer
+//	@Router			/admin/flags [get]
+func (h *AdminHandler) GetFlags(c *gin.Context) {
+	all := h.flags.All()
+	resp := models.FeatureFlagsResponse{
+		Data:  make([]models.FeatureFlag, 0, len(all)),
+		Links: []models.Link{{Rel: "self", Href: "/api/v1/admin/flags", Method: "GET"}},
+	}
+	for _, f := range all {
+		resp.Data = append(resp.Data, featureFlag(f))
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, resp)
+}
+
+// SetFlag handles PUT /api/v1/admin/flags/:name
+// Turns a feature flag on or off.  With a database the change is stored
+// and reaches the other instances within 30 seconds; otherwise it lasts
+// until the server restarts.
+//
+//	@Summary		Set a feature flag
+//	@Description	Turn a feature flag on or off at runtime
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			name	path		string						true	"Flag name"
+//	@Param			body	body		models.FeatureFlagRequest	true	"New value"
+//	@Success		200		{object}	models.FeatureFlag
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid request"
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	models.ErrorResponse	"Admin privileges required"
+//	@Failure		404		{object}	models.ErrorResponse	"Unknown flag"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/admin/flags/{name} [put]
+func (h *AdminHandler) SetFlag(c *gin.Context) {
+	var req models.FeatureFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: errcode.Binding(err)})
+		return
+	}
+	f, err := h.flags.Set(c.Request.Context(), c.Param("name"), *req.Enabled, c.GetString("username"), time.Now().UTC())
+	if errors.Is(err, flags.ErrUnknown) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "unknown flag", Code: errcode.FlagNotFound})
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	c.JSON(http.StatusOK, featureFlag(f))
+}
+
+func featureFlag(f flags.Flag) models.FeatureFlag {
+	href := "/api/v1/admin/flags/" + f.Name
+	out := models.FeatureFlag{
+		Name:      f.Name,
+		Enabled:   f.Enabled,
+		Source:    f.Source,
+		UpdatedBy: f.UpdatedBy,
+		Links: []models.Link{
+			{Rel: "update", Href: href, Method: "PUT"},
+			{Rel: "collection", Href: "/api/v1/admin/flags", Method: "GET"},
+		},
+	}
+	if !f.UpdatedAt.IsZero() {
+		at := f.UpdatedAt
+		out.UpdatedAt = &at
+	}
+	return out
+}
+
+// maxAnalyticsDays bounds the range of GET /admin/analytics/endpoints.
+const maxAnalyticsDays = 31
+
+// GetEndpointUsage handles GET /api/v1/admin/analytics/endpoints
+// Returns requests and distinct users per route for each UTC day in a range
+// of at most 31 days (default the last seven, including today).
+//
+//	@Summary		Endpoint usage
+//	@Description	Requests and distinct signed-in users per route and UTC day
+//	@Tags			admin
+//	@Produce		json
+//	@Param			from	query		string	false	"First day as YYYY-MM-DD (default six days before to)"
+//	@Param			to		query		string	false	"Last day as YYYY-MM-DD (default today)"
+//	@Success		200		{object}	models.EndpointUsageResponse
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid range"
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	models.ErrorResponse	"Admin privileges required"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/admin/analytics/endpoints [get]
+func (h *AdminHandler) GetEndpointUsage(c *gin.Context) {
+	to := time.Now().UTC()
+	if v := c.Query("to"); v != "" {
+		var err error
+		if to, err = time.Parse(analytics.DateLayout, v); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "to must be YYYY-MM-DD", Code: errcode.InvalidParameter})
+			return
+		}
+	}
+	from := to.AddDate(0, 0, -6)
+	if v := c.Query("from"); v != "" {
+		var err error
+		if from, err = time.Parse(analytics.DateLayout, v); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "from must be YYYY-MM-DD", Code: errcode.InvalidParameter})
+			return
+		}
+	}
+	if from.After(to) || to.Sub(from) >= maxAnalyticsDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: fmt.Sprintf("from must be on or before to, at most %d days earlier", maxAnalyticsDays-1), Code: errcode.InvalidParameter})
+		return
+	}
+
+	data, err := h.analytics.Endpoints(c.Request.Context(), from, to)
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	resp := models.EndpointUsageResponse{
+		From: from.Format(analytics.DateLayout),
+		To:   to.Format(analytics.DateLayout),
+		Data: data,
+	}
+	resp.Links = []models.Link{
+		{Rel: "self", Href: "/api/v1/admin/analytics/endpoints?from=" + resp.From + "&to=" + resp.To, Method: http.MethodGet},
+		{Rel: "users", Href: "/api/v1/admin/analytics/users?date=" + resp.To, Method: http.MethodGet},
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetUserUsage handles GET /api/v1/admin/analytics/users
+// Returns each pseudonymous user's request count on one UTC day (default
+// today).  Subjects change every day and cannot be traced to usernames.
+//
+//	@Summary		Usage per user
+//	@Description	Requests per pseudonymous user on one UTC day, most active first
+//	@Tags			admin
+//	@Produce		json
+//	@Param			date	query		string	false	"Day as YYYY-MM-DD (default today)"
+//	@Param			limit	query		int		false	"Maximum users (default 100, max 1000)"
+//	@Success		200		{object}	models.SubjectUsageResponse
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid date or limit"
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	models.ErrorResponse	"Admin privileges required"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/admin/analytics/users [get]
+func (h *AdminHandler) GetUserUsage(c *gin.Context) {
+	day := time.Now().UTC()
+	if v := c.Query("date"); v != "" {
+		var err error
+		if day, err = time.Parse(analytics.DateLayout, v); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "date must be YYYY-MM-DD", Code: errcode.InvalidParameter})
+			return
+		}
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 || limit > 1000 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "limit must be between 1 and 1000", Code: errcode.InvalidParameter})
+		return
+	}
+
+	data, err := h.analytics.Users(c.Request.Context(), day, limit)
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	date := day.Format(analytics.DateLayout)
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, models.SubjectUsageResponse{
+		Date: date,
+		Data: data,
+		Links: []models.Link{
+			{Rel: "self", Href: "/api/v1/admin/analytics/users?date=" + date, Method: http.MethodGet},
+			{Rel: "endpoints", Href: "/api/v1/admin/analytics/endpoints?from=" + date + "&to=" + date, Method: http.MethodGet},
+		},
+	})
+}
@@ -0,0 +1,246 @@
+package handlers_test
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/flags"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/handlers"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/lock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/logging"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/recording"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/redact"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/scheduler"
+)
+
+func newAdminRouter(level *logging.Level) *gin.Engine {
+	h := handlers.NewAdminHandler(level)
+	r := gin.New()
+	r.GET("/api/v1/admin/log-level", h.GetLogLevel)
+	r.PUT("/api/v1/admin/log-level", h.SetLogLevel)
+	return r
+}
+
+func TestSetLogLevel_SchedulesRevert(t *testing.T) {
+	level := logging.NewLevel(slog.LevelInfo)
+	r := newAdminRouter(level)
+
+	w := doRequest(r, http.MethodPut, "/api/v1/admin/log-level", map[string]string{"level": "debug", "revertAfter": "5m"})
+	assertStatus(t, w, http.StatusOK)
+
+	var resp models.LogLevelResponse
+	decodeJSON(t, w, &resp)
+	if resp.Level != "debug" || resp.DefaultLevel != "info" {
+		t.Errorf("expected debug (default info), got %s (default %s)", resp.Level, resp.DefaultLevel)
+	}
+	if resp.RevertAt == nil {
+		t.Error("expected revertAt for a temporary level change")
+	}
+	if !level.Enabled(slog.LevelDebug) {
+		t.Error("expected debug logging to be enabled")
+	}
+
+	w = doRequest(r, http.MethodGet, "/api/v1/admin/log-level", nil)
+	assertStatus(t, w, http.StatusOK)
+	decodeJSON(t, w, &resp)
+	if resp.Level != "debug" {
+		t.Errorf("expected GET to report debug, got %s", resp.Level)
+	}
+}
+
+func TestSetLogLevel_Invalid(t *testing.T) {
+	r := newAdminRouter(logging.NewLevel(slog.LevelInfo))
+
+	for _, body := range []map[string]string{
+		{},
+		{"level": "verbose"},
+		{"level": "debug", "revertAfter": "soon"},
+		{"level": "debug", "revertAfter": "-1m"},
+	} {
+		w := doRequest(r, http.MethodPut, "/api/v1/admin/log-level", body)
+		assertStatus(t, w, http.StatusBadRequest)
+	}
+}
+
+func TestSetRecording_StartAndStop(t *testing.T) {
+	h := handlers.NewAdminHandler(logging.NewLevel(slog.LevelInfo))
+	h.SetRecorder(recording.New(t.TempDir(), redact.New(nil, nil, false)))
+	r := gin.New()
+	r.GET("/api/v1/admin/recording", h.GetRecording)
+	r.PUT("/api/v1/admin/recording", h.SetRecording)
+
+	w := doRequest(r, http.MethodPut, "/api/v1/admin/recording", map[string]interface{}{"enabled": true, "duration": "2m"})
+	assertStatus(t, w, http.StatusOK)
+	var resp models.RecordingResponse
+	decodeJSON(t, w, &resp)
+	if !resp.Enabled || resp.File == "" || resp.Until == nil {
+		t.Fatalf("expected a running recording, got %+v", resp)
+	}
+
+	w = doRequest(r, http.MethodPut, "/api/v1/admin/recording", map[string]interface{}{"enabled": true, "duration": "soon"})
+	assertStatus(t, w, http.StatusBadRequest)
+
+	w = doRequest(r, http.MethodPut, "/api/v1/admin/recording", map[string]interface{}{"enabled": false})
+	assertStatus(t, w, http.StatusOK)
+	w = doRequest(r, http.MethodGet, "/api/v1/admin/recording", nil)
+	decodeJSON(t, w, &resp)
+	if resp.Enabled {
+		t.Fatalf("expected recording stopped, got %+v", resp)
+	}
+}
+
+func TestGetJobs(t *testing.T) {
+	sched := scheduler.New(lock.NewLocal(), scheduler.NewMemoryHistory())
+	if err := sched.Add("cleanup", "@hourly", func(context.Context) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	sched.RunDue(context.Background(), time.Now().Add(time.Hour))
+	sched.Wait()
+
+	h := handlers.NewAdminHandler(logging.NewLevel(slog.LevelInfo))
+	h.SetScheduler(sched)
+	r := gin.New()
+	r.GET("/api/v1/admin/jobs", h.GetJobs)
+
+	w := doRequest(r, http.MethodGet, "/api/v1/admin/jobs", nil)
+	assertStatus(t, w, http.StatusOK)
+	var resp models.JobsResponse
+	decodeJSON(t, w, &resp)
+	if len(resp.Data) != 1 {
+		t.Fatalf("expected one job, got %+v", resp.Data)
+	}
+	job := resp.Data[0]
+	if job.Name != "cleanup" || job.Schedule != "@hourly" || job.NextRun == nil {
+		t.Errorf("unexpected job %+v", job)
+	}
+	if job.LastRun == nil || job.LastRun.Status != scheduler.StatusOK {
+		t.Errorf("expected an ok last run, got %+v", job.LastRun)
+	}
+}
+
+func TestFlags(t *testing.T) {
+	f, err := flags.New(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handlers.NewAdminHandler(logging.NewLevel(slog.LevelInfo))
+	h.SetFlags(f)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("username", "alice") })
+	r.GET("/api/v1/admin/flags", h.GetFlags)
+	r.PUT("/api/v1/admin/flags/:name", h.SetFlag)
+
+	w := doRequest(r, http.MethodPut, "/api/v1/admin/flags/registration", map[string]bool{"enabled": false})
+	assertStatus(t, w, http.StatusOK)
+	var flag models.FeatureFlag
+	decodeJSON(t, w, &flag)
+	if flag.Enabled || flag.Source != flags.SourceRuntime || flag.UpdatedBy != "alice" {
+		t.Errorf("unexpected flag %+v", flag)
+	}
+	if f.Enabled(flags.Registration) {
+		t.Error("expected registration to be off")
+	}
+
+	w = doRequest(r, http.MethodGet, "/api/v1/admin/flags", nil)
+	assertStatus(t, w, http.StatusOK)
+	var resp models.FeatureFlagsResponse
+	decodeJSON(t, w, &resp)
+	if len(resp.Data) != len(flags.Defaults) {
+		t.Errorf("expected %d flags, got %+v", len(flags.Defaults), resp.Data)
+	}
+
+	w = doRequest(r, http.MethodPut, "/api/v1/admin/flags/nope", map[string]bool{"enabled": true})
+	assertStatus(t, w, http.StatusNotFound)
+	w = doRequest(r, http.MethodPut, "/api/v1/admin/flags/registration", map[string]string{})
+	assertStatus(t, w, http.StatusBadRequest)
+}
+
+type fakeReports map[string]models.DailyReport
+
+func (f fakeReports) Daily(_ context.Context, day time.Time) (models.DailyReport, error) {
+	return f[day.Format("2006-01-02")], nil
+}
+
+func TestGetDailyReport(t *testing.T) {
+	h := handlers.NewAdminHandler(logging.NewLevel(slog.LevelInfo))
+	h.SetReports(fakeReports{"2024-03-15": {Date: "2024-03-15", Requests: 200, ServerErrors: 3, ErrorRate: 0.015}})
+	r := gin.New()
+	r.GET("/api/v1/admin/reports/daily", h.GetDailyReport)
+
+	w := doRequest(r, http.MethodGet, "/api/v1/admin/reports/daily?date=2024-03-15", nil)
+	assertStatus(t, w, http.StatusOK)
+	var rep models.DailyReport
+	decodeJSON(t, w, &rep)
+	if rep.Date != "2024-03-15" || rep.ServerErrors != 3 || len(rep.Links) == 0 {
+		t.Errorf("unexpected report %+v", rep)
+	}
+
+	w = doRequest(r, http.MethodGet, "/api/v1/admin/reports/daily?date=2024-03-15&format=html&download=true", nil)
+	assertStatus(t, w, http.StatusOK)
+	if ct := w.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename="report-2024-03-15.html"` {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+	if body := w.Body.String(); !strings.Contains(body, "1.50%") {
+		t.Errorf("expected the error rate in the page:\n%s", body)
+	}
+
+	for _, q := range []string{"?date=15/03/2024", "?format=pdf"} {
+		w = doRequest(r, http.MethodGet, "/api/v1/admin/reports/daily"+q, nil)
+		assertStatus(t, w, http.StatusBadRequest)
+	}
+}
+
+type fakeAnalytics struct{ from, to time.Time }
+
+func (f *fakeAnalytics) Endpoints(_ context.Context, from, to time.Time) ([]models.EndpointUsage, error) {
+	f.from, f.to = from, to
+	return []models.EndpointUsage{{Date: "2024-03-15", Method: "GET", Route: "/api/v1/football/teams", Requests: 10, Users: 3}}, nil
+}
+
+func (f *fakeAnalytics) Users(_ context.Context, day time.Time, limit int) ([]models.SubjectUsage, error) {
+	return []models.SubjectUsage{{Subject: "u-1", Requests: 7, Endpoints: 2}}, nil
+}
+
+func TestAnalytics(t *testing.T) {
+	h := handlers.NewAdminHandler(logging.NewLevel(slog.LevelInfo))
+	f := &fakeAnalytics{}
+	h.SetAnalytics(f)
+	r := gin.New()
+	r.GET("/api/v1/admin/analytics/endpoints", h.GetEndpointUsage)
+	r.GET("/api/v1/admin/analytics/users", h.GetUserUsage)
+
+	w := doRequest(r, http.MethodGet, "/api/v1/admin/analytics/endpoints?to=2024-03-15", nil)
+	assertStatus(t, w, http.StatusOK)
+	var endpoints models.EndpointUsageResponse
+	decodeJSON(t, w, &endpoints)
+	if endpoints.From != "2024-03-09" || len(endpoints.Data) != 1 || f.from.Format("2006-01-02") != "2024-03-09" {
+		t.Errorf("expected the week to 2024-03-15, got %+v", endpoints)
+	}
+
+	w = doRequest(r, http.MethodGet, "/api/v1/admin/analytics/users?date=2024-03-15", nil)
+	assertStatus(t, w, http.StatusOK)
+	var users models.SubjectUsageResponse
+	decodeJSON(t, w, &users)
+	if users.Date != "2024-03-15" || len(users.Data) != 1 || users.Data[0].Subject != "u-1" {
+		t.Errorf("unexpected users %+v", users)
+	}
+
+	for _, q := range []string{
+		"endpoints?from=2024-03-16&to=2024-03-15",
+		"endpoints?from=2024-01-01&to=2024-03-15",
+		"endpoints?to=tomorrow",
+		"users?date=15/03/2024",
+		"users?limit=0",
+	} {
+		assertStatus(t, doRequest(r, http.MethodGet, "/api/v1/admin/analytics/"+q, nil), http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/sanitize"
+)
+
+// AnnouncementHandler serves /admin/announcements, through which operators
+// broadcast messages to every user, and the public banner list at
+// /announcements.
+type AnnouncementHandler struct {
+	repo   db.AnnouncementRepository
+	events *events.Bus
+	clock  clock.Clock
+}
+
+// NewAnnouncementHandler constructs an AnnouncementHandler.
+func NewAnnouncementHandler(repo db.AnnouncementRepository) *AnnouncementHandler {
+	return &AnnouncementHandler{repo: repo, clock: clock.System{}}
+}
+
+// SetClock makes scheduling and expiry relative to c instead of the wall
+// clock.
+func (h *AnnouncementHandler) SetClock(c clock.Clock) {
+	h.clock = clock.Or(c)
+}
+
+// SetEvents publishes new announcements to bus, from which the inbox
+// copies them to every user.
+func (h *AnnouncementHandler) SetEvents(bus *events.Bus) {
+	h.events = bus
+}
+
+// CreateAnnouncement handles POST /api/v1/admin/announcements
+// Publishes an announcement to every user's inbox at publishAt (default
+// now) until expiresAt, and lists it at /announcements meanwhile when banner
+// is set.
+//
+//	@Summary		Create an announcement
+//	@Description	Broadcast a message to every user's inbox, optionally as a banner, with scheduling and expiry
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		models.AnnouncementRequest	true	"Announcement"
+//	@Success		201		{object}	models.Announcement
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid announcement"
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	models.ErrorResponse	"Admin privileges required"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/admin/announcements [post]
+func (h *AnnouncementHandler) CreateAnnouncement(c *gin.Context) {
+	var req models.AnnouncementRequest
+	if err := c.ShouldBindWith(&req, sanitize.JSON); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: errcode.Binding(err)})
+		return
+	}
+	publishAt := h.clock.Now().UTC()
+	if req.PublishAt != nil && req.PublishAt.After(publishAt) {
+		publishAt = req.PublishAt.UTC()
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(publishAt) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "expiresAt must be after publishAt and in the future", Code: errcode.FieldInvalid})
+		return
+	}
+
+	a, err := h.repo.CreateAnnouncement(models.Announcement{
+		Title:     req.Title,
+		Body:      req.Body,
+		Banner:    req.Banner,
+		PublishAt: publishAt,
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: c.GetString("username"),
+	})
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	publish(c, h.events, events.AnnouncementCreated, strconv.Itoa(a.ID), a)
+
+	a.Links = announcementLinks(a.ID)
+	c.Header("Location", "/api/v1/admin/announcements/"+strconv.Itoa(a.ID))
+	c.JSON(http.StatusCreated, a)
+}
+
+// ListAnnouncements handles GET /api/v1/admin/announcements
+//
+//	@Summary		List announcements
+//	@Description	Every announcement, newest first, including scheduled and expired ones
+//	@Tags			admin
+//	@Produce		json
+//	@Success		200	{object}	models.AnnouncementListResponse
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	models.ErrorResponse	"Admin privileges required"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/admin/announcements [get]
+func (h *AnnouncementHandler) ListAnnouncements(c *gin.Context) {
+	list, err := h.repo.ListAnnouncements()
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	if list == nil {
+		list = []models.Announcement{}
+	}
+	for i := range list {
+		list[i].Links = announcementLinks(list[i].ID)
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, models.AnnouncementListResponse{
+		Data: list,
+		Links: []models.Link{
+			{Rel: "self", Href: "/api/v1/admin/announcements", Method: http.MethodGet},
+			{Rel: "create", Href: "/api/v1/admin/announcements", Method: http.MethodPost},
+		},
+	})
+}
+
+// DeleteAnnouncement handles DELETE /api/v1/admin/announcements/:id
+// Withdraws an announcement, removing it from every inbox.
+//
+//	@Summary		Delete an announcement
+//	@Tags			admin
+//	@Param			id	path	int	true	"Announcement ID"
+//	@Success		204
+//	@Failure		400	{object}	models.ErrorResponse	"Invalid announcement ID"
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	models.ErrorResponse	"Admin privileges required"
+//	@Failure		404	{object}	models.ErrorResponse	"Announcement not found"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/admin/announcements/{id} [delete]
+func (h *AnnouncementHandler) DeleteAnnouncement(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid announcement id", Code: errcode.InvalidID})
+		return
+	}
+	err = h.repo.DeleteAnnouncement(id)
+	if errors.Is(err, models.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "announcement not found", Code: errcode.AnnouncementNotFound})
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListBanners handles GET /api/v1/announcements
+// Lists the banner announcements live now, for clients to show on every
+// page.
+//
+//	@Summary		Active banners
+//	@Description	Banner announcements between their publish and expiry times, newest first
+//	@Tags			meta
+//	@Produce		json
+//	@Success		200	{object}	models.AnnouncementListResponse
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Router			/announcements [get]
+func (h *AnnouncementHandler) ListBanners(c *gin.Context) {
+	list, err := h.repo.ActiveBanners(h.clock.Now())
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	if list == nil {
+		list = []models.Announcement{}
+	}
+	for i := range list {
+		// Who wrote it is for operators only.
+		list[i].CreatedBy = ""
+	}
+	c.JSON(http.StatusOK, models.AnnouncementListResponse{
+		Data: list,
+		Links: []models.Link{
+			{Rel: "self", Href: "/api/v1/announcements", Method: http.MethodGet},
+		},
+	})
+}
+
+func announcementLinks(id int) []models.Link {
+	return []models.Link{
+		{Rel: "delete", Href: "/api/v1/admin/announcements/" + strconv.Itoa(id), Method: http.MethodDelete},
+		{Rel: "collection", Href: "/api/v1/admin/announcements", Method: http.MethodGet},
+	}
+}
@@ -0,0 +1,89 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/audit"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/handlers"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// fakeAuditLog filters a fixed set of entries; failAfter > 0 makes Iterate
+// fail after that many.
+type fakeAuditLog struct {
+	entries   []models.AuditEntry
+	failAfter int
+	got       audit.Filter
+}
+
+func (f *fakeAuditLog) Iterate(_ context.Context, filter audit.Filter, fn func(models.AuditEntry) error) error {
+	f.got = filter
+	for i, e := range f.entries {
+		if f.failAfter > 0 && i == f.failAfter {
+			return errors.New("connection reset")
+		}
+		if filter.Actor != "" && e.Actor != filter.Actor {
+			continue
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func auditRouter(log handlers.AuditLog) *gin.Engine {
+	r := gin.New()
+	r.GET("/api/v1/audit/export", handlers.NewAuditHandler(log).Export)
+	return r
+}
+
+func TestAuditExport(t *testing.T) {
+	at := time.Date(2024, 3, 15, 9, 30, 0, 0, time.UTC)
+	log := &fakeAuditLog{entries: []models.AuditEntry{
+		{ID: 1, At: at, Actor: "alice", Action: "team.created", ResourceID: "7"},
+		{ID: 2, At: at, Actor: "bob", Action: "match.deleted", ResourceID: "12"},
+		{ID: 3, At: at, Actor: "alice", Action: "match.updated", ResourceID: `4,"x"`, Impersonator: "root",
+			Changes: json.RawMessage(`[{"field":"homeScore","old":1,"new":2}]`)},
+	}}
+	r := auditRouter(log)
+
+	w := doRequest(r, http.MethodGet, "/api/v1/audit/export?actor=alice&impersonator=root&from=2024-03-01T00:00:00Z", nil)
+	assertStatus(t, w, http.StatusOK)
+	if ct := w.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	rows, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[0][0] != "id" || rows[1][3] != "team.created" || rows[2][4] != `4,"x"` || rows[2][5] != "root" ||
+		rows[1][6] != "" || rows[2][6] != `[{"field":"homeScore","old":1,"new":2}]` {
+		t.Errorf("unexpected CSV %q", rows)
+	}
+	if log.got.Actor != "alice" || log.got.Impersonator != "root" || !log.got.From.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("filter not passed through: %+v", log.got)
+	}
+	if w.Header().Get("X-Export-Error") != "" {
+		t.Error("expected no error trailer on success")
+	}
+
+	w = doRequest(r, http.MethodGet, "/api/v1/audit/export?to=yesterday", nil)
+	assertStatus(t, w, http.StatusBadRequest)
+}
+
+func TestAuditExport_FailurePartWay(t *testing.T) {
+	log := &fakeAuditLog{failAfter: 1, entries: []models.AuditEntry{{ID: 1}, {ID: 2}}}
+	w := doRequest(auditRouter(log), http.MethodGet, "/api/v1/audit/export", nil)
+	if got := w.Header().Get("X-Export-Error"); got == "" {
+		t.Error("expected the X-Export-Error trailer")
+	}
+}
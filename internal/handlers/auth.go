@@ -5,31 +5,147 @@ package handlers
 
 import (
 	"errors"
+	"log"
 	"net/http"
+	"net/url"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/db"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/device"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/flags"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
-	"golang.org/x/crypto/bcrypt"
 )
 
+// maxDeviceLabel is the longest device label stored with a session.
+const maxDeviceLabel = 100
+
+// maxUserAgent is the longest User-Agent kept in login activity.
+const maxUserAgent = 500
+
+// truncate shortens s to at most n characters, without splitting one.
+func truncate(s string, n int) string {
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+	return s
+}
+
 // AuthHandler holds dependencies for authentication endpoints.
 type AuthHandler struct {
 	users      db.UserRepository
+	sessions   db.SessionRepository
+	logins     db.LoginRepository
+	grants     db.OAuthRepository
 	jwtService *auth.JWTService
+	passwords  *auth.PasswordHasher
+	events     *events.Bus
+	flags      *flags.Flags
+	invites    db.InviteRepository
+	terms      db.TermsRepository
+	termsVer   string
+	clock      clock.Clock
+	ids        auth.IDGenerator
+	admins     map[string]bool
+
+	// dummyHash is verified against when the username is unknown, so that a
+	// failed login takes as long whether or not the account exists.
+	dummyHash string
 }
 
-// NewAuthHandler constructs an AuthHandler.
-func NewAuthHandler(users db.UserRepository, jwtService *auth.JWTService) *AuthHandler {
+// NewAuthHandler constructs an AuthHandler.  It panics if passwords cannot
+// hash, which happens only when the system's random source fails: logins
+// for unknown usernames would otherwise fail fast and reveal which accounts
+// exist.
+func NewAuthHandler(users db.UserRepository, sessions db.SessionRepository, jwtService *auth.JWTService, passwords *auth.PasswordHasher) *AuthHandler {
+	dummyHash, err := passwords.Hash("not-a-real-password")
+	if err != nil {
+		panic("handlers: hash the dummy password: " + err.Error())
+	}
 	return &AuthHandler{
 		users:      users,
+		sessions:   sessions,
 		jwtService: jwtService,
+		passwords:  passwords,
+		clock:      clock.System{},
+		ids:        auth.RandomIDs{},
+		dummyHash:  dummyHash,
+	}
+}
+
+// SetClock makes session expiry times relative to c instead of the wall
+// clock.
+func (h *AuthHandler) SetClock(c clock.Clock) {
+	h.clock = clock.Or(c)
+}
+
+// SetIDGenerator makes new sessions take their IDs from g.
+func (h *AuthHandler) SetIDGenerator(g auth.IDGenerator) {
+	if g == nil {
+		g = auth.RandomIDs{}
+	}
+	h.ids = g
+}
+
+// SetEvents publishes user lifecycle events to bus.
+func (h *AuthHandler) SetEvents(bus *events.Bus) {
+	h.events = bus
+}
+
+// SetFlags makes registration follow the registration feature flag.
+func (h *AuthHandler) SetFlags(f *flags.Flags) {
+	h.flags = f
+}
+
+// SetLogins records each login in logins, so that users are only warned of
+// sign-ins from devices and locations they have not used before.  Without
+// it every sign-in is treated as unfamiliar.
+func (h *AuthHandler) SetLogins(logins db.LoginRepository) {
+	h.logins = logins
+}
+
+// SetGrants lets introspection check that the user has not revoked the
+// access of the application a token was issued to.  Without it tokens
+// issued to applications are never active, as Authenticate refuses them
+// too.
+func (h *AuthHandler) SetGrants(grants db.OAuthRepository) {
+	h.grants = grants
+}
+
+// SetInvites lets registration redeem invite codes while the invite-only
+// flag is on.  Without it, invite-only registration admits nobody.
+func (h *AuthHandler) SetInvites(invites db.InviteRepository) {
+	h.invites = invites
+}
+
+// SetTerms makes registration require, and record, acceptance of the
+// terms-of-service version.  A nil terms disables this.
+func (h *AuthHandler) SetTerms(terms db.TermsRepository, version string) {
+	h.terms, h.termsVer = terms, version
+}
+
+// SetAdmins names the administrator accounts, which may impersonate other
+// users but not be impersonated.
+func (h *AuthHandler) SetAdmins(admins []string) {
+	h.admins = make(map[string]bool, len(admins))
+	for _, name := range admins {
+		h.admins[name] = true
 	}
 }
 
 // Register handles POST /api/v1/auth/register
-// Creates a new user account with hashed password.
+// Creates a new user account with hashed password.  The username is
+// normalised (trimmed, NFC, lower-cased) and reserved or confusable names are
+// rejected.  While the invite-only flag is on, inviteCode must name an
+// unexpired invite with uses left; the use is given back if registration
+// then fails.  When terms of service are configured, acceptTerms must name
+// the current version, and the acceptance is recorded with the account.
 //
 //	@Summary		Register a new user
 //	@Description	Create a new user account with username and password
@@ -38,35 +154,92 @@ func NewAuthHandler(users db.UserRepository, jwtService *auth.JWTService) *AuthH
 //	@Produce		json
 //	@Param			request	body		models.RegisterRequest	true	"User registration details"
 //	@Success		201		{object}	map[string]interface{}	"User created successfully"
-//	@Failure		400		{object}	models.ErrorResponse	"Invalid request"
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid request or terms not accepted"
+//	@Failure		403		{object}	models.ErrorResponse	"Registration is closed or the invite code is invalid"
 //	@Failure		409		{object}	models.ErrorResponse	"Username already exists"
 //	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
 //	@Router			/auth/register [post]
 func (h *AuthHandler) Register(c *gin.Context) {
+	if !h.flags.Enabled(flags.Registration) {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "registration is closed", Code: errcode.RegistrationClosed})
+		return
+	}
+
 	var req models.RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: errcode.Binding(err)})
+		return
+	}
+
+	req.Username = auth.NormalizeUsername(req.Username)
+	if err := auth.ValidateUsername(req.Username); err != nil {
+		code := errcode.UsernameInvalid
+		switch {
+		case errors.Is(err, auth.ErrUsernameTooShort):
+			code = errcode.FieldTooShort
+		case errors.Is(err, auth.ErrUsernameTooLong):
+			code = errcode.FieldTooLong
+		}
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: code})
+		return
+	}
+	if h.terms != nil && req.AcceptTerms != h.termsVer {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "acceptTerms must be the current terms version " + h.termsVer, Code: errcode.FieldInvalid})
 		return
 	}
 
-	// Hash password before calling the repository so the slow bcrypt
+	// Hash password before calling the repository so the slow argon2id
 	// operation does not block any shared resource (lock, connection, etc.).
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := h.passwords.Hash(req.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "failed to hash password"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "failed to hash password", Code: errcode.Internal})
 		return
 	}
 
-	user, err := h.users.CreateUser(req.Username, string(hashedPassword))
+	inviteOnly := h.flags.Enabled(flags.InviteOnly)
+	if inviteOnly {
+		if req.InviteCode == "" {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "registration is by invitation only", Code: errcode.InviteRequired})
+			return
+		}
+		err := models.ErrNotFound
+		if h.invites != nil {
+			err = h.invites.RedeemInvite(req.InviteCode)
+		}
+		if errors.Is(err, models.ErrNotFound) {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "invalid or expired invite code", Code: errcode.InviteInvalid})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+			return
+		}
+	}
+
+	user, err := h.users.CreateUser(req.Username, hashedPassword)
+	if err != nil && inviteOnly {
+		if rerr := h.invites.ReleaseInvite(req.InviteCode); rerr != nil {
+			log.Printf("register: release invite: %v", rerr)
+		}
+	}
 	if errors.Is(err, models.ErrConflict) {
-		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "username already exists"})
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "username already exists", Code: errcode.UsernameTaken})
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
+	if h.terms != nil {
+		// The account exists either way; a user whose acceptance was not
+		// stored is simply asked to accept again.
+		if _, err := h.terms.AcceptTerms(user.Username, h.termsVer); err != nil {
+			log.Printf("register: record terms acceptance: %v", err)
+		}
+	}
+
+	publish(c, h.events, events.UserRegistered, user.Username, user)
 	c.JSON(http.StatusCreated, gin.H{
 		"message":  "user created successfully",
 		"username": user.Username,
@@ -77,7 +250,8 @@ func (h *AuthHandler) Register(c *gin.Context) {
 }
 
 // Login handles POST /api/v1/auth/login
-// Validates credentials and returns a JWT token.
+// Validates credentials, opens a login session and returns a JWT token bound
+// to it.  The session can later be listed and revoked under /me/sessions.
 //
 //	@Summary		User login
 //	@Description	Authenticate user and return JWT token
@@ -93,30 +267,72 @@ func (h *AuthHandler) Register(c *gin.Context) {
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req models.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: errcode.Binding(err)})
 		return
 	}
 
-	user, err := h.users.GetUser(req.Username)
+	// Unknown usernames and wrong passwords get the same response, after the
+	// same hashing work, so the endpoint does not disclose which accounts exist.
+	user, err := h.users.GetUser(auth.NormalizeUsername(req.Username))
 	if errors.Is(err, models.ErrNotFound) {
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "invalid credentials"})
+		h.passwords.Verify(req.Password, h.dummyHash)
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "invalid credentials", Code: errcode.InvalidCredentials})
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
-	// Verify password against the stored bcrypt hash.
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "invalid credentials"})
+	// Verify password against the stored hash (argon2id or legacy bcrypt).
+	ok, needsRehash, err := h.passwords.Verify(req.Password, user.PasswordHash)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "invalid credentials", Code: errcode.InvalidCredentials})
 		return
 	}
 
+	// Upgrade legacy or outdated hashes while the password is at hand.  A
+	// failure here must not block the login; it is retried next time.
+	if needsRehash {
+		if hash, err := h.passwords.Hash(req.Password); err != nil {
+			log.Printf("password rehash failed: %v", err)
+		} else if err := h.users.UpdatePasswordHash(user.Username, hash); err != nil {
+			log.Printf("password rehash failed: %v", err)
+		}
+	}
+
+	sessionID, err := h.ids.NewID()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "failed to generate token", Code: errcode.Internal})
+		return
+	}
+	label := req.DeviceLabel
+	if label == "" {
+		label = c.Request.UserAgent()
+	}
+	label = truncate(label, maxDeviceLabel)
+	session, err := h.sessions.CreateSession(models.Session{
+		ID:          sessionID,
+		Username:    user.Username,
+		DeviceLabel: label,
+		IPAddress:   c.ClientIP(),
+		ExpiresAt:   h.clock.Now().Add(auth.TokenTTL),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	publish(c, h.events, events.SessionCreated, user.Username, session)
+	h.recordLogin(c, session)
+
 	// Generate JWT token
-	token, err := h.jwtService.GenerateToken(user.Username)
+	token, err := h.jwtService.GenerateSessionToken(user.Username, sessionID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "failed to generate token"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "failed to generate token", Code: errcode.Internal})
 		return
 	}
 
@@ -124,6 +340,258 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		Token: token,
 		Links: []models.Link{
 			{Rel: "football", Href: "/api/v1/football/teams", Method: http.MethodGet},
+			{Rel: "sessions", Href: "/api/v1/me/sessions", Method: http.MethodGet},
+		},
+	})
+}
+
+// Reauthenticate handles POST /api/v1/auth/reauthenticate
+// Trades the caller's password for a new token for the same session whose
+// auth_time is now, as destructive operations guarded by RequireFreshAuth
+// require.  Only tokens issued at login can be refreshed this way: an
+// administrator impersonating a user cannot confirm their password.
+//
+//	@Summary		Confirm the password
+//	@Description	Re-enter the password to get a token with a fresh auth_time, required by destructive operations
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.ReauthenticateRequest	true	"Current password"
+//	@Success		200		{object}	models.LoginResponse			"Fresh token"
+//	@Failure		400		{object}	models.ErrorResponse			"Invalid request"
+//	@Failure		401		{object}	models.ErrorResponse			"Unauthorized or wrong password"
+//	@Failure		403		{object}	models.ErrorResponse			"Not signed in with a login token"
+//	@Failure		500		{object}	models.ErrorResponse			"Internal server error"
+//	@Security		Bearer
+//	@Router			/auth/reauthenticate [post]
+func (h *AuthHandler) Reauthenticate(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	if c.GetString("impersonator") != "" {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "impersonation tokens cannot be refreshed", Code: errcode.ImpersonationForbidden})
+		return
+	}
+	if c.GetString("authScheme") != "Bearer" {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "only Bearer tokens can be refreshed", Code: errcode.Forbidden})
+		return
+	}
+
+	var req models.ReauthenticateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: errcode.Binding(err)})
+		return
+	}
+	user, err := h.users.GetUser(c.GetString("username"))
+	if errors.Is(err, models.ErrNotFound) {
+		h.passwords.Verify(req.Password, h.dummyHash)
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "invalid credentials", Code: errcode.InvalidCredentials})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	ok, _, err := h.passwords.Verify(req.Password, user.PasswordHash)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "invalid credentials", Code: errcode.InvalidCredentials})
+		return
+	}
+
+	token, err := h.jwtService.GenerateSessionToken(user.Username, c.GetString("sessionID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "failed to generate token", Code: errcode.Internal})
+		return
+	}
+	c.JSON(http.StatusOK, models.LoginResponse{
+		Token: token,
+		Links: []models.Link{
+			{Rel: "sessions", Href: "/api/v1/me/sessions", Method: http.MethodGet},
+		},
+	})
+}
+
+// recordLogin adds session's sign-in to the user's login activity and, when
+// it came from a device or network new to them, publishes
+// events.UnfamiliarLogin so that they are warned.  A failure is logged
+// rather than failing the login.
+func (h *AuthHandler) recordLogin(c *gin.Context, session models.Session) {
+	ua := truncate(c.Request.UserAgent(), maxUserAgent)
+	login := models.Login{
+		Username:    session.Username,
+		SessionID:   session.ID,
+		At:          h.clock.Now(),
+		IPAddress:   session.IPAddress,
+		UserAgent:   ua,
+		Device:      device.Describe(ua),
+		Fingerprint: device.Fingerprint(ua),
+		Network:     device.Network(session.IPAddress),
+		NewDevice:   true,
+		NewLocation: true,
+	}
+	if h.logins != nil {
+		recorded, err := h.logins.RecordLogin(login, login.At.Add(-db.LoginRetention))
+		if err != nil {
+			log.Printf("login activity: %v", err)
+			return
+		}
+		login = recorded
+	}
+	if login.NewDevice || login.NewLocation {
+		publish(c, h.events, events.UnfamiliarLogin, login.Username, login)
+	}
+}
+
+// Introspect handles POST /api/v1/auth/introspect
+// Reports whether an access token is active and, if so, its claims, in the
+// style of RFC 7662.  Resource servers and gateways use it to validate tokens
+// without sharing the signing secret.  The token is sent as the
+// form-encoded "token" parameter.  A token is active only where
+// Authenticate would accept it: a token whose login session has been
+// revoked is not, nor one issued to an application whose access the user
+// has revoked.
+//
+//	@Summary		Introspect an access token
+//	@Description	RFC 7662 token introspection for service accounts (signed requests or client certificates)
+//	@Tags			auth
+//	@Accept			x-www-form-urlencoded
+//	@Produce		json
+//	@Param			token	formData	string						true	"Access token to introspect"
+//	@Success		200		{object}	models.IntrospectionResponse
+//	@Failure		400		{object}	models.ErrorResponse	"Missing token parameter"
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	models.ErrorResponse	"Caller is not a service account"
+//	@Failure		429		{object}	models.QuotaExceededResponse	"Rate limit exceeded"
+//	@Router			/auth/introspect [post]
+func (h *AuthHandler) Introspect(c *gin.Context) {
+	// Introspection results must not be cached by intermediaries.
+	c.Header("Cache-Control", "no-store")
+
+	token := c.PostForm("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "token parameter is required", Code: errcode.InvalidParameter})
+		return
+	}
+
+	claims, err := h.jwtService.ValidateToken(token)
+	if err != nil {
+		c.JSON(http.StatusOK, models.IntrospectionResponse{Active: false})
+		return
+	}
+	// A token whose session has been revoked is refused by Authenticate,
+	// so it is not active either.  Asking is not a use of the session.
+	if claims.SessionID != "" && h.sessions != nil {
+		err := h.sessions.CheckSession(claims.SessionID)
+		if errors.Is(err, models.ErrNotFound) {
+			c.JSON(http.StatusOK, models.IntrospectionResponse{Active: false})
+			return
+		}
+		if err != nil {
+			_ = c.Error(err)
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+			return
+		}
+	}
+	if claims.GrantID != "" {
+		if h.grants == nil {
+			c.JSON(http.StatusOK, models.IntrospectionResponse{Active: false})
+			return
+		}
+		err := h.grants.TouchGrant(claims.GrantID)
+		if errors.Is(err, models.ErrNotFound) {
+			c.JSON(http.StatusOK, models.IntrospectionResponse{Active: false})
+			return
+		}
+		if err != nil {
+			_ = c.Error(err)
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+			return
+		}
+	}
+
+	resp := models.IntrospectionResponse{
+		Active:    true,
+		Username:  claims.Username,
+		Subject:   claims.Username,
+		Issuer:    claims.Issuer,
+		TokenType: "Bearer",
+		TokenID:   claims.ID,
+		ClientID:  claims.ClientID,
+		Scope:     claims.Scope,
+	}
+	if claims.IssuedAt != nil {
+		resp.IssuedAt = claims.IssuedAt.Unix()
+	}
+	if claims.ExpiresAt != nil {
+		resp.ExpiresAt = claims.ExpiresAt.Unix()
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Impersonate handles POST /api/v1/admin/impersonate
+// Issues the calling administrator a token, valid for 15 minutes, with which
+// to act as another user while investigating a support request.  The token
+// carries both names: requests made with it are logged, and changes audited,
+// with the administrator as impersonator.  Administrators cannot be
+// impersonated, and an impersonation token cannot be used to obtain another.
+//
+//	@Summary		Impersonate a user
+//	@Description	Mint a short-lived token to act as a user; changes made with it are audited under both identities
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.ImpersonateRequest		true	"User to impersonate"
+//	@Success		201		{object}	models.ImpersonationResponse	"Impersonation token"
+//	@Failure		400		{object}	models.ErrorResponse			"Invalid request"
+//	@Failure		401		{object}	models.ErrorResponse			"Unauthorized"
+//	@Failure		403		{object}	models.ErrorResponse			"Admin privileges required, or the user may not be impersonated"
+//	@Failure		404		{object}	models.ErrorResponse			"User not found"
+//	@Failure		500		{object}	models.ErrorResponse			"Internal server error"
+//	@Security		Bearer
+//	@Router			/admin/impersonate [post]
+func (h *AuthHandler) Impersonate(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	admin := c.GetString("username")
+	if c.GetString("impersonator") != "" {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "impersonation tokens cannot be used to impersonate", Code: errcode.ImpersonationForbidden})
+		return
+	}
+
+	var req models.ImpersonateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: errcode.Binding(err)})
+		return
+	}
+	user, err := h.users.GetUser(auth.NormalizeUsername(req.Username))
+	if errors.Is(err, models.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "user not found", Code: errcode.UserNotFound})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	if user.Username == admin || h.admins[user.Username] {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "administrators cannot be impersonated", Code: errcode.ImpersonationForbidden})
+		return
+	}
+
+	token, expires, err := h.jwtService.GenerateImpersonationToken(user.Username, admin)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "failed to generate token", Code: errcode.Internal})
+		return
+	}
+	publish(c, h.events, events.UserImpersonated, user.Username, nil)
+
+	c.JSON(http.StatusCreated, models.ImpersonationResponse{
+		Token:        token,
+		Username:     user.Username,
+		Impersonator: admin,
+		ExpiresAt:    expires,
+		Links: []models.Link{
+			{Rel: "audit", Href: "/api/v1/audit/export?impersonator=" + url.QueryEscape(admin), Method: http.MethodGet},
 		},
 	})
 }
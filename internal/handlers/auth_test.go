@@ -0,0 +1,444 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/memory"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/flags"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/handlers"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/pkg/repository/fake"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testHasher keeps argon2id cheap in tests.
+var testHasher = auth.NewPasswordHasher(auth.Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1})
+
+func introspect(t *testing.T, jwt *auth.JWTService, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	h := handlers.NewAuthHandler(newUserMock(), newSessionMock(), jwt, testHasher)
+	r := gin.New()
+	r.POST("/api/v1/auth/introspect", h.Introspect)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/introspect",
+		strings.NewReader(url.Values{"token": {token}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRegister_NormalisesUsername(t *testing.T) {
+	users := newUserMock()
+	r := gin.New()
+	r.POST("/api/v1/auth/register", handlers.NewAuthHandler(users, newSessionMock(),
+		auth.NewJWTService("test-secret", "COMP3011_API"), testHasher).Register)
+
+	w := doRequest(r, http.MethodPost, "/api/v1/auth/register",
+		models.RegisterRequest{Username: "  Alice ", Password: "password123"})
+	assertStatus(t, w, http.StatusCreated)
+	if _, ok := users.users["alice"]; !ok {
+		t.Fatal("expected account stored under normalised username")
+	}
+
+	w = doRequest(r, http.MethodPost, "/api/v1/auth/register",
+		models.RegisterRequest{Username: "ALICE", Password: "password123"})
+	assertStatus(t, w, http.StatusConflict)
+}
+
+func TestRegister_RejectsReservedAndConfusableNames(t *testing.T) {
+	r := gin.New()
+	r.POST("/api/v1/auth/register", handlers.NewAuthHandler(newUserMock(), newSessionMock(),
+		auth.NewJWTService("test-secret", "COMP3011_API"), testHasher).Register)
+
+	for _, name := range []string{"Admin", "api", "p\u0430ypal"} {
+		w := doRequest(r, http.MethodPost, "/api/v1/auth/register",
+			models.RegisterRequest{Username: name, Password: "password123"})
+		assertStatus(t, w, http.StatusBadRequest)
+	}
+}
+
+func TestRegister_ChecksNormalisedLength(t *testing.T) {
+	users := newUserMock()
+	r := gin.New()
+	r.POST("/api/v1/auth/register", handlers.NewAuthHandler(users, newSessionMock(),
+		auth.NewJWTService("test-secret", "COMP3011_API"), testHasher).Register)
+
+	// Padding does not make a name long enough.
+	w := doRequest(r, http.MethodPost, "/api/v1/auth/register",
+		models.RegisterRequest{Username: "  ab  ", Password: "password123"})
+	assertStatus(t, w, http.StatusBadRequest)
+	assertCode(t, w, errcode.FieldTooShort)
+
+	// Fifty decomposed accented letters compose to fifty characters.
+	name := strings.Repeat("e\u0301", 50)
+	w = doRequest(r, http.MethodPost, "/api/v1/auth/register",
+		models.RegisterRequest{Username: name, Password: "password123"})
+	assertStatus(t, w, http.StatusCreated)
+	if _, ok := users.users[strings.Repeat("\u00e9", 50)]; !ok {
+		t.Fatalf("expected the normalised name to be stored, got %v", users.users)
+	}
+}
+
+func TestRegister_ClosedByFlag(t *testing.T) {
+	f, err := flags.New(map[string]bool{flags.Registration: false}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := newUserMock()
+	h := handlers.NewAuthHandler(users, newSessionMock(), auth.NewJWTService("test-secret", "COMP3011_API"), testHasher)
+	h.SetFlags(f)
+	r := gin.New()
+	r.POST("/api/v1/auth/register", h.Register)
+
+	w := doRequest(r, http.MethodPost, "/api/v1/auth/register",
+		models.RegisterRequest{Username: "alice", Password: "password123"})
+	assertStatus(t, w, http.StatusForbidden)
+	if len(users.users) != 0 {
+		t.Fatal("expected no account to be created")
+	}
+}
+
+func TestLogin_CreatesSession(t *testing.T) {
+	users, sessions := newUserMock(), newSessionMock()
+	hash, _ := testHasher.Hash("password123")
+	users.CreateUser("alice", hash)
+
+	jwt := auth.NewJWTService("test-secret", "COMP3011_API")
+	r := gin.New()
+	r.POST("/api/v1/auth/login", handlers.NewAuthHandler(users, sessions, jwt, testHasher).Login)
+
+	w := doRequest(r, http.MethodPost, "/api/v1/auth/login",
+		models.LoginRequest{Username: "alice", Password: "password123", DeviceLabel: "laptop"})
+	assertStatus(t, w, http.StatusOK)
+
+	var resp models.LoginResponse
+	decodeJSON(t, w, &resp)
+	claims, err := jwt.ValidateToken(resp.Token)
+	if err != nil {
+		t.Fatalf("invalid token: %v", err)
+	}
+	s, ok := sessions.sessions[claims.SessionID]
+	if !ok {
+		t.Fatalf("token sid %q does not match a stored session", claims.SessionID)
+	}
+	if s.Username != "alice" || s.DeviceLabel != "laptop" {
+		t.Errorf("unexpected session: %+v", s)
+	}
+}
+
+func TestLogin_TruncatesDeviceLabel(t *testing.T) {
+	users, sessions := newUserMock(), newSessionMock()
+	hash, _ := testHasher.Hash("password123")
+	users.CreateUser("alice", hash)
+	r := gin.New()
+	r.POST("/api/v1/auth/login", handlers.NewAuthHandler(users, sessions,
+		auth.NewJWTService("test-secret", "COMP3011_API"), testHasher).Login)
+
+	// Without a label the User-Agent names the device.
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login",
+		strings.NewReader(`{"username":"alice","password":"password123"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", strings.Repeat("é", 150))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assertStatus(t, w, http.StatusOK)
+	for _, s := range sessions.sessions {
+		if s.DeviceLabel != strings.Repeat("é", 100) {
+			t.Errorf("unexpected label %q", s.DeviceLabel)
+		}
+	}
+}
+
+func TestLogin_RecordsTruncatedUserAgent(t *testing.T) {
+	users := newUserMock()
+	hash, _ := testHasher.Hash("password123")
+	users.CreateUser("alice", hash)
+	logins := memory.New().Repositories().Logins
+	h := handlers.NewAuthHandler(users, newSessionMock(), auth.NewJWTService("test-secret", "COMP3011_API"), testHasher)
+	h.SetLogins(logins)
+	r := gin.New()
+	r.POST("/api/v1/auth/login", h.Login)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login",
+		strings.NewReader(`{"username":"alice","password":"password123","deviceLabel":"laptop"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", strings.Repeat("é", 600))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assertStatus(t, w, http.StatusOK)
+	list, err := logins.ListLogins("alice", 10)
+	if err != nil || len(list) != 1 || list[0].UserAgent != strings.Repeat("é", 500) {
+		t.Fatalf("unexpected logins %+v, %v", list, err)
+	}
+}
+
+func TestLogin_UpgradesLegacyBcryptHash(t *testing.T) {
+	users := newUserMock()
+	legacy, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	users.CreateUser("alice", string(legacy))
+
+	r := gin.New()
+	r.POST("/api/v1/auth/login", handlers.NewAuthHandler(users, newSessionMock(),
+		auth.NewJWTService("test-secret", "COMP3011_API"), testHasher).Login)
+
+	w := doRequest(r, http.MethodPost, "/api/v1/auth/login",
+		models.LoginRequest{Username: "alice", Password: "password123"})
+	assertStatus(t, w, http.StatusOK)
+
+	if hash := users.users["alice"].PasswordHash; !strings.HasPrefix(hash, "$argon2id$") {
+		t.Fatalf("expected hash to be upgraded to argon2id, got %q", hash)
+	}
+
+	// The upgraded hash still authenticates.
+	w = doRequest(r, http.MethodPost, "/api/v1/auth/login",
+		models.LoginRequest{Username: "alice", Password: "password123"})
+	assertStatus(t, w, http.StatusOK)
+}
+
+func TestLogin_WrongPassword(t *testing.T) {
+	users := newUserMock()
+	hash, _ := testHasher.Hash("password123")
+	users.CreateUser("alice", hash)
+
+	r := gin.New()
+	r.POST("/api/v1/auth/login", handlers.NewAuthHandler(users, newSessionMock(),
+		auth.NewJWTService("test-secret", "COMP3011_API"), testHasher).Login)
+
+	w := doRequest(r, http.MethodPost, "/api/v1/auth/login",
+		models.LoginRequest{Username: "alice", Password: "not-the-password"})
+	assertStatus(t, w, http.StatusUnauthorized)
+}
+
+func TestLogin_UnknownUserMatchesWrongPassword(t *testing.T) {
+	users := newUserMock()
+	hash, _ := testHasher.Hash("password123")
+	users.CreateUser("alice", hash)
+
+	r := gin.New()
+	r.POST("/api/v1/auth/login", handlers.NewAuthHandler(users, newSessionMock(),
+		auth.NewJWTService("test-secret", "COMP3011_API"), testHasher).Login)
+
+	wrong := doRequest(r, http.MethodPost, "/api/v1/auth/login",
+		models.LoginRequest{Username: "alice", Password: "not-the-password"})
+	unknown := doRequest(r, http.MethodPost, "/api/v1/auth/login",
+		models.LoginRequest{Username: "nobody", Password: "not-the-password"})
+	if wrong.Code != unknown.Code || wrong.Body.String() != unknown.Body.String() {
+		t.Errorf("responses differ: %d %s vs %d %s", wrong.Code, wrong.Body, unknown.Code, unknown.Body)
+	}
+}
+
+func TestIntrospect_ActiveToken(t *testing.T) {
+	jwt := auth.NewJWTService("test-secret", "COMP3011_API")
+	token, err := jwt.GenerateToken("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := introspect(t, jwt, token)
+	assertStatus(t, w, http.StatusOK)
+
+	var resp models.IntrospectionResponse
+	decodeJSON(t, w, &resp)
+	if !resp.Active || resp.Subject != "alice" || resp.Issuer != "COMP3011_API" || resp.ExpiresAt == 0 || resp.TokenID == "" {
+		t.Errorf("unexpected introspection result: %+v", resp)
+	}
+}
+
+func TestIntrospect_InactiveToken(t *testing.T) {
+	token, _ := auth.NewJWTService("other-secret", "COMP3011_API").GenerateToken("alice")
+
+	w := introspect(t, auth.NewJWTService("test-secret", "COMP3011_API"), token)
+	assertStatus(t, w, http.StatusOK)
+	if body := strings.TrimSpace(w.Body.String()); body != `{"active":false}` {
+		t.Errorf("expected only active=false, got %s", body)
+	}
+}
+
+func TestIntrospect_RevokedSession(t *testing.T) {
+	jwt := auth.NewJWTService("test-secret", "COMP3011_API")
+	sessions := newSessionMock()
+	sessions.sessions["live"] = models.Session{ID: "live", Username: "alice"}
+	h := handlers.NewAuthHandler(newUserMock(), sessions, jwt, testHasher)
+	r := gin.New()
+	r.POST("/api/v1/auth/introspect", h.Introspect)
+
+	for sessionID, want := range map[string]bool{"live": true, "revoked": false} {
+		token, _ := jwt.GenerateSessionToken("alice", sessionID)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/introspect",
+			strings.NewReader(url.Values{"token": {token}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assertStatus(t, w, http.StatusOK)
+		var resp models.IntrospectionResponse
+		decodeJSON(t, w, &resp)
+		if resp.Active != want {
+			t.Errorf("session %q: expected active=%v, got %+v", sessionID, want, resp)
+		}
+	}
+}
+
+func TestIntrospect_DoesNotTouchSession(t *testing.T) {
+	jwt := auth.NewJWTService("test-secret", "COMP3011_API")
+	sessions := &fake.Sessions{}
+	h := handlers.NewAuthHandler(newUserMock(), sessions, jwt, testHasher)
+	r := gin.New()
+	r.POST("/api/v1/auth/introspect", h.Introspect)
+
+	token, _ := jwt.GenerateSessionToken("alice", "live")
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/introspect",
+		strings.NewReader(url.Values{"token": {token}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assertStatus(t, w, http.StatusOK)
+	// A resource server asking about a token is not the user using it.
+	if len(sessions.CallsTo("CheckSession")) != 1 || len(sessions.CallsTo("TouchSession")) != 0 {
+		t.Fatalf("unexpected calls %v", sessions.Calls())
+	}
+}
+
+func TestIntrospect_MissingToken(t *testing.T) {
+	w := introspect(t, auth.NewJWTService("test-secret", "COMP3011_API"), "")
+	assertStatus(t, w, http.StatusBadRequest)
+}
+
+func TestImpersonate(t *testing.T) {
+	users := newUserMock()
+	users.addUser("alice")
+	users.addUser("root")
+	users.addUser("ops")
+	jwt := auth.NewJWTService("test-secret", "COMP3011_API")
+	h := handlers.NewAuthHandler(users, newSessionMock(), jwt, testHasher)
+	h.SetAdmins([]string{"root", "ops"})
+	bus := events.NewBus(nil)
+	var got []events.Event
+	bus.Subscribe(events.BeforeResponse, events.SubscriberFunc(func(_ context.Context, e events.Event) error {
+		got = append(got, e)
+		return nil
+	}))
+	h.SetEvents(bus)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("username", "root")
+		if v := c.GetHeader("X-Impersonator"); v != "" {
+			c.Set("impersonator", v)
+		}
+	})
+	r.POST("/api/v1/admin/impersonate", h.Impersonate)
+
+	w := doRequest(r, http.MethodPost, "/api/v1/admin/impersonate", models.ImpersonateRequest{Username: "Alice"})
+	assertStatus(t, w, http.StatusCreated)
+	if cc := w.Header().Get("Cache-Control"); cc != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", cc)
+	}
+	var resp models.ImpersonationResponse
+	decodeJSON(t, w, &resp)
+	if resp.Username != "alice" || resp.Impersonator != "root" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+	claims, err := jwt.ValidateToken(resp.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Username != "alice" || claims.Act == nil || claims.Act.Subject != "root" || claims.SessionID != "" {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+	if len(got) != 1 || got[0].Type != events.UserImpersonated || got[0].ID != "alice" || got[0].Actor != "root" {
+		t.Errorf("unexpected events %+v", got)
+	}
+
+	for _, tc := range []struct {
+		name, target, impersonator string
+		status                     int
+		code                       string
+	}{
+		{"unknown user", "nobody", "", http.StatusNotFound, errcode.UserNotFound},
+		{"another admin", "ops", "", http.StatusForbidden, errcode.ImpersonationForbidden},
+		{"self", "root", "", http.StatusForbidden, errcode.ImpersonationForbidden},
+		{"already impersonating", "alice", "ops", http.StatusForbidden, errcode.ImpersonationForbidden},
+		{"missing username", "", "", http.StatusBadRequest, errcode.FieldRequired},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := doRequestWithHeader(r, http.MethodPost, "/api/v1/admin/impersonate",
+				models.ImpersonateRequest{Username: tc.target}, "X-Impersonator", tc.impersonator)
+			assertStatus(t, w, tc.status)
+			assertCode(t, w, tc.code)
+		})
+	}
+	if len(got) != 1 {
+		t.Errorf("refused requests published %d events", len(got)-1)
+	}
+}
+
+func TestReauthenticate(t *testing.T) {
+	users := newUserMock()
+	hash, _ := testHasher.Hash("password123")
+	users.CreateUser("alice", hash)
+	clk := clock.NewFake(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC))
+	jwt := auth.NewJWTService("test-secret", "COMP3011_API")
+	jwt.SetClock(clk)
+	h := handlers.NewAuthHandler(users, newSessionMock(), jwt, testHasher)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("username", "alice")
+		c.Set("sessionID", "s1")
+		c.Set("authScheme", "Bearer")
+		if v := c.GetHeader("X-Scheme"); v != "" {
+			c.Set("authScheme", v)
+		}
+		if v := c.GetHeader("X-Impersonator"); v != "" {
+			c.Set("impersonator", v)
+		}
+	})
+	r.POST("/api/v1/auth/reauthenticate", h.Reauthenticate)
+
+	clk.Advance(time.Hour)
+	w := doRequest(r, http.MethodPost, "/api/v1/auth/reauthenticate", models.ReauthenticateRequest{Password: "password123"})
+	assertStatus(t, w, http.StatusOK)
+	var resp models.LoginResponse
+	decodeJSON(t, w, &resp)
+	claims, err := jwt.ValidateToken(resp.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Username != "alice" || claims.SessionID != "s1" || claims.AuthTime == nil || !claims.AuthTime.Equal(clk.Now()) {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+
+	for _, tc := range []struct {
+		name, password, header, value string
+		status                        int
+		code                          string
+	}{
+		{"wrong password", "not-the-password", "", "", http.StatusUnauthorized, errcode.InvalidCredentials},
+		{"missing password", "", "", "", http.StatusBadRequest, errcode.FieldRequired},
+		{"impersonating", "password123", "X-Impersonator", "root", http.StatusForbidden, errcode.ImpersonationForbidden},
+		{"signed request", "password123", "X-Scheme", "HMAC", http.StatusForbidden, errcode.Forbidden},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := doRequestWithHeader(r, http.MethodPost, "/api/v1/auth/reauthenticate",
+				models.ReauthenticateRequest{Password: tc.password}, tc.header, tc.value)
+			assertStatus(t, w, tc.status)
+			var resp models.ErrorResponse
+			decodeJSON(t, w, &resp)
+			if resp.Code != tc.code {
+				t.Errorf("code = %q, want %q", resp.Code, tc.code)
+			}
+		})
+	}
+}
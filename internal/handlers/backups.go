@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/backup"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// Backups takes, lists and restores logical backups; *backup.Service
+// implements it.
+type Backups interface {
+	Create(ctx context.Context) (models.Backup, error)
+	List(ctx context.Context) ([]models.Backup, error)
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+	Restore(ctx context.Context, name string, dryRun bool) ([]models.TableCount, error)
+}
+
+func backupLinks(name string) []models.Link {
+	self := "/api/v1/admin/backups/" + name
+	return []models.Link{
+		{Rel: "self", Href: self, Method: "GET"},
+		{Rel: "restore", Href: self + "/restore", Method: "POST"},
+	}
+}
+
+// ListBackups handles GET /api/v1/admin/backups
+//
+//	@Summary		List backups
+//	@Description	Lists the stored backup archives, newest first
+//	@Tags			admin
+//	@Produce		json
+//	@Success		200	{object}	models.BackupsResponse
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	models.ErrorResponse	"Admin privileges required"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/admin/backups [get]
+func (h *AdminHandler) ListBackups(c *gin.Context) {
+	list, err := h.backups.List(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	for i := range list {
+		list[i].Links = backupLinks(list[i].Name)
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, models.BackupsResponse{
+		Data: list,
+		Links: []models.Link{
+			{Rel: "self", Href: "/api/v1/admin/backups", Method: "GET"},
+			{Rel: "create", Href: "/api/v1/admin/backups", Method: "POST"},
+		},
+	})
+}
+
+// CreateBackup handles POST /api/v1/admin/backups
+// Takes a consistent snapshot of the users and football data and stores it
+// as a gzipped JSON archive.
+//
+//	@Summary		Create a backup
+//	@Description	Dumps users and football data to a portable gzipped JSON archive in the backup storage
+//	@Tags			admin
+//	@Produce		json
+//	@Success		201	{object}	models.Backup
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	models.ErrorResponse	"Admin privileges required"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/admin/backups [post]
+func (h *AdminHandler) CreateBackup(c *gin.Context) {
+	b, err := h.backups.Create(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	b.Links = backupLinks(b.Name)
+	c.Header("Location", "/api/v1/admin/backups/"+b.Name)
+	c.JSON(http.StatusCreated, b)
+}
+
+// DownloadBackup handles GET /api/v1/admin/backups/:name
+//
+//	@Summary		Download a backup
+//	@Description	Returns the archive as gzipped JSON, for safekeeping or for the backup command's restore
+//	@Tags			admin
+//	@Produce		application/gzip
+//	@Param			name	path		string	true	"Backup name"
+//	@Success		200		{file}		binary
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	models.ErrorResponse	"Admin privileges required"
+//	@Failure		404		{object}	models.ErrorResponse	"Backup not found"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/admin/backups/{name} [get]
+func (h *AdminHandler) DownloadBackup(c *gin.Context) {
+	name := c.Param("name")
+	r, err := h.backups.Open(c.Request.Context(), name)
+	if errors.Is(err, models.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "backup not found", Code: errcode.BackupNotFound})
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	defer r.Close()
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Disposition", `attachment; filename="`+name+`"`)
+	c.DataFromReader(http.StatusOK, -1, "application/gzip", r, nil)
+}
+
+// RestoreBackup handles POST /api/v1/admin/backups/:name/restore
+// Replaces the users and football data with the archive's.  It is a dry
+// run unless dryRun=false: the restore is performed and rolled back, so an
+// archive the schema refuses is reported without changing anything.
+//
+//	@Summary		Restore a backup
+//	@Description	Validates the archive against the database (default), or with dryRun=false replaces the users and football data with it; sessions are ended
+//	@Tags			admin
+//	@Produce		json
+//	@Param			name	path		string	true	"Backup name"
+//	@Param			dryRun	query		bool	false	"Roll back once validated (default true)"
+//	@Success		200		{object}	models.RestoreResponse
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid dryRun"
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	models.ErrorResponse	"Admin privileges required"
+//	@Failure		404		{object}	models.ErrorResponse	"Backup not found"
+//	@Failure		422		{object}	models.ErrorResponse	"Archive does not fit the schema"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/admin/backups/{name}/restore [post]
+func (h *AdminHandler) RestoreBackup(c *gin.Context) {
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dryRun", "true"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "dryRun must be true or false", Code: errcode.InvalidParameter})
+		return
+	}
+	name := c.Param("name")
+	counts, err := h.backups.Restore(c.Request.Context(), name, dryRun)
+	switch {
+	case errors.Is(err, models.ErrNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "backup not found", Code: errcode.BackupNotFound})
+		return
+	case errors.Is(err, backup.ErrInvalid):
+		c.JSON(http.StatusUnprocessableEntity, models.ErrorResponse{Error: err.Error(), Code: errcode.BackupInvalid})
+		return
+	case err != nil:
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	c.JSON(http.StatusOK, models.RestoreResponse{Backup: name, DryRun: dryRun, Tables: counts, Links: backupLinks(name)})
+}
@@ -0,0 +1,87 @@
+package handlers_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/backup"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/handlers"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/logging"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+type fakeBackups struct {
+	restored []bool // dryRun of each restore
+}
+
+func (f *fakeBackups) Create(context.Context) (models.Backup, error) {
+	return models.Backup{Name: "backup-20240101T120000Z.json.gz", Size: 10}, nil
+}
+
+func (f *fakeBackups) List(context.Context) ([]models.Backup, error) {
+	return []models.Backup{{Name: "backup-20240101T120000Z.json.gz", Size: 10}}, nil
+}
+
+func (f *fakeBackups) Open(_ context.Context, name string) (io.ReadCloser, error) {
+	if name != "backup-20240101T120000Z.json.gz" {
+		return nil, models.ErrNotFound
+	}
+	return io.NopCloser(strings.NewReader("archive")), nil
+}
+
+func (f *fakeBackups) Restore(_ context.Context, name string, dryRun bool) ([]models.TableCount, error) {
+	if name == "backup-20230101T120000Z.json.gz" {
+		return nil, fmt.Errorf("%w: football_matches: foreign key violation", backup.ErrInvalid)
+	}
+	if _, err := f.Open(context.Background(), name); err != nil {
+		return nil, err
+	}
+	f.restored = append(f.restored, dryRun)
+	return []models.TableCount{{Table: "users", Rows: 2}}, nil
+}
+
+func TestBackups(t *testing.T) {
+	fake := &fakeBackups{}
+	h := handlers.NewAdminHandler(logging.NewLevel(slog.LevelInfo))
+	h.SetBackups(fake)
+	r := newAdminRouter(logging.NewLevel(slog.LevelInfo))
+	r.GET("/api/v1/admin/backups", h.ListBackups)
+	r.POST("/api/v1/admin/backups", h.CreateBackup)
+	r.GET("/api/v1/admin/backups/:name", h.DownloadBackup)
+	r.POST("/api/v1/admin/backups/:name/restore", h.RestoreBackup)
+	const base = "/api/v1/admin/backups/backup-20240101T120000Z.json.gz"
+
+	w := doRequest(r, http.MethodPost, "/api/v1/admin/backups", nil)
+	assertStatus(t, w, http.StatusCreated)
+	if loc := w.Header().Get("Location"); loc != base {
+		t.Errorf("Location = %q, want %q", loc, base)
+	}
+
+	w = doRequest(r, http.MethodGet, base, nil)
+	assertStatus(t, w, http.StatusOK)
+	if w.Header().Get("Content-Type") != "application/gzip" || w.Body.String() != "archive" {
+		t.Errorf("unexpected body %q", w.Body.String())
+	}
+	assertStatus(t, doRequest(r, http.MethodGet, "/api/v1/admin/backups/nope", nil), http.StatusNotFound)
+
+	// Restores are dry runs unless asked otherwise.
+	w = doRequest(r, http.MethodPost, base+"/restore", nil)
+	assertStatus(t, w, http.StatusOK)
+	var resp models.RestoreResponse
+	decodeJSON(t, w, &resp)
+	if !resp.DryRun || len(resp.Tables) != 1 {
+		t.Errorf("unexpected response %+v", resp)
+	}
+	assertStatus(t, doRequest(r, http.MethodPost, base+"/restore?dryRun=false", nil), http.StatusOK)
+	if len(fake.restored) != 2 || !fake.restored[0] || fake.restored[1] {
+		t.Errorf("expected a dry run then a restore, got %v", fake.restored)
+	}
+
+	assertStatus(t, doRequest(r, http.MethodPost, base+"/restore?dryRun=maybe", nil), http.StatusBadRequest)
+	assertStatus(t, doRequest(r, http.MethodPost, "/api/v1/admin/backups/backup-20230101T120000Z.json.gz/restore", nil), http.StatusUnprocessableEntity)
+}
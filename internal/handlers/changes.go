@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// fieldChanges compares two values of the same struct type and lists the
+// exported fields whose values differ, named by their JSON tags and in
+// declaration order.  Fields tagged json:"-" are skipped.
+func fieldChanges(before, after interface{}) []models.FieldChange {
+	bv, av := reflect.ValueOf(before), reflect.ValueOf(after)
+	if bv.Type() != av.Type() || bv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var changes []models.FieldChange
+	t := bv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		old, cur := bv.Field(i).Interface(), av.Field(i).Interface()
+		if !reflect.DeepEqual(old, cur) {
+			changes = append(changes, models.FieldChange{Field: name, Old: old, New: cur})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// ListErrorCodes handles GET /api/v1/errors
+// Returns every code an error response may carry in its "code" member,
+// with the status it comes with and what it means.
+//
+//	@Summary		List error codes
+//	@Description	The stable, machine-readable codes of error responses, with their HTTP status (0 for any) and meaning.  Codes are never reused; new ones may be added.
+//	@Tags			meta
+//	@Produce		json
+//	@Success		200	{object}	models.ErrorCodesResponse
+//	@Router			/errors [get]
+func ListErrorCodes(c *gin.Context) {
+	c.JSON(http.StatusOK, models.ErrorCodesResponse{
+		Data:  errcode.Catalogue(),
+		Links: []models.Link{{Rel: "self", Href: "/api/v1/errors", Method: http.MethodGet}},
+	})
+}
@@ -0,0 +1,37 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/handlers"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+func TestListErrorCodes(t *testing.T) {
+	r := gin.New()
+	r.GET("/api/v1/errors", handlers.ListErrorCodes)
+
+	w := doRequest(r, http.MethodGet, "/api/v1/errors", nil)
+	assertStatus(t, w, http.StatusOK)
+
+	var resp models.ErrorCodesResponse
+	decodeJSON(t, w, &resp)
+	if len(resp.Data) != len(errcode.Catalogue()) {
+		t.Fatalf("expected %d codes, got %d", len(errcode.Catalogue()), len(resp.Data))
+	}
+	found := false
+	for _, ec := range resp.Data {
+		if ec.Code == errcode.TeamNotFound {
+			found = ec.Status == http.StatusNotFound && ec.Description != ""
+		}
+	}
+	if !found {
+		t.Errorf("expected %s with status 404 and a description, got %+v", errcode.TeamNotFound, resp.Data)
+	}
+	if len(resp.Links) == 0 {
+		t.Error("expected HATEOAS links")
+	}
+}
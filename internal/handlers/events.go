@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
+)
+
+// publish reports a committed change to bus on behalf of the caller
+// authenticated on c.  It is a no-op when bus is nil.
+func publish(c *gin.Context, bus *events.Bus, t events.Type, id string, data interface{}) {
+	bus.Publish(c.Request.Context(), events.Event{
+		Type:         t,
+		ID:           id,
+		Actor:        c.GetString("username"),
+		Impersonator: c.GetString("impersonator"),
+		Data:         data,
+	})
+}
+
+// publishUpdate reports an update from before to after, which are values of
+// the same struct type, with the fields it changed.  A nil before publishes
+// no changes.
+func publishUpdate(c *gin.Context, bus *events.Bus, t events.Type, id string, before, after interface{}) {
+	update := events.Update{Resource: after}
+	if before != nil {
+		update.Changes = fieldChanges(before, after)
+	}
+	publish(c, bus, t, id, update)
+}
@@ -11,7 +11,10 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/elo"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/lock"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/retry"
 )
 
 const eloDateLayout = "2006-01-02"
@@ -48,17 +51,17 @@ func eloLinks(teamID int, dateStr string) []models.Link {
 func (h *FootballHandler) GetTeamElo(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid team id"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid team id", Code: errcode.InvalidID})
 		return
 	}
 
 	team, err := h.repo.GetTeamByID(id)
 	if errors.Is(err, models.ErrNotFound) {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "team not found"})
+		h.teamNotFound(c, id)
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
@@ -67,7 +70,7 @@ func (h *FootballHandler) GetTeamElo(c *gin.Context) {
 	if dateStr != "" {
 		parsed, parseErr := time.Parse(eloDateLayout, dateStr)
 		if parseErr != nil {
-			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid date format; expected YYYY-MM-DD"})
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid date format; expected YYYY-MM-DD", Code: errcode.InvalidParameter})
 			return
 		}
 		asOf = parsed
@@ -77,29 +80,33 @@ func (h *FootballHandler) GetTeamElo(c *gin.Context) {
 
 	cfg := elo.DefaultConfig()
 
-	// Try to use cached Elo data first.
-	cachedElo, cachedRank, cachedMatches, cacheErr := h.repo.GetTeamCachedElo(id, asOf)
-	if cacheErr == nil {
-		// Cache hit: use cached data.
-		c.Header("X-Cache-Status", "hit")
-		c.Header("X-Elo-Computed-At", time.Now().UTC().Format(time.RFC3339))
-		c.JSON(http.StatusOK, elo.Rating{
-			TeamID:            team.ID,
-			TeamName:          team.Name,
-			Date:              asOf,
-			Elo:               roundElo(cachedElo),
-			Rank:              cachedRank,
-			ChangeFromPrev:    0,
-			MatchesConsidered: cachedMatches,
-			Methodology: elo.Methodology{
-				KFactor:          cfg.DefaultKFactor,
-				HomeAdvantage:    cfg.HomeAdvantage,
-				WeightMultiplier: 1.0,
-				FormulaReference: cfg.FormulaRef(),
-			},
-			Links: eloLinks(id, dateStr),
-		})
-		return
+	// Try to use cached Elo data first.  A consistent read skips the cache,
+	// which is only refreshed by recalculation and may predate the caller's
+	// own writes.
+	if !c.GetBool("consistentRead") {
+		cachedElo, cachedRank, cachedMatches, cacheErr := h.repo.GetTeamCachedElo(id, asOf)
+		if cacheErr == nil {
+			// Cache hit: use cached data.
+			c.Header("X-Cache-Status", "hit")
+			c.Header("X-Elo-Computed-At", time.Now().UTC().Format(time.RFC3339))
+			c.JSON(http.StatusOK, elo.Rating{
+				TeamID:            team.ID,
+				TeamName:          team.Name,
+				Date:              asOf,
+				Elo:               roundElo(cachedElo),
+				Rank:              cachedRank,
+				ChangeFromPrev:    0,
+				MatchesConsidered: cachedMatches,
+				Methodology: elo.Methodology{
+					KFactor:          cfg.DefaultKFactor,
+					HomeAdvantage:    cfg.HomeAdvantage,
+					WeightMultiplier: 1.0,
+					FormulaReference: cfg.FormulaRef(),
+				},
+				Links: eloLinks(id, dateStr),
+			})
+			return
+		}
 	}
 
 	// Cache miss: calculate from scratch.
@@ -109,7 +116,7 @@ func (h *FootballHandler) GetTeamElo(c *gin.Context) {
 	// ELO ratings depend on opponent ratings, which depend on all their matches.
 	matches, err := h.repo.GetMatchesChronological(0, asOf)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
@@ -119,7 +126,7 @@ func (h *FootballHandler) GetTeamElo(c *gin.Context) {
 	// other team's matches and ensures the delta reflects the team's own last game.
 	teamMatches, err := h.repo.GetMatchesChronological(id, asOf)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
@@ -145,7 +152,7 @@ func (h *FootballHandler) GetTeamElo(c *gin.Context) {
 
 	// Look up the most-recently cached global rank for this team.
 	// Returns 0 if no rank has been cached yet (recalculation not yet run).
-	cachedRank, err = h.repo.GetTeamCachedRank(id, asOf)
+	cachedRank, err := h.repo.GetTeamCachedRank(id, asOf)
 	if err != nil {
 		// Non-fatal: proceed without a rank rather than failing the whole request.
 		cachedRank = 0
@@ -189,17 +196,17 @@ func (h *FootballHandler) GetTeamElo(c *gin.Context) {
 func (h *FootballHandler) GetTeamEloTimeline(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid team id"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid team id", Code: errcode.InvalidID})
 		return
 	}
 
 	team, err := h.repo.GetTeamByID(id)
 	if errors.Is(err, models.ErrNotFound) {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "team not found"})
+		h.teamNotFound(c, id)
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
@@ -207,7 +214,7 @@ func (h *FootballHandler) GetTeamEloTimeline(c *gin.Context) {
 	if s := c.Query("end_date"); s != "" {
 		parsed, parseErr := time.Parse(eloDateLayout, s)
 		if parseErr != nil {
-			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid end_date format; expected YYYY-MM-DD"})
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid end_date format; expected YYYY-MM-DD", Code: errcode.InvalidParameter})
 			return
 		}
 		endDate = parsed
@@ -217,7 +224,7 @@ func (h *FootballHandler) GetTeamEloTimeline(c *gin.Context) {
 	if s := c.Query("start_date"); s != "" {
 		parsed, parseErr := time.Parse(eloDateLayout, s)
 		if parseErr != nil {
-			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid start_date format; expected YYYY-MM-DD"})
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid start_date format; expected YYYY-MM-DD", Code: errcode.InvalidParameter})
 			return
 		}
 		startDate = &parsed
@@ -238,7 +245,7 @@ func (h *FootballHandler) GetTeamEloTimeline(c *gin.Context) {
 	// Note: Timeline requires full match-by-match calculation; cache cannot be used.
 	matches, err := h.repo.GetMatchesChronological(0, endDate)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
@@ -273,7 +280,8 @@ func (h *FootballHandler) GetTeamEloTimeline(c *gin.Context) {
 }
 
 // GetEloRankings handles GET /api/v1/football/rankings/elo
-// Returns a global Elo rankings snapshot with pagination.
+// Returns a global Elo rankings snapshot with pagination.  Without ?limit=,
+// signed-in callers get their pageSize preference.
 //
 //	@Summary		Get global Elo rankings
 //	@Description	Returns a paginated snapshot of global Elo rankings, optionally filtered by region
@@ -293,7 +301,7 @@ func (h *FootballHandler) GetEloRankings(c *gin.Context) {
 	if dateStr != "" {
 		parsed, parseErr := time.Parse(eloDateLayout, dateStr)
 		if parseErr != nil {
-			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid date format; expected YYYY-MM-DD"})
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid date format; expected YYYY-MM-DD", Code: errcode.InvalidParameter})
 			return
 		}
 		asOf = parsed
@@ -301,11 +309,11 @@ func (h *FootballHandler) GetEloRankings(c *gin.Context) {
 		dateStr = asOf.Format(eloDateLayout)
 	}
 
-	limit := 50
+	limit := h.pageSize(c, 50)
 	if s := c.Query("limit"); s != "" {
 		v, err := strconv.Atoi(s)
 		if err != nil || v < 1 {
-			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "limit must be a positive integer"})
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "limit must be a positive integer", Code: errcode.InvalidParameter})
 			return
 		}
 		limit = v
@@ -315,7 +323,7 @@ func (h *FootballHandler) GetEloRankings(c *gin.Context) {
 	if s := c.Query("offset"); s != "" {
 		v, err := strconv.Atoi(s)
 		if err != nil || v < 0 {
-			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "offset must be a non-negative integer"})
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "offset must be a non-negative integer", Code: errcode.InvalidParameter})
 			return
 		}
 		offset = v
@@ -325,7 +333,7 @@ func (h *FootballHandler) GetEloRankings(c *gin.Context) {
 
 	rankings, err := h.repo.GetEloRankings(asOf, region, limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
@@ -362,6 +370,19 @@ func (h *FootballHandler) GetEloRankings(c *gin.Context) {
 	})
 }
 
+// eloRecalcLock names the lock held while Elo ratings are recalculated.
+const eloRecalcLock = "elo-recalculate"
+
+const (
+	// eloRecalcInterval is the least time between recalculations, unless
+	// forced.
+	eloRecalcInterval = 5 * time.Minute
+	// eloRecalcRetry is when a client refused because a recalculation is
+	// running is told to try again: a full recalculation typically takes
+	// well under a minute.
+	eloRecalcRetry = 30 * time.Second
+)
+
 // RecalculateEloRankings handles POST /api/v1/football/rankings/elo/recalculate
 // Triggers a background recalculation of Elo ratings for all (or one) team.
 // Requests are rate-limited to one run per 5 minutes; concurrent runs return 429.
@@ -384,15 +405,15 @@ func (h *FootballHandler) RecalculateEloRankings(c *gin.Context) {
 	if s := c.Query("team_id"); s != "" {
 		v, err := strconv.Atoi(s)
 		if err != nil || v < 1 {
-			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "team_id must be a positive integer"})
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "team_id must be a positive integer", Code: errcode.InvalidParameter})
 			return
 		}
 		// Verify team exists.
 		if _, err := h.repo.GetTeamByID(v); errors.Is(err, models.ErrNotFound) {
-			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "team not found"})
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "team not found", Code: errcode.TeamNotFound})
 			return
 		} else if err != nil {
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 			return
 		}
 		teamID = v
@@ -405,20 +426,42 @@ func (h *FootballHandler) RecalculateEloRankings(c *gin.Context) {
 	if h.eloRecalc.running {
 		h.eloRecalc.mu.Unlock()
 		c.Header("Cache-Control", "no-store")
-		c.JSON(http.StatusTooManyRequests, models.ErrorResponse{Error: "recalculation already in progress"})
+		retry.After(c.Writer.Header(), retry.Limit{Limit: 1, Reset: eloRecalcRetry})
+		c.JSON(http.StatusTooManyRequests, models.ErrorResponse{Error: "recalculation already in progress", Code: errcode.RecalculationRunning})
 		return
 	}
-	if !force && !h.eloRecalc.lastRun.IsZero() && time.Since(h.eloRecalc.lastRun) < 5*time.Minute {
+	if !force && !h.eloRecalc.lastRun.IsZero() && time.Since(h.eloRecalc.lastRun) < eloRecalcInterval {
+		wait := eloRecalcInterval - time.Since(h.eloRecalc.lastRun)
 		h.eloRecalc.mu.Unlock()
 		c.Header("Cache-Control", "no-store")
-		c.JSON(http.StatusTooManyRequests, models.ErrorResponse{Error: "recalculation rate limit: wait 5 minutes between runs or use ?force=true"})
+		retry.After(c.Writer.Header(), retry.Limit{Limit: 1, Reset: wait, Window: eloRecalcInterval})
+		c.JSON(http.StatusTooManyRequests, models.ErrorResponse{Error: "recalculation rate limit: wait 5 minutes between runs or use ?force=true", Code: errcode.RecalculationTooSoon})
 		return
 	}
 	h.eloRecalc.running = true
 	h.eloRecalc.mu.Unlock()
 
+	// Another instance may be recalculating against the same database.
+	release, err := h.locks.Acquire(c.Request.Context(), eloRecalcLock, 0)
+	if err != nil {
+		h.eloRecalc.mu.Lock()
+		h.eloRecalc.running = false
+		h.eloRecalc.mu.Unlock()
+		c.Header("Cache-Control", "no-store")
+		if errors.Is(err, lock.ErrTimeout) {
+			retry.After(c.Writer.Header(), retry.Limit{Limit: 1, Reset: eloRecalcRetry})
+			c.JSON(http.StatusTooManyRequests, models.ErrorResponse{Error: "recalculation already in progress", Code: errcode.RecalculationRunning})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+
 	// Launch background goroutine; respond immediately with 202.
-	go h.runEloRecalculation(teamID)
+	go func() {
+		defer release()
+		h.runEloRecalculation(teamID)
+	}()
 
 	c.Header("Cache-Control", "no-store")
 	c.JSON(http.StatusAccepted, elo.RecalculateResponse{
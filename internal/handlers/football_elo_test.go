@@ -1,6 +1,7 @@
 package handlers_test
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"strings"
@@ -10,6 +11,7 @@ import (
 	"github.com/gin-gonic/gin"
 	elomodels "github.com/sc23bd/COMP3011_Coursework1/internal/elo"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/handlers"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/lock"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
 )
 
@@ -457,6 +459,28 @@ func TestRecalculateEloRankings_AlreadyRunning(t *testing.T) {
 	close(bm.block)
 }
 
+// TestRecalculateEloRankings_LockedElsewhere verifies that a recalculation
+// running on another instance, which holds the shared lock, returns 429.
+func TestRecalculateEloRankings_LockedElsewhere(t *testing.T) {
+	locks := lock.NewLocal()
+	release, err := locks.Acquire(context.Background(), "elo-recalculate", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fh := handlers.NewFootballHandler(&footballMock{})
+	fh.SetLocks(locks)
+	r := gin.New()
+	r.POST("/api/v1/football/rankings/elo/recalculate", fh.RecalculateEloRankings)
+
+	w := doRequest(r, http.MethodPost, "/api/v1/football/rankings/elo/recalculate", nil)
+	assertStatus(t, w, http.StatusTooManyRequests)
+
+	// Once the other instance finishes, this one may run.
+	release()
+	w = doRequest(r, http.MethodPost, "/api/v1/football/rankings/elo/recalculate", nil)
+	assertStatus(t, w, http.StatusAccepted)
+}
+
 // TestRecalculateEloRankings_RateLimited verifies that a second recalculation
 // request (without ?force=true) within the 5-minute cooldown window returns 429
 // with the cooldown-specific error message.
@@ -6,7 +6,10 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/sanitize"
 )
 
 // --- Goals & shootouts (read) ------------------------------------------------
@@ -27,22 +30,22 @@ import (
 func (h *FootballHandler) GetMatchGoals(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid match id"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid match id", Code: errcode.InvalidID})
 		return
 	}
 
 	// Verify the match exists first.
 	if _, err := h.repo.GetMatchByID(id); errors.Is(err, models.ErrNotFound) {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "match not found"})
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "match not found", Code: errcode.MatchNotFound})
 		return
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
 	goals, err := h.repo.GetMatchGoals(id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 	if goals == nil {
@@ -73,26 +76,26 @@ func (h *FootballHandler) GetMatchGoals(c *gin.Context) {
 func (h *FootballHandler) GetMatchShootout(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid match id"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid match id", Code: errcode.InvalidID})
 		return
 	}
 
 	// Verify the match exists first.
 	if _, err := h.repo.GetMatchByID(id); errors.Is(err, models.ErrNotFound) {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "match not found"})
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "match not found", Code: errcode.MatchNotFound})
 		return
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
 	shootout, err := h.repo.GetMatchShootout(id)
 	if errors.Is(err, models.ErrNotFound) {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "no shootout recorded for this match"})
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "no shootout recorded for this match", Code: errcode.ShootoutNotFound})
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
@@ -121,13 +124,13 @@ func (h *FootballHandler) GetMatchShootout(c *gin.Context) {
 func (h *FootballHandler) GetPlayerGoals(c *gin.Context) {
 	name := c.Param("name")
 	if name == "" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "player name is required"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "player name is required", Code: errcode.FieldRequired})
 		return
 	}
 
 	goals, err := h.repo.GetPlayerGoals(name)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 	if goals == nil {
@@ -158,39 +161,48 @@ func (h *FootballHandler) GetPlayerGoals(c *gin.Context) {
 //	@Failure		400		{object}	models.ErrorResponse		"Invalid input"
 //	@Failure		401		{object}	models.ErrorResponse		"Unauthorized"
 //	@Failure		404		{object}	models.ErrorResponse		"Match or team not found"
+//	@Failure		422		{object}	models.ContentRejectedResponse	"Content rejected by the classifier"
 //	@Failure		500		{object}	models.ErrorResponse		"Internal server error"
+//	@Failure		503		{object}	models.ErrorResponse		"Content classifier unavailable"
 //	@Security		Bearer
 //	@Router			/football/matches/{id}/goals [post]
 func (h *FootballHandler) CreateGoal(c *gin.Context) {
 	matchID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid match id"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid match id", Code: errcode.InvalidID})
 		return
 	}
 
 	var req models.CreateGoalRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+	if err := c.ShouldBindWith(&req, sanitize.JSON); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: errcode.Binding(err)})
 		return
 	}
 
 	// Verify the match exists.
 	if _, err := h.repo.GetMatchByID(matchID); errors.Is(err, models.ErrNotFound) {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "match not found"})
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "match not found", Code: errcode.MatchNotFound})
 		return
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
 	// Look up the team to populate the team name on the goal.
 	team, err := h.repo.GetTeamByID(req.TeamID)
 	if errors.Is(err, models.ErrNotFound) {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "team not found"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "team not found", Code: errcode.TeamNotFound})
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+
+	// Goals are not moderated on their own; a quarantined scorer puts
+	// the match up for review.
+	verdict, ok := h.screen(c, models.ContentMatch, map[string]string{"scorer": req.Scorer})
+	if !ok {
 		return
 	}
 
@@ -203,10 +215,12 @@ func (h *FootballHandler) CreateGoal(c *gin.Context) {
 		Penalty: req.Penalty,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
+	h.quarantine(models.ContentMatch, matchID, verdict)
+	publish(c, h.events, events.GoalCreated, strconv.Itoa(goal.ID), goal)
 	c.JSON(http.StatusCreated, models.GoalsResponse{
 		Data: []models.Goal{goal},
 		Links: []models.Link{
@@ -233,18 +247,19 @@ func (h *FootballHandler) CreateGoal(c *gin.Context) {
 func (h *FootballHandler) DeleteGoal(c *gin.Context) {
 	goalID, err := strconv.Atoi(c.Param("goalId"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid goal id"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid goal id", Code: errcode.InvalidID})
 		return
 	}
 
 	if err := h.repo.DeleteGoal(goalID); errors.Is(err, models.ErrNotFound) {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "goal not found"})
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "goal not found", Code: errcode.GoalNotFound})
 		return
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
+	publish(c, h.events, events.GoalDeleted, strconv.Itoa(goalID), nil)
 	c.Status(http.StatusNoContent)
 }
 
@@ -264,40 +279,40 @@ func (h *FootballHandler) DeleteGoal(c *gin.Context) {
 //	@Failure		400			{object}	models.ErrorResponse			"Invalid input"
 //	@Failure		401			{object}	models.ErrorResponse			"Unauthorized"
 //	@Failure		404			{object}	models.ErrorResponse			"Match or team not found"
-//	@Failure		409			{object}	models.ErrorResponse			"Shootout already exists"
+//	@Failure		409			{object}	models.ConflictResponse			"Shootout already exists (current holds the recorded shootout)"
 //	@Failure		500			{object}	models.ErrorResponse			"Internal server error"
 //	@Security		Bearer
 //	@Router			/football/matches/{id}/shootout [post]
 func (h *FootballHandler) CreateShootout(c *gin.Context) {
 	matchID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid match id"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid match id", Code: errcode.InvalidID})
 		return
 	}
 
 	var req models.CreateShootoutRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: errcode.Binding(err)})
 		return
 	}
 
 	// Verify the match exists.
 	if _, err := h.repo.GetMatchByID(matchID); errors.Is(err, models.ErrNotFound) {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "match not found"})
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "match not found", Code: errcode.MatchNotFound})
 		return
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
 	// Look up the winning team to populate the winner name.
 	winner, err := h.repo.GetTeamByID(req.WinnerID)
 	if errors.Is(err, models.ErrNotFound) {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "winner team not found"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "winner team not found", Code: errcode.ReferenceNotFound})
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
@@ -307,14 +322,19 @@ func (h *FootballHandler) CreateShootout(c *gin.Context) {
 		Winner:   winner.Name,
 	})
 	if errors.Is(err, models.ErrConflict) {
-		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "shootout already recorded for this match"})
+		resp := models.ConflictResponse{Error: "shootout already recorded for this match", Code: errcode.ShootoutExists}
+		if existing, err := h.repo.GetMatchShootout(matchID); err == nil {
+			resp.Current = existing
+		}
+		c.JSON(http.StatusConflict, resp)
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
+	publish(c, h.events, events.ShootoutCreated, strconv.Itoa(matchID), shootout)
 	c.JSON(http.StatusCreated, models.ShootoutResponse{
 		Shootout: shootout,
 		Links: []models.Link{
@@ -340,17 +360,18 @@ func (h *FootballHandler) CreateShootout(c *gin.Context) {
 func (h *FootballHandler) DeleteShootout(c *gin.Context) {
 	matchID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid match id"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid match id", Code: errcode.InvalidID})
 		return
 	}
 
 	if err := h.repo.DeleteShootout(matchID); errors.Is(err, models.ErrNotFound) {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "no shootout found for this match"})
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "no shootout found for this match", Code: errcode.ShootoutNotFound})
 		return
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
+	publish(c, h.events, events.ShootoutDeleted, strconv.Itoa(matchID), nil)
 	c.Status(http.StatusNoContent)
 }
@@ -1,11 +1,15 @@
 package handlers_test
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"testing"
 	"time"
 
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/handlers"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
 )
 
@@ -314,6 +318,14 @@ func TestCreateShootout_Conflict(t *testing.T) {
 	if w.Code != http.StatusConflict {
 		t.Fatalf("expected 409, got %d", w.Code)
 	}
+
+	var resp struct {
+		Current models.Shootout `json:"current"`
+	}
+	decodeJSON(t, w, &resp)
+	if resp.Current.WinnerID != ger.ID {
+		t.Fatalf("expected current shootout won by %d, got %+v", ger.ID, resp.Current)
+	}
 }
 
 // --- DeleteShootout ----------------------------------------------------------
@@ -346,3 +358,56 @@ func TestDeleteShootout_NotFound(t *testing.T) {
 		t.Fatalf("expected 404, got %d", w.Code)
 	}
 }
+
+func TestGoalsAndShootouts_PublishEvents(t *testing.T) {
+	mock := &footballMock{}
+	fh := handlers.NewFootballHandler(mock)
+	bus := events.NewBus(nil)
+	var got []events.Event
+	bus.Subscribe(events.BeforeResponse, events.SubscriberFunc(func(_ context.Context, e events.Event) error {
+		got = append(got, e)
+		return nil
+	}))
+	fh.SetEvents(bus)
+
+	r := gin.New()
+	r.POST("/matches/:id/goals", fh.CreateGoal)
+	r.DELETE("/matches/:id/goals/:goalId", fh.DeleteGoal)
+	r.POST("/matches/:id/shootout", fh.CreateShootout)
+	r.DELETE("/matches/:id/shootout", fh.DeleteShootout)
+
+	eng := mock.addTeam("England")
+	ger := mock.addTeam("Germany")
+	m := mock.addMatch(models.Match{HomeTeamID: eng.ID, AwayTeamID: ger.ID, TournamentID: 1})
+	match := "/matches/" + itoa(m.ID)
+
+	w := doRequest(r, http.MethodPost, match+"/goals", map[string]interface{}{"teamId": eng.ID, "scorer": "Hurst"})
+	assertStatus(t, w, http.StatusCreated)
+	var goals models.GoalsResponse
+	_ = json.NewDecoder(w.Body).Decode(&goals)
+	goalID := itoa(goals.Data[0].ID)
+	assertStatus(t, doRequest(r, http.MethodDelete, match+"/goals/"+goalID, nil), http.StatusNoContent)
+	assertStatus(t, doRequest(r, http.MethodPost, match+"/shootout", map[string]interface{}{"winnerId": ger.ID}), http.StatusCreated)
+	assertStatus(t, doRequest(r, http.MethodDelete, match+"/shootout", nil), http.StatusNoContent)
+
+	want := []struct {
+		typ events.Type
+		id  string
+	}{
+		{events.GoalCreated, goalID},
+		{events.GoalDeleted, goalID},
+		{events.ShootoutCreated, itoa(m.ID)},
+		{events.ShootoutDeleted, itoa(m.ID)},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d events, got %+v", len(want), got)
+	}
+	for i, w := range want {
+		if got[i].Type != w.typ || got[i].ID != w.id {
+			t.Errorf("event %d: expected %s %s, got %s %s", i, w.typ, w.id, got[i].Type, got[i].ID)
+		}
+	}
+	if goal, ok := got[0].Data.(models.Goal); !ok || goal.Scorer != "Hurst" {
+		t.Errorf("unexpected goal.created data %+v", got[0].Data)
+	}
+}
@@ -7,17 +7,33 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/classify"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/db"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/lock"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
 )
 
 // FootballHandler holds the dependencies required by the football HTTP handlers.
 type FootballHandler struct {
-	repo db.FootballRepository
+	repo   db.FootballRepository
+	events *events.Bus
+	locks  lock.Manager
+	prefs  db.PreferencesRepository
+
+	// deletes sets how DELETE answers for IDs that do not exist.
+	deletes DeleteOptions
+
+	// classifier screens free text before it is stored; content it
+	// quarantines is opened in quarantineQueue.
+	classifier      classify.Classifier
+	quarantineQueue db.ModerationRepository
 
 	// eloRecalc tracks background recalculation state for rate limiting.
 	eloRecalc struct {
@@ -29,7 +45,176 @@ type FootballHandler struct {
 
 // NewFootballHandler constructs a FootballHandler backed by the provided repository.
 func NewFootballHandler(repo db.FootballRepository) *FootballHandler {
-	return &FootballHandler{repo: repo}
+	return &FootballHandler{repo: repo, locks: lock.NewLocal()}
+}
+
+// SetLocks coordinates work such as Elo recalculation through m, so that
+// it runs on one instance at a time rather than once per process.
+func (h *FootballHandler) SetLocks(m lock.Manager) {
+	h.locks = m
+}
+
+// SetEvents publishes team and match lifecycle events to bus.
+func (h *FootballHandler) SetEvents(bus *events.Bus) {
+	h.events = bus
+}
+
+// SetPreferences lets signed-in callers choose their default page size
+// through the pageSize preference.
+func (h *FootballHandler) SetPreferences(repo db.PreferencesRepository) {
+	h.prefs = repo
+}
+
+// DeleteOptions sets how DELETE answers for a team or match that does not
+// exist.  By default the answer is 404 Not Found.
+type DeleteOptions struct {
+	// Idempotent answers 204 No Content, as for a successful delete, so
+	// that a retried DELETE succeeds.
+	Idempotent bool
+	// Tombstones answers 410 Gone, with a models.GoneResponse saying when,
+	// for a team or match that was deleted, for as long as the revision
+	// history records it.  This takes precedence over Idempotent.
+	Tombstones bool
+}
+
+// SetDeleteOptions sets how DELETE answers for teams and matches that do
+// not exist.
+func (h *FootballHandler) SetDeleteOptions(opts DeleteOptions) {
+	h.deletes = opts
+}
+
+// deleteMissing answers a DELETE of resource id, which does not exist, as
+// h.deletes says, calling notFound for the default answer.
+func (h *FootballHandler) deleteMissing(c *gin.Context, resource string, id int, tombstone func(int) (models.Tombstone, error), notFound func()) {
+	if h.deletes.Tombstones {
+		t, err := tombstone(id)
+		if err == nil {
+			c.JSON(http.StatusGone, models.GoneResponse{Error: resource + " was deleted", Code: errcode.ResourceDeleted, Tombstone: t})
+			return
+		}
+		if !errors.Is(err, models.ErrNotFound) {
+			_ = c.Error(err)
+		}
+	}
+	if h.deletes.Idempotent {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	notFound()
+}
+
+// pageSize returns the default page size for the caller: their pageSize
+// preference when public reads identified them and they have set one,
+// otherwise fallback.
+func (h *FootballHandler) pageSize(c *gin.Context, fallback int) int {
+	return preferredPageSize(c, h.prefs, fallback)
+}
+
+// timeFilter reads the optional createdAfter, updatedSince and asOf query
+// parameters as RFC 3339 timestamps.  It writes a 400 response and returns
+// false when any is malformed.
+func timeFilter(c *gin.Context) (models.TimeFilter, bool) {
+	var f models.TimeFilter
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"createdAfter", &f.CreatedAfter},
+		{"updatedSince", &f.UpdatedSince},
+		{"asOf", &f.AsOf},
+	} {
+		t, ok := queryTime(c, p.name)
+		if !ok {
+			return models.TimeFilter{}, false
+		}
+		*p.dst = t
+	}
+	return f, true
+}
+
+// queryTime reads the optional query parameter name as an RFC 3339
+// timestamp, returning nil if it is absent.  It writes a 400 response and
+// returns false when it is malformed.
+func queryTime(c *gin.Context, name string) (*time.Time, bool) {
+	v := c.Query(name)
+	if v == "" {
+		return nil, true
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: name + " must be an RFC 3339 timestamp, e.g. 2025-01-31T12:00:00Z",
+			Code:  errcode.InvalidParameter,
+		})
+		return nil, false
+	}
+	t = t.UTC()
+	return &t, true
+}
+
+// noHistory writes the 422 response for an asOf before the revision
+// history began.
+func noHistory(c *gin.Context) {
+	c.JSON(http.StatusUnprocessableEntity, models.ErrorResponse{
+		Error: "asOf is before the revision history began; earlier states cannot be reconstructed",
+		Code:  errcode.HistoryUnavailable,
+	})
+}
+
+// teamConflict writes a 409 response carrying the team that already holds
+// name, so the client can reconcile without another request.
+func (h *FootballHandler) teamConflict(c *gin.Context, msg, name string) {
+	resp := models.ConflictResponse{Error: msg, Code: errcode.TeamExists}
+	if t, err := h.repo.GetTeamByName(name); err == nil {
+		resp.Current = models.TeamResponse{Team: t, Links: teamLinks(t)}
+	}
+	c.JSON(http.StatusConflict, resp)
+}
+
+// teamNotFound answers a request for team id, which does not exist: with
+// 308 Permanent Redirect to the same URL for the team it was merged into,
+// if it was, or else 404.
+func (h *FootballHandler) teamNotFound(c *gin.Context, id int) {
+	target, err := h.repo.ResolveTeamAlias(id)
+	if err != nil {
+		if !errors.Is(err, models.ErrNotFound) {
+			_ = c.Error(err)
+		}
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "team not found", Code: errcode.TeamNotFound})
+		return
+	}
+	u := *c.Request.URL
+	u.Path = strings.Replace(u.Path, "/teams/"+c.Param("id"), "/teams/"+strconv.Itoa(target), 1)
+	c.Redirect(http.StatusPermanentRedirect, u.RequestURI())
+}
+
+// teamBefore returns team id as it stands before an update, to compare the
+// updated team with, or nil if it cannot be read; the update itself then
+// reports whatever is wrong.
+func (h *FootballHandler) teamBefore(id int) interface{} {
+	team, err := h.repo.GetTeamByID(id)
+	if err != nil {
+		return nil
+	}
+	return team
+}
+
+// matchConflict writes a 409 response carrying the existing match between
+// the same home and away teams on the same date as m.
+func (h *FootballHandler) matchConflict(c *gin.Context, m models.Match) {
+	resp := models.ConflictResponse{Error: "match already exists", Code: errcode.MatchExists}
+	if matches, err := h.repo.GetHeadToHead(m.HomeTeamID, m.AwayTeamID); err == nil {
+		y, mo, d := m.Date.Date()
+		for _, existing := range matches {
+			ey, emo, ed := existing.Date.Date()
+			if existing.ID != m.ID && existing.HomeTeamID == m.HomeTeamID &&
+				existing.AwayTeamID == m.AwayTeamID && ey == y && emo == mo && ed == d {
+				resp.Current = models.MatchResponse{Match: existing, Links: matchLinks(existing.ID)}
+				break
+			}
+		}
+	}
+	c.JSON(http.StatusConflict, resp)
 }
 
 // checkTeamExists looks up a team by ID and writes a 400/500 response if it
@@ -37,11 +222,11 @@ func NewFootballHandler(repo db.FootballRepository) *FootballHandler {
 func (h *FootballHandler) checkTeamExists(c *gin.Context, id int, label string) bool {
 	_, err := h.repo.GetTeamByID(id)
 	if errors.Is(err, models.ErrNotFound) {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: label + " not found"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: label + " not found", Code: errcode.ReferenceNotFound})
 		return false
 	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return false
 	}
 	return true
@@ -52,24 +237,38 @@ func (h *FootballHandler) checkTeamExists(c *gin.Context, id int, label string)
 func (h *FootballHandler) checkTournamentExists(c *gin.Context, id int) bool {
 	_, err := h.repo.GetTournamentByID(id)
 	if errors.Is(err, models.ErrNotFound) {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "tournament not found"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "tournament not found", Code: errcode.ReferenceNotFound})
 		return false
 	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return false
 	}
 	return true
 }
 
-func teamLinks(id int) []models.Link {
-	base := "/api/v1/football/teams/" + strconv.Itoa(id)
-	return []models.Link{
+// setLastModified sets the Last-Modified header from a resource's
+// updatedAt, when it has one.
+func setLastModified(c *gin.Context, t time.Time) {
+	if !t.IsZero() {
+		c.Header("Last-Modified", t.UTC().Format(http.TimeFormat))
+	}
+}
+
+// teamLinks links to t by ID and, as rel "alternate", by slug.
+func teamLinks(t models.Team) []models.Link {
+	base := "/api/v1/football/teams/" + strconv.Itoa(t.ID)
+	links := []models.Link{
 		{Rel: "self", Href: base, Method: http.MethodGet},
 		{Rel: "update", Href: base, Method: http.MethodPut},
 		{Rel: "delete", Href: base, Method: http.MethodDelete},
 		{Rel: "history", Href: base + "/history", Method: http.MethodGet},
+		{Rel: "translations", Href: base + "/translations", Method: http.MethodGet},
+	}
+	if t.Slug != "" {
+		links = append(links, models.Link{Rel: "alternate", Href: "/api/v1/football/teams/slug/" + t.Slug, Method: http.MethodGet})
 	}
+	return links
 }
 
 func matchLinks(id int) []models.Link {
@@ -3,6 +3,7 @@ package handlers_test
 import (
 	"database/sql"
 	"encoding/json"
+	"maps"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -14,6 +15,7 @@ import (
 	elomodels "github.com/sc23bd/COMP3011_Coursework1/internal/elo"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/handlers"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/slug"
 )
 
 // ---------------------------------------------------------------------------
@@ -28,10 +30,13 @@ type footballMock struct {
 	goals       []models.Goal
 	shootouts   []models.Shootout
 	formerNames []models.FormerName
+	tombstones  []models.Tombstone
+	deadTeams   []models.Tombstone
+	aliases     map[int]int
 }
 
 func (m *footballMock) addTeam(name string) models.Team {
-	t := models.Team{ID: len(m.teams) + 1, Name: name, CreatedAt: time.Time{}}
+	t := models.Team{ID: len(m.teams) + 1, Name: name, Slug: slug.Make(name), CreatedAt: time.Time{}}
 	m.teams = append(m.teams, t)
 	return t
 }
@@ -62,9 +67,13 @@ func (m *footballMock) addShootout(s models.Shootout) models.Shootout {
 
 // --- Read implementations ---------------------------------------------------
 
-func (m *footballMock) ListTeams() ([]models.Team, error) {
-	result := make([]models.Team, len(m.teams))
-	copy(result, m.teams)
+func (m *footballMock) ListTeams(f models.TimeFilter) ([]models.Team, error) {
+	result := make([]models.Team, 0, len(m.teams))
+	for _, t := range m.teams {
+		if f.Matches(t.CreatedAt, t.UpdatedAt) {
+			result = append(result, t)
+		}
+	}
 	return result, nil
 }
 
@@ -77,6 +86,36 @@ func (m *footballMock) GetTeamByID(id int) (models.Team, error) {
 	return models.Team{}, models.ErrNotFound
 }
 
+func (m *footballMock) GetTeamByName(name string) (models.Team, error) {
+	for _, t := range m.teams {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	return models.Team{}, models.ErrNotFound
+}
+
+func (m *footballMock) GetTeamBySlug(slug string) (models.Team, error) {
+	for _, t := range m.teams {
+		if t.Slug == slug {
+			return t, nil
+		}
+	}
+	return models.Team{}, models.ErrNotFound
+}
+
+// GetTeamAsOf ignores the time: the mock keeps no history.
+func (m *footballMock) GetTeamAsOf(id int, _ time.Time) (models.Team, error) {
+	return m.GetTeamByID(id)
+}
+
+func (m *footballMock) ResolveTeamAlias(id int) (int, error) {
+	if target, ok := m.aliases[id]; ok {
+		return target, nil
+	}
+	return 0, models.ErrNotFound
+}
+
 func (m *footballMock) GetTeamHistory(teamID int) ([]models.FormerName, error) {
 	var result []models.FormerName
 	for _, fn := range m.formerNames {
@@ -96,16 +135,22 @@ func (m *footballMock) GetTournamentByID(id int) (models.Tournament, error) {
 	return models.Tournament{}, models.ErrNotFound
 }
 
-func (m *footballMock) ListMatches(limit, offset int) ([]models.Match, error) {
-	if offset >= len(m.matches) {
+func (m *footballMock) ListMatches(limit, offset int, f models.TimeFilter) ([]models.Match, error) {
+	var matched []models.Match
+	for _, match := range m.matches {
+		if f.Matches(match.CreatedAt, match.UpdatedAt) {
+			matched = append(matched, match)
+		}
+	}
+	if offset >= len(matched) {
 		return []models.Match{}, nil
 	}
 	end := offset + limit
-	if end > len(m.matches) {
-		end = len(m.matches)
+	if end > len(matched) {
+		end = len(matched)
 	}
 	result := make([]models.Match, end-offset)
-	copy(result, m.matches[offset:end])
+	copy(result, matched[offset:end])
 	return result, nil
 }
 
@@ -118,6 +163,11 @@ func (m *footballMock) GetMatchByID(id int) (models.Match, error) {
 	return models.Match{}, models.ErrNotFound
 }
 
+// GetMatchAsOf ignores the time: the mock keeps no history.
+func (m *footballMock) GetMatchAsOf(id int, _ time.Time) (models.Match, error) {
+	return m.GetMatchByID(id)
+}
+
 func (m *footballMock) GetHeadToHead(teamA, teamB int) ([]models.Match, error) {
 	var result []models.Match
 	for _, match := range m.matches {
@@ -182,16 +232,98 @@ func (m *footballMock) UpdateTeam(id int, name string) (models.Team, error) {
 	return models.Team{}, models.ErrNotFound
 }
 
+func (m *footballMock) SetTeamTranslation(id int, lang, name string) (models.Team, error) {
+	for i, t := range m.teams {
+		if t.ID == id {
+			tr := maps.Clone(t.Translations)
+			if tr == nil {
+				tr = map[string]string{}
+			}
+			tr[lang] = name
+			m.teams[i].Translations = tr
+			return m.teams[i], nil
+		}
+	}
+	return models.Team{}, models.ErrNotFound
+}
+
+func (m *footballMock) DeleteTeamTranslation(id int, lang string) (models.Team, error) {
+	for i, t := range m.teams {
+		if t.ID == id {
+			if _, ok := t.Translations[lang]; !ok {
+				return models.Team{}, models.ErrNotFound
+			}
+			tr := maps.Clone(t.Translations)
+			delete(tr, lang)
+			m.teams[i].Translations = tr
+			return m.teams[i], nil
+		}
+	}
+	return models.Team{}, models.ErrNotFound
+}
+
+func (m *footballMock) MergeTeam(id, target int) (models.Team, error) {
+	var src, dst *models.Team
+	for i := range m.teams {
+		switch m.teams[i].ID {
+		case id:
+			src = &m.teams[i]
+		case target:
+			dst = &m.teams[i]
+		}
+	}
+	if src == nil || dst == nil {
+		return models.Team{}, models.ErrNotFound
+	}
+	for i, match := range m.matches {
+		if (match.HomeTeamID == id && match.AwayTeamID == target) || (match.HomeTeamID == target && match.AwayTeamID == id) {
+			return models.Team{}, models.ErrConflict
+		}
+		if match.HomeTeamID == id {
+			m.matches[i].HomeTeamID = target
+		}
+		if match.AwayTeamID == id {
+			m.matches[i].AwayTeamID = target
+		}
+	}
+	m.formerNames = append(m.formerNames, models.FormerName{ID: len(m.formerNames) + 1, TeamID: target, FormerName: src.Name})
+	if m.aliases == nil {
+		m.aliases = map[int]int{}
+	}
+	m.aliases[id] = target
+	merged := *dst
+	_ = m.DeleteTeam(id)
+	return merged, nil
+}
+
 func (m *footballMock) DeleteTeam(id int) error {
 	for i, t := range m.teams {
 		if t.ID == id {
 			m.teams = append(m.teams[:i], m.teams[i+1:]...)
+			m.deadTeams = append(m.deadTeams, models.Tombstone{ID: id, DeletedAt: time.Now()})
 			return nil
 		}
 	}
 	return models.ErrNotFound
 }
 
+func (m *footballMock) TeamTombstone(id int) (models.Tombstone, error) {
+	return findTombstone(m.deadTeams, id)
+}
+
+func (m *footballMock) MatchTombstone(id int) (models.Tombstone, error) {
+	return findTombstone(m.tombstones, id)
+}
+
+func findTombstone(ts []models.Tombstone, id int) (models.Tombstone, error) {
+	for _, t := range ts {
+		if t.ID == id {
+			return t, nil
+		}
+	}
+	return models.Tombstone{}, models.ErrNotFound
+}
+
 func (m *footballMock) CreateMatch(match models.Match) (models.Match, error) {
 	match.ID = len(m.matches) + 1
 	m.matches = append(m.matches, match)
@@ -213,12 +345,28 @@ func (m *footballMock) DeleteMatch(id int) error {
 	for i, ms := range m.matches {
 		if ms.ID == id {
 			m.matches = append(m.matches[:i], m.matches[i+1:]...)
+			m.tombstones = append(m.tombstones, models.Tombstone{ID: id, DeletedAt: time.Now()})
 			return nil
 		}
 	}
 	return models.ErrNotFound
 }
 
+func (m *footballMock) MatchChangesSince(since time.Time) (models.MatchChanges, error) {
+	ch := models.MatchChanges{AsOf: time.Now()}
+	for _, match := range m.matches {
+		if !match.UpdatedAt.Before(since) {
+			ch.Changed = append(ch.Changed, match)
+		}
+	}
+	for _, t := range m.tombstones {
+		if !t.DeletedAt.Before(since) {
+			ch.Deleted = append(ch.Deleted, t)
+		}
+	}
+	return ch, nil
+}
+
 func (m *footballMock) CreateGoal(g models.Goal) (models.Goal, error) {
 	g.ID = len(m.goals) + 1
 	m.goals = append(m.goals, g)
@@ -317,8 +465,11 @@ func newFootballRouter() (*gin.Engine, *footballMock) {
 		// Read routes
 		v1.GET("/teams", fh.ListTeams)
 		v1.GET("/teams/:id", fh.GetTeam)
+		v1.GET("/teams/slug/:slug", fh.GetTeamBySlug)
 		v1.GET("/teams/:id/history", fh.GetTeamHistory)
+		v1.GET("/teams/:id/translations", fh.GetTeamTranslations)
 		v1.GET("/matches", fh.ListMatches)
+		v1.GET("/matches/changes", fh.MatchChanges)
 		v1.GET("/matches/:id", fh.GetMatch)
 		v1.GET("/matches/:id/goals", fh.GetMatchGoals)
 		v1.GET("/matches/:id/shootout", fh.GetMatchShootout)
@@ -329,9 +480,13 @@ func newFootballRouter() (*gin.Engine, *footballMock) {
 		v1.POST("/teams", fh.CreateTeam)
 		v1.PUT("/teams/:id", fh.UpdateTeam)
 		v1.DELETE("/teams/:id", fh.DeleteTeam)
+		v1.POST("/teams/:id/merge-into/:target", fh.MergeTeam)
+		v1.PUT("/teams/:id/translations/:lang", fh.SetTeamTranslation)
+		v1.DELETE("/teams/:id/translations/:lang", fh.DeleteTeamTranslation)
 
 		v1.POST("/matches", fh.CreateMatch)
 		v1.PUT("/matches/:id", fh.UpdateMatch)
+		v1.PATCH("/matches/:id", fh.PatchMatch)
 		v1.DELETE("/matches/:id", fh.DeleteMatch)
 
 		v1.POST("/matches/:id/goals", fh.CreateGoal)
@@ -343,6 +498,18 @@ func newFootballRouter() (*gin.Engine, *footballMock) {
 	return r, mock
 }
 
+// newDeleteRouter wires only the team and match DELETE routes, answering
+// for missing IDs as opts says.
+func newDeleteRouter(opts handlers.DeleteOptions) (*gin.Engine, *footballMock) {
+	mock := &footballMock{}
+	fh := handlers.NewFootballHandler(mock)
+	fh.SetDeleteOptions(opts)
+	r := gin.New()
+	r.DELETE("/api/v1/football/teams/:id", fh.DeleteTeam)
+	r.DELETE("/api/v1/football/matches/:id", fh.DeleteMatch)
+	return r, mock
+}
+
 // newFootballRouterWithAuth builds a router where write routes are gated by a
 // simple stub middleware that rejects requests without an "Authorization" header.
 // This is enough to confirm the auth gate is wired correctly at the handler level.
@@ -367,6 +534,7 @@ func newFootballRouterWithAuth() (*gin.Engine, *footballMock) {
 
 		v1.POST("/matches", authGuard, fh.CreateMatch)
 		v1.PUT("/matches/:id", authGuard, fh.UpdateMatch)
+		v1.PATCH("/matches/:id", authGuard, fh.PatchMatch)
 		v1.DELETE("/matches/:id", authGuard, fh.DeleteMatch)
 
 		v1.POST("/matches/:id/goals", authGuard, fh.CreateGoal)
@@ -425,6 +593,7 @@ func TestWriteRoutes_RequireAuth(t *testing.T) {
 		{http.MethodDelete, "/api/v1/football/teams/" + itoa(eng.ID)},
 		{http.MethodPost, "/api/v1/football/matches"},
 		{http.MethodPut, "/api/v1/football/matches/" + itoa(match.ID)},
+		{http.MethodPatch, "/api/v1/football/matches/" + itoa(match.ID)},
 		{http.MethodDelete, "/api/v1/football/matches/" + itoa(match.ID)},
 		{http.MethodPost, "/api/v1/football/matches/" + itoa(match.ID) + "/goals"},
 		{http.MethodDelete, "/api/v1/football/matches/" + itoa(match.ID) + "/goals/" + itoa(goal.ID)},
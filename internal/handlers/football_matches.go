@@ -6,7 +6,10 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/sanitize"
 )
 
 // defaultLimit is the default number of matches returned per page.
@@ -27,7 +30,7 @@ const defaultLimit = 50
 func (h *FootballHandler) ListTournaments(c *gin.Context) {
 	tournaments, err := h.repo.ListTournaments()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 	if tournaments == nil {
@@ -39,26 +42,33 @@ func (h *FootballHandler) ListTournaments(c *gin.Context) {
 // --- Matches (read) ----------------------------------------------------------
 
 // ListMatches handles GET /api/v1/football/matches
-// Accepts optional ?limit= and ?offset= query parameters for pagination.
+// Accepts optional ?limit= and ?offset= query parameters for pagination, and
+// ?createdAfter= / ?updatedSince= RFC 3339 timestamps for incremental sync.
+// ?asOf= lists the matches as they were at that time instead.  Without
+// ?limit=, signed-in callers get their pageSize preference.
 //
 //	@Summary		List all matches
 //	@Description	Get all matches with pagination support
 //	@Tags			matches
 //	@Produce		json
-//	@Param			limit	query		int						false	"Number of results per page"	default(50)
-//	@Param			offset	query		int						false	"Offset for pagination"			default(0)
-//	@Success		200		{object}	models.MatchesResponse	"List of matches"
-//	@Failure		400		{object}	models.ErrorResponse	"Invalid query parameters"
-//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Param			limit			query		int						false	"Number of results per page"	default(50)
+//	@Param			offset			query		int						false	"Offset for pagination"			default(0)
+//	@Param			createdAfter	query		string					false	"Only matches created after this RFC 3339 time"
+//	@Param			updatedSince	query		string					false	"Only matches updated at or after this RFC 3339 time"
+//	@Param			asOf			query		string					false	"List the matches as they were at this RFC 3339 time"
+//	@Success		200				{object}	models.MatchesResponse	"List of matches"
+//	@Failure		400				{object}	models.ErrorResponse	"Invalid query parameters"
+//	@Failure		422				{object}	models.ErrorResponse	"asOf before the revision history began"
+//	@Failure		500				{object}	models.ErrorResponse	"Internal server error"
 //	@Router			/football/matches [get]
 func (h *FootballHandler) ListMatches(c *gin.Context) {
-	limit := defaultLimit
+	limit := h.pageSize(c, defaultLimit)
 	offset := 0
 
 	if v := c.Query("limit"); v != "" {
 		n, err := strconv.Atoi(v)
 		if err != nil || n <= 0 {
-			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "limit must be a positive integer"})
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "limit must be a positive integer", Code: errcode.InvalidParameter})
 			return
 		}
 		limit = n
@@ -66,15 +76,23 @@ func (h *FootballHandler) ListMatches(c *gin.Context) {
 	if v := c.Query("offset"); v != "" {
 		n, err := strconv.Atoi(v)
 		if err != nil || n < 0 {
-			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "offset must be a non-negative integer"})
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "offset must be a non-negative integer", Code: errcode.InvalidParameter})
 			return
 		}
 		offset = n
 	}
+	f, ok := timeFilter(c)
+	if !ok {
+		return
+	}
 
-	matches, err := h.repo.ListMatches(limit, offset)
+	matches, err := h.repo.ListMatches(limit, offset, f)
+	if errors.Is(err, models.ErrNoHistory) {
+		noHistory(c)
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
@@ -95,35 +113,53 @@ func (h *FootballHandler) ListMatches(c *gin.Context) {
 }
 
 // GetMatch handles GET /api/v1/football/matches/:id
-// Returns the requested match or 404 if it does not exist.
+// Returns the requested match or 404 if it does not exist.  With ?asOf= it
+// returns the match as it was at that time, with the team names of the
+// time, or 404 if it did not exist then.
 //
 //	@Summary		Get a match by ID
 //	@Description	Get detailed information about a specific match
 //	@Tags			matches
 //	@Produce		json
-//	@Param			id	path		int						true	"Match ID"
-//	@Success		200	{object}	models.MatchResponse	"Match details"
-//	@Failure		400	{object}	models.ErrorResponse	"Invalid match ID"
-//	@Failure		404	{object}	models.ErrorResponse	"Match not found"
-//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Param			id		path		int						true	"Match ID"
+//	@Param			asOf	query		string					false	"Return the match as it was at this RFC 3339 time"
+//	@Success		200		{object}	models.MatchResponse	"Match details"
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid match ID or asOf"
+//	@Failure		404		{object}	models.ErrorResponse	"Match not found"
+//	@Failure		422		{object}	models.ErrorResponse	"asOf before the revision history began"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
 //	@Router			/football/matches/{id} [get]
 func (h *FootballHandler) GetMatch(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid match id"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid match id", Code: errcode.InvalidID})
+		return
+	}
+	asOf, ok := queryTime(c, "asOf")
+	if !ok {
 		return
 	}
 
-	match, err := h.repo.GetMatchByID(id)
+	var match models.Match
+	if asOf != nil {
+		match, err = h.repo.GetMatchAsOf(id, *asOf)
+	} else {
+		match, err = h.repo.GetMatchByID(id)
+	}
 	if errors.Is(err, models.ErrNotFound) {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "match not found"})
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "match not found", Code: errcode.MatchNotFound})
+		return
+	}
+	if errors.Is(err, models.ErrNoHistory) {
+		noHistory(c)
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
+	setLastModified(c, match.UpdatedAt)
 	c.JSON(http.StatusOK, models.MatchResponse{
 		Match: match,
 		Links: matchLinks(match.ID),
@@ -147,24 +183,24 @@ func (h *FootballHandler) GetHeadToHead(c *gin.Context) {
 	aStr := c.Query("teamA")
 	bStr := c.Query("teamB")
 	if aStr == "" || bStr == "" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "teamA and teamB query parameters are required"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "teamA and teamB query parameters are required", Code: errcode.InvalidParameter})
 		return
 	}
 
 	teamA, err := strconv.Atoi(aStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "teamA must be an integer"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "teamA must be an integer", Code: errcode.InvalidParameter})
 		return
 	}
 	teamB, err := strconv.Atoi(bStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "teamB must be an integer"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "teamB must be an integer", Code: errcode.InvalidParameter})
 		return
 	}
 
 	matches, err := h.repo.GetHeadToHead(teamA, teamB)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
@@ -198,14 +234,16 @@ func (h *FootballHandler) GetHeadToHead(c *gin.Context) {
 //	@Success		201		{object}	models.MatchResponse		"Match created"
 //	@Failure		400		{object}	models.ErrorResponse		"Invalid request"
 //	@Failure		401		{object}	models.ErrorResponse		"Unauthorized"
-//	@Failure		409		{object}	models.ErrorResponse		"Match already exists"
+//	@Failure		409		{object}	models.ConflictResponse		"Match already exists (current holds the existing match)"
+//	@Failure		422		{object}	models.ContentRejectedResponse	"Content rejected by the classifier"
 //	@Failure		500		{object}	models.ErrorResponse		"Internal server error"
+//	@Failure		503		{object}	models.ErrorResponse		"Content classifier unavailable"
 //	@Security		Bearer
 //	@Router			/football/matches [post]
 func (h *FootballHandler) CreateMatch(c *gin.Context) {
 	var req models.CreateMatchRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+	if err := c.ShouldBindWith(&req, sanitize.JSON); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: errcode.Binding(err)})
 		return
 	}
 
@@ -220,6 +258,10 @@ func (h *FootballHandler) CreateMatch(c *gin.Context) {
 	if !h.checkTournamentExists(c, req.TournamentID) {
 		return
 	}
+	verdict, ok := h.screen(c, models.ContentMatch, matchText(req.City, req.Country))
+	if !ok {
+		return
+	}
 
 	m := models.Match{
 		Date:         req.Date,
@@ -235,14 +277,16 @@ func (h *FootballHandler) CreateMatch(c *gin.Context) {
 
 	created, err := h.repo.CreateMatch(m)
 	if errors.Is(err, models.ErrConflict) {
-		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "match already exists"})
+		h.matchConflict(c, m)
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
+	h.quarantine(models.ContentMatch, created.ID, verdict)
+	publish(c, h.events, events.MatchCreated, strconv.Itoa(created.ID), created)
 	c.Header("Location", "/api/v1/football/matches/"+strconv.Itoa(created.ID))
 	c.JSON(http.StatusCreated, models.MatchResponse{
 		Match: created,
@@ -252,6 +296,9 @@ func (h *FootballHandler) CreateMatch(c *gin.Context) {
 
 // UpdateMatch handles PUT /api/v1/football/matches/:id
 // Replaces an existing match record. Requires JWT authorisation.
+// The response lists the fields that changed with their old and new values, or
+// is a JSON Patch of replace operations when the client accepts
+// application/json-patch+json.
 //
 //	@Summary		Update a match
 //	@Description	Update an existing match record (requires authentication)
@@ -264,23 +311,42 @@ func (h *FootballHandler) CreateMatch(c *gin.Context) {
 //	@Failure		400		{object}	models.ErrorResponse		"Invalid request"
 //	@Failure		401		{object}	models.ErrorResponse		"Unauthorized"
 //	@Failure		404		{object}	models.ErrorResponse		"Match not found"
-//	@Failure		409		{object}	models.ErrorResponse		"Match already exists"
+//	@Failure		409		{object}	models.ConflictResponse		"Match already exists (current holds the existing match)"
+//	@Failure		422		{object}	models.ContentRejectedResponse	"Content rejected by the classifier"
 //	@Failure		500		{object}	models.ErrorResponse		"Internal server error"
+//	@Failure		503		{object}	models.ErrorResponse		"Content classifier unavailable"
 //	@Security		Bearer
 //	@Router			/football/matches/{id} [put]
 func (h *FootballHandler) UpdateMatch(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid match id"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid match id", Code: errcode.InvalidID})
 		return
 	}
 
 	var req models.UpdateMatchRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+	if err := c.ShouldBindWith(&req, sanitize.JSON); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: errcode.Binding(err)})
+		return
+	}
+
+	before, err := h.repo.GetMatchByID(id)
+	if errors.Is(err, models.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "match not found", Code: errcode.MatchNotFound})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
+	h.replaceMatch(c, before, req)
+}
+
+// replaceMatch validates the references in req, stores it over before and
+// writes the response.  It is shared by PUT and PATCH, which differ only in
+// how they arrive at the full replacement.
+func (h *FootballHandler) replaceMatch(c *gin.Context, before models.Match, req models.UpdateMatchRequest) {
 	// Verify teams and tournament exist before updating.
 	if !h.checkTeamExists(c, req.HomeTeamID, "home team") {
 		return
@@ -291,6 +357,10 @@ func (h *FootballHandler) UpdateMatch(c *gin.Context) {
 	if !h.checkTournamentExists(c, req.TournamentID) {
 		return
 	}
+	verdict, ok := h.screen(c, models.ContentMatch, matchText(req.City, req.Country))
+	if !ok {
+		return
+	}
 
 	m := models.Match{
 		Date:         req.Date,
@@ -304,55 +374,69 @@ func (h *FootballHandler) UpdateMatch(c *gin.Context) {
 		Neutral:      req.Neutral,
 	}
 
-	updated, err := h.repo.UpdateMatch(id, m)
+	updated, err := h.repo.UpdateMatch(before.ID, m)
 	if errors.Is(err, models.ErrNotFound) {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "match not found"})
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "match not found", Code: errcode.MatchNotFound})
 		return
 	}
 	if errors.Is(err, models.ErrConflict) {
-		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "match already exists"})
+		m.ID = before.ID
+		h.matchConflict(c, m)
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
+	h.quarantine(models.ContentMatch, updated.ID, verdict)
+	changes := fieldChanges(before, updated)
+	publish(c, h.events, events.MatchUpdated, strconv.Itoa(updated.ID), events.Update{Resource: updated, Changes: changes})
+	if wantsPatch(c) {
+		writePatch(c, changes)
+		return
+	}
 	c.JSON(http.StatusOK, models.MatchResponse{
-		Match: updated,
-		Links: matchLinks(updated.ID),
+		Match:   updated,
+		Changes: changes,
+		Links:   matchLinks(updated.ID),
 	})
 }
 
 // DeleteMatch handles DELETE /api/v1/football/matches/:id
-// Removes a match record. Requires JWT authorisation.
+// Removes a match record. Requires JWT authorisation.  A match that does
+// not exist is answered as the DeleteOptions say.
 //
 //	@Summary		Delete a match
 //	@Description	Delete a match by ID (requires authentication)
 //	@Tags			matches
 //	@Produce		json
 //	@Param			id	path	int	true	"Match ID"
-//	@Success		204	"Match deleted successfully"
+//	@Success		204	"Match deleted successfully, or already absent where deletes are idempotent"
 //	@Failure		400	{object}	models.ErrorResponse	"Invalid match ID"
 //	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
 //	@Failure		404	{object}	models.ErrorResponse	"Match not found"
+//	@Failure		410	{object}	models.GoneResponse		"Match already deleted, where tombstones are kept"
 //	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
 //	@Security		Bearer
 //	@Router			/football/matches/{id} [delete]
 func (h *FootballHandler) DeleteMatch(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid match id"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid match id", Code: errcode.InvalidID})
 		return
 	}
 
 	if err := h.repo.DeleteMatch(id); errors.Is(err, models.ErrNotFound) {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "match not found"})
+		h.deleteMissing(c, "match", id, h.repo.MatchTombstone, func() {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "match not found", Code: errcode.MatchNotFound})
+		})
 		return
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
+	publish(c, h.events, events.MatchDeleted, strconv.Itoa(id), nil)
 	c.Status(http.StatusNoContent)
 }
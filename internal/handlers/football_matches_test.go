@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/sc23bd/COMP3011_Coursework1/internal/handlers"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
 )
 
@@ -256,6 +257,39 @@ func TestUpdateMatch_Success(t *testing.T) {
 	}
 }
 
+func TestUpdateMatch_ReportsChanges(t *testing.T) {
+	r, mock := newFootballRouter()
+	eng := mock.addTeam("England")
+	ger := mock.addTeam("Germany")
+	tourn := mock.addTournament("FIFA World Cup")
+	date := time.Date(1990, 7, 4, 0, 0, 0, 0, time.UTC)
+	m := mock.addMatch(models.Match{
+		Date: date, HomeTeamID: eng.ID, AwayTeamID: ger.ID,
+		HomeScore: 1, AwayScore: 1, TournamentID: tourn.ID, City: "Turin",
+	})
+
+	w := doRequest(r, http.MethodPut, "/api/v1/football/matches/"+itoa(m.ID), map[string]interface{}{
+		"date":         "1990-07-04T00:00:00Z",
+		"homeTeamId":   eng.ID,
+		"awayTeamId":   ger.ID,
+		"homeScore":    1,
+		"awayScore":    2,
+		"tournamentId": tourn.ID,
+		"city":         "Turin",
+	})
+	assertStatus(t, w, http.StatusOK)
+
+	var resp models.MatchResponse
+	decodeJSON(t, w, &resp)
+	if len(resp.Changes) != 1 {
+		t.Fatalf("expected exactly one change, got %+v", resp.Changes)
+	}
+	ch := resp.Changes[0]
+	if ch.Field != "awayScore" || ch.Old != float64(1) || ch.New != float64(2) {
+		t.Errorf("unexpected change: %+v", ch)
+	}
+}
+
 func TestUpdateMatch_NotFound(t *testing.T) {
 	r, mock := newFootballRouter()
 	eng := mock.addTeam("England")
@@ -366,3 +400,37 @@ func TestDeleteMatch_NotFound(t *testing.T) {
 		t.Fatalf("expected 404, got %d", w.Code)
 	}
 }
+
+func TestDeleteMatch_Idempotent(t *testing.T) {
+	r, _ := newDeleteRouter(handlers.DeleteOptions{Idempotent: true})
+	w := doRequest(r, http.MethodDelete, "/api/v1/football/matches/999", nil)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+}
+
+func TestDeleteMatch_Tombstone(t *testing.T) {
+	r, mock := newDeleteRouter(handlers.DeleteOptions{Idempotent: true, Tombstones: true})
+	eng := mock.addTeam("England")
+	ger := mock.addTeam("Germany")
+	m := mock.addMatch(models.Match{HomeTeamID: eng.ID, AwayTeamID: ger.ID, TournamentID: 1})
+
+	if w := doRequest(r, http.MethodDelete, "/api/v1/football/matches/"+itoa(m.ID), nil); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+	w := doRequest(r, http.MethodDelete, "/api/v1/football/matches/"+itoa(m.ID), nil)
+	if w.Code != http.StatusGone {
+		t.Fatalf("expected 410, got %d: %s", w.Code, w.Body.String())
+	}
+	var body models.GoneResponse
+	decodeJSON(t, w, &body)
+	if body.ID != m.ID || body.DeletedAt.IsZero() {
+		t.Errorf("expected a tombstone for match %d, got %+v", m.ID, body)
+	}
+
+	// IDs that never existed are not gone, only absent.
+	if w := doRequest(r, http.MethodDelete, "/api/v1/football/matches/999", nil); w.Code != http.StatusNoContent {
+		t.Errorf("expected 204 for an unknown ID, got %d", w.Code)
+	}
+}
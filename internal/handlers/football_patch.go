@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/patch"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/sanitize"
+)
+
+// PatchMatch handles PATCH /api/v1/football/matches/:id
+// Applies a JSON Patch (RFC 6902) or JSON Merge Patch (RFC 7386) to a match,
+// chosen by Content-Type. Requires JWT authorisation.
+// The patch is applied to the match's UpdateMatchRequest representation and
+// the result is validated exactly as a PUT body would be, so a patch can
+// never produce a match that PUT would reject.  In a merge patch a null
+// clears an optional field (city, country); nulling a required field fails
+// validation.
+//
+//	@Summary		Patch a match
+//	@Description	Apply a JSON Patch or JSON Merge Patch document to a match (requires authentication)
+//	@Tags			matches
+//	@Accept			application/json-patch+json,application/merge-patch+json
+//	@Produce		json
+//	@Param			id		path		int						true	"Match ID"
+//	@Param			request	body		[]patch.Operation		true	"JSON Patch operations, or a merge patch object"
+//	@Success		200		{object}	models.MatchResponse	"Match updated"
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid patch or patched match"
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		404		{object}	models.ErrorResponse	"Match not found"
+//	@Failure		409		{object}	models.ErrorResponse	"A test operation failed"
+//	@Failure		415		{object}	models.ErrorResponse	"Unsupported patch media type"
+//	@Failure		422		{object}	models.ErrorResponse	"Patch path does not exist, or content rejected by the classifier"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Failure		503		{object}	models.ErrorResponse	"Content classifier unavailable"
+//	@Security		Bearer
+//	@Router			/football/matches/{id} [patch]
+func (h *FootballHandler) PatchMatch(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid match id", Code: errcode.InvalidID})
+		return
+	}
+	contentType := c.ContentType()
+	if contentType != patch.MediaType && contentType != patch.MergeMediaType {
+		c.JSON(http.StatusUnsupportedMediaType, models.ErrorResponse{
+			Error: "PATCH requires Content-Type " + patch.MediaType + " or " + patch.MergeMediaType,
+			Code:  errcode.UnsupportedMediaType,
+		})
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "could not read request body", Code: errcode.MalformedBody})
+		return
+	}
+	var ops []patch.Operation
+	if contentType == patch.MediaType {
+		if ops, err = patch.Decode(body); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: errcode.InvalidPatch})
+			return
+		}
+	}
+
+	before, err := h.repo.GetMatchByID(id)
+	if errors.Is(err, models.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "match not found", Code: errcode.MatchNotFound})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+
+	doc, err := json.Marshal(models.UpdateMatchRequest{
+		Date:         before.Date,
+		HomeTeamID:   before.HomeTeamID,
+		AwayTeamID:   before.AwayTeamID,
+		HomeScore:    before.HomeScore,
+		AwayScore:    before.AwayScore,
+		TournamentID: before.TournamentID,
+		City:         before.City,
+		Country:      before.Country,
+		Neutral:      before.Neutral,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	var patched []byte
+	if contentType == patch.MediaType {
+		patched, err = patch.Apply(doc, ops)
+	} else {
+		patched, err = patch.Merge(doc, body)
+	}
+	switch {
+	case errors.Is(err, patch.ErrTestFailed):
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: err.Error(), Code: errcode.PatchTestFailed})
+		return
+	case errors.Is(err, patch.ErrPathNotFound):
+		c.JSON(http.StatusUnprocessableEntity, models.ErrorResponse{Error: err.Error(), Code: errcode.PatchPathNotFound})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: errcode.InvalidPatch})
+		return
+	}
+
+	// Reject patches that add members the resource does not have, then bind
+	// the patched document so the usual validation rules apply.
+	dec := json.NewDecoder(bytes.NewReader(patched))
+	dec.DisallowUnknownFields()
+	var probe models.UpdateMatchRequest
+	if err := dec.Decode(&probe); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "patched match is invalid: " + err.Error(), Code: errcode.InvalidPatch})
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(patched))
+	var req models.UpdateMatchRequest
+	if err := c.ShouldBindWith(&req, sanitize.JSON); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "patched match is invalid: " + err.Error(), Code: errcode.InvalidPatch})
+		return
+	}
+
+	h.replaceMatch(c, before, req)
+}
+
+// wantsPatch reports whether the client listed application/json-patch+json in
+// its Accept header.
+func wantsPatch(c *gin.Context) bool {
+	for _, part := range strings.Split(c.GetHeader("Accept"), ",") {
+		if mt, _, err := mime.ParseMediaType(strings.TrimSpace(part)); err == nil && mt == patch.MediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// writePatch responds with changes expressed as JSON Patch replace
+// operations, which a client can apply to its cached copy of the resource.
+func writePatch(c *gin.Context, changes []models.FieldChange) {
+	ops := make([]patch.Operation, 0, len(changes))
+	for _, ch := range changes {
+		op, err := patch.Replace(ch.Field, ch.New)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+			return
+		}
+		ops = append(ops, op)
+	}
+	body, err := json.Marshal(ops)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	c.Data(http.StatusOK, patch.MediaType, body)
+}
@@ -0,0 +1,148 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/patch"
+)
+
+// doPatch sends body as a PATCH with the given Content-Type and Accept headers.
+func doPatch(r *gin.Engine, path, body, contentType, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPatch, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// patchFixture seeds a match between two teams and returns the router and
+// the match path.
+func patchFixture() (*gin.Engine, *footballMock, string) {
+	r, mock := newFootballRouter()
+	eng := mock.addTeam("England")
+	ger := mock.addTeam("Germany")
+	tourn := mock.addTournament("FIFA World Cup")
+	m := mock.addMatch(models.Match{
+		Date:       time.Date(1990, 7, 4, 0, 0, 0, 0, time.UTC),
+		HomeTeamID: eng.ID, AwayTeamID: ger.ID, HomeScore: 1, AwayScore: 1,
+		TournamentID: tourn.ID, City: "Turin", Country: "Italy",
+	})
+	return r, mock, "/api/v1/football/matches/" + itoa(m.ID)
+}
+
+func TestPatchMatch_AppliesOperations(t *testing.T) {
+	r, mock, path := patchFixture()
+
+	w := doPatch(r, path, `[
+		{"op":"test","path":"/homeScore","value":1},
+		{"op":"replace","path":"/homeScore","value":4},
+		{"op":"replace","path":"/city","value":"Rome"}
+	]`, patch.MediaType, "")
+	assertStatus(t, w, http.StatusOK)
+
+	var resp models.MatchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if resp.Match.HomeScore != 4 || resp.Match.City != "Rome" || resp.Match.AwayScore != 1 {
+		t.Fatalf("unexpected match %+v", resp.Match)
+	}
+	if len(resp.Changes) != 2 {
+		t.Fatalf("expected 2 changes, got %+v", resp.Changes)
+	}
+	if got := mock.matches[0].Country; got != "Italy" {
+		t.Fatalf("untouched field changed to %q", got)
+	}
+}
+
+func TestPatchMatch_RespondsWithPatch(t *testing.T) {
+	r, _, path := patchFixture()
+
+	w := doPatch(r, path, `[{"op":"replace","path":"/awayScore","value":2}]`,
+		patch.MediaType, patch.MediaType)
+	assertStatus(t, w, http.StatusOK)
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, patch.MediaType) {
+		t.Fatalf("expected %s, got %q", patch.MediaType, ct)
+	}
+	var ops []patch.Operation
+	if err := json.Unmarshal(w.Body.Bytes(), &ops); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(ops) != 1 || ops[0].Op != "replace" || ops[0].Path != "/awayScore" || string(ops[0].Value) != "2" {
+		t.Fatalf("unexpected patch %+v", ops)
+	}
+}
+
+func TestPatchMatch_Errors(t *testing.T) {
+	cases := []struct {
+		name, body, contentType string
+		want                    int
+	}{
+		{"plain JSON", `[]`, "application/json", http.StatusUnsupportedMediaType},
+		{"malformed", `{"op":"replace"}`, patch.MediaType, http.StatusBadRequest},
+		{"failed test", `[{"op":"test","path":"/homeScore","value":9}]`, patch.MediaType, http.StatusConflict},
+		{"missing path", `[{"op":"remove","path":"/nope"}]`, patch.MediaType, http.StatusUnprocessableEntity},
+		{"unknown member", `[{"op":"add","path":"/nope","value":1}]`, patch.MediaType, http.StatusBadRequest},
+		{"fails validation", `[{"op":"replace","path":"/homeScore","value":-1}]`, patch.MediaType, http.StatusBadRequest},
+		{"unknown team", `[{"op":"replace","path":"/homeTeamId","value":999}]`, patch.MediaType, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, mock, path := patchFixture()
+			w := doPatch(r, path, tc.body, tc.contentType, "")
+			assertStatus(t, w, tc.want)
+			if mock.matches[0].HomeScore != 1 {
+				t.Fatal("rejected patch modified the match")
+			}
+		})
+	}
+}
+
+func TestPatchMatch_NotFound(t *testing.T) {
+	r, _ := newFootballRouter()
+	w := doPatch(r, "/api/v1/football/matches/999", `[]`, patch.MediaType, "")
+	assertStatus(t, w, http.StatusNotFound)
+}
+
+func TestPatchMatch_MergePatch(t *testing.T) {
+	r, mock, path := patchFixture()
+
+	w := doPatch(r, path, `{"awayScore":3,"city":null}`, patch.MergeMediaType, "")
+	assertStatus(t, w, http.StatusOK)
+
+	got := mock.matches[0]
+	if got.AwayScore != 3 || got.City != "" || got.Country != "Italy" || got.HomeScore != 1 {
+		t.Fatalf("unexpected match after merge patch: %+v", got)
+	}
+}
+
+func TestPatchMatch_MergePatchErrors(t *testing.T) {
+	cases := []struct {
+		name, body string
+	}{
+		{"malformed", `{"awayScore":`},
+		{"null required field", `{"homeTeamId":null}`},
+		{"unknown member", `{"stadium":"Delle Alpi"}`},
+		{"not an object", `[1]`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, mock, path := patchFixture()
+			w := doPatch(r, path, tc.body, patch.MergeMediaType, "")
+			assertStatus(t, w, http.StatusBadRequest)
+			if mock.matches[0].City != "Turin" {
+				t.Fatal("rejected patch modified the match")
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/classify"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/retry"
+)
+
+// SetClassifier screens team names, match venues and goal scorers with cl
+// before they are stored.  Content cl quarantines is stored and opened in
+// queue for a moderator to review; with a nil queue it is simply stored.
+func (h *FootballHandler) SetClassifier(cl classify.Classifier, queue db.ModerationRepository) {
+	h.classifier = cl
+	h.quarantineQueue = queue
+}
+
+// screenRetry is when a write refused because the classifier is down is
+// told to try again.
+const screenRetry = 5 * time.Second
+
+// screen runs the classifier over the free-text fields of a write.  It
+// writes 422 when the content is rejected, or 503 with Retry-After when it
+// could not be screened, and returns false; otherwise the caller goes ahead and passes
+// the verdict to quarantine once the content is stored.
+func (h *FootballHandler) screen(c *gin.Context, kind string, fields map[string]string) (classify.Verdict, bool) {
+	if h.classifier == nil {
+		return classify.Verdict{}, true
+	}
+	v, err := h.classifier.Classify(c.Request.Context(), classify.Content{Kind: kind, Fields: fields})
+	if err != nil {
+		log.Printf("content classifier: %v", err)
+		retry.After(c.Writer.Header(), retry.Limit{Reset: screenRetry})
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "content screening unavailable", Code: errcode.ScreeningUnavailable})
+		return classify.Verdict{}, false
+	}
+	if v.Action == classify.Reject {
+		c.JSON(http.StatusUnprocessableEntity, models.ContentRejectedResponse{
+			Error:  "content rejected",
+			Code:   errcode.ContentRejected,
+			Reason: v.Reason,
+			Field:  v.Field,
+		})
+		return v, false
+	}
+	return v, true
+}
+
+// quarantine opens stored content in the moderation queue when v asks for
+// review.  The write has already succeeded, so a failure is only logged.
+func (h *FootballHandler) quarantine(kind string, id int, v classify.Verdict) {
+	if v.Action != classify.Quarantine || h.quarantineQueue == nil {
+		return
+	}
+	if err := h.quarantineQueue.QuarantineContent(kind, id, v.Reason); err != nil {
+		log.Printf("quarantine %s %d: %v", kind, id, err)
+	}
+}
+
+// matchText returns the free-text fields of a match for screening.
+func matchText(city, country string) map[string]string {
+	return map[string]string{"city": city, "country": country}
+}
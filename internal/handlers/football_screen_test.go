@@ -0,0 +1,88 @@
+package handlers_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/classify"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/memory"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/handlers"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+type classifierFunc func(classify.Content) (classify.Verdict, error)
+
+func (f classifierFunc) Classify(_ context.Context, c classify.Content) (classify.Verdict, error) {
+	return f(c)
+}
+
+func TestFootballHandler_ContentClassifier(t *testing.T) {
+	store := memory.New()
+	repos := store.Repositories()
+	eng, _ := repos.Football.CreateTeam("England")
+	ger, _ := repos.Football.CreateTeam("Germany")
+	cup := store.Football().AddTournament("FIFA World Cup")
+
+	deny, err := classify.NewDenylist([]string{"rubbish fc"}, classify.Reject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unavailable := false
+	chain := classify.Chain{deny, classifierFunc(func(c classify.Content) (classify.Verdict, error) {
+		if unavailable {
+			return classify.Verdict{}, errors.New("down")
+		}
+		if c.Fields["city"] == "Gotham" || c.Fields["scorer"] == "Nobody" {
+			return classify.Verdict{Action: classify.Quarantine, Reason: "fictional"}, nil
+		}
+		return classify.Verdict{}, nil
+	})}
+
+	h := handlers.NewFootballHandler(repos.Football)
+	h.SetClassifier(chain, repos.Moderation)
+	r := gin.New()
+	r.POST("/teams", h.CreateTeam)
+	r.PUT("/teams/:id", h.UpdateTeam)
+	r.POST("/matches", h.CreateMatch)
+	r.POST("/matches/:id/goals", h.CreateGoal)
+
+	w := doRequest(r, http.MethodPost, "/teams", models.CreateTeamRequest{Name: "Rubbish FC!"})
+	assertStatus(t, w, http.StatusUnprocessableEntity)
+	var rejected models.ContentRejectedResponse
+	decodeJSON(t, w, &rejected)
+	if rejected.Reason != classify.ReasonDenylisted || rejected.Field != "name" {
+		t.Fatalf("unexpected rejection %+v", rejected)
+	}
+	assertStatus(t, doRequest(r, http.MethodPut, fmt.Sprintf("/teams/%d", eng.ID), models.UpdateTeamRequest{Name: "rubbish fc"}), http.StatusUnprocessableEntity)
+	if team, _ := repos.Football.GetTeamByID(eng.ID); team.Name != "England" {
+		t.Fatalf("rejected update was stored: %+v", team)
+	}
+
+	// Quarantined content is stored and queued for review.
+	w = doRequest(r, http.MethodPost, "/matches", models.CreateMatchRequest{
+		Date: time.Date(1990, 7, 4, 0, 0, 0, 0, time.UTC), HomeTeamID: eng.ID, AwayTeamID: ger.ID, TournamentID: cup.ID, City: "Gotham",
+	})
+	assertStatus(t, w, http.StatusCreated)
+	var created models.MatchResponse
+	decodeJSON(t, w, &created)
+	item, err := repos.Moderation.GetModerationItem(models.ContentMatch, created.Match.ID)
+	if err != nil || item.State != models.ModerationOpen || item.QuarantineReason != "fictional" || item.ReportCount != 0 {
+		t.Fatalf("expected the match to be quarantined, got %+v (%v)", item, err)
+	}
+
+	assertStatus(t, doRequest(r, http.MethodPost, "/teams", models.CreateTeamRequest{Name: "Rubbish Town"}), http.StatusCreated)
+	if items, _ := repos.Moderation.ListModerationItems(models.ModerationOpen, 10, 0); len(items) != 1 {
+		t.Fatalf("allowed content was queued: %+v", items)
+	}
+
+	unavailable = true
+	assertStatus(t, doRequest(r, http.MethodPost, fmt.Sprintf("/matches/%d/goals", created.Match.ID), models.CreateGoalRequest{TeamID: eng.ID, Scorer: "Lineker"}), http.StatusServiceUnavailable)
+	if goals, _ := repos.Football.GetMatchGoals(created.Match.ID); len(goals) != 0 {
+		t.Fatalf("goal stored while screening was unavailable: %+v", goals)
+	}
+}
@@ -9,7 +9,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/elo"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/retry"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/simulator"
 )
 
@@ -45,13 +47,13 @@ func (h *FootballHandler) SimulateMatch(c *gin.Context) {
 	var req models.SimulateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.Header("Cache-Control", "no-store")
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid request body: " + err.Error()})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid request body: " + err.Error(), Code: errcode.Binding(err)})
 		return
 	}
 
 	if req.HomeTeamID == req.AwayTeamID {
 		c.Header("Cache-Control", "no-store")
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "home and away teams must be different"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "home and away teams must be different", Code: errcode.SameTeams})
 		return
 	}
 
@@ -60,9 +62,10 @@ func (h *FootballHandler) SimulateMatch(c *gin.Context) {
 	if concurrencyLimiter.concurrent >= maxConcurrentSimulations {
 		concurrencyLimiter.mu.Unlock()
 		c.Header("Cache-Control", "no-store")
-		c.Header("Retry-After", "1")
+		retry.After(c.Writer.Header(), retry.Limit{Limit: maxConcurrentSimulations, Reset: time.Second})
 		c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
 			Error: "too many concurrent simulation requests; please retry shortly",
+			Code:  errcode.TooManySimulations,
 		})
 		return
 	}
@@ -79,24 +82,24 @@ func (h *FootballHandler) SimulateMatch(c *gin.Context) {
 	homeTeam, err := h.repo.GetTeamByID(req.HomeTeamID)
 	if errors.Is(err, models.ErrNotFound) {
 		c.Header("Cache-Control", "no-store")
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "home team not found"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "home team not found", Code: errcode.ReferenceNotFound})
 		return
 	}
 	if err != nil {
 		c.Header("Cache-Control", "no-store")
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
 	awayTeam, err := h.repo.GetTeamByID(req.AwayTeamID)
 	if errors.Is(err, models.ErrNotFound) {
 		c.Header("Cache-Control", "no-store")
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "away team not found"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "away team not found", Code: errcode.ReferenceNotFound})
 		return
 	}
 	if err != nil {
 		c.Header("Cache-Control", "no-store")
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
@@ -107,7 +110,7 @@ func (h *FootballHandler) SimulateMatch(c *gin.Context) {
 		parsed, parseErr := time.Parse(simulateDateLayout, dateStr)
 		if parseErr != nil {
 			c.Header("Cache-Control", "no-store")
-			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid date format; expected YYYY-MM-DD"})
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid date format; expected YYYY-MM-DD", Code: errcode.InvalidParameter})
 			return
 		}
 		asOf = parsed
@@ -134,7 +137,7 @@ func (h *FootballHandler) SimulateMatch(c *gin.Context) {
 		allMatches, err := h.repo.GetMatchesChronological(0, asOf)
 		if err != nil {
 			c.Header("Cache-Control", "no-store")
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 			return
 		}
 
@@ -154,14 +157,14 @@ func (h *FootballHandler) SimulateMatch(c *gin.Context) {
 	homeMatches, err := h.repo.GetMatchesChronological(homeTeam.ID, asOf)
 	if err != nil {
 		c.Header("Cache-Control", "no-store")
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
 	awayMatches, err := h.repo.GetMatchesChronological(awayTeam.ID, asOf)
 	if err != nil {
 		c.Header("Cache-Control", "no-store")
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
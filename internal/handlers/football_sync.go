@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// syncLag is subtracted from the snapshot time when issuing a sync cursor.
+// A write stamps updated_at with its transaction's start time but may commit
+// after a sync snapshot is taken, so the next sync re-reads this window to
+// avoid missing it.  Clients therefore occasionally see a change twice and
+// must apply changes idempotently (upsert by ID).
+const syncLag = 5 * time.Second
+
+// encodeSyncCursor returns the opaque cursor for t.
+func encodeSyncCursor(t time.Time) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(t.UTC().Format(time.RFC3339Nano)))
+}
+
+// decodeSyncCursor parses a cursor produced by encodeSyncCursor.
+func decodeSyncCursor(cursor string) (time.Time, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339Nano, string(raw))
+}
+
+// MatchChanges handles GET /api/v1/football/matches/changes
+// Returns the matches created, updated and deleted since the ?since= cursor
+// so that offline clients can sync without downloading every match again.
+// Without a cursor only nextCursor is meaningful: clients fetch it first,
+// then download /matches, then sync from the cursor.
+//
+//	@Summary		Incremental match sync
+//	@Description	List matches created, updated and deleted since a sync cursor
+//	@Tags			matches
+//	@Produce		json
+//	@Param			since	query		string							false	"Cursor returned as nextCursor by the previous sync"
+//	@Success		200		{object}	models.MatchChangesResponse		"Changes since the cursor"
+//	@Failure		400		{object}	models.ErrorResponse			"Malformed cursor"
+//	@Failure		410		{object}	models.ErrorResponse			"Cursor older than the tombstone retention period"
+//	@Failure		500		{object}	models.ErrorResponse			"Internal server error"
+//	@Router			/football/matches/changes [get]
+func (h *FootballHandler) MatchChanges(c *gin.Context) {
+	// Each sync must see the latest state, never a cached one.
+	c.Header("Cache-Control", "no-store")
+
+	since := time.Now()
+	if v := c.Query("since"); v != "" {
+		t, err := decodeSyncCursor(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "since must be a cursor returned by a previous sync", Code: errcode.InvalidParameter})
+			return
+		}
+		if time.Since(t) > db.TombstoneRetention {
+			c.JSON(http.StatusGone, models.ErrorResponse{
+				Error: "sync cursor has expired; download /matches again and sync from a new cursor",
+				Code:  errcode.CursorExpired,
+			})
+			return
+		}
+		since = t
+	}
+
+	changes, err := h.repo.MatchChangesSince(since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+
+	resp := models.MatchChangesResponse{
+		Created:    []models.MatchResponse{},
+		Updated:    []models.MatchResponse{},
+		Deleted:    changes.Deleted,
+		NextCursor: encodeSyncCursor(changes.AsOf.Add(-syncLag)),
+	}
+	if resp.Deleted == nil {
+		resp.Deleted = []models.Tombstone{}
+	}
+	for _, m := range changes.Changed {
+		mr := models.MatchResponse{Match: m, Links: matchLinks(m.ID)}
+		if m.CreatedAt.Before(since) {
+			resp.Updated = append(resp.Updated, mr)
+		} else {
+			resp.Created = append(resp.Created, mr)
+		}
+	}
+	resp.Links = []models.Link{
+		{Rel: "self", Href: c.Request.URL.RequestURI(), Method: http.MethodGet},
+		{Rel: "next", Href: "/api/v1/football/matches/changes?since=" + url.QueryEscape(resp.NextCursor), Method: http.MethodGet},
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,91 @@
+package handlers_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// syncChanges calls the changes endpoint with cursor (none when empty).
+func syncChanges(t *testing.T, r *gin.Engine, cursor string) models.MatchChangesResponse {
+	t.Helper()
+	path := "/api/v1/football/matches/changes"
+	if cursor != "" {
+		path += "?since=" + url.QueryEscape(cursor)
+	}
+	w := doRequest(r, http.MethodGet, path, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("expected Cache-Control no-store, got %q", got)
+	}
+	var resp models.MatchChangesResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	return resp
+}
+
+func TestMatchChanges_Bootstrap(t *testing.T) {
+	r, _ := newFootballRouter()
+	resp := syncChanges(t, r, "")
+	if resp.NextCursor == "" {
+		t.Fatal("expected a cursor")
+	}
+	if resp.Created == nil || resp.Updated == nil || resp.Deleted == nil {
+		t.Fatal("expected non-nil change lists")
+	}
+}
+
+func TestMatchChanges_SinceCursor(t *testing.T) {
+	r, mock := newFootballRouter()
+	old := time.Now().Add(-time.Hour)
+	mock.addMatch(models.Match{CreatedAt: old, UpdatedAt: old})
+	updated := mock.addMatch(models.Match{CreatedAt: old, UpdatedAt: old})
+	doomed := mock.addMatch(models.Match{CreatedAt: old, UpdatedAt: old})
+
+	cursor := syncChanges(t, r, "").NextCursor
+
+	now := time.Now()
+	created := mock.addMatch(models.Match{CreatedAt: now, UpdatedAt: now})
+	mock.matches[updated.ID-1].UpdatedAt = now
+	if w := doRequest(r, http.MethodDelete, "/api/v1/football/matches/3", nil); w.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d", w.Code)
+	}
+
+	resp := syncChanges(t, r, cursor)
+	if len(resp.Created) != 1 || resp.Created[0].ID != created.ID {
+		t.Errorf("expected match %d created, got %+v", created.ID, resp.Created)
+	}
+	if len(resp.Updated) != 1 || resp.Updated[0].ID != updated.ID {
+		t.Errorf("expected match %d updated, got %+v", updated.ID, resp.Updated)
+	}
+	if len(resp.Deleted) != 1 || resp.Deleted[0].ID != doomed.ID {
+		t.Errorf("expected match %d deleted, got %+v", doomed.ID, resp.Deleted)
+	}
+}
+
+func TestMatchChanges_BadCursor(t *testing.T) {
+	r, _ := newFootballRouter()
+	w := doRequest(r, http.MethodGet, "/api/v1/football/matches/changes?since=not-a-cursor!", nil)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestMatchChanges_ExpiredCursor(t *testing.T) {
+	r, _ := newFootballRouter()
+	stale := time.Now().AddDate(0, -6, 0).UTC().Format(time.RFC3339Nano)
+	cursor := base64.RawURLEncoding.EncodeToString([]byte(stale))
+	w := doRequest(r, http.MethodGet, "/api/v1/football/matches/changes?since="+cursor, nil)
+	if w.Code != http.StatusGone {
+		t.Fatalf("expected 410, got %d", w.Code)
+	}
+}
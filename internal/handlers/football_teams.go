@@ -6,36 +6,53 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/sanitize"
 )
 
 // --- Teams (read) ------------------------------------------------------------
 
 // ListTeams handles GET /api/v1/football/teams
-// Returns all national teams with HATEOAS links.
+// Returns all national teams with HATEOAS links.  Optional ?createdAfter= and
+// ?updatedSince= RFC 3339 timestamps restrict the list for incremental sync;
+// ?asOf= lists the teams as they were at that time instead.
 //
 //	@Summary		List all teams
 //	@Description	Get all national teams with HATEOAS links
 //	@Tags			teams
 //	@Produce		json
-//	@Success		200	{object}	models.TeamsResponse	"List of teams"
-//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Param			createdAfter	query		string					false	"Only teams created after this RFC 3339 time"
+//	@Param			updatedSince	query		string					false	"Only teams updated at or after this RFC 3339 time"
+//	@Param			asOf			query		string					false	"List the teams as they were at this RFC 3339 time"
+//	@Success		200				{object}	models.TeamsResponse	"List of teams"
+//	@Failure		400				{object}	models.ErrorResponse	"Invalid query parameters"
+//	@Failure		422				{object}	models.ErrorResponse	"asOf before the revision history began"
+//	@Failure		500				{object}	models.ErrorResponse	"Internal server error"
 //	@Router			/football/teams [get]
 func (h *FootballHandler) ListTeams(c *gin.Context) {
-	teams, err := h.repo.ListTeams()
+	f, ok := timeFilter(c)
+	if !ok {
+		return
+	}
+
+	teams, err := h.repo.ListTeams(f)
+	if errors.Is(err, models.ErrNoHistory) {
+		noHistory(c)
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
 	responses := make([]models.TeamResponse, 0, len(teams))
 	for _, t := range teams {
-		responses = append(responses, models.TeamResponse{
-			Team:  t,
-			Links: teamLinks(t.ID),
-		})
+		responses = append(responses, localizeTeam(c, t))
 	}
 
+	c.Writer.Header().Add("Vary", "Accept-Language")
 	c.JSON(http.StatusOK, models.TeamsResponse{
 		Data: responses,
 		Links: []models.Link{
@@ -45,39 +62,92 @@ func (h *FootballHandler) ListTeams(c *gin.Context) {
 }
 
 // GetTeam handles GET /api/v1/football/teams/:id
-// Returns the requested team or 404 if it does not exist.
+// Returns the requested team or 404 if it does not exist.  With ?asOf= it
+// returns the team as it was at that time, or 404 if it did not exist then;
+// a merged team is not redirected, as it may have existed at that time.
 //
 //	@Summary		Get a team by ID
 //	@Description	Get detailed information about a specific team
 //	@Tags			teams
 //	@Produce		json
-//	@Param			id	path		int						true	"Team ID"
-//	@Success		200	{object}	models.TeamResponse		"Team details"
-//	@Failure		400	{object}	models.ErrorResponse	"Invalid team ID"
-//	@Failure		404	{object}	models.ErrorResponse	"Team not found"
-//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Param			id		path		int						true	"Team ID"
+//	@Param			asOf	query		string					false	"Return the team as it was at this RFC 3339 time"
+//	@Success		200		{object}	models.TeamResponse		"Team details"
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid team ID or asOf"
+//	@Failure		404		{object}	models.ErrorResponse	"Team not found"
+//	@Failure		422		{object}	models.ErrorResponse	"asOf before the revision history began"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
 //	@Router			/football/teams/{id} [get]
 func (h *FootballHandler) GetTeam(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid team id"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid team id", Code: errcode.InvalidID})
+		return
+	}
+	asOf, ok := queryTime(c, "asOf")
+	if !ok {
 		return
 	}
 
-	team, err := h.repo.GetTeamByID(id)
+	var team models.Team
+	if asOf != nil {
+		team, err = h.repo.GetTeamAsOf(id, *asOf)
+		switch {
+		case errors.Is(err, models.ErrNotFound):
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "team did not exist at asOf", Code: errcode.TeamNotFound})
+			return
+		case errors.Is(err, models.ErrNoHistory):
+			noHistory(c)
+			return
+		}
+	} else {
+		team, err = h.repo.GetTeamByID(id)
+	}
 	if errors.Is(err, models.ErrNotFound) {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "team not found"})
+		h.teamNotFound(c, id)
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
-	c.JSON(http.StatusOK, models.TeamResponse{
-		Team:  team,
-		Links: teamLinks(team.ID),
-	})
+	setLastModified(c, team.UpdatedAt)
+	resp := localizeTeam(c, team)
+	varyLanguage(c, resp)
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetTeamBySlug handles GET /api/v1/football/teams/slug/:slug
+// Returns the team with the given slug, as GET /teams/:id does for its ID.
+// Slugs follow renames, so a link by slug breaks when the team is renamed;
+// the self link by ID does not.
+//
+//	@Summary		Get a team by slug
+//	@Description	Get a team by the URL-friendly slug derived from its name
+//	@Tags			teams
+//	@Produce		json
+//	@Param			slug	path		string					true	"Team slug"	example(cote-d-ivoire)
+//	@Success		200		{object}	models.TeamResponse		"Team details"
+//	@Failure		404		{object}	models.ErrorResponse	"Team not found"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Router			/football/teams/slug/{slug} [get]
+func (h *FootballHandler) GetTeamBySlug(c *gin.Context) {
+	team, err := h.repo.GetTeamBySlug(c.Param("slug"))
+	if errors.Is(err, models.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "team not found", Code: errcode.TeamNotFound})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+
+	setLastModified(c, team.UpdatedAt)
+	c.Header("Content-Location", "/api/v1/football/teams/"+strconv.Itoa(team.ID))
+	resp := localizeTeam(c, team)
+	varyLanguage(c, resp)
+	c.JSON(http.StatusOK, resp)
 }
 
 // GetTeamHistory handles GET /api/v1/football/teams/:id/history
@@ -96,22 +166,22 @@ func (h *FootballHandler) GetTeam(c *gin.Context) {
 func (h *FootballHandler) GetTeamHistory(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid team id"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid team id", Code: errcode.InvalidID})
 		return
 	}
 
 	// Verify the team exists first.
 	if _, err := h.repo.GetTeamByID(id); errors.Is(err, models.ErrNotFound) {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "team not found"})
+		h.teamNotFound(c, id)
 		return
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
 	history, err := h.repo.GetTeamHistory(id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 	if history == nil {
@@ -140,36 +210,46 @@ func (h *FootballHandler) GetTeamHistory(c *gin.Context) {
 //	@Success		201		{object}	models.TeamResponse			"Team created"
 //	@Failure		400		{object}	models.ErrorResponse		"Invalid request"
 //	@Failure		401		{object}	models.ErrorResponse		"Unauthorized"
-//	@Failure		409		{object}	models.ErrorResponse		"Team already exists"
+//	@Failure		409		{object}	models.ConflictResponse		"Team already exists (current holds the existing team)"
+//	@Failure		422		{object}	models.ContentRejectedResponse	"Content rejected by the classifier"
 //	@Failure		500		{object}	models.ErrorResponse		"Internal server error"
+//	@Failure		503		{object}	models.ErrorResponse		"Content classifier unavailable"
 //	@Security		Bearer
 //	@Router			/football/teams [post]
 func (h *FootballHandler) CreateTeam(c *gin.Context) {
 	var req models.CreateTeamRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+	if err := c.ShouldBindWith(&req, sanitize.JSON); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: errcode.Binding(err)})
+		return
+	}
+
+	verdict, ok := h.screen(c, models.ContentTeam, map[string]string{"name": req.Name})
+	if !ok {
 		return
 	}
 
 	team, err := h.repo.CreateTeam(req.Name)
 	if errors.Is(err, models.ErrConflict) {
-		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "team already exists"})
+		h.teamConflict(c, "team already exists", req.Name)
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
+	h.quarantine(models.ContentTeam, team.ID, verdict)
+	publish(c, h.events, events.TeamCreated, strconv.Itoa(team.ID), team)
 	c.Header("Location", "/api/v1/football/teams/"+strconv.Itoa(team.ID))
 	c.JSON(http.StatusCreated, models.TeamResponse{
 		Team:  team,
-		Links: teamLinks(team.ID),
+		Links: teamLinks(team),
 	})
 }
 
 // UpdateTeam handles PUT /api/v1/football/teams/:id
 // Replaces the name of an existing team. Requires JWT authorisation.
+// The response lists the fields that changed with their old and new values.
 //
 //	@Summary		Update a team
 //	@Description	Update team name (requires authentication)
@@ -182,72 +262,156 @@ func (h *FootballHandler) CreateTeam(c *gin.Context) {
 //	@Failure		400		{object}	models.ErrorResponse		"Invalid request"
 //	@Failure		401		{object}	models.ErrorResponse		"Unauthorized"
 //	@Failure		404		{object}	models.ErrorResponse		"Team not found"
-//	@Failure		409		{object}	models.ErrorResponse		"Team name already in use"
+//	@Failure		409		{object}	models.ConflictResponse		"Team name already in use (current holds the existing team)"
+//	@Failure		422		{object}	models.ContentRejectedResponse	"Content rejected by the classifier"
 //	@Failure		500		{object}	models.ErrorResponse		"Internal server error"
+//	@Failure		503		{object}	models.ErrorResponse		"Content classifier unavailable"
 //	@Security		Bearer
 //	@Router			/football/teams/{id} [put]
 func (h *FootballHandler) UpdateTeam(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid team id"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid team id", Code: errcode.InvalidID})
 		return
 	}
 
 	var req models.UpdateTeamRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+	if err := c.ShouldBindWith(&req, sanitize.JSON); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: errcode.Binding(err)})
+		return
+	}
+
+	before, err := h.repo.GetTeamByID(id)
+	if errors.Is(err, models.ErrNotFound) {
+		h.teamNotFound(c, id)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+
+	verdict, ok := h.screen(c, models.ContentTeam, map[string]string{"name": req.Name})
+	if !ok {
 		return
 	}
 
 	team, err := h.repo.UpdateTeam(id, req.Name)
 	if errors.Is(err, models.ErrNotFound) {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "team not found"})
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "team not found", Code: errcode.TeamNotFound})
 		return
 	}
 	if errors.Is(err, models.ErrConflict) {
-		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "team name already in use"})
+		h.teamConflict(c, "team name already in use", req.Name)
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
+	h.quarantine(models.ContentTeam, team.ID, verdict)
+	publishUpdate(c, h.events, events.TeamUpdated, strconv.Itoa(team.ID), before, team)
 	c.JSON(http.StatusOK, models.TeamResponse{
-		Team:  team,
-		Links: teamLinks(team.ID),
+		Team:    team,
+		Changes: fieldChanges(before, team),
+		Links:   teamLinks(team),
 	})
 }
 
 // DeleteTeam handles DELETE /api/v1/football/teams/:id
-// Removes a team. Requires JWT authorisation.
+// Removes a team. Requires JWT authorisation.  A team that does not exist
+// is answered as the DeleteOptions say.
 //
 //	@Summary		Delete a team
 //	@Description	Delete a team by ID (requires authentication)
 //	@Tags			teams
 //	@Produce		json
 //	@Param			id	path	int	true	"Team ID"
-//	@Success		204	"Team deleted successfully"
+//	@Success		204	"Team deleted successfully, or already absent where deletes are idempotent"
 //	@Failure		400	{object}	models.ErrorResponse	"Invalid team ID"
 //	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
 //	@Failure		404	{object}	models.ErrorResponse	"Team not found"
+//	@Failure		410	{object}	models.GoneResponse		"Team already deleted, where tombstones are kept"
 //	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
 //	@Security		Bearer
 //	@Router			/football/teams/{id} [delete]
 func (h *FootballHandler) DeleteTeam(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid team id"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid team id", Code: errcode.InvalidID})
 		return
 	}
 
 	if err := h.repo.DeleteTeam(id); errors.Is(err, models.ErrNotFound) {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "team not found"})
+		h.deleteMissing(c, "team", id, h.repo.TeamTombstone, func() { h.teamNotFound(c, id) })
 		return
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error"})
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
 		return
 	}
 
+	publish(c, h.events, events.TeamDeleted, strconv.Itoa(id), nil)
 	c.Status(http.StatusNoContent)
 }
+
+// MergeTeam handles POST /api/v1/football/teams/:id/merge-into/:target
+// Folds a duplicate team into another: its matches, goals, shootouts and
+// former names move to target, its name becomes one of target's former
+// names, and it is deleted.  Requests for its ID are then redirected to
+// target with 308 Permanent Redirect, so existing links keep working.
+// Requires JWT authorisation.
+//
+//	@Summary		Merge a duplicate team into another
+//	@Description	Move a team's matches, goals and history to another team and redirect its ID there (requires authentication)
+//	@Tags			teams
+//	@Produce		json
+//	@Param			id		path		int						true	"ID of the team to merge away"
+//	@Param			target	path		int						true	"ID of the surviving team"
+//	@Success		200		{object}	models.TeamResponse		"The surviving team"
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid team IDs"
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		404		{object}	models.ErrorResponse	"Team not found"
+//	@Failure		409		{object}	models.ErrorResponse	"The teams played each other or share a fixture"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/football/teams/{id}/merge-into/{target} [post]
+func (h *FootballHandler) MergeTeam(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid team id", Code: errcode.InvalidID})
+		return
+	}
+	target, err := strconv.Atoi(c.Param("target"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid target team id", Code: errcode.InvalidID})
+		return
+	}
+	if id == target {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "a team cannot be merged into itself", Code: errcode.SameTeams})
+		return
+	}
+
+	before := h.teamBefore(target)
+	team, err := h.repo.MergeTeam(id, target)
+	if errors.Is(err, models.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "team not found", Code: errcode.TeamNotFound})
+		return
+	}
+	if errors.Is(err, models.ErrConflict) {
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "the teams played each other or share a fixture; resolve the matches first", Code: errcode.TeamsShareFixture})
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+
+	publish(c, h.events, events.TeamMerged, strconv.Itoa(id), team)
+	publishUpdate(c, h.events, events.TeamUpdated, strconv.Itoa(team.ID), before, team)
+	c.JSON(http.StatusOK, models.TeamResponse{
+		Team:  team,
+		Links: teamLinks(team),
+	})
+}
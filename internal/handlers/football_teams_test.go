@@ -1,11 +1,21 @@
 package handlers_test
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"strings"
 	"testing"
-
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/memory"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/handlers"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/pkg/repository/fake"
 )
 
 // --- ListTeams ---------------------------------------------------------------
@@ -51,6 +61,35 @@ func TestListTeams_WithData(t *testing.T) {
 	}
 }
 
+func TestListTeams_UpdatedSince(t *testing.T) {
+	r, mock := newFootballRouter()
+	old := mock.addTeam("England")
+	recent := mock.addTeam("Brazil")
+	mock.teams[old.ID-1].UpdatedAt = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.teams[recent.ID-1].UpdatedAt = time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	w := doRequest(r, http.MethodGet, "/api/v1/football/teams?updatedSince=2025-01-01T00:00:00%2B01:00", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var resp models.TeamsResponse
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	if len(resp.Data) != 1 || resp.Data[0].Name != "Brazil" {
+		t.Fatalf("expected only Brazil, got %+v", resp.Data)
+	}
+}
+
+func TestListTeams_InvalidTimeFilter(t *testing.T) {
+	r, _ := newFootballRouter()
+	for _, q := range []string{"createdAfter=yesterday", "updatedSince=2025-01-01"} {
+		w := doRequest(r, http.MethodGet, "/api/v1/football/teams?"+q, nil)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", q, w.Code)
+		}
+	}
+}
+
 // --- GetTeam -----------------------------------------------------------------
 
 func TestGetTeam_NotFound(t *testing.T) {
@@ -60,6 +99,7 @@ func TestGetTeam_NotFound(t *testing.T) {
 	if w.Code != http.StatusNotFound {
 		t.Fatalf("expected 404, got %d", w.Code)
 	}
+	assertCode(t, w, errcode.TeamNotFound)
 }
 
 func TestGetTeam_Success(t *testing.T) {
@@ -78,6 +118,17 @@ func TestGetTeam_Success(t *testing.T) {
 	}
 }
 
+func TestGetTeam_LastModified(t *testing.T) {
+	r, mock := newFootballRouter()
+	team := mock.addTeam("Germany")
+	mock.teams[0].UpdatedAt = time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	w := doRequest(r, http.MethodGet, "/api/v1/football/teams/"+itoa(team.ID), nil)
+	if got, want := w.Header().Get("Last-Modified"), "Fri, 01 Mar 2024 09:30:00 GMT"; got != want {
+		t.Fatalf("Last-Modified = %q, want %q", got, want)
+	}
+}
+
 func TestGetTeam_InvalidID(t *testing.T) {
 	r, _ := newFootballRouter()
 	w := doRequest(r, http.MethodGet, "/api/v1/football/teams/abc", nil)
@@ -146,6 +197,44 @@ func TestCreateTeam_MissingName(t *testing.T) {
 	if w.Code != http.StatusBadRequest {
 		t.Fatalf("expected 400, got %d", w.Code)
 	}
+	assertCode(t, w, errcode.FieldRequired)
+}
+
+func TestCreateTeam_NameTooLong(t *testing.T) {
+	r, _ := newFootballRouter()
+	w := doRequest(r, http.MethodPost, "/api/v1/football/teams", map[string]string{"name": strings.Repeat("x", 101)})
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+	assertCode(t, w, errcode.FieldTooLong)
+}
+
+func TestCreateTeam_NormalisesName(t *testing.T) {
+	r, _ := newFootballRouter()
+	// "Curaçao" with a combining cedilla, padded: 100 characters once
+	// composed, though 101 code points as sent.
+	name := "  Cura\u0063\u0327ao" + strings.Repeat("x", 93) + " "
+	w := doRequest(r, http.MethodPost, "/api/v1/football/teams", map[string]string{"name": name})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp models.TeamResponse
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	if want := "Cura\u00e7ao" + strings.Repeat("x", 93); resp.Name != want {
+		t.Fatalf("expected NFC name %q, got %q", want, resp.Name)
+	}
+}
+
+func TestCreateTeam_RejectsInvisibleCharacters(t *testing.T) {
+	r, _ := newFootballRouter()
+	for _, name := range []string{"Bra\u200bzil", "\u202eliztarB", "Ita\u0000ly"} {
+		w := doRequest(r, http.MethodPost, "/api/v1/football/teams", map[string]string{"name": name})
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%q: expected 400, got %d", name, w.Code)
+		}
+		assertCode(t, w, errcode.InvisibleCharacters)
+	}
 }
 
 func TestCreateTeam_Conflict(t *testing.T) {
@@ -159,6 +248,19 @@ func TestCreateTeam_Conflict(t *testing.T) {
 	if w.Code != http.StatusConflict {
 		t.Fatalf("expected 409, got %d", w.Code)
 	}
+
+	var resp struct {
+		Error   string              `json:"error"`
+		Code    string              `json:"code"`
+		Current models.TeamResponse `json:"current"`
+	}
+	decodeJSON(t, w, &resp)
+	if resp.Code != errcode.TeamExists {
+		t.Errorf("expected code %s, got %q", errcode.TeamExists, resp.Code)
+	}
+	if resp.Current.Name != "Italy" || resp.Current.ID == 0 {
+		t.Fatalf("expected current server copy of Italy, got %+v", resp.Current)
+	}
 }
 
 // --- UpdateTeam --------------------------------------------------------------
@@ -180,6 +282,10 @@ func TestUpdateTeam_Success(t *testing.T) {
 	if resp.Name != "Germany" {
 		t.Fatalf("expected name 'Germany', got %q", resp.Name)
 	}
+	if len(resp.Changes) != 1 || resp.Changes[0].Field != "name" ||
+		resp.Changes[0].Old != "West Germany" || resp.Changes[0].New != "Germany" {
+		t.Fatalf("expected name change West Germany -> Germany, got %+v", resp.Changes)
+	}
 }
 
 func TestUpdateTeam_NotFound(t *testing.T) {
@@ -224,3 +330,208 @@ func TestDeleteTeam_NotFound(t *testing.T) {
 		t.Fatalf("expected 404, got %d", w.Code)
 	}
 }
+
+func TestDeleteTeam_Tombstone(t *testing.T) {
+	r, mock := newDeleteRouter(handlers.DeleteOptions{Tombstones: true})
+	team := mock.addTeam("Yugoslavia")
+
+	doRequest(r, http.MethodDelete, "/api/v1/football/teams/"+itoa(team.ID), nil)
+	w := doRequest(r, http.MethodDelete, "/api/v1/football/teams/"+itoa(team.ID), nil)
+	if w.Code != http.StatusGone {
+		t.Fatalf("expected 410, got %d", w.Code)
+	}
+	if w := doRequest(r, http.MethodDelete, "/api/v1/football/teams/999", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown ID, got %d", w.Code)
+	}
+}
+
+func TestCreateTeam_PublishesEvent(t *testing.T) {
+	mock := &footballMock{}
+	fh := handlers.NewFootballHandler(mock)
+	bus := events.NewBus(nil)
+	var got []events.Event
+	bus.Subscribe(events.BeforeResponse, events.SubscriberFunc(func(_ context.Context, e events.Event) error {
+		got = append(got, e)
+		return nil
+	}))
+	fh.SetEvents(bus)
+
+	r := gin.New()
+	r.POST("/teams", fh.CreateTeam)
+	r.PUT("/teams/:id", fh.UpdateTeam)
+	r.DELETE("/teams/:id", fh.DeleteTeam)
+
+	w := doRequest(r, http.MethodPost, "/teams", map[string]string{"name": "Brazil"})
+	assertStatus(t, w, http.StatusCreated)
+	w = doRequest(r, http.MethodPut, "/teams/1", map[string]string{"name": "Brasil"})
+	assertStatus(t, w, http.StatusOK)
+	w = doRequest(r, http.MethodDelete, "/teams/1", nil)
+	assertStatus(t, w, http.StatusNoContent)
+
+	if len(got) != 3 || got[0].Type != events.TeamCreated || got[1].Type != events.TeamUpdated || got[2].Type != events.TeamDeleted {
+		t.Fatalf("unexpected events %+v", got)
+	}
+	if team, ok := got[0].Data.(models.Team); !ok || team.Name != "Brazil" || got[0].ID != "1" {
+		t.Fatalf("unexpected created event %+v", got[0])
+	}
+	update, ok := got[1].Data.(events.Update)
+	if !ok || update.Resource.(models.Team).Name != "Brasil" {
+		t.Fatalf("unexpected updated event %+v", got[1])
+	}
+	var renamed bool
+	for _, ch := range update.Changes {
+		renamed = renamed || ch.Field == "name" && ch.Old == "Brazil" && ch.New == "Brasil"
+	}
+	if !renamed {
+		t.Fatalf("expected the rename among the changes, got %+v", update.Changes)
+	}
+}
+
+func TestGetTeamBySlug(t *testing.T) {
+	r, mock := newFootballRouter()
+	team := mock.addTeam("C\u00f4te d'Ivoire")
+
+	w := doRequest(r, http.MethodGet, "/api/v1/football/teams/slug/cote-d-ivoire", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp models.TeamResponse
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	if resp.ID != team.ID || resp.Slug != "cote-d-ivoire" {
+		t.Fatalf("expected team %d with slug cote-d-ivoire, got %+v", team.ID, resp.Team)
+	}
+	if got := w.Header().Get("Content-Location"); got != "/api/v1/football/teams/1" {
+		t.Fatalf("expected Content-Location of the team by ID, got %q", got)
+	}
+	var alternate bool
+	for _, l := range resp.Links {
+		alternate = alternate || l.Rel == "alternate" && l.Href == "/api/v1/football/teams/slug/cote-d-ivoire"
+	}
+	if !alternate {
+		t.Fatalf("expected an alternate link by slug, got %+v", resp.Links)
+	}
+
+	w = doRequest(r, http.MethodGet, "/api/v1/football/teams/slug/atlantis", nil)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestMergeTeam_RedirectsOldID(t *testing.T) {
+	r, mock := newFootballRouter()
+	zaire := mock.addTeam("Zaire")
+	drc := mock.addTeam("DR Congo")
+	other := mock.addTeam("Ghana")
+	mock.addMatch(models.Match{HomeTeamID: zaire.ID, AwayTeamID: other.ID})
+
+	w := doRequest(r, http.MethodPost, "/api/v1/football/teams/1/merge-into/2", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp models.TeamResponse
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	if resp.ID != drc.ID {
+		t.Fatalf("expected the surviving team %d, got %+v", drc.ID, resp.Team)
+	}
+	if mock.matches[0].HomeTeamID != drc.ID {
+		t.Fatalf("expected the match to move to team %d, got %+v", drc.ID, mock.matches[0])
+	}
+
+	for path, want := range map[string]string{
+		"/api/v1/football/teams/1":                   "/api/v1/football/teams/2",
+		"/api/v1/football/teams/1/history?full=true": "/api/v1/football/teams/2/history?full=true",
+	} {
+		w = doRequest(r, http.MethodGet, path, nil)
+		if w.Code != http.StatusPermanentRedirect {
+			t.Fatalf("GET %s: expected 308, got %d", path, w.Code)
+		}
+		if got := w.Header().Get("Location"); got != want {
+			t.Fatalf("GET %s: Location = %q, want %q", path, got, want)
+		}
+	}
+
+	if w = doRequest(r, http.MethodGet, "/api/v1/football/teams/99", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown team: expected 404, got %d", w.Code)
+	}
+}
+
+func TestMergeTeam_Errors(t *testing.T) {
+	r, mock := newFootballRouter()
+	a := mock.addTeam("England")
+	b := mock.addTeam("Scotland")
+	mock.addMatch(models.Match{HomeTeamID: a.ID, AwayTeamID: b.ID})
+
+	for path, want := range map[string]int{
+		"/api/v1/football/teams/1/merge-into/1":  http.StatusBadRequest,
+		"/api/v1/football/teams/x/merge-into/2":  http.StatusBadRequest,
+		"/api/v1/football/teams/1/merge-into/99": http.StatusNotFound,
+		"/api/v1/football/teams/1/merge-into/2":  http.StatusConflict,
+	} {
+		if w := doRequest(r, http.MethodPost, path, nil); w.Code != want {
+			t.Errorf("POST %s: expected %d, got %d", path, want, w.Code)
+		}
+	}
+}
+
+// --- As-of reads -------------------------------------------------------------
+
+func TestTeamsAndMatches_AsOf(t *testing.T) {
+	store := memory.New()
+	clk := clock.NewFake(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC))
+	store.SetClock(clk)
+	repo := store.Football()
+	cup := repo.AddTournament("UEFA Euro")
+	fed, _ := repo.CreateTeam("West Germany")
+	eng, _ := repo.CreateTeam("England")
+	match, _ := repo.CreateMatch(models.Match{Date: clk.Now(), HomeTeamID: fed.ID, AwayTeamID: eng.ID, TournamentID: cup.ID})
+	before := clk.Now().Format(time.RFC3339)
+	clk.Advance(time.Hour)
+	_, _ = repo.UpdateTeam(fed.ID, "Germany")
+
+	h := handlers.NewFootballHandler(repo)
+	r := gin.New()
+	r.GET("/teams", h.ListTeams)
+	r.GET("/teams/:id", h.GetTeam)
+	r.GET("/matches/:id", h.GetMatch)
+
+	w := doRequest(r, http.MethodGet, "/teams/"+itoa(fed.ID)+"?asOf="+before, nil)
+	var team models.TeamResponse
+	_ = json.NewDecoder(w.Body).Decode(&team)
+	if w.Code != http.StatusOK || team.Name != "West Germany" {
+		t.Fatalf("expected West Germany, got %d %+v", w.Code, team)
+	}
+
+	w = doRequest(r, http.MethodGet, "/matches/"+itoa(match.ID)+"?asOf="+before, nil)
+	var m models.MatchResponse
+	_ = json.NewDecoder(w.Body).Decode(&m)
+	if w.Code != http.StatusOK || m.HomeTeam != "West Germany" {
+		t.Fatalf("expected the match with the old name, got %d %+v", w.Code, m)
+	}
+
+	w = doRequest(r, http.MethodGet, "/teams?asOf=2024-01-01T00:00:00Z", nil)
+	var teams models.TeamsResponse
+	_ = json.NewDecoder(w.Body).Decode(&teams)
+	if w.Code != http.StatusOK || len(teams.Data) != 0 {
+		t.Fatalf("expected no teams before any existed, got %d %+v", w.Code, teams.Data)
+	}
+
+	if w := doRequest(r, http.MethodGet, "/teams/"+itoa(fed.ID)+"?asOf=2024-01-01T00:00:00Z", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 before the team existed, got %d", w.Code)
+	}
+	if w := doRequest(r, http.MethodGet, "/teams/"+itoa(fed.ID)+"?asOf=yesterday", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a malformed asOf, got %d", w.Code)
+	}
+}
+
+func TestGetTeam_AsOfBeforeHistory(t *testing.T) {
+	repo := &fake.Football{
+		GetTeamAsOfFunc: func(int, time.Time) (models.Team, error) { return models.Team{}, models.ErrNoHistory },
+	}
+	r := gin.New()
+	r.GET("/teams/:id", handlers.NewFootballHandler(repo).GetTeam)
+
+	w := doRequest(r, http.MethodGet, "/teams/1?asOf=2000-01-01T00:00:00Z", nil)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", w.Code)
+	}
+}
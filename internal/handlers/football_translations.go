@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/sanitize"
+	"golang.org/x/text/language"
+)
+
+// teamNameLanguage is the language of teams' own names, which the
+// football data gives in English.  A client preferring it, or a language
+// no translation matches, gets the team's own name.
+var teamNameLanguage = language.English
+
+// localizeTeam returns t's response with Name in the language that best
+// matches the request's Accept-Language, falling back along the tag's
+// chain ("de-AT" to "de") and finally to the team's own name.
+func localizeTeam(c *gin.Context, t models.Team) models.TeamResponse {
+	resp := models.TeamResponse{Team: t, Links: teamLinks(t)}
+	if lang := translationFor(c.GetHeader("Accept-Language"), t.Translations); lang != "" {
+		resp.Name, resp.Language = t.Translations[lang], lang
+	}
+	return resp
+}
+
+// translationFor returns the key of the translation that best matches
+// accept, or "" if the team's own name matches as well or no translation
+// matches confidently.
+func translationFor(accept string, translations map[string]string) string {
+	if accept == "" || len(translations) == 0 {
+		return ""
+	}
+	prefs, _, err := language.ParseAcceptLanguage(accept)
+	if err != nil || len(prefs) == 0 {
+		return ""
+	}
+	keys := slices.Sorted(maps.Keys(translations))
+	tags := make([]language.Tag, 0, len(keys)+1)
+	tags = append(tags, teamNameLanguage)
+	for _, k := range keys {
+		tags = append(tags, language.Make(k))
+	}
+	// Low-confidence matches, such as Simplified for Traditional Chinese,
+	// are worse than the team's own name.
+	_, i, conf := language.NewMatcher(tags).Match(prefs...)
+	if i == 0 || conf < language.High {
+		return ""
+	}
+	return keys[i-1]
+}
+
+// varyLanguage marks a response as chosen by Accept-Language, and names the
+// language of a single team's representation.
+func varyLanguage(c *gin.Context, resp models.TeamResponse) {
+	c.Writer.Header().Add("Vary", "Accept-Language")
+	if resp.Language != "" {
+		c.Header("Content-Language", resp.Language)
+	}
+}
+
+func translationsResponse(t models.Team) models.TeamTranslationsResponse {
+	base := "/api/v1/football/teams/" + strconv.Itoa(t.ID)
+	data := t.Translations
+	if data == nil {
+		data = map[string]string{}
+	}
+	return models.TeamTranslationsResponse{
+		Data: data,
+		Links: []models.Link{
+			{Rel: "self", Href: base + "/translations", Method: http.MethodGet},
+			{Rel: "team", Href: base, Method: http.MethodGet},
+		},
+	}
+}
+
+// teamLanguage parses the :lang parameter, returning its canonical form or
+// writing a 400.
+func teamLanguage(c *gin.Context) (string, bool) {
+	tag, err := language.Parse(c.Param("lang"))
+	if err != nil || tag == language.Und {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "lang must be a BCP 47 language tag such as fr or pt-BR", Code: errcode.InvalidParameter})
+		return "", false
+	}
+	return tag.String(), true
+}
+
+// GetTeamTranslations handles GET /api/v1/football/teams/:id/translations
+//
+//	@Summary		List a team's translated names
+//	@Description	The team's name in other languages, keyed by BCP 47 tag
+//	@Tags			teams
+//	@Produce		json
+//	@Param			id	path		int								true	"Team ID"
+//	@Success		200	{object}	models.TeamTranslationsResponse
+//	@Failure		400	{object}	models.ErrorResponse	"Invalid team ID"
+//	@Failure		404	{object}	models.ErrorResponse	"Team not found"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Router			/football/teams/{id}/translations [get]
+func (h *FootballHandler) GetTeamTranslations(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid team id", Code: errcode.InvalidID})
+		return
+	}
+	team, err := h.repo.GetTeamByID(id)
+	if errors.Is(err, models.ErrNotFound) {
+		h.teamNotFound(c, id)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	setLastModified(c, team.UpdatedAt)
+	c.JSON(http.StatusOK, translationsResponse(team))
+}
+
+// SetTeamTranslation handles PUT /api/v1/football/teams/:id/translations/:lang
+// Sets the team's name in one language.  The tag is stored in canonical
+// form, so "PT-br" sets "pt-BR".  Requires JWT authorisation.
+//
+//	@Summary		Set a translated team name
+//	@Description	Set the team's name in the language with the given BCP 47 tag (requires authentication)
+//	@Tags			teams
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int								true	"Team ID"
+//	@Param			lang	path		string							true	"BCP 47 language tag"	example(fr)
+//	@Param			request	body		models.TeamTranslationRequest	true	"Translated name"
+//	@Success		200		{object}	models.TeamTranslationsResponse
+//	@Failure		400		{object}	models.ErrorResponse			"Invalid request or language tag"
+//	@Failure		401		{object}	models.ErrorResponse			"Unauthorized"
+//	@Failure		404		{object}	models.ErrorResponse			"Team not found"
+//	@Failure		422		{object}	models.ContentRejectedResponse	"Content rejected by the classifier"
+//	@Failure		500		{object}	models.ErrorResponse			"Internal server error"
+//	@Security		Bearer
+//	@Router			/football/teams/{id}/translations/{lang} [put]
+func (h *FootballHandler) SetTeamTranslation(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid team id", Code: errcode.InvalidID})
+		return
+	}
+	lang, ok := teamLanguage(c)
+	if !ok {
+		return
+	}
+	var req models.TeamTranslationRequest
+	if err := c.ShouldBindWith(&req, sanitize.JSON); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: errcode.Binding(err)})
+		return
+	}
+	verdict, ok := h.screen(c, models.ContentTeam, map[string]string{"name": req.Name})
+	if !ok {
+		return
+	}
+
+	before := h.teamBefore(id)
+	team, err := h.repo.SetTeamTranslation(id, lang, req.Name)
+	if errors.Is(err, models.ErrNotFound) {
+		h.teamNotFound(c, id)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	h.quarantine(models.ContentTeam, team.ID, verdict)
+	publishUpdate(c, h.events, events.TeamUpdated, strconv.Itoa(team.ID), before, team)
+	c.JSON(http.StatusOK, translationsResponse(team))
+}
+
+// DeleteTeamTranslation handles DELETE /api/v1/football/teams/:id/translations/:lang
+// Requires JWT authorisation.
+//
+//	@Summary		Delete a translated team name
+//	@Description	Remove the team's name in one language (requires authentication)
+//	@Tags			teams
+//	@Param			id		path	int		true	"Team ID"
+//	@Param			lang	path	string	true	"BCP 47 language tag"
+//	@Success		204		"Translation deleted"
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid team ID or language tag"
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		404		{object}	models.ErrorResponse	"Team or translation not found"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/football/teams/{id}/translations/{lang} [delete]
+func (h *FootballHandler) DeleteTeamTranslation(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid team id", Code: errcode.InvalidID})
+		return
+	}
+	lang, ok := teamLanguage(c)
+	if !ok {
+		return
+	}
+	before := h.teamBefore(id)
+	team, err := h.repo.DeleteTeamTranslation(id, lang)
+	if errors.Is(err, models.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "translation not found", Code: errcode.TranslationNotFound})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	publishUpdate(c, h.events, events.TeamUpdated, strconv.Itoa(team.ID), before, team)
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,98 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+func TestTeamTranslations_SetListDelete(t *testing.T) {
+	r, mock := newFootballRouter()
+	team := mock.addTeam("Germany")
+	base := "/api/v1/football/teams/" + itoa(team.ID) + "/translations"
+
+	w := doRequest(r, http.MethodPut, base+"/DE", map[string]string{"name": "Deutschland"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp models.TeamTranslationsResponse
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Data["de"] != "Deutschland" {
+		t.Fatalf("expected tag stored canonically as de, got %+v", resp.Data)
+	}
+
+	w = doRequest(r, http.MethodGet, base, nil)
+	resp = models.TeamTranslationsResponse{}
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusOK || len(resp.Data) != 1 {
+		t.Fatalf("expected one translation, got %d %+v", w.Code, resp.Data)
+	}
+
+	if w := doRequest(r, http.MethodDelete, base+"/de", nil); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+	if w := doRequest(r, http.MethodDelete, base+"/de", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 deleting a missing translation, got %d", w.Code)
+	}
+}
+
+func TestTeamTranslations_Errors(t *testing.T) {
+	r, mock := newFootballRouter()
+	team := mock.addTeam("Germany")
+	base := "/api/v1/football/teams/" + itoa(team.ID) + "/translations"
+
+	cases := []struct {
+		name, path string
+		body       any
+		want       int
+	}{
+		{"bad tag", base + "/not_a_tag!", map[string]string{"name": "x"}, http.StatusBadRequest},
+		{"und", base + "/und", map[string]string{"name": "x"}, http.StatusBadRequest},
+		{"missing name", base + "/fr", map[string]string{}, http.StatusBadRequest},
+		{"unknown team", "/api/v1/football/teams/999/translations/fr", map[string]string{"name": "Allemagne"}, http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if w := doRequest(r, http.MethodPut, tc.path, tc.body); w.Code != tc.want {
+				t.Fatalf("expected %d, got %d: %s", tc.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetTeam_AcceptLanguage(t *testing.T) {
+	r, mock := newFootballRouter()
+	team := mock.addTeam("Germany")
+	mock.teams[0].Translations = map[string]string{"de": "Deutschland", "fr": "Allemagne", "zh-Hant": "\u5fb7\u570b"}
+	path := "/api/v1/football/teams/" + itoa(team.ID)
+
+	cases := []struct {
+		accept, name, lang string
+	}{
+		{"", "Germany", ""},
+		{"fr-CA", "Allemagne", "fr"},
+		{"de-AT, en;q=0.5", "Deutschland", "de"},
+		{"en, de;q=0.9", "Germany", ""},
+		{"it;q=0.9, fr;q=0.8", "Allemagne", "fr"},
+		{"ja", "Germany", ""},
+		{"zh-CN", "Germany", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.accept, func(t *testing.T) {
+			w := doRequestWithHeader(r, http.MethodGet, path, nil, "Accept-Language", tc.accept)
+			var resp models.TeamResponse
+			_ = json.NewDecoder(w.Body).Decode(&resp)
+			if resp.Name != tc.name || resp.Language != tc.lang {
+				t.Fatalf("expected %q (%q), got %q (%q)", tc.name, tc.lang, resp.Name, resp.Language)
+			}
+			if got := w.Header().Get("Content-Language"); got != tc.lang {
+				t.Fatalf("expected Content-Language %q, got %q", tc.lang, got)
+			}
+			if got := w.Header().Get("Vary"); got != "Accept-Language" {
+				t.Fatalf("expected Vary: Accept-Language, got %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/health"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// probeTimeout bounds each probe's checks, below Kubernetes' default
+// one-second probe timeout.
+const probeTimeout = 800 * time.Millisecond
+
+// HealthHandler serves the container orchestrator probes.
+type HealthHandler struct {
+	probes *health.Probes
+}
+
+// NewHealthHandler creates a HealthHandler reporting probes.
+func NewHealthHandler(probes *health.Probes) *HealthHandler {
+	return &HealthHandler{probes: probes}
+}
+
+// Livez handles GET /livez
+// Succeeds whenever the process can answer HTTP; it checks no dependencies,
+// so that a database outage does not make the orchestrator restart every
+// instance.
+//
+//	@Summary		Liveness probe
+//	@Description	Returns 200 while the process is running
+//	@Tags			meta
+//	@Produce		json
+//	@Success		200	{object}	models.ProbeResponse
+//	@Router			/livez [get]
+func (h *HealthHandler) Livez(c *gin.Context) {
+	probe(c, func(context.Context) error { return nil })
+}
+
+// Readyz handles GET /readyz
+// Fails until startup has finished, while a critical dependency such as the
+// database is unreachable and once shutdown has begun.  The latency and
+// outcome of each dependency's check are listed; one that is not critical
+// failing, or any answering slowly, reports "degraded" with a 200, as the
+// instance can still serve most requests.
+//
+//	@Summary		Readiness probe
+//	@Description	Returns 200 ("ok" or "degraded") when the instance should receive traffic, 503 otherwise, with the latency of each dependency
+//	@Tags			meta
+//	@Produce		json
+//	@Success		200	{object}	models.ProbeResponse
+//	@Failure		503	{object}	models.ProbeResponse
+//	@Router			/readyz [get]
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), probeTimeout)
+	defer cancel()
+	report := h.probes.Readiness(ctx)
+	resp := models.ProbeResponse{Status: report.Status}
+	for _, r := range report.Dependencies {
+		dep := models.DependencyCheck{
+			Name:      r.Name,
+			Status:    r.Status,
+			Critical:  r.Critical,
+			LatencyMs: float64(r.Latency.Microseconds()) / 1000,
+		}
+		if r.Err != nil {
+			dep.Error = r.Err.Error()
+		}
+		resp.Dependencies = append(resp.Dependencies, dep)
+	}
+	code := http.StatusOK
+	if report.Err != nil {
+		resp.Error, code = report.Err.Error(), http.StatusServiceUnavailable
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(code, resp)
+}
+
+// Startupz handles GET /startupz
+// Fails until the database schema is in place, so that a startup probe with
+// a generous failure threshold can wait out migrations without the
+// liveness probe restarting the container.
+//
+//	@Summary		Startup probe
+//	@Description	Returns 200 once initialisation has finished, 503 before
+//	@Tags			meta
+//	@Produce		json
+//	@Success		200	{object}	models.ProbeResponse
+//	@Failure		503	{object}	models.ProbeResponse
+//	@Router			/startupz [get]
+func (h *HealthHandler) Startupz(c *gin.Context) {
+	probe(c, h.probes.Started)
+}
+
+func probe(c *gin.Context, check health.Check) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), probeTimeout)
+	defer cancel()
+	c.Header("Cache-Control", "no-store")
+	if err := check(ctx); err != nil {
+		c.JSON(http.StatusServiceUnavailable, models.ProbeResponse{Status: "unavailable", Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, models.ProbeResponse{Status: "ok"})
+}
@@ -0,0 +1,128 @@
+package handlers_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/handlers"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/health"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/metrics"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+func newHealthRouter(probes *health.Probes) *gin.Engine {
+	h := handlers.NewHealthHandler(probes)
+	r := gin.New()
+	r.GET("/livez", h.Livez)
+	r.GET("/readyz", h.Readyz)
+	r.GET("/startupz", h.Startupz)
+	return r
+}
+
+func TestHealth_Lifecycle(t *testing.T) {
+	migrated, dbUp := false, true
+	probes := health.New(
+		func(context.Context) error {
+			if !migrated {
+				return errors.New("waiting for migrations")
+			}
+			return nil
+		},
+		func(context.Context) error {
+			if !dbUp {
+				return errors.New("database unreachable")
+			}
+			return nil
+		},
+	)
+	r := newHealthRouter(probes)
+	status := func(path string) int { return doRequest(r, http.MethodGet, path, nil).Code }
+
+	// Starting: live, but neither started nor ready.
+	if status("/livez") != http.StatusOK || status("/startupz") != http.StatusServiceUnavailable || status("/readyz") != http.StatusServiceUnavailable {
+		t.Fatal("expected only /livez to pass before migrations")
+	}
+
+	migrated = true
+	if status("/startupz") != http.StatusOK || status("/readyz") != http.StatusOK {
+		t.Fatal("expected startup and readiness to pass after migrations")
+	}
+
+	// An outage after startup fails readiness only.
+	dbUp = false
+	w := doRequest(r, http.MethodGet, "/readyz", nil)
+	assertStatus(t, w, http.StatusServiceUnavailable)
+	var body models.ProbeResponse
+	decodeJSON(t, w, &body)
+	if body.Status != "unavailable" || body.Error != "database unreachable" {
+		t.Errorf("unexpected body %+v", body)
+	}
+	migrated = false
+	if status("/startupz") != http.StatusOK || status("/livez") != http.StatusOK {
+		t.Error("expected startup to stay passed and liveness to ignore the database")
+	}
+
+	dbUp = true
+	probes.Drain()
+	if status("/readyz") != http.StatusServiceUnavailable || status("/livez") != http.StatusOK {
+		t.Error("expected draining to fail readiness only")
+	}
+}
+
+func TestHealth_ReadyzDependencies(t *testing.T) {
+	var cacheErr, dbErr error
+	probes := health.New(nil, nil)
+	probes.Depend(health.Dependency{Name: "database", Critical: true, Check: func(context.Context) error { return dbErr }})
+	probes.Depend(health.Dependency{Name: "cache", Check: func(context.Context) error { return cacheErr }})
+	probes.Depend(health.Dependency{Name: "slow", Slow: time.Nanosecond, Check: func(context.Context) error {
+		time.Sleep(time.Millisecond)
+		return nil
+	}})
+	r := newHealthRouter(probes)
+	readyz := func(code int) models.ProbeResponse {
+		t.Helper()
+		w := doRequest(r, http.MethodGet, "/readyz", nil)
+		assertStatus(t, w, code)
+		var body models.ProbeResponse
+		decodeJSON(t, w, &body)
+		return body
+	}
+
+	// A slow dependency degrades readiness without failing it.
+	body := readyz(http.StatusOK)
+	if body.Status != health.StatusDegraded || len(body.Dependencies) != 3 {
+		t.Fatalf("unexpected body %+v", body)
+	}
+	if d := body.Dependencies[2]; d.Name != "slow" || d.Status != health.StatusDegraded || d.LatencyMs < 1 {
+		t.Errorf("unexpected slow dependency %+v", d)
+	}
+	var exposition strings.Builder
+	metrics.WriteTo(&exposition)
+	if !strings.Contains(exposition.String(), `football_api_dependency_up{dependency="database"} 1`) {
+		t.Errorf("expected the database up gauge in\n%s", exposition.String())
+	}
+
+	// A failing dependency that is not critical also only degrades.
+	cacheErr = errors.New("connection refused")
+	body = readyz(http.StatusOK)
+	if d := body.Dependencies[1]; body.Status != health.StatusDegraded || d.Status != health.StatusUnavailable || d.Error != "connection refused" || d.Critical {
+		t.Errorf("unexpected body %+v", body)
+	}
+
+	// A failing critical dependency makes the instance unavailable.
+	dbErr = errors.New("database unreachable")
+	body = readyz(http.StatusServiceUnavailable)
+	if body.Status != health.StatusUnavailable || body.Error != "database: database unreachable" || !body.Dependencies[0].Critical {
+		t.Errorf("unexpected body %+v", body)
+	}
+	exposition.Reset()
+	metrics.WriteTo(&exposition)
+	if !strings.Contains(exposition.String(), `football_api_dependency_up{dependency="database"} 0`) {
+		t.Errorf("expected the database down gauge in\n%s", exposition.String())
+	}
+}
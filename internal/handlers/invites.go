@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// defaultInviteExpiry is how long an invite lasts when the request does not
+// say; maxInviteExpiry bounds what it may ask for.
+const (
+	defaultInviteExpiry = 7 * 24 * time.Hour
+	maxInviteExpiry     = 90 * 24 * time.Hour
+)
+
+// InviteHandler serves the /admin/invites endpoints through which operators
+// hand out registration codes for invite-only mode.
+type InviteHandler struct {
+	invites db.InviteRepository
+	ids     auth.IDGenerator
+}
+
+// NewInviteHandler constructs an InviteHandler.
+func NewInviteHandler(invites db.InviteRepository) *InviteHandler {
+	return &InviteHandler{invites: invites, ids: auth.RandomIDs{}}
+}
+
+// SetIDGenerator makes new invites take their codes from g.
+func (h *InviteHandler) SetIDGenerator(g auth.IDGenerator) {
+	if g == nil {
+		g = auth.RandomIDs{}
+	}
+	h.ids = g
+}
+
+// CreateInvite handles POST /api/v1/admin/invites
+// Generates a random invite code good for maxUses registrations (default 1)
+// until expiresIn has passed (default 7 days, at most 90).
+//
+//	@Summary		Create an invite
+//	@Description	Generate a single- or multi-use registration code with an expiry
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		models.InviteRequest	false	"Uses and lifetime"
+//	@Success		201		{object}	models.Invite
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid maxUses or expiresIn"
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	models.ErrorResponse	"Admin privileges required"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/admin/invites [post]
+func (h *InviteHandler) CreateInvite(c *gin.Context) {
+	var req models.InviteRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: errcode.Binding(err)})
+			return
+		}
+	}
+	if req.MaxUses == 0 {
+		req.MaxUses = 1
+	}
+	if req.MaxUses < 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "maxUses must be positive", Code: errcode.FieldInvalid})
+		return
+	}
+	expiresIn := defaultInviteExpiry
+	if req.ExpiresIn != "" {
+		var err error
+		expiresIn, err = time.ParseDuration(req.ExpiresIn)
+		if err != nil || expiresIn <= 0 || expiresIn > maxInviteExpiry {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "expiresIn must be a positive duration of at most 2160h, such as 72h", Code: errcode.FieldInvalid})
+			return
+		}
+	}
+
+	code, err := h.ids.NewID()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	inv, err := h.invites.CreateInvite(models.Invite{
+		Code:      code,
+		MaxUses:   req.MaxUses,
+		ExpiresAt: time.Now().Add(expiresIn).UTC(),
+		CreatedBy: c.GetString("username"),
+	})
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	inv.Links = inviteLinks(inv.Code)
+	c.Header("Location", "/api/v1/admin/invites/"+inv.Code)
+	c.JSON(http.StatusCreated, inv)
+}
+
+// ListInvites handles GET /api/v1/admin/invites
+//
+//	@Summary		List invites
+//	@Description	Every invite code, newest first, with its uses and expiry
+//	@Tags			admin
+//	@Produce		json
+//	@Success		200	{object}	models.InviteListResponse
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	models.ErrorResponse	"Admin privileges required"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/admin/invites [get]
+func (h *InviteHandler) ListInvites(c *gin.Context) {
+	invites, err := h.invites.ListInvites()
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	for i := range invites {
+		invites[i].Links = inviteLinks(invites[i].Code)
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, models.InviteListResponse{
+		Invites: invites,
+		Links: []models.Link{
+			{Rel: "self", Href: "/api/v1/admin/invites", Method: http.MethodGet},
+			{Rel: "create", Href: "/api/v1/admin/invites", Method: http.MethodPost},
+		},
+	})
+}
+
+// DeleteInvite handles DELETE /api/v1/admin/invites/:code
+// Revokes an invite; accounts already registered with it are unaffected.
+//
+//	@Summary		Revoke an invite
+//	@Tags			admin
+//	@Param			code	path	string	true	"Invite code"
+//	@Success		204
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	models.ErrorResponse	"Admin privileges required"
+//	@Failure		404	{object}	models.ErrorResponse	"Invite not found"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/admin/invites/{code} [delete]
+func (h *InviteHandler) DeleteInvite(c *gin.Context) {
+	err := h.invites.DeleteInvite(c.Param("code"))
+	if errors.Is(err, models.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "invite not found", Code: errcode.InviteNotFound})
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func inviteLinks(code string) []models.Link {
+	return []models.Link{
+		{Rel: "revoke", Href: "/api/v1/admin/invites/" + code, Method: http.MethodDelete},
+		{Rel: "collection", Href: "/api/v1/admin/invites", Method: http.MethodGet},
+		{Rel: "register", Href: "/api/v1/auth/register", Method: http.MethodPost},
+	}
+}
@@ -0,0 +1,78 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/memory"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/flags"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/handlers"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+func TestInviteOnlyRegistration(t *testing.T) {
+	repos := memory.New().Repositories()
+	f, err := flags.New(map[string]bool{flags.InviteOnly: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authHandler := handlers.NewAuthHandler(repos.Users, repos.Sessions, auth.NewJWTService("test-secret", "COMP3011_API"), testHasher)
+	authHandler.SetFlags(f)
+	authHandler.SetInvites(repos.Invites)
+	inviteHandler := handlers.NewInviteHandler(repos.Invites)
+	inviteHandler.SetIDGenerator(&auth.SequentialIDs{Prefix: "inv-"})
+
+	r := gin.New()
+	r.POST("/api/v1/auth/register", authHandler.Register)
+	r.POST("/api/v1/admin/invites", inviteHandler.CreateInvite)
+	r.GET("/api/v1/admin/invites", inviteHandler.ListInvites)
+	r.DELETE("/api/v1/admin/invites/:code", inviteHandler.DeleteInvite)
+
+	register := func(username, code string) int {
+		return doRequest(r, http.MethodPost, "/api/v1/auth/register",
+			models.RegisterRequest{Username: username, Password: "password123", InviteCode: code}).Code
+	}
+
+	if got := register("alice", ""); got != http.StatusForbidden {
+		t.Fatalf("without a code: expected 403, got %d", got)
+	}
+
+	w := doRequest(r, http.MethodPost, "/api/v1/admin/invites", models.InviteRequest{MaxUses: 2, ExpiresIn: "1h"})
+	assertStatus(t, w, http.StatusCreated)
+	var inv models.Invite
+	decodeJSON(t, w, &inv)
+	if inv.Code != "inv-1" || inv.MaxUses != 2 || len(inv.Links) == 0 {
+		t.Fatalf("unexpected invite %+v", inv)
+	}
+
+	if got := register("alice", "nope"); got != http.StatusForbidden {
+		t.Fatalf("unknown code: expected 403, got %d", got)
+	}
+	if got := register("alice", inv.Code); got != http.StatusCreated {
+		t.Fatalf("first use: expected 201, got %d", got)
+	}
+	// A failed registration gives its use back.
+	if got := register("alice", inv.Code); got != http.StatusConflict {
+		t.Fatalf("duplicate username: expected 409, got %d", got)
+	}
+	if got := register("bob", inv.Code); got != http.StatusCreated {
+		t.Fatalf("second use: expected 201, got %d", got)
+	}
+	if got := register("carol", inv.Code); got != http.StatusForbidden {
+		t.Fatalf("used up: expected 403, got %d", got)
+	}
+
+	w = doRequest(r, http.MethodGet, "/api/v1/admin/invites", nil)
+	assertStatus(t, w, http.StatusOK)
+	var list models.InviteListResponse
+	decodeJSON(t, w, &list)
+	if len(list.Invites) != 1 || list.Invites[0].Uses != 2 {
+		t.Fatalf("unexpected invites %+v", list.Invites)
+	}
+
+	assertStatus(t, doRequest(r, http.MethodDelete, "/api/v1/admin/invites/"+inv.Code, nil), http.StatusNoContent)
+	assertStatus(t, doRequest(r, http.MethodDelete, "/api/v1/admin/invites/"+inv.Code, nil), http.StatusNotFound)
+	assertStatus(t, doRequest(r, http.MethodPost, "/api/v1/admin/invites", models.InviteRequest{ExpiresIn: "-1h"}), http.StatusBadRequest)
+}
@@ -0,0 +1,331 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/sanitize"
+)
+
+// defaultModerationLimit is the default moderation queue page size.
+const defaultModerationLimit = 50
+
+// ModerationHandler serves content reports on teams and matches and the
+// /admin/moderation queue in which admins review them.
+type ModerationHandler struct {
+	repo     db.ModerationRepository
+	football db.FootballRepository
+	events   *events.Bus
+}
+
+// NewModerationHandler constructs a ModerationHandler.  Content taken down
+// is deleted from football.
+func NewModerationHandler(repo db.ModerationRepository, football db.FootballRepository) *ModerationHandler {
+	return &ModerationHandler{repo: repo, football: football}
+}
+
+// SetEvents publishes the deletions made by takedowns to bus.
+func (h *ModerationHandler) SetEvents(bus *events.Bus) {
+	h.events = bus
+}
+
+// ReportTeam handles POST /api/v1/football/teams/:id/report
+//
+//	@Summary		Report a team
+//	@Description	Flag a team for review by the moderators; each user may report a team once
+//	@Tags			teams
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int						true	"Team ID"
+//	@Param			body	body		models.ReportRequest	true	"Reason (spam, offensive, inaccurate, duplicate or other)"
+//	@Success		201		{object}	models.ContentReport
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid request"
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		404		{object}	models.ErrorResponse	"Team not found"
+//	@Failure		409		{object}	models.ErrorResponse	"Already reported"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/football/teams/{id}/report [post]
+func (h *ModerationHandler) ReportTeam(c *gin.Context) {
+	h.report(c, models.ContentTeam)
+}
+
+// ReportMatch handles POST /api/v1/football/matches/:id/report
+//
+//	@Summary		Report a match
+//	@Description	Flag a match for review by the moderators; each user may report a match once
+//	@Tags			matches
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int						true	"Match ID"
+//	@Param			body	body		models.ReportRequest	true	"Reason (spam, offensive, inaccurate, duplicate or other)"
+//	@Success		201		{object}	models.ContentReport
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid request"
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		404		{object}	models.ErrorResponse	"Match not found"
+//	@Failure		409		{object}	models.ErrorResponse	"Already reported"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/football/matches/{id}/report [post]
+func (h *ModerationHandler) ReportMatch(c *gin.Context) {
+	h.report(c, models.ContentMatch)
+}
+
+func (h *ModerationHandler) report(c *gin.Context, kind string) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid " + kind + " id", Code: errcode.InvalidID})
+		return
+	}
+	var req models.ReportRequest
+	if err := c.ShouldBindWith(&req, sanitize.JSON); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: errcode.Binding(err)})
+		return
+	}
+	if err := h.exists(kind, id); errors.Is(err, models.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: kind + " not found", Code: notFoundCodes[kind]})
+		return
+	} else if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+
+	rep, err := h.repo.ReportContent(models.ContentReport{
+		Kind:       kind,
+		ResourceID: id,
+		Reporter:   c.GetString("username"),
+		Reason:     req.Reason,
+		Note:       req.Note,
+	})
+	if errors.Is(err, models.ErrConflict) {
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "you have already reported this " + kind, Code: errcode.AlreadyReported})
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	c.JSON(http.StatusCreated, rep)
+}
+
+// notFoundCodes maps each kind of content to the code for its absence.
+var notFoundCodes = map[string]string{
+	models.ContentTeam:  errcode.TeamNotFound,
+	models.ContentMatch: errcode.MatchNotFound,
+}
+
+func (h *ModerationHandler) exists(kind string, id int) error {
+	var err error
+	switch kind {
+	case models.ContentTeam:
+		_, err = h.football.GetTeamByID(id)
+	case models.ContentMatch:
+		_, err = h.football.GetMatchByID(id)
+	default:
+		err = models.ErrNotFound
+	}
+	return err
+}
+
+// ModerationQueue handles GET /api/v1/admin/moderation
+// Accepts ?state= (default open) and ?limit= / ?offset= for pagination.
+//
+//	@Summary		Moderation queue
+//	@Description	Reported teams and matches in one state, most reported first, with their reports
+//	@Tags			admin
+//	@Produce		json
+//	@Param			state	query		string	false	"open (default), dismissed or removed"
+//	@Param			limit	query		int		false	"Page size"	default(50)
+//	@Param			offset	query		int		false	"Page offset"	default(0)
+//	@Success		200		{object}	models.ModerationQueueResponse
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid query parameters"
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	models.ErrorResponse	"Admin privileges required"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/admin/moderation [get]
+func (h *ModerationHandler) ModerationQueue(c *gin.Context) {
+	state := c.DefaultQuery("state", models.ModerationOpen)
+	switch state {
+	case models.ModerationOpen, models.ModerationDismissed, models.ModerationRemoved:
+	default:
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "state must be open, dismissed or removed", Code: errcode.InvalidParameter})
+		return
+	}
+	limit, offset := defaultModerationLimit, 0
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "limit must be a positive integer", Code: errcode.InvalidParameter})
+			return
+		}
+		limit = n
+	}
+	if v := c.Query("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "offset must be a non-negative integer", Code: errcode.InvalidParameter})
+			return
+		}
+		offset = n
+	}
+
+	items, err := h.repo.ListModerationItems(state, limit, offset)
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	if items == nil {
+		items = []models.ModerationItem{}
+	}
+	for i := range items {
+		items[i].Links = moderationLinks(items[i])
+	}
+	page := func(offset int) string {
+		return fmt.Sprintf("/api/v1/admin/moderation?state=%s&limit=%d&offset=%d", state, limit, offset)
+	}
+	links := []models.Link{{Rel: "self", Href: page(offset), Method: http.MethodGet}}
+	if len(items) == limit {
+		links = append(links, models.Link{Rel: "next", Href: page(offset + limit), Method: http.MethodGet})
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, models.ModerationQueueResponse{Data: items, Links: links})
+}
+
+// Dismiss handles POST /api/v1/admin/moderation/:kind/:id/dismiss
+// Keeps the content and closes its reports; a later report reopens it.
+//
+//	@Summary		Dismiss reports
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			kind	path		string								true	"team or match"
+//	@Param			id		path		int									true	"Team or match ID"
+//	@Param			body	body		models.ModerationDecisionRequest	false	"Resolution note"
+//	@Success		200		{object}	models.ModerationItem
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid request"
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	models.ErrorResponse	"Admin privileges required"
+//	@Failure		404		{object}	models.ErrorResponse	"Not reported"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/admin/moderation/{kind}/{id}/dismiss [post]
+func (h *ModerationHandler) Dismiss(c *gin.Context) {
+	h.resolve(c, models.ModerationDismissed)
+}
+
+// TakeDown handles POST /api/v1/admin/moderation/:kind/:id/takedown
+// Deletes the team or match, as DELETE on it would, and marks it removed.
+//
+//	@Summary		Take content down
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			kind	path		string								true	"team or match"
+//	@Param			id		path		int									true	"Team or match ID"
+//	@Param			body	body		models.ModerationDecisionRequest	false	"Resolution note"
+//	@Success		200		{object}	models.ModerationItem
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid request"
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	models.ErrorResponse	"Admin privileges required"
+//	@Failure		404		{object}	models.ErrorResponse	"Not reported"
+//	@Failure		409		{object}	models.ErrorResponse	"Already removed"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/admin/moderation/{kind}/{id}/takedown [post]
+func (h *ModerationHandler) TakeDown(c *gin.Context) {
+	h.resolve(c, models.ModerationRemoved)
+}
+
+func (h *ModerationHandler) resolve(c *gin.Context, state string) {
+	kind := c.Param("kind")
+	if kind != models.ContentTeam && kind != models.ContentMatch {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "kind must be team or match", Code: errcode.InvalidParameter})
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid " + kind + " id", Code: errcode.InvalidID})
+		return
+	}
+	var req models.ModerationDecisionRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindWith(&req, sanitize.JSON); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: errcode.Binding(err)})
+			return
+		}
+	}
+
+	item, err := h.repo.GetModerationItem(kind, id)
+	if errors.Is(err, models.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: kind + " has not been reported", Code: errcode.ReportNotFound})
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	if item.State == models.ModerationRemoved {
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: kind + " has already been taken down", Code: errcode.AlreadyTakenDown})
+		return
+	}
+
+	if state == models.ModerationRemoved {
+		// Content already deleted through the API still counts as taken
+		// down.
+		if err := h.delete(c, kind, id); err != nil && !errors.Is(err, models.ErrNotFound) {
+			_ = c.Error(err)
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+			return
+		}
+	}
+	item, err = h.repo.ResolveModerationItem(kind, id, state, c.GetString("username"), req.Note)
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	item.Links = moderationLinks(item)
+	c.JSON(http.StatusOK, item)
+}
+
+func (h *ModerationHandler) delete(c *gin.Context, kind string, id int) error {
+	if kind == models.ContentTeam {
+		if err := h.football.DeleteTeam(id); err != nil {
+			return err
+		}
+		publish(c, h.events, events.TeamDeleted, strconv.Itoa(id), nil)
+		return nil
+	}
+	if err := h.football.DeleteMatch(id); err != nil {
+		return err
+	}
+	publish(c, h.events, events.MatchDeleted, strconv.Itoa(id), nil)
+	return nil
+}
+
+func moderationLinks(item models.ModerationItem) []models.Link {
+	base := fmt.Sprintf("/api/v1/admin/moderation/%s/%d", item.Kind, item.ResourceID)
+	var links []models.Link
+	if item.State != models.ModerationRemoved {
+		links = append(links,
+			models.Link{Rel: "content", Href: fmt.Sprintf("/api/v1/football/%ss/%d", item.Kind, item.ResourceID), Method: http.MethodGet},
+			models.Link{Rel: "takedown", Href: base + "/takedown", Method: http.MethodPost},
+		)
+	}
+	if item.State == models.ModerationOpen {
+		links = append(links, models.Link{Rel: "dismiss", Href: base + "/dismiss", Method: http.MethodPost})
+	}
+	return append(links, models.Link{Rel: "queue", Href: "/api/v1/admin/moderation?state=" + item.State, Method: http.MethodGet})
+}
@@ -0,0 +1,93 @@
+package handlers_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/memory"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/handlers"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+func TestModeration(t *testing.T) {
+	store := memory.New()
+	repos := store.Repositories()
+	football := store.Football()
+	eng, _ := football.CreateTeam("England")
+	ger, _ := football.CreateTeam("Germany")
+	cup := football.AddTournament("FIFA World Cup")
+	match, _ := football.CreateMatch(models.Match{Date: time.Date(1990, 7, 4, 0, 0, 0, 0, time.UTC), HomeTeamID: eng.ID, AwayTeamID: ger.ID, TournamentID: cup.ID})
+
+	h := handlers.NewModerationHandler(repos.Moderation, repos.Football)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("username", c.GetHeader("X-User")) })
+	r.POST("/api/v1/football/teams/:id/report", h.ReportTeam)
+	r.POST("/api/v1/football/matches/:id/report", h.ReportMatch)
+	r.GET("/api/v1/admin/moderation", h.ModerationQueue)
+	r.POST("/api/v1/admin/moderation/:kind/:id/dismiss", h.Dismiss)
+	r.POST("/api/v1/admin/moderation/:kind/:id/takedown", h.TakeDown)
+
+	report := func(user, path string, req models.ReportRequest) int {
+		return doRequestWithHeader(r, http.MethodPost, path, req, "X-User", user).Code
+	}
+	teamPath := fmt.Sprintf("/api/v1/football/teams/%d/report", eng.ID)
+	matchPath := fmt.Sprintf("/api/v1/football/matches/%d/report", match.ID)
+
+	if code := report("alice", teamPath, models.ReportRequest{Reason: "rude"}); code != http.StatusBadRequest {
+		t.Fatalf("unknown reason: expected 400, got %d", code)
+	}
+	if code := report("alice", "/api/v1/football/teams/999/report", models.ReportRequest{Reason: "spam"}); code != http.StatusNotFound {
+		t.Fatalf("unknown team: expected 404, got %d", code)
+	}
+	for _, user := range []string{"alice", "bob"} {
+		if code := report(user, matchPath, models.ReportRequest{Reason: "inaccurate", Note: "score reversed"}); code != http.StatusCreated {
+			t.Fatalf("report match: expected 201, got %d", code)
+		}
+	}
+	if code := report("alice", matchPath, models.ReportRequest{Reason: "spam"}); code != http.StatusConflict {
+		t.Fatalf("second report: expected 409, got %d", code)
+	}
+	if code := report("alice", teamPath, models.ReportRequest{Reason: "duplicate"}); code != http.StatusCreated {
+		t.Fatalf("report team: expected 201, got %d", code)
+	}
+
+	queue := func(state string) []models.ModerationItem {
+		t.Helper()
+		w := doRequestWithHeader(r, http.MethodGet, "/api/v1/admin/moderation?state="+state, nil, "X-User", "admin")
+		assertStatus(t, w, http.StatusOK)
+		var resp models.ModerationQueueResponse
+		decodeJSON(t, w, &resp)
+		return resp.Data
+	}
+	open := queue("open")
+	if len(open) != 2 || open[0].Kind != models.ContentMatch || open[0].ReportCount != 2 || len(open[0].Reports) != 2 {
+		t.Fatalf("unexpected queue %+v", open)
+	}
+
+	// Dismissed reports are reopened by a new one.
+	dismiss := fmt.Sprintf("/api/v1/admin/moderation/team/%d/dismiss", eng.ID)
+	assertStatus(t, doRequestWithHeader(r, http.MethodPost, dismiss, models.ModerationDecisionRequest{Note: "fine"}, "X-User", "admin"), http.StatusOK)
+	if got := queue("dismissed"); len(got) != 1 || got[0].ReviewedBy != "admin" || got[0].Resolution != "fine" {
+		t.Fatalf("unexpected dismissed queue %+v", got)
+	}
+	report("bob", teamPath, models.ReportRequest{Reason: "duplicate"})
+	if got := queue("open"); len(got) != 2 {
+		t.Fatalf("expected the team to be reopened, got %+v", got)
+	}
+
+	// Taking a match down deletes it.
+	takedown := fmt.Sprintf("/api/v1/admin/moderation/match/%d/takedown", match.ID)
+	assertStatus(t, doRequestWithHeader(r, http.MethodPost, takedown, nil, "X-User", "admin"), http.StatusOK)
+	if _, err := repos.Football.GetMatchByID(match.ID); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("expected the match to be deleted, got %v", err)
+	}
+	assertStatus(t, doRequestWithHeader(r, http.MethodPost, takedown, nil, "X-User", "admin"), http.StatusConflict)
+	if got := queue("removed"); len(got) != 1 || got[0].ResourceID != match.ID {
+		t.Fatalf("unexpected removed queue %+v", got)
+	}
+	assertStatus(t, doRequestWithHeader(r, http.MethodPost, fmt.Sprintf("/api/v1/admin/moderation/team/%d/dismiss", ger.ID), nil, "X-User", "admin"), http.StatusNotFound)
+}
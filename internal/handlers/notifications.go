@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// defaultNotificationLimit is the default inbox page size for callers
+// without a pageSize preference.
+const defaultNotificationLimit = 20
+
+// NotificationHandler serves /me/notifications, the authenticated user's
+// inbox.
+type NotificationHandler struct {
+	repo   db.NotificationRepository
+	prefs  db.PreferencesRepository
+	events *events.Bus
+}
+
+// NewNotificationHandler constructs a NotificationHandler.
+func NewNotificationHandler(repo db.NotificationRepository) *NotificationHandler {
+	return &NotificationHandler{repo: repo}
+}
+
+// SetPreferences lets callers choose their default page size through the
+// pageSize preference.
+func (h *NotificationHandler) SetPreferences(repo db.PreferencesRepository) {
+	h.prefs = repo
+}
+
+// SetEvents publishes notifications being read to bus.
+func (h *NotificationHandler) SetEvents(bus *events.Bus) {
+	h.events = bus
+}
+
+// ListNotifications handles GET /api/v1/me/notifications
+// Accepts optional ?limit= and ?offset= query parameters for pagination and
+// ?unread=true to leave out notifications already read.
+//
+//	@Summary		My notifications
+//	@Description	A page of the caller's inbox, newest first, with the total number unread
+//	@Tags			account
+//	@Produce		json
+//	@Param			limit	query		int								false	"Page size (default 20, or the pageSize preference)"
+//	@Param			offset	query		int								false	"Page offset"	default(0)
+//	@Param			unread	query		bool							false	"Only unread notifications"
+//	@Success		200		{object}	models.NotificationListResponse
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid query parameters"
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/me/notifications [get]
+func (h *NotificationHandler) ListNotifications(c *gin.Context) {
+	limit := preferredPageSize(c, h.prefs, defaultNotificationLimit)
+	offset := 0
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "limit must be a positive integer", Code: errcode.InvalidParameter})
+			return
+		}
+		limit = n
+	}
+	if v := c.Query("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "offset must be a non-negative integer", Code: errcode.InvalidParameter})
+			return
+		}
+		offset = n
+	}
+	unreadOnly, err := strconv.ParseBool(c.DefaultQuery("unread", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "unread must be true or false", Code: errcode.InvalidParameter})
+		return
+	}
+
+	username := c.GetString("username")
+	list, err := h.repo.ListNotifications(username, limit, offset, unreadOnly)
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	unread, err := h.repo.CountUnreadNotifications(username)
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+
+	if list == nil {
+		list = []models.Notification{}
+	}
+	for i := range list {
+		list[i].Links = notificationLinks(list[i])
+	}
+	page := func(offset int) string {
+		href := fmt.Sprintf("/api/v1/me/notifications?limit=%d&offset=%d", limit, offset)
+		if unreadOnly {
+			href += "&unread=true"
+		}
+		return href
+	}
+	links := []models.Link{
+		{Rel: "self", Href: page(offset), Method: http.MethodGet},
+		{Rel: "mark-all-read", Href: "/api/v1/me/notifications/read", Method: http.MethodPost},
+	}
+	if offset > 0 {
+		links = append(links, models.Link{Rel: "prev", Href: page(max(offset-limit, 0)), Method: http.MethodGet})
+	}
+	if len(list) == limit {
+		links = append(links, models.Link{Rel: "next", Href: page(offset + limit), Method: http.MethodGet})
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, models.NotificationListResponse{Data: list, Unread: unread, Links: links})
+}
+
+// MarkNotificationRead handles POST /api/v1/me/notifications/:id/read
+//
+//	@Summary		Mark a notification read
+//	@Tags			account
+//	@Produce		json
+//	@Param			id	path		int	true	"Notification ID"
+//	@Success		200	{object}	models.Notification
+//	@Failure		400	{object}	models.ErrorResponse	"Invalid notification ID"
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		404	{object}	models.ErrorResponse	"Notification not found"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/me/notifications/{id}/read [post]
+func (h *NotificationHandler) MarkNotificationRead(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid notification id", Code: errcode.InvalidID})
+		return
+	}
+	n, err := h.repo.MarkNotificationRead(c.GetString("username"), id)
+	if errors.Is(err, models.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "notification not found", Code: errcode.NotificationNotFound})
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	publish(c, h.events, events.NotificationRead, strconv.Itoa(n.ID), n)
+	n.Links = notificationLinks(n)
+	c.JSON(http.StatusOK, n)
+}
+
+// MarkAllNotificationsRead handles POST /api/v1/me/notifications/read
+//
+//	@Summary		Mark every notification read
+//	@Tags			account
+//	@Success		204
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/me/notifications/read [post]
+func (h *NotificationHandler) MarkAllNotificationsRead(c *gin.Context) {
+	if _, err := h.repo.MarkAllNotificationsRead(c.GetString("username")); err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	publish(c, h.events, events.NotificationRead, c.GetString("username"), nil)
+	c.Status(http.StatusNoContent)
+}
+
+func notificationLinks(n models.Notification) []models.Link {
+	links := []models.Link{
+		{Rel: "collection", Href: "/api/v1/me/notifications", Method: http.MethodGet},
+	}
+	if n.ReadAt == nil {
+		links = append(links, models.Link{Rel: "mark-read", Href: fmt.Sprintf("/api/v1/me/notifications/%d/read", n.ID), Method: http.MethodPost})
+	}
+	if n.Href != "" {
+		links = append(links, models.Link{Rel: "related", Href: n.Href, Method: http.MethodGet})
+	}
+	return links
+}
@@ -0,0 +1,555 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/replay"
+)
+
+// OAuthHandler serves delegated authorisation: the registry of third-party
+// applications under /admin/oauth/clients, the consent and token endpoints
+// of the OAuth 2.0 authorization-code flow (RFC 6749, with RFC 7636 PKCE)
+// under /oauth, and /me/apps, where users review and revoke the access
+// they granted.
+type OAuthHandler struct {
+	oauth  db.OAuthRepository
+	jwt    *auth.JWTService
+	ledger replay.Ledger
+	events *events.Bus
+	ids    auth.IDGenerator
+}
+
+// NewOAuthHandler constructs an OAuthHandler.  Authorization codes are
+// signed by jwt, and ledger makes each one usable once.
+func NewOAuthHandler(oauth db.OAuthRepository, jwt *auth.JWTService, ledger replay.Ledger) *OAuthHandler {
+	return &OAuthHandler{oauth: oauth, jwt: jwt, ledger: ledger, ids: auth.RandomIDs{}}
+}
+
+// SetEvents publishes app.authorized and app.revoked on bus.
+func (h *OAuthHandler) SetEvents(bus *events.Bus) {
+	h.events = bus
+}
+
+// SetIDGenerator makes new clients and grants take their IDs from g.
+func (h *OAuthHandler) SetIDGenerator(g auth.IDGenerator) {
+	if g == nil {
+		g = auth.RandomIDs{}
+	}
+	h.ids = g
+}
+
+// CreateClient handles POST /api/v1/admin/oauth/clients
+// Registers a third-party application.  The response carries its client
+// secret, which is stored only as a hash and cannot be shown again.
+//
+//	@Summary		Register an OAuth client
+//	@Description	Register a third-party application with its redirect URIs and the scopes it may ask users for; returns its secret once
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.OAuthClientRequest	true	"Application"
+//	@Success		201		{object}	models.OAuthClientCreatedResponse
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid name, redirect URI or scope"
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	models.ErrorResponse	"Admin privileges required"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/admin/oauth/clients [post]
+func (h *OAuthHandler) CreateClient(c *gin.Context) {
+	var req models.OAuthClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: errcode.Binding(err)})
+		return
+	}
+	for _, uri := range req.RedirectURIs {
+		if !auth.ValidRedirectURI(uri) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "redirect URIs must be https URLs, or http on a loopback host, without a fragment: " + uri, Code: errcode.InvalidRedirectURI})
+			return
+		}
+	}
+	scopes, err := auth.ParseScope(strings.Join(req.Scopes, " "))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: errcode.InvalidScope})
+		return
+	}
+	if len(scopes) == 0 {
+		for s := range auth.Scopes {
+			scopes = append(scopes, s)
+		}
+		sort.Strings(scopes)
+	}
+
+	id, err := h.ids.NewID()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	secret, err := auth.NewClientSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	client, err := h.oauth.CreateClient(models.OAuthClient{
+		ID:           id,
+		Name:         req.Name,
+		RedirectURIs: req.RedirectURIs,
+		Scopes:       scopes,
+		SecretHash:   auth.HashClientSecret(secret),
+		CreatedBy:    c.GetString("username"),
+	})
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	client.Links = clientLinks(client.ID)
+	c.Header("Cache-Control", "no-store")
+	c.Header("Location", "/api/v1/admin/oauth/clients/"+client.ID)
+	c.JSON(http.StatusCreated, models.OAuthClientCreatedResponse{OAuthClient: client, ClientSecret: secret})
+}
+
+// ListClients handles GET /api/v1/admin/oauth/clients
+//
+//	@Summary		List OAuth clients
+//	@Description	Every registered third-party application, newest first
+//	@Tags			admin
+//	@Produce		json
+//	@Success		200	{object}	models.OAuthClientListResponse
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	models.ErrorResponse	"Admin privileges required"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/admin/oauth/clients [get]
+func (h *OAuthHandler) ListClients(c *gin.Context) {
+	clients, err := h.oauth.ListClients()
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	for i := range clients {
+		clients[i].Links = clientLinks(clients[i].ID)
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, models.OAuthClientListResponse{
+		Clients: clients,
+		Links: []models.Link{
+			{Rel: "self", Href: "/api/v1/admin/oauth/clients", Method: http.MethodGet},
+			{Rel: "create", Href: "/api/v1/admin/oauth/clients", Method: http.MethodPost},
+		},
+	})
+}
+
+// DeleteClient handles DELETE /api/v1/admin/oauth/clients/:id
+// Removes an application together with every user's grant to it, so that
+// the tokens it holds stop working at once.
+//
+//	@Summary		Delete an OAuth client
+//	@Tags			admin
+//	@Param			id	path	string	true	"Client ID"
+//	@Success		204
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	models.ErrorResponse	"Admin privileges required"
+//	@Failure		404	{object}	models.ErrorResponse	"Client not found"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/admin/oauth/clients/{id} [delete]
+func (h *OAuthHandler) DeleteClient(c *gin.Context) {
+	err := h.oauth.DeleteClient(c.Param("id"))
+	if errors.Is(err, models.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "OAuth client not found", Code: errcode.ClientNotFound})
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetConsent handles GET /api/v1/oauth/authorize
+// Checks an authorization request from an application and describes it for
+// the consent screen: who is asking, for which scopes, and which of them
+// the user has already granted.  The user's answer goes to
+// POST /oauth/authorize.
+//
+//	@Summary		Consent screen
+//	@Description	Describe an OAuth authorization request for the user to approve or deny
+//	@Tags			oauth
+//	@Produce		json
+//	@Param			response_type			query		string	true	"Must be code"
+//	@Param			client_id				query		string	true	"Client ID"
+//	@Param			redirect_uri			query		string	true	"One of the client's redirect URIs"
+//	@Param			scope					query		string	false	"Space-separated scopes; defaults to all the client may ask for"
+//	@Param			state					query		string	false	"Opaque value returned to the client"
+//	@Param			code_challenge			query		string	false	"RFC 7636 PKCE challenge"
+//	@Param			code_challenge_method	query		string	false	"S256"
+//	@Success		200						{object}	models.OAuthConsent
+//	@Failure		400						{object}	models.ErrorResponse	"Invalid redirect URI, scope or parameter"
+//	@Failure		401						{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403						{object}	models.ErrorResponse	"Not signed in with a user token"
+//	@Failure		404						{object}	models.ErrorResponse	"Client not found"
+//	@Failure		500						{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/oauth/authorize [get]
+func (h *OAuthHandler) GetConsent(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	if c.Query("response_type") != "code" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "response_type must be code", Code: errcode.InvalidParameter})
+		return
+	}
+	req := models.AuthorizeRequest{
+		ClientID:            c.Query("client_id"),
+		RedirectURI:         c.Query("redirect_uri"),
+		Scope:               c.Query("scope"),
+		State:               c.Query("state"),
+		CodeChallenge:       c.Query("code_challenge"),
+		CodeChallengeMethod: c.Query("code_challenge_method"),
+	}
+	client, scopes, ok := h.checkAuthorization(c, req)
+	if !ok {
+		return
+	}
+	var granted []string
+	grant, err := h.oauth.GetGrant(c.GetString("username"), client.ID)
+	switch {
+	case err == nil:
+		granted = grant.Scopes
+	case !errors.Is(err, models.ErrNotFound):
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+
+	consent := models.OAuthConsent{
+		ClientID:    client.ID,
+		ClientName:  client.Name,
+		RedirectURI: req.RedirectURI,
+		State:       req.State,
+		Links: []models.Link{
+			{Rel: "decide", Href: "/api/v1/oauth/authorize", Method: http.MethodPost},
+			{Rel: "apps", Href: "/api/v1/me/apps", Method: http.MethodGet},
+		},
+	}
+	for _, s := range scopes {
+		consent.Scopes = append(consent.Scopes, models.OAuthScope{Name: s, Description: auth.Scopes[s], Granted: slices.Contains(granted, s)})
+	}
+	c.JSON(http.StatusOK, consent)
+}
+
+// Authorize handles POST /api/v1/oauth/authorize
+// Records the user's answer to a consent screen.  On approval the scopes
+// are added to the user's grant to the application and a one-shot
+// authorization code, valid for five minutes, is issued; either way the
+// response says where to send the user back to.
+//
+//	@Summary		Approve or deny an application
+//	@Description	Answer an OAuth authorization request; returns the client redirect URI with a code, or with error=access_denied
+//	@Tags			oauth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.AuthorizeRequest	true	"The authorization request and the user's decision"
+//	@Success		200		{object}	models.AuthorizeResponse
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid redirect URI, scope or parameter"
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	models.ErrorResponse	"Not signed in with a user token"
+//	@Failure		404		{object}	models.ErrorResponse	"Client not found"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/oauth/authorize [post]
+func (h *OAuthHandler) Authorize(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	var req models.AuthorizeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: errcode.Binding(err)})
+		return
+	}
+	client, scopes, ok := h.checkAuthorization(c, req)
+	if !ok {
+		return
+	}
+	params := url.Values{}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	if !req.Approve {
+		params.Set("error", "access_denied")
+		c.JSON(http.StatusOK, models.AuthorizeResponse{RedirectTo: withQuery(req.RedirectURI, params)})
+		return
+	}
+
+	id, err := h.ids.NewID()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	grant, err := h.oauth.GrantAccess(models.OAuthGrant{ID: id, Username: c.GetString("username"), ClientID: client.ID, Scopes: scopes})
+	if errors.Is(err, models.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "OAuth client not found", Code: errcode.ClientNotFound})
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	code, err := h.jwt.GenerateAuthorizationCode(auth.AuthorizationCode{
+		Username:      grant.Username,
+		ClientID:      client.ID,
+		GrantID:       grant.ID,
+		RedirectURI:   req.RedirectURI,
+		Scope:         strings.Join(scopes, " "),
+		CodeChallenge: req.CodeChallenge,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	publish(c, h.events, events.AppAuthorized, client.ID, grant)
+	params.Set("code", code)
+	c.JSON(http.StatusOK, models.AuthorizeResponse{RedirectTo: withQuery(req.RedirectURI, params)})
+}
+
+// checkAuthorization validates an authorization request from the signed-in
+// user, returning the client and the scopes asked for, or reporting false
+// after refusing the request.
+func (h *OAuthHandler) checkAuthorization(c *gin.Context, req models.AuthorizeRequest) (models.OAuthClient, []string, bool) {
+	if c.GetString("impersonator") != "" {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "impersonation tokens cannot authorise applications", Code: errcode.ImpersonationForbidden})
+		return models.OAuthClient{}, nil, false
+	}
+	if c.GetString("authScheme") != "Bearer" {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "only users signed in with a token can authorise applications", Code: errcode.Forbidden})
+		return models.OAuthClient{}, nil, false
+	}
+	if req.ClientID == "" || req.RedirectURI == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "client_id and redirect_uri are required", Code: errcode.InvalidParameter})
+		return models.OAuthClient{}, nil, false
+	}
+	client, err := h.oauth.GetClient(req.ClientID)
+	if errors.Is(err, models.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "OAuth client not found", Code: errcode.ClientNotFound})
+		return models.OAuthClient{}, nil, false
+	}
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return models.OAuthClient{}, nil, false
+	}
+	// Never send the user, or a code, anywhere the client did not register.
+	if !slices.Contains(client.RedirectURIs, req.RedirectURI) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "redirect_uri is not registered for this client", Code: errcode.InvalidRedirectURI})
+		return models.OAuthClient{}, nil, false
+	}
+	scopes := client.Scopes
+	if req.Scope != "" {
+		if scopes, err = auth.ParseScope(req.Scope); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: errcode.InvalidScope})
+			return models.OAuthClient{}, nil, false
+		}
+		for _, s := range scopes {
+			if !slices.Contains(client.Scopes, s) {
+				c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "this client may not ask for " + s, Code: errcode.InvalidScope})
+				return models.OAuthClient{}, nil, false
+			}
+		}
+	}
+	if (req.CodeChallenge == "") != (req.CodeChallengeMethod == "") || (req.CodeChallengeMethod != "" && req.CodeChallengeMethod != "S256") {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "code_challenge needs code_challenge_method S256", Code: errcode.InvalidParameter})
+		return models.OAuthClient{}, nil, false
+	}
+	return client, scopes, true
+}
+
+// Token handles POST /api/v1/oauth/token
+// Exchanges an authorization code for an access token with which the
+// application acts for the user for an hour.  The client authenticates
+// with HTTP Basic or the client_id and client_secret form fields.  Errors
+// take the RFC 6749 form, with the usual code alongside.
+//
+//	@Summary		Exchange an authorization code
+//	@Description	OAuth 2.0 token endpoint for the authorization_code grant
+//	@Tags			oauth
+//	@Accept			x-www-form-urlencoded
+//	@Produce		json
+//	@Param			grant_type		formData	string	true	"authorization_code"
+//	@Param			code			formData	string	true	"Authorization code"
+//	@Param			redirect_uri	formData	string	true	"The redirect URI the code was issued for"
+//	@Param			code_verifier	formData	string	false	"PKCE verifier, if the request had a challenge"
+//	@Param			client_id		formData	string	false	"Client ID, without HTTP Basic"
+//	@Param			client_secret	formData	string	false	"Client secret, without HTTP Basic"
+//	@Success		200				{object}	models.OAuthTokenResponse
+//	@Failure		400				{object}	models.OAuthErrorResponse	"Invalid or used code, or unsupported grant type"
+//	@Failure		401				{object}	models.OAuthErrorResponse	"Invalid client credentials"
+//	@Failure		500				{object}	models.ErrorResponse		"Internal server error"
+//	@Router			/oauth/token [post]
+func (h *OAuthHandler) Token(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	clientID, secret, basic := c.Request.BasicAuth()
+	if !basic {
+		clientID, secret = c.PostForm("client_id"), c.PostForm("client_secret")
+	}
+	client, err := h.oauth.GetClient(clientID)
+	if err != nil && !errors.Is(err, models.ErrNotFound) {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	if err != nil || !auth.VerifyClientSecret(secret, client.SecretHash) {
+		if basic {
+			c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+		c.JSON(http.StatusUnauthorized, models.OAuthErrorResponse{Error: "invalid_client", Description: "unknown client or wrong secret", Code: errcode.InvalidClient})
+		return
+	}
+	if c.PostForm("grant_type") != "authorization_code" {
+		c.JSON(http.StatusBadRequest, models.OAuthErrorResponse{Error: "unsupported_grant_type", Description: "grant_type must be authorization_code", Code: errcode.UnsupportedGrant})
+		return
+	}
+
+	invalid := func(description string) {
+		c.JSON(http.StatusBadRequest, models.OAuthErrorResponse{Error: "invalid_grant", Description: description, Code: errcode.InvalidGrant})
+	}
+	code, err := h.jwt.ValidateAuthorizationCode(c.PostForm("code"))
+	if err != nil {
+		invalid("authorization code is invalid or expired")
+		return
+	}
+	if code.ClientID != client.ID || code.RedirectURI != c.PostForm("redirect_uri") {
+		invalid("authorization code was issued to another client or redirect_uri")
+		return
+	}
+	if code.CodeChallenge != "" && !auth.VerifyPKCE(c.PostForm("code_verifier"), code.CodeChallenge) {
+		invalid("code_verifier does not match the code_challenge")
+		return
+	}
+	err = h.ledger.Consume(c.Request.Context(), code.ID, code.ExpiresAt.Time)
+	if errors.Is(err, replay.ErrReplayed) {
+		invalid("authorization code has already been used")
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	// The user may have revoked access since approving.
+	err = h.oauth.TouchGrant(code.GrantID)
+	if errors.Is(err, models.ErrNotFound) {
+		invalid("the user has revoked this application's access")
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+
+	token, _, err := h.jwt.GenerateDelegatedToken(code.Username, client.ID, code.GrantID, code.Scope)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "failed to generate token", Code: errcode.Internal})
+		return
+	}
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, models.OAuthTokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(auth.DelegatedTokenTTL.Seconds()),
+		Scope:       code.Scope,
+	})
+}
+
+// ListApps handles GET /api/v1/me/apps
+//
+//	@Summary		Authorised applications
+//	@Description	The third-party applications the caller has let act for them, with the scopes granted and when each last used its access
+//	@Tags			account
+//	@Produce		json
+//	@Success		200	{object}	models.OAuthGrantListResponse
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/me/apps [get]
+func (h *OAuthHandler) ListApps(c *gin.Context) {
+	grants, err := h.oauth.ListGrants(c.GetString("username"))
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	for i := range grants {
+		grants[i].Links = []models.Link{
+			{Rel: "revoke", Href: "/api/v1/me/apps/" + grants[i].ClientID, Method: http.MethodDelete},
+		}
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, models.OAuthGrantListResponse{
+		Apps: grants,
+		Links: []models.Link{
+			{Rel: "self", Href: "/api/v1/me/apps", Method: http.MethodGet},
+		},
+	})
+}
+
+// RevokeApp handles DELETE /api/v1/me/apps/:clientId
+// Withdraws the caller's grant to an application.  Every token it holds
+// for the caller stops working at once, and it must ask again for access.
+//
+//	@Summary		Revoke an application's access
+//	@Tags			account
+//	@Param			clientId	path	string	true	"Client ID"
+//	@Success		204
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		404	{object}	models.ErrorResponse	"Application not authorised"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/me/apps/{clientId} [delete]
+func (h *OAuthHandler) RevokeApp(c *gin.Context) {
+	clientID := c.Param("clientId")
+	err := h.oauth.RevokeGrant(c.GetString("username"), clientID)
+	if errors.Is(err, models.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "you have not authorised this application", Code: errcode.AppNotFound})
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	publish(c, h.events, events.AppRevoked, clientID, nil)
+	c.Status(http.StatusNoContent)
+}
+
+// withQuery adds params to uri's query string.
+func withQuery(uri string, params url.Values) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	q := u.Query()
+	for k, vs := range params {
+		q[k] = vs
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func clientLinks(id string) []models.Link {
+	return []models.Link{
+		{Rel: "delete", Href: "/api/v1/admin/oauth/clients/" + id, Method: http.MethodDelete},
+		{Rel: "collection", Href: "/api/v1/admin/oauth/clients", Method: http.MethodGet},
+		{Rel: "authorize", Href: "/api/v1/oauth/authorize?response_type=code&client_id=" + url.QueryEscape(id), Method: http.MethodGet},
+	}
+}
@@ -0,0 +1,290 @@
+package handlers_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/memory"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/handlers"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/replay"
+)
+
+const testRedirectURI = "https://tracker.example/callback?app=1"
+
+// oauthRouter serves the OAuth endpoints as the user alice, or as the
+// administrator root on /admin routes.
+func oauthRouter(t *testing.T) (*gin.Engine, *auth.JWTService) {
+	t.Helper()
+	store := memory.New()
+	repos := store.Repositories()
+	if _, err := repos.Users.CreateUser("alice", "hash"); err != nil {
+		t.Fatal(err)
+	}
+	jwt := auth.NewJWTService("test-secret", "COMP3011_API")
+	h := handlers.NewOAuthHandler(repos.OAuth, jwt, replay.NewLocal())
+	h.SetIDGenerator(&auth.SequentialIDs{Prefix: "id-"})
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("username", "alice")
+		if strings.HasPrefix(c.Request.URL.Path, "/api/v1/admin/") {
+			c.Set("username", "root")
+		}
+		c.Set("authScheme", "Bearer")
+		if v := c.GetHeader("X-Impersonator"); v != "" {
+			c.Set("impersonator", v)
+		}
+	})
+	r.POST("/api/v1/admin/oauth/clients", h.CreateClient)
+	r.GET("/api/v1/admin/oauth/clients", h.ListClients)
+	r.DELETE("/api/v1/admin/oauth/clients/:id", h.DeleteClient)
+	r.GET("/api/v1/oauth/authorize", h.GetConsent)
+	r.POST("/api/v1/oauth/authorize", h.Authorize)
+	r.POST("/api/v1/oauth/token", h.Token)
+	r.GET("/api/v1/me/apps", h.ListApps)
+	r.DELETE("/api/v1/me/apps/:clientId", h.RevokeApp)
+	return r, jwt
+}
+
+func registerClient(t *testing.T, r *gin.Engine, scopes ...string) models.OAuthClientCreatedResponse {
+	t.Helper()
+	w := doRequest(r, http.MethodPost, "/api/v1/admin/oauth/clients", models.OAuthClientRequest{
+		Name: "Fixture Tracker", RedirectURIs: []string{testRedirectURI}, Scopes: scopes,
+	})
+	assertStatus(t, w, http.StatusCreated)
+	var client models.OAuthClientCreatedResponse
+	decodeJSON(t, w, &client)
+	return client
+}
+
+// authorize approves req as alice and returns the code sent back.
+func authorize(t *testing.T, r *gin.Engine, req models.AuthorizeRequest) string {
+	t.Helper()
+	req.Approve = true
+	w := doRequest(r, http.MethodPost, "/api/v1/oauth/authorize", req)
+	assertStatus(t, w, http.StatusOK)
+	var resp models.AuthorizeResponse
+	decodeJSON(t, w, &resp)
+	u, err := url.Parse(resp.RedirectTo)
+	if err != nil || u.Query().Get("code") == "" || u.Query().Get("app") != "1" {
+		t.Fatalf("unexpected redirect %q", resp.RedirectTo)
+	}
+	return u.Query().Get("code")
+}
+
+func exchange(r *gin.Engine, client models.OAuthClientCreatedResponse, form url.Values) *httptest.ResponseRecorder {
+	form.Set("grant_type", "authorization_code")
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/oauth/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(client.ID, client.ClientSecret)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestOAuth_AuthorizationCodeFlow(t *testing.T) {
+	r, jwt := oauthRouter(t)
+	client := registerClient(t, r)
+	if client.ID != "id-1" || client.ClientSecret == "" || len(client.Scopes) != 2 || client.CreatedBy != "root" {
+		t.Fatalf("unexpected client %+v", client)
+	}
+
+	w := doRequest(r, http.MethodGet, "/api/v1/oauth/authorize?"+url.Values{
+		"response_type": {"code"}, "client_id": {client.ID}, "redirect_uri": {testRedirectURI},
+		"scope": {auth.ScopeFootballRead}, "state": {"xyz"},
+	}.Encode(), nil)
+	assertStatus(t, w, http.StatusOK)
+	var consent models.OAuthConsent
+	decodeJSON(t, w, &consent)
+	if consent.ClientName != "Fixture Tracker" || len(consent.Scopes) != 1 || consent.Scopes[0].Granted || consent.State != "xyz" {
+		t.Fatalf("unexpected consent %+v", consent)
+	}
+
+	verifier := "a-code-verifier-that-is-long-enough-for-rfc-7636"
+	sum := sha256.Sum256([]byte(verifier))
+	code := authorize(t, r, models.AuthorizeRequest{
+		ClientID: client.ID, RedirectURI: testRedirectURI, Scope: auth.ScopeFootballRead, State: "xyz",
+		CodeChallenge: base64.RawURLEncoding.EncodeToString(sum[:]), CodeChallengeMethod: "S256",
+	})
+
+	// The wrong verifier does not spend the code.
+	w = exchange(r, client, url.Values{"code": {code}, "redirect_uri": {testRedirectURI}, "code_verifier": {"wrong"}})
+	assertStatus(t, w, http.StatusBadRequest)
+	assertCode(t, w, errcode.InvalidGrant)
+
+	w = exchange(r, client, url.Values{"code": {code}, "redirect_uri": {testRedirectURI}, "code_verifier": {verifier}})
+	assertStatus(t, w, http.StatusOK)
+	var token models.OAuthTokenResponse
+	decodeJSON(t, w, &token)
+	if token.TokenType != "Bearer" || token.Scope != auth.ScopeFootballRead || token.ExpiresIn != 3600 {
+		t.Fatalf("unexpected token response %+v", token)
+	}
+	claims, err := jwt.ValidateToken(token.AccessToken)
+	if err != nil || claims.Username != "alice" || claims.ClientID != client.ID || claims.GrantID == "" || claims.AuthTime != nil {
+		t.Fatalf("unexpected claims %+v, %v", claims, err)
+	}
+
+	// Codes are one-shot.
+	w = exchange(r, client, url.Values{"code": {code}, "redirect_uri": {testRedirectURI}, "code_verifier": {verifier}})
+	assertStatus(t, w, http.StatusBadRequest)
+	assertCode(t, w, errcode.InvalidGrant)
+
+	// Approving more scopes extends the same grant.
+	authorize(t, r, models.AuthorizeRequest{ClientID: client.ID, RedirectURI: testRedirectURI, Scope: auth.ScopeFootballWrite})
+	w = doRequest(r, http.MethodGet, "/api/v1/me/apps", nil)
+	assertStatus(t, w, http.StatusOK)
+	var apps models.OAuthGrantListResponse
+	decodeJSON(t, w, &apps)
+	if len(apps.Apps) != 1 || apps.Apps[0].ClientName != "Fixture Tracker" || len(apps.Apps[0].Scopes) != 2 {
+		t.Fatalf("unexpected apps %+v", apps)
+	}
+
+	w = doRequest(r, http.MethodDelete, "/api/v1/me/apps/"+client.ID, nil)
+	assertStatus(t, w, http.StatusNoContent)
+	w = doRequest(r, http.MethodDelete, "/api/v1/me/apps/"+client.ID, nil)
+	assertStatus(t, w, http.StatusNotFound)
+	assertCode(t, w, errcode.AppNotFound)
+}
+
+func TestOAuth_Refusals(t *testing.T) {
+	r, _ := oauthRouter(t)
+	readOnly := registerClient(t, r, auth.ScopeFootballRead)
+	valid := models.AuthorizeRequest{ClientID: readOnly.ID, RedirectURI: testRedirectURI, Approve: true}
+
+	for _, tc := range []struct {
+		name         string
+		edit         func(*models.AuthorizeRequest)
+		impersonator string
+		status       int
+		code         string
+	}{
+		{"unknown client", func(r *models.AuthorizeRequest) { r.ClientID = "nope" }, "", http.StatusNotFound, errcode.ClientNotFound},
+		{"unregistered redirect", func(r *models.AuthorizeRequest) { r.RedirectURI = "https://evil.example/cb" }, "", http.StatusBadRequest, errcode.InvalidRedirectURI},
+		{"scope beyond the client's", func(r *models.AuthorizeRequest) { r.Scope = auth.ScopeFootballWrite }, "", http.StatusBadRequest, errcode.InvalidScope},
+		{"unknown scope", func(r *models.AuthorizeRequest) { r.Scope = "admin" }, "", http.StatusBadRequest, errcode.InvalidScope},
+		{"plain PKCE", func(r *models.AuthorizeRequest) { r.CodeChallenge, r.CodeChallengeMethod = "abc", "plain" }, "", http.StatusBadRequest, errcode.InvalidParameter},
+		{"impersonating", func(*models.AuthorizeRequest) {}, "root", http.StatusForbidden, errcode.ImpersonationForbidden},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := valid
+			tc.edit(&req)
+			w := doRequestWithHeader(r, http.MethodPost, "/api/v1/oauth/authorize", req, "X-Impersonator", tc.impersonator)
+			assertStatus(t, w, tc.status)
+			assertCode(t, w, tc.code)
+		})
+	}
+
+	// Denying sends the user back with error=access_denied.
+	deny := valid
+	deny.Approve, deny.State = false, "s1"
+	w := doRequest(r, http.MethodPost, "/api/v1/oauth/authorize", deny)
+	assertStatus(t, w, http.StatusOK)
+	var resp models.AuthorizeResponse
+	decodeJSON(t, w, &resp)
+	if u, _ := url.Parse(resp.RedirectTo); u.Query().Get("error") != "access_denied" || u.Query().Get("state") != "s1" || u.Query().Has("code") {
+		t.Fatalf("unexpected redirect %q", resp.RedirectTo)
+	}
+
+	code := authorize(t, r, valid)
+	other := registerClient(t, r)
+	for _, tc := range []struct {
+		name   string
+		client models.OAuthClientCreatedResponse
+		form   url.Values
+		status int
+		code   string
+	}{
+		{"wrong secret", models.OAuthClientCreatedResponse{OAuthClient: readOnly.OAuthClient, ClientSecret: "nope"},
+			url.Values{"code": {code}, "redirect_uri": {testRedirectURI}}, http.StatusUnauthorized, errcode.InvalidClient},
+		{"another client's code", other, url.Values{"code": {code}, "redirect_uri": {testRedirectURI}}, http.StatusBadRequest, errcode.InvalidGrant},
+		{"another redirect URI", readOnly, url.Values{"code": {code}, "redirect_uri": {"https://tracker.example/other"}}, http.StatusBadRequest, errcode.InvalidGrant},
+		{"a token for a code", readOnly, url.Values{"code": {"not-a-code"}, "redirect_uri": {testRedirectURI}}, http.StatusBadRequest, errcode.InvalidGrant},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := exchange(r, tc.client, tc.form)
+			assertStatus(t, w, tc.status)
+			assertCode(t, w, tc.code)
+		})
+	}
+
+	// A grant revoked before the code is exchanged yields no token.
+	assertStatus(t, doRequest(r, http.MethodDelete, "/api/v1/me/apps/"+readOnly.ID, nil), http.StatusNoContent)
+	w = exchange(r, readOnly, url.Values{"code": {code}, "redirect_uri": {testRedirectURI}})
+	assertStatus(t, w, http.StatusBadRequest)
+	assertCode(t, w, errcode.InvalidGrant)
+}
+
+func TestOAuth_CreateClientValidation(t *testing.T) {
+	r, _ := oauthRouter(t)
+	for _, tc := range []struct {
+		name string
+		req  models.OAuthClientRequest
+		code string
+	}{
+		{"no redirect URIs", models.OAuthClientRequest{Name: "App"}, errcode.FieldRequired},
+		{"plain http", models.OAuthClientRequest{Name: "App", RedirectURIs: []string{"http://app.example/cb"}}, errcode.InvalidRedirectURI},
+		{"unknown scope", models.OAuthClientRequest{Name: "App", RedirectURIs: []string{testRedirectURI}, Scopes: []string{"admin"}}, errcode.InvalidScope},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := doRequest(r, http.MethodPost, "/api/v1/admin/oauth/clients", tc.req)
+			assertStatus(t, w, http.StatusBadRequest)
+			assertCode(t, w, tc.code)
+		})
+	}
+
+	client := registerClient(t, r)
+	assertStatus(t, doRequest(r, http.MethodDelete, "/api/v1/admin/oauth/clients/"+client.ID, nil), http.StatusNoContent)
+	w := doRequest(r, http.MethodDelete, "/api/v1/admin/oauth/clients/"+client.ID, nil)
+	assertStatus(t, w, http.StatusNotFound)
+	assertCode(t, w, errcode.ClientNotFound)
+}
+
+func TestOAuth_IntrospectRevokedGrant(t *testing.T) {
+	repos := memory.New().Repositories()
+	jwt := auth.NewJWTService("test-secret", "COMP3011_API")
+	if _, err := repos.OAuth.CreateClient(models.OAuthClient{ID: "app", Name: "App"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.OAuth.GrantAccess(models.OAuthGrant{ID: "g1", Username: "alice", ClientID: "app"}); err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := jwt.GenerateDelegatedToken("alice", "app", "g1", auth.ScopeFootballRead)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handlers.NewAuthHandler(repos.Users, repos.Sessions, jwt, testHasher)
+	h.SetGrants(repos.OAuth)
+	r := gin.New()
+	r.POST("/api/v1/auth/introspect", h.Introspect)
+	active := func() models.IntrospectionResponse {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/introspect",
+			strings.NewReader(url.Values{"token": {token}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assertStatus(t, w, http.StatusOK)
+		var resp models.IntrospectionResponse
+		decodeJSON(t, w, &resp)
+		return resp
+	}
+
+	if resp := active(); !resp.Active || resp.ClientID != "app" || resp.Scope != auth.ScopeFootballRead {
+		t.Fatalf("unexpected result %+v", resp)
+	}
+	if err := repos.OAuth.RevokeGrant("alice", "app"); err != nil {
+		t.Fatal(err)
+	}
+	if resp := active(); resp.Active {
+		t.Fatalf("revoked grant still active: %+v", resp)
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/async"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// maxOperationWait caps how long GET /me/operations/:id holds a request
+// for Prefer: wait.
+const maxOperationWait = 30 * time.Second
+
+// OperationHandler serves /me/operations, the requests the caller had run
+// in the background with Prefer: respond-async.
+type OperationHandler struct {
+	queue *async.Queue
+}
+
+// NewOperationHandler constructs an OperationHandler.
+func NewOperationHandler(queue *async.Queue) *OperationHandler {
+	return &OperationHandler{queue: queue}
+}
+
+// GetOperation handles GET /api/v1/me/operations/:id
+// Returns the operation, with its response once it is done.  With Prefer:
+// wait=N the request is held for up to N seconds (at most 30) while the
+// operation is still running.
+//
+//	@Summary		Background operation
+//	@Description	Status and, once done, response of a request made with Prefer: respond-async.  Results are kept for 15 minutes on the instance that accepted the request.
+//	@Tags			account
+//	@Produce		json
+//	@Param			id		path		string	true	"Operation ID"
+//	@Param			Prefer	header		string	false	"wait=N to wait up to N seconds for the operation to finish"
+//	@Success		200		{object}	models.Operation
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		404		{object}	models.ErrorResponse	"Operation not found"
+//	@Security		Bearer
+//	@Router			/me/operations/{id} [get]
+func (h *OperationHandler) GetOperation(c *gin.Context) {
+	id := c.Param("id")
+	op, ok := h.queue.Get(c.GetString("username"), id)
+	if !ok {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "operation not found", Code: errcode.OperationNotFound})
+		return
+	}
+	if op.Status == models.OperationRunning {
+		if secs, err := strconv.Atoi(async.ParsePreferences(c.Request.Header)[async.Wait]); err == nil && secs > 0 {
+			op, _ = h.queue.Wait(id, min(time.Duration(secs)*time.Second, maxOperationWait))
+		}
+	}
+
+	self := "/api/v1/me/operations/" + op.ID
+	op.Links = []models.Link{{Rel: "self", Href: self, Method: http.MethodGet}}
+	if op.Response != nil && op.Response.Location != "" {
+		op.Links = append(op.Links, models.Link{Rel: "result", Href: op.Response.Location, Method: http.MethodGet})
+	}
+	if op.Status == models.OperationRunning {
+		// Clients polling without Prefer: wait should not hammer the API.
+		c.Header("Retry-After", "1")
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, op)
+}
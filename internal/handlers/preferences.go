@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/notify"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/prefs"
+)
+
+// PreferencesHandler serves /me/preferences, the authenticated user's
+// settings.
+type PreferencesHandler struct {
+	prefs  db.PreferencesRepository
+	events *events.Bus
+	tokens *auth.JWTService
+	mail   *notify.Queue
+}
+
+// NewPreferencesHandler constructs a PreferencesHandler.
+func NewPreferencesHandler(repo db.PreferencesRepository) *PreferencesHandler {
+	return &PreferencesHandler{prefs: repo}
+}
+
+// SetEvents publishes preference changes to bus.
+func (h *PreferencesHandler) SetEvents(bus *events.Bus) {
+	h.events = bus
+}
+
+// SetVerification has each new email address confirmed with a token signed
+// by tokens and sent to it through mail.  Until then addresses stay
+// unverified, and notifications are not emailed to them.
+func (h *PreferencesHandler) SetVerification(tokens *auth.JWTService, mail *notify.Queue) {
+	h.tokens, h.mail = tokens, mail
+}
+
+// GetPreferences handles GET /api/v1/me/preferences
+//
+//	@Summary		My preferences
+//	@Description	The authenticated user's preferences; keys never set are omitted
+//	@Tags			account
+//	@Produce		json
+//	@Success		200	{object}	models.PreferencesResponse
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/me/preferences [get]
+func (h *PreferencesHandler) GetPreferences(c *gin.Context) {
+	p, err := h.prefs.GetPreferences(c.GetString("username"))
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, models.PreferencesResponse{Preferences: p, Links: preferencesLinks()})
+}
+
+// PutPreferences handles PUT /api/v1/me/preferences
+// Replaces the caller's preferences with the object in the body; keys left
+// out are cleared.  Unknown keys and badly typed values are rejected.  A
+// verified email address stays verified while it is unchanged; a new one is
+// sent a verification token.
+//
+//	@Summary		Replace my preferences
+//	@Description	Set pageSize (1-200), locale (e.g. en-GB), email and emailNotifications
+//	@Tags			account
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		object	true	"Preferences"
+//	@Success		200		{object}	models.PreferencesResponse
+//	@Failure		400		{object}	models.ErrorResponse	"Unknown key or invalid value"
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		404		{object}	models.ErrorResponse	"Account not found"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/me/preferences [put]
+func (h *PreferencesHandler) PutPreferences(c *gin.Context) {
+	var body map[string]any
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "body must be a JSON object of preferences", Code: errcode.MalformedBody})
+		return
+	}
+	p, err := prefs.Validate(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: errcode.FieldInvalid})
+		return
+	}
+	username := c.GetString("username")
+	old, err := h.prefs.GetPreferences(username)
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	addr, _ := prefs.String(p, prefs.Email)
+	oldAddr, _ := prefs.String(old, prefs.Email)
+	if verified, _ := prefs.Bool(old, prefs.EmailVerified); verified && addr == oldAddr {
+		p[prefs.EmailVerified] = true
+	}
+	err = h.prefs.PutPreferences(username, p)
+	if errors.Is(err, models.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "account not found", Code: errcode.AccountNotFound})
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	publish(c, h.events, events.PreferencesUpdated, username, p)
+	if addr != "" && addr != oldAddr {
+		h.sendVerification(c, username, addr)
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, models.PreferencesResponse{Preferences: p, Links: preferencesLinks()})
+}
+
+// sendVerification emails addr a token with which username can confirm
+// that they receive mail there.  Failures are recorded on c but do not
+// fail the request; the user can set the address again to retry.
+func (h *PreferencesHandler) sendVerification(c *gin.Context, username, addr string) {
+	if h.tokens == nil || h.mail == nil {
+		return
+	}
+	token, err := h.tokens.GenerateEmailVerification(username, addr)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	m, err := notify.Render("verify_email", struct{ Username, Token string }{username, token}, addr)
+	if err == nil {
+		err = h.mail.Enqueue(m)
+	}
+	if err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// VerifyEmail handles POST /api/v1/me/preferences/email/verify
+// Confirms the caller's email preference with the token that was emailed
+// to it, so that notifications can be emailed there.
+//
+//	@Summary		Verify my email address
+//	@Description	Confirm the email preference with the token sent to it when it was set
+//	@Tags			account
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		models.VerifyEmailRequest	true	"Verification token"
+//	@Success		200		{object}	models.PreferencesResponse
+//	@Failure		400		{object}	models.ErrorResponse	"Malformed body, or the token is invalid, expired or for another address"
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		404		{object}	models.ErrorResponse	"Account not found"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/me/preferences/email/verify [post]
+func (h *PreferencesHandler) VerifyEmail(c *gin.Context) {
+	var req models.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "body must be {\"token\": \"...\"}", Code: errcode.MalformedBody})
+		return
+	}
+	username := c.GetString("username")
+	invalid := func() {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid or expired verification token", Code: errcode.EmailTokenInvalid})
+	}
+	if h.tokens == nil {
+		invalid()
+		return
+	}
+	claims, err := h.tokens.ValidateEmailVerification(req.Token)
+	if err != nil || claims.Username != username {
+		invalid()
+		return
+	}
+	p, err := h.prefs.GetPreferences(username)
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	if addr, _ := prefs.String(p, prefs.Email); addr != claims.Email {
+		invalid()
+		return
+	}
+	p[prefs.EmailVerified] = true
+	err = h.prefs.PutPreferences(username, p)
+	if errors.Is(err, models.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "account not found", Code: errcode.AccountNotFound})
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	publish(c, h.events, events.PreferencesUpdated, username, p)
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, models.PreferencesResponse{Preferences: p, Links: preferencesLinks()})
+}
+
+// preferredPageSize returns the pageSize preference of the caller
+// identified on c, or fallback when there is none or repo is nil.
+func preferredPageSize(c *gin.Context, repo db.PreferencesRepository, fallback int) int {
+	username := c.GetString("username")
+	if repo == nil || username == "" {
+		return fallback
+	}
+	p, err := repo.GetPreferences(username)
+	if err != nil {
+		_ = c.Error(err)
+		return fallback
+	}
+	if n, ok := prefs.Int(p, prefs.PageSize); ok && n > 0 {
+		return n
+	}
+	return fallback
+}
+
+func preferencesLinks() []models.Link {
+	return []models.Link{
+		{Rel: "self", Href: "/api/v1/me/preferences", Method: http.MethodGet},
+		{Rel: "update", Href: "/api/v1/me/preferences", Method: http.MethodPut},
+	}
+}
@@ -0,0 +1,173 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/memory"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/handlers"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/notify"
+	"github.com/sc23bd/COMP3011_Coursework1/pkg/repository/fake"
+)
+
+func TestPreferences(t *testing.T) {
+	repos := memory.New().Repositories()
+	if _, err := repos.Users.CreateUser("alice", "hash"); err != nil {
+		t.Fatal(err)
+	}
+	h := handlers.NewPreferencesHandler(repos.Preferences)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("username", "alice") })
+	r.GET("/api/v1/me/preferences", h.GetPreferences)
+	r.PUT("/api/v1/me/preferences", h.PutPreferences)
+
+	w := doRequest(r, http.MethodGet, "/api/v1/me/preferences", nil)
+	assertStatus(t, w, http.StatusOK)
+	var resp models.PreferencesResponse
+	decodeJSON(t, w, &resp)
+	if len(resp.Preferences) != 0 {
+		t.Fatalf("expected no preferences, got %v", resp.Preferences)
+	}
+
+	assertStatus(t, doRequest(r, http.MethodPut, "/api/v1/me/preferences", map[string]any{"theme": "dark"}), http.StatusBadRequest)
+	assertStatus(t, doRequest(r, http.MethodPut, "/api/v1/me/preferences", []int{1}), http.StatusBadRequest)
+
+	w = doRequest(r, http.MethodPut, "/api/v1/me/preferences", map[string]any{"pageSize": 10, "locale": "fr"})
+	assertStatus(t, w, http.StatusOK)
+
+	// PUT replaces the whole object.
+	assertStatus(t, doRequest(r, http.MethodPut, "/api/v1/me/preferences", map[string]any{"pageSize": 20}), http.StatusOK)
+	w = doRequest(r, http.MethodGet, "/api/v1/me/preferences", nil)
+	resp = models.PreferencesResponse{}
+	decodeJSON(t, w, &resp)
+	if len(resp.Preferences) != 1 || resp.Preferences["pageSize"] != float64(20) {
+		t.Fatalf("unexpected preferences %v", resp.Preferences)
+	}
+}
+
+// mailbox records the messages sent through a notify.Queue.
+type mailbox struct {
+	mu   sync.Mutex
+	sent []notify.Message
+}
+
+func (m *mailbox) Send(_ context.Context, msg notify.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestPreferences_VerifyEmail(t *testing.T) {
+	repos := memory.New().Repositories()
+	for _, u := range []string{"alice", "bob"} {
+		if _, err := repos.Users.CreateUser(u, "hash"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	box := &mailbox{}
+	mail := notify.NewQueue(box, 0)
+	tokens := auth.NewJWTService("test-secret", "COMP3011_API")
+	h := handlers.NewPreferencesHandler(repos.Preferences)
+	h.SetVerification(tokens, mail)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("username", "alice") })
+	r.PUT("/api/v1/me/preferences", h.PutPreferences)
+	r.POST("/api/v1/me/preferences/email/verify", h.VerifyEmail)
+	verified := func() any {
+		p, _ := repos.Preferences.GetPreferences("alice")
+		return p["emailVerified"]
+	}
+	put := func(body map[string]any) {
+		t.Helper()
+		assertStatus(t, doRequest(r, http.MethodPut, "/api/v1/me/preferences", body), http.StatusOK)
+	}
+	verify := func(token string, want int) {
+		t.Helper()
+		assertStatus(t, doRequest(r, http.MethodPost, "/api/v1/me/preferences/email/verify", map[string]string{"token": token}), want)
+	}
+
+	put(map[string]any{"email": "alice@example.com", "emailNotifications": true})
+	put(map[string]any{"email": "alice@example.com", "emailNotifications": true, "pageSize": 5})
+	if err := mail.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(box.sent) != 1 || box.sent[0].To[0] != "alice@example.com" {
+		t.Fatalf("expected one verification email, got %+v", box.sent)
+	}
+	if verified() != nil {
+		t.Fatal("expected the address to be unverified")
+	}
+
+	// Tokens for another user or address are refused.
+	bobs, _ := tokens.GenerateEmailVerification("bob", "alice@example.com")
+	verify(bobs, http.StatusBadRequest)
+	other, _ := tokens.GenerateEmailVerification("alice", "other@example.com")
+	verify(other, http.StatusBadRequest)
+	verify("not-a-token", http.StatusBadRequest)
+
+	var token string
+	for _, line := range strings.Split(box.sent[0].Text, "\n") {
+		if strings.Count(line, ".") == 2 && !strings.Contains(line, " ") {
+			token = line
+		}
+	}
+	verify(token, http.StatusOK)
+	if verified() != true {
+		t.Fatal("expected the address to be verified")
+	}
+
+	// The address stays verified until it changes.
+	put(map[string]any{"email": "alice@example.com", "emailNotifications": true})
+	if verified() != true {
+		t.Fatal("expected an unchanged address to stay verified")
+	}
+	assertStatus(t, doRequest(r, http.MethodPut, "/api/v1/me/preferences", map[string]any{"emailVerified": true}), http.StatusBadRequest)
+	put(map[string]any{"email": "new@example.com", "emailNotifications": true})
+	if verified() != nil {
+		t.Fatal("expected a new address to be unverified")
+	}
+}
+
+func TestListMatches_PageSizePreference(t *testing.T) {
+	var limit int
+	repo := &fake.Football{ListMatchesFunc: func(l, _ int, _ models.TimeFilter) ([]models.Match, error) {
+		limit = l
+		return nil, nil
+	}}
+	h := handlers.NewFootballHandler(repo)
+	h.SetPreferences(&fake.Preferences{GetPreferencesFunc: func(username string) (map[string]any, error) {
+		if username == "alice" {
+			return map[string]any{"pageSize": 5}, nil
+		}
+		return map[string]any{}, nil
+	}})
+
+	for _, tc := range []struct {
+		user, query string
+		want        int
+	}{
+		{"", "", 50},
+		{"bob", "", 50},
+		{"alice", "", 5},
+		{"alice", "?limit=7", 7},
+	} {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			if tc.user != "" {
+				c.Set("username", tc.user)
+			}
+		})
+		r.GET("/api/v1/football/matches", h.ListMatches)
+		assertStatus(t, doRequest(r, http.MethodGet, "/api/v1/football/matches"+tc.query, nil), http.StatusOK)
+		if limit != tc.want {
+			t.Errorf("user %q, query %q: limit %d, want %d", tc.user, tc.query, limit, tc.want)
+		}
+	}
+}
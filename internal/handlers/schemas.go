@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/codec"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/schema"
+)
+
+// SchemaContentType is the media type of a served JSON Schema.
+const SchemaContentType = "application/schema+json"
+
+// SchemaHandler serves the JSON Schemas in a registry under base, which
+// ends in a slash.
+type SchemaHandler struct {
+	registry *schema.Registry
+	base     string
+}
+
+// NewSchemaHandler constructs a SchemaHandler.
+func NewSchemaHandler(registry *schema.Registry, base string) *SchemaHandler {
+	return &SchemaHandler{registry: registry, base: base}
+}
+
+// ListSchemas handles GET /api/v1/schemas
+// Lists the schemas that responses link to in their X-Schema header.
+//
+//	@Summary		List response schemas
+//	@Description	Names and URLs of the JSON Schemas (draft 2020-12) describing the API's responses
+//	@Tags			meta
+//	@Produce		json
+//	@Success		200	{object}	models.SchemaListResponse
+//	@Router			/schemas [get]
+func (h *SchemaHandler) ListSchemas(c *gin.Context) {
+	names := h.registry.Names()
+	data := make([]models.SchemaSummary, len(names))
+	for i, name := range names {
+		data[i] = models.SchemaSummary{Name: name, Href: h.base + name}
+	}
+	c.JSON(http.StatusOK, models.SchemaListResponse{
+		Data: data,
+		Links: []models.Link{
+			{Rel: "self", Href: h.base[:len(h.base)-1], Method: http.MethodGet},
+		},
+	})
+}
+
+// GetSchema handles GET /api/v1/schemas/:name
+// Returns one JSON Schema, derived from the Go type the handlers encode, so
+// it cannot drift from the responses it describes.
+//
+//	@Summary		Get a response schema
+//	@Description	A JSON Schema (draft 2020-12) with the types it uses under $defs
+//	@Tags			meta
+//	@Produce		json
+//	@Param			name	path		string	true	"Schema name, as in X-Schema"
+//	@Success		200		{object}	object
+//	@Failure		404		{object}	models.ErrorResponse	"Unknown schema"
+//	@Router			/schemas/{name} [get]
+func (h *SchemaHandler) GetSchema(c *gin.Context) {
+	name := c.Param("name")
+	s, ok := h.registry.Lookup(name, h.base+name)
+	if !ok {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "schema not found", Code: errcode.SchemaNotFound})
+		return
+	}
+	c.Header("Content-Type", SchemaContentType)
+	c.JSON(http.StatusOK, s)
+}
+
+// GetFootballProto handles GET /api/v1/schemas/football.proto
+// Returns the Protocol Buffers messages of the application/x-protobuf
+// encoding, for clients that generate their types with protoc.
+//
+//	@Summary		Protocol Buffers definitions
+//	@Description	The .proto file of the messages exchanged as application/x-protobuf by the team and match endpoints
+//	@Tags			meta
+//	@Produce		plain
+//	@Success		200	{string}	string	"football.proto"
+//	@Router			/schemas/football.proto [get]
+func GetFootballProto(c *gin.Context) {
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(codec.FootballProto))
+}
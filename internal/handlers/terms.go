@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// TermsHandler serves /me/terms, through which a user sees and accepts the
+// terms-of-service version in force.
+type TermsHandler struct {
+	terms   db.TermsRepository
+	version string
+	events  *events.Bus
+}
+
+// NewTermsHandler constructs a TermsHandler requiring version.
+func NewTermsHandler(terms db.TermsRepository, version string) *TermsHandler {
+	return &TermsHandler{terms: terms, version: version}
+}
+
+// SetEvents publishes terms acceptances to bus.
+func (h *TermsHandler) SetEvents(bus *events.Bus) {
+	h.events = bus
+}
+
+// GetTerms handles GET /api/v1/me/terms
+//
+//	@Summary		My terms-of-service status
+//	@Description	The current terms version and the latest one the authenticated user has accepted
+//	@Tags			account
+//	@Produce		json
+//	@Success		200	{object}	models.TermsStatus
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/me/terms [get]
+func (h *TermsHandler) GetTerms(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	latest, err := h.terms.LatestTerms(c.GetString("username"))
+	if err != nil && !errors.Is(err, models.ErrNotFound) {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	status := models.TermsStatus{CurrentVersion: h.version, Links: termsLinks()}
+	if err == nil {
+		status.Accepted = &latest
+		status.UpToDate = latest.Version == h.version
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// AcceptTerms handles PUT /api/v1/me/terms
+// Records that the caller accepts the current terms, for example after the
+// version was bumped.  Only the current version can be accepted.
+//
+//	@Summary		Accept the terms of service
+//	@Description	Accept the current terms-of-service version
+//	@Tags			account
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		models.AcceptTermsRequest	true	"Version being accepted"
+//	@Success		200		{object}	models.TermsStatus
+//	@Failure		400		{object}	models.ErrorResponse	"Not the current version"
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		404		{object}	models.ErrorResponse	"Account not found"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/me/terms [put]
+func (h *TermsHandler) AcceptTerms(c *gin.Context) {
+	var req models.AcceptTermsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: errcode.Binding(err)})
+		return
+	}
+	if req.Version != h.version {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "version must be the current terms version " + h.version, Code: errcode.FieldInvalid})
+		return
+	}
+	accepted, err := h.terms.AcceptTerms(c.GetString("username"), req.Version)
+	if errors.Is(err, models.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "account not found", Code: errcode.AccountNotFound})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	publish(c, h.events, events.TermsAccepted, c.GetString("username"), accepted)
+	c.JSON(http.StatusOK, models.TermsStatus{
+		CurrentVersion: h.version,
+		Accepted:       &accepted,
+		UpToDate:       true,
+		Links:          termsLinks(),
+	})
+}
+
+func termsLinks() []models.Link {
+	return []models.Link{
+		{Rel: "self", Href: "/api/v1/me/terms", Method: http.MethodGet},
+		{Rel: "accept", Href: "/api/v1/me/terms", Method: http.MethodPut},
+	}
+}
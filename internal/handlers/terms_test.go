@@ -0,0 +1,51 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/memory"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/handlers"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+func TestTerms(t *testing.T) {
+	repos := memory.New().Repositories()
+	authHandler := handlers.NewAuthHandler(repos.Users, repos.Sessions, auth.NewJWTService("test-secret", "COMP3011_API"), testHasher)
+	authHandler.SetTerms(repos.Terms, "v1")
+
+	r := gin.New()
+	r.POST("/api/v1/auth/register", authHandler.Register)
+
+	w := doRequest(r, http.MethodPost, "/api/v1/auth/register",
+		models.RegisterRequest{Username: "alice", Password: "password123"})
+	assertStatus(t, w, http.StatusBadRequest)
+	w = doRequest(r, http.MethodPost, "/api/v1/auth/register",
+		models.RegisterRequest{Username: "alice", Password: "password123", AcceptTerms: "v1"})
+	assertStatus(t, w, http.StatusCreated)
+
+	// The terms are bumped to v2.
+	termsHandler := handlers.NewTermsHandler(repos.Terms, "v2")
+	me := gin.New()
+	me.Use(func(c *gin.Context) { c.Set("username", "alice") })
+	me.GET("/api/v1/me/terms", termsHandler.GetTerms)
+	me.PUT("/api/v1/me/terms", termsHandler.AcceptTerms)
+
+	w = doRequest(me, http.MethodGet, "/api/v1/me/terms", nil)
+	assertStatus(t, w, http.StatusOK)
+	var status models.TermsStatus
+	decodeJSON(t, w, &status)
+	if status.CurrentVersion != "v2" || status.UpToDate || status.Accepted == nil || status.Accepted.Version != "v1" {
+		t.Fatalf("unexpected status %+v", status)
+	}
+
+	assertStatus(t, doRequest(me, http.MethodPut, "/api/v1/me/terms", models.AcceptTermsRequest{Version: "v1"}), http.StatusBadRequest)
+	w = doRequest(me, http.MethodPut, "/api/v1/me/terms", models.AcceptTermsRequest{Version: "v2"})
+	assertStatus(t, w, http.StatusOK)
+	decodeJSON(t, w, &status)
+	if !status.UpToDate || status.Accepted.Version != "v2" {
+		t.Fatalf("unexpected status %+v", status)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
 )
 
 func init() {
@@ -64,6 +65,19 @@ func decodeJSON(t interface {
 	}
 }
 
+// assertCode verifies the code of an error response, leaving the body
+// unread.
+func assertCode(t interface {
+	Helper()
+	Errorf(string, ...interface{})
+}, w *httptest.ResponseRecorder, want string) {
+	t.Helper()
+	var resp models.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Code != want {
+		t.Errorf("expected code %s, got %s", want, w.Body.String())
+	}
+}
+
 // checkHeader verifies that a response header is non-empty.
 func checkHeader(t interface {
 	Helper()
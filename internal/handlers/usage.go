@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/metering"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// maxUsageDays bounds the range of a usage query.
+const maxUsageDays = 366
+
+// defaultUsageRollupLimit is the default page size of /admin/usage.
+const defaultUsageRollupLimit = 100
+
+// UsageHandler serves metered API usage: /me/usage for the caller and
+// /admin/usage for operators.
+type UsageHandler struct {
+	repo db.MeteringRepository
+}
+
+// NewUsageHandler constructs a UsageHandler.
+func NewUsageHandler(repo db.MeteringRepository) *UsageHandler {
+	return &UsageHandler{repo: repo}
+}
+
+// usageRange reads ?from= and ?to= (YYYY-MM-DD, default the current UTC
+// month so far), writing 400 and returning false if they are invalid.
+func usageRange(c *gin.Context) (from, to string, ok bool) {
+	now := time.Now().UTC()
+	end := now
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &start}, {"to", &end}} {
+		if v := c.Query(p.name); v != "" {
+			t, err := time.Parse(metering.DateLayout, v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: p.name + " must be YYYY-MM-DD", Code: errcode.InvalidParameter})
+				return "", "", false
+			}
+			*p.dst = t
+		}
+	}
+	if start.After(end) || end.Sub(start) >= maxUsageDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: fmt.Sprintf("from must be on or before to, at most %d days earlier", maxUsageDays-1), Code: errcode.InvalidParameter})
+		return "", "", false
+	}
+	return start.Format(metering.DateLayout), end.Format(metering.DateLayout), true
+}
+
+// GetMyUsage handles GET /api/v1/me/usage
+// Returns the caller's metered usage per day and API key, with totals.
+// Counts are stored once a minute, so the latest requests may be missing.
+//
+//	@Summary		My API usage
+//	@Description	Requests and bytes in and out per UTC day and API key, for the current month unless a range is given
+//	@Tags			account
+//	@Produce		json
+//	@Param			from	query		string	false	"First day as YYYY-MM-DD (default the first of this month)"
+//	@Param			to		query		string	false	"Last day as YYYY-MM-DD (default today)"
+//	@Success		200		{object}	models.UsageResponse
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid range"
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/me/usage [get]
+func (h *UsageHandler) GetMyUsage(c *gin.Context) {
+	from, to, ok := usageRange(c)
+	if !ok {
+		return
+	}
+	records, err := h.repo.ListUsage(c.GetString("username"), from, to)
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+
+	var total models.UsageTotal
+	for _, r := range records {
+		total.Requests += r.Requests
+		total.BytesIn += r.BytesIn
+		total.BytesOut += r.BytesOut
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, models.UsageResponse{
+		From:  from,
+		To:    to,
+		Total: total,
+		Data:  records,
+		Links: []models.Link{
+			{Rel: "self", Href: "/api/v1/me/usage?from=" + from + "&to=" + to, Method: http.MethodGet},
+		},
+	})
+}
+
+// GetUsageRollup handles GET /api/v1/admin/usage
+// Returns usage summed per caller and API key over a range, most requests
+// first, for billing and capacity planning.
+//
+//	@Summary		API usage rollup
+//	@Description	Requests and bytes in and out per caller and API key over a range of UTC days
+//	@Tags			admin
+//	@Produce		json
+//	@Param			from	query		string	false	"First day as YYYY-MM-DD (default the first of this month)"
+//	@Param			to		query		string	false	"Last day as YYYY-MM-DD (default today)"
+//	@Param			limit	query		int		false	"Page size (default 100)"
+//	@Param			offset	query		int		false	"Page offset"	default(0)
+//	@Success		200		{object}	models.UsageRollupResponse
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid query parameters"
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403		{object}	models.ErrorResponse	"Admin privileges required"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Security		Bearer
+//	@Router			/admin/usage [get]
+func (h *UsageHandler) GetUsageRollup(c *gin.Context) {
+	from, to, ok := usageRange(c)
+	if !ok {
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultUsageRollupLimit)))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "limit must be a positive integer", Code: errcode.InvalidParameter})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "offset must be a non-negative integer", Code: errcode.InvalidParameter})
+		return
+	}
+
+	totals, err := h.repo.UsageTotals(from, to, limit, offset)
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+		return
+	}
+	base := "/api/v1/admin/usage?from=" + from + "&to=" + to + "&limit=" + strconv.Itoa(limit) + "&offset="
+	links := []models.Link{{Rel: "self", Href: base + strconv.Itoa(offset), Method: http.MethodGet}}
+	if offset > 0 {
+		links = append(links, models.Link{Rel: "prev", Href: base + strconv.Itoa(max(offset-limit, 0)), Method: http.MethodGet})
+	}
+	if len(totals) == limit {
+		links = append(links, models.Link{Rel: "next", Href: base + strconv.Itoa(offset+limit), Method: http.MethodGet})
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, models.UsageRollupResponse{From: from, To: to, Data: totals, Links: links})
+}
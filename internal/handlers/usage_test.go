@@ -0,0 +1,68 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/memory"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/handlers"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+func TestUsage(t *testing.T) {
+	repos := memory.New().Repositories()
+	today := time.Now().UTC().Format("2006-01-02")
+	if err := repos.Metering.AddUsage([]models.UsageRecord{
+		{Date: "2024-03-14", Username: "alice", Requests: 2, BytesIn: 10, BytesOut: 100},
+		{Date: "2024-03-15", Username: "alice", Requests: 3, BytesOut: 50},
+		{Date: "2024-03-15", Username: "alice", Key: "ci", Requests: 1},
+		{Date: "2024-03-15", Username: "bob", Requests: 10},
+		{Date: today, Username: "alice", Requests: 1},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	h := handlers.NewUsageHandler(repos.Metering)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("username", "alice") })
+	r.GET("/api/v1/me/usage", h.GetMyUsage)
+	r.GET("/api/v1/admin/usage", h.GetUsageRollup)
+
+	w := doRequest(r, http.MethodGet, "/api/v1/me/usage?from=2024-03-01&to=2024-03-31", nil)
+	assertStatus(t, w, http.StatusOK)
+	var mine models.UsageResponse
+	decodeJSON(t, w, &mine)
+	if len(mine.Data) != 3 || mine.Total.Requests != 6 || mine.Total.BytesIn != 10 || mine.Total.BytesOut != 150 {
+		t.Fatalf("unexpected usage %+v", mine)
+	}
+
+	// The default range is the current month so far.
+	w = doRequest(r, http.MethodGet, "/api/v1/me/usage", nil)
+	assertStatus(t, w, http.StatusOK)
+	mine = models.UsageResponse{}
+	decodeJSON(t, w, &mine)
+	if mine.To != today || len(mine.Data) != 1 {
+		t.Fatalf("unexpected default range %+v", mine)
+	}
+
+	for _, q := range []string{"?from=15-03-2024", "?from=2024-03-15&to=2024-03-14", "?from=2023-01-01&to=2024-03-15"} {
+		assertStatus(t, doRequest(r, http.MethodGet, "/api/v1/me/usage"+q, nil), http.StatusBadRequest)
+	}
+
+	w = doRequest(r, http.MethodGet, "/api/v1/admin/usage?from=2024-03-01&to=2024-03-31&limit=2", nil)
+	assertStatus(t, w, http.StatusOK)
+	var rollup models.UsageRollupResponse
+	decodeJSON(t, w, &rollup)
+	if len(rollup.Data) != 2 || rollup.Data[0].Username != "bob" || rollup.Data[1].Requests != 5 {
+		t.Fatalf("unexpected rollup %+v", rollup.Data)
+	}
+	var next bool
+	for _, l := range rollup.Links {
+		next = next || l.Rel == "next"
+	}
+	if !next {
+		t.Fatalf("expected a next link, got %+v", rollup.Links)
+	}
+	assertStatus(t, doRequest(r, http.MethodGet, "/api/v1/admin/usage?limit=0", nil), http.StatusBadRequest)
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/version"
+)
+
+// GetVersion handles GET /api/v1/version
+// Returns the semantic version, git commit and build date of the running
+// binary together with Go runtime details, so operators can confirm exactly
+// what is deployed.
+//
+//	@Summary		Get build version
+//	@Description	Returns the API version, git commit, build date and Go runtime information
+//	@Tags			meta
+//	@Produce		json
+//	@Success		200	{object}	version.Info
+//	@Router			/version [get]
+func GetVersion(c *gin.Context) {
+	c.JSON(http.StatusOK, version.Get())
+}
@@ -0,0 +1,31 @@
+package handlers_test
+
+import (
+	"net/http"
+	"runtime"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/handlers"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/version"
+)
+
+func TestGetVersion(t *testing.T) {
+	r := gin.New()
+	r.GET("/api/v1/version", handlers.GetVersion)
+
+	w := doRequest(r, http.MethodGet, "/api/v1/version", nil)
+	assertStatus(t, w, http.StatusOK)
+
+	var info version.Info
+	decodeJSON(t, w, &info)
+	if info.Version != version.Version {
+		t.Errorf("expected version %q, got %q", version.Version, info.Version)
+	}
+	if info.GoVersion != runtime.Version() {
+		t.Errorf("expected Go version %q, got %q", runtime.Version(), info.GoVersion)
+	}
+	if info.Platform == "" || info.Commit == "" {
+		t.Errorf("expected platform and commit to be populated, got %+v", info)
+	}
+}
@@ -0,0 +1,194 @@
+// Package health tracks the three states reported to a container
+// orchestrator: live (the process is running and should not be restarted),
+// started (initialisation, such as waiting for migrations, has finished) and
+// ready (the instance should receive traffic).
+//
+// Readiness also checks the instance's dependencies, each timed and
+// reported by name.  A critical dependency that fails makes the instance
+// unavailable; a non-critical one that fails or answers slowly only makes it
+// degraded, which still receives traffic.  The latest result for each
+// dependency is exported as the dependency_up and
+// dependency_latency_seconds gauges.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/metrics"
+)
+
+// Check reports a problem that makes a probe fail.
+type Check func(ctx context.Context) error
+
+// ErrDraining is reported by Ready once shutdown has begun.
+var ErrDraining = errors.New("shutting down")
+
+// ErrNotStarted is reported by Ready before Started has succeeded.
+var ErrNotStarted = errors.New("still starting")
+
+// Readiness states.
+const (
+	StatusOK          = "ok"
+	StatusDegraded    = "degraded"
+	StatusUnavailable = "unavailable"
+)
+
+// Dependency is a service readiness relies on.
+type Dependency struct {
+	// Name identifies the dependency in reports and metric labels.
+	Name  string
+	Check Check
+	// Critical dependencies make the instance unavailable when they fail;
+	// others only degrade it.
+	Critical bool
+	// Slow, if positive, is the latency above which a passing check is
+	// reported as degraded.
+	Slow time.Duration
+}
+
+// Result is the outcome of checking one dependency.
+type Result struct {
+	Name     string
+	Critical bool
+	// Status is StatusOK, StatusDegraded (slow) or, for a failed check,
+	// StatusUnavailable.
+	Status  string
+	Latency time.Duration
+	Err     error
+}
+
+// Report is the outcome of a readiness probe.
+type Report struct {
+	// Status is StatusOK, StatusDegraded or StatusUnavailable.
+	Status string
+	// Err explains why the instance is unavailable.
+	Err error
+	// Dependencies holds a result for each dependency, in the order they
+	// were added.  It is empty if the probe failed before checking them.
+	Dependencies []Result
+}
+
+var (
+	dependencyUp = metrics.NewGauge("dependency_up",
+		"Whether the dependency passed its last readiness check (1) or failed it (0).", "dependency")
+	dependencyLatency = metrics.NewGauge("dependency_latency_seconds",
+		"How long the dependency took to answer its last readiness check.", "dependency")
+)
+
+// Probes evaluates the startup and readiness checks.  It is safe for
+// concurrent use.
+type Probes struct {
+	startup Check
+	ready   Check
+
+	mu   sync.Mutex
+	deps []Dependency
+
+	started  atomic.Bool
+	draining atomic.Bool
+}
+
+// New returns probes that run startup until it first succeeds and ready on
+// every readiness probe.  Either may be nil.
+func New(startup, ready Check) *Probes {
+	return &Probes{startup: startup, ready: ready}
+}
+
+// Started reports whether initialisation has finished.  Once startup
+// succeeds it is not run again, so a later outage fails readiness rather
+// than startup.
+func (p *Probes) Started(ctx context.Context) error {
+	if p.started.Load() {
+		return nil
+	}
+	if p.startup != nil {
+		if err := p.startup(ctx); err != nil {
+			return err
+		}
+	}
+	p.started.Store(true)
+	return nil
+}
+
+// Depend adds a dependency to check on every readiness probe.
+func (p *Probes) Depend(d Dependency) {
+	p.mu.Lock()
+	p.deps = append(p.deps, d)
+	p.mu.Unlock()
+}
+
+// Ready reports whether the instance should receive traffic: it has
+// started, is not draining, the readiness check passes and no critical
+// dependency fails.  A degraded instance is ready.
+func (p *Probes) Ready(ctx context.Context) error {
+	return p.Readiness(ctx).Err
+}
+
+// Readiness runs the readiness check and checks every dependency
+// concurrently, and reports the result of each.
+func (p *Probes) Readiness(ctx context.Context) Report {
+	if p.draining.Load() {
+		return Report{Status: StatusUnavailable, Err: ErrDraining}
+	}
+	if !p.started.Load() {
+		if err := p.Started(ctx); err != nil {
+			return Report{Status: StatusUnavailable, Err: ErrNotStarted}
+		}
+	}
+
+	p.mu.Lock()
+	deps := p.deps
+	p.mu.Unlock()
+	results := make([]Result, len(deps))
+	var wg sync.WaitGroup
+	for i, d := range deps {
+		wg.Go(func() { results[i] = check(ctx, d) })
+	}
+	var err error
+	if p.ready != nil {
+		err = p.ready(ctx)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Err: err, Dependencies: results}
+	for _, r := range results {
+		switch {
+		case r.Err != nil && r.Critical:
+			if report.Err == nil {
+				report.Err = fmt.Errorf("%s: %w", r.Name, r.Err)
+			}
+		case r.Status != StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+	if report.Err != nil {
+		report.Status = StatusUnavailable
+	}
+	return report
+}
+
+// check times d's check and records the result in the gauges.
+func check(ctx context.Context, d Dependency) Result {
+	start := time.Now()
+	err := d.Check(ctx)
+	r := Result{Name: d.Name, Critical: d.Critical, Status: StatusOK, Latency: time.Since(start), Err: err}
+	up := 1.0
+	switch {
+	case err != nil:
+		r.Status, up = StatusUnavailable, 0
+	case d.Slow > 0 && r.Latency > d.Slow:
+		r.Status = StatusDegraded
+	}
+	dependencyUp.Set(up, d.Name)
+	dependencyLatency.Set(r.Latency.Seconds(), d.Name)
+	return r
+}
+
+// Drain makes Ready fail from now on, so that load balancers stop sending
+// new requests before the server stops accepting them.
+func (p *Probes) Drain() { p.draining.Store(true) }
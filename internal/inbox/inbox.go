@@ -0,0 +1,118 @@
+// Package inbox turns domain events into notifications in users' inboxes,
+// served by /me/notifications, and emails a copy to users who opted in
+// through their preferences.
+package inbox
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/notify"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/prefs"
+)
+
+// Notification types.
+const (
+	TypeWelcome = "welcome"
+	TypeSignIn  = "sign-in"
+	// TypeAnnouncement is a copy of an operator announcement, sent to
+	// every user.
+	TypeAnnouncement = "announcement"
+)
+
+// Inbox delivers notifications.
+type Inbox struct {
+	repo  db.NotificationRepository
+	prefs db.PreferencesRepository
+	mail  *notify.Queue
+}
+
+// New returns an Inbox storing notifications in repo.  When mail is set,
+// users whose preferences hold a verified email address and
+// emailNotifications are also sent each notification by email; prefs and
+// mail may be nil.
+func New(repo db.NotificationRepository, prefs db.PreferencesRepository, mail *notify.Queue) *Inbox {
+	return &Inbox{repo: repo, prefs: prefs, mail: mail}
+}
+
+// Subscribe delivers a notification for each registration, sign-in from an
+// unfamiliar device or location, and announcement published on bus from
+// now on.  Notifications are stored before the response is sent, so a
+// client sees them as soon as its request completes.
+func (i *Inbox) Subscribe(bus *events.Bus) {
+	bus.Subscribe(events.BeforeResponse, i, events.UserRegistered, events.UnfamiliarLogin, events.AnnouncementCreated)
+}
+
+// HandleEvent implements events.Subscriber.
+func (i *Inbox) HandleEvent(ctx context.Context, e events.Event) error {
+	switch e.Type {
+	case events.UserRegistered:
+		return i.Deliver(ctx, e.ID, models.Notification{
+			Type:  TypeWelcome,
+			Title: "Welcome to the Football API",
+			Body:  "Your account is ready. Set your page size and email notifications through your preferences.",
+			Href:  "/api/v1/me/preferences",
+		})
+	case events.UnfamiliarLogin:
+		l, _ := e.Data.(models.Login)
+		from := "a new device"
+		switch {
+		case l.NewDevice && l.NewLocation:
+			from = "a new device and location"
+		case l.NewLocation:
+			from = "a new location"
+		}
+		return i.Deliver(ctx, e.ID, models.Notification{
+			Type:  TypeSignIn,
+			Title: "Sign-in from " + from,
+			Body:  fmt.Sprintf("Signed in with %s from %s. If this was not you, revoke the session in /me/sessions and change your password.", l.Device, l.IPAddress),
+			Href:  "/api/v1/me/logins",
+		})
+	case events.AnnouncementCreated:
+		a, ok := e.Data.(models.Announcement)
+		if !ok {
+			return nil
+		}
+		_, err := i.repo.BroadcastNotification(models.Notification{
+			Type:           TypeAnnouncement,
+			Title:          a.Title,
+			Body:           a.Body,
+			CreatedAt:      a.PublishAt,
+			ExpiresAt:      a.ExpiresAt,
+			AnnouncementID: a.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("inbox: broadcast announcement %d: %w", a.ID, err)
+		}
+	}
+	return nil
+}
+
+// Deliver stores n in the user's inbox and, if they opted in and have
+// verified their email address, emails it to them.
+func (i *Inbox) Deliver(ctx context.Context, username string, n models.Notification) error {
+	stored, err := i.repo.AddNotification(username, n)
+	if err != nil {
+		return fmt.Errorf("inbox: deliver %s to %s: %w", n.Type, username, err)
+	}
+	if i.mail == nil || i.prefs == nil {
+		return nil
+	}
+	p, err := i.prefs.GetPreferences(username)
+	if err != nil {
+		return fmt.Errorf("inbox: preferences of %s: %w", username, err)
+	}
+	addr, _ := prefs.String(p, prefs.Email)
+	verified, _ := prefs.Bool(p, prefs.EmailVerified)
+	if on, _ := prefs.Bool(p, prefs.EmailNotifications); !on || addr == "" || !verified {
+		return nil
+	}
+	m, err := notify.Render("notification", stored, addr)
+	if err != nil {
+		return err
+	}
+	return i.mail.Enqueue(m)
+}
@@ -0,0 +1,72 @@
+package inbox_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/memory"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/inbox"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/notify"
+)
+
+type sender struct {
+	mu   sync.Mutex
+	sent []notify.Message
+}
+
+func (s *sender) Send(_ context.Context, m notify.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, m)
+	return nil
+}
+
+func TestInbox(t *testing.T) {
+	repos := memory.New().Repositories()
+	for _, u := range []string{"alice", "bob"} {
+		if _, err := repos.Users.CreateUser(u, "hash"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repos.Preferences.PutPreferences("alice", map[string]any{"email": "alice@example.com", "emailNotifications": true, "emailVerified": true}); err != nil {
+		t.Fatal(err)
+	}
+	// bob's address is not verified.
+	if err := repos.Preferences.PutPreferences("bob", map[string]any{"email": "bob@example.com", "emailNotifications": true}); err != nil {
+		t.Fatal(err)
+	}
+	s := &sender{}
+	mail := notify.NewQueue(s, 0)
+	bus := events.NewBus(nil)
+	inbox.New(repos.Notifications, repos.Preferences, mail).Subscribe(bus)
+
+	ctx := context.Background()
+	bus.Publish(ctx, events.Event{Type: events.UserRegistered, ID: "alice"})
+	bus.Publish(ctx, events.Event{Type: events.SessionCreated, ID: "bob",
+		Data: models.Session{Username: "bob", DeviceLabel: "phone", IPAddress: "192.0.2.1"}})
+	bus.Publish(ctx, events.Event{Type: events.UnfamiliarLogin, ID: "bob",
+		Data: models.Login{Username: "bob", Device: "Safari on iOS", IPAddress: "192.0.2.1", NewLocation: true}})
+	bus.Publish(ctx, events.Event{Type: events.TeamCreated, ID: "1", Actor: "alice"})
+	if err := mail.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	list, _ := repos.Notifications.ListNotifications("alice", 10, 0, false)
+	if len(list) != 1 || list[0].Type != inbox.TypeWelcome {
+		t.Fatalf("alice: unexpected notifications %+v", list)
+	}
+	list, _ = repos.Notifications.ListNotifications("bob", 10, 0, false)
+	if len(list) != 1 || list[0].Type != inbox.TypeSignIn || list[0].Title != "Sign-in from a new location" ||
+		!strings.Contains(list[0].Body, "192.0.2.1") {
+		t.Fatalf("bob: unexpected notifications %+v", list)
+	}
+
+	// Only alice opted in to email with a verified address.
+	if len(s.sent) != 1 || s.sent[0].To[0] != "alice@example.com" || !strings.Contains(s.sent[0].Subject, "Welcome") {
+		t.Fatalf("unexpected mail %+v", s.sent)
+	}
+}
@@ -0,0 +1,274 @@
+// Package lambda serves an http.Handler on AWS Lambda.  It implements the
+// Lambda runtime API for custom runtimes (provided.al2023) directly, and
+// translates API Gateway REST (v1), API Gateway HTTP API (v2) and
+// Application Load Balancer events to and from HTTP requests, so that the
+// gin engine runs unchanged behind any of them.
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// runtimeAPIVersion prefixes every runtime API path.
+const runtimeAPIVersion = "/2018-06-01/runtime"
+
+// Event is the union of the API Gateway v1, API Gateway v2 and ALB request
+// events; Version and RequestContext tell them apart.
+type Event struct {
+	Version string `json:"version"`
+
+	// API Gateway v1 and ALB.
+	HTTPMethod                      string              `json:"httpMethod"`
+	Path                            string              `json:"path"`
+	QueryStringParameters           map[string]string   `json:"queryStringParameters"`
+	MultiValueQueryStringParameters map[string][]string `json:"multiValueQueryStringParameters"`
+	Headers                         map[string]string   `json:"headers"`
+	MultiValueHeaders               map[string][]string `json:"multiValueHeaders"`
+
+	// API Gateway v2.
+	RawPath        string   `json:"rawPath"`
+	RawQueryString string   `json:"rawQueryString"`
+	Cookies        []string `json:"cookies"`
+
+	RequestContext struct {
+		HTTP struct {
+			Method   string `json:"method"`
+			SourceIP string `json:"sourceIp"`
+		} `json:"http"`
+		Identity struct {
+			SourceIP string `json:"sourceIp"`
+		} `json:"identity"`
+		ELB *struct {
+			TargetGroupArn string `json:"targetGroupArn"`
+		} `json:"elb"`
+	} `json:"requestContext"`
+
+	Body            string `json:"body"`
+	IsBase64Encoded bool   `json:"isBase64Encoded"`
+}
+
+// Response is the union of the response formats.  Fields that do not apply
+// to the event's source are left empty and omitted.
+type Response struct {
+	StatusCode        int                 `json:"statusCode"`
+	StatusDescription string              `json:"statusDescription,omitempty"`
+	Headers           map[string]string   `json:"headers,omitempty"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders,omitempty"`
+	Cookies           []string            `json:"cookies,omitempty"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded"`
+}
+
+func (e *Event) isV2() bool  { return e.Version == "2.0" }
+func (e *Event) isALB() bool { return e.RequestContext.ELB != nil }
+
+// Request converts e into an HTTP request carrying ctx.
+func (e *Event) Request(ctx context.Context) (*http.Request, error) {
+	body := []byte(e.Body)
+	if e.IsBase64Encoded {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(e.Body); err != nil {
+			return nil, fmt.Errorf("lambda: decode body: %w", err)
+		}
+	}
+
+	method, path, query := e.HTTPMethod, e.Path, url.Values{}
+	header := http.Header{}
+	if e.isV2() {
+		method, path = e.RequestContext.HTTP.Method, e.RawPath
+		if q, err := url.ParseQuery(e.RawQueryString); err == nil {
+			query = q
+		}
+		// v2 joins repeated headers with commas already.
+		for k, v := range e.Headers {
+			header.Set(k, v)
+		}
+		if len(e.Cookies) > 0 {
+			header.Set("Cookie", strings.Join(e.Cookies, "; "))
+		}
+	} else {
+		for k, vs := range e.MultiValueQueryStringParameters {
+			query[k] = vs
+		}
+		for k, v := range e.QueryStringParameters {
+			if _, ok := query[k]; !ok {
+				query.Set(k, v)
+			}
+		}
+		for k, vs := range e.MultiValueHeaders {
+			for _, v := range vs {
+				header.Add(k, v)
+			}
+		}
+		for k, v := range e.Headers {
+			if header.Get(k) == "" {
+				header.Set(k, v)
+			}
+		}
+	}
+	if e.isALB() {
+		// ALB passes query values through still percent-encoded.
+		decoded := url.Values{}
+		for k, vs := range query {
+			key, _ := url.QueryUnescape(k)
+			for _, v := range vs {
+				v, _ = url.QueryUnescape(v)
+				decoded.Add(key, v)
+			}
+		}
+		query = decoded
+	}
+
+	u := &url.URL{Path: path, RawQuery: query.Encode()}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("lambda: %w", err)
+	}
+	req.Header = header
+	req.Host = header.Get("Host")
+	req.ContentLength = int64(len(body))
+	req.RequestURI = u.RequestURI()
+	if ip := e.RequestContext.HTTP.SourceIP + e.RequestContext.Identity.SourceIP; ip != "" {
+		req.RemoteAddr = ip + ":0"
+	} else if fwd := header.Get("X-Forwarded-For"); fwd != "" {
+		ip, _, _ := strings.Cut(fwd, ",")
+		req.RemoteAddr = strings.TrimSpace(ip) + ":0"
+	}
+	if header.Get("X-Forwarded-Proto") == "https" {
+		req.URL.Scheme = "https"
+	}
+	return req, nil
+}
+
+// Serve runs h on the request described by e and returns the response in
+// the format e's source expects.
+func Serve(ctx context.Context, h http.Handler, e *Event) (*Response, error) {
+	req, err := e.Request(ctx)
+	if err != nil {
+		return nil, err
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	res := w.Result()
+
+	out := &Response{StatusCode: res.StatusCode}
+	body := w.Body.Bytes()
+	if utf8.Valid(body) {
+		out.Body = string(body)
+	} else {
+		out.Body, out.IsBase64Encoded = base64.StdEncoding.EncodeToString(body), true
+	}
+
+	switch {
+	case e.isV2():
+		out.Headers = map[string]string{}
+		for k, vs := range res.Header {
+			if k == "Set-Cookie" {
+				out.Cookies = vs
+				continue
+			}
+			out.Headers[k] = strings.Join(vs, ",")
+		}
+	case e.MultiValueHeaders != nil:
+		// API Gateway v1 always sends multiValueHeaders; ALB only when
+		// the target group enables them, and then requires them back.
+		out.MultiValueHeaders = res.Header
+	default:
+		out.Headers = map[string]string{}
+		for k, vs := range res.Header {
+			out.Headers[k] = vs[len(vs)-1]
+		}
+	}
+	if e.isALB() {
+		out.StatusDescription = strconv.Itoa(res.StatusCode) + " " + http.StatusText(res.StatusCode)
+	}
+	return out, nil
+}
+
+// Start serves h for every invocation until the runtime API fails.  It
+// reads AWS_LAMBDA_RUNTIME_API, set by Lambda for custom runtimes.
+func Start(h http.Handler) error {
+	api := os.Getenv("AWS_LAMBDA_RUNTIME_API")
+	if api == "" {
+		return errors.New("lambda: AWS_LAMBDA_RUNTIME_API is not set; not running on Lambda")
+	}
+	r := &runtime{base: "http://" + api + runtimeAPIVersion, client: &http.Client{}}
+	for {
+		if err := r.next(h); err != nil {
+			return err
+		}
+	}
+}
+
+type runtime struct {
+	base   string
+	client *http.Client
+}
+
+// next waits for an invocation, serves it and posts the result.
+func (r *runtime) next(h http.Handler) error {
+	res, err := r.client.Get(r.base + "/invocation/next")
+	if err != nil {
+		return fmt.Errorf("lambda: next invocation: %w", err)
+	}
+	payload, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return fmt.Errorf("lambda: next invocation: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("lambda: next invocation: runtime API returned %s", res.Status)
+	}
+	id := res.Header.Get("Lambda-Runtime-Aws-Request-Id")
+
+	ctx := context.Background()
+	if ms, err := strconv.ParseInt(res.Header.Get("Lambda-Runtime-Deadline-Ms"), 10, 64); err == nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, time.UnixMilli(ms))
+		defer cancel()
+	}
+
+	var e Event
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return r.post(id, "error", invocationError(fmt.Errorf("decode event: %w", err)))
+	}
+	out, err := Serve(ctx, h, &e)
+	if err != nil {
+		return r.post(id, "error", invocationError(err))
+	}
+	return r.post(id, "response", out)
+}
+
+func invocationError(err error) map[string]string {
+	return map[string]string{"errorMessage": err.Error(), "errorType": "InvalidEvent"}
+}
+
+func (r *runtime) post(id, kind string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	res, err := r.client.Post(r.base+"/invocation/"+id+"/"+kind, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("lambda: post %s: %w", kind, err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("lambda: post %s: runtime API returned %s", kind, res.Status)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package lambda_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/lambda"
+)
+
+// echo reports what it received and sets a cookie.
+var echo = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	http.SetCookie(w, &http.Cookie{Name: "a", Value: "1"})
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusCreated)
+	io.WriteString(w, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery+" "+r.Header.Get("X-Test")+" "+string(body))
+})
+
+func decode(t *testing.T, event string) *lambda.Event {
+	t.Helper()
+	var e lambda.Event
+	if err := json.Unmarshal([]byte(event), &e); err != nil {
+		t.Fatal(err)
+	}
+	return &e
+}
+
+func TestServe_APIGatewayV1(t *testing.T) {
+	e := decode(t, `{
+		"httpMethod": "POST", "path": "/api/v1/football/teams",
+		"multiValueQueryStringParameters": {"q": ["a b"]},
+		"multiValueHeaders": {"X-Test": ["yes"]},
+		"body": "eyJuYW1lIjoiWCJ9", "isBase64Encoded": true,
+		"requestContext": {"identity": {"sourceIp": "203.0.113.1"}}
+	}`)
+	res, err := lambda.Serve(context.Background(), echo, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusCreated || res.Body != `POST /api/v1/football/teams?q=a+b yes {"name":"X"}` {
+		t.Errorf("unexpected response %d %q", res.StatusCode, res.Body)
+	}
+	if got := res.MultiValueHeaders["Set-Cookie"]; len(got) != 1 {
+		t.Errorf("expected the cookie in multiValueHeaders, got %v", res.MultiValueHeaders)
+	}
+}
+
+func TestServe_APIGatewayV2(t *testing.T) {
+	e := decode(t, `{
+		"version": "2.0", "rawPath": "/api/v1/version", "rawQueryString": "x=1",
+		"headers": {"x-test": "yes"}, "cookies": ["c=2"],
+		"requestContext": {"http": {"method": "GET", "sourceIp": "203.0.113.1"}}
+	}`)
+	res, err := lambda.Serve(context.Background(), echo, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Body != "GET /api/v1/version?x=1 yes " {
+		t.Errorf("unexpected body %q", res.Body)
+	}
+	if len(res.Cookies) != 1 || !strings.HasPrefix(res.Cookies[0], "a=1") {
+		t.Errorf("expected cookies to be returned separately, got %v", res.Cookies)
+	}
+	if res.Headers["Content-Type"] != "text/plain" || res.MultiValueHeaders != nil {
+		t.Errorf("unexpected headers %v / %v", res.Headers, res.MultiValueHeaders)
+	}
+}
+
+func TestServe_ALB(t *testing.T) {
+	e := decode(t, `{
+		"httpMethod": "GET", "path": "/api/v1/version",
+		"queryStringParameters": {"q": "a%20b"},
+		"headers": {"x-test": "yes"},
+		"requestContext": {"elb": {"targetGroupArn": "arn"}}
+	}`)
+	res, err := lambda.Serve(context.Background(), echo, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Body != "GET /api/v1/version?q=a+b yes " {
+		t.Errorf("expected the query to be decoded, got %q", res.Body)
+	}
+	if res.StatusDescription != "201 Created" || res.Headers == nil {
+		t.Errorf("unexpected ALB response %+v", res)
+	}
+}
+
+func TestStart_RuntimeAPI(t *testing.T) {
+	var posted lambda.Response
+	calls := 0
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/2018-06-01/runtime/invocation/next":
+			calls++
+			if calls > 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Lambda-Runtime-Aws-Request-Id", "req-1")
+			io.WriteString(w, `{"version":"2.0","rawPath":"/x","requestContext":{"http":{"method":"GET"}}}`)
+		case "/2018-06-01/runtime/invocation/req-1/response":
+			json.NewDecoder(r.Body).Decode(&posted)
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			t.Errorf("unexpected call %s", r.URL.Path)
+		}
+	}))
+	defer api.Close()
+	t.Setenv("AWS_LAMBDA_RUNTIME_API", strings.TrimPrefix(api.URL, "http://"))
+
+	if err := lambda.Start(echo); err == nil {
+		t.Fatal("expected Start to return when the runtime API fails")
+	}
+	if posted.StatusCode != http.StatusCreated || posted.Body != "GET /x?  " {
+		t.Errorf("unexpected posted response %+v", posted)
+	}
+}
@@ -0,0 +1,142 @@
+// Package leader elects one instance among several sharing a PostgreSQL
+// database to run singleton background work.  Leadership is a session-level
+// advisory lock held on a dedicated connection: if the leader exits,
+// crashes or loses its connection, PostgreSQL releases the lock and another
+// instance takes over within the retry interval.
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"hash/fnv"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// Default intervals used by NewElector.
+const (
+	DefaultRetry = 10 * time.Second
+	DefaultCheck = 5 * time.Second
+)
+
+// Elector campaigns for leadership of one named role.
+type Elector struct {
+	db   *sql.DB
+	name string
+	key  int64
+
+	// Retry is how often a follower tries to take over.
+	Retry time.Duration
+	// Check is how often the leader confirms its connection, and so its
+	// lock, is still alive.
+	Check time.Duration
+
+	leader atomic.Bool
+}
+
+// NewElector returns an elector for the role name (e.g. "scheduler").
+// Instances using the same name compete for the same lock.  With a nil db
+// there is only one instance, which is always the leader.
+func NewElector(db *sql.DB, name string) *Elector {
+	h := fnv.New64a()
+	h.Write([]byte("leader:" + name))
+	return &Elector{db: db, name: name, key: int64(h.Sum64()), Retry: DefaultRetry, Check: DefaultCheck}
+}
+
+// IsLeader reports whether this instance currently holds leadership.
+func (e *Elector) IsLeader() bool { return e.leader.Load() }
+
+// Run campaigns until ctx is done, calling task whenever this instance
+// becomes leader.  task's context is cancelled when leadership is lost or
+// ctx is done, and task must then return promptly; Run waits for it before
+// releasing the lock, so two instances never run task at once.  If task
+// returns on its own, leadership is released and the campaign continues.
+func (e *Elector) Run(ctx context.Context, task func(ctx context.Context)) {
+	if e.db == nil {
+		e.leader.Store(true)
+		defer e.leader.Store(false)
+		task(ctx)
+		return
+	}
+	for {
+		if conn := e.acquire(ctx); conn != nil {
+			e.lead(ctx, conn, task)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(e.Retry):
+		}
+	}
+}
+
+// acquire returns a connection holding the lock, or nil when another
+// instance is leader or the database is unreachable.
+func (e *Elector) acquire(ctx context.Context) *sql.Conn {
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("leader %s: %v", e.name, err)
+		}
+		return nil
+	}
+	var held bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, e.key).Scan(&held); err != nil || !held {
+		if err != nil && ctx.Err() == nil {
+			log.Printf("leader %s: %v", e.name, err)
+		}
+		conn.Close()
+		return nil
+	}
+	return conn
+}
+
+// lead runs task while conn keeps the lock alive.
+func (e *Elector) lead(ctx context.Context, conn *sql.Conn, task func(ctx context.Context)) {
+	log.Printf("leader %s: acquired leadership", e.name)
+	e.leader.Store(true)
+
+	taskCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		task(taskCtx)
+	}()
+
+	ticker := time.NewTicker(e.Check)
+	defer ticker.Stop()
+	lost := false
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-done:
+			break loop
+		case <-ticker.C:
+			if err := conn.PingContext(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("leader %s: lost connection, stepping down: %v", e.name, err)
+				lost = true
+				break loop
+			}
+		}
+	}
+	cancel()
+	<-done
+	e.leader.Store(false)
+
+	if !lost {
+		// Release explicitly rather than waiting for the pooled
+		// connection to be closed.
+		unlockCtx, unlockCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, _ = conn.ExecContext(unlockCtx, `SELECT pg_advisory_unlock($1)`, e.key)
+		unlockCancel()
+		log.Printf("leader %s: released leadership", e.name)
+	}
+	// Discard the connection rather than returning it to the pool, so
+	// that a lock it may still hold server-side is released with it.
+	_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	conn.Close()
+}
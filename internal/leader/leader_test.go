@@ -0,0 +1,82 @@
+package leader_test
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/postgres"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/leader"
+)
+
+func TestElector_NoDatabaseAlwaysLeads(t *testing.T) {
+	e := leader.NewElector(nil, "test")
+	ctx, cancel := context.WithCancel(context.Background())
+	ran := make(chan bool, 1)
+	go e.Run(ctx, func(ctx context.Context) {
+		ran <- e.IsLeader()
+		<-ctx.Done()
+	})
+	if !<-ran {
+		t.Error("expected IsLeader during the task")
+	}
+	cancel()
+}
+
+// TestElector_Failover runs two electors against TEST_DATABASE_URL and
+// checks that exactly one leads at a time and the other takes over when
+// the leader stops.
+func TestElector_Failover(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	conn, err := postgres.Connect(dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer conn.Close()
+
+	var running atomic.Int32
+	started := make(chan int, 2)
+	run := func(id int) (*leader.Elector, context.CancelFunc) {
+		e := leader.NewElector(conn, "failover-test")
+		e.Retry, e.Check = 50*time.Millisecond, 50*time.Millisecond
+		ctx, cancel := context.WithCancel(context.Background())
+		go e.Run(ctx, func(ctx context.Context) {
+			if running.Add(1) > 1 {
+				t.Error("two leaders at once")
+			}
+			started <- id
+			<-ctx.Done()
+			running.Add(-1)
+		})
+		return e, cancel
+	}
+
+	a, stopA := run(1)
+	b, stopB := run(2)
+	defer stopB()
+
+	first := <-started
+	time.Sleep(200 * time.Millisecond)
+	if a.IsLeader() == b.IsLeader() {
+		t.Fatalf("expected exactly one leader, got a=%v b=%v", a.IsLeader(), b.IsLeader())
+	}
+	if first == 1 {
+		stopA()
+	} else {
+		stopB()
+	}
+	select {
+	case second := <-started:
+		if second == first {
+			t.Fatalf("elector %d led twice", first)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no failover after the leader stopped")
+	}
+	stopA()
+}
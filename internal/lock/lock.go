@@ -0,0 +1,109 @@
+// Package lock provides named mutual-exclusion locks for operations that
+// must not run concurrently, either within one process (Local) or across
+// every instance sharing a PostgreSQL database (Postgres, using
+// session-level advisory locks).
+//
+// Contention is published through expvar as the "locks" map, so it appears
+// at /debug/vars: for each lock name, <name>.acquired counts acquisitions,
+// <name>.contended those that had to wait or failed, <name>.timeouts those
+// that gave up, and <name>.wait_ms the total time spent waiting.
+package lock
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"sync"
+	"time"
+)
+
+// ErrTimeout is returned by Acquire when the lock is still held by someone
+// else after the timeout.
+var ErrTimeout = errors.New("lock: held elsewhere")
+
+// pollInterval is how often a waiting Acquire retries.
+const pollInterval = 50 * time.Millisecond
+
+// Manager hands out named locks.
+type Manager interface {
+	// Acquire takes the lock name, waiting up to timeout (zero tries once)
+	// or until ctx is done.  It returns ErrTimeout when the lock stayed
+	// held, and otherwise a release function that must be called exactly
+	// once.
+	Acquire(ctx context.Context, name string, timeout time.Duration) (release func(), err error)
+}
+
+var stats = expvar.NewMap("locks")
+
+// acquire polls try until it succeeds, fails, the timeout passes or ctx is
+// done, recording contention under name.
+func acquire(ctx context.Context, name string, timeout time.Duration, try func(context.Context) (bool, error)) error {
+	start := time.Now()
+	contended := false
+	defer func() {
+		if contended {
+			stats.Add(name+".contended", 1)
+			stats.Add(name+".wait_ms", time.Since(start).Milliseconds())
+		}
+	}()
+
+	deadline := start.Add(timeout)
+	for {
+		ok, err := try(ctx)
+		if err != nil {
+			return err
+		}
+		if ok {
+			stats.Add(name+".acquired", 1)
+			return nil
+		}
+		contended = true
+		wait := min(pollInterval, time.Until(deadline))
+		if wait <= 0 {
+			stats.Add(name+".timeouts", 1)
+			return ErrTimeout
+		}
+		select {
+		case <-ctx.Done():
+			stats.Add(name+".timeouts", 1)
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Local is a Manager for a single process.  The zero value is ready to use.
+type Local struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+// NewLocal returns an in-process Manager.
+func NewLocal() *Local { return &Local{} }
+
+// Acquire implements Manager.
+func (l *Local) Acquire(ctx context.Context, name string, timeout time.Duration) (func(), error) {
+	err := acquire(ctx, name, timeout, func(context.Context) (bool, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.held[name] {
+			return false, nil
+		}
+		if l.held == nil {
+			l.held = make(map[string]bool)
+		}
+		l.held[name] = true
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			delete(l.held, name)
+			l.mu.Unlock()
+		})
+	}, nil
+}
@@ -0,0 +1,83 @@
+package lock_test
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/postgres"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/lock"
+)
+
+// exclusion checks that m lets one holder at a time take name.
+func exclusion(t *testing.T, m lock.Manager, name string) {
+	t.Helper()
+	ctx := context.Background()
+	release, err := m.Acquire(ctx, name, 0)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	if _, err := m.Acquire(ctx, name, 0); !errors.Is(err, lock.ErrTimeout) {
+		t.Fatalf("expected ErrTimeout while held, got %v", err)
+	}
+
+	// A waiter gets the lock once it is released.
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		release()
+	}()
+	release2, err := m.Acquire(ctx, name, 2*time.Second)
+	if err != nil {
+		t.Fatalf("Acquire with timeout: %v", err)
+	}
+	release2()
+	release2() // releasing twice is harmless
+}
+
+func TestLocal(t *testing.T) {
+	exclusion(t, lock.NewLocal(), "local-test")
+}
+
+func TestLocal_ContextCancelled(t *testing.T) {
+	m := lock.NewLocal()
+	release, _ := m.Acquire(context.Background(), "cancel-test", 0)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := m.Acquire(ctx, "cancel-test", time.Minute); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the context error, got %v", err)
+	}
+}
+
+func TestLocal_Metrics(t *testing.T) {
+	m := lock.NewLocal()
+	release, _ := m.Acquire(context.Background(), "metrics-test", 0)
+	_, _ = m.Acquire(context.Background(), "metrics-test", 0)
+	release()
+
+	vars := expvar.Get("locks").String()
+	for _, want := range []string{`"metrics-test.acquired": 1`, `"metrics-test.contended": 1`, `"metrics-test.timeouts": 1`} {
+		if !strings.Contains(vars, want) {
+			t.Errorf("expected %s in %s", want, vars)
+		}
+	}
+}
+
+func TestPostgres(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	conn, err := postgres.Connect(dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer conn.Close()
+	// Two managers on one pool behave as two instances: each lock pins
+	// its own connection.
+	exclusion(t, lock.NewPostgres(conn), "postgres-test")
+}
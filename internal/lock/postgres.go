@@ -0,0 +1,64 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sync"
+	"time"
+)
+
+// Postgres is a Manager shared by every instance using the same database.
+// Each held lock pins one pooled connection until it is released; if the
+// process dies, PostgreSQL releases its locks with the connection.
+type Postgres struct {
+	db *sql.DB
+}
+
+// NewPostgres returns a Manager backed by advisory locks in db.
+func NewPostgres(db *sql.DB) *Postgres { return &Postgres{db: db} }
+
+// key maps a lock name to an advisory lock key.
+func key(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("lock:" + name))
+	return int64(h.Sum64())
+}
+
+// Acquire implements Manager.
+func (p *Postgres) Acquire(ctx context.Context, name string, timeout time.Duration) (func(), error) {
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("lock: %w", err)
+	}
+	k := key(name)
+	err = acquire(ctx, name, timeout, func(ctx context.Context) (bool, error) {
+		var ok bool
+		if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, k).Scan(&ok); err != nil {
+			return false, fmt.Errorf("lock: %w", err)
+		}
+		return ok, nil
+	})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, k); err != nil {
+				// Discard the connection so that PostgreSQL releases the
+				// lock when it closes, rather than pooling it still held.
+				log.Printf("lock %s: unlock: %v", name, err)
+				_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+			}
+			conn.Close()
+		})
+	}, nil
+}
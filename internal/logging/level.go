@@ -0,0 +1,96 @@
+// Package logging holds the server's runtime-adjustable log level.
+package logging
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxOverride bounds how long a level change may last before it reverts, so
+// that verbose logging is never left on indefinitely by accident.
+const MaxOverride = 24 * time.Hour
+
+// Level is a log level that can be changed while the server runs.  Any
+// change away from the default reverts automatically after a timeout.  It
+// implements slog.Leveler and is safe for concurrent use.
+type Level struct {
+	v   slog.LevelVar
+	def slog.Level
+
+	mu       sync.Mutex
+	timer    *time.Timer
+	revertAt time.Time
+}
+
+// NewLevel returns a Level set to def, which is also the level that
+// temporary overrides revert to.
+func NewLevel(def slog.Level) *Level {
+	l := &Level{def: def}
+	l.v.Set(def)
+	return l
+}
+
+// Level returns the current level.
+func (l *Level) Level() slog.Level { return l.v.Level() }
+
+// Default returns the level that overrides revert to.
+func (l *Level) Default() slog.Level { return l.def }
+
+// Enabled reports whether messages at level x should be logged.
+func (l *Level) Enabled(x slog.Level) bool { return x >= l.v.Level() }
+
+// RevertAt returns when the current override expires, or the zero time when
+// the default level is in effect.
+func (l *Level) RevertAt() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.revertAt
+}
+
+// Set changes the level and schedules a revert to the default after
+// revertAfter, which is clamped to MaxOverride.  Setting the default level
+// cancels any pending revert.  Returns the revert time (zero if none).
+func (l *Level) Set(x slog.Level, revertAfter time.Duration) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+	l.revertAt = time.Time{}
+	l.v.Set(x)
+
+	if x == l.def {
+		return l.revertAt
+	}
+	if revertAfter <= 0 || revertAfter > MaxOverride {
+		revertAfter = MaxOverride
+	}
+	l.revertAt = time.Now().Add(revertAfter)
+
+	var t *time.Timer
+	t = time.AfterFunc(revertAfter, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		// A later Set may have replaced this timer after it fired.
+		if l.timer != t {
+			return
+		}
+		l.v.Set(l.def)
+		l.timer = nil
+		l.revertAt = time.Time{}
+	})
+	l.timer = t
+	return l.revertAt
+}
+
+// ParseLevel parses a level name such as "debug", "info", "warn" or "error",
+// case-insensitively.
+func ParseLevel(s string) (slog.Level, error) {
+	var x slog.Level
+	err := x.UnmarshalText([]byte(strings.TrimSpace(s)))
+	return x, err
+}
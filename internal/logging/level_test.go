@@ -0,0 +1,62 @@
+package logging_test
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/logging"
+)
+
+func TestLevel_RevertsAfterTimeout(t *testing.T) {
+	l := logging.NewLevel(slog.LevelInfo)
+	if l.Enabled(slog.LevelDebug) {
+		t.Fatal("debug should be disabled at the default info level")
+	}
+
+	if l.Set(slog.LevelDebug, 20*time.Millisecond).IsZero() {
+		t.Fatal("expected a revert time for a non-default level")
+	}
+	if !l.Enabled(slog.LevelDebug) {
+		t.Fatal("debug should be enabled after Set")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for l.Level() != slog.LevelInfo {
+		if time.Now().After(deadline) {
+			t.Fatal("level did not revert to info")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !l.RevertAt().IsZero() {
+		t.Errorf("expected no pending revert, got %v", l.RevertAt())
+	}
+}
+
+func TestLevel_SetDefaultCancelsRevert(t *testing.T) {
+	l := logging.NewLevel(slog.LevelInfo)
+	l.Set(slog.LevelDebug, time.Hour)
+	if !l.Set(slog.LevelInfo, time.Hour).IsZero() {
+		t.Fatal("setting the default level should not schedule a revert")
+	}
+	if !l.RevertAt().IsZero() {
+		t.Errorf("expected pending revert to be cancelled")
+	}
+}
+
+func TestLevel_ClampsOverride(t *testing.T) {
+	l := logging.NewLevel(slog.LevelInfo)
+	revertAt := l.Set(slog.LevelWarn, 0)
+	if d := time.Until(revertAt); d > logging.MaxOverride || d < logging.MaxOverride-time.Minute {
+		t.Errorf("expected revert in about %v, got %v", logging.MaxOverride, d)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	if x, err := logging.ParseLevel("DEBUG"); err != nil || x != slog.LevelDebug {
+		t.Errorf("expected debug, got %v (%v)", x, err)
+	}
+	if _, err := logging.ParseLevel("verbose"); err == nil {
+		t.Error("expected error for unknown level")
+	}
+}
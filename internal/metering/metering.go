@@ -0,0 +1,291 @@
+// Package metering counts each authenticated caller's requests and the
+// bytes they send and receive, per UTC day and API key, for billing.  A
+// Meter gathers counts in memory, adds them to the metering store once a
+// minute and hands the same deltas to its Exporters, so that usage can feed
+// an external billing system without that system polling the API.
+package metering
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/async"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// DateLayout formats usage days.
+const DateLayout = "2006-01-02"
+
+// flushInterval is how often counts are stored and exported.
+const flushInterval = time.Minute
+
+// Exporter receives usage deltas after they have been stored: the counts
+// added since the previous export, one record per day, caller and key.  A
+// batch that fails is offered again, merged with later usage, on the next
+// flush.
+type Exporter interface {
+	Export(ctx context.Context, usage []models.UsageRecord) error
+}
+
+// ExporterFunc adapts a function to the Exporter interface.
+type ExporterFunc func(ctx context.Context, usage []models.UsageRecord) error
+
+// Export calls f(ctx, usage).
+func (f ExporterFunc) Export(ctx context.Context, usage []models.UsageRecord) error {
+	return f(ctx, usage)
+}
+
+type key struct {
+	date, username, apiKey string
+}
+
+// batch accumulates usage by day, caller and key.
+type batch map[key]*models.UsageRecord
+
+func (b batch) add(r models.UsageRecord) {
+	k := key{r.Date, r.Username, r.Key}
+	cur := b[k]
+	if cur == nil {
+		cur = &models.UsageRecord{Date: r.Date, Username: r.Username, Key: r.Key}
+		b[k] = cur
+	}
+	cur.Requests += r.Requests
+	cur.BytesIn += r.BytesIn
+	cur.BytesOut += r.BytesOut
+}
+
+func (b batch) records() []models.UsageRecord {
+	out := make([]models.UsageRecord, 0, len(b))
+	for _, r := range b {
+		out = append(out, *r)
+	}
+	return out
+}
+
+// Meter meters requests.  Create one with New.
+type Meter struct {
+	repo      db.MeteringRepository
+	exporters []Exporter
+	clock     clock.Clock
+
+	mu      sync.Mutex
+	pending batch   // not yet stored
+	backlog []batch // per exporter, stored but not yet exported
+
+	// stored caches each caller's requests stored this month, read back for
+	// MonthToDate, until the next flush adds to them.  gen counts flushes,
+	// so that a read racing a flush is not cached.
+	stored map[monthKey]int64
+	gen    uint64
+}
+
+type monthKey struct {
+	month, username string
+}
+
+// New returns a Meter that stores usage in repo and passes it on to
+// exporters.
+func New(repo db.MeteringRepository, exporters ...Exporter) *Meter {
+	backlog := make([]batch, len(exporters))
+	for i := range backlog {
+		backlog[i] = batch{}
+	}
+	return &Meter{repo: repo, exporters: exporters, clock: clock.System{}, pending: batch{}, backlog: backlog, stored: map[monthKey]int64{}}
+}
+
+// SetClock replaces the wall clock used to date requests.
+func (m *Meter) SetClock(c clock.Clock) {
+	m.clock = clock.Or(c)
+}
+
+// Record meters every authenticated request once it has been served: one
+// request, the request body's declared length in and the response body's
+// length out.  Requests signed with an HMAC key are metered against that
+// key as well as the caller.  The background copy of a Prefer:
+// respond-async request is not metered again.
+func (m *Meter) Record() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if async.Replayed(c.Request.Context()) {
+			// Counted when the request was accepted.
+			return
+		}
+		username := c.GetString("username")
+		if username == "" {
+			return
+		}
+		var apiKey string
+		if c.GetString("authScheme") == auth.HMACScheme {
+			apiKey = strings.TrimPrefix(username, "key:")
+		}
+		r := models.UsageRecord{
+			Date:     m.clock.Now().UTC().Format(DateLayout),
+			Username: username,
+			Key:      apiKey,
+			Requests: 1,
+			BytesIn:  max(c.Request.ContentLength, 0),
+			BytesOut: int64(max(c.Writer.Size(), 0)),
+		}
+		m.mu.Lock()
+		m.pending.add(r)
+		m.mu.Unlock()
+	}
+}
+
+// MonthToDate returns username's requests so far this UTC calendar month:
+// those in the metering store, from every instance, plus those this
+// instance has yet to store.  The stored count is read once per flush, so
+// other instances' latest requests can be up to a minute late.
+func (m *Meter) MonthToDate(username string) (int64, error) {
+	now := m.clock.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).Format(DateLayout)
+	k := monthKey{from, username}
+
+	m.mu.Lock()
+	stored, ok := m.stored[k]
+	gen := m.gen
+	m.mu.Unlock()
+	if !ok {
+		records, err := m.repo.ListUsage(username, from, now.Format(DateLayout))
+		if err != nil {
+			return 0, fmt.Errorf("metering: month to date: %w", err)
+		}
+		for _, r := range records {
+			stored += r.Requests
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !ok && gen == m.gen {
+		m.stored[k] = stored
+	}
+	total := stored
+	for _, r := range m.pending {
+		if r.Username == username && r.Date >= from {
+			total += r.Requests
+		}
+	}
+	return total, nil
+}
+
+// Flush stores the usage gathered since the last flush and then exports
+// it.  Usage that cannot be stored is kept for the next flush and not
+// exported until it has been.
+func (m *Meter) Flush(ctx context.Context) error {
+	m.mu.Lock()
+	pending := m.pending
+	m.pending = batch{}
+	m.mu.Unlock()
+
+	if len(pending) > 0 {
+		if err := m.repo.AddUsage(pending.records()); err != nil {
+			m.mu.Lock()
+			for _, r := range pending {
+				m.pending.add(*r)
+			}
+			m.mu.Unlock()
+			return fmt.Errorf("metering: store: %w", err)
+		}
+		m.mu.Lock()
+		m.stored = map[monthKey]int64{}
+		m.gen++
+		m.mu.Unlock()
+	}
+
+	var errs []error
+	for i, exp := range m.exporters {
+		m.mu.Lock()
+		todo := m.backlog[i]
+		for _, r := range pending {
+			todo.add(*r)
+		}
+		m.backlog[i] = batch{}
+		m.mu.Unlock()
+		if len(todo) == 0 {
+			continue
+		}
+		if err := exp.Export(ctx, todo.records()); err != nil {
+			errs = append(errs, err)
+			m.mu.Lock()
+			for _, r := range m.backlog[i] {
+				todo.add(*r)
+			}
+			m.backlog[i] = todo
+			m.mu.Unlock()
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("metering: export: %w", err)
+	}
+	return nil
+}
+
+// RunFlusher flushes usage every minute until ctx is done, then once more.
+func (m *Meter) RunFlusher(ctx context.Context) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := m.Flush(context.WithoutCancel(ctx)); err != nil {
+				log.Print(err)
+			}
+			return
+		case <-ticker.C:
+			if err := m.Flush(ctx); err != nil {
+				log.Print(err)
+			}
+		}
+	}
+}
+
+// HTTPExporter POSTs each batch to a URL as {"usage": [...]} and expects a
+// 2xx response.
+type HTTPExporter struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPExporter returns an exporter that posts to url.
+func NewHTTPExporter(url string) *HTTPExporter {
+	return &HTTPExporter{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Export implements Exporter.
+func (e *HTTPExporter) Export(ctx context.Context, usage []models.UsageRecord) error {
+	body, err := json.Marshal(struct {
+		Usage []models.UsageRecord `json:"usage"`
+	}{usage})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("usage export: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("usage export: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package metering_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/memory"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/metering"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/pkg/repository/fake"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newRouter serves POST /echo through m, authenticating as the X-User
+// header and, with X-Key, as that HMAC key.
+func newRouter(m *metering.Meter) *gin.Engine {
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if u := c.GetHeader("X-User"); u != "" {
+			c.Set("username", u)
+			c.Set("authScheme", "Bearer")
+		}
+		if k := c.GetHeader("X-Key"); k != "" {
+			c.Set("username", "key:"+k)
+			c.Set("authScheme", auth.HMACScheme)
+		}
+	})
+	r.Use(m.Record())
+	r.POST("/echo", func(c *gin.Context) { c.String(http.StatusOK, "0123456789") })
+	return r
+}
+
+func send(r *gin.Engine, body, header, value string) {
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(body))
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	r.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestMeter_RecordAndFlush(t *testing.T) {
+	repos := memory.New().Repositories()
+	m := metering.New(repos.Metering)
+	m.SetClock(clock.NewFake(time.Date(2024, 3, 15, 23, 0, 0, 0, time.UTC)))
+	r := newRouter(m)
+
+	send(r, "abc", "X-User", "alice")
+	send(r, "abcde", "X-User", "alice")
+	send(r, "", "X-Key", "ci")
+	send(r, "anonymous", "", "")
+
+	if err := m.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	got, err := repos.Metering.ListUsage("alice", "2024-03-01", "2024-03-31")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := models.UsageRecord{Date: "2024-03-15", Username: "alice", Requests: 2, BytesIn: 8, BytesOut: 20}
+	if len(got) != 1 || got[0] != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+	got, _ = repos.Metering.ListUsage("key:ci", "2024-03-15", "2024-03-15")
+	if len(got) != 1 || got[0].Key != "ci" || got[0].Requests != 1 {
+		t.Fatalf("expected one request metered against key ci, got %+v", got)
+	}
+	totals, _ := repos.Metering.UsageTotals("2024-03-01", "2024-03-31", 10, 0)
+	if len(totals) != 2 {
+		t.Fatalf("expected the anonymous request to go unmetered, got %+v", totals)
+	}
+
+	// A second flush adds only what came since.
+	send(r, "", "X-User", "alice")
+	if err := m.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = repos.Metering.ListUsage("alice", "2024-03-15", "2024-03-15")
+	if got[0].Requests != 3 {
+		t.Fatalf("expected 3 requests, got %+v", got)
+	}
+}
+
+func TestMeter_StoreFailureKeepsUsage(t *testing.T) {
+	var stored []models.UsageRecord
+	fail := true
+	repo := &fake.Metering{AddUsageFunc: func(records []models.UsageRecord) error {
+		if fail {
+			return errors.New("db down")
+		}
+		stored = append(stored, records...)
+		return nil
+	}}
+	var exported int
+	m := metering.New(repo, metering.ExporterFunc(func(_ context.Context, usage []models.UsageRecord) error {
+		exported += len(usage)
+		return nil
+	}))
+	send(newRouter(m), "", "X-User", "alice")
+
+	if err := m.Flush(context.Background()); err == nil {
+		t.Fatal("expected the store error")
+	}
+	if exported != 0 {
+		t.Fatal("expected nothing exported before it is stored")
+	}
+	fail = false
+	if err := m.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 || stored[0].Requests != 1 || exported != 1 {
+		t.Fatalf("expected the kept request stored and exported, got %+v, %d exported", stored, exported)
+	}
+}
+
+func TestMeter_ExportBacklog(t *testing.T) {
+	repos := memory.New().Repositories()
+	fail := true
+	var batches [][]models.UsageRecord
+	m := metering.New(repos.Metering, metering.ExporterFunc(func(_ context.Context, usage []models.UsageRecord) error {
+		if fail {
+			return errors.New("billing down")
+		}
+		batches = append(batches, usage)
+		return nil
+	}))
+	r := newRouter(m)
+
+	send(r, "", "X-User", "alice")
+	if err := m.Flush(context.Background()); err == nil {
+		t.Fatal("expected the export error")
+	}
+	fail = false
+	send(r, "", "X-User", "alice")
+	if err := m.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 1 || len(batches[0]) != 1 || batches[0][0].Requests != 2 {
+		t.Fatalf("expected the failed batch merged into the next, got %+v", batches)
+	}
+
+	// Nothing new: nothing to export.
+	if err := m.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 1 {
+		t.Fatalf("expected no empty export, got %d batches", len(batches))
+	}
+}
+
+func TestHTTPExporter(t *testing.T) {
+	var body string
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b := new(strings.Builder)
+		_, _ = io.Copy(b, r.Body)
+		body = b.String()
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	exp := metering.NewHTTPExporter(srv.URL)
+	usage := []models.UsageRecord{{Date: "2024-03-15", Username: "alice", Requests: 1}}
+	if err := exp.Export(context.Background(), usage); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(body, `{"usage":[{"date":"2024-03-15","username":"alice"`) {
+		t.Fatalf("unexpected body %s", body)
+	}
+	status = http.StatusBadGateway
+	if err := exp.Export(context.Background(), usage); err == nil {
+		t.Fatal("expected an error for a 502")
+	}
+}
+
+func TestMeter_MonthToDate(t *testing.T) {
+	repos := memory.New().Repositories()
+	if err := repos.Metering.AddUsage([]models.UsageRecord{
+		{Date: "2024-02-29", Username: "alice", Requests: 100},
+		{Date: "2024-03-01", Username: "alice", Requests: 5},
+		{Date: "2024-03-02", Username: "alice", Key: "ci", Requests: 2},
+		{Date: "2024-03-02", Username: "bob", Requests: 7},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	m := metering.New(repos.Metering)
+	m.SetClock(clock.NewFake(time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)))
+	r := newRouter(m)
+
+	month := func() int64 {
+		t.Helper()
+		n, err := m.MonthToDate("alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	if n := month(); n != 7 {
+		t.Fatalf("expected 7 stored requests this month, got %d", n)
+	}
+
+	// Another instance's requests show up after the next flush; this
+	// instance's at once.
+	_ = repos.Metering.AddUsage([]models.UsageRecord{{Date: "2024-03-15", Username: "alice", Requests: 10}})
+	send(r, "", "X-User", "alice")
+	if n := month(); n != 8 {
+		t.Fatalf("expected 8 with the cached count, got %d", n)
+	}
+	if err := m.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := month(); n != 18 {
+		t.Fatalf("expected 18 after a flush, got %d", n)
+	}
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
+)
+
+// The business counters.
+var (
+	Created = NewCounter("resources_created",
+		"Resources created, by kind (team, match, announcement).", "kind")
+	Updated = NewCounter("resources_updated",
+		"Resources updated, by kind.", "kind")
+	Deleted = NewCounter("resources_deleted",
+		"Resources deleted, by kind.", "kind")
+	Registrations = NewCounter("registrations",
+		"Users registered.")
+	Logins = NewCounter("logins",
+		"Successful sign-ins, each starting a session.")
+	WebhookDeliveries = NewCounter("webhook_deliveries",
+		"Outgoing webhook posts, by target and outcome (sent, failed).", "target", "outcome")
+)
+
+// Subscribe counts the events published on bus.  It runs before the
+// response, as it only increments counters.
+func Subscribe(bus *events.Bus) {
+	bus.Subscribe(events.BeforeResponse, events.SubscriberFunc(count))
+}
+
+func count(_ context.Context, e events.Event) error {
+	switch e.Type {
+	case events.UserRegistered:
+		Registrations.Inc()
+		return nil
+	case events.SessionCreated:
+		Logins.Inc()
+		return nil
+	}
+	kind, action, ok := strings.Cut(string(e.Type), ".")
+	if !ok {
+		return nil
+	}
+	switch action {
+	case "created":
+		Created.Inc(kind)
+	case "updated":
+		Updated.Inc(kind)
+	case "deleted":
+		Deleted.Inc(kind)
+	}
+	return nil
+}
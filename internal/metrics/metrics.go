@@ -0,0 +1,206 @@
+// Package metrics exports application-level counters — teams and matches
+// created and deleted, registrations, logins, webhook deliveries — in the
+// OpenMetrics text format that Prometheus scrapes, so that dashboards can
+// show how the product is used rather than only how the process is doing.
+//
+// Counters are registered on a process-wide registry, like expvar's, and
+// served by Handler.  Most are driven by Subscribe from the event bus, so
+// they count only changes that were committed.  Gauges report values that
+// go up and down, such as the dependency latencies the readiness probe
+// measures.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the media type Handler serves.
+const ContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// Namespace prefixes every metric name.
+const Namespace = "football_api"
+
+var registry struct {
+	mu       sync.Mutex
+	families []family
+}
+
+// family is a registered metric family.
+type family interface {
+	metricName() string
+	write(w io.Writer)
+}
+
+// register adds f to the registry, panicking if its name is taken.
+func register(f family) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	for _, other := range registry.families {
+		if other.metricName() == f.metricName() {
+			panic("metrics: " + f.metricName() + " registered twice")
+		}
+	}
+	registry.families = append(registry.families, f)
+}
+
+// Counter is a monotonically increasing count, optionally partitioned by
+// labels.
+type Counter struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]uint64 // keyed by the label values joined with \x00
+}
+
+// NewCounter registers a counter family.  name is given without the
+// namespace or the _total suffix; labels name the dimensions whose values
+// are passed to Inc and Add.  It panics if name is already registered.
+func NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{name: Namespace + "_" + name, help: help, labels: labels, values: map[string]uint64{}}
+	register(c)
+	return c
+}
+
+// Inc adds one to the counter for the given label values.
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds n to the counter for the given label values, which must match
+// the labels the counter was registered with.
+func (c *Counter) Add(n uint64, values ...string) {
+	if len(values) != len(c.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", c.name, len(c.labels), len(values)))
+	}
+	key := strings.Join(values, "\x00")
+	c.mu.Lock()
+	c.values[key] += n
+	c.mu.Unlock()
+}
+
+// Value returns the counter for the given label values.
+func (c *Counter) Value(values ...string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[strings.Join(values, "\x00")]
+}
+
+func (c *Counter) metricName() string { return c.name }
+
+func (c *Counter) write(w io.Writer) {
+	fmt.Fprintf(w, "# TYPE %s counter\n# HELP %s %s\n", c.name, c.name, escapeHelp(c.help))
+	c.mu.Lock()
+	keys := make([]string, 0, len(c.values))
+	for k := range c.values {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s_total%s %d\n", c.name, labelSet(c.labels, k), c.values[k])
+	}
+	c.mu.Unlock()
+}
+
+// Gauge is a value that can go up and down, such as a latency last
+// measured, optionally partitioned by labels.
+type Gauge struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]float64 // keyed like Counter's
+}
+
+// NewGauge registers a gauge family.  name is given without the namespace;
+// labels name the dimensions whose values are passed to Set.  It panics if
+// name is already registered.
+func NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{name: Namespace + "_" + name, help: help, labels: labels, values: map[string]float64{}}
+	register(g)
+	return g
+}
+
+// Set sets the gauge for the given label values, which must match the
+// labels the gauge was registered with.
+func (g *Gauge) Set(v float64, values ...string) {
+	if len(values) != len(g.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", g.name, len(g.labels), len(values)))
+	}
+	key := strings.Join(values, "\x00")
+	g.mu.Lock()
+	g.values[key] = v
+	g.mu.Unlock()
+}
+
+// Value returns the gauge for the given label values.
+func (g *Gauge) Value(values ...string) float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.values[strings.Join(values, "\x00")]
+}
+
+func (g *Gauge) metricName() string { return g.name }
+
+func (g *Gauge) write(w io.Writer) {
+	fmt.Fprintf(w, "# TYPE %s gauge\n# HELP %s %s\n", g.name, g.name, escapeHelp(g.help))
+	g.mu.Lock()
+	keys := make([]string, 0, len(g.values))
+	for k := range g.values {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s%s %s\n", g.name, labelSet(g.labels, k), strconv.FormatFloat(g.values[k], 'g', -1, 64))
+	}
+	g.mu.Unlock()
+}
+
+func labelSet(labels []string, key string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	values := strings.Split(key, "\x00")
+	pairs := make([]string, len(labels))
+	for i, l := range labels {
+		pairs[i] = l + `="` + escapeLabel(values[i]) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+// WriteTo writes every registered metric to w in the OpenMetrics text
+// format, ending with the # EOF marker.
+func WriteTo(w io.Writer) {
+	registry.mu.Lock()
+	families := slices.Clone(registry.families)
+	registry.mu.Unlock()
+	for _, f := range families {
+		f.write(w)
+	}
+	io.WriteString(w, "# EOF\n")
+}
+
+// Handler serves the registered metrics for Prometheus to scrape.  Like
+// the other diagnostics it carries no authentication of its own.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		WriteTo(w)
+	})
+}
@@ -0,0 +1,80 @@
+package metrics_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/metrics"
+)
+
+func TestCounterExposition(t *testing.T) {
+	c := metrics.NewCounter("test_widgets", "Widgets made.\nSecond line.", "colour")
+	c.Inc("red")
+	c.Add(2, `bl"ue`)
+
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if ct := w.Header().Get("Content-Type"); ct != metrics.ContentType {
+		t.Fatalf("unexpected Content-Type %q", ct)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		"# TYPE football_api_test_widgets counter\n",
+		"# HELP football_api_test_widgets Widgets made.\\nSecond line.\n",
+		`football_api_test_widgets_total{colour="bl\"ue"} 2` + "\n",
+		`football_api_test_widgets_total{colour="red"} 1` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in\n%s", want, body)
+		}
+	}
+	if !strings.HasSuffix(body, "# EOF\n") {
+		t.Errorf("expected the # EOF marker last, got\n%s", body)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	bus := events.NewBus(nil)
+	metrics.Subscribe(bus)
+	teams, matches := metrics.Created.Value("team"), metrics.Deleted.Value("match")
+	logins, registrations := metrics.Logins.Value(), metrics.Registrations.Value()
+
+	ctx := context.Background()
+	for _, typ := range []events.Type{events.TeamCreated, events.TeamCreated, events.MatchDeleted, events.UserRegistered, events.SessionCreated} {
+		bus.Publish(ctx, events.Event{Type: typ})
+	}
+
+	if got := metrics.Created.Value("team") - teams; got != 2 {
+		t.Errorf("teams created: got %d, want 2", got)
+	}
+	if got := metrics.Deleted.Value("match") - matches; got != 1 {
+		t.Errorf("matches deleted: got %d, want 1", got)
+	}
+	if metrics.Logins.Value()-logins != 1 || metrics.Registrations.Value()-registrations != 1 {
+		t.Error("expected one login and one registration")
+	}
+}
+
+func TestGaugeExposition(t *testing.T) {
+	g := metrics.NewGauge("test_temperature_celsius", "Temperature.", "room")
+	g.Set(21.5, "kitchen")
+	g.Set(3, "hall")
+	g.Set(19, "hall")
+	if g.Value("hall") != 19 {
+		t.Fatalf("Value = %v, want the last value set", g.Value("hall"))
+	}
+
+	var b strings.Builder
+	metrics.WriteTo(&b)
+	want := "# TYPE football_api_test_temperature_celsius gauge\n" +
+		"# HELP football_api_test_temperature_celsius Temperature.\n" +
+		`football_api_test_temperature_celsius{room="hall"} 19` + "\n" +
+		`football_api_test_temperature_celsius{room="kitchen"} 21.5` + "\n"
+	if !strings.Contains(b.String(), want) {
+		t.Errorf("missing\n%s\nin\n%s", want, b.String())
+	}
+}
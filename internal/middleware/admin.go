@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// RequireAdmin restricts a route to the named administrator accounts.  It must
+// run after Authenticate, which sets the caller's username; callers that are
+// authenticated but not listed receive 403 Forbidden.
+func RequireAdmin(admins []string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(admins))
+	for _, name := range admins {
+		allowed[name] = true
+	}
+
+	return func(c *gin.Context) {
+		if !allowed[c.GetString("username")] {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{Error: "admin privileges required", Code: errcode.AdminRequired})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,42 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/middleware"
+)
+
+// adminRouter authenticates every request as username, then applies
+// RequireAdmin with the given administrators.
+func adminRouter(username string, admins []string) *gin.Engine {
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("username", username)
+		c.Next()
+	})
+	r.GET("/admin", middleware.RequireAdmin(admins), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	return r
+}
+
+func TestRequireAdmin(t *testing.T) {
+	cases := []struct {
+		username string
+		want     int
+	}{
+		{"alice", http.StatusNoContent},
+		{"mallory", http.StatusForbidden},
+		{"", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		adminRouter(tc.username, []string{"alice"}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+		if w.Code != tc.want {
+			t.Errorf("user %q: expected %d, got %d", tc.username, tc.want, w.Code)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/alert"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// Recovery is gin.Recovery that also raises an alert.Panic alert.  A nil
+// alerter behaves like gin.Recovery.
+func Recovery(a *alert.Alerter) gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, err any) {
+		id, _ := c.Get("requestID")
+		a.Send(alert.Panic, fmt.Sprintf("Panic in %s %s", c.Request.Method, c.FullPath()),
+			fmt.Sprintf("req-id=%v: %v\n```\n%s```", id, err, debug.Stack()))
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+	})
+}
+
+// ErrorRateAlert raises an alert.ErrorRate alert when threshold or more
+// responses within one window are 5xx.  A threshold of zero or less
+// disables it.
+func ErrorRateAlert(a *alert.Alerter, threshold int, window time.Duration) gin.HandlerFunc {
+	if a == nil || threshold <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	var (
+		mu     sync.Mutex
+		start  time.Time
+		count  int
+		raised bool
+	)
+	return func(c *gin.Context) {
+		c.Next()
+		if c.Writer.Status() < 500 {
+			return
+		}
+		now := time.Now()
+		mu.Lock()
+		if now.Sub(start) >= window {
+			start, count, raised = now, 0, false
+		}
+		count++
+		fire := count >= threshold && !raised
+		if fire {
+			raised = true
+		}
+		mu.Unlock()
+		if fire {
+			a.Send(alert.ErrorRate, "Server error spike",
+				fmt.Sprintf("%d responses with status 5xx within %v; latest %d on %s %s",
+					threshold, window, c.Writer.Status(), c.Request.Method, c.FullPath()))
+		}
+	}
+}
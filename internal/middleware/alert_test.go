@@ -0,0 +1,76 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/alert"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/middleware"
+)
+
+func recordingAlerter(t *testing.T) (*alert.Alerter, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var posts []string
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		posts = append(posts, string(b))
+		mu.Unlock()
+	}))
+	t.Cleanup(hook.Close)
+	a, err := alert.New(alert.Config{WebhookURL: hook.URL, MinInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a, func() []string {
+		a.Wait()
+		mu.Lock()
+		defer mu.Unlock()
+		return posts
+	}
+}
+
+func TestRecovery_Alerts(t *testing.T) {
+	a, posts := recordingAlerter(t)
+	r := gin.New()
+	r.Use(middleware.Recovery(a))
+	r.GET("/boom", func(c *gin.Context) { panic("kaboom") })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/boom", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+	got := posts()
+	if len(got) != 1 || !strings.Contains(got[0], "kaboom") || !strings.Contains(got[0], "/boom") {
+		t.Errorf("unexpected alerts %v", got)
+	}
+}
+
+func TestErrorRateAlert(t *testing.T) {
+	a, posts := recordingAlerter(t)
+	r := gin.New()
+	r.Use(middleware.ErrorRateAlert(a, 3, time.Minute))
+	r.GET("/fail", func(c *gin.Context) { c.Status(http.StatusBadGateway) })
+	r.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, path := range []string{"/fail", "/ok", "/fail", "/ok"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if got := posts(); len(got) != 0 {
+		t.Fatalf("expected no alert below the threshold, got %v", got)
+	}
+	for range 3 {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+	}
+	if got := posts(); len(got) != 1 || !strings.Contains(got[0], "502") {
+		t.Errorf("expected one alert for the spike, got %v", got)
+	}
+}
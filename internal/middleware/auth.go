@@ -1,32 +1,115 @@
 package middleware
 
 import (
+	"bytes"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/async"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
 )
 
+// Authenticators lists the credential types accepted by Authenticate.  JWT is
+// always required; HMAC and ClientCerts are optional and disabled when nil.
+// When Sessions is set, JWTs bound to a login session are rejected once that
+// session has been revoked.  When Quota is set, authenticated callers who
+// have used up their monthly quota are refused with 402.  Tokens issued
+// to third-party applications are refused with 403 unless Grants is set;
+// then they are accepted while the user's grant to the application stands,
+// if they carry Scope.
+type Authenticators struct {
+	JWT  *auth.JWTService
+	HMAC *auth.HMACVerifier
+	// HMACMaxBody caps the body of a signed request, which is read in full
+	// to check the signature; larger bodies are refused with 413.  Zero
+	// uses DefaultHMACMaxBody.
+	HMACMaxBody int64
+	ClientCerts *auth.ClientCertMapper
+	Sessions    SessionChecker
+	Grants      GrantChecker
+	Scope       string
+	Quota       *MonthlyQuota
+}
+
+// DefaultHMACMaxBody is the largest signed request body read when
+// Authenticators.HMACMaxBody is zero.
+const DefaultHMACMaxBody = 1 << 20
+
+// SessionChecker confirms that a login session is still active.
+// db.SessionRepository satisfies it.
+type SessionChecker interface {
+	TouchSession(id string) error
+}
+
+// GrantChecker confirms that a user's grant to an application still
+// stands.  db.OAuthRepository satisfies it.
+type GrantChecker interface {
+	TouchGrant(id string) error
+}
+
 // JWTAuth validates JWT tokens from the Authorization header.
 // This middleware enforces the Stateless principle — all authentication state
 // is contained in the self-describing JWT token, not in server-side sessions.
 func JWTAuth(jwtService *auth.JWTService) gin.HandlerFunc {
+	return Authenticate(Authenticators{JWT: jwtService})
+}
+
+// Authenticate resolves the caller from, in order of precedence:
+//
+//  1. a verified TLS client certificate whose subject is mapped to a user or
+//     service account (no Authorization header needed);
+//  2. an HMAC-SHA256 signed request — the caller is identified by key ID,
+//     attached as "username" with a "key:" prefix so it can never collide
+//     with a registered account;
+//  3. a Bearer JWT.
+func Authenticate(a Authenticators) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if identity, ok := async.Identity(c.Request.Context()); ok {
+			// A background copy of a request authenticated, and admitted
+			// by the quota, when it was accepted; see Prefer.
+			for key, v := range identity {
+				c.Set(key, v)
+			}
+			c.Next()
+			return
+		}
+		if a.ClientCerts != nil {
+			if identity, ok := a.ClientCerts.Identify(c.Request.TLS); ok {
+				c.Set("username", identity)
+				c.Set("authScheme", "mTLS")
+				a.Quota.admit(c)
+				return
+			}
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
 				Error: "authorization header required",
+				Code:  errcode.AuthRequired,
 			})
 			return
 		}
 
-		// Extract token from "Bearer <token>" format
 		parts := strings.SplitN(authHeader, " ", 2)
+		if len(parts) == 2 && parts[0] == auth.HMACScheme && a.HMAC != nil {
+			if verifySignedRequest(c, a.HMAC, parts[1], a.HMACMaxBody) {
+				a.Quota.admit(c)
+			}
+			return
+		}
+
+		// Extract token from "Bearer <token>" format
 		if len(parts) != 2 || parts[0] != "Bearer" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
 				Error: "authorization header format must be 'Bearer {token}'",
+				Code:  errcode.AuthHeaderMalformed,
 			})
 			return
 		}
@@ -34,16 +117,208 @@ func JWTAuth(jwtService *auth.JWTService) gin.HandlerFunc {
 		tokenString := parts[1]
 
 		// Validate token
-		claims, err := jwtService.ValidateToken(tokenString)
+		claims, err := a.JWT.ValidateToken(tokenString)
 		if err != nil {
+			code := errcode.TokenInvalid
+			if errors.Is(err, auth.ErrExpiredToken) {
+				code = errcode.TokenExpired
+			}
 			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
 				Error: "invalid or expired token",
+				Code:  code,
 			})
 			return
 		}
 
+		if claims.ClientID != "" && !checkGrant(c, a, claims) {
+			return
+		}
+
+		if claims.SessionID != "" && a.Sessions != nil {
+			err := a.Sessions.TouchSession(claims.SessionID)
+			if errors.Is(err, models.ErrNotFound) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
+					Error: "session has been revoked",
+					Code:  errcode.SessionRevoked,
+				})
+				return
+			}
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{
+					Error: "internal server error",
+					Code:  errcode.Internal,
+				})
+				return
+			}
+			c.Set("sessionID", claims.SessionID)
+		}
+
 		// Attach username to context for handlers to use
 		c.Set("username", claims.Username)
+		c.Set("authScheme", "Bearer")
+		if claims.Act != nil {
+			// An administrator is acting as the user; see
+			// POST /admin/impersonate.
+			c.Set("impersonator", claims.Act.Subject)
+		}
+		if claims.AuthTime != nil {
+			c.Set("authTime", claims.AuthTime.Time)
+		}
+		if claims.ClientID != "" {
+			// An application is acting for the user.
+			c.Set("oauthClient", claims.ClientID)
+			c.Set("scope", claims.Scope)
+		}
+		a.Quota.admit(c)
+	}
+}
+
+// identityKeys are the context keys in which Authenticate records who the
+// caller is.
+var identityKeys = []string{"username", "authScheme", "sessionID", "impersonator", "authTime", "oauthClient", "scope"}
+
+// checkGrant admits a token issued to an application if the route accepts
+// such tokens, the token carries the route's scope and the user has not
+// revoked the application's access, reporting false after refusing the
+// request otherwise.  Refusals carry RFC 6750 challenges.
+func checkGrant(c *gin.Context, a Authenticators, claims *auth.Claims) bool {
+	if a.Grants == nil {
+		c.Header("WWW-Authenticate", `Bearer error="insufficient_scope"`)
+		c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+			Error: "this endpoint does not accept tokens issued to applications",
+			Code:  errcode.InsufficientScope,
+		})
+		return false
+	}
+	if a.Scope != "" && !auth.HasScope(claims.Scope, a.Scope) {
+		c.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+a.Scope+`"`)
+		c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+			Error: "this application has not been granted the " + a.Scope + " scope",
+			Code:  errcode.InsufficientScope,
+		})
+		return false
+	}
+	err := a.Grants.TouchGrant(claims.GrantID)
+	if errors.Is(err, models.ErrNotFound) {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error: "the user has revoked this application's access",
+			Code:  errcode.AppAccessRevoked,
+		})
+		return false
+	}
+	if err != nil {
+		_ = c.Error(err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "internal server error",
+			Code:  errcode.Internal,
+		})
+		return false
+	}
+	return true
+}
+
+// IdentifyBearer attaches the username of a valid Bearer JWT to the
+// context, as Authenticate does, but never rejects the request: without a
+// token, or with an invalid one, the caller is simply anonymous.  Public
+// routes use it to personalise responses for signed-in users; as nothing is
+// authorised on the strength of it, revoked sessions are not checked.
+func IdentifyBearer(jwt *auth.JWTService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Responses may differ per caller, so shared caches must key on the
+		// token.
+		c.Writer.Header().Add("Vary", "Authorization")
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if ok {
+			if claims, err := jwt.ValidateToken(token); err == nil {
+				c.Set("username", claims.Username)
+				c.Set("authScheme", "Bearer")
+			}
+		}
 		c.Next()
 	}
 }
+
+// verifySignedRequest validates an HMAC-SHA256 signature over the request,
+// attaching the key's identity and returning true if it is valid.  The body
+// is read in full, up to maxBody bytes, to compute its hash and then restored
+// so that handlers can bind it as usual.  A body Codecs has converted to JSON
+// is checked as it was sent.
+func verifySignedRequest(c *gin.Context, verifier *auth.HMACVerifier, credentials string, maxBody int64) bool {
+	if maxBody <= 0 {
+		maxBody = DefaultHMACMaxBody
+	}
+	tooLarge := func() bool {
+		c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{
+			Error: "signed request bodies are limited to " + strconv.FormatInt(maxBody, 10) + " bytes",
+			Code:  errcode.BodyTooLarge,
+		})
+		return false
+	}
+	var body []byte
+	if raw, ok := c.Get(wireBodyKey); ok {
+		body = raw.([]byte)
+		if int64(len(body)) > maxBody {
+			return tooLarge()
+		}
+	} else if c.Request.Body != nil {
+		var err error
+		body, err = io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBody))
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return tooLarge()
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: "failed to read request body",
+				Code:  errcode.MalformedBody,
+			})
+			return false
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	keyID, err := verifier.Verify(c.Request, credentials, body)
+	switch {
+	case errors.Is(err, auth.ErrReplayedRequest):
+		c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  errcode.CredentialReplayed,
+		})
+		return false
+	case errors.Is(err, auth.ErrInvalidSignature), errors.Is(err, auth.ErrUnknownKey), errors.Is(err, auth.ErrStaleSignature):
+		c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  errcode.SignatureInvalid,
+		})
+		return false
+	case err != nil:
+		_ = c.Error(err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "internal server error",
+			Code:  errcode.Internal,
+		})
+		return false
+	}
+
+	c.Set("username", "key:"+keyID)
+	c.Set("authScheme", auth.HMACScheme)
+	return true
+}
+
+// RequireServiceAccount restricts a route to machine callers: those
+// authenticated by an HMAC-signed request or a TLS client certificate rather
+// than by a user's Bearer JWT.  It must run after Authenticate.
+func RequireServiceAccount() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.GetString("authScheme") {
+		case auth.HMACScheme, "mTLS":
+			c.Next()
+		default:
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+				Error: "this endpoint is restricted to service accounts",
+				Code:  errcode.ServiceAccountRequired,
+			})
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/middleware"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// activeSessions is a SessionChecker over a fixed set of session IDs.
+type activeSessions map[string]bool
+
+func (s activeSessions) TouchSession(id string) error {
+	if !s[id] {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+func TestAuthenticate_RevokedSession(t *testing.T) {
+	jwt := auth.NewJWTService("test-secret", "COMP3011_API")
+	r := gin.New()
+	r.GET("/", middleware.Authenticate(middleware.Authenticators{
+		JWT:      jwt,
+		Sessions: activeSessions{"live": true},
+	}), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	cases := []struct {
+		sessionID string
+		want      int
+	}{
+		{"live", http.StatusOK},
+		{"revoked", http.StatusUnauthorized},
+		{"", http.StatusOK}, // tokens without a session are not checked
+	}
+	for _, tc := range cases {
+		token, _ := jwt.GenerateSessionToken("alice", tc.sessionID)
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("session %q: expected %d, got %d", tc.sessionID, tc.want, w.Code)
+		}
+	}
+}
+
+func TestAuthenticate_ImpersonationToken(t *testing.T) {
+	jwt := auth.NewJWTService("test-secret", "COMP3011_API")
+	r := gin.New()
+	r.GET("/", middleware.Authenticate(middleware.Authenticators{JWT: jwt}), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("username")+" "+c.GetString("impersonator"))
+	})
+
+	token, expires, err := jwt.GenerateImpersonationToken("alice", "root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ttl := time.Until(expires); ttl > auth.ImpersonationTTL || ttl < auth.ImpersonationTTL-time.Minute {
+		t.Errorf("token expires in %v, want %v", ttl, auth.ImpersonationTTL)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "alice root" {
+		t.Errorf("got %d %q, want alice acting as root", w.Code, w.Body.String())
+	}
+}
+
+// liveGrants is a GrantChecker over a fixed set of grant IDs.
+type liveGrants map[string]bool
+
+func (g liveGrants) TouchGrant(id string) error {
+	if !g[id] {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+func TestAuthenticate_DelegatedToken(t *testing.T) {
+	jwt := auth.NewJWTService("test-secret", "COMP3011_API")
+	ok := func(c *gin.Context) { c.String(http.StatusOK, c.GetString("oauthClient")+" "+c.GetString("scope")) }
+	r := gin.New()
+	r.GET("/account", middleware.Authenticate(middleware.Authenticators{JWT: jwt}), ok)
+	r.GET("/read", middleware.Authenticate(middleware.Authenticators{
+		JWT: jwt, Grants: liveGrants{"g1": true}, Scope: auth.ScopeFootballRead,
+	}), ok)
+	r.GET("/write", middleware.Authenticate(middleware.Authenticators{
+		JWT: jwt, Grants: liveGrants{"g1": true}, Scope: auth.ScopeFootballWrite,
+	}), ok)
+
+	cases := []struct {
+		path, grantID string
+		want          int
+		challenge     string
+	}{
+		{"/read", "g1", http.StatusOK, ""},
+		{"/account", "g1", http.StatusForbidden, `Bearer error="insufficient_scope"`},
+		{"/write", "g1", http.StatusForbidden, `Bearer error="insufficient_scope", scope="football:write"`},
+		{"/read", "revoked", http.StatusUnauthorized, `Bearer error="invalid_token"`},
+	}
+	for _, tc := range cases {
+		token, _, err := jwt.GenerateDelegatedToken("alice", "app", tc.grantID, auth.ScopeFootballRead)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want || w.Header().Get("WWW-Authenticate") != tc.challenge {
+			t.Errorf("%s with grant %q: got %d %q, want %d %q", tc.path, tc.grantID,
+				w.Code, w.Header().Get("WWW-Authenticate"), tc.want, tc.challenge)
+		}
+		if tc.want == http.StatusOK && w.Body.String() != "app football:read" {
+			t.Errorf("%s: got body %q", tc.path, w.Body.String())
+		}
+	}
+}
+
+func TestAuthenticate_SignedBodyLimit(t *testing.T) {
+	r := gin.New()
+	r.POST("/", middleware.Authenticate(middleware.Authenticators{
+		HMAC:        auth.NewHMACVerifier(map[string]string{"svc": "shared-secret"}, 5*time.Minute),
+		HMACMaxBody: 16,
+	}), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for _, tc := range []struct {
+		body string
+		want int
+	}{
+		{`{"name":"Wales"}`, http.StatusNoContent},
+		{`{"name":"Bosnia and Herzegovina"}`, http.StatusRequestEntityTooLarge},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+		date := time.Now().UTC().Format(time.RFC3339)
+		req.Header.Set(auth.HMACDateHeader, date)
+		req.Header.Set("Authorization", auth.HMACScheme+" KeyId=svc,Signature="+
+			auth.Sign("shared-secret", auth.StringToSign(http.MethodPost, "/", date, []byte(tc.body))))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Fatalf("%d-byte body: expected %d, got %d: %s", len(tc.body), tc.want, w.Code, w.Body)
+		}
+		if tc.want == http.StatusRequestEntityTooLarge {
+			var body models.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != errcode.BodyTooLarge {
+				t.Errorf("unexpected body %s", w.Body)
+			}
+		}
+	}
+}
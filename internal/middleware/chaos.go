@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// ChaosFaultHeader names the fault Chaos injected into a response, so that
+// client logs can tell injected failures from real ones.
+const ChaosFaultHeader = "X-Chaos-Fault"
+
+// ChaosConfig sets how often Chaos injects each kind of fault.  Each
+// percentage (0–100) is rolled independently per request.
+type ChaosConfig struct {
+	// LatencyPercent of requests are delayed by Latency before running.
+	LatencyPercent int
+	Latency        time.Duration
+	// DropPercent of requests have their connection closed without a
+	// response.
+	DropPercent int
+	// ErrorPercent of requests fail with 500 Internal Server Error without
+	// reaching the handler.
+	ErrorPercent int
+
+	// Rand returns a number in [0, 100); nil uses math/rand.  Tests set it
+	// to choose faults deterministically.
+	Rand func() int
+}
+
+// Chaos injects latency, dropped connections and 500 responses into a share
+// of requests, so that client retries, timeouts and circuit breakers can be
+// exercised against a staging deployment.  It must never run in
+// production; the server only installs it when CHAOS_MODE=true.
+func Chaos(cfg ChaosConfig) gin.HandlerFunc {
+	roll := cfg.Rand
+	if roll == nil {
+		roll = func() int { return rand.IntN(100) }
+	}
+	hit := func(percent int) bool { return percent > 0 && roll() < percent }
+
+	return func(c *gin.Context) {
+		if hit(cfg.LatencyPercent) {
+			c.Header(ChaosFaultHeader, "latency")
+			timer := time.NewTimer(cfg.Latency)
+			select {
+			case <-timer.C:
+			case <-c.Request.Context().Done():
+				timer.Stop()
+				c.Abort()
+				return
+			}
+		}
+		fail := false
+		if hit(cfg.DropPercent) {
+			if canHijack(c.Writer) {
+				if conn, _, err := c.Writer.Hijack(); err == nil {
+					conn.Close()
+					c.Abort()
+					return
+				}
+			}
+			// The connection cannot be taken over (HTTP/2, tests), so
+			// fail the request instead.
+			fail = true
+		}
+		if fail || hit(cfg.ErrorPercent) {
+			c.Header(ChaosFaultHeader, "error")
+			c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "internal server error",
+				Code:  errcode.Internal,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// canHijack reports whether the connection under w can be taken over.
+// gin's writer panics on Hijack rather than returning an error when it
+// cannot, so the writers it and other middleware wrap are unwrapped and
+// checked first.
+func canHijack(w http.ResponseWriter) bool {
+	for {
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			_, ok := w.(http.Hijacker)
+			return ok
+		}
+		w = u.Unwrap()
+	}
+}
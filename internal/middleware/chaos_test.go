@@ -0,0 +1,94 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/middleware"
+)
+
+// fixedRoll makes every percentage check see n.
+func fixedRoll(n int) func() int { return func() int { return n } }
+
+func chaosRouter(cfg middleware.ChaosConfig) *gin.Engine {
+	r := gin.New()
+	r.Use(middleware.Chaos(cfg))
+	r.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func TestChaos_PassesThroughBelowRate(t *testing.T) {
+	r := chaosRouter(middleware.ChaosConfig{LatencyPercent: 10, Latency: time.Hour, DropPercent: 10, ErrorPercent: 10, Rand: fixedRoll(50)})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	if w.Code != http.StatusOK || w.Header().Get(middleware.ChaosFaultHeader) != "" {
+		t.Fatalf("expected untouched 200, got %d %q", w.Code, w.Header().Get(middleware.ChaosFaultHeader))
+	}
+}
+
+func TestChaos_InjectsError(t *testing.T) {
+	r := chaosRouter(middleware.ChaosConfig{ErrorPercent: 100})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	if w.Code != http.StatusInternalServerError || w.Header().Get(middleware.ChaosFaultHeader) != "error" {
+		t.Fatalf("expected injected 500, got %d %q", w.Code, w.Header().Get(middleware.ChaosFaultHeader))
+	}
+}
+
+func TestChaos_InjectsLatency(t *testing.T) {
+	r := chaosRouter(middleware.ChaosConfig{LatencyPercent: 100, Latency: 20 * time.Millisecond})
+	w := httptest.NewRecorder()
+	start := time.Now()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Fatalf("expected at least 20ms delay, took %v", elapsed)
+	}
+	if w.Code != http.StatusOK || w.Header().Get(middleware.ChaosFaultHeader) != "latency" {
+		t.Fatalf("expected delayed 200, got %d %q", w.Code, w.Header().Get(middleware.ChaosFaultHeader))
+	}
+}
+
+func TestChaos_DropsConnection(t *testing.T) {
+	srv := httptest.NewServer(chaosRouter(middleware.ChaosConfig{DropPercent: 100}))
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/ok")
+	if err == nil {
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+		t.Fatalf("expected a dropped connection, got status %d", res.StatusCode)
+	}
+}
+
+func TestChaos_DropFallsBackToErrorWithoutHijack(t *testing.T) {
+	r := chaosRouter(middleware.ChaosConfig{DropPercent: 100})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 when the connection cannot be hijacked, got %d", w.Code)
+	}
+}
+
+func TestChaos_DropBehindWrappedWriters(t *testing.T) {
+	r := gin.New()
+	r.Use(middleware.ErrorCodes(), middleware.Compact())
+	r.Use(middleware.Chaos(middleware.ChaosConfig{DropPercent: 100}))
+	r.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 when the connection cannot be hijacked, got %d", w.Code)
+	}
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+	if res, err := http.Get(srv.URL + "/ok"); err == nil {
+		res.Body.Close()
+		t.Fatalf("expected a dropped connection, got status %d", res.StatusCode)
+	}
+}
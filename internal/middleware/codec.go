@@ -0,0 +1,195 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/codec"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/schema"
+)
+
+// Documents names the schemas of the JSON documents routes read and write,
+// keyed by "METHOD /full/path" as schema.Routes are, for codecs such as
+// Protocol Buffers that need them.
+type Documents struct {
+	Requests  schema.Routes
+	Responses schema.Routes
+	// Error is the schema of every 4xx and 5xx response.
+	Error string
+}
+
+// MaxDecodedBody caps a body Codecs converts to JSON, which is read whole
+// before the request is authenticated.
+const MaxDecodedBody = 1 << 20
+
+// wireBodyKey is the context key under which Codecs keeps a converted
+// body as it was sent, for signatures computed over it.
+const wireBodyKey = "wireBody"
+
+// Codecs lets clients use the encodings of codecs in place of JSON.
+//
+// A POST or PUT body in one of their media types is converted to JSON
+// before the handler reads it, the bytes sent being kept for signature
+// checks; one that cannot be decoded is refused with 400, one larger than
+// MaxDecodedBody with 413, and one whose codec does not support the
+// route's request schema with 415.  A JSON response is converted to the codec the Accept header
+// prefers over JSON, with Content-Type parameters such as profile kept,
+// unless the codec cannot encode it, in which case it is sent as JSON;
+// */* and a missing Accept header mean JSON.  Every response varies on
+// Accept.
+func Codecs(codecs []codec.Codec, docs Documents) gin.HandlerFunc {
+	byType := map[string]codec.Codec{}
+	for _, cd := range codecs {
+		byType[cd.MediaType()] = cd
+	}
+	return func(c *gin.Context) {
+		vary(c.Writer.Header(), "Accept")
+		if c.Request.Method == http.MethodPost || c.Request.Method == http.MethodPut {
+			mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+			cd, ok := byType[strings.ToLower(mediaType)]
+			if ok && !decodeBody(c, cd, docs.Requests[c.Request.Method+" "+c.FullPath()]) {
+				return
+			}
+		}
+
+		cd := negotiate(c.GetHeader("Accept"), byType)
+		if cd == nil {
+			c.Next()
+			return
+		}
+		w := &codecWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		if !w.buffering {
+			return
+		}
+		name := docs.Error
+		if w.Status() < 400 {
+			name = docs.Responses[c.Request.Method+" "+c.FullPath()]
+		}
+		body, err := cd.Encode(name, w.body.Bytes())
+		if err != nil {
+			// Not valid JSON after all: send it as it is.
+			w.ResponseWriter.Write(w.body.Bytes())
+			return
+		}
+		_, params, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+		delete(params, "charset")
+		w.Header().Set("Content-Type", mime.FormatMediaType(cd.MediaType(), params))
+		w.Header().Del("Content-Length")
+		w.ResponseWriter.Write(body)
+	}
+}
+
+// decodeBody replaces c's body, encoded with cd, by its JSON equivalent of
+// schema name, reporting false after refusing the request if it cannot be
+// decoded.
+func decodeBody(c *gin.Context, cd codec.Codec, name string) bool {
+	raw, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, MaxDecodedBody))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{
+			Error: cd.MediaType() + " bodies are limited to " + strconv.Itoa(MaxDecodedBody) + " bytes",
+			Code:  errcode.BodyTooLarge,
+		})
+		return false
+	}
+	var body []byte
+	if err == nil {
+		body, err = cd.Decode(name, raw)
+	}
+	if errors.Is(err, codec.ErrUnsupported) {
+		c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, models.UnsupportedMediaTypeResponse{
+			Error:     "Content-Type " + cd.MediaType() + " is not supported by this endpoint; use application/json",
+			Code:      errcode.UnsupportedMediaType,
+			Supported: []string{"application/json"},
+		})
+		return false
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid " + cd.MediaType() + " body", Code: errcode.MalformedBody})
+		return false
+	}
+	c.Set(wireBodyKey, raw)
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	c.Request.ContentLength = int64(len(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Request.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return true
+}
+
+// negotiate returns the codec accept prefers to JSON, or nil.  Media ranges
+// are weighed by their q parameter, earlier ones winning ties; JSON, */*
+// and application/* count as JSON.
+func negotiate(accept string, byType map[string]codec.Codec) codec.Codec {
+	var best codec.Codec
+	bestQ := -1.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil || q <= 0 {
+				continue
+			}
+		}
+		if q <= bestQ {
+			continue
+		}
+		switch cd, ok := byType[mediaType]; {
+		case ok:
+			best, bestQ = cd, q
+		case mediaType == "application/json" || mediaType == "*/*" || mediaType == "application/*":
+			best, bestQ = nil, q
+		}
+	}
+	return best
+}
+
+// codecWriter holds back a JSON response so that it can be re-encoded.
+// Other responses, including streams, pass straight through.
+type codecWriter struct {
+	gin.ResponseWriter
+	decided, buffering bool
+	body               bytes.Buffer
+}
+
+// Unwrap returns the writer underneath, for http.ResponseController.
+func (w *codecWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+func (w *codecWriter) decide() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	w.buffering = mediaType == "application/json"
+}
+
+func (w *codecWriter) Write(b []byte) (int, error) {
+	w.decide()
+	if w.buffering {
+		return w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *codecWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *codecWriter) Flush() {
+	if !w.buffering {
+		w.ResponseWriter.Flush()
+	}
+}
@@ -0,0 +1,117 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/codec"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/middleware"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/schema"
+)
+
+func codecRouter() *gin.Engine {
+	r := gin.New()
+	r.Use(middleware.Codecs(codec.Default(), middleware.Documents{
+		Requests:  schema.Routes{"POST /teams": "CreateTeamRequest"},
+		Responses: schema.Routes{"POST /teams": "TeamResponse"},
+		Error:     "ErrorResponse",
+	}))
+	r.POST("/teams", func(c *gin.Context) {
+		var body map[string]any
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"id": 1, "name": body["name"]})
+	})
+	r.POST("/teams/:id/elo", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"rating": 1500}) })
+	return r
+}
+
+func TestCodecs_DecodesBodyAndEncodesResponse(t *testing.T) {
+	for _, cd := range codec.Default() {
+		body, err := cd.Encode("CreateTeamRequest", []byte(`{"name":"Italy"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/teams", bytes.NewReader(body))
+		req.Header.Set("Content-Type", cd.MediaType())
+		req.Header.Set("Accept", "application/json;q=0.5, "+cd.MediaType())
+		w := httptest.NewRecorder()
+		codecRouter().ServeHTTP(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("%s: expected 201, got %d: %s", cd.MediaType(), w.Code, w.Body.String())
+		}
+		if got := w.Header().Get("Content-Type"); got != cd.MediaType() {
+			t.Errorf("%s: unexpected Content-Type %q", cd.MediaType(), got)
+		}
+		out, err := cd.Decode("TeamResponse", w.Body.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got map[string]any
+		json.Unmarshal(out, &got)
+		if got["name"] != "Italy" || got["id"] != 1.0 {
+			t.Errorf("%s: unexpected response %s", cd.MediaType(), out)
+		}
+	}
+}
+
+func TestCodecs_JSONByDefault(t *testing.T) {
+	for _, accept := range []string{"", "*/*", "application/json", "application/msgpack;q=0.1, application/json"} {
+		req := httptest.NewRequest(http.MethodPost, "/teams", bytes.NewReader([]byte(`{"name":"Italy"}`)))
+		req.Header.Set("Content-Type", "application/json")
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		codecRouter().ServeHTTP(w, req)
+		if b, _ := io.ReadAll(w.Body); !json.Valid(b) {
+			t.Errorf("Accept %q: expected JSON, got %q", accept, b)
+		}
+	}
+}
+
+func TestCodecs_RejectsUndecodableBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/teams", bytes.NewReader([]byte{0xc1}))
+	req.Header.Set("Content-Type", "application/msgpack")
+	w := httptest.NewRecorder()
+	codecRouter().ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestCodecs_RejectsOversizedBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/teams", bytes.NewReader(make([]byte, middleware.MaxDecodedBody+1)))
+	req.Header.Set("Content-Type", "application/msgpack")
+	w := httptest.NewRecorder()
+	codecRouter().ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413, got %d", w.Code)
+	}
+}
+
+func TestCodecs_ProtobufOnlyWhereItHasMessages(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/teams/1/elo", bytes.NewReader([]byte{0x0a, 0x01, 0x41}))
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	w := httptest.NewRecorder()
+	codecRouter().ServeHTTP(w, req)
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("expected 415 for a body without a message, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/teams/1/elo", nil)
+	req.Header.Set("Accept", "application/x-protobuf")
+	w = httptest.NewRecorder()
+	codecRouter().ServeHTTP(w, req)
+	if ct := w.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" || !json.Valid(w.Body.Bytes()) {
+		t.Errorf("expected a JSON fallback, got %q %q", ct, w.Body.String())
+	}
+}
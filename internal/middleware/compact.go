@@ -0,0 +1,228 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// CompactProfile is the media-type profile of the compact list encoding,
+// requested with Accept: application/json; profile="compact".
+const CompactProfile = "compact"
+
+// Compact serves list responses in a columnar encoding when the client asks
+// for it with ?compact=true or an Accept media range carrying
+// profile="compact", in JSON or in any of the Codecs.  A successful JSON
+// response whose "data" is an array of objects has the keys of those
+// objects listed once in "fields", and each object replaced by the array
+// of its values in that order:
+//
+//	{"fields":["id","name"],"data":[[1,"Italy"],[2,"Spain"]],"links":[…]}
+//
+// A key an object lacks is null in its row.  Other members of the response
+// are left alone, as are responses that are not lists; compact responses
+// have the Content-Type application/json; profile="compact".  GET
+// responses vary on Accept, so shared caches keep the encodings apart.
+func Compact() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+		vary(c.Writer.Header(), "Accept")
+		compact := acceptsCompact(c.GetHeader("Accept"))
+		if v := c.Query("compact"); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "compact must be true or false", Code: errcode.InvalidParameter})
+				return
+			}
+			compact = b
+		}
+		if !compact {
+			c.Next()
+			return
+		}
+
+		w := &compactWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		if !w.buffering {
+			return
+		}
+		body := w.body.Bytes()
+		if out, ok := compactList(body); ok {
+			w.Header().Set("Content-Type", mime.FormatMediaType("application/json", map[string]string{"profile": CompactProfile}))
+			body = out
+		}
+		w.Header().Del("Content-Length")
+		w.ResponseWriter.Write(body)
+	}
+}
+
+// acceptsCompact reports whether an Accept header asks for the compact
+// profile.
+func acceptsCompact(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		_, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err == nil && params["profile"] == CompactProfile {
+			return true
+		}
+	}
+	return false
+}
+
+// compactWriter holds back a successful JSON response so that it can be
+// re-encoded.  Other responses, including streams, pass straight through.
+type compactWriter struct {
+	gin.ResponseWriter
+	decided, buffering bool
+	body               bytes.Buffer
+}
+
+// Unwrap returns the writer underneath, for http.ResponseController.
+func (w *compactWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+func (w *compactWriter) decide() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	w.buffering = w.Status() == http.StatusOK && mediaType == "application/json"
+}
+
+func (w *compactWriter) Write(b []byte) (int, error) {
+	w.decide()
+	if w.buffering {
+		return w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *compactWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *compactWriter) Flush() {
+	if !w.buffering {
+		w.ResponseWriter.Flush()
+	}
+}
+
+// member is one key and value of a JSON object, in document order.
+type member struct {
+	key   string
+	value json.RawMessage
+}
+
+// compactList re-encodes body in the compact profile, or reports false if
+// it is not an object with a "data" array of objects.
+func compactList(body []byte) ([]byte, bool) {
+	top, ok := members(body)
+	if !ok {
+		return nil, false
+	}
+	i := -1
+	for j, m := range top {
+		if m.key == "data" {
+			i = j
+		}
+	}
+	if i < 0 {
+		return nil, false
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(top[i].value, &items); err != nil {
+		return nil, false
+	}
+
+	var fields []string
+	index := map[string]int{}
+	objects := make([][]member, 0, len(items))
+	for _, item := range items {
+		obj, ok := members(item)
+		if !ok {
+			return nil, false
+		}
+		for _, m := range obj {
+			if _, seen := index[m.key]; !seen {
+				index[m.key] = len(fields)
+				fields = append(fields, m.key)
+			}
+		}
+		objects = append(objects, obj)
+	}
+
+	var rows bytes.Buffer
+	rows.WriteByte('[')
+	for n, obj := range objects {
+		if n > 0 {
+			rows.WriteByte(',')
+		}
+		row := make([]json.RawMessage, len(fields))
+		for k := range row {
+			row[k] = json.RawMessage("null")
+		}
+		for _, m := range obj {
+			row[index[m.key]] = m.value
+		}
+		b, _ := json.Marshal(row)
+		rows.Write(b)
+	}
+	rows.WriteByte(']')
+
+	if fields == nil {
+		fields = []string{}
+	}
+	names, _ := json.Marshal(fields)
+	out := append([]member{}, top[:i]...)
+	out = append(out, member{"fields", names}, member{"data", rows.Bytes()})
+	out = append(out, top[i+1:]...)
+	return encodeMembers(out), true
+}
+
+// members decodes a JSON object into its members in document order, or
+// reports false if b is not an object.
+func members(b []byte) ([]member, bool) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, false
+	}
+	var out []member
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, false
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, false
+		}
+		out = append(out, member{tok.(string), value})
+	}
+	return out, true
+}
+
+func encodeMembers(ms []member) []byte {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, m := range ms {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(m.key)
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(m.value)
+	}
+	b.WriteByte('}')
+	return b.Bytes()
+}
@@ -0,0 +1,67 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/middleware"
+)
+
+func compactRouter() *gin.Engine {
+	r := gin.New()
+	r.Use(middleware.Compact())
+	r.GET("/teams", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"data": []gin.H{
+				{"id": 1, "name": "Italy"},
+				{"id": 2, "name": "Spain", "language": "fr"},
+			},
+			"links": []gin.H{{"rel": "self"}},
+		})
+	})
+	r.GET("/teams/1", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"id": 1}) })
+	return r
+}
+
+func TestCompact_EncodesListsAsColumns(t *testing.T) {
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/teams?compact=true", nil),
+		func() *http.Request {
+			r := httptest.NewRequest(http.MethodGet, "/teams", nil)
+			r.Header.Set("Accept", `application/json; profile="compact"`)
+			return r
+		}(),
+	} {
+		w := httptest.NewRecorder()
+		compactRouter().ServeHTTP(w, req)
+
+		want := `{"fields":["id","name","language"],"data":[[1,"Italy",null],[2,"Spain","fr"]],"links":[{"rel":"self"}]}`
+		if w.Body.String() != want {
+			t.Errorf("%s: expected %s, got %s", req.URL, want, w.Body.String())
+		}
+		if got := w.Header().Get("Content-Type"); got != `application/json; profile=compact` {
+			t.Errorf("%s: unexpected Content-Type %q", req.URL, got)
+		}
+		if got := w.Header().Get("Vary"); got != "Accept" {
+			t.Errorf("%s: expected Vary Accept, got %q", req.URL, got)
+		}
+	}
+}
+
+func TestCompact_LeavesOtherResponsesAlone(t *testing.T) {
+	for _, target := range []string{"/teams", "/teams/1?compact=true"} {
+		w := httptest.NewRecorder()
+		compactRouter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Body.Len() == 0 || w.Body.String()[:2] == `{"f` {
+			t.Errorf("%s: expected the ordinary encoding, got %s", target, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	compactRouter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/teams?compact=maybe", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid compact, got %d", w.Code)
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConsistencyTokenHeader carries read-your-writes tokens.  Mutating requests
+// receive one in the response; clients echo it on the reads that follow.
+const ConsistencyTokenHeader = "X-Consistency-Token"
+
+// ReadYourWrites issues and honours consistency tokens so that a client does
+// not read stale data straight after its own write.
+//
+// Every POST, PUT, PATCH and DELETE response carries a token recording when
+// the write happened.  A GET or HEAD that echoes a token younger than the
+// cache lifetime is a consistent read: the response is marked no-store, the
+// handler sees "consistentRead" set in the context and skips server-side
+// caches (such as the Elo cache), and because GET responses vary on the
+// header, shared caches do not answer it from an entry stored before the
+// write.  Older or malformed tokens are ignored, since any cached copy from
+// before them has expired.  CacheControl leaves the no-store in place.
+func ReadYourWrites() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead:
+			c.Writer.Header().Add("Vary", ConsistencyTokenHeader)
+			if consistentRead(c.GetHeader(ConsistencyTokenHeader)) {
+				c.Set("consistentRead", true)
+				c.Header("Cache-Control", "no-store")
+			}
+		default:
+			c.Header(ConsistencyTokenHeader, strconv.FormatInt(time.Now().UnixNano(), 10))
+		}
+		c.Next()
+	}
+}
+
+// consistentRead reports whether token records a write recent enough that a
+// cached response could predate it.
+func consistentRead(token string) bool {
+	if token == "" {
+		return false
+	}
+	n, err := strconv.ParseInt(token, 10, 64)
+	if err != nil {
+		return false
+	}
+	return time.Since(time.Unix(0, n)) < cacheMaxAge
+}
@@ -0,0 +1,65 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/middleware"
+)
+
+func consistencyRouter() *gin.Engine {
+	r := gin.New()
+	r.Use(middleware.CacheControl(), middleware.ReadYourWrites())
+	handler := func(c *gin.Context) {
+		if c.GetBool("consistentRead") {
+			c.Header("X-Consistent", "true")
+		}
+		c.Status(http.StatusOK)
+	}
+	r.GET("/teams", handler)
+	r.POST("/teams", handler)
+	return r
+}
+
+func TestReadYourWrites_IssuesTokenOnWrite(t *testing.T) {
+	w := httptest.NewRecorder()
+	consistencyRouter().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/teams", nil))
+	if w.Header().Get(middleware.ConsistencyTokenHeader) == "" {
+		t.Fatal("expected a consistency token on a write response")
+	}
+}
+
+func TestReadYourWrites_FreshTokenBypassesCaches(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/teams", nil)
+	req.Header.Set(middleware.ConsistencyTokenHeader, strconv.FormatInt(time.Now().UnixNano(), 10))
+	w := httptest.NewRecorder()
+	consistencyRouter().ServeHTTP(w, req)
+
+	if w.Header().Get("X-Consistent") != "true" {
+		t.Error("expected handler to see a consistent read")
+	}
+	if got := w.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("expected Cache-Control no-store, got %q", got)
+	}
+	if got := w.Header().Get("Vary"); got != middleware.ConsistencyTokenHeader {
+		t.Errorf("expected Vary %s, got %q", middleware.ConsistencyTokenHeader, got)
+	}
+}
+
+func TestReadYourWrites_StaleOrMalformedTokenIgnored(t *testing.T) {
+	for _, token := range []string{"", "garbage", strconv.FormatInt(time.Now().Add(-time.Hour).UnixNano(), 10)} {
+		req := httptest.NewRequest(http.MethodGet, "/teams", nil)
+		if token != "" {
+			req.Header.Set(middleware.ConsistencyTokenHeader, token)
+		}
+		w := httptest.NewRecorder()
+		consistencyRouter().ServeHTTP(w, req)
+		if w.Header().Get("X-Consistent") != "" {
+			t.Errorf("token %q: expected an ordinary read", token)
+		}
+	}
+}
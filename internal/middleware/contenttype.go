@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// ContentTypes rejects requests whose body is not in a media type the
+// method accepts, with 415 Unsupported Media Type and the list of supported
+// types, rather than letting a handler try to bind it.  accepted maps a
+// method to its media types, or a method and route such as
+// "POST /api/v1/oauth/token" to that route's alone; methods not listed,
+// and requests without a body, are not checked.  Parameters such as charset are ignored, and
+// PATCH and POST rejections also advertise the types in Accept-Patch or
+// Accept-Post.
+func ContentTypes(accepted map[string][]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		supported, ok := accepted[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			supported, ok = accepted[c.Request.Method]
+		}
+		if !ok || c.Request.ContentLength == 0 {
+			c.Next()
+			return
+		}
+		header := c.GetHeader("Content-Type")
+		mediaType, _, err := mime.ParseMediaType(header)
+		if err == nil && slices.Contains(supported, strings.ToLower(mediaType)) {
+			c.Next()
+			return
+		}
+
+		list := strings.Join(supported, ", ")
+		switch c.Request.Method {
+		case http.MethodPatch:
+			c.Header("Accept-Patch", list)
+		case http.MethodPost:
+			c.Header("Accept-Post", list)
+		}
+		msg := "Content-Type " + list + " required"
+		if header != "" {
+			msg = "unsupported Content-Type " + header + "; use " + list
+		}
+		c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, models.UnsupportedMediaTypeResponse{
+			Error:     msg,
+			Code:      errcode.UnsupportedMediaType,
+			Supported: supported,
+		})
+	}
+}
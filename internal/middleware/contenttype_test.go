@@ -0,0 +1,68 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/middleware"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+func TestContentTypes(t *testing.T) {
+	r := gin.New()
+	r.Use(middleware.ContentTypes(map[string][]string{
+		http.MethodPost:  {"application/json"},
+		http.MethodPatch: {"application/merge-patch+json"},
+
+		http.MethodPost + " /form": {"application/x-www-form-urlencoded"},
+	}))
+	r.Any("/", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	r.Any("/form", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for _, tc := range []struct {
+		method, path, contentType, body string
+		want                            int
+	}{
+		{http.MethodPost, "/", "application/json", `{}`, http.StatusNoContent},
+		{http.MethodPost, "/", "Application/JSON; charset=utf-8", `{}`, http.StatusNoContent},
+		{http.MethodPost, "/", "text/plain", `{}`, http.StatusUnsupportedMediaType},
+		{http.MethodPost, "/", "", `{}`, http.StatusUnsupportedMediaType},
+		{http.MethodPost, "/", "application/json;;", `{}`, http.StatusUnsupportedMediaType},
+		{http.MethodPost, "/", "", "", http.StatusNoContent}, // no body
+		{http.MethodPatch, "/", "application/json", `{}`, http.StatusUnsupportedMediaType},
+		{http.MethodPatch, "/", "application/merge-patch+json", `{}`, http.StatusNoContent},
+		{http.MethodDelete, "/", "text/plain", "x", http.StatusNoContent}, // not checked
+		{http.MethodPost, "/", "application/x-www-form-urlencoded", "a=1", http.StatusUnsupportedMediaType},
+		{http.MethodPost, "/form", "application/x-www-form-urlencoded", "a=1", http.StatusNoContent},
+		{http.MethodPost, "/form", "application/json", `{}`, http.StatusUnsupportedMediaType},
+		{http.MethodPatch, "/form", "application/merge-patch+json", `{}`, http.StatusNoContent}, // method's types
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		if tc.contentType != "" {
+			req.Header.Set("Content-Type", tc.contentType)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Fatalf("%s %s %q: expected %d, got %d", tc.method, tc.path, tc.contentType, tc.want, w.Code)
+		}
+		if w.Code != http.StatusUnsupportedMediaType {
+			continue
+		}
+		var body models.UnsupportedMediaTypeResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if len(body.Supported) != 1 || body.Error == "" {
+			t.Fatalf("unexpected body %+v", body)
+		}
+		header := map[string]string{http.MethodPost: "Accept-Post", http.MethodPatch: "Accept-Patch"}[tc.method]
+		if w.Header().Get(header) != body.Supported[0] {
+			t.Fatalf("expected %s: %s, got %v", header, body.Supported[0], w.Header())
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// CORSPolicy is the cross-origin access a browser application on one of
+// Origins is granted.
+type CORSPolicy struct {
+	// Origins lists the origins allowed, such as "https://app.example",
+	// or "*" for any.
+	Origins []string
+	// Credentials lets pages send requests with cookies, HTTP
+	// authentication or client certificates and read the responses
+	// (Access-Control-Allow-Credentials).  "*" is then ignored: origins
+	// trusted with credentials must be named.
+	Credentials bool
+	// Headers lists the request headers pages may set beyond those CORS
+	// always allows, and Expose the response headers they may read.
+	Headers []string
+	Expose  []string
+	// MaxAge is how long browsers may cache a preflight result.  Zero
+	// leaves it to the browser, which caches it for seconds.
+	MaxAge time.Duration
+}
+
+// CORSRule applies Policy to requests with one of Methods to a path under
+// one of Prefixes.  A prefix matches the path itself and the paths below
+// it.
+type CORSRule struct {
+	Prefixes []string
+	Methods  []string
+	Policy   CORSPolicy
+}
+
+// CORS answers cross-origin requests from browsers under the first of
+// rules that covers the request and allows its origin, so different route
+// groups can admit different origins on different terms.  Preflight
+// requests, for the method they announce, are answered here with 204 No
+// Content, or 403 Forbidden when a rule covers the route but none allows
+// the origin; other requests proceed, with the headers that let the page
+// read the response if the origin is allowed.  Requests without an Origin,
+// and routes no rule covers, are left alone.
+func CORS(rules ...CORSRule) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		method := c.Request.Method
+		preflight := method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if preflight {
+			method = c.GetHeader("Access-Control-Request-Method")
+		}
+
+		covered := false
+		for _, rule := range rules {
+			if !rule.covers(method, c.Request.URL.Path) {
+				continue
+			}
+			covered = true
+			p := rule.Policy
+			if !p.allows(origin) {
+				continue
+			}
+			vary(c.Writer.Header(), "Origin")
+			if !p.Credentials && slices.Contains(p.Origins, "*") {
+				c.Header("Access-Control-Allow-Origin", "*")
+			} else {
+				c.Header("Access-Control-Allow-Origin", origin)
+			}
+			if p.Credentials {
+				c.Header("Access-Control-Allow-Credentials", "true")
+			}
+			if !preflight {
+				if len(p.Expose) > 0 {
+					c.Header("Access-Control-Expose-Headers", strings.Join(p.Expose, ", "))
+				}
+				c.Next()
+				return
+			}
+			c.Header("Access-Control-Allow-Methods", strings.Join(rule.Methods, ", "))
+			if len(p.Headers) > 0 {
+				c.Header("Access-Control-Allow-Headers", strings.Join(p.Headers, ", "))
+			}
+			if p.MaxAge > 0 {
+				c.Header("Access-Control-Max-Age", strconv.Itoa(int(p.MaxAge.Seconds())))
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		if covered {
+			// Responses differ by origin even when this one is refused.
+			vary(c.Writer.Header(), "Origin")
+			if preflight {
+				c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+					Error: "origin " + origin + " may not " + method + " " + c.Request.URL.Path,
+					Code:  errcode.OriginNotAllowed,
+				})
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+func (r CORSRule) covers(method, path string) bool {
+	if !slices.Contains(r.Methods, method) {
+		return false
+	}
+	for _, prefix := range r.Prefixes {
+		if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (p CORSPolicy) allows(origin string) bool {
+	for _, o := range p.Origins {
+		if (o == "*" && !p.Credentials) || strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	return false
+}
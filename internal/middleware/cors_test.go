@@ -0,0 +1,83 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/middleware"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+func TestCORS(t *testing.T) {
+	r := gin.New()
+	r.Use(middleware.CORS(
+		middleware.CORSRule{
+			Prefixes: []string{"/api"},
+			Methods:  []string{http.MethodGet, http.MethodPost},
+			Policy: middleware.CORSPolicy{
+				Origins:     []string{"https://app.example", "*"},
+				Credentials: true,
+				Headers:     []string{"Authorization", "Content-Type"},
+				Expose:      []string{"Location"},
+				MaxAge:      10 * time.Minute,
+			},
+		},
+		middleware.CORSRule{
+			Prefixes: []string{"/api/public/"},
+			Methods:  []string{http.MethodGet},
+			Policy:   middleware.CORSPolicy{Origins: []string{"*"}},
+		},
+	))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/api/public/teams", ok)
+	r.GET("/api/me", ok)
+	r.POST("/api/public/teams", ok)
+	r.GET("/apikeys", ok)
+
+	for _, tc := range []struct {
+		name, method, path, origin, preflight string
+		status                                int
+		allowOrigin, credentials, maxAge      string
+	}{
+		{"no origin", http.MethodGet, "/api/me", "", "", http.StatusOK, "", "", ""},
+		{"trusted read", http.MethodGet, "/api/me", "https://app.example", "", http.StatusOK, "https://app.example", "true", ""},
+		{"untrusted read", http.MethodGet, "/api/me", "https://evil.example", "", http.StatusOK, "", "", ""},
+		{"public read", http.MethodGet, "/api/public/teams", "https://evil.example", "", http.StatusOK, "*", "", ""},
+		{"trusted preflight", http.MethodOptions, "/api/public/teams", "https://App.example", http.MethodPost, http.StatusNoContent, "https://App.example", "true", "600"},
+		{"public preflight", http.MethodOptions, "/api/public/teams", "https://evil.example", http.MethodGet, http.StatusNoContent, "*", "", ""},
+		{"untrusted write preflight", http.MethodOptions, "/api/public/teams", "https://evil.example", http.MethodPost, http.StatusForbidden, "", "", ""},
+		{"uncovered method", http.MethodOptions, "/api/me", "https://app.example", http.MethodDelete, http.StatusNotFound, "", "", ""},
+		{"uncovered path", http.MethodGet, "/apikeys", "https://app.example", "", http.StatusOK, "", "", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			if tc.origin != "" {
+				req.Header.Set("Origin", tc.origin)
+			}
+			if tc.preflight != "" {
+				req.Header.Set("Access-Control-Request-Method", tc.preflight)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			h := w.Header()
+			if w.Code != tc.status || h.Get("Access-Control-Allow-Origin") != tc.allowOrigin ||
+				h.Get("Access-Control-Allow-Credentials") != tc.credentials || h.Get("Access-Control-Max-Age") != tc.maxAge {
+				t.Fatalf("got %d %v", w.Code, h)
+			}
+			if tc.status == http.StatusForbidden {
+				var body models.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != errcode.OriginNotAllowed {
+					t.Errorf("unexpected body %s", w.Body)
+				}
+			}
+			if tc.allowOrigin != "" && h.Get("Vary") != "Origin" {
+				t.Errorf("Vary: got %q", h.Get("Vary"))
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// ErrorCodes makes sure every error response carries a code.  A JSON error
+// object with an "error" member but no "code" — from a plugin, say — is
+// given the general code for its status, placed after "error"; an error
+// response with no body at all, such as Gin's own 404 for unknown routes,
+// is given an ErrorResponse.  Codes handlers set are left alone.
+func ErrorCodes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		w := &errorCodeWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+		status := w.Status()
+		if !w.decided && status >= 400 && !w.ResponseWriter.Written() {
+			c.JSON(status, models.ErrorResponse{
+				Error: strings.ToLower(http.StatusText(status)),
+				Code:  errcode.ForStatus(status),
+			})
+			return
+		}
+		if !w.buffering {
+			return
+		}
+		body := w.body.Bytes()
+		if out, ok := withCode(body, errcode.ForStatus(status)); ok {
+			body = out
+			w.Header().Del("Content-Length")
+		}
+		w.ResponseWriter.Write(body)
+	}
+}
+
+// withCode adds code to the error object body, or reports false if body is
+// not an object with an "error" member, or already has a code.
+func withCode(body []byte, code string) ([]byte, bool) {
+	top, ok := members(body)
+	if !ok {
+		return nil, false
+	}
+	kept := top[:0]
+	for _, m := range top {
+		if m.key == "code" {
+			var s string
+			if json.Unmarshal(m.value, &s) != nil || s != "" {
+				return nil, false
+			}
+			continue
+		}
+		kept = append(kept, m)
+	}
+	at := -1
+	for i, m := range kept {
+		if m.key == "error" {
+			at = i
+		}
+	}
+	if at < 0 {
+		return nil, false
+	}
+	value, _ := json.Marshal(code)
+	out := append([]member{}, kept[:at+1]...)
+	out = append(out, member{"code", value})
+	out = append(out, kept[at+1:]...)
+	return encodeMembers(out), true
+}
+
+// errorCodeWriter holds back a JSON error response so that a code can be
+// added.  Other responses pass straight through.
+type errorCodeWriter struct {
+	gin.ResponseWriter
+	decided, buffering bool
+	body               bytes.Buffer
+}
+
+// Unwrap returns the writer underneath, for http.ResponseController.
+func (w *errorCodeWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+func (w *errorCodeWriter) decide() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	w.buffering = w.Status() >= 400 && mediaType == "application/json"
+}
+
+func (w *errorCodeWriter) Write(b []byte) (int, error) {
+	w.decide()
+	if w.buffering {
+		return w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *errorCodeWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *errorCodeWriter) Flush() {
+	if !w.buffering {
+		w.ResponseWriter.Flush()
+	}
+}
@@ -0,0 +1,37 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/middleware"
+)
+
+func TestErrorCodes(t *testing.T) {
+	r := gin.New()
+	r.Use(middleware.ErrorCodes())
+	r.GET("/plugin", func(c *gin.Context) {
+		c.JSON(http.StatusConflict, gin.H{"error": "taken", "current": 1})
+	})
+	r.GET("/coded", func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "team not found", "code": "TEAM_NOT_FOUND"})
+	})
+	r.GET("/ok", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"error": "none"})
+	})
+
+	for target, want := range map[string]string{
+		"/plugin":  `{"current":1,"error":"taken","code":"CONFLICT"}`,
+		"/coded":   `{"code":"TEAM_NOT_FOUND","error":"team not found"}`,
+		"/ok":      `{"error":"none"}`,
+		"/missing": `{"error":"not found","code":"NOT_FOUND"}`,
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Body.String() != want {
+			t.Errorf("%s: expected %s, got %s", target, want, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/flags"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// RequireFlag answers 404 Not Found, as if the route did not exist, while
+// the named feature flag is off.  The flag is checked on every request, so
+// flips take effect without a restart.
+func RequireFlag(f *flags.Flags, name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !f.Enabled(name) {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ErrorResponse{Error: "this endpoint is disabled", Code: errcode.EndpointDisabled})
+			return
+		}
+		c.Next()
+	}
+}
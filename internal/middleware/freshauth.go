@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// RequireFreshAuth guards destructive operations: a caller signed in with a
+// Bearer JWT is refused with 401 Unauthorized unless the token's auth_time
+// is at most maxAge old on clk, so that a stolen or long-lived token cannot
+// be used for them on its own.  The response carries the RFC 9470
+// insufficient_user_authentication challenge and links to
+// POST /auth/reauthenticate, which trades the password for a fresh token.
+// Tokens without auth_time, such as impersonation tokens, never qualify,
+// and tokens issued to third-party applications are refused with 403.
+// Signed requests and client certificates prove possession of their key on
+// every request and pass.  It must run after Authenticate.
+func RequireFreshAuth(maxAge time.Duration, clk clock.Clock) gin.HandlerFunc {
+	clk = clock.Or(clk)
+	secs := int(maxAge / time.Second)
+	return func(c *gin.Context) {
+		if c.GetString("authScheme") != "Bearer" {
+			c.Next()
+			return
+		}
+		if c.GetString("oauthClient") != "" {
+			// Applications cannot confirm the user's password.
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+				Error: "applications cannot perform this operation",
+				Code:  errcode.InsufficientScope,
+			})
+			return
+		}
+		if at, ok := c.Get("authTime"); ok && clk.Now().Sub(at.(time.Time)) <= maxAge {
+			c.Next()
+			return
+		}
+		c.Header("WWW-Authenticate", `Bearer error="insufficient_user_authentication", `+
+			`error_description="A more recent sign-in is required", max_age=`+strconv.Itoa(secs))
+		c.AbortWithStatusJSON(http.StatusUnauthorized, models.FreshAuthRequiredResponse{
+			Error:  "this operation requires a recent sign-in; confirm your password and retry with the new token",
+			Code:   errcode.FreshAuthRequired,
+			MaxAge: secs,
+			Links: []models.Link{
+				{Rel: "reauthenticate", Href: "/api/v1/auth/reauthenticate", Method: http.MethodPost},
+			},
+		})
+	}
+}
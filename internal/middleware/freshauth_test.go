@@ -0,0 +1,66 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/middleware"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+func TestRequireFreshAuth(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(now)
+
+	cases := []struct {
+		name     string
+		scheme   string
+		authTime time.Time
+		want     int
+	}{
+		{"fresh", "Bearer", now.Add(-5 * time.Minute), http.StatusOK},
+		{"stale", "Bearer", now.Add(-20 * time.Minute), http.StatusUnauthorized},
+		{"no auth_time", "Bearer", time.Time{}, http.StatusUnauthorized},
+		{"signed request", "HMAC", time.Time{}, http.StatusOK},
+		{"client certificate", "mTLS", time.Time{}, http.StatusOK},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := gin.New()
+			r.DELETE("/", func(c *gin.Context) {
+				c.Set("authScheme", tc.scheme)
+				if !tc.authTime.IsZero() {
+					c.Set("authTime", tc.authTime)
+				}
+			}, middleware.RequireFreshAuth(15*time.Minute, clk), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/", nil))
+			if w.Code != tc.want {
+				t.Fatalf("expected %d, got %d", tc.want, w.Code)
+			}
+			if tc.want == http.StatusOK {
+				return
+			}
+			if h := w.Header().Get("WWW-Authenticate"); !strings.Contains(h, `error="insufficient_user_authentication"`) || !strings.Contains(h, "max_age=900") {
+				t.Errorf("unexpected WWW-Authenticate %q", h)
+			}
+			var resp models.FreshAuthRequiredResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Code != errcode.FreshAuthRequired || resp.MaxAge != 900 ||
+				len(resp.Links) != 1 || resp.Links[0].Href != "/api/v1/auth/reauthenticate" {
+				t.Errorf("unexpected response %+v", resp)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/retry"
+)
+
+// ConcurrencyLimit is a bulkhead: at most max requests run the remaining
+// handler chain at once.  A request arriving while all slots are taken waits
+// up to queueTimeout for one to free up, then fails fast with 503 Service
+// Unavailable, a Retry-After hint and RateLimit fields describing the cap.
+//
+// Bounding concurrency in front of the handlers protects the database
+// connection pool from thundering herds and keeps latency predictable under
+// overload, rather than letting every request queue inside database/sql.
+// A max of zero or less disables the limiter.
+func ConcurrencyLimit(max int, queueTimeout time.Duration) gin.HandlerFunc {
+	if max <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	slots := make(chan struct{}, max)
+	busy := retry.Limit{Limit: int64(max), Reset: queueTimeout.Truncate(time.Second) + time.Second}
+
+	return func(c *gin.Context) {
+		select {
+		case slots <- struct{}{}:
+		default:
+			timer := time.NewTimer(queueTimeout)
+			select {
+			case slots <- struct{}{}:
+				timer.Stop()
+			case <-timer.C:
+				retry.After(c.Writer.Header(), busy)
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, models.ErrorResponse{
+					Error: "server is busy; please retry shortly",
+					Code:  errcode.ServerBusy,
+				})
+				return
+			case <-c.Request.Context().Done():
+				timer.Stop()
+				c.Abort()
+				return
+			}
+		}
+		defer func() { <-slots }()
+		c.Next()
+	}
+}
+
+// RateLimit allows each caller at most limit requests per fixed window and
+// answers any excess with 429 Too Many Requests and a Retry-After header
+// counting the seconds until the window resets.  Every response it passes
+// or refuses carries RateLimit fields with the caller's remaining
+// requests.  Callers are keyed by the
+// authenticated username when one is set, else by client IP, so it should run
+// after Authenticate on protected routes.  The 429 body reports the caller's
+// count, the limit and when the window resets, with links, for example to
+// an upgrade page.  A limit of zero or less disables the limiter.
+func RateLimit(limit int, window time.Duration, links ...models.Link) gin.HandlerFunc {
+	if limit <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	var (
+		mu     sync.Mutex
+		counts = make(map[string]int)
+		resets = time.Now().Add(window)
+	)
+
+	return func(c *gin.Context) {
+		key := c.GetString("username")
+		if key == "" {
+			key = c.ClientIP()
+		}
+
+		mu.Lock()
+		now := time.Now()
+		if !now.Before(resets) {
+			counts = make(map[string]int)
+			resets = now.Add(window)
+		}
+		counts[key]++
+		count := counts[key]
+		reset := resets
+		mu.Unlock()
+
+		state := retry.Limit{
+			Limit:     int64(limit),
+			Remaining: int64(limit - count),
+			Reset:     reset.Sub(now),
+			Window:    window,
+		}
+		if count > limit {
+			retry.After(c.Writer.Header(), state)
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, models.QuotaExceededResponse{
+				Error: "rate limit exceeded; please retry later",
+				Code:  errcode.RateLimited,
+				Quota: models.QuotaRate,
+				Usage: int64(count),
+				Limit: int64(limit),
+				Reset: reset.UTC(),
+				Links: links,
+			})
+			return
+		}
+		retry.Headers(c.Writer.Header(), state)
+		c.Next()
+	}
+}
@@ -0,0 +1,148 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/middleware"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// blockingRouter returns a router whose single route holds its slot until
+// release is closed, signalling on entered once it is running.
+func blockingRouter(max int, queue time.Duration, entered chan<- struct{}, release <-chan struct{}) *gin.Engine {
+	r := gin.New()
+	r.Use(middleware.ConcurrencyLimit(max, queue))
+	r.GET("/slow", func(c *gin.Context) {
+		entered <- struct{}{}
+		<-release
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func TestConcurrencyLimit_RejectsWhenFull(t *testing.T) {
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	r := blockingRouter(1, 10*time.Millisecond, entered, release)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	}()
+	<-entered
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Fatal("expected Retry-After header")
+	}
+
+	close(release)
+	wg.Wait()
+}
+
+func TestConcurrencyLimit_QueuedRequestProceeds(t *testing.T) {
+	entered := make(chan struct{}, 2)
+	release := make(chan struct{})
+	r := blockingRouter(1, time.Second, entered, release)
+
+	var wg sync.WaitGroup
+	codes := make([]int, 2)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+			codes[i] = w.Code
+		}(i)
+	}
+
+	// Only one request may run; the other waits in the queue until released.
+	<-entered
+	close(release)
+	<-entered
+	wg.Wait()
+
+	for i, code := range codes {
+		if code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i, code)
+		}
+	}
+}
+
+func TestConcurrencyLimit_Disabled(t *testing.T) {
+	r := gin.New()
+	r.Use(middleware.ConcurrencyLimit(0, 0))
+	r.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestRateLimit_PerCaller(t *testing.T) {
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("username", c.GetHeader("X-User"))
+		c.Next()
+	})
+	r.Use(middleware.RateLimit(2, time.Minute))
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	get := func(user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-User", user)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		w := get("svc-a")
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i+1, w.Code)
+		}
+		if got, want := w.Header().Get("RateLimit-Remaining"), strconv.Itoa(1-i); got != want {
+			t.Errorf("request %d: RateLimit-Remaining = %q, want %q", i+1, got, want)
+		}
+	}
+	w := get("svc-a")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 over the limit, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" || w.Header().Get("Retry-After") != w.Header().Get("RateLimit-Reset") {
+		t.Errorf("expected matching Retry-After and RateLimit-Reset on 429, got %v", w.Header())
+	}
+	if got := w.Header().Get("RateLimit-Policy"); got != "2;w=60" {
+		t.Errorf("RateLimit-Policy = %q", got)
+	}
+	var body models.QuotaExceededResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Quota != models.QuotaRate || body.Usage != 3 || body.Limit != 2 || !body.Reset.After(time.Now()) {
+		t.Errorf("unexpected 429 body %+v", body)
+	}
+	if w := get("svc-b"); w.Code != http.StatusOK {
+		t.Errorf("expected other callers to be unaffected, got %d", w.Code)
+	}
+}
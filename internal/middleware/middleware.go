@@ -4,11 +4,17 @@ package middleware
 
 import (
 	"fmt"
+	"log/slog"
 	"net/http"
+	"strings"
 	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/logging"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/redact"
 )
 
 // RequestID attaches a unique identifier to every incoming request and echoes
@@ -26,24 +32,49 @@ func RequestID() gin.HandlerFunc {
 	}
 }
 
+// cacheMaxAge is how long shared caches may serve a GET response.
+const cacheMaxAge = 60 * time.Second
+
 // CacheControl sets appropriate Cache-Control headers so that clients and
 // intermediate caches know whether a response may be stored (Cacheable
 // principle).
 //
 //   - Safe, idempotent GET/HEAD responses are marked as cacheable for 60 s.
 //   - All other methods are marked no-store to prevent stale mutations.
-//   - If a handler has already set Cache-Control, it is not overridden.
+//   - Later middleware and handlers may replace the header with their own.
+//
+// The default is set before the handler runs: headers added after the
+// response has been written never reach the client.
 func CacheControl() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Next()
-		if c.Writer.Header().Get("Cache-Control") != "" {
-			return
-		}
 		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
-			c.Header("Cache-Control", "public, max-age=60")
+			c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(cacheMaxAge.Seconds())))
 		} else {
 			c.Header("Cache-Control", "no-store")
 		}
+		c.Next()
+	}
+}
+
+// vary adds name to the Vary header of h unless it is already listed.
+func vary(h http.Header, name string) {
+	for _, v := range h.Values("Vary") {
+		for _, listed := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(listed), name) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", name)
+}
+
+// VersionHeader adds an X-API-Version header carrying the build version and
+// commit to every response, so that responses captured behind a load balancer
+// can be traced to the instance version that produced them.
+func VersionHeader(v string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("X-API-Version", v)
+		c.Next()
 	}
 }
 
@@ -53,8 +84,9 @@ func CacheControl() gin.HandlerFunc {
 func NoSessionState() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if c.Request.Header.Get("Cookie") != "" {
-			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-				"error": "session cookies are not supported; the API is stateless",
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: "session cookies are not supported; the API is stateless",
+				Code:  errcode.CookiesNotSupported,
 			})
 			return
 		}
@@ -66,18 +98,39 @@ func NoSessionState() gin.HandlerFunc {
 // request-ID injected by RequestID().  Logging middleware is a classic
 // example of the Layered System principle — the handler never knows whether
 // an additional layer is observing its traffic.
-func Logger() gin.HandlerFunc {
+//
+// The path and the authenticated caller pass through r, so emails, tokens
+// and usernames are masked or pseudonymised unless PII logging is enabled.
+//
+// Request lines are logged at info level: raising level to warn or above
+// silences them, and lowering it to debug adds the client IP and user
+// agent, which r treats as the "ip" and "user_agent" fields.
+func Logger(r *redact.Redactor, level *logging.Level) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		c.Next()
+		if !level.Enabled(slog.LevelInfo) {
+			return
+		}
 		id, _ := c.Get("requestID")
-		fmt.Printf("[GIN] %s | %3d | %12v | %-7s %s | req-id=%v\n",
+		line := fmt.Sprintf("[GIN] %s | %3d | %12v | %-7s %s | req-id=%v user=%s",
 			time.Now().Format("2006/01/02 - 15:04:05"),
 			c.Writer.Status(),
 			time.Since(start),
 			c.Request.Method,
-			c.Request.URL.Path,
+			r.String(c.Request.URL.Path),
 			id,
+			r.Field("username", c.GetString("username")),
 		)
+		if admin := c.GetString("impersonator"); admin != "" {
+			line += " impersonator=" + r.Field("username", admin)
+		}
+		if client := c.GetString("oauthClient"); client != "" {
+			line += " client=" + client
+		}
+		if level.Enabled(slog.LevelDebug) {
+			line += fmt.Sprintf(" ip=%s ua=%q", r.Field("ip", c.ClientIP()), r.Field("user_agent", c.Request.UserAgent()))
+		}
+		fmt.Println(line)
 	}
 }
@@ -0,0 +1,59 @@
+package middleware_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/logging"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/middleware"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/redact"
+)
+
+// logLine serves one request through Logger and returns what it printed.
+func logLine(t *testing.T, pii bool) string {
+	t.Helper()
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("username", "alice"); c.Next() },
+		middleware.Logger(redact.New([]byte("key"), nil, pii), logging.NewLevel(slog.LevelDebug)))
+	r.GET("/ping", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	stdout := os.Stdout
+	read, write, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = write
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.RemoteAddr = "203.0.113.7:4000"
+	req.Header.Set("User-Agent", "curl/8.0")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	os.Stdout = stdout
+	write.Close()
+	out, _ := io.ReadAll(read)
+	return string(out)
+}
+
+func TestLogger_RedactsClientAtDebug(t *testing.T) {
+	line := logLine(t, false)
+	for _, leak := range []string{"alice", "203.0.113.7", "curl/8.0"} {
+		if strings.Contains(line, leak) {
+			t.Errorf("log line contains %q: %s", leak, line)
+		}
+	}
+	if !strings.Contains(line, "ip=anon-") {
+		t.Errorf("expected a pseudonymised IP: %s", line)
+	}
+
+	line = logLine(t, true)
+	for _, want := range []string{"user=alice", "ip=203.0.113.7", `ua="curl/8.0"`} {
+		if !strings.Contains(line, want) {
+			t.Errorf("LOG_PII: expected %q in %s", want, line)
+		}
+	}
+}
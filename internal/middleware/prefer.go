@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/async"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// PreferenceAppliedHeader lists the Prefer preferences a response honoured.
+const PreferenceAppliedHeader = "Preference-Applied"
+
+// Prefer honours the RFC 7240 preferences of mutating requests, echoing
+// those it applied in Preference-Applied:
+//
+//   - return=minimal leaves out the body of a successful response, turning
+//     200 OK into 204 No Content; a 201 keeps its status and Location.
+//     Error responses keep their bodies.
+//   - return=representation is the default, and is only acknowledged.
+//   - respond-async, when queue is set, answers 202 Accepted at once with
+//     the URL of an operation under /me/operations, and serves the request
+//     in the background.  When queue is full the request is served as
+//     usual.
+//
+// It must run after Authenticate: an operation belongs to the caller, and
+// its background copy is served as the same caller without being
+// authenticated again.
+func Prefer(queue *async.Queue) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		prefs := async.ParsePreferences(c.Request.Header)
+		if queue != nil && prefs.Has(async.RespondAsync) {
+			if respondAsync(c, queue, prefs) {
+				return
+			}
+		}
+		switch prefs[async.Return] {
+		case async.ReturnMinimal:
+			c.Header(PreferenceAppliedHeader, async.Return+"="+async.ReturnMinimal)
+			c.Writer = &minimalWriter{ResponseWriter: c.Writer}
+		case async.ReturnRepresentation:
+			c.Header(PreferenceAppliedHeader, async.Return+"="+async.ReturnRepresentation)
+		}
+		c.Next()
+	}
+}
+
+// respondAsync starts c's request on queue and answers 202, reporting
+// whether it did.  Otherwise the request is left to run as usual.
+func respondAsync(c *gin.Context, queue *async.Queue, prefs async.Preferences) bool {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{Error: "could not read request body", Code: errcode.MalformedBody})
+		return true
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	r := c.Request.Clone(c.Request.Context())
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	// The background copy must run, not be deferred once more.
+	if rest := prefs.Without(async.RespondAsync); rest != "" {
+		r.Header.Set("Prefer", rest)
+	} else {
+		r.Header.Del("Prefer")
+	}
+	// The caller is already authenticated and counted; the copy carries
+	// who they are rather than being checked again.
+	identity := map[string]any{}
+	for _, key := range identityKeys {
+		if v, ok := c.Get(key); ok {
+			identity[key] = v
+		}
+	}
+	r = r.WithContext(async.WithIdentity(r.Context(), identity))
+	op, err := queue.Start(c.GetString("username"), r)
+	if errors.Is(err, async.ErrFull) {
+		return false
+	}
+	href := "/api/v1/me/operations/" + op.ID
+	op.Links = []models.Link{{Rel: "self", Href: href, Method: http.MethodGet}}
+	c.Header(PreferenceAppliedHeader, async.RespondAsync)
+	c.Header("Location", href)
+	c.AbortWithStatusJSON(http.StatusAccepted, op)
+	return true
+}
+
+// minimalWriter drops the body of successful responses, sending 204 No
+// Content in place of 200 OK.
+type minimalWriter struct {
+	gin.ResponseWriter
+}
+
+// Unwrap returns the writer underneath, for http.ResponseController.
+func (w *minimalWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+func (w *minimalWriter) minimal() bool {
+	status := w.Status()
+	if status < 200 || status >= 300 {
+		return false
+	}
+	if !w.Written() {
+		if status == http.StatusOK {
+			w.WriteHeader(http.StatusNoContent)
+		}
+		w.Header().Del("Content-Type")
+		w.Header().Del("Content-Length")
+		w.ResponseWriter.WriteHeaderNow()
+	}
+	return true
+}
+
+func (w *minimalWriter) Write(b []byte) (int, error) {
+	if w.minimal() {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *minimalWriter) WriteString(s string) (int, error) {
+	if w.minimal() {
+		return len(s), nil
+	}
+	return w.ResponseWriter.WriteString(s)
+}
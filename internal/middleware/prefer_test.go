@@ -0,0 +1,101 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/async"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/middleware"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+func preferRouter() (*gin.Engine, *async.Queue) {
+	r := gin.New()
+	q := async.New(r, 4)
+	r.Use(func(c *gin.Context) { c.Set("username", "alice"); c.Next() }, middleware.Prefer(q))
+	r.POST("/teams", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.Header("Location", "/teams/1")
+		c.JSON(http.StatusCreated, gin.H{"id": 1, "sent": string(body)})
+	})
+	r.PUT("/teams/:id", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"id": 1}) })
+	r.DELETE("/teams/:id", func(c *gin.Context) { c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "team not found"}) })
+	return r, q
+}
+
+func TestPrefer_ReturnMinimal(t *testing.T) {
+	r, _ := preferRouter()
+	cases := []struct {
+		method, path string
+		status       int
+		body         bool
+	}{
+		{http.MethodPut, "/teams/1", http.StatusNoContent, false},
+		{http.MethodPost, "/teams", http.StatusCreated, false},
+		{http.MethodDelete, "/teams/1", http.StatusNotFound, true},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(`{}`))
+		req.Header.Set("Prefer", "return=minimal")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tc.status {
+			t.Errorf("%s %s: expected %d, got %d", tc.method, tc.path, tc.status, w.Code)
+		}
+		if (w.Body.Len() > 0) != tc.body {
+			t.Errorf("%s %s: unexpected body %q", tc.method, tc.path, w.Body.String())
+		}
+		if got := w.Header().Get(middleware.PreferenceAppliedHeader); got != "return=minimal" {
+			t.Errorf("%s %s: expected Preference-Applied return=minimal, got %q", tc.method, tc.path, got)
+		}
+	}
+}
+
+func TestPrefer_WithoutPreferenceReturnsRepresentation(t *testing.T) {
+	r, _ := preferRouter()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/teams/1", nil))
+	if w.Code != http.StatusOK || w.Body.Len() == 0 {
+		t.Errorf("expected 200 with a body, got %d %q", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get(middleware.PreferenceAppliedHeader); got != "" {
+		t.Errorf("expected no Preference-Applied, got %q", got)
+	}
+}
+
+func TestPrefer_RespondAsync(t *testing.T) {
+	r, q := preferRouter()
+	req := httptest.NewRequest(http.MethodPost, "/teams", strings.NewReader(`{"name":"Italy"}`))
+	req.Header.Set("Prefer", "respond-async")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get(middleware.PreferenceAppliedHeader); got != "respond-async" {
+		t.Errorf("expected Preference-Applied respond-async, got %q", got)
+	}
+	var op models.Operation
+	if err := json.Unmarshal(w.Body.Bytes(), &op); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Header().Get("Location"); got != "/api/v1/me/operations/"+op.ID {
+		t.Errorf("unexpected Location %q", got)
+	}
+
+	done, ok := q.Wait(op.ID, time.Second)
+	if !ok {
+		t.Fatal("expected the operation to finish")
+	}
+	if done.Response.Status != http.StatusCreated || !strings.Contains(string(done.Response.Body), `Italy`) {
+		t.Errorf("expected the request to be served with its body, got %+v %s", done.Response, done.Response.Body)
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/retry"
+)
+
+// QuotaUsage reports how many requests a caller has made so far in the
+// current UTC calendar month.  metering.Meter satisfies it.
+type QuotaUsage interface {
+	MonthToDate(username string) (int64, error)
+}
+
+// MonthlyQuota caps each authenticated caller's requests per UTC calendar
+// month.  Authenticate enforces it when set in Authenticators.Quota, as
+// only then is the caller known.
+type MonthlyQuota struct {
+	// Limit is the number of requests allowed per month.
+	Limit int64
+	// Usage counts the caller's requests so far.
+	Usage QuotaUsage
+	// Links are attached to the 402 body, for example to an upgrade page.
+	Links []models.Link
+}
+
+// admit passes the request on, unless the caller has already made Limit
+// requests this month, in which case it answers 402 Payment Required with
+// the usage, the limit and when the quota resets, also given in Retry-After
+// and the RateLimit fields.  A nil quota admits
+// everything, and so does one whose usage cannot be read: an unavailable
+// metering store should not take the API down with it.
+func (q *MonthlyQuota) admit(c *gin.Context) {
+	if q == nil || q.Limit <= 0 {
+		c.Next()
+		return
+	}
+	used, err := q.Usage.MonthToDate(c.GetString("username"))
+	if err != nil {
+		log.Printf("monthly quota: %v", err)
+		c.Next()
+		return
+	}
+	if used >= q.Limit {
+		now := time.Now().UTC()
+		reset := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		c.Header("Cache-Control", "no-store")
+		retry.After(c.Writer.Header(), retry.Limit{Limit: q.Limit, Reset: reset.Sub(now)})
+		c.AbortWithStatusJSON(http.StatusPaymentRequired, models.QuotaExceededResponse{
+			Error: "monthly request quota exhausted",
+			Code:  errcode.QuotaExhausted,
+			Quota: models.QuotaMonthlyRequests,
+			Usage: used,
+			Limit: q.Limit,
+			Reset: reset,
+			Links: q.Links,
+		})
+		return
+	}
+	c.Next()
+}
@@ -0,0 +1,68 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/middleware"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// fixedUsage reports the same month-to-date count for every caller.
+type fixedUsage struct {
+	n   int64
+	err error
+}
+
+func (u fixedUsage) MonthToDate(string) (int64, error) { return u.n, u.err }
+
+func TestAuthenticate_MonthlyQuota(t *testing.T) {
+	jwt := auth.NewJWTService("test-secret", "COMP3011_API")
+	token, _ := jwt.GenerateSessionToken("alice", "")
+	upgrade := models.Link{Rel: "upgrade", Href: "https://example.com/plans", Method: http.MethodGet}
+
+	for _, tc := range []struct {
+		name  string
+		usage fixedUsage
+		want  int
+	}{
+		{"under", fixedUsage{n: 9}, http.StatusOK},
+		{"exhausted", fixedUsage{n: 10}, http.StatusPaymentRequired},
+		{"store down", fixedUsage{err: errors.New("db down")}, http.StatusOK},
+	} {
+		r := gin.New()
+		r.GET("/", middleware.Authenticate(middleware.Authenticators{
+			JWT:   jwt,
+			Quota: &middleware.MonthlyQuota{Limit: 10, Usage: tc.usage, Links: []models.Link{upgrade}},
+		}), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Fatalf("%s: expected %d, got %d", tc.name, tc.want, w.Code)
+		}
+		if w.Code != http.StatusPaymentRequired {
+			continue
+		}
+		var body models.QuotaExceededResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		now := time.Now().UTC()
+		reset := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		if body.Quota != models.QuotaMonthlyRequests || body.Usage != 10 || body.Limit != 10 || !body.Reset.Equal(reset) {
+			t.Fatalf("unexpected body %+v", body)
+		}
+		if len(body.Links) != 1 || body.Links[0] != upgrade {
+			t.Fatalf("expected the upgrade link, got %+v", body.Links)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// RequireTerms refuses a request with 403 Forbidden unless the caller's
+// latest accepted terms-of-service version is version.  It must run after
+// Authenticate.  Only users signing in with a Bearer JWT are checked;
+// service accounts have no terms to accept.
+func RequireTerms(terms db.TermsRepository, version string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("authScheme") != "Bearer" {
+			c.Next()
+			return
+		}
+		latest, err := terms.LatestTerms(c.GetString("username"))
+		if err != nil && !errors.Is(err, models.ErrNotFound) {
+			_ = c.Error(err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "internal server error", Code: errcode.Internal})
+			return
+		}
+		if err != nil || latest.Version != version {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.TermsRequiredResponse{
+				Error:          "the current terms of service must be accepted first",
+				Code:           errcode.TermsNotAccepted,
+				CurrentVersion: version,
+				Links: []models.Link{
+					{Rel: "accept-terms", Href: "/api/v1/me/terms", Method: http.MethodPut},
+				},
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,49 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/memory"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/middleware"
+)
+
+func TestRequireTerms(t *testing.T) {
+	repos := memory.New().Repositories()
+	for _, name := range []string{"alice", "bob"} {
+		if _, err := repos.Users.CreateUser(name, "hash"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := repos.Terms.AcceptTerms("alice", "v2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.Terms.AcceptTerms("bob", "v1"); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		username, scheme string
+		want             int
+	}{
+		{"alice", "Bearer", http.StatusNoContent},
+		{"bob", "Bearer", http.StatusForbidden},
+		{"carol", "Bearer", http.StatusForbidden},
+		{"key:importer", "HMAC-SHA256", http.StatusNoContent},
+	}
+	for _, tc := range cases {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Set("username", tc.username)
+			c.Set("authScheme", tc.scheme)
+		})
+		r.POST("/", middleware.RequireTerms(repos.Terms, "v2"), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+		if w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.username, tc.want, w.Code)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// LogLevelRequest is the payload for PUT /admin/log-level.
+type LogLevelRequest struct {
+	// Level is one of debug, info, warn or error.
+	Level string `json:"level" binding:"required"`
+	// RevertAfter is a Go duration such as "15m" after which the level returns
+	// to the default.  Defaults to 15 minutes; capped at 24 hours.
+	RevertAfter string `json:"revertAfter,omitempty"`
+}
+
+// LogLevelResponse reports the current runtime log level.
+type LogLevelResponse struct {
+	Level        string     `json:"level"`
+	DefaultLevel string     `json:"defaultLevel"`
+	RevertAt     *time.Time `json:"revertAt,omitempty"`
+	Links        []Link     `json:"links"`
+}
+
+// RecordingRequest is the payload for PUT /admin/recording.
+type RecordingRequest struct {
+	// Enabled starts a new recording when true and stops it when false.
+	Enabled bool `json:"enabled"`
+	// Duration is a Go duration such as "10m" after which recording stops.
+	// Defaults to 10 minutes; capped at 1 hour.
+	Duration string `json:"duration,omitempty"`
+}
+
+// RecordingResponse reports whether requests are being recorded.
+type RecordingResponse struct {
+	Enabled bool `json:"enabled"`
+	// File is the recording on the server's disk, for cmd/replay.
+	File  string     `json:"file,omitempty"`
+	Until *time.Time `json:"until,omitempty"`
+	Links []Link     `json:"links"`
+}
+
+// JobRun is one run of a scheduled job.
+type JobRun struct {
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	// Status is "ok", "failed", or "skipped" when the previous run was
+	// still in progress.
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Job describes a scheduled background job.
+type Job struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`
+	// NextRun is omitted for schedules that never fire.
+	NextRun *time.Time `json:"nextRun,omitempty"`
+	Running bool       `json:"running"`
+	LastRun *JobRun    `json:"lastRun,omitempty"`
+}
+
+// JobsResponse lists the scheduled jobs.
+type JobsResponse struct {
+	Data  []Job  `json:"data"`
+	Links []Link `json:"links"`
+}
+
+// DailyReport summarises one UTC day of activity.
+type DailyReport struct {
+	// Date is the day reported on, as YYYY-MM-DD.
+	Date           string `json:"date"`
+	TeamsCreated   int64  `json:"teamsCreated"`
+	TeamsUpdated   int64  `json:"teamsUpdated"`
+	MatchesCreated int64  `json:"matchesCreated"`
+	MatchesUpdated int64  `json:"matchesUpdated"`
+	MatchesDeleted int64  `json:"matchesDeleted"`
+	NewUsers       int64  `json:"newUsers"`
+	// ActiveUsers counts users who logged in or used a session that day.
+	ActiveUsers  int64 `json:"activeUsers"`
+	Requests     int64 `json:"requests"`
+	ServerErrors int64 `json:"serverErrors"`
+	// ErrorRate is ServerErrors / Requests, or 0 without requests.
+	ErrorRate   float64   `json:"errorRate"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	Links       []Link    `json:"links,omitempty"`
+}
+
+// EndpointUsage is the use of one route on one UTC day.
+type EndpointUsage struct {
+	Date     string `json:"date"`
+	Method   string `json:"method"`
+	Route    string `json:"route" example:"/api/v1/football/teams/:id"`
+	Requests int64  `json:"requests"`
+	// Users counts the distinct signed-in users who called the route.
+	Users int64 `json:"users"`
+}
+
+// EndpointUsageResponse lists route usage over a range of days.
+type EndpointUsageResponse struct {
+	From  string          `json:"from"`
+	To    string          `json:"to"`
+	Data  []EndpointUsage `json:"data"`
+	Links []Link          `json:"links"`
+}
+
+// SubjectUsage is one pseudonymous user's activity on one UTC day.
+type SubjectUsage struct {
+	// Subject is a pseudonym that changes daily, or "anonymous".
+	Subject  string `json:"subject" example:"u-3f2a9c0d41b7e865"`
+	Requests int64  `json:"requests"`
+	// Endpoints counts the distinct routes called.
+	Endpoints int64 `json:"endpoints"`
+}
+
+// SubjectUsageResponse lists the users active on one day.
+type SubjectUsageResponse struct {
+	Date  string         `json:"date"`
+	Data  []SubjectUsage `json:"data"`
+	Links []Link         `json:"links"`
+}
+
+// AuditEntry is one row of the audit log.
+type AuditEntry struct {
+	ID int64
+	At time.Time
+	// Actor is the authenticated caller, or empty for unauthenticated
+	// requests such as registration.
+	Actor string
+	// Action is the event type, e.g. "match.deleted".
+	Action string
+	// ResourceID is the affected team or match ID, or a username.
+	ResourceID string
+	// Impersonator is the administrator who made the change while
+	// impersonating Actor, or empty.
+	Impersonator string
+	// Changes is the JSON array of the fields an update altered, as
+	// FieldChange values, or nil for other changes.
+	Changes json.RawMessage
+}
+
+// FeatureFlag is the current value of a feature flag.
+type FeatureFlag struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	// Source is "default", "config" (FEATURE_FLAGS or the flags file) or
+	// "runtime" (set through the admin endpoint).
+	Source    string     `json:"source"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+	UpdatedBy string     `json:"updatedBy,omitempty"`
+	Links     []Link     `json:"links"`
+}
+
+// FeatureFlagsResponse lists the feature flags.
+type FeatureFlagsResponse struct {
+	Data  []FeatureFlag `json:"data"`
+	Links []Link        `json:"links"`
+}
+
+// FeatureFlagRequest is the payload for PUT /admin/flags/{name}.
+type FeatureFlagRequest struct {
+	Enabled *bool `json:"enabled" binding:"required" example:"false"`
+}
+
+// TableCount is the number of rows a backup holds, or a restore wrote, for
+// one table.
+type TableCount struct {
+	Table string `json:"table" example:"football_teams"`
+	Rows  int64  `json:"rows" example:"312"`
+}
+
+// Backup describes a stored backup archive.
+type Backup struct {
+	Name      string    `json:"name" example:"backup-20240101T120000Z.json.gz"`
+	Size      int64     `json:"size" example:"2048"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Tables counts the rows taken; it is only returned when the backup is
+	// created.
+	Tables []TableCount `json:"tables,omitempty"`
+	Links  []Link       `json:"links"`
+}
+
+// BackupsResponse lists the stored backups, newest first.
+type BackupsResponse struct {
+	Data  []Backup `json:"data"`
+	Links []Link   `json:"links"`
+}
+
+// RestoreResponse reports a restore, or what one would restore.
+type RestoreResponse struct {
+	Backup string `json:"backup"`
+	// DryRun is true when nothing was changed.
+	DryRun bool         `json:"dryRun"`
+	Tables []TableCount `json:"tables"`
+	Links  []Link       `json:"links"`
+}
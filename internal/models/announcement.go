@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Announcement is a message from the operators to every user, delivered to
+// their inboxes and, when Banner is set, listed by GET /announcements while
+// it is live.
+type Announcement struct {
+	ID     int    `json:"id"`
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+	Banner bool   `json:"banner"`
+	// PublishAt is when the announcement becomes visible.
+	PublishAt time.Time `json:"publishAt"`
+	// ExpiresAt, when set, is when it stops being visible.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	CreatedBy string     `json:"createdBy,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	Links     []Link     `json:"links,omitempty"`
+}
+
+// AnnouncementRequest is the payload for POST /admin/announcements.
+type AnnouncementRequest struct {
+	Title  string `json:"title" sanitize:"line" binding:"required,max=200" example:"Scheduled maintenance"`
+	Body   string `json:"body" sanitize:"multiline" binding:"max=2000" example:"The API will be read-only from 02:00 to 03:00 UTC."`
+	Banner bool   `json:"banner"`
+	// PublishAt defaults to now.
+	PublishAt *time.Time `json:"publishAt,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// AnnouncementListResponse wraps a list of announcements.
+type AnnouncementListResponse struct {
+	Data  []Announcement `json:"data"`
+	Links []Link         `json:"links"`
+}
@@ -1,6 +1,8 @@
 // Package models defines the data structures used throughout the API.
 package models
 
+import "time"
+
 // Link represents a hypermedia link used to satisfy HATEOAS (Uniform Interface).
 type Link struct {
 	Rel    string `json:"rel"`
@@ -10,5 +12,107 @@ type Link struct {
 
 // ErrorResponse is the standard error envelope returned by all handlers.
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error string `json:"error" example:"team not found"`
+	// Code is the stable, machine-readable code of the error; see
+	// GET /api/v1/errors.
+	Code string `json:"code" example:"TEAM_NOT_FOUND"`
+}
+
+// ErrorCode describes one of the codes error responses carry.
+type ErrorCode struct {
+	Code string `json:"code" example:"TEAM_NOT_FOUND"`
+	// Status is the HTTP status of responses with the code, or 0 if it
+	// may come with any.
+	Status      int    `json:"status" example:"404"`
+	Description string `json:"description" example:"No team has this ID, or had it at asOf."`
+}
+
+// ErrorCodesResponse is the body of GET /api/v1/errors.
+type ErrorCodesResponse struct {
+	Data  []ErrorCode `json:"data"`
+	Links []Link      `json:"links"`
+}
+
+// ConflictResponse is returned with 409 Conflict when a write collides with
+// existing data.  Current holds the server's copy of the conflicting resource
+// so the client can reconcile without a further GET; it is omitted if that
+// copy could not be loaded.
+type ConflictResponse struct {
+	Error   string      `json:"error"`
+	Code    string      `json:"code" example:"TEAM_EXISTS"`
+	Current interface{} `json:"current,omitempty"`
+}
+
+// GoneResponse is returned with 410 Gone for a resource that was deleted,
+// carrying when, where the deployment answers so; see
+// handlers.DeleteOptions.
+type GoneResponse struct {
+	Error string `json:"error" example:"match was deleted"`
+	Code  string `json:"code" example:"RESOURCE_DELETED"`
+	Tombstone
+}
+
+// UnsupportedMediaTypeResponse is returned with 415 Unsupported Media Type
+// when a request body's Content-Type is not one the endpoint reads.
+type UnsupportedMediaTypeResponse struct {
+	Error string `json:"error" example:"unsupported Content-Type text/plain"`
+	Code  string `json:"code" example:"UNSUPPORTED_MEDIA_TYPE"`
+	// Supported lists the media types the endpoint accepts.
+	Supported []string `json:"supported" example:"application/json"`
+}
+
+// FieldChange records one field altered by an update, keyed by its JSON name,
+// so clients need not diff the old and new representations themselves.
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// TimeFilter narrows a list to records created or updated after given
+// instants, letting sync clients fetch only what changed since their last
+// run.  Nil fields do not filter.
+type TimeFilter struct {
+	// CreatedAfter keeps records created strictly after this instant.
+	CreatedAfter *time.Time
+	// UpdatedSince keeps records last modified at or after this instant.
+	UpdatedSince *time.Time
+	// AsOf lists the records as they were at this instant, reconstructed
+	// from the revision history, instead of as they are now.  The other
+	// fields then filter those records.
+	AsOf *time.Time
+}
+
+// Matches reports whether a record with the given timestamps passes f.
+func (f TimeFilter) Matches(createdAt, updatedAt time.Time) bool {
+	if f.CreatedAfter != nil && !createdAt.After(*f.CreatedAfter) {
+		return false
+	}
+	if f.UpdatedSince != nil && updatedAt.Before(*f.UpdatedSince) {
+		return false
+	}
+	return true
+}
+
+// ProbeResponse is returned by the /livez, /readyz and /startupz probes.
+type ProbeResponse struct {
+	// Status is "ok" or "unavailable", or for /readyz also "degraded".
+	Status string `json:"status" example:"ok"`
+	// Error explains why the probe failed.
+	Error string `json:"error,omitempty"`
+	// Dependencies reports each dependency /readyz checked.
+	Dependencies []DependencyCheck `json:"dependencies,omitempty"`
+}
+
+// DependencyCheck is the outcome of checking one dependency for /readyz.
+type DependencyCheck struct {
+	Name string `json:"name" example:"database"`
+	// Status is "ok", "degraded" (answered, but slowly) or "unavailable".
+	Status string `json:"status" example:"ok"`
+	// Critical dependencies make the instance unavailable when they fail;
+	// others only degrade it.
+	Critical bool `json:"critical"`
+	// LatencyMs is how long the check took, in milliseconds.
+	LatencyMs float64 `json:"latencyMs" example:"1.25"`
+	Error     string  `json:"error,omitempty"`
 }
@@ -9,3 +9,8 @@ var ErrNotFound = errors.New("not found")
 // ErrConflict is returned when a unique constraint would be violated (e.g. a
 // duplicate username).  HTTP handlers map this to 409 Conflict.
 var ErrConflict = errors.New("conflict")
+
+// ErrNoHistory is returned by as-of reads for an instant before the revision
+// history began, which cannot be reconstructed.  HTTP handlers map this to
+// 422 Unprocessable Entity.
+var ErrNoHistory = errors.New("no revision history at that time")
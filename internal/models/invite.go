@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Invite is a registration code generated by an administrator for
+// invite-only mode.  It admits up to MaxUses registrations before
+// ExpiresAt.
+type Invite struct {
+	Code      string    `json:"code"`
+	MaxUses   int       `json:"maxUses"`
+	Uses      int       `json:"uses"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	CreatedBy string    `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+	Links     []Link    `json:"links,omitempty"`
+}
+
+// InviteRequest is the payload for POST /admin/invites.
+type InviteRequest struct {
+	// MaxUses defaults to 1.
+	MaxUses int `json:"maxUses" example:"1"`
+	// ExpiresIn is a duration such as "72h"; it defaults to 7 days.
+	ExpiresIn string `json:"expiresIn" example:"72h"`
+}
+
+// InviteListResponse wraps every invite, usable or not.
+type InviteListResponse struct {
+	Invites []Invite `json:"invites"`
+	Links   []Link   `json:"links"`
+}
@@ -15,15 +15,19 @@ type Match struct {
 	AwayScore    int       `json:"awayScore"`
 	Tournament   string    `json:"tournament"`
 	TournamentID int       `json:"tournamentId"`
-	City         string    `json:"city"`
-	Country      string    `json:"country"`
+	City         string    `json:"city"         sanitize:"line" binding:"max=100"`
+	Country      string    `json:"country"      sanitize:"line" binding:"max=100"`
 	Neutral      bool      `json:"neutral"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
 }
 
 // MatchResponse wraps a Match with hypermedia links (HATEOAS).
 type MatchResponse struct {
 	Match
-	Links []Link `json:"links"`
+	// Changes lists the fields altered by an update; omitted otherwise.
+	Changes []FieldChange `json:"changes,omitempty"`
+	Links   []Link        `json:"links"`
 }
 
 // MatchesResponse wraps a list of matches with a collection-level link.
@@ -71,8 +75,8 @@ type CreateMatchRequest struct {
 	HomeScore    int       `json:"homeScore"    binding:"min=0"`
 	AwayScore    int       `json:"awayScore"    binding:"min=0"`
 	TournamentID int       `json:"tournamentId" binding:"required,min=1"`
-	City         string    `json:"city"`
-	Country      string    `json:"country"`
+	City         string    `json:"city"         sanitize:"line" binding:"max=100"`
+	Country      string    `json:"country"      sanitize:"line" binding:"max=100"`
 	Neutral      bool      `json:"neutral"`
 }
 
@@ -84,15 +88,15 @@ type UpdateMatchRequest struct {
 	HomeScore    int       `json:"homeScore"    binding:"min=0"`
 	AwayScore    int       `json:"awayScore"    binding:"min=0"`
 	TournamentID int       `json:"tournamentId" binding:"required,min=1"`
-	City         string    `json:"city"`
-	Country      string    `json:"country"`
+	City         string    `json:"city"         sanitize:"line" binding:"max=100"`
+	Country      string    `json:"country"      sanitize:"line" binding:"max=100"`
 	Neutral      bool      `json:"neutral"`
 }
 
 // CreateGoalRequest is the payload accepted when recording a goal in a match.
 type CreateGoalRequest struct {
 	TeamID  int    `json:"teamId"  binding:"required,min=1"`
-	Scorer  string `json:"scorer"  binding:"required,min=1,max=100"`
+	Scorer  string `json:"scorer"  sanitize:"line" binding:"required,min=1,max=100"`
 	OwnGoal bool   `json:"ownGoal"`
 	Penalty bool   `json:"penalty"`
 }
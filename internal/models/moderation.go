@@ -0,0 +1,81 @@
+package models
+
+import "time"
+
+// Kinds of content that users can report.
+const (
+	ContentTeam  = "team"
+	ContentMatch = "match"
+)
+
+// Moderation states of reported content.
+const (
+	// ModerationOpen content awaits review.
+	ModerationOpen = "open"
+	// ModerationDismissed content was reviewed and kept.  A new report
+	// reopens it.
+	ModerationDismissed = "dismissed"
+	// ModerationRemoved content was taken down.
+	ModerationRemoved = "removed"
+)
+
+// ContentReport is one user's report of a team or match.
+type ContentReport struct {
+	ID         int       `json:"id"`
+	Kind       string    `json:"kind"`
+	ResourceID int       `json:"resourceId"`
+	Reporter   string    `json:"reporter"`
+	Reason     string    `json:"reason"`
+	Note       string    `json:"note,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// ModerationItem is the moderation state of a reported team or match.
+type ModerationItem struct {
+	Kind            string    `json:"kind"`
+	ResourceID      int       `json:"resourceId"`
+	State           string    `json:"state"`
+	ReportCount     int       `json:"reportCount"`
+	FirstReportedAt time.Time `json:"firstReportedAt"`
+	LastReportedAt  time.Time `json:"lastReportedAt"`
+	// QuarantineReason is the classifier's reason code when the content
+	// was quarantined on write rather than (or as well as) reported.
+	QuarantineReason string `json:"quarantineReason,omitempty"`
+	// ReviewedBy, ReviewedAt and Resolution are set once an admin has
+	// dismissed the reports or taken the content down.
+	ReviewedBy string     `json:"reviewedBy,omitempty"`
+	ReviewedAt *time.Time `json:"reviewedAt,omitempty"`
+	Resolution string     `json:"resolution,omitempty"`
+	// Reports is filled in for the moderation queue.
+	Reports []ContentReport `json:"reports,omitempty"`
+	Links   []Link          `json:"links,omitempty"`
+}
+
+// ReportRequest is the payload for reporting a team or match.
+type ReportRequest struct {
+	Reason string `json:"reason" binding:"required,oneof=spam offensive inaccurate duplicate other" example:"inaccurate"`
+	Note   string `json:"note" sanitize:"multiline" binding:"max=500" example:"The score is reversed"`
+}
+
+// ModerationDecisionRequest is the optional payload for dismissing reports
+// or taking content down.
+type ModerationDecisionRequest struct {
+	Note string `json:"note" sanitize:"multiline" binding:"max=500" example:"Checked against the match report"`
+}
+
+// ModerationQueueResponse is one page of the moderation queue.
+type ModerationQueueResponse struct {
+	Data  []ModerationItem `json:"data"`
+	Links []Link           `json:"links"`
+}
+
+// ContentRejectedResponse is returned with 422 Unprocessable Entity when the
+// content classifier blocks a write.
+type ContentRejectedResponse struct {
+	Error string `json:"error" example:"content rejected"`
+	Code  string `json:"code" example:"CONTENT_REJECTED"`
+	// Reason is the classifier's machine-readable reason code.
+	Reason string `json:"reason" example:"denylisted_term"`
+	// Field is the JSON name of the offending field, when known.
+	Field string `json:"field,omitempty" example:"name"`
+}
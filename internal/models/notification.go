@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Notification is one entry in a user's inbox.
+type Notification struct {
+	ID int `json:"id"`
+	// Type is the kind of notification, such as "welcome" or "sign-in".
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	// Href, when set, points at the resource the notification is about.
+	Href string `json:"href,omitempty"`
+	// CreatedAt is when the notification became visible, which for a
+	// scheduled announcement is its publish time.
+	CreatedAt time.Time `json:"createdAt"`
+	// ExpiresAt, when set, is when the notification leaves the inbox.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// AnnouncementID links a broadcast copy to its announcement, so that
+	// deleting the announcement withdraws it.
+	AnnouncementID int `json:"-"`
+	// ReadAt is omitted while the notification is unread.
+	ReadAt *time.Time `json:"readAt,omitempty"`
+	Links  []Link     `json:"links,omitempty"`
+}
+
+// NotificationListResponse is one page of the caller's inbox.
+type NotificationListResponse struct {
+	Data []Notification `json:"data"`
+	// Unread counts every unread notification, not only those on this
+	// page.
+	Unread int    `json:"unread"`
+	Links  []Link `json:"links"`
+}
@@ -0,0 +1,115 @@
+package models
+
+import "time"
+
+// OAuthClient is a third-party application registered by an administrator
+// that users can let act for them.  Scopes bounds what it may ask for.
+type OAuthClient struct {
+	ID           string    `json:"clientId"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirectUris"`
+	Scopes       []string  `json:"scopes"`
+	SecretHash   string    `json:"-"`
+	CreatedBy    string    `json:"createdBy"`
+	CreatedAt    time.Time `json:"createdAt"`
+	Links        []Link    `json:"links,omitempty"`
+}
+
+// OAuthClientRequest is the payload for POST /admin/oauth/clients.
+type OAuthClientRequest struct {
+	Name string `json:"name" sanitize:"line" binding:"required,max=100" example:"Fixture Tracker"`
+	// RedirectURIs are the only places users are sent back to with an
+	// authorization code: https URLs, or http on a loopback host.
+	RedirectURIs []string `json:"redirectUris" binding:"required,min=1,max=10" example:"https://tracker.example/callback"`
+	// Scopes defaults to every scope.
+	Scopes []string `json:"scopes" example:"football:read"`
+}
+
+// OAuthClientCreatedResponse is a newly registered client with its secret,
+// which is shown only once.
+type OAuthClientCreatedResponse struct {
+	OAuthClient
+	ClientSecret string `json:"clientSecret"`
+}
+
+// OAuthClientListResponse wraps every registered client.
+type OAuthClientListResponse struct {
+	Clients []OAuthClient `json:"clients"`
+	Links   []Link        `json:"links"`
+}
+
+// OAuthGrant is a user's consent to a client acting for them within
+// Scopes.  Revoking it invalidates every token the client holds for them.
+type OAuthGrant struct {
+	ID         string     `json:"-"`
+	Username   string     `json:"-"`
+	ClientID   string     `json:"clientId"`
+	ClientName string     `json:"clientName"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	Links      []Link     `json:"links,omitempty"`
+}
+
+// OAuthGrantListResponse wraps the applications the caller has authorised.
+type OAuthGrantListResponse struct {
+	Apps  []OAuthGrant `json:"apps"`
+	Links []Link       `json:"links"`
+}
+
+// OAuthScope describes a scope on a consent screen.
+type OAuthScope struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Granted marks scopes the user has already given the client.
+	Granted bool `json:"granted"`
+}
+
+// OAuthConsent is what a consent screen shows the user before they let a
+// client act for them.
+type OAuthConsent struct {
+	ClientID    string       `json:"clientId"`
+	ClientName  string       `json:"clientName"`
+	RedirectURI string       `json:"redirectUri"`
+	Scopes      []OAuthScope `json:"scopes"`
+	State       string       `json:"state,omitempty"`
+	Links       []Link       `json:"links"`
+}
+
+// AuthorizeRequest is the user's answer to a consent screen, repeating the
+// parameters of the authorization request.
+type AuthorizeRequest struct {
+	ClientID    string `json:"clientId" binding:"required"`
+	RedirectURI string `json:"redirectUri" binding:"required"`
+	// Scope is space-separated; it defaults to every scope the client may
+	// ask for.
+	Scope string `json:"scope" example:"football:read football:write"`
+	State string `json:"state" binding:"max=500"`
+	// CodeChallenge is the RFC 7636 S256 challenge, which the client must
+	// then answer with its code verifier.
+	CodeChallenge       string `json:"codeChallenge" binding:"max=128"`
+	CodeChallengeMethod string `json:"codeChallengeMethod" example:"S256"`
+	Approve             bool   `json:"approve"`
+}
+
+// AuthorizeResponse tells the consent screen where to send the user: back
+// to the client with a code, or with error=access_denied.
+type AuthorizeResponse struct {
+	RedirectTo string `json:"redirectTo"`
+}
+
+// OAuthTokenResponse is the RFC 6749 access token response.
+type OAuthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+// OAuthErrorResponse is the RFC 6749 error response of the token endpoint,
+// which carries the code of the other error responses as well.
+type OAuthErrorResponse struct {
+	Error       string `json:"error" example:"invalid_grant"`
+	Description string `json:"error_description"`
+	Code        string `json:"code" example:"INVALID_GRANT"`
+}
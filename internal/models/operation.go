@@ -0,0 +1,38 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Operation statuses.
+const (
+	OperationRunning = "running"
+	OperationDone    = "done"
+)
+
+// Operation is a request accepted with Prefer: respond-async and run in
+// the background, as reported by GET /me/operations/:id.
+type Operation struct {
+	ID     string `json:"id" example:"3f2a9c1e5b7d4a60"`
+	Status string `json:"status" example:"done"`
+	Method string `json:"method" example:"POST"`
+	Path   string `json:"path" example:"/api/v1/football/matches"`
+	// Owner is the user who made the request; only they can see it.
+	Owner       string     `json:"-"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	// Response is the request's response, once it is done.
+	Response *OperationResult `json:"response,omitempty"`
+	Links    []Link           `json:"links,omitempty"`
+}
+
+// OperationResult is the response an Operation's request received.
+type OperationResult struct {
+	Status int `json:"status" example:"201"`
+	// Location is the response's Location header, such as the URL of a
+	// created resource.
+	Location string `json:"location,omitempty"`
+	// Body is the JSON response body, absent for an empty or non-JSON one.
+	Body json.RawMessage `json:"body,omitempty" swaggertype:"object"`
+}
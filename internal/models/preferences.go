@@ -0,0 +1,16 @@
+package models
+
+// PreferencesResponse is the body of GET and PUT /me/preferences.
+type PreferencesResponse struct {
+	// Preferences maps preference keys (pageSize, locale, email,
+	// emailNotifications, analytics) to their values; unset keys are
+	// omitted.  emailVerified is true once email has been verified.
+	Preferences map[string]any `json:"preferences" swaggertype:"object"`
+	Links       []Link         `json:"links"`
+}
+
+// VerifyEmailRequest is the body of POST /me/preferences/email/verify.
+type VerifyEmailRequest struct {
+	// Token is the verification token emailed to the address.
+	Token string `json:"token" binding:"required"`
+}
@@ -0,0 +1,13 @@
+package models
+
+// SchemaSummary names one JSON Schema served under /api/v1/schemas.
+type SchemaSummary struct {
+	Name string `json:"name" example:"TeamResponse"`
+	Href string `json:"href" example:"/api/v1/schemas/TeamResponse"`
+}
+
+// SchemaListResponse lists the JSON Schemas of the API's responses.
+type SchemaListResponse struct {
+	Data  []SchemaSummary `json:"data"`
+	Links []Link          `json:"links"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Session is one login of a user.  Each access token issued at login carries
+// the session ID, so revoking the session invalidates the token before it
+// expires.
+type Session struct {
+	ID          string    `json:"id"`
+	Username    string    `json:"-"`
+	DeviceLabel string    `json:"deviceLabel"`
+	IPAddress   string    `json:"ipAddress"`
+	CreatedAt   time.Time `json:"createdAt"`
+	LastUsedAt  time.Time `json:"lastUsedAt"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	// Current marks the session the request was made with.
+	Current bool   `json:"current"`
+	Links   []Link `json:"links,omitempty"`
+}
+
+// SessionListResponse wraps the caller's active sessions.
+type SessionListResponse struct {
+	Sessions []Session `json:"sessions"`
+	Links    []Link    `json:"links"`
+}
+
+// Login is one sign-in in a user's login activity.
+type Login struct {
+	ID        int64     `json:"id"`
+	Username  string    `json:"-"`
+	SessionID string    `json:"sessionId,omitempty"`
+	At        time.Time `json:"at"`
+	IPAddress string    `json:"ipAddress"`
+	UserAgent string    `json:"userAgent"`
+	// Device names the client and operating system, e.g. "Firefox on
+	// Windows"; Fingerprint identifies it regardless of version.
+	Device      string `json:"device"`
+	Fingerprint string `json:"fingerprint"`
+	// Network is the address prefix standing for the location signed in
+	// from, e.g. "203.0.113.0/24".
+	Network string `json:"network"`
+	// NewDevice and NewLocation mark a sign-in from a device or network
+	// the user had not signed in from before.  A user's first sign-in is
+	// neither.
+	NewDevice   bool `json:"newDevice"`
+	NewLocation bool `json:"newLocation"`
+}
+
+// LoginListResponse wraps the caller's recent sign-ins, newest first.
+type LoginListResponse struct {
+	Logins []Login `json:"logins"`
+	Links  []Link  `json:"links"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Tombstone records the deletion of a resource so that incremental-sync
+// clients learn to drop their local copy.
+type Tombstone struct {
+	ID        int       `json:"id"`
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+// MatchChanges is the set of match changes at or after a point in time, as
+// read from one consistent snapshot.
+type MatchChanges struct {
+	// Changed holds matches created or updated since the point in time.
+	Changed []Match
+	// Deleted holds tombstones for matches deleted since the point in time.
+	Deleted []Tombstone
+	// AsOf is the database time of the snapshot.
+	AsOf time.Time
+}
+
+// MatchChangesResponse is returned by GET /football/matches/changes.
+// Clients apply the three lists to their local copy, store NextCursor and
+// pass it as ?since= on their next sync.
+type MatchChangesResponse struct {
+	Created    []MatchResponse `json:"created"`
+	Updated    []MatchResponse `json:"updated"`
+	Deleted    []Tombstone     `json:"deleted"`
+	NextCursor string          `json:"nextCursor"`
+	Links      []Link          `json:"links"`
+}
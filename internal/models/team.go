@@ -5,15 +5,28 @@ import "time"
 
 // Team represents a national football team.
 type Team struct {
-	ID        int       `json:"id"`
-	Name      string    `json:"name"`
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// Slug identifies the team in URLs as an alternative to its ID, as in
+	// /football/teams/slug/{slug}.  It follows renames.
+	Slug      string    `json:"slug"`
 	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// Translations maps BCP 47 language tags to the team's name in that
+	// language.  They are served through /teams/{id}/translations and used
+	// to localise Name by Accept-Language, not listed with the team.
+	Translations map[string]string `json:"-"`
 }
 
 // TeamResponse wraps a Team with hypermedia links (HATEOAS).
 type TeamResponse struct {
 	Team
-	Links []Link `json:"links"`
+	// Language is the BCP 47 tag of the translation Name is given in,
+	// chosen by Accept-Language; omitted when Name is the team's own name.
+	Language string `json:"language,omitempty" example:"fr"`
+	// Changes lists the fields altered by an update; omitted otherwise.
+	Changes []FieldChange `json:"changes,omitempty"`
+	Links   []Link        `json:"links"`
 }
 
 // TeamsResponse wraps a list of teams with a collection-level link.
@@ -39,10 +52,22 @@ type FormerNamesResponse struct {
 
 // CreateTeamRequest is the payload accepted when creating a new Team.
 type CreateTeamRequest struct {
-	Name string `json:"name" binding:"required,min=1,max=100"`
+	Name string `json:"name" sanitize:"line" binding:"required,min=1,max=100"`
 }
 
 // UpdateTeamRequest is the payload accepted when replacing an existing Team.
 type UpdateTeamRequest struct {
-	Name string `json:"name" binding:"required,min=1,max=100"`
+	Name string `json:"name" sanitize:"line" binding:"required,min=1,max=100"`
+}
+
+// TeamTranslationsResponse lists a team's translated names by BCP 47 tag.
+type TeamTranslationsResponse struct {
+	Data  map[string]string `json:"data" example:"fr:Angleterre"`
+	Links []Link            `json:"links"`
+}
+
+// TeamTranslationRequest is the payload for
+// PUT /football/teams/{id}/translations/{lang}.
+type TeamTranslationRequest struct {
+	Name string `json:"name" sanitize:"line" binding:"required,min=1,max=100" example:"Angleterre"`
 }
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// TermsAcceptance records that a user accepted a version of the terms of
+// service.
+type TermsAcceptance struct {
+	Version    string    `json:"version"`
+	AcceptedAt time.Time `json:"acceptedAt"`
+}
+
+// TermsStatus reports the terms version in force and the latest one the
+// caller has accepted.
+type TermsStatus struct {
+	CurrentVersion string `json:"currentVersion"`
+	// Accepted is omitted when the caller has never accepted any version.
+	Accepted *TermsAcceptance `json:"accepted,omitempty"`
+	UpToDate bool             `json:"upToDate"`
+	Links    []Link           `json:"links"`
+}
+
+// AcceptTermsRequest is the payload for PUT /me/terms.
+type AcceptTermsRequest struct {
+	Version string `json:"version" binding:"required" example:"2024-06"`
+}
+
+// TermsRequiredResponse is returned with 403 Forbidden when a mutation is
+// refused because the caller has not accepted the current terms.
+type TermsRequiredResponse struct {
+	Error          string `json:"error"`
+	Code           string `json:"code"`
+	CurrentVersion string `json:"currentVersion"`
+	Links          []Link `json:"links"`
+}
@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// UsageRecord is the metered use of the API by one caller, through one API
+// key, on one UTC day.  Records also carry the deltas handed to usage
+// exporters.
+type UsageRecord struct {
+	// Date is the UTC day as YYYY-MM-DD.
+	Date     string `json:"date"`
+	Username string `json:"username"`
+	// Key is the ID of the HMAC key that signed the requests, or empty for
+	// requests authenticated otherwise.
+	Key      string `json:"key,omitempty"`
+	Requests int64  `json:"requests"`
+	BytesIn  int64  `json:"bytesIn"`
+	BytesOut int64  `json:"bytesOut"`
+}
+
+// UsageTotal sums usage over a period, for one caller and key or, as a
+// response's Total, for everything listed.
+type UsageTotal struct {
+	Username string `json:"username,omitempty"`
+	Key      string `json:"key,omitempty"`
+	Requests int64  `json:"requests"`
+	BytesIn  int64  `json:"bytesIn"`
+	BytesOut int64  `json:"bytesOut"`
+}
+
+// UsageResponse is the caller's own daily usage.
+type UsageResponse struct {
+	From  string        `json:"from"`
+	To    string        `json:"to"`
+	Total UsageTotal    `json:"total"`
+	Data  []UsageRecord `json:"data"`
+	Links []Link        `json:"links"`
+}
+
+// UsageRollupResponse is usage per caller and key over a period.
+type UsageRollupResponse struct {
+	From  string       `json:"from"`
+	To    string       `json:"to"`
+	Data  []UsageTotal `json:"data"`
+	Links []Link       `json:"links"`
+}
+
+// Quota names carried by QuotaExceededResponse.
+const (
+	// QuotaRate is a per-window request rate limit.
+	QuotaRate = "rate"
+	// QuotaMonthlyRequests is a caller's requests per calendar month.
+	QuotaMonthlyRequests = "monthly_requests"
+)
+
+// QuotaExceededResponse is returned with 429 Too Many Requests when a rate
+// limit is hit, or 402 Payment Required when a monthly quota is used up.
+// Links point to the caller's usage and, when configured, to where the
+// quota can be raised.
+type QuotaExceededResponse struct {
+	Error string `json:"error" example:"monthly request quota exhausted"`
+	Code  string `json:"code" example:"QUOTA_EXHAUSTED"`
+	// Quota names the limit that was hit.
+	Quota string `json:"quota" example:"monthly_requests"`
+	// Usage is the caller's count against the limit, including this request
+	// for rate limits.
+	Usage int64 `json:"usage" example:"10000"`
+	Limit int64 `json:"limit" example:"10000"`
+	// Reset is when the count starts again.
+	Reset time.Time `json:"reset" example:"2024-04-01T00:00:00Z"`
+	Links []Link    `json:"links,omitempty"`
+}
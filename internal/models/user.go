@@ -11,14 +11,24 @@ type User struct {
 
 // RegisterRequest is the payload for creating a new user account.
 type RegisterRequest struct {
-	Username string `json:"username" binding:"required,min=3,max=50"`
+	// Username is checked for length once normalised; see
+	// auth.ValidateUsername.
+	Username string `json:"username" binding:"required" minLength:"3" maxLength:"50"`
 	Password string `json:"password" binding:"required,min=8,max=128"`
+	// InviteCode is required while registration is invite-only.
+	InviteCode string `json:"inviteCode,omitempty" binding:"max=64"`
+	// AcceptTerms must name the current terms of service version when
+	// one is configured.
+	AcceptTerms string `json:"acceptTerms,omitempty" binding:"max=50"`
 }
 
 // LoginRequest is the payload for authenticating a user.
 type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	// DeviceLabel optionally names the device for the session list; the
+	// User-Agent is used when omitted.
+	DeviceLabel string `json:"deviceLabel,omitempty" binding:"max=100"`
 }
 
 // LoginResponse contains the JWT token returned after successful authentication.
@@ -26,3 +36,62 @@ type LoginResponse struct {
 	Token string `json:"token"`
 	Links []Link `json:"links"`
 }
+
+// ReauthenticateRequest is the payload for POST /auth/reauthenticate.
+type ReauthenticateRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// FreshAuthRequiredResponse is returned with 401 Unauthorized when an
+// operation is refused because the caller signed in too long ago.
+type FreshAuthRequiredResponse struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+	// MaxAge is how many seconds old the sign-in may be.
+	MaxAge int    `json:"maxAge"`
+	Links  []Link `json:"links"`
+}
+
+// ImpersonateRequest is the payload for POST /admin/impersonate.
+type ImpersonateRequest struct {
+	Username string `json:"username" binding:"required,max=50"`
+}
+
+// ImpersonationResponse carries a short-lived token with which an
+// administrator acts as Username.  Changes made with it are recorded in the
+// audit log under both names.
+type ImpersonationResponse struct {
+	Token        string    `json:"token"`
+	Username     string    `json:"username"`
+	Impersonator string    `json:"impersonator"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	Links        []Link    `json:"links"`
+}
+
+// IntrospectionResponse is the RFC 7662 token introspection result.  An
+// inactive token yields only {"active": false}, revealing nothing about why.
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Username  string `json:"username,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	Issuer    string `json:"iss,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	TokenID   string `json:"jti,omitempty"`
+	// ClientID and Scope are set for tokens issued to third-party
+	// applications.
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+}
+
+// ExportManifest describes the contents of a personal-data export archive.
+type ExportManifest struct {
+	FormatVersion int       `json:"formatVersion"`
+	Username      string    `json:"username"`
+	ExportedAt    time.Time `json:"exportedAt"`
+	Files         []string  `json:"files"`
+	// Truncated lists the files that held too many rows to export in
+	// full.
+	Truncated []string `json:"truncated,omitempty"`
+}
@@ -0,0 +1,196 @@
+package notify_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/notify"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/scheduler"
+)
+
+// smtpServer accepts SMTP sessions and records the DATA of each message.
+// It advertises no extensions, so the client neither starts TLS nor
+// authenticates.
+func smtpServer(t *testing.T) (addr string, received <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	ch := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, ch)
+		}
+	}()
+	return ln.Addr().String(), ch
+}
+
+func serveSMTP(conn net.Conn, ch chan<- string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+	reply("220 test ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.Fields(line + " x")[0])
+		switch cmd {
+		case "EHLO", "HELO":
+			reply("250 test")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			ch <- data.String()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func TestSMTPSender(t *testing.T) {
+	addr, received := smtpServer(t)
+	s := notify.NewSMTPSender(notify.SMTPConfig{Addr: addr, From: "api@example.com"})
+	err := s.Send(context.Background(), notify.Message{
+		To:      []string{"ops@example.com"},
+		Subject: "Hello",
+		Text:    "plain body",
+		HTML:    "<p>html body</p>",
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	data := <-received
+	for _, want := range []string{
+		"From: api@example.com", "To: ops@example.com", "Subject: Hello",
+		"multipart/alternative", "plain body", "<p>html body</p>",
+	} {
+		if !strings.Contains(data, want) {
+			t.Errorf("message does not contain %q:\n%s", want, data)
+		}
+	}
+
+	err = s.Send(context.Background(), notify.Message{To: []string{"a@example.com\r\nBcc: x@example.com"}, Text: "x"})
+	if err == nil {
+		t.Error("expected an error for a recipient containing a newline")
+	}
+}
+
+func TestRender_JobFailed(t *testing.T) {
+	at := time.Date(2024, 3, 15, 3, 0, 0, 0, time.UTC)
+	m, err := notify.Render("job_failed", scheduler.Run{
+		Job: "tombstone-purge", Started: at, Finished: at, Status: scheduler.StatusFailed, Error: "<timeout>",
+	}, "ops@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Subject != "[Football API] Scheduled job tombstone-purge failed" {
+		t.Errorf("subject = %q", m.Subject)
+	}
+	if !strings.Contains(m.Text, "Error:    <timeout>") {
+		t.Errorf("text body:\n%s", m.Text)
+	}
+	if !strings.Contains(m.HTML, "&lt;timeout&gt;") {
+		t.Errorf("expected the HTML body to escape the error:\n%s", m.HTML)
+	}
+	if len(m.To) != 1 || m.To[0] != "ops@example.com" {
+		t.Errorf("to = %v", m.To)
+	}
+
+	if _, err := notify.Render("no_such_template", nil); err == nil {
+		t.Error("expected an error for an unknown template")
+	}
+}
+
+// flakySender fails its first `failures` sends.
+type flakySender struct {
+	mu       sync.Mutex
+	failures int
+	sent     []notify.Message
+}
+
+func (f *flakySender) Send(_ context.Context, m notify.Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("connection refused")
+	}
+	f.sent = append(f.sent, m)
+	return nil
+}
+
+func TestQueue_RetriesAndDrainsOnClose(t *testing.T) {
+	sender := &flakySender{failures: 2}
+	q := notify.NewQueue(sender, 10)
+	q.RetryDelay = time.Millisecond
+	for _, subject := range []string{"one", "two"} {
+		if err := q.Enqueue(notify.Message{To: []string{"ops@example.com"}, Subject: subject}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := q.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if len(sender.sent) != 2 || sender.sent[0].Subject != "one" {
+		t.Errorf("sent = %+v", sender.sent)
+	}
+	if err := q.Enqueue(notify.Message{}); err == nil {
+		t.Error("expected an error enqueueing after Close")
+	}
+}
+
+func TestQueue_Full(t *testing.T) {
+	block := make(chan struct{})
+	q := notify.NewQueue(senderFunc(func(context.Context, notify.Message) error { <-block; return nil }), 1)
+	defer func() {
+		close(block)
+		_ = q.Close(context.Background())
+	}()
+	// The worker takes the first message and blocks; the second fills the
+	// queue.
+	_ = q.Enqueue(notify.Message{})
+	time.Sleep(50 * time.Millisecond)
+	_ = q.Enqueue(notify.Message{})
+	if err := q.Enqueue(notify.Message{}); !errors.Is(err, notify.ErrQueueFull) {
+		t.Errorf("expected ErrQueueFull, got %v", err)
+	}
+
+	var nilQueue *notify.Queue
+	if err := nilQueue.Enqueue(notify.Message{}); err != nil {
+		t.Errorf("nil queue Enqueue: %v", err)
+	}
+}
+
+type senderFunc func(context.Context, notify.Message) error
+
+func (f senderFunc) Send(ctx context.Context, m notify.Message) error { return f(ctx, m) }
@@ -0,0 +1,136 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrQueueFull is returned by Enqueue when the queue is at capacity, which
+// usually means the mail server has been unreachable for a while.
+var ErrQueueFull = errors.New("notify: queue full")
+
+// Queue defaults.
+const (
+	DefaultQueueSize  = 100
+	DefaultRetryDelay = 2 * time.Second
+	maxAttempts       = 5
+	sendTimeout       = 30 * time.Second
+)
+
+var metrics = expvar.NewMap("notify")
+
+// Queue delivers messages through a Sender from a single background worker,
+// retrying failed sends with exponential backoff.  Create one with NewQueue
+// and stop it with Close.
+type Queue struct {
+	// RetryDelay is the wait before the second attempt at a message; it
+	// doubles after each further failure, for up to five attempts.  Set it
+	// before the first Enqueue.
+	RetryDelay time.Duration
+
+	sender Sender
+	ch     chan Message
+
+	closeOnce sync.Once
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// NewQueue starts a queue holding up to size pending messages (size <= 0
+// means DefaultQueueSize).
+func NewQueue(sender Sender, size int) *Queue {
+	if size <= 0 {
+		size = DefaultQueueSize
+	}
+	q := &Queue{
+		RetryDelay: DefaultRetryDelay,
+		sender:     sender,
+		ch:         make(chan Message, size),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// Enqueue schedules m for delivery without waiting for it.  A nil *Queue
+// discards the message, so callers need not check whether email is
+// configured.
+func (q *Queue) Enqueue(m Message) error {
+	if q == nil {
+		return nil
+	}
+	select {
+	case <-q.stop:
+		return errors.New("notify: queue closed")
+	default:
+	}
+	select {
+	case q.ch <- m:
+		metrics.Add("queued", 1)
+		return nil
+	default:
+		metrics.Add("dropped", 1)
+		return ErrQueueFull
+	}
+}
+
+// Close stops accepting messages and waits for those already queued to be
+// sent, or for ctx to expire, when the rest are dropped.
+func (q *Queue) Close(ctx context.Context) error {
+	if q == nil {
+		return nil
+	}
+	q.closeOnce.Do(func() { close(q.stop) })
+	select {
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *Queue) run() {
+	defer close(q.done)
+	for {
+		select {
+		case m := <-q.ch:
+			q.deliver(m)
+		case <-q.stop:
+			// Drain what was queued before Close.
+			for {
+				select {
+				case m := <-q.ch:
+					q.deliver(m)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (q *Queue) deliver(m Message) {
+	delay := q.RetryDelay
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		err := q.sender.Send(ctx, m)
+		cancel()
+		if err == nil {
+			metrics.Add("sent", 1)
+			return
+		}
+		if attempt == maxAttempts {
+			metrics.Add("failed", 1)
+			log.Printf("notify: giving up on %q after %d attempts: %v", m.Subject, attempt, err)
+			return
+		}
+		log.Printf("notify: send %q (attempt %d): %v", m.Subject, attempt, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
@@ -0,0 +1,79 @@
+package notify
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	"text/template"
+)
+
+// Each kind of message has templates/<name>.txt, which defines a "subject"
+// template alongside the plain-text body, and optionally
+// templates/<name>.html.
+//
+//go:embed templates
+var templateFS embed.FS
+
+type messageTemplate struct {
+	text *template.Template
+	html *htmltemplate.Template
+}
+
+var templates = mustParseTemplates()
+
+func mustParseTemplates() map[string]messageTemplate {
+	out := make(map[string]messageTemplate)
+	files, err := fs.Glob(templateFS, "templates/*.txt")
+	if err != nil {
+		panic(err)
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(path.Base(file), ".txt")
+		mt := messageTemplate{text: template.Must(template.ParseFS(templateFS, file))}
+		if mt.text.Lookup("subject") == nil {
+			panic("notify: " + file + " does not define a subject")
+		}
+		if html := "templates/" + name + ".html"; exists(html) {
+			mt.html = htmltemplate.Must(htmltemplate.ParseFS(templateFS, html))
+		}
+		out[name] = mt
+	}
+	return out
+}
+
+func exists(file string) bool {
+	_, err := fs.Stat(templateFS, file)
+	return err == nil
+}
+
+// Render builds the message name (e.g. "job_failed") addressed to to from
+// its embedded templates and data.  The HTML part is escaped for HTML; the
+// subject and text part are not.
+func Render(name string, data interface{}, to ...string) (Message, error) {
+	mt, ok := templates[name]
+	if !ok {
+		return Message{}, fmt.Errorf("notify: no template %q", name)
+	}
+	var subject, text, html bytes.Buffer
+	if err := mt.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, fmt.Errorf("notify: %s subject: %w", name, err)
+	}
+	if err := mt.text.Execute(&text, data); err != nil {
+		return Message{}, fmt.Errorf("notify: %s text: %w", name, err)
+	}
+	if mt.html != nil {
+		if err := mt.html.Execute(&html, data); err != nil {
+			return Message{}, fmt.Errorf("notify: %s html: %w", name, err)
+		}
+	}
+	return Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
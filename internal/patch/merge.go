@@ -0,0 +1,42 @@
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// MergeMediaType is the Content-Type of JSON Merge Patch documents.
+const MergeMediaType = "application/merge-patch+json"
+
+// Merge applies the JSON Merge Patch (RFC 7386) mergePatch to doc.  Object
+// members in the patch replace those in doc, a null member removes the
+// member, and any non-object patch replaces the document outright.
+func Merge(doc, mergePatch []byte) ([]byte, error) {
+	var target, p interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, fmt.Errorf("patch: decode document: %w", err)
+	}
+	if err := json.Unmarshal(mergePatch, &p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return json.Marshal(mergeValue(target, p))
+}
+
+func mergeValue(target, p interface{}) interface{} {
+	pm, ok := p.(map[string]interface{})
+	if !ok {
+		return p
+	}
+	tm, ok := target.(map[string]interface{})
+	if !ok {
+		tm = map[string]interface{}{}
+	}
+	for k, v := range pm {
+		if v == nil {
+			delete(tm, k)
+			continue
+		}
+		tm[k] = mergeValue(tm[k], v)
+	}
+	return tm
+}
@@ -0,0 +1,42 @@
+package patch_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/patch"
+)
+
+// The cases are the examples from RFC 7386 appendix A.
+func TestMerge(t *testing.T) {
+	cases := []struct{ doc, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tc := range cases {
+		got, err := patch.Merge([]byte(tc.doc), []byte(tc.patch))
+		if err != nil {
+			t.Fatalf("Merge(%s, %s): %v", tc.doc, tc.patch, err)
+		}
+		assertJSON(t, got, tc.want)
+	}
+}
+
+func TestMerge_InvalidPatch(t *testing.T) {
+	if _, err := patch.Merge([]byte(`{}`), []byte(`{`)); !errors.Is(err, patch.ErrInvalidPatch) {
+		t.Fatalf("expected ErrInvalidPatch, got %v", err)
+	}
+}
@@ -0,0 +1,316 @@
+// Package patch applies JSON Patch (RFC 6902) documents to JSON values.  It
+// works on the generic decoded form of a document, so handlers can patch any
+// resource representation and then bind and validate the result exactly as
+// they would a full replacement.
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// MediaType is the Content-Type of JSON Patch documents.
+const MediaType = "application/json-patch+json"
+
+var (
+	// ErrInvalidPatch reports a malformed patch document or operation.
+	ErrInvalidPatch = errors.New("invalid JSON Patch")
+	// ErrPathNotFound reports an operation whose target location does not
+	// exist in the document.
+	ErrPathNotFound = errors.New("path does not exist")
+	// ErrTestFailed reports a "test" operation whose value did not match.
+	ErrTestFailed = errors.New("test operation failed")
+)
+
+// Operation is one JSON Patch operation.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Replace returns a "replace" operation setting the top-level member name to
+// value.
+func Replace(name string, value interface{}) (Operation, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return Operation{}, err
+	}
+	return Operation{Op: "replace", Path: "/" + EscapeToken(name), Value: raw}, nil
+}
+
+// Decode parses and validates a JSON Patch document.
+func Decode(body []byte) ([]Operation, error) {
+	var ops []Operation
+	if err := json.Unmarshal(body, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	for i, op := range ops {
+		if err := op.validate(); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+	return ops, nil
+}
+
+func (op Operation) validate() error {
+	if _, err := parsePointer(op.Path); err != nil {
+		return err
+	}
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return fmt.Errorf("%w: %q requires a value", ErrInvalidPatch, op.Op)
+		}
+	case "move", "copy":
+		if _, err := parsePointer(op.From); err != nil {
+			return err
+		}
+		if op.Op == "move" && strings.HasPrefix(op.Path, op.From+"/") {
+			return fmt.Errorf("%w: cannot move %q into its own child", ErrInvalidPatch, op.From)
+		}
+	case "remove":
+	default:
+		return fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.Op)
+	}
+	return nil
+}
+
+// Apply applies ops in order to the JSON document doc and returns the
+// patched document.  The patch is atomic: on error doc is left as it was and
+// no partial result is returned.
+func Apply(doc []byte, ops []Operation) ([]byte, error) {
+	var v interface{}
+	if err := json.Unmarshal(doc, &v); err != nil {
+		return nil, fmt.Errorf("patch: decode document: %w", err)
+	}
+	for i, op := range ops {
+		var err error
+		if v, err = applyOne(v, op); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return json.Marshal(v)
+}
+
+func applyOne(doc interface{}, op Operation) (interface{}, error) {
+	if err := op.validate(); err != nil {
+		return nil, err
+	}
+	path, _ := parsePointer(op.Path)
+
+	var value interface{}
+	if op.Value != nil {
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: value: %v", ErrInvalidPatch, err)
+		}
+	}
+
+	switch op.Op {
+	case "add":
+		return add(doc, path, value)
+	case "remove":
+		doc, _, err := remove(doc, path)
+		return doc, err
+	case "replace":
+		if _, err := get(doc, path); err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return value, nil
+		}
+		doc, _, err := remove(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	case "move":
+		from, _ := parsePointer(op.From)
+		doc, moved, err := remove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, moved)
+	case "copy":
+		from, _ := parsePointer(op.From)
+		src, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, deepCopy(src))
+	case "test":
+		cur, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(cur, value) {
+			return nil, ErrTestFailed
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.Op)
+}
+
+// get returns the value at path.
+func get(doc interface{}, path []string) (interface{}, error) {
+	cur := doc
+	for _, tok := range path {
+		switch n := cur.(type) {
+		case map[string]interface{}:
+			v, ok := n[tok]
+			if !ok {
+				return nil, ErrPathNotFound
+			}
+			cur = v
+		case []interface{}:
+			i, err := arrayIndex(tok, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			cur = n[i]
+		default:
+			return nil, ErrPathNotFound
+		}
+	}
+	return cur, nil
+}
+
+// add inserts value at path: a new or replaced object member, or an array
+// element inserted before the given index ("-" appends).
+func add(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return mutateParent(doc, path, func(parent interface{}, key string) (interface{}, error) {
+		switch n := parent.(type) {
+		case map[string]interface{}:
+			n[key] = value
+			return n, nil
+		case []interface{}:
+			i := len(n)
+			if key != "-" {
+				var err error
+				if i, err = arrayIndex(key, len(n)); err != nil {
+					return nil, err
+				}
+			}
+			n = append(n, nil)
+			copy(n[i+1:], n[i:])
+			n[i] = value
+			return n, nil
+		default:
+			return nil, ErrPathNotFound
+		}
+	})
+}
+
+// remove deletes the value at path and returns it.
+func remove(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+	var removed interface{}
+	doc, err := mutateParent(doc, path, func(parent interface{}, key string) (interface{}, error) {
+		switch n := parent.(type) {
+		case map[string]interface{}:
+			v, ok := n[key]
+			if !ok {
+				return nil, ErrPathNotFound
+			}
+			removed = v
+			delete(n, key)
+			return n, nil
+		case []interface{}:
+			i, err := arrayIndex(key, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			removed = n[i]
+			return append(n[:i], n[i+1:]...), nil
+		default:
+			return nil, ErrPathNotFound
+		}
+	})
+	return doc, removed, err
+}
+
+// mutateParent walks to the container holding the last token of path, lets
+// fn change it, and stores the (possibly reallocated) container back.
+func mutateParent(node interface{}, path []string, fn func(parent interface{}, key string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return fn(node, path[0])
+	}
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[path[0]]
+		if !ok {
+			return nil, ErrPathNotFound
+		}
+		updated, err := mutateParent(child, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		n[path[0]] = updated
+		return n, nil
+	case []interface{}:
+		i, err := arrayIndex(path[0], len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := mutateParent(n[i], path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = updated
+		return n, nil
+	default:
+		return nil, ErrPathNotFound
+	}
+}
+
+// arrayIndex parses an array index token no greater than max.
+func arrayIndex(tok string, max int) (int, error) {
+	if tok == "" || (len(tok) > 1 && tok[0] == '0') {
+		return 0, fmt.Errorf("%w: bad array index %q", ErrInvalidPatch, tok)
+	}
+	i, err := strconv.Atoi(tok)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("%w: bad array index %q", ErrInvalidPatch, tok)
+	}
+	if i > max {
+		return 0, ErrPathNotFound
+	}
+	return i, nil
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into unescaped tokens.
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if p[0] != '/' {
+		return nil, fmt.Errorf("%w: path %q must start with /", ErrInvalidPatch, p)
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// EscapeToken escapes a member name for use in a JSON Pointer.
+func EscapeToken(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
+
+func deepCopy(v interface{}) interface{} {
+	raw, _ := json.Marshal(v)
+	var out interface{}
+	_ = json.Unmarshal(raw, &out)
+	return out
+}
@@ -0,0 +1,96 @@
+package patch_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/patch"
+)
+
+func apply(t *testing.T, doc, ops string) ([]byte, error) {
+	t.Helper()
+	decoded, err := patch.Decode([]byte(ops))
+	if err != nil {
+		return nil, err
+	}
+	return patch.Apply([]byte(doc), decoded)
+}
+
+func assertJSON(t *testing.T, got []byte, want string) {
+	t.Helper()
+	var g, w interface{}
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("result is not JSON: %v", err)
+	}
+	_ = json.Unmarshal([]byte(want), &w)
+	if !reflect.DeepEqual(g, w) {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+// The cases follow the examples in RFC 6902 appendix A.
+func TestApply(t *testing.T) {
+	cases := []struct {
+		name, doc, ops, want string
+	}{
+		{"add member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{"add array element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"append", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":"baz"}]`, `{"foo":["bar","baz"]}`},
+		{"remove member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"remove element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"replace", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"move", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"copy", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"}]`, `{"a":{"b":1},"c":{"b":1}}`},
+		{"test then replace", `{"n":1}`, `[{"op":"test","path":"/n","value":1},{"op":"replace","path":"/n","value":2}]`, `{"n":2}`},
+		{"escaped tokens", `{"a/b":1,"m~n":2}`, `[{"op":"replace","path":"/a~1b","value":3},{"op":"remove","path":"/m~0n"}]`, `{"a/b":3}`},
+		{"null value", `{"a":1}`, `[{"op":"replace","path":"/a","value":null}]`, `{"a":null}`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := apply(t, tc.doc, tc.ops)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assertJSON(t, got, tc.want)
+		})
+	}
+}
+
+func TestApply_Errors(t *testing.T) {
+	cases := []struct {
+		name, doc, ops string
+		want           error
+	}{
+		{"unknown op", `{}`, `[{"op":"frobnicate","path":"/a"}]`, patch.ErrInvalidPatch},
+		{"missing value", `{}`, `[{"op":"add","path":"/a"}]`, patch.ErrInvalidPatch},
+		{"relative path", `{}`, `[{"op":"remove","path":"a"}]`, patch.ErrInvalidPatch},
+		{"not an array", `{}`, `{"op":"remove","path":"/a"}`, patch.ErrInvalidPatch},
+		{"missing member", `{"a":1}`, `[{"op":"remove","path":"/b"}]`, patch.ErrPathNotFound},
+		{"replace missing", `{"a":1}`, `[{"op":"replace","path":"/b","value":1}]`, patch.ErrPathNotFound},
+		{"index out of range", `{"a":[1]}`, `[{"op":"add","path":"/a/5","value":1}]`, patch.ErrPathNotFound},
+		{"leading zero index", `{"a":[1,2]}`, `[{"op":"remove","path":"/a/01"}]`, patch.ErrInvalidPatch},
+		{"move into child", `{"a":{}}`, `[{"op":"move","from":"/a","path":"/a/b"}]`, patch.ErrInvalidPatch},
+		{"test mismatch", `{"a":"x"}`, `[{"op":"test","path":"/a","value":"y"}]`, patch.ErrTestFailed},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := apply(t, tc.doc, tc.ops); !errors.Is(err, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestReplace_EscapesName(t *testing.T) {
+	op, err := patch.Replace("a/b", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if op.Op != "replace" || op.Path != "/a~1b" || string(op.Value) != "1" {
+		t.Fatalf("unexpected operation %+v", op)
+	}
+}
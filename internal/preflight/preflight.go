@@ -0,0 +1,88 @@
+// Package preflight collects the results of startup self-checks into a
+// diagnostic report.  The server runs these checks when started with -check
+// so that CI/CD pipelines and container entrypoints can validate a deployment
+// before it takes traffic.
+package preflight
+
+import (
+	"fmt"
+	"io"
+)
+
+// Status is the outcome of a single check.
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+)
+
+// minSecretBytes is the shortest JWT secret accepted without a warning; an
+// HMAC-SHA256 key should carry at least as many bytes as the hash output.
+const minSecretBytes = 32
+
+// Result is one line of the report.
+type Result struct {
+	Name   string
+	Status Status
+	Detail string
+}
+
+// Report accumulates check results in the order they were run.
+type Report struct {
+	Results []Result
+}
+
+// OK records a passing check.
+func (r *Report) OK(name, detail string) { r.add(name, StatusOK, detail) }
+
+// Warn records a check that passed but deserves attention.
+func (r *Report) Warn(name, detail string) { r.add(name, StatusWarn, detail) }
+
+// Fail records a failing check.
+func (r *Report) Fail(name, detail string) { r.add(name, StatusFail, detail) }
+
+func (r *Report) add(name string, status Status, detail string) {
+	r.Results = append(r.Results, Result{Name: name, Status: status, Detail: detail})
+}
+
+// Failed reports whether any check failed.
+func (r *Report) Failed() bool {
+	for _, res := range r.Results {
+		if res.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+// Write prints the report, one check per line, followed by a summary.
+func (r *Report) Write(w io.Writer) error {
+	counts := map[Status]int{}
+	for _, res := range r.Results {
+		counts[res.Status]++
+		if _, err := fmt.Fprintf(w, "[%-4s] %-22s %s\n", res.Status, res.Name, res.Detail); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d ok, %d warnings, %d failed\n",
+		counts[StatusOK], counts[StatusWarn], counts[StatusFail])
+	return err
+}
+
+// CheckJWTSecret validates the JWT signing key material.  With devMode set an
+// empty secret is only a warning, matching the server's DEV_MODE fallback to a
+// random per-process secret.
+func (r *Report) CheckJWTSecret(secret string, devMode bool) {
+	switch {
+	case secret == "" && devMode:
+		r.Warn("jwt secret", "not set; DEV_MODE will generate a random secret per process")
+	case secret == "":
+		r.Fail("jwt secret", "JWT_SECRET (or JWT_SECRET_FILE) is not set")
+	case len(secret) < minSecretBytes:
+		r.Warn("jwt secret", fmt.Sprintf("%d bytes; at least %d are recommended", len(secret), minSecretBytes))
+	default:
+		r.OK("jwt secret", fmt.Sprintf("%d bytes", len(secret)))
+	}
+}
@@ -0,0 +1,52 @@
+package preflight_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/preflight"
+)
+
+func TestCheckJWTSecret(t *testing.T) {
+	cases := []struct {
+		secret  string
+		devMode bool
+		want    preflight.Status
+	}{
+		{"", false, preflight.StatusFail},
+		{"", true, preflight.StatusWarn},
+		{"short", false, preflight.StatusWarn},
+		{strings.Repeat("k", 32), false, preflight.StatusOK},
+	}
+	for _, tc := range cases {
+		var r preflight.Report
+		r.CheckJWTSecret(tc.secret, tc.devMode)
+		if got := r.Results[0].Status; got != tc.want {
+			t.Errorf("secret of %d bytes: expected %s, got %s", len(tc.secret), tc.want, got)
+		}
+	}
+}
+
+func TestReport_FailedAndWrite(t *testing.T) {
+	var r preflight.Report
+	r.OK("database", "connected")
+	r.Warn("tls", "disabled")
+	if r.Failed() {
+		t.Fatal("expected no failure before a failing check is recorded")
+	}
+	r.Fail("migrations", "missing tables: users")
+	if !r.Failed() {
+		t.Fatal("expected Failed to report the failing check")
+	}
+
+	var out strings.Builder
+	if err := r.Write(&out); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if !strings.Contains(out.String(), "missing tables: users") {
+		t.Errorf("expected detail in report, got:\n%s", out.String())
+	}
+	if !strings.HasSuffix(out.String(), "1 ok, 1 warnings, 1 failed\n") {
+		t.Errorf("unexpected summary in report:\n%s", out.String())
+	}
+}
@@ -0,0 +1,129 @@
+// Package prefs defines the user preference keys accepted by
+// /me/preferences, validates values for them, and reads them back for the
+// features they tune.
+package prefs
+
+import (
+	"fmt"
+	"math"
+	"net/mail"
+	"regexp"
+	"sort"
+)
+
+// Preference keys.
+const (
+	// PageSize is the default page size of paginated lists, 1 to MaxPageSize.
+	PageSize = "pageSize"
+	// Locale is a BCP 47 language tag such as "en-GB".
+	Locale = "locale"
+	// Email is the address notifications are sent to.
+	Email = "email"
+	// EmailNotifications opts in to receiving notifications by email.
+	EmailNotifications = "emailNotifications"
+	// Analytics set to false leaves the user out of usage analytics.
+	Analytics = "analytics"
+	// EmailVerified is set by the server, not by users, once the owner of
+	// Email has confirmed it; notifications are only emailed after that.
+	EmailVerified = "emailVerified"
+)
+
+// Sealed lists the keys whose values are encrypted where preferences are
+// stored.
+var Sealed = []string{Email}
+
+// MaxPageSize bounds the PageSize preference.
+const MaxPageSize = 200
+
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// validators normalise a decoded JSON value for each key, or reject it.
+var validators = map[string]func(v any) (any, error){
+	PageSize: func(v any) (any, error) {
+		f, ok := v.(float64)
+		if !ok || f != math.Trunc(f) || f < 1 || f > MaxPageSize {
+			return nil, fmt.Errorf("must be an integer from 1 to %d", MaxPageSize)
+		}
+		return int(f), nil
+	},
+	Locale: func(v any) (any, error) {
+		s, ok := v.(string)
+		if !ok || !localePattern.MatchString(s) {
+			return nil, fmt.Errorf("must be a language tag such as en-GB")
+		}
+		return s, nil
+	},
+	Email: func(v any) (any, error) {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("must be an email address")
+		}
+		addr, err := mail.ParseAddress(s)
+		if err != nil || addr.Name != "" {
+			return nil, fmt.Errorf("must be an email address")
+		}
+		return addr.Address, nil
+	},
+	EmailNotifications: boolean,
+	Analytics:          boolean,
+}
+
+func boolean(v any) (any, error) {
+	b, ok := v.(bool)
+	if !ok {
+		return nil, fmt.Errorf("must be true or false")
+	}
+	return b, nil
+}
+
+// Keys returns the accepted preference keys, sorted.
+func Keys() []string {
+	keys := make([]string, 0, len(validators))
+	for k := range validators {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Validate checks preferences decoded from JSON and returns them
+// normalised.  Unknown keys and null values are rejected.
+func Validate(in map[string]any) (map[string]any, error) {
+	out := make(map[string]any, len(in))
+	for k, v := range in {
+		validate, ok := validators[k]
+		if !ok {
+			return nil, fmt.Errorf("unknown preference %q", k)
+		}
+		nv, err := validate(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s %v", k, err)
+		}
+		out[k] = nv
+	}
+	return out, nil
+}
+
+// Int returns the integer stored under key, whether it was stored as an int
+// or has been decoded from JSON as a float64.
+func Int(p map[string]any, key string) (int, bool) {
+	switch v := p[key].(type) {
+	case int:
+		return v, true
+	case float64:
+		return int(v), true
+	}
+	return 0, false
+}
+
+// String returns the string stored under key.
+func String(p map[string]any, key string) (string, bool) {
+	s, ok := p[key].(string)
+	return s, ok
+}
+
+// Bool returns the boolean stored under key.
+func Bool(p map[string]any, key string) (bool, bool) {
+	b, ok := p[key].(bool)
+	return b, ok
+}
@@ -0,0 +1,49 @@
+package prefs_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/prefs"
+)
+
+func TestValidate(t *testing.T) {
+	decode := func(s string) map[string]any {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(s), &m); err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	got, err := prefs.Validate(decode(`{"pageSize": 25, "locale": "en-GB", "email": "a@example.com", "emailNotifications": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := prefs.Int(got, prefs.PageSize); !ok || n != 25 {
+		t.Errorf("pageSize = %v, %v", n, ok)
+	}
+	if s, _ := prefs.String(got, prefs.Email); s != "a@example.com" {
+		t.Errorf("email = %q", s)
+	}
+	if b, _ := prefs.Bool(got, prefs.EmailNotifications); !b {
+		t.Error("expected emailNotifications to be true")
+	}
+
+	for _, bad := range []string{
+		`{"theme": "dark"}`,
+		`{"pageSize": 0}`,
+		`{"pageSize": 201}`,
+		`{"pageSize": 2.5}`,
+		`{"pageSize": "10"}`,
+		`{"locale": "english please"}`,
+		`{"email": "Alice <a@example.com>"}`,
+		`{"email": "not an address"}`,
+		`{"emailNotifications": "yes"}`,
+		`{"locale": null}`,
+	} {
+		if _, err := prefs.Validate(decode(bad)); err == nil {
+			t.Errorf("%s: expected an error", bad)
+		}
+	}
+}
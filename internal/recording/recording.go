@@ -0,0 +1,323 @@
+// Package recording captures full request/response pairs to disk so that a
+// client-reported bug can be reproduced locally with cmd/replay.  Recording
+// is off until an administrator starts it, stops itself after a bounded
+// time, and sanitises credentials and personal data before anything is
+// written.
+//
+// A recording is a JSON Lines file with one Exchange per line.
+package recording
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/redact"
+)
+
+// MaxDuration caps how long a recording runs before it stops on its own.
+const MaxDuration = time.Hour
+
+// maxBody bounds how much of each request and response body is kept.
+const maxBody = 1 << 20
+
+// Redacted replaces credentials in recorded headers and bodies.
+const Redacted = "[redacted]"
+
+// secretHeaders are replaced by Redacted; replaying needs fresh credentials.
+var secretHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"}
+
+// secretFields are JSON and form body fields, compared in lower case,
+// replaced by Redacted at any depth.
+var secretFields = map[string]bool{
+	"password": true, "token": true, "secret": true,
+	"clientsecret": true, "client_secret": true, "access_token": true, "code_verifier": true,
+}
+
+// secretFormFields are further form fields replaced by Redacted.  In JSON,
+// "code" is an error code.
+var secretFormFields = map[string]bool{"code": true}
+
+// Exchange is one recorded request and its response.
+type Exchange struct {
+	Time           time.Time       `json:"time"`
+	Method         string          `json:"method"`
+	Path           string          `json:"path"`
+	RequestHeader  http.Header     `json:"requestHeader,omitempty"`
+	RequestBody    json.RawMessage `json:"requestBody,omitempty"`
+	Status         int             `json:"status"`
+	ResponseHeader http.Header     `json:"responseHeader,omitempty"`
+	ResponseBody   json.RawMessage `json:"responseBody,omitempty"`
+	Duration       time.Duration   `json:"durationNs"`
+}
+
+// State describes the current recording.
+type State struct {
+	// File is the recording being written, empty when stopped.
+	File string
+	// Until is when the recording stops on its own.
+	Until time.Time
+}
+
+// Recorder writes exchanges to files in a directory while started.  It is
+// safe for concurrent use.
+type Recorder struct {
+	dir    string
+	redact *redact.Redactor
+
+	mu    sync.Mutex
+	file  *os.File
+	state State
+	timer *time.Timer
+}
+
+// New returns a stopped Recorder that writes into dir, sanitising with r.
+func New(dir string, r *redact.Redactor) *Recorder {
+	return &Recorder{dir: dir, redact: r}
+}
+
+// Start begins a new recording file that stops after d, which is clamped to
+// MaxDuration.  A recording already in progress is stopped first.
+func (rec *Recorder) Start(d time.Duration) (State, error) {
+	if d <= 0 || d > MaxDuration {
+		d = MaxDuration
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.stopLocked()
+
+	if err := os.MkdirAll(rec.dir, 0o700); err != nil {
+		return State{}, fmt.Errorf("recording: %w", err)
+	}
+	now := time.Now().UTC()
+	name := filepath.Join(rec.dir, "recording-"+now.Format("20060102T150405Z")+".jsonl")
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return State{}, fmt.Errorf("recording: %w", err)
+	}
+	rec.file = f
+	rec.state = State{File: name, Until: now.Add(d)}
+
+	var t *time.Timer
+	t = time.AfterFunc(d, func() {
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+		// A later Start or Stop may have replaced this timer after it fired.
+		if rec.timer == t {
+			rec.stopLocked()
+		}
+	})
+	rec.timer = t
+	return rec.state, nil
+}
+
+// Stop ends the current recording, if any.
+func (rec *Recorder) Stop() {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.stopLocked()
+}
+
+func (rec *Recorder) stopLocked() {
+	if rec.timer != nil {
+		rec.timer.Stop()
+		rec.timer = nil
+	}
+	if rec.file != nil {
+		rec.file.Close()
+		rec.file = nil
+	}
+	rec.state = State{}
+}
+
+// State returns the current recording, zero when stopped.
+func (rec *Recorder) State() State {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.state
+}
+
+func (rec *Recorder) active() bool {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.file != nil
+}
+
+// write appends e to the current recording; it is dropped if the recording
+// stopped while the request ran.
+func (rec *Recorder) write(e Exchange) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if rec.file == nil {
+		return nil
+	}
+	_, err = rec.file.Write(append(line, '\n'))
+	return err
+}
+
+// Middleware records every request that passes through it while a
+// recording is in progress.  Paths starting with one of skip (such as the
+// admin endpoints that control recording) are never recorded.
+func (rec *Recorder) Middleware(skip ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !rec.active() {
+			c.Next()
+			return
+		}
+		for _, prefix := range skip {
+			if strings.HasPrefix(c.Request.URL.Path, prefix) {
+				c.Next()
+				return
+			}
+		}
+
+		start := time.Now()
+		var reqBody []byte
+		if c.Request.Body != nil {
+			reqBody, _ = io.ReadAll(io.LimitReader(c.Request.Body, maxBody))
+			c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(reqBody), c.Request.Body))
+		}
+		w := &captureWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+
+		c.Next()
+
+		e := Exchange{
+			Time:           start.UTC(),
+			Method:         c.Request.Method,
+			Path:           rec.redact.String(c.Request.URL.RequestURI()),
+			RequestHeader:  rec.header(c.Request.Header),
+			RequestBody:    rec.body(c.Request.Header.Get("Content-Type"), reqBody),
+			Status:         w.Status(),
+			ResponseHeader: rec.header(w.Header()),
+			ResponseBody:   rec.body(w.Header().Get("Content-Type"), w.body.Bytes()),
+			Duration:       time.Since(start),
+		}
+		if err := rec.write(e); err != nil {
+			_ = c.Error(fmt.Errorf("recording: %w", err))
+		}
+	}
+}
+
+// captureWriter keeps a copy of the first maxBody bytes of the response.
+type captureWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Unwrap returns the writer underneath, for http.ResponseController.
+func (w *captureWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	if room := maxBody - w.body.Len(); room > 0 {
+		w.body.Write(b[:min(len(b), room)])
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (rec *Recorder) header(h http.Header) http.Header {
+	out := h.Clone()
+	for _, name := range secretHeaders {
+		if out.Get(name) != "" {
+			out.Set(name, Redacted)
+		}
+	}
+	return out
+}
+
+// body sanitises a JSON or form body of type contentType.  Other bodies
+// are kept as a JSON string with embedded tokens and emails masked.
+func (rec *Recorder) body(contentType string, b []byte) json.RawMessage {
+	if len(b) == 0 {
+		return nil
+	}
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "application/x-www-form-urlencoded" {
+		if form, err := url.ParseQuery(string(b)); err == nil {
+			raw, _ := json.Marshal(rec.form(form))
+			return raw
+		}
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		raw, _ := json.Marshal(rec.redact.String(string(b)))
+		return raw
+	}
+	raw, err := json.Marshal(rec.value("", v))
+	if err != nil {
+		return nil
+	}
+	return raw
+}
+
+// form sanitises a form body, which is kept as a JSON string.
+func (rec *Recorder) form(form url.Values) string {
+	for key, values := range form {
+		secret := secretFields[strings.ToLower(key)] || secretFormFields[strings.ToLower(key)]
+		for i, v := range values {
+			if secret {
+				values[i] = Redacted
+			} else {
+				values[i] = rec.redact.String(rec.redact.Field(key, v))
+			}
+		}
+	}
+	return form.Encode()
+}
+
+func (rec *Recorder) value(key string, v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for k, item := range x {
+			x[k] = rec.value(k, item)
+		}
+		return x
+	case []interface{}:
+		for i, item := range x {
+			x[i] = rec.value(key, item)
+		}
+		return x
+	case string:
+		if secretFields[strings.ToLower(key)] {
+			return Redacted
+		}
+		return rec.redact.String(rec.redact.Field(key, x))
+	default:
+		return v
+	}
+}
+
+// Load reads the exchanges from a recording file.
+func Load(r io.Reader) ([]Exchange, error) {
+	var out []Exchange
+	dec := json.NewDecoder(r)
+	for {
+		var e Exchange
+		err := dec.Decode(&e)
+		if errors.Is(err, io.EOF) {
+			return out, nil
+		}
+		if err != nil {
+			return out, fmt.Errorf("recording: exchange %d: %w", len(out)+1, err)
+		}
+		out = append(out, e)
+	}
+}
@@ -0,0 +1,128 @@
+package recording_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/recording"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/redact"
+)
+
+func newRouter(rec *recording.Recorder) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(rec.Middleware("/admin/"))
+	r.POST("/login", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"token": "eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiJ4In0.sig", "username": "alice"})
+	})
+	r.POST("/token", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"access_token": "opaque-access", "token_type": "Bearer"})
+	})
+	r.GET("/admin/ping", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	return r
+}
+
+func post(r http.Handler, path, body string) {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret-token")
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestRecorder_RecordsSanitisedExchanges(t *testing.T) {
+	rec := recording.New(t.TempDir(), redact.New(nil, nil, false))
+	r := newRouter(rec)
+
+	post(r, "/login", `{"username":"bob","password":"hunter2"}`) // not recording yet
+	state, err := rec.Start(time.Minute)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	post(r, "/login", `{"username":"alice","password":"hunter2"}`)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/admin/ping", nil))
+	rec.Stop()
+	post(r, "/login", `{"username":"carol","password":"hunter2"}`) // stopped
+
+	f, err := os.Open(state.File)
+	if err != nil {
+		t.Fatalf("open recording: %v", err)
+	}
+	defer f.Close()
+	exchanges, err := recording.Load(f)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(exchanges) != 1 {
+		t.Fatalf("expected 1 exchange, got %d", len(exchanges))
+	}
+	e := exchanges[0]
+	if e.Method != http.MethodPost || e.Path != "/login" || e.Status != http.StatusOK {
+		t.Fatalf("unexpected exchange: %s %s -> %d", e.Method, e.Path, e.Status)
+	}
+	if got := e.RequestHeader.Get("Authorization"); got != recording.Redacted {
+		t.Errorf("Authorization = %q, want redacted", got)
+	}
+	for _, leak := range []string{"hunter2", "alice", "eyJ"} {
+		if strings.Contains(string(e.RequestBody)+string(e.ResponseBody), leak) {
+			t.Errorf("recording leaks %q: %s / %s", leak, e.RequestBody, e.ResponseBody)
+		}
+	}
+	if !strings.Contains(string(e.RequestBody), redact.Pseudonym(nil, "alice")) {
+		t.Errorf("expected the username to be pseudonymised: %s", e.RequestBody)
+	}
+	if rec.State() != (recording.State{}) {
+		t.Errorf("expected stopped state, got %+v", rec.State())
+	}
+}
+
+func TestRecorder_RedactsFormBodies(t *testing.T) {
+	rec := recording.New(t.TempDir(), redact.New(nil, nil, false))
+	r := newRouter(rec)
+	state, err := rec.Start(time.Minute)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/token", strings.NewReader(
+		"grant_type=authorization_code&client_id=app&client_secret=s3cret&code=one-time&code_verifier=pkce-verifier-value"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	rec.Stop()
+
+	f, err := os.Open(state.File)
+	if err != nil {
+		t.Fatalf("open recording: %v", err)
+	}
+	defer f.Close()
+	exchanges, err := recording.Load(f)
+	if err != nil || len(exchanges) != 1 {
+		t.Fatalf("Load: %d exchanges, %v", len(exchanges), err)
+	}
+	e := exchanges[0]
+	for _, leak := range []string{"s3cret", "one-time", "pkce-verifier-value", "opaque-access"} {
+		if strings.Contains(string(e.RequestBody)+string(e.ResponseBody), leak) {
+			t.Errorf("recording leaks %q: %s / %s", leak, e.RequestBody, e.ResponseBody)
+		}
+	}
+	if !strings.Contains(string(e.RequestBody), "grant_type=authorization_code") {
+		t.Errorf("expected the other fields to be kept: %s", e.RequestBody)
+	}
+}
+
+func TestRecorder_StopsOnItsOwn(t *testing.T) {
+	rec := recording.New(t.TempDir(), redact.New(nil, nil, false))
+	if _, err := rec.Start(10 * time.Millisecond); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for rec.State().File != "" {
+		if time.Now().After(deadline) {
+			t.Fatal("recording did not stop")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,65 @@
+package redact_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/redact"
+)
+
+func TestField_SensitiveIsPseudonymised(t *testing.T) {
+	r := redact.New(nil, nil, false)
+	got := r.Field("username", "alice")
+	if got == "alice" || !strings.HasPrefix(got, "anon-") {
+		t.Fatalf("expected pseudonym, got %q", got)
+	}
+	if again := r.Field("Username", "alice"); again != got {
+		t.Fatalf("expected stable pseudonym, got %q and %q", got, again)
+	}
+}
+
+func TestPseudonym_Keyed(t *testing.T) {
+	a := redact.New([]byte("key-a"), nil, false).Field("username", "alice")
+	if a != redact.Pseudonym([]byte("key-a"), "alice") {
+		t.Fatalf("expected the keyed pseudonym, got %q", a)
+	}
+	if b := redact.New([]byte("key-b"), nil, false).Field("username", "alice"); b == a {
+		t.Fatalf("expected pseudonyms to depend on the key, got %q for both", a)
+	}
+	if redact.Pseudonym(nil, "alice") != redact.Pseudonym(nil, "alice") {
+		t.Fatal("expected the process key to give stable pseudonyms")
+	}
+}
+
+func TestField_NonSensitivePassesThrough(t *testing.T) {
+	r := redact.New(nil, nil, false)
+	if got := r.Field("method", "GET"); got != "GET" {
+		t.Fatalf("expected GET, got %q", got)
+	}
+}
+
+func TestField_ExtraFields(t *testing.T) {
+	r := redact.New(nil, []string{"scorer"}, false)
+	if got := r.Field("scorer", "Pelé"); got == "Pelé" {
+		t.Fatal("expected configured field to be redacted")
+	}
+}
+
+func TestString_MasksEmailsAndTokens(t *testing.T) {
+	r := redact.New(nil, nil, false)
+	in := "/api/v1/x/bob@example.com?t=eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiJ4In0.c2ln"
+	got := r.String(in)
+	if strings.Contains(got, "bob@example.com") || strings.Contains(got, "eyJhbGci") {
+		t.Fatalf("expected PII to be masked, got %q", got)
+	}
+}
+
+func TestShowPII_DisablesRedaction(t *testing.T) {
+	r := redact.New(nil, nil, true)
+	if got := r.Field("username", "alice"); got != "alice" {
+		t.Fatalf("expected alice, got %q", got)
+	}
+	if got := r.String("bob@example.com"); got != "bob@example.com" {
+		t.Fatalf("expected email unchanged, got %q", got)
+	}
+}
@@ -0,0 +1,44 @@
+package replay
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Postgres is a Ledger shared by every instance using the same database,
+// kept in the consumed_tokens table (migration 028).  Expired rows are
+// reused on conflict and removed by DeleteExpired.
+type Postgres struct {
+	db *sql.DB
+}
+
+// NewPostgres returns a Ledger backed by db.
+func NewPostgres(db *sql.DB) *Postgres { return &Postgres{db: db} }
+
+// Consume implements Ledger.
+func (p *Postgres) Consume(ctx context.Context, id string, expires time.Time) error {
+	res, err := p.db.ExecContext(ctx,
+		`INSERT INTO consumed_tokens (id, expires_at) VALUES ($1, $2)
+		 ON CONFLICT (id) DO UPDATE SET expires_at = EXCLUDED.expires_at
+		 WHERE consumed_tokens.expires_at <= NOW()`,
+		id, expires)
+	if err != nil {
+		return fmt.Errorf("replay: consume: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrReplayed
+	}
+	return nil
+}
+
+// DeleteExpired removes the IDs whose credentials have expired and returns
+// how many were deleted.
+func (p *Postgres) DeleteExpired(ctx context.Context) (int64, error) {
+	res, err := p.db.ExecContext(ctx, `DELETE FROM consumed_tokens WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("replay: delete expired: %w", err)
+	}
+	return res.RowsAffected()
+}
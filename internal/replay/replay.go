@@ -0,0 +1,70 @@
+// Package replay remembers the IDs of one-shot credentials — a signed
+// request, or a token meant to be used exactly once — until they expire, so
+// that a copy captured in transit cannot be used a second time.  Local
+// remembers them within one process; Postgres shares them between every
+// instance using the same database.
+package replay
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+)
+
+// ErrReplayed is returned by Consume for an ID that was already consumed.
+var ErrReplayed = errors.New("replay: credential already used")
+
+// Ledger records consumed credential IDs.
+type Ledger interface {
+	// Consume records id as used until expires, after which the credential
+	// is refused on other grounds and id may be forgotten.  It returns
+	// ErrReplayed if id was already consumed and has not yet expired.
+	Consume(ctx context.Context, id string, expires time.Time) error
+}
+
+// pruneInterval is how often Local forgets expired IDs.
+const pruneInterval = time.Minute
+
+// Local is a Ledger for a single process.  The zero value is ready to use.
+type Local struct {
+	mu        sync.Mutex
+	clock     clock.Clock
+	seen      map[string]time.Time
+	nextPrune time.Time
+}
+
+// NewLocal returns an in-process Ledger.
+func NewLocal() *Local { return &Local{} }
+
+// SetClock makes the ledger expire IDs against c instead of the wall clock.
+func (l *Local) SetClock(c clock.Clock) {
+	l.mu.Lock()
+	l.clock = c
+	l.mu.Unlock()
+}
+
+// Consume implements Ledger.
+func (l *Local) Consume(_ context.Context, id string, expires time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := clock.Or(l.clock).Now()
+	if now.After(l.nextPrune) {
+		for k, exp := range l.seen {
+			if !exp.After(now) {
+				delete(l.seen, k)
+			}
+		}
+		l.nextPrune = now.Add(pruneInterval)
+	}
+	if exp, ok := l.seen[id]; ok && exp.After(now) {
+		return ErrReplayed
+	}
+	if l.seen == nil {
+		l.seen = make(map[string]time.Time)
+	}
+	l.seen[id] = expires
+	return nil
+}
@@ -0,0 +1,64 @@
+package replay_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/postgres"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/replay"
+)
+
+// oneShot checks that l accepts id once until expires.
+func oneShot(t *testing.T, l replay.Ledger, id string, expires time.Time) {
+	t.Helper()
+	ctx := context.Background()
+	if err := l.Consume(ctx, id, expires); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	if err := l.Consume(ctx, id, expires); !errors.Is(err, replay.ErrReplayed) {
+		t.Fatalf("expected ErrReplayed on second use, got %v", err)
+	}
+	if err := l.Consume(ctx, id+"-other", expires); err != nil {
+		t.Fatalf("other id: %v", err)
+	}
+}
+
+func TestLocal(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	l := replay.NewLocal()
+	l.SetClock(clk)
+	oneShot(t, l, "abc", clk.Now().Add(5*time.Minute))
+
+	// Once the credential has expired its ID is forgotten.
+	clk.Advance(5 * time.Minute)
+	if err := l.Consume(context.Background(), "abc", clk.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("expected an expired ID to be accepted, got %v", err)
+	}
+}
+
+func TestPostgres(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	conn, err := postgres.Connect(dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer conn.Close()
+	l := replay.NewPostgres(conn)
+	id := "test:" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	oneShot(t, l, id, time.Now().Add(time.Minute))
+
+	if err := l.Consume(context.Background(), id+"-expired", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("consume: %v", err)
+	}
+	if n, err := l.DeleteExpired(context.Background()); err != nil || n < 1 {
+		t.Fatalf("DeleteExpired = %d, %v; want at least 1", n, err)
+	}
+}
@@ -0,0 +1,33 @@
+package report
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"text/template"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+var (
+	funcs    = template.FuncMap{"percent": func(f float64) float64 { return f * 100 }}
+	textTmpl = template.Must(template.New("daily.txt").Funcs(funcs).ParseFS(templateFS, "templates/daily.txt"))
+	htmlTmpl = htmltemplate.Must(htmltemplate.New("daily.html").Funcs(htmltemplate.FuncMap(funcs)).ParseFS(templateFS, "templates/daily.html"))
+)
+
+// Text renders rep as plain text, for email.
+func Text(rep models.DailyReport) (string, error) {
+	var b bytes.Buffer
+	err := textTmpl.Execute(&b, rep)
+	return b.String(), err
+}
+
+// HTML renders rep as a standalone HTML page.
+func HTML(rep models.DailyReport) (string, error) {
+	var b bytes.Buffer
+	err := htmlTmpl.Execute(&b, rep)
+	return b.String(), err
+}
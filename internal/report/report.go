@@ -0,0 +1,202 @@
+// Package report produces the daily activity report: records created,
+// changed and deleted, new and active users, and the share of requests that
+// failed with a server error.  Request counts are accumulated per UTC day by
+// CountRequests on every instance and flushed to daily_traffic, so the
+// report covers the whole deployment; reports for past days are stored in
+// daily_reports and returned unchanged afterwards.
+package report
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/async"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// DateLayout formats report dates.
+const DateLayout = "2006-01-02"
+
+// flushInterval is how often request counts are written to daily_traffic.
+const flushInterval = time.Minute
+
+type traffic struct{ requests, serverErrors int64 }
+
+// Reporter generates and stores daily reports.  Create one with New.
+type Reporter struct {
+	db    *sql.DB
+	clock clock.Clock
+
+	mu      sync.Mutex
+	pending map[string]*traffic // by day, not yet flushed
+}
+
+// New returns a Reporter backed by db.
+func New(db *sql.DB) *Reporter {
+	return &Reporter{db: db, clock: clock.System{}, pending: make(map[string]*traffic)}
+}
+
+// SetClock replaces the wall clock used to date requests and decide which
+// days are complete.
+func (r *Reporter) SetClock(c clock.Clock) {
+	r.clock = clock.Or(c)
+}
+
+// CountRequests counts every request, and those answered with a 5xx status,
+// towards the current day's report, leaving out the background copies of
+// Prefer: respond-async requests.
+func (r *Reporter) CountRequests() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if async.Replayed(c.Request.Context()) {
+			// Counted when the request was accepted.
+			return
+		}
+		day := r.clock.Now().UTC().Format(DateLayout)
+		r.mu.Lock()
+		t := r.pending[day]
+		if t == nil {
+			t = &traffic{}
+			r.pending[day] = t
+		}
+		t.requests++
+		if c.Writer.Status() >= 500 {
+			t.serverErrors++
+		}
+		r.mu.Unlock()
+	}
+}
+
+// Flush adds the counts gathered since the last flush to daily_traffic.
+// Counts that fail to write are kept for the next attempt.
+func (r *Reporter) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	pending := r.pending
+	r.pending = make(map[string]*traffic)
+	r.mu.Unlock()
+
+	var errs []error
+	for day, t := range pending {
+		_, err := r.db.ExecContext(ctx, `
+			INSERT INTO daily_traffic (day, requests, server_errors) VALUES ($1, $2, $3)
+			ON CONFLICT (day) DO UPDATE SET
+				requests = daily_traffic.requests + EXCLUDED.requests,
+				server_errors = daily_traffic.server_errors + EXCLUDED.server_errors`,
+			day, t.requests, t.serverErrors)
+		if err != nil {
+			errs = append(errs, err)
+			r.mu.Lock()
+			if cur := r.pending[day]; cur != nil {
+				cur.requests += t.requests
+				cur.serverErrors += t.serverErrors
+			} else {
+				r.pending[day] = t
+			}
+			r.mu.Unlock()
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("report: flush traffic: %w", err)
+	}
+	return nil
+}
+
+// RunFlusher flushes request counts every minute until ctx is done, then
+// once more.
+func (r *Reporter) RunFlusher(ctx context.Context) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := r.Flush(context.WithoutCancel(ctx)); err != nil {
+				log.Print(err)
+			}
+			return
+		case <-ticker.C:
+			if err := r.Flush(ctx); err != nil {
+				log.Print(err)
+			}
+		}
+	}
+}
+
+// Daily returns the report for the UTC day containing day.  A stored
+// report is returned as it is; otherwise the report is generated, and
+// stored if the day is over.
+func (r *Reporter) Daily(ctx context.Context, day time.Time) (models.DailyReport, error) {
+	date := day.UTC().Format(DateLayout)
+	var raw []byte
+	err := r.db.QueryRowContext(ctx, `SELECT report FROM daily_reports WHERE day = $1`, date).Scan(&raw)
+	switch {
+	case err == nil:
+		var rep models.DailyReport
+		if err := json.Unmarshal(raw, &rep); err != nil {
+			return models.DailyReport{}, fmt.Errorf("report: decode stored report %s: %w", date, err)
+		}
+		return rep, nil
+	case errors.Is(err, sql.ErrNoRows):
+		return r.Generate(ctx, day)
+	default:
+		return models.DailyReport{}, fmt.Errorf("report: load %s: %w", date, err)
+	}
+}
+
+// Generate computes the report for the UTC day containing day, replacing
+// any stored one once the day is over.
+func (r *Reporter) Generate(ctx context.Context, day time.Time) (models.DailyReport, error) {
+	start := day.UTC().Truncate(24 * time.Hour)
+	end := start.Add(24 * time.Hour)
+	rep := models.DailyReport{Date: start.Format(DateLayout), GeneratedAt: r.clock.Now().UTC()}
+
+	counts := []struct {
+		dst   *int64
+		query string
+	}{
+		{&rep.TeamsCreated, `SELECT COUNT(*) FROM football_teams WHERE created_at >= $1 AND created_at < $2`},
+		{&rep.TeamsUpdated, `SELECT COUNT(*) FROM football_teams WHERE updated_at >= $1 AND updated_at < $2 AND updated_at > created_at`},
+		{&rep.MatchesCreated, `SELECT COUNT(*) FROM football_matches WHERE created_at >= $1 AND created_at < $2`},
+		{&rep.MatchesUpdated, `SELECT COUNT(*) FROM football_matches WHERE updated_at >= $1 AND updated_at < $2 AND updated_at > created_at`},
+		{&rep.MatchesDeleted, `SELECT COUNT(*) FROM football_match_tombstones WHERE deleted_at >= $1 AND deleted_at < $2`},
+		{&rep.NewUsers, `SELECT COUNT(*) FROM users WHERE created_at >= $1 AND created_at < $2`},
+		{&rep.ActiveUsers, `SELECT COUNT(DISTINCT username) FROM user_sessions
+			WHERE (last_used_at >= $1 AND last_used_at < $2) OR (created_at >= $1 AND created_at < $2)`},
+	}
+	for _, c := range counts {
+		if err := r.db.QueryRowContext(ctx, c.query, start, end).Scan(c.dst); err != nil {
+			return models.DailyReport{}, fmt.Errorf("report: %s: %w", rep.Date, err)
+		}
+	}
+	err := r.db.QueryRowContext(ctx,
+		`SELECT requests, server_errors FROM daily_traffic WHERE day = $1`, rep.Date,
+	).Scan(&rep.Requests, &rep.ServerErrors)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return models.DailyReport{}, fmt.Errorf("report: %s traffic: %w", rep.Date, err)
+	}
+	if rep.Requests > 0 {
+		rep.ErrorRate = float64(rep.ServerErrors) / float64(rep.Requests)
+	}
+
+	if !r.clock.Now().Before(end) {
+		raw, err := json.Marshal(rep)
+		if err != nil {
+			return models.DailyReport{}, err
+		}
+		_, err = r.db.ExecContext(ctx, `
+			INSERT INTO daily_reports (day, report, generated_at) VALUES ($1, $2, $3)
+			ON CONFLICT (day) DO UPDATE SET report = EXCLUDED.report, generated_at = EXCLUDED.generated_at`,
+			rep.Date, raw, rep.GeneratedAt)
+		if err != nil {
+			return models.DailyReport{}, fmt.Errorf("report: store %s: %w", rep.Date, err)
+		}
+	}
+	return rep, nil
+}
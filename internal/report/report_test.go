@@ -0,0 +1,92 @@
+package report_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/postgres"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/report"
+)
+
+func TestRender(t *testing.T) {
+	rep := models.DailyReport{
+		Date: "2024-03-15", MatchesCreated: 4, NewUsers: 2, ActiveUsers: 7,
+		Requests: 1000, ServerErrors: 5, ErrorRate: 0.005,
+		GeneratedAt: time.Date(2024, 3, 16, 0, 5, 0, 0, time.UTC),
+	}
+	text, err := report.Text(rep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html, err := report.HTML(rep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"2024-03-15", "4 created", "2 new, 7 active", "5 server errors (0.50%)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text report missing %q:\n%s", want, text)
+		}
+	}
+	if !strings.Contains(html, "<h1>Daily report for 2024-03-15</h1>") || !strings.Contains(html, "0.50%") {
+		t.Errorf("unexpected HTML report:\n%s", html)
+	}
+}
+
+func TestReporter_Postgres(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	conn, err := postgres.Connect(dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer conn.Close()
+
+	// A day far in the past, so that real traffic does not interfere.
+	day := time.Date(2001, 1, 2, 12, 0, 0, 0, time.UTC)
+	_, _ = conn.Exec(`DELETE FROM daily_traffic WHERE day = '2001-01-02'`)
+	_, _ = conn.Exec(`DELETE FROM daily_reports WHERE day = '2001-01-02'`)
+
+	r := report.New(conn)
+	r.SetClock(clock.NewFake(day))
+	e := gin.New()
+	e.Use(r.CountRequests())
+	e.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+	e.GET("/fail", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+	for _, path := range []string{"/ok", "/ok", "/ok", "/fail"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	ctx := context.Background()
+	if err := r.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Still that day: generated but not stored.
+	rep, err := r.Daily(ctx, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Requests != 4 || rep.ServerErrors != 1 || rep.ErrorRate != 0.25 {
+		t.Errorf("unexpected traffic in %+v", rep)
+	}
+
+	// The next day the report is stored, and later traffic no longer
+	// changes it.
+	r.SetClock(clock.NewFake(day.Add(24 * time.Hour)))
+	if _, err := r.Daily(ctx, day); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = conn.Exec(`UPDATE daily_traffic SET requests = requests + 100 WHERE day = '2001-01-02'`)
+	if rep, err = r.Daily(ctx, day); err != nil || rep.Requests != 4 {
+		t.Errorf("expected the stored report, got %+v, %v", rep, err)
+	}
+}
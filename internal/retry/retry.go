@@ -0,0 +1,64 @@
+// Package retry writes the headers that tell a client when to try a request
+// again after the API turned it away, throttled or too busy to serve it, so
+// that one retry policy in a client covers every such response:
+//
+//	Retry-After: 42
+//	RateLimit-Limit: 600
+//	RateLimit-Remaining: 0
+//	RateLimit-Reset: 42
+//	RateLimit-Policy: 600;w=60
+//
+// Retry-After is RFC 9110's; the RateLimit fields follow the IETF
+// draft-ietf-httpapi-ratelimit-headers.  All counts are in whole seconds.
+package retry
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Limit describes the limit a request ran into.
+type Limit struct {
+	// Limit is how many requests, or requests at once, the limit allows.
+	// Zero leaves out the RateLimit fields, for refusals that are not
+	// counted, such as a dependency being down.
+	Limit int64
+	// Remaining is how many more the caller may make before Reset.
+	Remaining int64
+	// Reset is how long until the limit lets the caller in again.
+	Reset time.Duration
+	// Window is the period Limit counts over, sent in RateLimit-Policy;
+	// zero leaves the policy out, for limits on requests at once and for
+	// windows of varying length such as a calendar month.
+	Window time.Duration
+}
+
+// Headers sets the RateLimit fields describing l on h, for responses the
+// limit let through as well as those it refused.
+func Headers(h http.Header, l Limit) {
+	if l.Limit <= 0 {
+		return
+	}
+	h.Set("RateLimit-Limit", strconv.FormatInt(l.Limit, 10))
+	h.Set("RateLimit-Remaining", strconv.FormatInt(max(l.Remaining, 0), 10))
+	h.Set("RateLimit-Reset", strconv.FormatInt(seconds(l.Reset), 10))
+	if l.Window > 0 {
+		h.Set("RateLimit-Policy", strconv.FormatInt(l.Limit, 10)+";w="+strconv.FormatInt(seconds(l.Window), 10))
+	}
+}
+
+// After sets Retry-After to l.Reset, at least a second, and the RateLimit
+// fields, on h, for a response refusing a request because of l.
+func After(h http.Header, l Limit) {
+	Headers(h, l)
+	h.Set("Retry-After", strconv.FormatInt(max(seconds(l.Reset), 1), 10))
+}
+
+// seconds rounds d up to whole seconds.
+func seconds(d time.Duration) int64 {
+	if d <= 0 {
+		return 0
+	}
+	return int64((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,44 @@
+package retry_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/retry"
+)
+
+func TestAfter(t *testing.T) {
+	h := http.Header{}
+	retry.After(h, retry.Limit{Limit: 600, Remaining: -1, Reset: 41500 * time.Millisecond, Window: time.Minute})
+	for name, want := range map[string]string{
+		"Retry-After":         "42",
+		"RateLimit-Limit":     "600",
+		"RateLimit-Remaining": "0",
+		"RateLimit-Reset":     "42",
+		"RateLimit-Policy":    "600;w=60",
+	} {
+		if got := h.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestAfter_Uncounted(t *testing.T) {
+	h := http.Header{}
+	retry.After(h, retry.Limit{})
+	if got := h.Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want at least a second", got)
+	}
+	if got := h.Get("RateLimit-Limit"); got != "" {
+		t.Errorf("expected no RateLimit fields without a limit, got %q", got)
+	}
+}
+
+func TestHeaders_NoPolicyWithoutWindow(t *testing.T) {
+	h := http.Header{}
+	retry.Headers(h, retry.Limit{Limit: 4, Remaining: 3, Reset: time.Second})
+	if h.Get("RateLimit-Remaining") != "3" || h.Get("RateLimit-Policy") != "" || h.Get("Retry-After") != "" {
+		t.Errorf("unexpected headers %v", h)
+	}
+}
@@ -6,109 +6,861 @@
 //   - Uniform Interface: all resources are identified by versioned URI paths
 //     (/api/v1/…) and accessed through standard HTTP methods.
 //   - Layered System: middleware (RequestID, Logger, CacheControl,
-//     NoSessionState, JWTAuth) runs transparently between the client and handler,
-//     just as a proxy or gateway would.
+//     ConcurrencyLimit, NoSessionState, JWTAuth) runs transparently between
+//     the client and handler, just as a proxy or gateway would.
 //   - Stateless: JWT authentication carries all user identity in the token itself,
 //     eliminating server-side session state.
 package router
 
 import (
 	"database/sql"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/alert"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/analytics"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/app"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/async"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/audit"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/classify"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/codec"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/crypt"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/db/postgres"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/diagnostics"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/errcode"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/flags"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/handlers"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/health"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/inbox"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/lock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/logging"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/metering"
 	"github.com/sc23bd/COMP3011_Coursework1/internal/middleware"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/notify"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/patch"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/recording"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/redact"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/replay"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/report"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/scheduler"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/schema"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/version"
 )
 
+// Config holds the settings needed to build the router.
+type Config struct {
+	// JWTSecret is used to sign and verify JWT tokens.
+	JWTSecret string
+
+	// DB backs the authentication and football routes.  Pass nil only when
+	// running without a database (no routes requiring persistence will be
+	// registered).
+	DB *sql.DB
+
+	// Repositories, when set, serves the authentication and football routes
+	// instead of PostgreSQL repositories built from DB — for example the
+	// in-memory store used by tests.
+	Repositories *db.Repositories
+
+	// HMACKeys maps key IDs to shared secrets for callers that sign requests
+	// instead of presenting a JWT.  Signed requests are disabled when empty.
+	HMACKeys map[string]string
+
+	// HMACMaxBody caps the body of a signed request, which is read whole to
+	// check its signature.  Zero uses middleware.DefaultHMACMaxBody (1 MiB).
+	HMACMaxBody int64
+
+	// ClientCertSubjects maps TLS client-certificate common names to the
+	// user or service account they authenticate as.  Requests over a verified
+	// mapped certificate skip JWT validation.  Ignored when empty.
+	ClientCertSubjects map[string]string
+
+	// AdminUsers lists the usernames granted access to administrative
+	// endpoints such as /debug.  Admin routes are not registered when empty.
+	AdminUsers []string
+
+	// Concurrency bounds how many requests may be in flight at once, both
+	// globally and per route group.  Zero values disable the limit.
+	Concurrency ConcurrencyConfig
+
+	// LogPII disables redaction of usernames, emails and tokens in request
+	// logs.  Intended for local debugging only.
+	LogPII bool
+
+	// PasswordHashing tunes argon2id for new password hashes.  Zero fields
+	// use auth.DefaultArgon2Params.  Existing hashes with other parameters,
+	// and legacy bcrypt hashes, are upgraded at the next successful login.
+	PasswordHashing auth.Argon2Params
+
+	// IntrospectRateLimit caps token introspection calls per service account
+	// per minute.  Zero disables the limit.
+	IntrospectRateLimit int
+
+	// VersionHeader adds an X-API-Version header with the build version to
+	// every response.
+	VersionHeader bool
+
+	// LogLevel is the runtime-adjustable level for request logging.  Nil
+	// means info with no runtime control.
+	LogLevel *logging.Level
+
+	// LogRedactFields names additional log fields to pseudonymise on top of
+	// redact.DefaultFields.
+	LogRedactFields []string
+
+	// LogPseudonymKey keys the pseudonyms that stand in for usernames and
+	// emails in logs.  It must be the same on every instance for one user's
+	// requests to be correlated across them; see redact.Pseudonym.
+	// server.New derives one from JWTSecret when it is empty.
+	LogPseudonymKey []byte
+
+	// FieldKeys encrypts sensitive values, such as the email preference,
+	// where they are stored.  Nil stores them in plain text; server.New
+	// derives a keyring from JWTSecret when it is nil.
+	FieldKeys *crypt.Keyring
+
+	// Transactions sets the isolation level and retry budget for football
+	// write transactions.  Zero fields use postgres.DefaultTxOptions.
+	Transactions postgres.TxOptions
+
+	// Events receives team, match and user lifecycle events after each
+	// change commits.  Nil disables publishing.
+	Events *events.Bus
+
+	// Recording, when set, lets administrators record sanitised
+	// request/response pairs through /admin/recording.  Requires AdminUsers.
+	Recording *recording.Recorder
+
+	// Chaos, when set, injects latency, dropped connections and 500s into a
+	// share of requests.  For resilience testing in staging only.
+	Chaos *middleware.ChaosConfig
+
+	// Locks coordinates operations that must not run concurrently, such
+	// as Elo recalculation.  Nil uses PostgreSQL advisory locks when DB is
+	// set, so the exclusion spans instances, and in-process locks otherwise.
+	Locks lock.Manager
+
+	// Replays records the one-shot credentials already used, such as the
+	// signatures of HMAC-signed requests and OAuth authorization codes, so
+	// that each is accepted once.
+	// Nil uses the consumed_tokens table when DB is set, so a replay is
+	// caught by any instance, and this process's memory otherwise.
+	Replays replay.Ledger
+
+	// Alerts, when set, receives panic and error-spike alerts: an alert is
+	// raised when ErrorAlertThreshold or more 5xx responses are sent within
+	// a minute (zero disables that alert).
+	Alerts              *alert.Alerter
+	ErrorAlertThreshold int
+
+	// Audit, when set, is exported by /audit/export.  Requires AdminUsers.
+	// Recording entries is up to the caller, which subscribes it to Events.
+	Audit *audit.Log
+
+	// Mail, when set, emails notifications to users who opted in through
+	// their preferences.
+	Mail *notify.Queue
+
+	// Reports, when set, counts requests for the daily report and serves
+	// /admin/reports/daily.
+	Reports *report.Reporter
+
+	// Metering, when set, meters each authenticated caller's requests and
+	// serves /me/usage and /admin/usage from Repositories.Metering.  The
+	// caller runs its flusher.
+	Metering *metering.Meter
+
+	// MonthlyRequestQuota, when positive and Metering is set, caps each
+	// authenticated caller's requests per UTC calendar month; callers over
+	// it get 402 everywhere but /me.
+	MonthlyRequestQuota int64
+
+	// QuotaUpgradeURL, when set, is linked as "upgrade" from 402 and 429
+	// quota responses: where a caller can raise their limits.
+	QuotaUpgradeURL string
+
+	// Analytics, when set, aggregates usage per day, route and
+	// pseudonymous user and serves /admin/analytics.
+	Analytics *analytics.Analytics
+
+	// Backups, when set, takes and restores logical backups through
+	// /admin/backups.
+	Backups handlers.Backups
+
+	// Flags switches endpoints and behaviours on and off at runtime, and is
+	// listed and flipped through /admin/flags.  Nil keeps every flag at its
+	// default.
+	Flags *flags.Flags
+
+	// Classifier, when set, screens team names, match venues and goal
+	// scorers before they are stored.  Rejected writes get 422; content it
+	// quarantines is stored and opened in the moderation queue.
+	Classifier classify.Classifier
+
+	// Scheduler, when set, is listed by /admin/jobs.  Requires AdminUsers.
+	Scheduler *scheduler.Scheduler
+
+	// Health backs the /livez, /readyz and /startupz probes.  Nil reports
+	// ready always; pkg/server supplies database-backed checks.
+	Health *health.Probes
+
+	// Clock and IDs replace the wall clock and random session IDs used for
+	// token and session expiry, so tests can assert exact values.  Nil uses
+	// the real ones.
+	Clock clock.Clock
+	IDs   auth.IDGenerator
+
+	// Terms, when its Version is set, records the terms-of-service version
+	// each user accepts, at registration and through /me/terms.
+	Terms TermsConfig
+
+	// PrivateReads requires authentication on the read endpoints too, for
+	// deployments whose data is not public.  The served OpenAPI document
+	// marks those operations as secured.
+	PrivateReads bool
+
+	// CORS sets which browser applications on other origins may call the
+	// API, and on what terms.  Without origins no CORS headers are sent,
+	// and browsers keep pages on other origins from reading responses.
+	CORS CORSConfig
+
+	// Deletes sets how DELETE answers for teams and matches that do not
+	// exist: 404 by default, 204 when idempotent, and 410 with a tombstone
+	// for those that were deleted.
+	Deletes handlers.DeleteOptions
+
+	// FreshAuthMaxAge is how long after proving their password users may
+	// delete or merge teams and matches, restore backups, take content down
+	// and impersonate users; later, they must confirm it again through
+	// /auth/reauthenticate.  Zero uses DefaultFreshAuthMaxAge.
+	FreshAuthMaxAge time.Duration
+
+	// AsyncOperations caps the football mutations running in the
+	// background at once for callers that send Prefer: respond-async.
+	// Requests over the cap are served synchronously.  Zero uses
+	// DefaultAsyncOperations.
+	AsyncOperations int
+
+	// Plugins add middleware and routes on top of the built-in API, in
+	// order.  The server binary passes app.Default.Plugins().
+	Plugins []app.Plugin
+}
+
+// TermsConfig sets the terms of service users must accept.
+type TermsConfig struct {
+	// Version is the terms version in force.  Registration must accept it
+	// and users accept it again through PUT /me/terms after it changes.
+	// Empty disables terms tracking.
+	Version string
+	// Enforce refuses football mutations from users who have not accepted
+	// Version, with 403 Forbidden.
+	Enforce bool
+}
+
+// CORSConfig sets the origins admitted to each group of routes.
+type CORSConfig struct {
+	// ReadOrigins may read the public endpoints — football data,
+	// announcements, schemas, error codes and the version — but not send
+	// Authorization or credentials.  "*" admits any origin.  Ignored with
+	// PrivateReads, as every read then needs a token.
+	ReadOrigins []string
+	// Origins may call every endpoint, signed in with an Authorization
+	// header, including mutations and public reads.
+	Origins []string
+	// Credentials lets Origins also send cookies and client certificates.
+	// Each origin must then be named; "*" is ignored.
+	Credentials bool
+	// MaxAge is how long browsers may cache a preflight result, saving
+	// the extra round trip before each mutation.  Zero uses
+	// DefaultCORSMaxAge.
+	MaxAge time.Duration
+}
+
+// ConcurrencyConfig sets the bulkhead limits applied by the router.
+type ConcurrencyConfig struct {
+	// Global caps in-flight requests across the whole API.
+	Global int
+	// Auth caps in-flight /auth requests; password hashing is CPU- and
+	// memory-bound, so a
+	// burst of logins should not starve the rest of the API.
+	Auth int
+	// Football caps in-flight /football requests, which hold database
+	// connections for their duration.
+	Football int
+	// QueueTimeout is how long a request waits for a free slot before the
+	// limiter responds 503.
+	QueueTimeout time.Duration
+}
+
+// DefaultAsyncOperations is the default cap on background operations.
+const DefaultAsyncOperations = 64
+
+// DefaultCORSMaxAge is how long browsers may cache preflight results by
+// default.
+const DefaultCORSMaxAge = 10 * time.Minute
+
+// DefaultFreshAuthMaxAge is how recent a sign-in destructive operations
+// require by default.
+const DefaultFreshAuthMaxAge = 15 * time.Minute
+
+// hmacMaxSkew bounds how far a signed request's X-Date may drift from the
+// server clock before it is rejected as a possible replay.
+const hmacMaxSkew = 5 * time.Minute
+
+// TokenIssuer is the issuer claim of the JWTs the API mints and accepts.
+const TokenIssuer = "COMP3011_API"
+
 // New returns a configured *gin.Engine.
 //
-// When db is non-nil the router registers authentication and football routes
-// backed by PostgreSQL.  Pass a nil *sql.DB only when running without a
-// database (no routes requiring persistence will be registered).
-//
-// jwtSecret is used to sign and verify JWT tokens.
-func New(jwtSecret string, db *sql.DB) *gin.Engine {
+// When cfg.Repositories is set, or cfg.DB is non-nil, the router registers
+// authentication and football routes backed by them.
+func New(cfg Config) *gin.Engine {
+	r, _ := build(cfg, false)
+	return r
+}
+
+// NewSplit is New with the administrative routes (/api/v1/admin,
+// /api/v1/audit and /debug) moved to a second engine, so that they can be
+// served on a separate port that is firewalled from the public.  Both engines share the same
+// authentication, handlers and log level.
+func NewSplit(cfg Config) (public, admin *gin.Engine) {
+	return build(cfg, true)
+}
+
+func build(cfg Config, split bool) (*gin.Engine, *gin.Engine) {
+	repos := cfg.Repositories
+	if repos == nil && cfg.DB != nil {
+		repos = &db.Repositories{
+			Football:      postgres.NewFootballRepo(cfg.DB, cfg.Transactions),
+			Users:         postgres.NewUserRepo(cfg.DB),
+			Sessions:      postgres.NewSessionRepo(cfg.DB),
+			Logins:        postgres.NewLoginRepo(cfg.DB),
+			Invites:       postgres.NewInviteRepo(cfg.DB),
+			OAuth:         postgres.NewOAuthRepo(cfg.DB),
+			Terms:         postgres.NewTermsRepo(cfg.DB),
+			Preferences:   postgres.NewPreferencesRepo(cfg.DB, cfg.FieldKeys),
+			Notifications: postgres.NewNotificationRepo(cfg.DB),
+			Announcements: postgres.NewAnnouncementRepo(cfg.DB),
+			Moderation:    postgres.NewModerationRepo(cfg.DB),
+			Metering:      postgres.NewMeteringRepo(cfg.DB),
+		}
+	}
+
+	// Users' inboxes are fed from the event bus, so one is created when the
+	// caller supplies none.  The inbox only subscribes before the response,
+	// so nothing is left running that would need waiting for on shutdown.
+	var notifications *inbox.Inbox
+	if repos != nil && repos.Notifications != nil {
+		if cfg.Events == nil {
+			cfg.Events = events.NewBus(nil)
+		}
+		notifications = inbox.New(repos.Notifications, repos.Preferences, cfg.Mail)
+		notifications.Subscribe(cfg.Events)
+	}
+
 	// Initialize JWT service
-	jwtService := auth.NewJWTService(jwtSecret, "COMP3011_API")
+	jwtService := auth.NewJWTService(cfg.JWTSecret, TokenIssuer)
+	jwtService.SetClock(cfg.Clock)
+
+	replays := cfg.Replays
+	switch {
+	case replays != nil:
+	case cfg.DB != nil:
+		replays = replay.NewPostgres(cfg.DB)
+	default:
+		local := replay.NewLocal()
+		local.SetClock(cfg.Clock)
+		replays = local
+	}
+
+	// Protected routes accept a Bearer JWT or, when configured, an
+	// HMAC-signed request or a mapped TLS client certificate.
+	authenticators := middleware.Authenticators{JWT: jwtService}
+	if len(cfg.HMACKeys) > 0 {
+		authenticators.HMAC = auth.NewHMACVerifier(cfg.HMACKeys, hmacMaxSkew)
+		authenticators.HMAC.SetLedger(replays)
+		authenticators.HMAC.SetClock(cfg.Clock)
+		authenticators.HMACMaxBody = cfg.HMACMaxBody
+	}
+	if len(cfg.ClientCertSubjects) > 0 {
+		authenticators.ClientCerts = auth.NewClientCertMapper(cfg.ClientCertSubjects)
+	}
+	if repos != nil {
+		// Tokens bound to a revoked login session are rejected.
+		authenticators.Sessions = repos.Sessions
+	}
+	// Account routes stay reachable over quota, so that callers can see
+	// their usage.
+	requireAccount := middleware.Authenticate(authenticators)
+	quotaLinks := quotaLinks(cfg)
+	if cfg.Metering != nil && cfg.MonthlyRequestQuota > 0 {
+		authenticators.Quota = &middleware.MonthlyQuota{Limit: cfg.MonthlyRequestQuota, Usage: cfg.Metering, Links: quotaLinks}
+	}
+	requireAuth := middleware.Authenticate(authenticators)
+
+	// Football routes also take the tokens users let third-party
+	// applications have, within the scopes they granted; every other
+	// route refuses them.
+	requireAppRead, requireAppWrite := requireAuth, requireAuth
+	if repos != nil && repos.OAuth != nil {
+		delegated := authenticators
+		delegated.Grants = repos.OAuth
+		delegated.Scope = auth.ScopeFootballRead
+		requireAppRead = middleware.Authenticate(delegated)
+		delegated.Scope = auth.ScopeFootballWrite
+		requireAppWrite = middleware.Authenticate(delegated)
+	}
+
+	// Destructive operations also need a recent sign-in.
+	freshAuthMaxAge := cfg.FreshAuthMaxAge
+	if freshAuthMaxAge <= 0 {
+		freshAuthMaxAge = DefaultFreshAuthMaxAge
+	}
+	requireFresh := middleware.RequireFreshAuth(freshAuthMaxAge, cfg.Clock)
+
+	// Read endpoints are public unless the deployment is private; signed-in
+	// callers are still identified, for their preferences.
+	requireRead := middleware.IdentifyBearer(jwtService)
+	if cfg.PrivateReads {
+		requireRead = func(c *gin.Context) {
+			// Keep authenticated reads out of shared caches.
+			c.Header("Cache-Control", "private, max-age=60")
+			requireAppRead(c)
+		}
+	}
+
+	logLevel := cfg.LogLevel
+	if logLevel == nil {
+		logLevel = logging.NewLevel(slog.LevelInfo)
+	}
 
 	r := gin.New()
 
+	// Orchestrator probes are registered before the global middleware so
+	// that they are neither logged nor queued behind the concurrency limit
+	// or subject to chaos: an overloaded instance must still report live.
+	probes := cfg.Health
+	if probes == nil {
+		probes = health.New(nil, nil)
+	}
+	healthHandler := handlers.NewHealthHandler(probes)
+	probeGroup := r.Group("/", middleware.Recovery(cfg.Alerts))
+	probeGroup.GET("/livez", healthHandler.Livez)
+	probeGroup.GET("/readyz", healthHandler.Readyz)
+	probeGroup.GET("/startupz", healthHandler.Startupz)
+
 	// Global middleware — applied to every route (Layered System principle).
 	r.Use(middleware.RequestID())
-	r.Use(middleware.Logger())
+	r.Use(middleware.Logger(redact.New(cfg.LogPseudonymKey, cfg.LogRedactFields, cfg.LogPII), logLevel))
+	cors := middleware.CORS(corsRules(cfg.CORS, cfg.PrivateReads)...)
+	r.Use(cors)
 	r.Use(middleware.CacheControl())
-	r.Use(gin.Recovery())
+	schemas, schemaRoutes, requestRoutes := responseSchemas()
+	errorSchema := schema.Name(models.ErrorResponse{})
+	describe := schema.Describe(schemaBase, schemaRoutes, errorSchema)
+	r.Use(describe)
+	codecs := middleware.Codecs(bodyCodecs(cfg.Plugins), middleware.Documents{
+		Requests:  requestRoutes,
+		Responses: schemaRoutes,
+		Error:     errorSchema,
+	})
+	r.Use(codecs)
+	r.Use(middleware.ErrorCodes())
+	r.Use(middleware.Compact())
+	bodies := mediaTypes(cfg.Plugins)
+	r.Use(bodies)
+	if cfg.Recording != nil {
+		r.Use(cfg.Recording.Middleware("/api/v1/admin/", "/debug/"))
+	}
+	r.Use(middleware.ReadYourWrites())
+	if cfg.Reports != nil {
+		r.Use(cfg.Reports.CountRequests())
+	}
+	if cfg.Analytics != nil {
+		r.Use(cfg.Analytics.Record())
+	}
+	if cfg.Metering != nil {
+		r.Use(cfg.Metering.Record())
+	}
+	r.Use(middleware.ErrorRateAlert(cfg.Alerts, cfg.ErrorAlertThreshold, time.Minute))
+	r.Use(middleware.Recovery(cfg.Alerts))
+	r.Use(middleware.ConcurrencyLimit(cfg.Concurrency.Global, cfg.Concurrency.QueueTimeout))
+	if cfg.Chaos != nil {
+		r.Use(middleware.Chaos(*cfg.Chaos))
+	}
+	if cfg.VersionHeader {
+		r.Use(middleware.VersionHeader(version.Get().String()))
+	}
+	for _, p := range cfg.Plugins {
+		r.Use(p.Middleware...)
+	}
 
 	// Swagger documentation endpoint - serve from local dist folder
 	const swaggerDist = "./docs/dist"
 	if _, err := os.Stat(swaggerDist); err == nil {
 		r.StaticFile("/swagger", filepath.Join(swaggerDist, "index.html"))
-		r.Static("/swagger/", swaggerDist)
+		swagger := serveSwagger(swaggerDist, schemas, cfg.PrivateReads)
+		r.GET("/swagger/*filepath", swagger)
+		r.HEAD("/swagger/*filepath", swagger)
+	}
+
+	// Administrative routes go on the public engine unless split off.
+	// The admin engine has no concurrency limit, chaos or plugins, so
+	// operators can still reach it when the public API is overloaded.
+	adminEngine := r
+	if split {
+		adminEngine = gin.New()
+		adminEngine.Use(middleware.RequestID())
+		adminEngine.Use(middleware.Logger(redact.New(cfg.LogPseudonymKey, cfg.LogRedactFields, cfg.LogPII), logLevel))
+		adminEngine.Use(cors)
+		adminEngine.Use(middleware.CacheControl())
+		adminEngine.Use(describe)
+		adminEngine.Use(codecs)
+		adminEngine.Use(middleware.ErrorCodes())
+		adminEngine.Use(middleware.Compact())
+		adminEngine.Use(bodies)
+		adminEngine.Use(middleware.Recovery(cfg.Alerts))
+		if cfg.VersionHeader {
+			adminEngine.Use(middleware.VersionHeader(version.Get().String()))
+		}
+	}
+
+	// Runtime diagnostics (pprof, expvar) for administrators.
+	if len(cfg.AdminUsers) > 0 {
+		debug := adminEngine.Group("/debug", requireAuth, middleware.RequireAdmin(cfg.AdminUsers))
+		debug.Any("/*path", gin.WrapH(diagnostics.Handler()))
+	}
+
+	if cfg.Audit != nil && len(cfg.AdminUsers) > 0 {
+		auditHandler := handlers.NewAuditHandler(cfg.Audit)
+		adminEngine.GET("/api/v1/audit/export", requireAuth, middleware.RequireAdmin(cfg.AdminUsers), auditHandler.Export)
 	}
 
 	// API v1 route group — versioned URI prefix (Uniform Interface principle).
 	v1 := r.Group("/api/v1")
 
+	// Build metadata is available even without a database.
+	v1.GET("/version", handlers.GetVersion)
+
+	// JSON Schemas of the responses, linked from their X-Schema headers.
+	schemaHandler := handlers.NewSchemaHandler(schemas, schemaBase)
+	v1.GET("/schemas", schemaHandler.ListSchemas)
+	v1.GET("/schemas/:name", schemaHandler.GetSchema)
+	v1.GET("/schemas/football.proto", handlers.GetFootballProto)
+
+	// The codes error responses carry.
+	v1.GET("/errors", handlers.ListErrorCodes)
+
+	// Operator endpoints, restricted to ADMIN_USERS.
+	if len(cfg.AdminUsers) > 0 {
+		adminHandler := handlers.NewAdminHandler(logLevel)
+		admin := adminEngine.Group("/api/v1/admin", requireAuth, middleware.RequireAdmin(cfg.AdminUsers))
+		{
+			admin.GET("/log-level", adminHandler.GetLogLevel)
+			admin.PUT("/log-level", adminHandler.SetLogLevel)
+			if cfg.Recording != nil {
+				adminHandler.SetRecorder(cfg.Recording)
+				admin.GET("/recording", adminHandler.GetRecording)
+				admin.PUT("/recording", adminHandler.SetRecording)
+			}
+			if cfg.Flags != nil {
+				adminHandler.SetFlags(cfg.Flags)
+				admin.GET("/flags", adminHandler.GetFlags)
+				admin.PUT("/flags/:name", adminHandler.SetFlag)
+			}
+			if cfg.Scheduler != nil {
+				adminHandler.SetScheduler(cfg.Scheduler)
+				admin.GET("/jobs", adminHandler.GetJobs)
+			}
+			if cfg.Reports != nil {
+				adminHandler.SetReports(cfg.Reports)
+				admin.GET("/reports/daily", adminHandler.GetDailyReport)
+			}
+			if cfg.Analytics != nil {
+				adminHandler.SetAnalytics(cfg.Analytics)
+				admin.GET("/analytics/endpoints", adminHandler.GetEndpointUsage)
+				admin.GET("/analytics/users", adminHandler.GetUserUsage)
+			}
+			if cfg.Backups != nil {
+				adminHandler.SetBackups(cfg.Backups)
+				admin.GET("/backups", adminHandler.ListBackups)
+				admin.POST("/backups", adminHandler.CreateBackup)
+				admin.GET("/backups/:name", adminHandler.DownloadBackup)
+				admin.POST("/backups/:name/restore", requireFresh, adminHandler.RestoreBackup)
+			}
+		}
+	}
+
 	// All routes require a database connection.
-	if db != nil {
-		users := postgres.NewUserRepo(db)
-		authHandler := handlers.NewAuthHandler(users, jwtService)
+	if repos != nil {
+		users, sessions := repos.Users, repos.Sessions
+		authHandler := handlers.NewAuthHandler(users, sessions, jwtService, auth.NewPasswordHasher(cfg.PasswordHashing))
+		authHandler.SetEvents(cfg.Events)
+		authHandler.SetClock(cfg.Clock)
+		authHandler.SetIDGenerator(cfg.IDs)
+		authHandler.SetFlags(cfg.Flags)
+		authHandler.SetInvites(repos.Invites)
+		terms := repos.Terms
+		if cfg.Terms.Version == "" {
+			terms = nil
+		}
+		authHandler.SetTerms(terms, cfg.Terms.Version)
+		authHandler.SetAdmins(cfg.AdminUsers)
+		authHandler.SetLogins(repos.Logins)
+		authHandler.SetGrants(repos.OAuth)
 
-		// Public authentication routes (no JWT required)
-		authRoutes := v1.Group("/auth")
+		// Public authentication routes (no JWT required), plus token
+		// introspection for service accounts.
+		authRoutes := v1.Group("/auth", middleware.ConcurrencyLimit(cfg.Concurrency.Auth, cfg.Concurrency.QueueTimeout))
 		{
 			authRoutes.POST("/register", authHandler.Register)
 			authRoutes.POST("/login", authHandler.Login)
+			authRoutes.POST("/reauthenticate", requireAccount, authHandler.Reauthenticate)
+			authRoutes.POST("/introspect", requireAuth, middleware.RequireServiceAccount(),
+				middleware.RateLimit(cfg.IntrospectRateLimit, time.Minute, quotaLinks...), authHandler.Introspect)
+		}
+
+		// Support staff act as a user with a short-lived token; what they
+		// do with it is audited under both names.
+		if len(cfg.AdminUsers) > 0 {
+			adminEngine.POST("/api/v1/admin/impersonate", requireAuth, middleware.RequireAdmin(cfg.AdminUsers), requireFresh, authHandler.Impersonate)
+		}
+
+		// Invite codes for invite-only registration, restricted to
+		// ADMIN_USERS like the other operator endpoints.
+		if repos.Invites != nil && len(cfg.AdminUsers) > 0 {
+			inviteHandler := handlers.NewInviteHandler(repos.Invites)
+			inviteHandler.SetIDGenerator(cfg.IDs)
+			invites := adminEngine.Group("/api/v1/admin/invites", requireAuth, middleware.RequireAdmin(cfg.AdminUsers))
+			{
+				invites.GET("", inviteHandler.ListInvites)
+				invites.POST("", inviteHandler.CreateInvite)
+				invites.DELETE("/:code", inviteHandler.DeleteInvite)
+			}
+		}
+
+		// Delegated authorisation: operators register third-party
+		// applications, users approve them and revoke them at /me/apps.
+		var oauthHandler *handlers.OAuthHandler
+		if repos.OAuth != nil {
+			oauthHandler = handlers.NewOAuthHandler(repos.OAuth, jwtService, replays)
+			oauthHandler.SetEvents(cfg.Events)
+			oauthHandler.SetIDGenerator(cfg.IDs)
+			oauth := v1.Group("/oauth", middleware.ConcurrencyLimit(cfg.Concurrency.Auth, cfg.Concurrency.QueueTimeout))
+			{
+				oauth.GET("/authorize", requireAccount, oauthHandler.GetConsent)
+				oauth.POST("/authorize", requireAccount, oauthHandler.Authorize)
+				oauth.POST("/token", oauthHandler.Token)
+			}
+			if len(cfg.AdminUsers) > 0 {
+				clients := adminEngine.Group("/api/v1/admin/oauth/clients", requireAuth, middleware.RequireAdmin(cfg.AdminUsers))
+				{
+					clients.GET("", oauthHandler.ListClients)
+					clients.POST("", oauthHandler.CreateClient)
+					clients.DELETE("/:id", oauthHandler.DeleteClient)
+				}
+			}
+		}
+
+		// Announcements: operators broadcast them to every inbox, and live
+		// banners are listed publicly.
+		if repos.Announcements != nil {
+			announcementHandler := handlers.NewAnnouncementHandler(repos.Announcements)
+			announcementHandler.SetEvents(cfg.Events)
+			announcementHandler.SetClock(cfg.Clock)
+			v1.GET("/announcements", requireRead, announcementHandler.ListBanners)
+			if len(cfg.AdminUsers) > 0 {
+				announcements := adminEngine.Group("/api/v1/admin/announcements", requireAuth, middleware.RequireAdmin(cfg.AdminUsers))
+				{
+					announcements.GET("", announcementHandler.ListAnnouncements)
+					announcements.POST("", announcementHandler.CreateAnnouncement)
+					announcements.DELETE("/:id", announcementHandler.DeleteAnnouncement)
+				}
+			}
+		}
+
+		// Moderation queue for reported teams and matches.
+		var moderation *handlers.ModerationHandler
+		if repos.Moderation != nil {
+			moderation = handlers.NewModerationHandler(repos.Moderation, repos.Football)
+			moderation.SetEvents(cfg.Events)
+			if len(cfg.AdminUsers) > 0 {
+				queue := adminEngine.Group("/api/v1/admin/moderation", requireAuth, middleware.RequireAdmin(cfg.AdminUsers))
+				{
+					queue.GET("", moderation.ModerationQueue)
+					queue.POST("/:kind/:id/dismiss", moderation.Dismiss)
+					queue.POST("/:kind/:id/takedown", requireFresh, moderation.TakeDown)
+				}
+			}
+		}
+
+		var usage *handlers.UsageHandler
+		if cfg.Metering != nil && repos.Metering != nil {
+			usage = handlers.NewUsageHandler(repos.Metering)
+			if len(cfg.AdminUsers) > 0 {
+				adminEngine.GET("/api/v1/admin/usage", requireAuth, middleware.RequireAdmin(cfg.AdminUsers), usage.GetUsageRollup)
+			}
+		}
+
+		// Football mutations made with Prefer: respond-async run on r in
+		// the background and are collected from /me/operations.
+		asyncLimit := cfg.AsyncOperations
+		if asyncLimit <= 0 {
+			asyncLimit = DefaultAsyncOperations
+		}
+		operations := async.New(r, asyncLimit)
+		operations.SetClock(cfg.Clock)
+
+		// Account routes — the authenticated user's own data.
+		accountHandler := handlers.NewAccountHandler(users, sessions)
+		accountHandler.SetLogins(repos.Logins)
+		accountHandler.SetPreferences(repos.Preferences)
+		accountHandler.SetNotifications(repos.Notifications)
+		accountHandler.SetTerms(terms)
+		accountHandler.SetGrants(repos.OAuth)
+		accountHandler.SetModeration(repos.Moderation)
+		accountHandler.SetUsage(repos.Metering)
+		if cfg.Audit != nil {
+			accountHandler.SetAudit(cfg.Audit)
+		}
+		accountHandler.SetClock(cfg.Clock)
+		accountHandler.SetEvents(cfg.Events)
+		me := v1.Group("/me", requireAccount)
+		{
+			me.GET("/export", accountHandler.ExportData)
+			me.GET("/sessions", accountHandler.ListSessions)
+			me.DELETE("/sessions/:id", accountHandler.RevokeSession)
+			if repos.Logins != nil {
+				me.GET("/logins", accountHandler.ListLogins)
+			}
+			if terms != nil {
+				termsHandler := handlers.NewTermsHandler(terms, cfg.Terms.Version)
+				termsHandler.SetEvents(cfg.Events)
+				me.GET("/terms", termsHandler.GetTerms)
+				me.PUT("/terms", termsHandler.AcceptTerms)
+			}
+			if repos.Preferences != nil {
+				prefsHandler := handlers.NewPreferencesHandler(repos.Preferences)
+				prefsHandler.SetEvents(cfg.Events)
+				prefsHandler.SetVerification(jwtService, cfg.Mail)
+				me.GET("/preferences", prefsHandler.GetPreferences)
+				me.PUT("/preferences", prefsHandler.PutPreferences)
+				me.POST("/preferences/email/verify", prefsHandler.VerifyEmail)
+			}
+			if notifications != nil {
+				notificationHandler := handlers.NewNotificationHandler(repos.Notifications)
+				notificationHandler.SetPreferences(repos.Preferences)
+				notificationHandler.SetEvents(cfg.Events)
+				me.GET("/notifications", notificationHandler.ListNotifications)
+				me.POST("/notifications/read", notificationHandler.MarkAllNotificationsRead)
+				me.POST("/notifications/:id/read", notificationHandler.MarkNotificationRead)
+			}
+			if usage != nil {
+				me.GET("/usage", usage.GetMyUsage)
+			}
+			me.GET("/operations/:id", handlers.NewOperationHandler(operations).GetOperation)
+			if oauthHandler != nil {
+				me.GET("/apps", oauthHandler.ListApps)
+				me.DELETE("/apps/:clientId", oauthHandler.RevokeApp)
+			}
 		}
 
-		// Football routes - read operations are public, mutations require JWT.
-		fh := handlers.NewFootballHandler(postgres.NewFootballRepo(db))
-		football := v1.Group("/football")
+		// Football routes - read operations are public (unless PrivateReads),
+		// mutations require JWT.
+		fh := handlers.NewFootballHandler(repos.Football)
+		fh.SetEvents(cfg.Events)
+		fh.SetPreferences(repos.Preferences)
+		fh.SetDeleteOptions(cfg.Deletes)
+		if cfg.Classifier != nil {
+			fh.SetClassifier(cfg.Classifier, repos.Moderation)
+		}
+		switch {
+		case cfg.Locks != nil:
+			fh.SetLocks(cfg.Locks)
+		case cfg.DB != nil:
+			fh.SetLocks(lock.NewPostgres(cfg.DB))
+		}
+		football := v1.Group("/football", middleware.ConcurrencyLimit(cfg.Concurrency.Football, cfg.Concurrency.QueueTimeout))
 		{
-			// Public read endpoints
-			football.GET("/teams", fh.ListTeams)
-			football.GET("/teams/:id", fh.GetTeam)
-			football.GET("/teams/:id/history", fh.GetTeamHistory)
-			football.GET("/teams/:id/elo", fh.GetTeamElo)
-			football.GET("/teams/:id/elo/timeline", fh.GetTeamEloTimeline)
+			// Read endpoints
+			reads := football.Group("", requireRead)
+			reads.GET("/teams", fh.ListTeams)
+			reads.GET("/teams/:id", fh.GetTeam)
+			reads.GET("/teams/slug/:slug", fh.GetTeamBySlug)
+			reads.GET("/teams/:id/history", fh.GetTeamHistory)
+			reads.GET("/teams/:id/translations", fh.GetTeamTranslations)
+			reads.GET("/teams/:id/elo", fh.GetTeamElo)
+			reads.GET("/teams/:id/elo/timeline", fh.GetTeamEloTimeline)
 
-			football.GET("/tournaments", fh.ListTournaments)
+			reads.GET("/tournaments", fh.ListTournaments)
 
-			football.GET("/matches", fh.ListMatches)
-			football.GET("/matches/:id", fh.GetMatch)
-			football.GET("/matches/:id/goals", fh.GetMatchGoals)
-			football.GET("/matches/:id/shootout", fh.GetMatchShootout)
+			reads.GET("/matches", fh.ListMatches)
+			reads.GET("/matches/changes", fh.MatchChanges)
+			reads.GET("/matches/:id", fh.GetMatch)
+			reads.GET("/matches/:id/goals", fh.GetMatchGoals)
+			reads.GET("/matches/:id/shootout", fh.GetMatchShootout)
 
-			football.GET("/head-to-head", fh.GetHeadToHead)
+			reads.GET("/head-to-head", fh.GetHeadToHead)
 
-			football.GET("/players/:name/goals", fh.GetPlayerGoals)
+			reads.GET("/players/:name/goals", fh.GetPlayerGoals)
 
-			football.GET("/rankings/elo", fh.GetEloRankings)
+			reads.GET("/rankings/elo", fh.GetEloRankings)
 
-			// Protected mutation endpoints (JWT required)
-			football.POST("/teams", middleware.JWTAuth(jwtService), fh.CreateTeam)
-			football.PUT("/teams/:id", middleware.JWTAuth(jwtService), fh.UpdateTeam)
-			football.DELETE("/teams/:id", middleware.JWTAuth(jwtService), fh.DeleteTeam)
+			// Protected mutation endpoints (authentication required —
+			// football:write for applications — and the current terms
+			// when enforced), honouring Prefer
+			writes := football.Group("", requireAppWrite)
+			if terms != nil && cfg.Terms.Enforce {
+				writes.Use(middleware.RequireTerms(terms, cfg.Terms.Version))
+			}
+			writes.Use(middleware.Prefer(operations))
+			writes.POST("/teams", fh.CreateTeam)
+			writes.PUT("/teams/:id", fh.UpdateTeam)
+			writes.DELETE("/teams/:id", requireFresh, fh.DeleteTeam)
+			writes.POST("/teams/:id/merge-into/:target", requireFresh, fh.MergeTeam)
+			writes.PUT("/teams/:id/translations/:lang", fh.SetTeamTranslation)
+			writes.DELETE("/teams/:id/translations/:lang", fh.DeleteTeamTranslation)
 
-			football.POST("/matches", middleware.JWTAuth(jwtService), fh.CreateMatch)
-			football.PUT("/matches/:id", middleware.JWTAuth(jwtService), fh.UpdateMatch)
-			football.DELETE("/matches/:id", middleware.JWTAuth(jwtService), fh.DeleteMatch)
+			writes.POST("/matches", fh.CreateMatch)
+			writes.PUT("/matches/:id", fh.UpdateMatch)
+			writes.PATCH("/matches/:id", fh.PatchMatch)
+			writes.DELETE("/matches/:id", requireFresh, fh.DeleteMatch)
 
-			football.POST("/matches/:id/goals", middleware.JWTAuth(jwtService), fh.CreateGoal)
-			football.DELETE("/matches/:id/goals/:goalId", middleware.JWTAuth(jwtService), fh.DeleteGoal)
+			writes.POST("/matches/:id/goals", fh.CreateGoal)
+			writes.DELETE("/matches/:id/goals/:goalId", fh.DeleteGoal)
 
-			football.POST("/matches/:id/shootout", middleware.JWTAuth(jwtService), fh.CreateShootout)
-			football.DELETE("/matches/:id/shootout", middleware.JWTAuth(jwtService), fh.DeleteShootout)
+			writes.POST("/matches/:id/shootout", fh.CreateShootout)
+			writes.DELETE("/matches/:id/shootout", fh.DeleteShootout)
 
-			football.POST("/rankings/elo/recalculate", middleware.JWTAuth(jwtService), fh.RecalculateEloRankings)
+			writes.POST("/rankings/elo/recalculate",
+				middleware.RequireFlag(cfg.Flags, flags.EloRecalculation), fh.RecalculateEloRankings)
 
-			football.POST("/matches/simulate", middleware.JWTAuth(jwtService), fh.SimulateMatch)
+			writes.POST("/matches/simulate",
+				middleware.RequireFlag(cfg.Flags, flags.MatchSimulation), fh.SimulateMatch)
+
+			// Content reports, reviewed through /admin/moderation.
+			if moderation != nil {
+				writes.POST("/teams/:id/report", moderation.ReportTeam)
+				writes.POST("/matches/:id/report", moderation.ReportMatch)
+			}
+		}
+
+		// Plugin routes, registered last so they cannot shadow built-in ones
+		// (gin panics on a duplicate route).
+		for _, p := range cfg.Plugins {
+			if p.Routes != nil {
+				p.Routes(app.RouteContext{API: v1, DB: cfg.DB, RequireAuth: requireAuth, RequireRead: requireRead, Events: cfg.Events})
+			}
 		}
 	}
 
@@ -132,12 +884,103 @@ func New(jwtSecret string, db *sql.DB) *gin.Engine {
 		r.NoRoute(func(c *gin.Context) {
 			path := c.Request.URL.Path
 			if strings.HasPrefix(path, "/api/") || strings.HasPrefix(path, "/swagger/") {
-				c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+				c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "not found", Code: errcode.NotFound})
 				return
 			}
 			c.File(filepath.Join(frontendDist, "index.html"))
 		})
 	}
 
-	return r
+	return r, adminEngine
+}
+
+// quotaLinks returns the links attached to quota-exceeded responses: the
+// caller's usage when it is metered, and the upgrade page when configured.
+func quotaLinks(cfg Config) []models.Link {
+	var links []models.Link
+	if cfg.Metering != nil {
+		links = append(links, models.Link{Rel: "usage", Href: "/api/v1/me/usage", Method: http.MethodGet})
+	}
+	if cfg.QuotaUpgradeURL != "" {
+		links = append(links, models.Link{Rel: "upgrade", Href: cfg.QuotaUpgradeURL, Method: http.MethodGet})
+	}
+	return links
+}
+
+// bodyCodecs returns the built-in codecs followed by the plugins' ones.
+func bodyCodecs(plugins []app.Plugin) []codec.Codec {
+	codecs := codec.Default()
+	for _, p := range plugins {
+		codecs = append(codecs, p.Codecs...)
+	}
+	return codecs
+}
+
+// corsRules returns the CORS rules for cfg: Origins on every API route,
+// then ReadOrigins on the public reads.
+func corsRules(cfg CORSConfig, privateReads bool) []middleware.CORSRule {
+	maxAge := cfg.MaxAge
+	if maxAge == 0 {
+		maxAge = DefaultCORSMaxAge
+	}
+	// Response headers pages may read besides the CORS-safelisted ones.
+	expose := []string{
+		"Location", "Content-Location", "Link", "Retry-After", "WWW-Authenticate",
+		"Accept-Patch", "Accept-Post", "X-Request-ID", "X-API-Version", "X-Schema",
+		"X-Cache-Status", "X-Elo-Computed-At", middleware.ConsistencyTokenHeader,
+		middleware.PreferenceAppliedHeader,
+		"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy",
+	}
+	var rules []middleware.CORSRule
+	if len(cfg.Origins) > 0 {
+		rules = append(rules, middleware.CORSRule{
+			Prefixes: []string{"/api/v1"},
+			Methods: []string{http.MethodGet, http.MethodHead, http.MethodPost,
+				http.MethodPut, http.MethodPatch, http.MethodDelete},
+			Policy: middleware.CORSPolicy{
+				Origins:     cfg.Origins,
+				Credentials: cfg.Credentials,
+				Headers:     []string{"Authorization", "Content-Type", "Prefer", middleware.ConsistencyTokenHeader},
+				Expose:      expose,
+				MaxAge:      maxAge,
+			},
+		})
+	}
+	if len(cfg.ReadOrigins) > 0 && !privateReads {
+		rules = append(rules, middleware.CORSRule{
+			Prefixes: []string{"/api/v1/football", "/api/v1/announcements", "/api/v1/schemas",
+				"/api/v1/errors", "/api/v1/version"},
+			Methods: []string{http.MethodGet, http.MethodHead},
+			Policy: middleware.CORSPolicy{
+				Origins: cfg.ReadOrigins,
+				Headers: []string{middleware.ConsistencyTokenHeader},
+				Expose:  expose,
+				MaxAge:  maxAge,
+			},
+		})
+	}
+	return rules
+}
+
+// mediaTypes refuses request bodies the handlers cannot read: JSON, or an
+// encoding middleware.Codecs converts to JSON, for POST and PUT, and JSON
+// Patch or Merge Patch for PATCH, plus any types the plugins read.  The
+// OAuth token endpoint reads forms, as RFC 6749 requires.
+func mediaTypes(plugins []app.Plugin) gin.HandlerFunc {
+	var extra []string
+	for _, p := range plugins {
+		extra = append(extra, p.MediaTypes...)
+	}
+	json := []string{"application/json"}
+	for _, cd := range bodyCodecs(plugins) {
+		json = append(json, cd.MediaType())
+	}
+	json = append(json, extra...)
+	return middleware.ContentTypes(map[string][]string{
+		http.MethodPost:  json,
+		http.MethodPut:   json,
+		http.MethodPatch: append([]string{patch.MediaType, patch.MergeMediaType}, extra...),
+
+		http.MethodPost + " /api/v1/oauth/token": {"application/x-www-form-urlencoded"},
+	})
 }
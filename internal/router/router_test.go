@@ -0,0 +1,729 @@
+package router_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/codec"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/memory"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/metering"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/router"
+)
+
+func TestRouter_PrivateReads(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// The Swagger UI is served from ./docs/dist relative to the repository.
+	t.Chdir("../..")
+
+	token, err := auth.NewJWTService("secret", router.TokenIssuer).GenerateToken("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(r http.Handler, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	public := router.New(router.Config{JWTSecret: "secret", Repositories: memory.New().Repositories()})
+	if w := get(public, "/api/v1/football/teams", ""); w.Code != http.StatusOK {
+		t.Fatalf("public: expected 200, got %d", w.Code)
+	}
+
+	private := router.New(router.Config{JWTSecret: "secret", Repositories: memory.New().Repositories(), PrivateReads: true})
+	if w := get(private, "/api/v1/football/teams", ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("private without token: expected 401, got %d", w.Code)
+	}
+	w := get(private, "/api/v1/football/teams", token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("private with token: expected 200, got %d", w.Code)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "private, max-age=60" {
+		t.Fatalf("expected a private Cache-Control, got %q", cc)
+	}
+	if w := get(private, "/api/v1/version", ""); w.Code != http.StatusOK {
+		t.Fatalf("version: expected 200, got %d", w.Code)
+	}
+
+	w = get(private, "/swagger/swagger.json", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("swagger.json: expected 200, got %d", w.Code)
+	}
+	var doc struct {
+		Paths map[string]map[string]struct {
+			Security []map[string][]string `json:"security"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Paths["/football/teams"]["get"].Security) == 0 {
+		t.Fatal("expected GET /football/teams to be marked as secured")
+	}
+	if len(doc.Paths["/auth/login"]["post"].Security) != 0 {
+		t.Fatal("expected login to stay open")
+	}
+	if w := get(private, "/swagger/swagger-ui.css", ""); w.Code != http.StatusOK {
+		t.Fatalf("swagger UI: expected 200, got %d", w.Code)
+	}
+}
+
+func TestRouter_Notifications(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := router.New(router.Config{JWTSecret: "secret", Repositories: memory.New().Repositories()})
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	creds := `{"username":"alice","password":"password123"}`
+	if w := do(http.MethodPost, "/api/v1/auth/register", "", creds); w.Code != http.StatusCreated {
+		t.Fatalf("register: %d %s", w.Code, w.Body)
+	}
+	if w := do(http.MethodPost, "/api/v1/auth/login", "", creds); w.Code != http.StatusOK {
+		t.Fatalf("login: %d %s", w.Code, w.Body)
+	}
+	// Only the second login, from another device, is worth a warning.
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(creds))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "curl/8.5.0")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var login models.LoginResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &login); err != nil || login.Token == "" {
+		t.Fatalf("login: %d %s", w.Code, w.Body)
+	}
+	w = do(http.MethodGet, "/api/v1/me/logins", login.Token, "")
+	var logins models.LoginListResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &logins); err != nil || len(logins.Logins) != 2 ||
+		logins.Logins[0].Device != "curl" || !logins.Logins[0].NewDevice || logins.Logins[1].NewDevice {
+		t.Fatalf("logins: %d %s", w.Code, w.Body)
+	}
+
+	list := func(query string) models.NotificationListResponse {
+		t.Helper()
+		w := do(http.MethodGet, "/api/v1/me/notifications"+query, login.Token, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("list: %d %s", w.Code, w.Body)
+		}
+		var resp models.NotificationListResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	// Registration and the second login each left a notification, newest
+	// first.
+	resp := list("?limit=1")
+	if resp.Unread != 2 || len(resp.Data) != 1 || resp.Data[0].Type != "sign-in" {
+		t.Fatalf("unexpected first page %+v", resp)
+	}
+	resp = list("?limit=1&offset=1")
+	if len(resp.Data) != 1 || resp.Data[0].Type != "welcome" {
+		t.Fatalf("unexpected second page %+v", resp)
+	}
+
+	path := fmt.Sprintf("/api/v1/me/notifications/%d/read", resp.Data[0].ID)
+	if w := do(http.MethodPost, path, login.Token, ""); w.Code != http.StatusOK {
+		t.Fatalf("mark read: %d %s", w.Code, w.Body)
+	}
+	if resp = list("?unread=true"); resp.Unread != 1 || len(resp.Data) != 1 || resp.Data[0].Type != "sign-in" {
+		t.Fatalf("unexpected unread page %+v", resp)
+	}
+	if w := do(http.MethodPost, "/api/v1/me/notifications/read", login.Token, ""); w.Code != http.StatusNoContent {
+		t.Fatalf("mark all read: %d %s", w.Code, w.Body)
+	}
+	if resp = list(""); resp.Unread != 0 || len(resp.Data) != 2 {
+		t.Fatalf("unexpected inbox %+v", resp)
+	}
+	if w := do(http.MethodPost, "/api/v1/me/notifications/999/read", login.Token, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown notification: expected 404, got %d", w.Code)
+	}
+}
+
+func TestRouter_Announcements(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(now)
+	store := memory.New()
+	store.SetClock(clk)
+	r := router.New(router.Config{JWTSecret: "secret", Repositories: store.Repositories(), AdminUsers: []string{"carol"}, Clock: clk})
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	login := func(username string) string {
+		t.Helper()
+		creds := fmt.Sprintf(`{"username":%q,"password":"password123"}`, username)
+		do(http.MethodPost, "/api/v1/auth/register", "", creds)
+		var resp models.LoginResponse
+		if err := json.Unmarshal(do(http.MethodPost, "/api/v1/auth/login", "", creds).Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Token
+	}
+	admin, alice := login("carol"), login("alice")
+	do(http.MethodPost, "/api/v1/me/notifications/read", alice, "")
+
+	announce := func(body string) models.Announcement {
+		t.Helper()
+		w := do(http.MethodPost, "/api/v1/admin/announcements", admin, body)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("announce: %d %s", w.Code, w.Body)
+		}
+		var a models.Announcement
+		if err := json.Unmarshal(w.Body.Bytes(), &a); err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	announcements := func(token string) []models.Notification {
+		t.Helper()
+		var resp models.NotificationListResponse
+		if err := json.Unmarshal(do(http.MethodGet, "/api/v1/me/notifications?unread=true", token, "").Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Data
+	}
+	banners := func() []models.Announcement {
+		t.Helper()
+		var resp models.AnnouncementListResponse
+		if err := json.Unmarshal(do(http.MethodGet, "/api/v1/announcements", "", "").Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Data
+	}
+
+	if w := do(http.MethodPost, "/api/v1/admin/announcements", alice, `{"title":"hi"}`); w.Code != http.StatusForbidden {
+		t.Fatalf("non-admin: expected 403, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/api/v1/admin/announcements", admin,
+		fmt.Sprintf(`{"title":"x","expiresAt":%q}`, now.Add(-time.Hour).Format(time.RFC3339))); w.Code != http.StatusBadRequest {
+		t.Fatalf("expired: expected 400, got %d", w.Code)
+	}
+
+	announce(`{"title":"Welcome, everyone"}`)
+	maintenance := announce(fmt.Sprintf(`{"title":"Maintenance tonight","banner":true,"publishAt":%q,"expiresAt":%q}`,
+		now.Add(time.Hour).Format(time.RFC3339), now.Add(3*time.Hour).Format(time.RFC3339)))
+
+	if got := announcements(alice); len(got) != 1 || got[0].Title != "Welcome, everyone" {
+		t.Fatalf("before publishAt: unexpected inbox %+v", got)
+	}
+	if got := banners(); len(got) != 0 {
+		t.Fatalf("before publishAt: unexpected banners %+v", got)
+	}
+
+	clk.Advance(2 * time.Hour)
+	if got := announcements(alice); len(got) != 2 || got[0].Title != "Maintenance tonight" {
+		t.Fatalf("after publishAt: unexpected inbox %+v", got)
+	}
+	if got := banners(); len(got) != 1 || got[0].ID != maintenance.ID || got[0].CreatedBy != "" {
+		t.Fatalf("after publishAt: unexpected banners %+v", got)
+	}
+
+	clk.Advance(2 * time.Hour)
+	if got := announcements(alice); len(got) != 1 {
+		t.Fatalf("after expiresAt: unexpected inbox %+v", got)
+	}
+	if got := banners(); len(got) != 0 {
+		t.Fatalf("after expiresAt: unexpected banners %+v", got)
+	}
+
+	// Deleting an announcement withdraws it from inboxes.
+	var list models.AnnouncementListResponse
+	if err := json.Unmarshal(do(http.MethodGet, "/api/v1/admin/announcements", admin, "").Body.Bytes(), &list); err != nil || len(list.Data) != 2 {
+		t.Fatalf("list: %v %+v", err, list)
+	}
+	if w := do(http.MethodDelete, fmt.Sprintf("/api/v1/admin/announcements/%d", list.Data[1].ID), admin, ""); w.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d", w.Code)
+	}
+	if got := announcements(alice); len(got) != 0 {
+		t.Fatalf("after delete: unexpected inbox %+v", got)
+	}
+}
+
+func TestRouter_FreshAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	clk := clock.NewFake(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+	store := memory.New()
+	store.SetClock(clk)
+	r := router.New(router.Config{JWTSecret: "secret", Repositories: store.Repositories(), Clock: clk})
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	token := func(w *httptest.ResponseRecorder) string {
+		t.Helper()
+		var resp models.LoginResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Token == "" {
+			t.Fatalf("no token in %d %s", w.Code, w.Body)
+		}
+		return resp.Token
+	}
+	creds := `{"username":"alice","password":"password123"}`
+	do(http.MethodPost, "/api/v1/auth/register", "", creds)
+	alice := token(do(http.MethodPost, "/api/v1/auth/login", "", creds))
+
+	create := func() string {
+		t.Helper()
+		w := do(http.MethodPost, "/api/v1/football/teams", alice, `{"name":"Atlantis"}`)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("create: %d %s", w.Code, w.Body)
+		}
+		return w.Header().Get("Location")
+	}
+
+	// Straight after signing in, deleting needs nothing more.
+	if w := do(http.MethodDelete, create(), alice, ""); w.Code != http.StatusNoContent {
+		t.Fatalf("fresh delete: expected 204, got %d %s", w.Code, w.Body)
+	}
+
+	team := create()
+	clk.Advance(router.DefaultFreshAuthMaxAge + time.Minute)
+	w := do(http.MethodDelete, team, alice, "")
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Header().Get("WWW-Authenticate"), "insufficient_user_authentication") {
+		t.Fatalf("stale delete: expected 401 with a challenge, got %d %q", w.Code, w.Header().Get("WWW-Authenticate"))
+	}
+	// Other writes are unaffected.
+	if w := do(http.MethodPut, team, alice, `{"name":"Lemuria"}`); w.Code != http.StatusOK {
+		t.Fatalf("stale update: expected 200, got %d %s", w.Code, w.Body)
+	}
+
+	if w := do(http.MethodPost, "/api/v1/auth/reauthenticate", alice, `{"password":"wrong-password"}`); w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong password: expected 401, got %d", w.Code)
+	}
+	fresh := token(do(http.MethodPost, "/api/v1/auth/reauthenticate", alice, `{"password":"password123"}`))
+	if w := do(http.MethodDelete, team, fresh, ""); w.Code != http.StatusNoContent {
+		t.Fatalf("reauthenticated delete: expected 204, got %d %s", w.Code, w.Body)
+	}
+}
+
+func TestRouter_OAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := memory.New()
+	r := router.New(router.Config{JWTSecret: "secret", Repositories: store.Repositories(), AdminUsers: []string{"carol"}})
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	login := func(username string) string {
+		t.Helper()
+		creds := fmt.Sprintf(`{"username":%q,"password":"password123"}`, username)
+		do(http.MethodPost, "/api/v1/auth/register", "", creds)
+		var resp models.LoginResponse
+		if err := json.Unmarshal(do(http.MethodPost, "/api/v1/auth/login", "", creds).Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Token
+	}
+	admin, alice := login("carol"), login("alice")
+
+	w := do(http.MethodPost, "/api/v1/admin/oauth/clients", admin,
+		`{"name":"Fixture Tracker","redirectUris":["https://tracker.example/cb"]}`)
+	var client models.OAuthClientCreatedResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &client); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("register client: %d %s", w.Code, w.Body)
+	}
+
+	w = do(http.MethodPost, "/api/v1/oauth/authorize", alice, fmt.Sprintf(
+		`{"clientId":%q,"redirectUri":"https://tracker.example/cb","scope":"football:read football:write","approve":true}`, client.ID))
+	var consent models.AuthorizeResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &consent); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("authorize: %d %s", w.Code, w.Body)
+	}
+	redirect, _ := url.Parse(consent.RedirectTo)
+
+	form := url.Values{"grant_type": {"authorization_code"}, "code": {redirect.Query().Get("code")},
+		"redirect_uri": {"https://tracker.example/cb"}, "client_id": {client.ID}, "client_secret": {client.ClientSecret}}
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/oauth/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var token models.OAuthTokenResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &token); err != nil || token.AccessToken == "" {
+		t.Fatalf("token: %d %s", w.Code, w.Body)
+	}
+	app := token.AccessToken
+
+	// The application reaches football data as alice...
+	if w := do(http.MethodPost, "/api/v1/football/teams", app, `{"name":"Atlantis"}`); w.Code != http.StatusCreated {
+		t.Fatalf("app write: expected 201, got %d %s", w.Code, w.Body)
+	}
+	// ...but neither her account nor destructive operations.
+	if w := do(http.MethodGet, "/api/v1/me/apps", app, ""); w.Code != http.StatusForbidden {
+		t.Fatalf("app on /me: expected 403, got %d", w.Code)
+	}
+	if w := do(http.MethodDelete, "/api/v1/football/teams/1", app, ""); w.Code != http.StatusForbidden {
+		t.Fatalf("app delete: expected 403, got %d", w.Code)
+	}
+
+	if w := do(http.MethodDelete, "/api/v1/me/apps/"+client.ID, alice, ""); w.Code != http.StatusNoContent {
+		t.Fatalf("revoke: expected 204, got %d %s", w.Code, w.Body)
+	}
+	w = do(http.MethodPost, "/api/v1/football/teams", app, `{"name":"Lemuria"}`)
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "APP_ACCESS_REVOKED") {
+		t.Fatalf("revoked app: expected 401, got %d %s", w.Code, w.Body)
+	}
+}
+
+func TestRouter_CORS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := router.New(router.Config{JWTSecret: "secret", Repositories: memory.New().Repositories(), CORS: router.CORSConfig{
+		ReadOrigins: []string{"*"},
+		Origins:     []string{"https://app.example"},
+		Credentials: true,
+	}})
+	preflight := func(method, path, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodOptions, path, nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", method)
+		req.Header.Set("Access-Control-Request-Headers", "authorization, content-type")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := preflight(http.MethodPost, "/api/v1/football/teams", "https://app.example")
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Credentials") != "true" ||
+		w.Header().Get("Access-Control-Max-Age") != "600" || !strings.Contains(w.Header().Get("Access-Control-Allow-Headers"), "Authorization") {
+		t.Fatalf("app preflight: got %d %v", w.Code, w.Header())
+	}
+	// Any site may read public data, but not write it or reach accounts.
+	if w := preflight(http.MethodGet, "/api/v1/football/teams", "https://fans.example"); w.Code != http.StatusNoContent ||
+		w.Header().Get("Access-Control-Allow-Origin") != "*" || strings.Contains(w.Header().Get("Access-Control-Allow-Headers"), "Authorization") {
+		t.Fatalf("public preflight: got %d %v", w.Code, w.Header())
+	}
+	if w := preflight(http.MethodPost, "/api/v1/football/teams", "https://fans.example"); w.Code != http.StatusForbidden {
+		t.Fatalf("public write preflight: expected 403, got %d", w.Code)
+	}
+	if w := preflight(http.MethodGet, "/api/v1/me/sessions", "https://fans.example"); w.Code != http.StatusForbidden {
+		t.Fatalf("public account preflight: expected 403, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/register", strings.NewReader(`{"username":"alice","password":"password123"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", "https://app.example")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated || w.Header().Get("Access-Control-Allow-Origin") != "https://app.example" ||
+		!strings.Contains(w.Header().Get("Access-Control-Expose-Headers"), "Location") {
+		t.Fatalf("app request: got %d %v", w.Code, w.Header())
+	}
+	// Pages can read their rate limit to back off before being throttled.
+	exposed := w.Header().Get("Access-Control-Expose-Headers")
+	for _, h := range []string{"Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy"} {
+		if !strings.Contains(exposed, h) {
+			t.Errorf("Access-Control-Expose-Headers %q lacks %s", exposed, h)
+		}
+	}
+}
+
+func TestRouter_SignedRespondAsync(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := router.New(router.Config{JWTSecret: "secret", Repositories: memory.New().Repositories(),
+		HMACKeys: map[string]string{"svc": "shared-secret"}})
+
+	body := `{"name":"Atlantis"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/football/teams", strings.NewReader(body))
+	date := time.Now().UTC().Format(time.RFC3339)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Prefer", "respond-async")
+	req.Header.Set(auth.HMACDateHeader, date)
+	req.Header.Set("Authorization", auth.HMACScheme+" KeyId=svc,Signature="+
+		auth.Sign("shared-secret", auth.StringToSign(http.MethodPost, "/api/v1/football/teams", date, []byte(body))))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d %s", w.Code, w.Body)
+	}
+
+	// The background copy runs as the caller whose signature was
+	// verified, without spending the signature again.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/football/teams?name=Atlantis", nil))
+		if strings.Contains(w.Body.String(), "Atlantis") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the team was never created: %s", w.Body)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRouter_SignedMsgPack(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := router.New(router.Config{JWTSecret: "secret", Repositories: memory.New().Repositories(),
+		HMACKeys: map[string]string{"svc": "shared-secret"}})
+
+	// The signature covers the body as sent, not its JSON equivalent.
+	body, err := codec.MsgPack().Encode("", []byte(`{"name":"Atlantis"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/football/teams", strings.NewReader(string(body)))
+	date := time.Now().UTC().Format(time.RFC3339)
+	req.Header.Set("Content-Type", "application/msgpack")
+	req.Header.Set(auth.HMACDateHeader, date)
+	req.Header.Set("Authorization", auth.HMACScheme+" KeyId=svc,Signature="+
+		auth.Sign("shared-secret", auth.StringToSign(http.MethodPost, "/api/v1/football/teams", date, body)))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d %s", w.Code, w.Body)
+	}
+}
+
+func TestRouter_RespondAsyncMeteredOnce(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repos := memory.New().Repositories()
+	meter := metering.New(repos.Metering)
+	r := router.New(router.Config{JWTSecret: "secret", Repositories: repos, Metering: meter})
+	do := func(method, path, token, body string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	creds := `{"username":"alice","password":"password123"}`
+	do(http.MethodPost, "/api/v1/auth/register", "", creds)
+	var login models.LoginResponse
+	if err := json.Unmarshal(do(http.MethodPost, "/api/v1/auth/login", "", creds).Body.Bytes(), &login); err != nil {
+		t.Fatal(err)
+	}
+
+	w := do(http.MethodPost, "/api/v1/football/teams", login.Token, `{"name":"Atlantis"}`, "Prefer", "respond-async")
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d %s", w.Code, w.Body)
+	}
+	w = do(http.MethodGet, w.Header().Get("Location"), login.Token, "", "Prefer", "wait=5")
+	var op models.Operation
+	if err := json.Unmarshal(w.Body.Bytes(), &op); err != nil || op.Status != models.OperationDone {
+		t.Fatalf("operation not done: %d %s", w.Code, w.Body)
+	}
+
+	// The mutation and the poll; the background copy is not billed.
+	if n, err := meter.MonthToDate("alice"); err != nil || n != 2 {
+		t.Fatalf("expected 2 metered requests, got %d, %v", n, err)
+	}
+}
+
+func TestRouter_Schemas(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := router.New(router.Config{JWTSecret: "secret", Repositories: memory.New().Repositories()})
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := get("/api/v1/football/teams")
+	href := w.Header().Get("X-Schema")
+	if href != "/api/v1/schemas/TeamsResponse" || w.Header().Get("Link") != `</api/v1/schemas/TeamsResponse>; rel="describedby"` {
+		t.Fatalf("unexpected schema headers %v", w.Header())
+	}
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+
+	w = get(href)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/schema+json" {
+		t.Fatalf("schema: %d %v", w.Code, w.Header())
+	}
+	var s struct {
+		ID         string         `json:"$id"`
+		Properties map[string]any `json:"properties"`
+		Required   []string       `json:"required"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.ID != href {
+		t.Fatalf("expected $id %s, got %s", href, s.ID)
+	}
+	for key := range body {
+		if _, ok := s.Properties[key]; !ok {
+			t.Errorf("response field %q is not in the schema", key)
+		}
+	}
+	for _, key := range s.Required {
+		if _, ok := body[key]; !ok {
+			t.Errorf("required field %q is missing from the response", key)
+		}
+	}
+
+	if got := get("/api/v1/football/teams/999").Header().Get("X-Schema"); got != "/api/v1/schemas/ErrorResponse" {
+		t.Fatalf("expected the error schema on a 404, got %q", got)
+	}
+	if w := get("/api/v1/schemas/ErrorResponse"); w.Code != http.StatusOK {
+		t.Fatalf("error schema: expected 200, got %d", w.Code)
+	}
+	if w := get("/api/v1/schemas/Nope"); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown schema: expected 404, got %d", w.Code)
+	}
+	var list models.SchemaListResponse
+	if err := json.Unmarshal(get("/api/v1/schemas").Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Data) < 40 {
+		t.Fatalf("expected every response schema listed, got %d", len(list.Data))
+	}
+}
+
+func TestRouter_SwaggerDefinitions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Chdir("../..")
+	r := router.New(router.Config{JWTSecret: "secret", Repositories: memory.New().Repositories()})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger/swagger.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("swagger.json: expected 200, got %d", w.Code)
+	}
+	type property struct {
+		Ref       string `json:"$ref"`
+		MinLength *int   `json:"minLength"`
+		MaxLength *int   `json:"maxLength"`
+		Enum      []any  `json:"enum"`
+		Items     *struct {
+			Ref string `json:"$ref"`
+		} `json:"items"`
+	}
+	var doc struct {
+		Definitions map[string]struct {
+			Required   []string            `json:"required"`
+			Properties map[string]property `json:"properties"`
+		} `json:"definitions"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	// Binding rules reach the request definitions.
+	team := doc.Definitions["models.CreateTeamRequest"]
+	if fmt.Sprint(team.Required) != "[name]" {
+		t.Fatalf("expected name required, got %v", team.Required)
+	}
+	if name := team.Properties["name"]; name.MinLength == nil || *name.MinLength != 1 || name.MaxLength == nil || *name.MaxLength != 100 {
+		t.Fatalf("expected name length 1-100, got %+v", name)
+	}
+	if reason := doc.Definitions["models.ReportRequest"].Properties["reason"]; len(reason.Enum) != 5 {
+		t.Fatalf("expected the reason enum, got %+v", reason)
+	}
+
+	// Types swag never saw are added, with refs in swag's naming.
+	usage, ok := doc.Definitions["models.UsageResponse"]
+	if !ok {
+		t.Fatal("expected a models.UsageResponse definition")
+	}
+	if items := usage.Properties["data"].Items; items == nil || items.Ref != "#/definitions/models.UsageRecord" {
+		t.Fatalf("unexpected data items %+v", usage.Properties["data"])
+	}
+	if _, ok := doc.Definitions["models.UsageRecord"]; !ok {
+		t.Fatal("expected the referenced models.UsageRecord definition")
+	}
+}
+
+func TestRouter_ImpersonatedAccountActionsPublished(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	bus := events.NewBus(nil)
+	var got []events.Event
+	bus.Subscribe(events.BeforeResponse, events.SubscriberFunc(func(_ context.Context, e events.Event) error {
+		got = append(got, e)
+		return nil
+	}))
+	r := router.New(router.Config{JWTSecret: "secret", Repositories: memory.New().Repositories(), Events: bus})
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	creds := `{"username":"alice","password":"password123"}`
+	do(http.MethodPost, "/api/v1/auth/register", "", creds)
+	do(http.MethodPost, "/api/v1/auth/login", "", creds)
+	token, _, err := auth.NewJWTService("secret", router.TokenIssuer).GenerateImpersonationToken("alice", "root")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sessions models.SessionListResponse
+	json.Unmarshal(do(http.MethodGet, "/api/v1/me/sessions", token, "").Body.Bytes(), &sessions)
+	if len(sessions.Sessions) != 1 {
+		t.Fatalf("expected alice's session, got %+v", sessions)
+	}
+	got = nil
+	for _, req := range []struct{ method, path, body string }{
+		{http.MethodPut, "/api/v1/me/preferences", `{"pageSize":50}`},
+		{http.MethodGet, "/api/v1/me/export", ""},
+		{http.MethodDelete, "/api/v1/me/sessions/" + sessions.Sessions[0].ID, ""},
+	} {
+		if w := do(req.method, req.path, token, req.body); w.Code >= 300 {
+			t.Fatalf("%s %s: got %d %s", req.method, req.path, w.Code, w.Body)
+		}
+	}
+
+	want := []events.Type{events.PreferencesUpdated, events.DataExported, events.SessionRevoked}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %+v", want, got)
+	}
+	for i, e := range got {
+		if e.Type != want[i] || e.Actor != "alice" || e.Impersonator != "root" {
+			t.Errorf("event %d: expected %s by alice as root, got %+v", i, want[i], e)
+		}
+	}
+}
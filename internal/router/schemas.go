@@ -0,0 +1,156 @@
+package router
+
+import (
+	"github.com/sc23bd/COMP3011_Coursework1/internal/elo"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/schema"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/version"
+)
+
+// schemaBase is where the response schemas are served.
+const schemaBase = "/api/v1/schemas/"
+
+// responseTypes gives the type of each route's successful JSON responses,
+// as documented by the handlers' @Success annotations.  Routes missing
+// here still respond normally, just without an X-Schema header.
+var responseTypes = map[string]any{
+	"GET /api/v1/version":       version.Info{},
+	"GET /api/v1/errors":        models.ErrorCodesResponse{},
+	"GET /api/v1/announcements": models.AnnouncementListResponse{},
+
+	"POST /api/v1/auth/login":          models.LoginResponse{},
+	"POST /api/v1/auth/introspect":     models.IntrospectionResponse{},
+	"POST /api/v1/auth/reauthenticate": models.LoginResponse{},
+	"GET /api/v1/oauth/authorize":      models.OAuthConsent{},
+	"POST /api/v1/oauth/authorize":     models.AuthorizeResponse{},
+	"POST /api/v1/oauth/token":         models.OAuthTokenResponse{},
+
+	"GET /api/v1/football/teams":                         models.TeamsResponse{},
+	"POST /api/v1/football/teams":                        models.TeamResponse{},
+	"GET /api/v1/football/teams/:id":                     models.TeamResponse{},
+	"GET /api/v1/football/teams/slug/:slug":              models.TeamResponse{},
+	"PUT /api/v1/football/teams/:id":                     models.TeamResponse{},
+	"GET /api/v1/football/teams/:id/history":             models.FormerNamesResponse{},
+	"POST /api/v1/football/teams/:id/merge-into/:target": models.TeamResponse{},
+	"GET /api/v1/football/teams/:id/translations":        models.TeamTranslationsResponse{},
+	"PUT /api/v1/football/teams/:id/translations/:lang":  models.TeamTranslationsResponse{},
+	"GET /api/v1/football/teams/:id/elo":                 elo.Rating{},
+	"GET /api/v1/football/teams/:id/elo/timeline":        elo.TimelineResponse{},
+	"POST /api/v1/football/teams/:id/report":             models.ContentReport{},
+	"GET /api/v1/football/tournaments":                   models.TournamentsResponse{},
+	"GET /api/v1/football/matches":                       models.MatchesResponse{},
+	"POST /api/v1/football/matches":                      models.MatchResponse{},
+	"GET /api/v1/football/matches/changes":               models.MatchChangesResponse{},
+	"POST /api/v1/football/matches/simulate":             models.SimulateResponse{},
+	"GET /api/v1/football/matches/:id":                   models.MatchResponse{},
+	"PUT /api/v1/football/matches/:id":                   models.MatchResponse{},
+	"PATCH /api/v1/football/matches/:id":                 models.MatchResponse{},
+	"GET /api/v1/football/matches/:id/goals":             models.GoalsResponse{},
+	"POST /api/v1/football/matches/:id/goals":            models.GoalsResponse{},
+	"GET /api/v1/football/matches/:id/shootout":          models.ShootoutResponse{},
+	"POST /api/v1/football/matches/:id/shootout":         models.ShootoutResponse{},
+	"POST /api/v1/football/matches/:id/report":           models.ContentReport{},
+	"GET /api/v1/football/head-to-head":                  models.MatchesResponse{},
+	"GET /api/v1/football/players/:name/goals":           models.GoalsResponse{},
+	"GET /api/v1/football/rankings/elo":                  elo.RankingsResponse{},
+	"POST /api/v1/football/rankings/elo/recalculate":     elo.RecalculateResponse{},
+
+	"GET /api/v1/me/sessions":                  models.SessionListResponse{},
+	"GET /api/v1/me/logins":                    models.LoginListResponse{},
+	"GET /api/v1/me/apps":                      models.OAuthGrantListResponse{},
+	"GET /api/v1/me/terms":                     models.TermsStatus{},
+	"PUT /api/v1/me/terms":                     models.TermsStatus{},
+	"GET /api/v1/me/preferences":               models.PreferencesResponse{},
+	"PUT /api/v1/me/preferences":               models.PreferencesResponse{},
+	"POST /api/v1/me/preferences/email/verify": models.PreferencesResponse{},
+	"GET /api/v1/me/notifications":             models.NotificationListResponse{},
+	"POST /api/v1/me/notifications/:id/read":   models.Notification{},
+	"GET /api/v1/me/usage":                     models.UsageResponse{},
+	"GET /api/v1/me/operations/:id":            models.Operation{},
+
+	"GET /api/v1/admin/log-level":                      models.LogLevelResponse{},
+	"PUT /api/v1/admin/log-level":                      models.LogLevelResponse{},
+	"GET /api/v1/admin/recording":                      models.RecordingResponse{},
+	"PUT /api/v1/admin/recording":                      models.RecordingResponse{},
+	"GET /api/v1/admin/flags":                          models.FeatureFlagsResponse{},
+	"PUT /api/v1/admin/flags/:name":                    models.FeatureFlag{},
+	"GET /api/v1/admin/jobs":                           models.JobsResponse{},
+	"GET /api/v1/admin/reports/daily":                  models.DailyReport{},
+	"GET /api/v1/admin/analytics/endpoints":            models.EndpointUsageResponse{},
+	"GET /api/v1/admin/analytics/users":                models.SubjectUsageResponse{},
+	"GET /api/v1/admin/usage":                          models.UsageRollupResponse{},
+	"GET /api/v1/admin/backups":                        models.BackupsResponse{},
+	"POST /api/v1/admin/backups":                       models.Backup{},
+	"POST /api/v1/admin/backups/:name/restore":         models.RestoreResponse{},
+	"GET /api/v1/admin/invites":                        models.InviteListResponse{},
+	"POST /api/v1/admin/invites":                       models.Invite{},
+	"GET /api/v1/admin/oauth/clients":                  models.OAuthClientListResponse{},
+	"POST /api/v1/admin/oauth/clients":                 models.OAuthClientCreatedResponse{},
+	"POST /api/v1/admin/impersonate":                   models.ImpersonationResponse{},
+	"GET /api/v1/admin/announcements":                  models.AnnouncementListResponse{},
+	"POST /api/v1/admin/announcements":                 models.Announcement{},
+	"GET /api/v1/admin/moderation":                     models.ModerationQueueResponse{},
+	"POST /api/v1/admin/moderation/:kind/:id/dismiss":  models.ModerationItem{},
+	"POST /api/v1/admin/moderation/:kind/:id/takedown": models.ModerationItem{},
+
+	"GET /api/v1/schemas": models.SchemaListResponse{},
+}
+
+// requestTypes gives the type of the request body each route binds, whose
+// schema carries its binding rules.
+var requestTypes = map[string]any{
+	"POST /api/v1/auth/register":       models.RegisterRequest{},
+	"POST /api/v1/auth/login":          models.LoginRequest{},
+	"POST /api/v1/auth/reauthenticate": models.ReauthenticateRequest{},
+	"POST /api/v1/oauth/authorize":     models.AuthorizeRequest{},
+
+	"POST /api/v1/football/teams":                       models.CreateTeamRequest{},
+	"PUT /api/v1/football/teams/:id":                    models.UpdateTeamRequest{},
+	"PUT /api/v1/football/teams/:id/translations/:lang": models.TeamTranslationRequest{},
+	"POST /api/v1/football/teams/:id/report":            models.ReportRequest{},
+	"POST /api/v1/football/matches":                     models.CreateMatchRequest{},
+	"POST /api/v1/football/matches/simulate":            models.SimulateRequest{},
+	"PUT /api/v1/football/matches/:id":                  models.UpdateMatchRequest{},
+	"POST /api/v1/football/matches/:id/goals":           models.CreateGoalRequest{},
+	"POST /api/v1/football/matches/:id/shootout":        models.CreateShootoutRequest{},
+	"POST /api/v1/football/matches/:id/report":          models.ReportRequest{},
+
+	"PUT /api/v1/me/terms": models.AcceptTermsRequest{},
+
+	"PUT /api/v1/admin/log-level":                      models.LogLevelRequest{},
+	"PUT /api/v1/admin/recording":                      models.RecordingRequest{},
+	"PUT /api/v1/admin/flags/:name":                    models.FeatureFlagRequest{},
+	"POST /api/v1/admin/invites":                       models.InviteRequest{},
+	"POST /api/v1/admin/oauth/clients":                 models.OAuthClientRequest{},
+	"POST /api/v1/admin/impersonate":                   models.ImpersonateRequest{},
+	"POST /api/v1/admin/announcements":                 models.AnnouncementRequest{},
+	"POST /api/v1/admin/moderation/:kind/:id/dismiss":  models.ModerationDecisionRequest{},
+	"POST /api/v1/admin/moderation/:kind/:id/takedown": models.ModerationDecisionRequest{},
+}
+
+// responseSchemas registers the schema of every type in responseTypes and
+// requestTypes and of the error envelopes, returning the registry and the
+// schema names of each route's successful responses and request body.
+func responseSchemas() (*schema.Registry, schema.Routes, schema.Routes) {
+	reg := schema.NewRegistry()
+	routes := schema.Routes{}
+	for route, v := range responseTypes {
+		routes[route] = reg.Add(v)
+	}
+	requests := schema.Routes{}
+	for route, v := range requestTypes {
+		requests[route] = reg.AddRequest(v)
+	}
+	for _, v := range []any{
+		models.ErrorResponse{},
+		models.ConflictResponse{},
+		models.GoneResponse{},
+		models.ContentRejectedResponse{},
+		models.QuotaExceededResponse{},
+		models.FreshAuthRequiredResponse{},
+		models.OAuthErrorResponse{},
+	} {
+		reg.Add(v)
+	}
+	return reg, routes, requests
+}
@@ -0,0 +1,73 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/schema"
+)
+
+// serveSwagger serves the Swagger UI from dir like gin's Static, except
+// that swagger.json is rewritten by syncSpec so that the document matches
+// the Go types and the router.
+func serveSwagger(dir string, reg *schema.Registry, private bool) gin.HandlerFunc {
+	files := http.StripPrefix("/swagger/", http.FileServer(http.Dir(dir)))
+	spec, err := os.ReadFile(filepath.Join(dir, "swagger.json"))
+	if err == nil {
+		spec, err = syncSpec(spec, reg, private)
+	}
+	if err != nil {
+		log.Printf("swagger: %v; serving the document unchanged", err)
+		spec = nil
+	}
+	return func(c *gin.Context) {
+		if c.Param("filepath") == "/swagger.json" && spec != nil {
+			c.Data(http.StatusOK, "application/json; charset=utf-8", spec)
+			return
+		}
+		files.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// syncSpec replaces the document's definitions with those derived from the
+// registered types, so that they follow the structs' tags — binding rules
+// included — even when swag has not been rerun.  When private, it also
+// marks every read operation on the football resources as requiring the
+// Bearer scheme, as PrivateReads does in the router.
+func syncSpec(raw []byte, reg *schema.Registry, private bool) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	defs, _ := doc["definitions"].(map[string]any)
+	if defs == nil {
+		defs = map[string]any{}
+		doc["definitions"] = defs
+	}
+	for name, def := range reg.Definitions() {
+		defs[name] = def
+	}
+	if !private {
+		return json.MarshalIndent(doc, "", "    ")
+	}
+
+	paths, _ := doc["paths"].(map[string]any)
+	for path, item := range paths {
+		if !strings.HasPrefix(path, "/football/") {
+			continue
+		}
+		ops, _ := item.(map[string]any)
+		if op, ok := ops["get"].(map[string]any); ok {
+			op["security"] = []any{map[string]any{"Bearer": []any{}}}
+		}
+	}
+	return json.MarshalIndent(doc, "", "    ")
+}
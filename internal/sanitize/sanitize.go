@@ -0,0 +1,143 @@
+// Package sanitize cleans free text that clients submit before it is
+// validated and stored.  Text is trimmed and put in Unicode NFC, so that
+// the same name spelled with precomposed or combining characters is stored
+// once and its length is counted in the characters a reader sees; and text
+// containing control or invisible formatting characters (zero-width
+// spaces and joiners, bidirectional overrides, byte-order marks) is
+// refused, since they let two names that look identical differ, or make
+// one name display as another.
+//
+// Request fields opt in with a sanitize tag:
+//
+//	Name string `json:"name" sanitize:"line" binding:"required,min=1,max=100"`
+//
+// "line" is single-line text; "multiline" also allows line feeds and tabs.
+// Fields without the tag, such as passwords, are left exactly as sent.
+package sanitize
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin/binding"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Tag values.
+const (
+	Line      = "line"
+	Multiline = "multiline"
+)
+
+// ErrInvisible is returned for text containing control or invisible
+// formatting characters.
+var ErrInvisible = errors.New("contains control or invisible characters")
+
+// Text returns s trimmed and in NFC, or ErrInvisible if it contains a
+// control, format or line separator character, or the replacement
+// character that stands in for bytes that were not valid UTF-8.  When
+// multiline is true, line feeds and tabs are allowed and carriage returns
+// are dropped from line endings.
+func Text(s string, multiline bool) (string, error) {
+	if multiline {
+		s = strings.ReplaceAll(s, "\r\n", "\n")
+	}
+	s = norm.NFC.String(strings.TrimSpace(s))
+	for _, r := range s {
+		if multiline && (r == '\n' || r == '\t') {
+			continue
+		}
+		if unicode.In(r, unicode.Cc, unicode.Cf, unicode.Zl, unicode.Zp) || r == unicode.ReplacementChar {
+			return "", ErrInvisible
+		}
+	}
+	return s, nil
+}
+
+// FieldError reports a field whose text was refused.
+type FieldError struct {
+	Field string // JSON name
+	Err   error
+}
+
+func (e *FieldError) Error() string { return e.Field + " " + e.Err.Error() }
+
+func (e *FieldError) Unwrap() error { return e.Err }
+
+// Struct sanitises, in place, the tagged string fields of the struct v
+// points to, including those of embedded structs.  It returns a
+// *FieldError for the first field refused.
+func Struct(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	return walk(rv.Elem())
+}
+
+func walk(v reflect.Value) error {
+	t := v.Type()
+	for i := range t.NumField() {
+		f, fv := t.Field(i), v.Field(i)
+		if f.Anonymous && fv.Kind() == reflect.Struct {
+			if err := walk(fv); err != nil {
+				return err
+			}
+			continue
+		}
+		mode := f.Tag.Get("sanitize")
+		if mode == "" || fv.Kind() != reflect.String || !fv.CanSet() {
+			continue
+		}
+		s, err := Text(fv.String(), mode == Multiline)
+		if err != nil {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "" {
+				name = f.Name
+			}
+			return &FieldError{Field: name, Err: err}
+		}
+		fv.SetString(s)
+	}
+	return nil
+}
+
+// JSON binds a JSON request body like gin's binding.JSON, but sanitises the
+// tagged fields before the binding rules are checked, so that lengths are
+// counted in characters of the normalised text.  Use it with
+// c.ShouldBindWith.
+var JSON binding.BindingBody = jsonBinding{}
+
+type jsonBinding struct{}
+
+func (jsonBinding) Name() string { return "json" }
+
+func (b jsonBinding) Bind(req *http.Request, obj any) error {
+	if req == nil || req.Body == nil {
+		return errors.New("invalid request")
+	}
+	return b.decode(req.Body, obj)
+}
+
+func (b jsonBinding) BindBody(body []byte, obj any) error {
+	return b.decode(bytes.NewReader(body), obj)
+}
+
+func (jsonBinding) decode(r io.Reader, obj any) error {
+	if err := json.NewDecoder(r).Decode(obj); err != nil {
+		return err
+	}
+	if err := Struct(obj); err != nil {
+		return err
+	}
+	if binding.Validator == nil {
+		return nil
+	}
+	return binding.Validator.ValidateStruct(obj)
+}
@@ -0,0 +1,72 @@
+package sanitize_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/sanitize"
+)
+
+func TestText(t *testing.T) {
+	cases := []struct {
+		in        string
+		multiline bool
+		want      string
+		err       error
+	}{
+		{"  Brazil ", false, "Brazil", nil},
+		{"Cura\u0063\u0327ao", false, "Cura\u00e7ao", nil}, // combining cedilla composes
+		{"Bra\u200bzil", false, "", sanitize.ErrInvisible},
+		{"Bra\u200dzil", false, "", sanitize.ErrInvisible},
+		{"\u202eliztarB", false, "", sanitize.ErrInvisible},
+		{"\u2066Brazil\u2069", false, "", sanitize.ErrInvisible},
+		{"\ufeffBrazil", false, "", sanitize.ErrInvisible},
+		{"Bra\x00zil", false, "", sanitize.ErrInvisible},
+		{"Bra\xffzil", false, "", sanitize.ErrInvisible},
+		{"line\u2028break", false, "", sanitize.ErrInvisible},
+		{"two\nlines", false, "", sanitize.ErrInvisible},
+		{"two\r\nlines\tand a tab", true, "two\nlines\tand a tab", nil},
+		{"bell\a", true, "", sanitize.ErrInvisible},
+	}
+	for _, tc := range cases {
+		got, err := sanitize.Text(tc.in, tc.multiline)
+		if !errors.Is(err, tc.err) || got != tc.want {
+			t.Errorf("Text(%q, %v) = %q, %v; want %q, %v", tc.in, tc.multiline, got, err, tc.want, tc.err)
+		}
+	}
+}
+
+type inner struct {
+	Note string `json:"note" sanitize:"multiline"`
+}
+
+type request struct {
+	inner
+	Name     string `json:"name" sanitize:"line" binding:"required,max=5"`
+	Password string `json:"password"`
+}
+
+func TestJSON(t *testing.T) {
+	// Five characters once trimmed and composed, though six code points as
+	// sent: the limit applies to the normalised text.
+	var req request
+	body := `{"name":" Jose\u0301s ","password":" secret ","note":"a\r\nb "}`
+	if err := sanitize.JSON.BindBody([]byte(body), &req); err != nil {
+		t.Fatalf("BindBody: %v", err)
+	}
+	if req.Name != "Jos\u00e9s" || req.Password != " secret " || req.Note != "a\nb" {
+		t.Fatalf("got %+v", req)
+	}
+
+	err := sanitize.JSON.BindBody([]byte(`{"name":"Joseph"}`), &request{})
+	if err == nil || !strings.Contains(err.Error(), "max") {
+		t.Fatalf("expected max validation error, got %v", err)
+	}
+
+	var fe *sanitize.FieldError
+	err = sanitize.JSON.BindBody([]byte(`{"name":"a\u200bb"}`), &request{})
+	if !errors.As(err, &fe) || fe.Field != "name" || err.Error() != "name contains control or invisible characters" {
+		t.Fatalf("expected field error for name, got %v", err)
+	}
+}
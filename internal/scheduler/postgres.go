@@ -0,0 +1,44 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// PostgresHistory stores runs in the job_runs table (migration 011), so the
+// history is shared by every instance and survives restarts.
+type PostgresHistory struct {
+	db *sql.DB
+}
+
+// NewPostgresHistory returns a History backed by db.
+func NewPostgresHistory(db *sql.DB) *PostgresHistory { return &PostgresHistory{db: db} }
+
+// Record implements History.
+func (h *PostgresHistory) Record(ctx context.Context, r Run) error {
+	_, err := h.db.ExecContext(ctx,
+		`INSERT INTO job_runs (job, started_at, finished_at, status, error) VALUES ($1, $2, $3, $4, $5)`,
+		r.Job, r.Started, r.Finished, r.Status, r.Error)
+	if err != nil {
+		return fmt.Errorf("scheduler: record run: %w", err)
+	}
+	return nil
+}
+
+// Last implements History.
+func (h *PostgresHistory) Last(ctx context.Context, job string) (Run, bool, error) {
+	r := Run{Job: job}
+	err := h.db.QueryRowContext(ctx,
+		`SELECT started_at, finished_at, status, error FROM job_runs
+		 WHERE job = $1 ORDER BY started_at DESC, id DESC LIMIT 1`, job,
+	).Scan(&r.Started, &r.Finished, &r.Status, &r.Error)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Run{}, false, nil
+	}
+	if err != nil {
+		return Run{}, false, fmt.Errorf("scheduler: last run: %w", err)
+	}
+	return r, true, nil
+}
@@ -0,0 +1,256 @@
+// Package scheduler runs registered background jobs on cron schedules.
+// Each job is protected against overlapping itself, both within the
+// process and, through a lock.Manager, across instances; every run is
+// recorded in a History.  In a multi-instance deployment, run the scheduler
+// under a leader.Elector so that each job fires once per schedule rather
+// than once per instance.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/lock"
+)
+
+// Run statuses.
+const (
+	StatusOK      = "ok"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+// Run records one firing of a job.
+type Run struct {
+	Job      string
+	Started  time.Time
+	Finished time.Time
+	// Status is StatusOK, StatusFailed, or StatusSkipped when the previous
+	// run was still going.
+	Status string
+	Error  string
+}
+
+// History stores job runs.
+type History interface {
+	Record(ctx context.Context, r Run) error
+	// Last returns the most recent run of job, or ok=false if it has
+	// never run.
+	Last(ctx context.Context, job string) (r Run, ok bool, err error)
+}
+
+// JobStatus describes a registered job.
+type JobStatus struct {
+	Name     string
+	Schedule string
+	NextRun  time.Time
+	Running  bool
+	LastRun  *Run
+}
+
+type job struct {
+	name     string
+	spec     string
+	schedule Schedule
+	run      func(ctx context.Context) error
+
+	next    time.Time
+	running bool
+}
+
+// Scheduler fires jobs on their schedules.  Add jobs before calling Run.
+type Scheduler struct {
+	locks   lock.Manager
+	history History
+	clock   clock.Clock
+
+	mu        sync.Mutex
+	jobs      []*job
+	wg        sync.WaitGroup
+	onFailure func(Run)
+}
+
+// New returns a Scheduler that takes each job's lock from locks and records
+// runs in history.
+func New(locks lock.Manager, history History) *Scheduler {
+	return &Scheduler{locks: locks, history: history, clock: clock.System{}}
+}
+
+// SetClock replaces the wall clock used to compute next run times.
+func (s *Scheduler) SetClock(c clock.Clock) {
+	s.clock = clock.Or(c)
+}
+
+// OnFailure sets a function called after every failed run, such as one
+// that alerts operators.  Set it before calling Run.
+func (s *Scheduler) OnFailure(f func(Run)) {
+	s.onFailure = f
+}
+
+// Add registers run under name on the cron expression spec.
+func (s *Scheduler) Add(name, spec string, run func(ctx context.Context) error) error {
+	sched, err := Parse(spec)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		if j.name == name {
+			return fmt.Errorf("scheduler: job %q already registered", name)
+		}
+	}
+	s.jobs = append(s.jobs, &job{name: name, spec: spec, schedule: sched, run: run, next: sched.Next(s.clock.Now())})
+	return nil
+}
+
+// Run fires jobs until ctx is done, then waits for running jobs, whose
+// context is cancelled, to return.
+func (s *Scheduler) Run(ctx context.Context) {
+	defer s.wg.Wait()
+	for {
+		next := s.nextRun()
+		if next.IsZero() {
+			<-ctx.Done()
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.RunDue(ctx, s.clock.Now())
+	}
+}
+
+func (s *Scheduler) nextRun() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var next time.Time
+	for _, j := range s.jobs {
+		if !j.next.IsZero() && (next.IsZero() || j.next.Before(next)) {
+			next = j.next
+		}
+	}
+	return next
+}
+
+// RunDue starts, in the background, every job whose next run is at or
+// before now, and schedules its following run.  Run calls it; tests call it
+// directly with a fake time and then Wait.
+func (s *Scheduler) RunDue(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		if j.next.IsZero() || j.next.After(now) {
+			continue
+		}
+		j.next = j.schedule.Next(now)
+		if j.running {
+			s.record(ctx, Run{Job: j.name, Started: now, Finished: now, Status: StatusSkipped, Error: "previous run still in progress"})
+			continue
+		}
+		j.running = true
+		s.wg.Add(1)
+		go s.fire(ctx, j)
+	}
+}
+
+// Wait blocks until every started job has finished.
+func (s *Scheduler) Wait() { s.wg.Wait() }
+
+func (s *Scheduler) fire(ctx context.Context, j *job) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		j.running = false
+		s.mu.Unlock()
+	}()
+
+	run := Run{Job: j.name, Started: s.clock.Now()}
+	release, err := s.locks.Acquire(ctx, "job:"+j.name, 0)
+	switch {
+	case errors.Is(err, lock.ErrTimeout):
+		run.Status, run.Error = StatusSkipped, "running on another instance"
+	case err != nil:
+		run.Status, run.Error = StatusFailed, err.Error()
+	default:
+		err = j.run(ctx)
+		release()
+		run.Status = StatusOK
+		if err != nil {
+			run.Status, run.Error = StatusFailed, err.Error()
+			log.Printf("job %s failed: %v", j.name, err)
+		}
+	}
+	run.Finished = s.clock.Now()
+	s.record(ctx, run)
+	if run.Status == StatusFailed && s.onFailure != nil {
+		s.onFailure(run)
+	}
+}
+
+func (s *Scheduler) record(ctx context.Context, r Run) {
+	// Record even when ctx was cancelled mid-run.
+	ctx = context.WithoutCancel(ctx)
+	if err := s.history.Record(ctx, r); err != nil {
+		log.Printf("job %s: record run: %v", r.Job, err)
+	}
+}
+
+// Jobs describes every registered job, ordered by name.
+func (s *Scheduler) Jobs(ctx context.Context) ([]JobStatus, error) {
+	s.mu.Lock()
+	out := make([]JobStatus, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		out = append(out, JobStatus{Name: j.name, Schedule: j.spec, NextRun: j.next, Running: j.running})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(out, func(a, b int) bool { return out[a].Name < out[b].Name })
+	for i := range out {
+		last, ok, err := s.history.Last(ctx, out[i].Name)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			out[i].LastRun = &last
+		}
+	}
+	return out, nil
+}
+
+// MemoryHistory keeps the last run of each job in memory.
+type MemoryHistory struct {
+	mu   sync.Mutex
+	last map[string]Run
+}
+
+// NewMemoryHistory returns an empty in-memory History.
+func NewMemoryHistory() *MemoryHistory {
+	return &MemoryHistory{last: make(map[string]Run)}
+}
+
+// Record implements History.
+func (h *MemoryHistory) Record(_ context.Context, r Run) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.last[r.Job] = r
+	return nil
+}
+
+// Last implements History.
+func (h *MemoryHistory) Last(_ context.Context, job string) (Run, bool, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r, ok := h.last[job]
+	return r, ok, nil
+}
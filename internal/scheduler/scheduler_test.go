@@ -0,0 +1,179 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/postgres"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/lock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/scheduler"
+)
+
+var start = time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)
+
+func newScheduler(locks lock.Manager) (*scheduler.Scheduler, *scheduler.MemoryHistory, *clock.Fake) {
+	h := scheduler.NewMemoryHistory()
+	s := scheduler.New(locks, h)
+	c := clock.NewFake(start)
+	s.SetClock(c)
+	return s, h, c
+}
+
+func lastRun(t *testing.T, h scheduler.History, job string) scheduler.Run {
+	t.Helper()
+	r, ok, err := h.Last(context.Background(), job)
+	if err != nil || !ok {
+		t.Fatalf("Last(%q) = %v, %v", job, ok, err)
+	}
+	return r
+}
+
+func TestScheduler_RunsDueJobs(t *testing.T) {
+	s, h, c := newScheduler(lock.NewLocal())
+	ctx := context.Background()
+	var quarterly, hourly int
+	if err := s.Add("quarterly", "*/15 * * * *", func(context.Context) error { quarterly++; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add("hourly", "@hourly", func(context.Context) error { hourly++; return errors.New("boom") }); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add("hourly", "@daily", nil); err == nil {
+		t.Fatal("expected an error registering a duplicate name")
+	}
+	if err := s.Add("bad", "not cron", nil); err == nil {
+		t.Fatal("expected an error for an invalid expression")
+	}
+
+	c.Advance(15 * time.Minute) // 10:45
+	s.RunDue(ctx, c.Now())
+	s.Wait()
+	if quarterly != 1 || hourly != 0 {
+		t.Fatalf("at 10:45: quarterly=%d hourly=%d", quarterly, hourly)
+	}
+	c.Advance(15 * time.Minute) // 11:00
+	s.RunDue(ctx, c.Now())
+	s.Wait()
+	if quarterly != 2 || hourly != 1 {
+		t.Fatalf("at 11:00: quarterly=%d hourly=%d", quarterly, hourly)
+	}
+
+	if r := lastRun(t, h, "quarterly"); r.Status != scheduler.StatusOK {
+		t.Errorf("quarterly status = %q", r.Status)
+	}
+	if r := lastRun(t, h, "hourly"); r.Status != scheduler.StatusFailed || r.Error != "boom" {
+		t.Errorf("hourly run = %+v", r)
+	}
+
+	jobs, err := s.Jobs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 2 || jobs[0].Name != "hourly" || jobs[1].Name != "quarterly" {
+		t.Fatalf("Jobs = %+v", jobs)
+	}
+	if want := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC); !jobs[0].NextRun.Equal(want) {
+		t.Errorf("hourly next run = %v, want %v", jobs[0].NextRun, want)
+	}
+	if jobs[0].LastRun == nil || jobs[0].Schedule != "@hourly" {
+		t.Errorf("hourly status = %+v", jobs[0])
+	}
+}
+
+func TestScheduler_SkipsOverlappingRun(t *testing.T) {
+	s, h, c := newScheduler(lock.NewLocal())
+	ctx := context.Background()
+	started, unblock := make(chan struct{}), make(chan struct{})
+	runs := 0
+	_ = s.Add("slow", "* * * * *", func(context.Context) error {
+		runs++
+		close(started)
+		<-unblock
+		return nil
+	})
+
+	c.Advance(time.Minute)
+	s.RunDue(ctx, c.Now())
+	<-started
+	c.Advance(time.Minute)
+	s.RunDue(ctx, c.Now())
+	if r := lastRun(t, h, "slow"); r.Status != scheduler.StatusSkipped {
+		t.Errorf("overlapping run status = %q, want skipped", r.Status)
+	}
+	close(unblock)
+	s.Wait()
+	if runs != 1 {
+		t.Errorf("runs = %d, want 1", runs)
+	}
+	if r := lastRun(t, h, "slow"); r.Status != scheduler.StatusOK {
+		t.Errorf("final status = %q, want ok", r.Status)
+	}
+}
+
+func TestScheduler_SkipsWhenLockedElsewhere(t *testing.T) {
+	locks := lock.NewLocal()
+	release, _ := locks.Acquire(context.Background(), "job:cleanup", 0)
+	defer release()
+
+	s, h, c := newScheduler(locks)
+	ran := false
+	_ = s.Add("cleanup", "* * * * *", func(context.Context) error { ran = true; return nil })
+	c.Advance(time.Minute)
+	s.RunDue(context.Background(), c.Now())
+	s.Wait()
+	if ran {
+		t.Error("job ran while its lock was held")
+	}
+	if r := lastRun(t, h, "cleanup"); r.Status != scheduler.StatusSkipped {
+		t.Errorf("status = %q, want skipped", r.Status)
+	}
+}
+
+func TestScheduler_RunStopsOnCancel(t *testing.T) {
+	s := scheduler.New(lock.NewLocal(), scheduler.NewMemoryHistory())
+	_ = s.Add("never-soon", "@yearly", func(context.Context) error { return nil })
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+}
+
+func TestPostgresHistory(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	conn, err := postgres.Connect(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	h := scheduler.NewPostgresHistory(conn)
+	ctx := context.Background()
+	job := "history-test-" + time.Now().Format("150405.000000")
+	if _, ok, err := h.Last(ctx, job); err != nil || ok {
+		t.Fatalf("Last before any run = %v, %v", ok, err)
+	}
+	for i, status := range []string{scheduler.StatusOK, scheduler.StatusFailed} {
+		at := start.Add(time.Duration(i) * time.Hour)
+		if err := h.Record(ctx, scheduler.Run{Job: job, Started: at, Finished: at, Status: status}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if r := lastRun(t, h, job); r.Status != scheduler.StatusFailed {
+		t.Errorf("last status = %q, want failed", r.Status)
+	}
+}
@@ -0,0 +1,78 @@
+package schema
+
+import (
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Routes maps a route, as "METHOD /full/path" with gin's parameter
+// syntax, to the name of the schema its successful responses follow.
+type Routes map[string]string
+
+// Describe labels JSON responses with their schema: routes gives the
+// schema of each route's successful responses, and errors that of every
+// response with a 4xx or 5xx status.  The schema of name is linked as
+// base+name, in an X-Schema header and a Link header with
+// rel="describedby", and X-Envelope-Version is set on all JSON responses.
+// Responses of routes not listed, and those in a profile of JSON (a
+// Content-Type profile parameter, such as the compact list encoding),
+// carry only the version.
+func Describe(base string, routes Routes, errors string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer = &describeWriter{ResponseWriter: c.Writer, describe: func(w gin.ResponseWriter) {
+			mediaType, params, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+			if mediaType != "application/json" {
+				return
+			}
+			w.Header().Set("X-Envelope-Version", EnvelopeVersion)
+			if params["profile"] != "" {
+				return
+			}
+			name := errors
+			if w.Status() < 400 {
+				name = routes[c.Request.Method+" "+c.FullPath()]
+			}
+			if name == "" {
+				return
+			}
+			w.Header().Set("X-Schema", base+name)
+			w.Header().Add("Link", "<"+base+name+`>; rel="describedby"`)
+		}}
+		c.Next()
+	}
+}
+
+// describeWriter calls describe once, just before the headers are sent,
+// when the status and content type are known.
+type describeWriter struct {
+	gin.ResponseWriter
+	describe  func(gin.ResponseWriter)
+	described bool
+}
+
+// Unwrap returns the writer underneath, for http.ResponseController.
+func (w *describeWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+func (w *describeWriter) before() {
+	if !w.described && !w.Written() {
+		w.described = true
+		w.describe(w.ResponseWriter)
+	}
+}
+
+func (w *describeWriter) WriteHeaderNow() {
+	w.before()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *describeWriter) Write(b []byte) (int, error) {
+	w.before()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *describeWriter) WriteString(s string) (int, error) {
+	w.before()
+	return w.ResponseWriter.WriteString(s)
+}
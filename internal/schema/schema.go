@@ -0,0 +1,433 @@
+// Package schema derives JSON Schemas (draft 2020-12) from the Go types the
+// API reads and writes, so that clients can validate payloads and
+// generators can build typed SDKs from the same source of truth as the
+// handlers.
+//
+// Schemas follow the struct tags the handlers do: json names and omitempty,
+// binding rules (required, min, max, len, gte, lte, oneof, email, url) and
+// swag's example tags.  A Registry holds the documented types by
+// name, and also renders them as OpenAPI 2 definitions so that the Swagger
+// document cannot drift from the code.  Describe labels each JSON response
+// with the URL of its schema.
+package schema
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Draft is the JSON Schema dialect of every document.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// EnvelopeVersion is the version of the response envelopes — the data,
+// links and error shapes shared by every response — sent in the
+// X-Envelope-Version header.  It changes only when an envelope changes
+// incompatibly.
+const EnvelopeVersion = "1"
+
+// Schema is a JSON Schema, limited to the keywords the derivation uses.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 any                `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	ContentEncoding      string             `json:"contentEncoding,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+	Examples             []any              `json:"examples,omitempty"`
+
+	// OpenAPI 2 spellings, used only by Registry.Definitions.
+	Example  any  `json:"example,omitempty"`
+	Nullable bool `json:"x-nullable,omitempty"`
+}
+
+var (
+	timeType      = reflect.TypeFor[time.Time]()
+	marshalerType = reflect.TypeFor[json.Marshaler]()
+	textType      = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// Name returns the name a type is registered under: its Go name, prefixed
+// with its package's name unless that is models, so that elo.Rating is
+// EloRating and models.TeamResponse is TeamResponse.
+func Name(v any) string {
+	return typeName(indirect(reflect.TypeOf(v)))
+}
+
+func typeName(t reflect.Type) string {
+	pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+	if pkg == "models" || pkg == "" {
+		return t.Name()
+	}
+	r := []rune(pkg)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r) + t.Name()
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// For returns the schema of v's type as a response: fields are required
+// unless omitempty, as encoding/json always writes them.  The named struct
+// types it uses are under $defs.
+func For(v any) *Schema {
+	return root(indirect(reflect.TypeOf(v)), false)
+}
+
+// ForRequest returns the schema of v's type as a request body: fields are
+// required only by a binding:"required" rule, as gin's binding leaves
+// missing fields zero.
+func ForRequest(v any) *Schema {
+	return root(indirect(reflect.TypeOf(v)), true)
+}
+
+func root(t reflect.Type, request bool) *Schema {
+	g := generator{request: request, defs: map[string]*Schema{}}
+	s := g.object(t)
+	s.Schema = Draft
+	s.Title = typeName(t)
+	if len(g.defs) > 0 {
+		s.Defs = g.defs
+	}
+	return s
+}
+
+// generator derives schemas.  Named struct types are described once in
+// defs and referred to by $ref; openAPI switches to OpenAPI 2's flavour of
+// schema, which has no null type and keys definitions by swag's names.
+type generator struct {
+	request bool
+	openAPI bool
+	defs    map[string]*Schema
+}
+
+func (g *generator) ref(t reflect.Type) (key, ref string) {
+	if g.openAPI {
+		key = swagName(t)
+		return key, "#/definitions/" + key
+	}
+	key = typeName(t)
+	return key, "#/$defs/" + key
+}
+
+func (g *generator) schema(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Pointer:
+		s := g.schema(t.Elem())
+		if g.openAPI {
+			if s.Ref == "" {
+				s.Nullable = true
+			}
+			return s
+		}
+		if typ, ok := s.Type.(string); ok && s.Ref == "" {
+			s.Type = []string{typ, "null"}
+			return s
+		}
+		return &Schema{AnyOf: []*Schema{s, {Type: "null"}}}
+	case t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType):
+		// Custom encodings, json.RawMessage among them, could be anything.
+		return &Schema{}
+	case t.Implements(textType) || reflect.PointerTo(t).Implements(textType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := 0.0
+		return &Schema{Type: "integer", Minimum: &zero}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			if g.openAPI {
+				return &Schema{Type: "string", Format: "byte"}
+			}
+			return &Schema{Type: "string", ContentEncoding: "base64"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		key, ref := g.ref(t)
+		if _, ok := g.defs[key]; !ok {
+			g.defs[key] = nil // placeholder, for recursive types
+			g.defs[key] = g.object(t)
+			if !g.openAPI {
+				g.defs[key].Title = key
+			}
+		}
+		return &Schema{Ref: ref}
+	default:
+		// Interfaces hold any JSON value.
+		return &Schema{}
+	}
+}
+
+// object describes a struct's JSON fields, as encoding/json would encode
+// them: embedded structs without a tag are flattened.
+func (g *generator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.fields(t, s)
+	return s
+}
+
+func (g *generator) fields(t reflect.Type, s *Schema) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && indirect(f.Type).Kind() == reflect.Struct {
+			g.fields(indirect(f.Type), s)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fs := g.schema(f.Type)
+		if slices.Contains(strings.Split(opts, ","), "string") {
+			fs = &Schema{Type: "string"}
+		}
+		required := g.constrain(fs, f.Tag.Get("binding"))
+		if !g.request {
+			required = !slices.Contains(strings.Split(opts, ","), "omitempty")
+		}
+		g.example(fs, f.Tag.Get("example"))
+		s.Properties[name] = fs
+		if required {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// constrain adds a field's binding rules to its schema and reports whether
+// they include required.  Bounds apply to the length of strings, the
+// number of items of arrays and maps, and the value of numbers, as in the
+// validator gin uses.  Rules with no JSON Schema counterpart are skipped.
+func (g *generator) constrain(s *Schema, binding string) (required bool) {
+	if s.Ref != "" {
+		return binding != "" && slices.Contains(strings.Split(binding, ","), "required")
+	}
+	kind, _ := s.Type.(string)
+	if types, ok := s.Type.([]string); ok {
+		kind = types[0]
+	}
+	for _, rule := range strings.Split(binding, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				s.Enum = append(s.Enum, parseValue(kind, v))
+			}
+		case "email":
+			s.Format = "email"
+		case "url", "uri":
+			s.Format = "uri"
+		case "min", "gte", "max", "lte", "len":
+			n, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+			lower := name == "min" || name == "gte" || name == "len"
+			upper := name == "max" || name == "lte" || name == "len"
+			switch kind {
+			case "string":
+				if lower {
+					s.MinLength = ptr(int(n))
+				}
+				if upper {
+					s.MaxLength = ptr(int(n))
+				}
+			case "array":
+				if lower {
+					s.MinItems = ptr(int(n))
+				}
+				if upper {
+					s.MaxItems = ptr(int(n))
+				}
+			case "integer", "number":
+				if lower {
+					s.Minimum = ptr(n)
+				}
+				if upper {
+					s.Maximum = ptr(n)
+				}
+			}
+		}
+	}
+	return required
+}
+
+// example adds a swag example tag, parsed as the field's type.
+func (g *generator) example(s *Schema, example string) {
+	if example == "" {
+		return
+	}
+	kind, _ := s.Type.(string)
+	if types, ok := s.Type.([]string); ok {
+		kind = types[0]
+	}
+	v := parseValue(kind, example)
+	if g.openAPI {
+		s.Example = v
+	} else {
+		s.Examples = []any{v}
+	}
+}
+
+// parseValue parses a tag value as a JSON value of the given schema type,
+// falling back to the string itself.
+func parseValue(kind, v string) any {
+	switch kind {
+	case "integer":
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// swagName is the name swag gives a type's definition: models.TeamResponse.
+func swagName(t reflect.Type) string {
+	return t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:] + "." + t.Name()
+}
+
+// Registry holds the schemas of the types the API documents, by Name.
+type Registry struct {
+	mu    sync.RWMutex
+	types map[string]entry
+}
+
+type entry struct {
+	t       reflect.Type
+	request bool
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{types: map[string]entry{}}
+}
+
+// Add registers v's type as a response and returns its name.  It panics if
+// a different type is already registered under that name.
+func (r *Registry) Add(v any) string {
+	return r.add(v, false)
+}
+
+// AddRequest registers v's type as a request body and returns its name.
+func (r *Registry) AddRequest(v any) string {
+	return r.add(v, true)
+}
+
+func (r *Registry) add(v any, request bool) string {
+	t := indirect(reflect.TypeOf(v))
+	name := typeName(t)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if other, ok := r.types[name]; ok && other.t != t {
+		panic(fmt.Sprintf("schema: %s and %s are both named %s", other.t, t, name))
+	}
+	r.types[name] = entry{t: t, request: request}
+	return name
+}
+
+// Names returns the registered names in order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.types))
+	for name := range r.types {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Lookup returns the schema registered under name, with id as its $id.
+func (r *Registry) Lookup(name, id string) (*Schema, bool) {
+	r.mu.RLock()
+	e, ok := r.types[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	s := root(e.t, e.request)
+	s.ID = id
+	return s, true
+}
+
+// Definitions returns the registered types, and the struct types they use,
+// as OpenAPI 2 definitions keyed by swag's names, for merging into the
+// Swagger document in place of the ones swag generated.  A struct used by
+// both a request and a response type is described as part of whichever
+// name sorts first.
+func (r *Registry) Definitions() map[string]*Schema {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	defs := map[string]*Schema{}
+	names := make([]string, 0, len(r.types))
+	for name := range r.types {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		e := r.types[name]
+		g := generator{request: e.request, openAPI: true, defs: defs}
+		g.schema(e.t)
+	}
+	return defs
+}
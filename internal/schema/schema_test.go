@@ -0,0 +1,164 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/elo"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/schema"
+)
+
+type base struct {
+	ID int `json:"id"`
+}
+
+type node struct {
+	base
+	Name     string          `json:"name"`
+	Note     string          `json:"note,omitempty"`
+	Score    *int            `json:"score"`
+	At       time.Time       `json:"at"`
+	Count    uint            `json:"count,string"`
+	Tags     map[string]bool `json:"tags,omitempty"`
+	Children []node          `json:"children"`
+	Raw      json.RawMessage `json:"raw,omitempty"`
+	Links    []models.Link   `json:"links"`
+	Ignored  string          `json:"-"`
+	hidden   string
+}
+
+func TestFor(t *testing.T) {
+	s := schema.For(node{})
+	if s.Schema != schema.Draft || s.Title != "Schema_testnode" || s.Type != "object" {
+		t.Fatalf("unexpected root %+v", s)
+	}
+	want := []string{"id", "name", "score", "at", "count", "children", "links"}
+	if !slices.Equal(s.Required, want) {
+		t.Fatalf("expected required %v, got %v", want, s.Required)
+	}
+	if len(s.Properties) != 10 {
+		t.Fatalf("expected 10 properties, got %d", len(s.Properties))
+	}
+	p := s.Properties
+	if typ, _ := p["score"].Type.([]string); !slices.Equal(typ, []string{"integer", "null"}) {
+		t.Fatalf("expected a nullable integer, got %+v", p["score"])
+	}
+	if p["at"].Format != "date-time" || p["count"].Type != "string" || p["raw"].Type != nil {
+		t.Fatalf("unexpected at/count/raw %+v %+v %+v", p["at"], p["count"], p["raw"])
+	}
+	if p["children"].Items.Ref != "#/$defs/Schema_testnode" || p["links"].Items.Ref != "#/$defs/Link" {
+		t.Fatalf("expected refs to $defs, got %+v %+v", p["children"].Items, p["links"].Items)
+	}
+	if s.Defs["Link"] == nil || !slices.Equal(s.Defs["Link"].Required, []string{"rel", "href", "method"}) {
+		t.Fatalf("unexpected Link def %+v", s.Defs["Link"])
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	reg := schema.NewRegistry()
+	if name := reg.Add(models.TeamResponse{}); name != "TeamResponse" {
+		t.Fatalf("expected TeamResponse, got %s", name)
+	}
+	if name := reg.Add(&elo.Rating{}); name != "EloRating" {
+		t.Fatalf("expected EloRating, got %s", name)
+	}
+	if names := reg.Names(); !slices.Equal(names, []string{"EloRating", "TeamResponse"}) {
+		t.Fatalf("unexpected names %v", names)
+	}
+	s, ok := reg.Lookup("TeamResponse", "/schemas/TeamResponse")
+	if !ok || s.ID != "/schemas/TeamResponse" {
+		t.Fatalf("unexpected lookup %+v", s)
+	}
+	if _, ok := reg.Lookup("Nope", ""); ok {
+		t.Fatal("expected an unknown name to be missing")
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(schema.Describe("/schemas/", schema.Routes{"GET /teams/:id": "TeamResponse"}, "ErrorResponse"))
+	r.GET("/teams/:id", func(c *gin.Context) {
+		if c.Param("id") == "0" {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "not found"})
+			return
+		}
+		c.JSON(http.StatusOK, models.TeamResponse{})
+	})
+	r.GET("/other", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{}) })
+	r.GET("/text", func(c *gin.Context) { c.String(http.StatusOK, "hi") })
+
+	get := func(path string) http.Header {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Header()
+	}
+	h := get("/teams/1")
+	if h.Get("X-Schema") != "/schemas/TeamResponse" || h.Get("Link") != `</schemas/TeamResponse>; rel="describedby"` {
+		t.Fatalf("unexpected headers %v", h)
+	}
+	if h.Get("X-Envelope-Version") != schema.EnvelopeVersion {
+		t.Fatalf("expected the envelope version, got %v", h)
+	}
+	if got := get("/teams/0").Get("X-Schema"); got != "/schemas/ErrorResponse" {
+		t.Fatalf("expected the error schema, got %q", got)
+	}
+	if h := get("/other"); h.Get("X-Schema") != "" || h.Get("X-Envelope-Version") == "" {
+		t.Fatalf("expected only the version for an undescribed route, got %v", h)
+	}
+	if h := get("/text"); h.Get("X-Envelope-Version") != "" {
+		t.Fatalf("expected nothing on a non-JSON response, got %v", h)
+	}
+}
+
+func TestForRequest(t *testing.T) {
+	s := schema.ForRequest(models.CreateMatchRequest{})
+	want := []string{"date", "homeTeamId", "awayTeamId", "tournamentId"}
+	if !slices.Equal(s.Required, want) {
+		t.Fatalf("expected required %v, got %v", want, s.Required)
+	}
+	if m := s.Properties["homeTeamId"].Minimum; m == nil || *m != 1 {
+		t.Fatalf("expected homeTeamId minimum 1, got %+v", s.Properties["homeTeamId"])
+	}
+	if m := s.Properties["homeScore"].Minimum; m == nil || *m != 0 {
+		t.Fatalf("expected homeScore minimum 0, got %+v", s.Properties["homeScore"])
+	}
+
+	s = schema.ForRequest(models.AnnouncementRequest{})
+	title := s.Properties["title"]
+	if title.MaxLength == nil || *title.MaxLength != 200 || title.MinLength != nil {
+		t.Fatalf("expected title max length 200, got %+v", title)
+	}
+	if len(title.Examples) != 1 || title.Examples[0] != "Scheduled maintenance" {
+		t.Fatalf("expected the example, got %v", title.Examples)
+	}
+
+	reason := schema.ForRequest(models.ReportRequest{}).Properties["reason"]
+	if len(reason.Enum) != 5 || reason.Enum[0] != "spam" {
+		t.Fatalf("expected the oneof values as an enum, got %v", reason.Enum)
+	}
+}
+
+func TestRegistry_Definitions(t *testing.T) {
+	reg := schema.NewRegistry()
+	reg.Add(models.TeamsResponse{})
+	reg.AddRequest(models.FeatureFlagRequest{})
+	defs := reg.Definitions()
+	teams := defs["models.TeamsResponse"]
+	if teams == nil || teams.Properties["data"].Items.Ref != "#/definitions/models.TeamResponse" {
+		t.Fatalf("unexpected teams definition %+v", teams)
+	}
+	if defs["models.TeamResponse"] == nil || defs["models.Link"] == nil {
+		t.Fatalf("expected referenced definitions, got %v", defs)
+	}
+	enabled := defs["models.FeatureFlagRequest"].Properties["enabled"]
+	if enabled.Type != "boolean" || !enabled.Nullable || enabled.Example != false {
+		t.Fatalf("expected a nullable boolean with its example, got %+v", enabled)
+	}
+}
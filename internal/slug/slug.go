@@ -0,0 +1,68 @@
+// Package slug makes URL-friendly identifiers from names: lower-case ASCII
+// letters and digits separated by single hyphens, with accents removed
+// ("Côte d'Ivoire" becomes "cote-d-ivoire").  Slugs that would collide are
+// told apart by a numeric suffix ("-2", "-3", ...).
+package slug
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxLen is the longest slug Make returns, leaving room in a 110-character
+// column for a collision suffix.
+const MaxLen = 100
+
+// Make returns the slug of name, or "" if name has no ASCII letters or
+// digits once accents are removed.
+func Make(name string) string {
+	var b strings.Builder
+	gap := false
+	for _, r := range norm.NFKD.String(name) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Combining accents split off by NFKD.
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if gap && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			gap = false
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			gap = true
+		}
+	}
+	s := b.String()
+	if len(s) > MaxLen {
+		s = strings.TrimRight(s[:MaxLen], "-")
+	}
+	return s
+}
+
+// Unique returns base if it is not taken, or else base with the smallest
+// suffix from "-2" up that is not.
+func Unique(base string, taken func(string) bool) string {
+	s := base
+	for n := 2; taken(s); n++ {
+		s = base + "-" + strconv.Itoa(n)
+	}
+	return s
+}
+
+// Of reports whether s is base or base with a collision suffix, as Unique
+// returns, so that a renamed record whose name still yields base can keep
+// its slug.
+func Of(s, base string) bool {
+	if s == base {
+		return true
+	}
+	n, ok := strings.CutPrefix(s, base+"-")
+	if !ok {
+		return false
+	}
+	i, err := strconv.Atoi(n)
+	return err == nil && i >= 2 && strconv.Itoa(i) == n
+}
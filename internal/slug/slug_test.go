@@ -0,0 +1,53 @@
+package slug_test
+
+import (
+	"testing"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/slug"
+)
+
+func TestMake(t *testing.T) {
+	cases := map[string]string{
+		"England":                 "england",
+		"  Trinidad & Tobago ":    "trinidad-tobago",
+		"C\u00f4te d'Ivoire":      "cote-d-ivoire",
+		"Curac\u0327ao":           "curacao",
+		"S\u00e3o Tom\u00e9 e Pr": "sao-tome-e-pr",
+		"Korea DPR (1948)":        "korea-dpr-1948",
+		"\u4e2d\u56fd":            "",
+		"---":                     "",
+	}
+	for in, want := range cases {
+		if got := slug.Make(in); got != want {
+			t.Errorf("Make(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestUnique(t *testing.T) {
+	taken := map[string]bool{"england": true, "england-2": true}
+	if got := slug.Unique("england", func(s string) bool { return taken[s] }); got != "england-3" {
+		t.Fatalf("Unique = %q, want england-3", got)
+	}
+	if got := slug.Unique("wales", func(s string) bool { return taken[s] }); got != "wales" {
+		t.Fatalf("Unique = %q, want wales", got)
+	}
+}
+
+func TestOf(t *testing.T) {
+	for s, want := range map[string]bool{
+		"england":     true,
+		"england-2":   true,
+		"england-12":  true,
+		"england-1":   false,
+		"england-02":  false,
+		"england-b":   false,
+		"new-england": false,
+		"englandia":   false,
+		"england-2-2": false,
+	} {
+		if got := slug.Of(s, "england"); got != want {
+			t.Errorf("Of(%q, england) = %v, want %v", s, got, want)
+		}
+	}
+}
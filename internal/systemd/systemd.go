@@ -0,0 +1,125 @@
+// Package systemd implements the parts of the systemd service protocol the
+// server uses without depending on libsystemd: socket activation
+// (LISTEN_FDS), readiness and status notifications (NOTIFY_SOCKET) and
+// watchdog keep-alives (WATCHDOG_USEC).  Every function is a no-op when the
+// process was not started by systemd.
+package systemd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// listenFDsStart is the first file descriptor passed by socket activation.
+const listenFDsStart = 3
+
+// Notification states understood by systemd; see sd_notify(3).
+const (
+	Ready     = "READY=1"
+	Stopping  = "STOPPING=1"
+	Reloading = "RELOADING=1"
+	Watchdog  = "WATCHDOG=1"
+)
+
+// Listeners returns the sockets passed by systemd socket activation, in the
+// order of the ListenStream= lines of the .socket unit, or nil when there
+// are none.  The environment variables are cleared so that child processes
+// do not inherit them.
+func Listeners() ([]net.Listener, error) {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, nil
+	}
+
+	listeners := make([]net.Listener, 0, n)
+	for fd := listenFDsStart; fd < listenFDsStart+n; fd++ {
+		f := os.NewFile(uintptr(fd), "LISTEN_FD_"+strconv.Itoa(fd))
+		ln, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("systemd: socket %d is not a stream listener: %w", fd, err)
+		}
+		listeners = append(listeners, ln)
+	}
+	return listeners, nil
+}
+
+// Notify sends state (such as Ready or "STATUS=…") to the service manager.
+// It reports false, with no error, when NOTIFY_SOCKET is unset.
+func Notify(state string) (bool, error) {
+	addr := os.Getenv("NOTIFY_SOCKET")
+	if addr == "" {
+		return false, nil
+	}
+	// A leading @ names a socket in the abstract namespace.
+	if addr[0] == '@' {
+		addr = "\x00" + addr[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		return false, fmt.Errorf("systemd: notify: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, fmt.Errorf("systemd: notify: %w", err)
+	}
+	return true, nil
+}
+
+// WatchdogInterval returns the watchdog timeout configured with
+// WatchdogSec=, or zero when the watchdog is not enabled for this process.
+func WatchdogInterval() time.Duration {
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
+
+// RunWatchdog sends a watchdog keep-alive every half interval until ctx is
+// done, but only while healthy reports no error: a server that is running
+// but cannot do its job stops pinging and is restarted by systemd once the
+// interval passes.  It returns at once when interval is zero.
+func RunWatchdog(ctx context.Context, interval time.Duration, healthy func(context.Context) error) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		checkCtx, cancel := context.WithTimeout(ctx, interval/2)
+		err := healthy(checkCtx)
+		cancel()
+		if err != nil {
+			log.Printf("WARNING: health check failed, withholding watchdog keep-alive: %v", err)
+			continue
+		}
+		if _, err := Notify(Watchdog); err != nil {
+			log.Printf("watchdog: %v", err)
+		}
+	}
+}
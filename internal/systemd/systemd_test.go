@@ -0,0 +1,110 @@
+package systemd_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/systemd"
+)
+
+// notifySocket listens where NOTIFY_SOCKET points for the duration of t.
+func notifySocket(t *testing.T) *net.UnixConn {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	t.Setenv("NOTIFY_SOCKET", path)
+	return conn
+}
+
+func receive(t *testing.T, conn *net.UnixConn) string {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 256)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("read notification: %v", err)
+	}
+	return string(buf[:n])
+}
+
+func TestNotify(t *testing.T) {
+	conn := notifySocket(t)
+	sent, err := systemd.Notify(systemd.Ready)
+	if err != nil || !sent {
+		t.Fatalf("Notify: sent=%v err=%v", sent, err)
+	}
+	if got := receive(t, conn); got != systemd.Ready {
+		t.Errorf("expected %q, got %q", systemd.Ready, got)
+	}
+}
+
+func TestNotify_WithoutSystemd(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	if sent, err := systemd.Notify(systemd.Ready); sent || err != nil {
+		t.Errorf("expected a silent no-op, got sent=%v err=%v", sent, err)
+	}
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "30000000")
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	if got := systemd.WatchdogInterval(); got != 30*time.Second {
+		t.Errorf("expected 30s, got %v", got)
+	}
+	t.Setenv("WATCHDOG_PID", "1")
+	if got := systemd.WatchdogInterval(); got != 0 {
+		t.Errorf("expected 0 for another process's watchdog, got %v", got)
+	}
+}
+
+func TestRunWatchdog_PingsOnlyWhenHealthy(t *testing.T) {
+	conn := notifySocket(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	healthy := make(chan error, 1)
+	healthy <- errors.New("database down")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		systemd.RunWatchdog(ctx, 20*time.Millisecond, func(context.Context) error {
+			select {
+			case err := <-healthy:
+				return err
+			default:
+				return nil
+			}
+		})
+	}()
+
+	if got := receive(t, conn); got != systemd.Watchdog {
+		t.Errorf("expected %q, got %q", systemd.Watchdog, got)
+	}
+	if len(healthy) != 0 {
+		t.Error("expected the failing check to run before the first keep-alive")
+	}
+	cancel()
+	<-done
+}
+
+func TestListeners_NotActivated(t *testing.T) {
+	t.Setenv("LISTEN_PID", "")
+	t.Setenv("LISTEN_FDS", "1")
+	ls, err := systemd.Listeners()
+	if err != nil || ls != nil {
+		t.Errorf("expected no listeners, got %v, %v", ls, err)
+	}
+	if os.Getenv("LISTEN_FDS") != "" {
+		t.Error("expected LISTEN_FDS to be cleared")
+	}
+}
@@ -0,0 +1,190 @@
+// Package testsupport runs the complete API in-process on the in-memory
+// store, so test suites can exercise real routes and middleware without a
+// database and without copying router and request boilerplate:
+//
+//	api := testsupport.New(t)
+//	cup := api.Store.Football().AddTournament("Friendly")
+//	alice := api.As("alice")
+//	team, w := testsupport.Post[models.TeamResponse](alice, "/api/v1/football/teams", models.CreateTeamRequest{Name: "England"})
+//	testsupport.AssertStatus(t, w, http.StatusCreated)
+package testsupport
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/clock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/memory"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/router"
+)
+
+// JWTSecret signs the tokens of every API started by New.
+const JWTSecret = "testsupport-secret"
+
+// Epoch is the time at which every API started by New has its clock frozen.
+var Epoch = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// API is the server under test.
+type API struct {
+	// Store holds the API's data; seed it directly or inspect it after a
+	// request.
+	Store *memory.Store
+	// Handler serves the API.
+	Handler http.Handler
+	// Clock is the API's clock, frozen at Epoch; advance it to test expiry.
+	Clock *clock.Fake
+	// IDs numbers new sessions "session-1", "session-2" …
+	IDs *auth.SequentialIDs
+
+	t   testing.TB
+	jwt *auth.JWTService
+}
+
+// New starts the API on an empty in-memory store.  configure, when given,
+// may adjust the router configuration (admin users, plugins, events …)
+// before the router is built.
+func New(t testing.TB, configure ...func(*router.Config)) *API {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	clk := clock.NewFake(Epoch)
+	ids := &auth.SequentialIDs{Prefix: "session-"}
+	store := memory.New()
+	store.SetClock(clk)
+	cfg := router.Config{
+		JWTSecret:    JWTSecret,
+		Repositories: store.Repositories(),
+		Clock:        clk,
+		IDs:          ids,
+		// Fast hashing keeps register/login tests quick.
+		PasswordHashing: auth.Argon2Params{Memory: 8 * 1024, Iterations: 1, Parallelism: 1},
+	}
+	for _, fn := range configure {
+		fn(&cfg)
+	}
+	jwt := auth.NewJWTService(JWTSecret, router.TokenIssuer)
+	jwt.SetClock(clk)
+	return &API{
+		Store:   store,
+		Handler: router.New(cfg),
+		Clock:   clk,
+		IDs:     ids,
+		t:       t,
+		jwt:     jwt,
+	}
+}
+
+// Token mints a valid access token for username.  The user need not exist.
+func (a *API) Token(username string) string {
+	a.t.Helper()
+	token, err := a.jwt.GenerateToken(username)
+	if err != nil {
+		a.t.Fatalf("testsupport: mint token: %v", err)
+	}
+	return token
+}
+
+// Anonymous returns a client that sends no credentials.
+func (a *API) Anonymous() *Client {
+	return &Client{api: a}
+}
+
+// As returns a client authenticated as username.
+func (a *API) As(username string) *Client {
+	return &Client{api: a, header: http.Header{"Authorization": {"Bearer " + a.Token(username)}}}
+}
+
+// Client sends requests to an API.
+type Client struct {
+	api    *API
+	header http.Header
+}
+
+// WithHeader returns a copy of c that also sends the header key: value.
+func (c *Client) WithHeader(key, value string) *Client {
+	h := c.header.Clone()
+	if h == nil {
+		h = http.Header{}
+	}
+	h.Set(key, value)
+	return &Client{api: c.api, header: h}
+}
+
+// Do sends a request with body encoded as JSON (none when body is nil; a
+// []byte or string is sent as-is) and returns the recorded response.
+func (c *Client) Do(method, path string, body interface{}) *httptest.ResponseRecorder {
+	c.api.t.Helper()
+	var r io.Reader
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		r = bytes.NewReader(b)
+	case string:
+		r = bytes.NewReader([]byte(b))
+	default:
+		raw, err := json.Marshal(b)
+		if err != nil {
+			c.api.t.Fatalf("testsupport: encode request body: %v", err)
+		}
+		r = bytes.NewReader(raw)
+	}
+	req := httptest.NewRequest(method, path, r)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	c.api.Handler.ServeHTTP(w, req)
+	return w
+}
+
+// Get sends a GET and decodes a 2xx JSON response into T.
+func Get[T any](c *Client, path string) (T, *httptest.ResponseRecorder) {
+	return send[T](c, http.MethodGet, path, nil)
+}
+
+// Post sends body as a JSON POST and decodes a 2xx response into T.
+func Post[T any](c *Client, path string, body interface{}) (T, *httptest.ResponseRecorder) {
+	return send[T](c, http.MethodPost, path, body)
+}
+
+// Put sends body as a JSON PUT and decodes a 2xx response into T.
+func Put[T any](c *Client, path string, body interface{}) (T, *httptest.ResponseRecorder) {
+	return send[T](c, http.MethodPut, path, body)
+}
+
+// Delete sends a DELETE.
+func (c *Client) Delete(path string) *httptest.ResponseRecorder {
+	return c.Do(http.MethodDelete, path, nil)
+}
+
+// send decodes the response into T only on success, so callers can assert
+// on error statuses without a decoding failure getting in the way.
+func send[T any](c *Client, method, path string, body interface{}) (T, *httptest.ResponseRecorder) {
+	c.api.t.Helper()
+	var out T
+	w := c.Do(method, path, body)
+	if w.Code >= 200 && w.Code < 300 && w.Body.Len() > 0 {
+		if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+			c.api.t.Fatalf("testsupport: decode %s %s response: %v\n%s", method, path, err, w.Body.String())
+		}
+	}
+	return out, w
+}
+
+// AssertStatus fails the test unless the response has status want.
+func AssertStatus(t testing.TB, w *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if w.Code != want {
+		t.Fatalf("expected status %d, got %d: %s", want, w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,99 @@
+package testsupport_test
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/auth"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/testsupport"
+)
+
+func TestAPI_CRUDFlow(t *testing.T) {
+	api := testsupport.New(t)
+	cup := api.Store.Football().AddTournament("FIFA World Cup")
+	alice := api.As("alice")
+
+	eng, w := testsupport.Post[models.TeamResponse](alice, "/api/v1/football/teams", models.CreateTeamRequest{Name: "England"})
+	testsupport.AssertStatus(t, w, http.StatusCreated)
+	ger, _ := testsupport.Post[models.TeamResponse](alice, "/api/v1/football/teams", models.CreateTeamRequest{Name: "Germany"})
+
+	created, w := testsupport.Post[models.MatchResponse](alice, "/api/v1/football/matches", models.CreateMatchRequest{
+		Date:         time.Date(1990, 7, 4, 0, 0, 0, 0, time.UTC),
+		HomeTeamID:   eng.ID,
+		AwayTeamID:   ger.ID,
+		HomeScore:    1,
+		AwayScore:    1,
+		TournamentID: cup.ID,
+	})
+	testsupport.AssertStatus(t, w, http.StatusCreated)
+	if created.HomeTeam != "England" || created.Tournament != "FIFA World Cup" {
+		t.Fatalf("names not joined: %+v", created.Match)
+	}
+
+	list, w := testsupport.Get[models.MatchesResponse](api.Anonymous(), "/api/v1/football/matches")
+	testsupport.AssertStatus(t, w, http.StatusOK)
+	if len(list.Data) != 1 {
+		t.Fatalf("expected 1 match, got %d", len(list.Data))
+	}
+
+	w = alice.Delete("/api/v1/football/matches/" + strconv.Itoa(created.ID))
+	testsupport.AssertStatus(t, w, http.StatusNoContent)
+}
+
+func TestAPI_RequiresAuthForWrites(t *testing.T) {
+	api := testsupport.New(t)
+	_, w := testsupport.Post[models.TeamResponse](api.Anonymous(), "/api/v1/football/teams", models.CreateTeamRequest{Name: "England"})
+	testsupport.AssertStatus(t, w, http.StatusUnauthorized)
+}
+
+func TestAPI_RegisterAndLogin(t *testing.T) {
+	api := testsupport.New(t)
+	anon := api.Anonymous()
+	creds := models.RegisterRequest{Username: "Bob", Password: "correct horse battery"}
+
+	_, w := testsupport.Post[map[string]interface{}](anon, "/api/v1/auth/register", creds)
+	testsupport.AssertStatus(t, w, http.StatusCreated)
+
+	login, w := testsupport.Post[models.LoginResponse](anon, "/api/v1/auth/login",
+		models.LoginRequest{Username: "bob", Password: creds.Password})
+	testsupport.AssertStatus(t, w, http.StatusOK)
+
+	sessions, w := testsupport.Get[models.SessionListResponse](
+		anon.WithHeader("Authorization", "Bearer "+login.Token), "/api/v1/me/sessions")
+	testsupport.AssertStatus(t, w, http.StatusOK)
+	if len(sessions.Sessions) != 1 {
+		t.Fatalf("expected 1 session, got %d", len(sessions.Sessions))
+	}
+}
+
+func TestAPI_FrozenClock(t *testing.T) {
+	api := testsupport.New(t)
+	anon := api.Anonymous()
+	creds := models.RegisterRequest{Username: "carol", Password: "correct horse battery"}
+	_, w := testsupport.Post[map[string]interface{}](anon, "/api/v1/auth/register", creds)
+	testsupport.AssertStatus(t, w, http.StatusCreated)
+	login, w := testsupport.Post[models.LoginResponse](anon, "/api/v1/auth/login",
+		models.LoginRequest{Username: creds.Username, Password: creds.Password})
+	testsupport.AssertStatus(t, w, http.StatusOK)
+	carol := anon.WithHeader("Authorization", "Bearer "+login.Token)
+
+	sessions, w := testsupport.Get[models.SessionListResponse](carol, "/api/v1/me/sessions")
+	testsupport.AssertStatus(t, w, http.StatusOK)
+	s := sessions.Sessions[0]
+	if s.ID != "session-1" {
+		t.Errorf("session ID = %q, want session-1", s.ID)
+	}
+	if !s.CreatedAt.Equal(testsupport.Epoch) {
+		t.Errorf("createdAt = %v, want %v", s.CreatedAt, testsupport.Epoch)
+	}
+	if want := testsupport.Epoch.Add(auth.TokenTTL); !s.ExpiresAt.Equal(want) {
+		t.Errorf("expiresAt = %v, want %v", s.ExpiresAt, want)
+	}
+
+	api.Clock.Advance(auth.TokenTTL + time.Second)
+	_, w = testsupport.Get[models.SessionListResponse](carol, "/api/v1/me/sessions")
+	testsupport.AssertStatus(t, w, http.StatusUnauthorized)
+}
@@ -0,0 +1,144 @@
+// Package upgrade replaces a running server with a new binary without
+// closing its listening socket, for zero-downtime deploys on hosts without
+// a load balancer in front:
+//
+//  1. The old process receives SIGUSR2, and Spawn re-executes the
+//     binary on disk with the listening sockets as inherited files.
+//  2. The new process takes the sockets with Inherited, starts serving and
+//     calls Ready.
+//  3. Spawn returns in the old process, which then drains in-flight requests
+//     and exits as it would on SIGTERM.
+//
+// The kernel queues connections on the shared sockets throughout, so none
+// are refused.  If the new process fails to become ready the old one keeps
+// serving.
+package upgrade
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// Environment variables describing the descriptors passed to the new
+// process: the number of listeners, starting at descriptor 3, and the pipe
+// on which to report readiness.
+const (
+	listenersEnv = "UPGRADE_LISTENERS"
+	readyFDEnv   = "UPGRADE_READY_FD"
+)
+
+// firstFD is the descriptor of the first inherited listener.
+const firstFD = 3
+
+// Inherited returns the listening sockets handed over by the previous
+// process, in the order given to Spawn, or nil when this process was not
+// started by Spawn.
+func Inherited() ([]net.Listener, error) {
+	n, err := takeInt(listenersEnv)
+	if err != nil || n <= 0 {
+		return nil, err
+	}
+	listeners := make([]net.Listener, 0, n)
+	for fd := firstFD; fd < firstFD+n; fd++ {
+		f := os.NewFile(uintptr(fd), "upgrade-listener-"+strconv.Itoa(fd))
+		ln, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("upgrade: inherited socket %d: %w", fd, err)
+		}
+		listeners = append(listeners, ln)
+	}
+	return listeners, nil
+}
+
+// Ready tells the previous process that this one is serving, so that it can
+// drain and exit.  It is a no-op when this process was not started by Spawn.
+func Ready() error {
+	fd, err := takeInt(readyFDEnv)
+	if err != nil || fd < 0 {
+		return err
+	}
+	f := os.NewFile(uintptr(fd), "upgrade-ready")
+	defer f.Close()
+	if _, err := f.Write([]byte{1}); err != nil {
+		return fmt.Errorf("upgrade: signal readiness: %w", err)
+	}
+	return nil
+}
+
+// takeInt reads and clears a number from the environment, so that a later
+// upgrade of this process does not see stale values.  It returns -1 when
+// the variable is unset.
+func takeInt(env string) (int, error) {
+	v := os.Getenv(env)
+	if v == "" {
+		return -1, nil
+	}
+	os.Unsetenv(env)
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return -1, fmt.Errorf("upgrade: %s=%q is not a number", env, v)
+	}
+	return n, nil
+}
+
+// Spawn starts the current executable with the same arguments and
+// environment, handing it listeners, and waits up to timeout for it to call
+// Ready.  On failure the new process is killed and the caller should carry
+// on serving.
+func Spawn(listeners []*os.File, timeout time.Duration) (*os.Process, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("upgrade: %w", err)
+	}
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("upgrade: %w", err)
+	}
+	defer readyR.Close()
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	// ExtraFiles[i] becomes descriptor 3+i in the new process.
+	cmd.ExtraFiles = append(append([]*os.File(nil), listeners...), readyW)
+	cmd.Env = append(os.Environ(),
+		listenersEnv+"="+strconv.Itoa(len(listeners)),
+		readyFDEnv+"="+strconv.Itoa(firstFD+len(listeners)))
+	err = cmd.Start()
+	readyW.Close()
+	if err != nil {
+		return nil, fmt.Errorf("upgrade: start %s: %w", exe, err)
+	}
+
+	ready := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		_, err := readyR.Read(buf)
+		ready <- err
+	}()
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	select {
+	case err := <-ready:
+		if err == nil {
+			return cmd.Process, nil
+		}
+		// The pipe closed without a byte: the new process gave up.
+		cmd.Process.Kill()
+		return nil, errors.New("upgrade: new process closed the readiness pipe")
+	case err := <-exited:
+		return nil, fmt.Errorf("upgrade: new process exited before becoming ready: %v", err)
+	case <-time.After(timeout):
+		cmd.Process.Kill()
+		return nil, fmt.Errorf("upgrade: new process not ready after %v", timeout)
+	}
+}
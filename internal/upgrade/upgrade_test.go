@@ -0,0 +1,83 @@
+package upgrade_test
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/upgrade"
+)
+
+// TestMain runs the "new process" side when the test binary is re-executed
+// by Spawn: it serves one request on the inherited socket, reporting its
+// PID, and exits.
+func TestMain(m *testing.M) {
+	ls, err := upgrade.Inherited()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if ls == nil {
+		os.Exit(m.Run())
+	}
+	served := make(chan struct{})
+	go http.Serve(ls[0], http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, strconv.Itoa(os.Getpid()))
+		close(served)
+	}))
+	if err := upgrade.Ready(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	select {
+	case <-served:
+		time.Sleep(100 * time.Millisecond)
+	case <-time.After(10 * time.Second):
+	}
+	os.Exit(0)
+}
+
+func TestSpawn_HandsOverListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := ln.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Stop accepting here, as the old server does once the new one is up.
+	ln.Close()
+	defer f.Close()
+
+	proc, err := upgrade.Spawn([]*os.File{f}, 10*time.Second)
+	if err != nil {
+		t.Fatalf("Spawn: %v", err)
+	}
+	defer proc.Wait()
+
+	resp, err := http.Get("http://" + ln.Addr().String())
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != strconv.Itoa(proc.Pid) {
+		t.Errorf("expected the new process %d to answer, got %q", proc.Pid, body)
+	}
+}
+
+func TestInherited_NotUpgrading(t *testing.T) {
+	ls, err := upgrade.Inherited()
+	if err != nil || ls != nil {
+		t.Errorf("expected no listeners, got %v, %v", ls, err)
+	}
+	if err := upgrade.Ready(); err != nil {
+		t.Errorf("Ready outside an upgrade: %v", err)
+	}
+}
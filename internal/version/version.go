@@ -0,0 +1,61 @@
+// Package version exposes build metadata for the running binary.
+//
+// Version, Commit and BuildDate are injected at link time, e.g.
+//
+//	go build -ldflags "-X github.com/sc23bd/COMP3011_Coursework1/internal/version.Version=1.4.0 \
+//	  -X github.com/sc23bd/COMP3011_Coursework1/internal/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X github.com/sc23bd/COMP3011_Coursework1/internal/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/server
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Set via -ldflags -X at build time.
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildDate = "unknown"
+)
+
+// Info describes the running build.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+	Platform  string `json:"platform"`
+}
+
+// Get returns the build metadata.  When the commit was not injected it falls
+// back to the VCS revision the Go toolchain embeds in module builds.
+func Get() Info {
+	commit := Commit
+	if commit == "unknown" {
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			for _, s := range bi.Settings {
+				if s.Key == "vcs.revision" && s.Value != "" {
+					commit = s.Value
+				}
+			}
+		}
+	}
+	return Info{
+		Version:   Version,
+		Commit:    commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+}
+
+// String renders the version and commit in the compact form used by the
+// X-API-Version response header, e.g. "1.4.0 (3f2a9c1)".
+func (i Info) String() string {
+	commit := i.Commit
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+	return i.Version + " (" + commit + ")"
+}
@@ -0,0 +1,16 @@
+-- Migration 006: Login sessions.
+-- Each successful login creates a session; its ID is carried in the JWT "sid"
+-- claim so that users can list and remotely revoke their active sessions.
+-- This migration is idempotent.
+
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id            VARCHAR(64)  PRIMARY KEY,
+    username      VARCHAR(50)  NOT NULL REFERENCES users(username) ON DELETE CASCADE,
+    device_label  VARCHAR(100) NOT NULL DEFAULT '',
+    ip_address    VARCHAR(45)  NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    last_used_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    expires_at    TIMESTAMPTZ  NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_sessions_username ON user_sessions(username);
@@ -0,0 +1,14 @@
+-- Migration 007: Case-insensitive usernames.
+-- Usernames are normalised (trimmed, Unicode NFC, lower-cased) by the
+-- application before they are stored or looked up.  This index enforces
+-- uniqueness regardless of case for rows written before normalisation and
+-- serves the LOWER(username) lookups.
+--
+-- Creating the index fails if existing accounts differ only by case; rename
+-- or merge those accounts first:
+--   SELECT LOWER(username), array_agg(username) FROM users
+--   GROUP BY LOWER(username) HAVING COUNT(*) > 1;
+--
+-- This migration is idempotent.
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_username_lower_key ON users (LOWER(username));
@@ -0,0 +1,11 @@
+-- Migration 008: Indexes for the documented query patterns.
+-- Head-to-head lookups filter on both teams and sort by date; the session
+-- list filters by user and sorts by last use.  The single-column indexes from
+-- 002 and 006 remain for the other queries that use them.
+-- This migration is idempotent.
+
+CREATE INDEX IF NOT EXISTS football_matches_head_to_head_idx
+    ON football_matches (home_team_id, away_team_id, match_date DESC);
+
+CREATE INDEX IF NOT EXISTS idx_user_sessions_username_last_used
+    ON user_sessions (username, last_used_at DESC);
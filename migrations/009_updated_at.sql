@@ -0,0 +1,19 @@
+-- Migration 009: Modification timestamps on teams and matches.
+-- updated_at lets incremental-sync clients list only records changed since
+-- their last run (?updatedSince=); existing rows take their creation time.
+-- The repository sets it explicitly on every UPDATE.
+-- This migration is idempotent.
+
+ALTER TABLE football_teams   ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ;
+UPDATE football_teams   SET updated_at = created_at WHERE updated_at IS NULL;
+ALTER TABLE football_teams   ALTER COLUMN updated_at SET DEFAULT NOW(),
+                             ALTER COLUMN updated_at SET NOT NULL;
+
+ALTER TABLE football_matches ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ;
+UPDATE football_matches SET updated_at = created_at WHERE updated_at IS NULL;
+ALTER TABLE football_matches ALTER COLUMN updated_at SET DEFAULT NOW(),
+                             ALTER COLUMN updated_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS football_teams_updated_at_idx   ON football_teams (updated_at);
+CREATE INDEX IF NOT EXISTS football_matches_created_at_idx ON football_matches (created_at);
+CREATE INDEX IF NOT EXISTS football_matches_updated_at_idx ON football_matches (updated_at);
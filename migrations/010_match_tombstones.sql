@@ -0,0 +1,14 @@
+-- Migration 010: Tombstones for deleted matches.
+-- GET /football/matches/changes reports deletions to incremental-sync
+-- clients from this table.  Rows are pruned after the retention period
+-- (db.TombstoneRetention, 30 days); a sync cursor older than that is refused
+-- with 410 Gone and the client must download the matches again.
+-- This migration is idempotent.
+
+CREATE TABLE IF NOT EXISTS football_match_tombstones (
+    match_id    INTEGER     PRIMARY KEY,
+    deleted_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS football_match_tombstones_deleted_at_idx
+    ON football_match_tombstones (deleted_at);
@@ -0,0 +1,16 @@
+-- Migration 011: Scheduled job run history.
+-- The internal scheduler records each run of a background job here;
+-- GET /api/v1/admin/jobs reports the latest run of each job.
+-- This migration is idempotent.
+
+CREATE TABLE IF NOT EXISTS job_runs (
+    id           BIGSERIAL    PRIMARY KEY,
+    job          VARCHAR(100) NOT NULL,
+    started_at   TIMESTAMPTZ  NOT NULL,
+    finished_at  TIMESTAMPTZ  NOT NULL,
+    status       VARCHAR(10)  NOT NULL,
+    error        TEXT         NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS job_runs_job_started_idx
+    ON job_runs (job, started_at DESC);
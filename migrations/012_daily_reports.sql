@@ -0,0 +1,17 @@
+-- Migration 012: Daily activity reports.
+-- daily_traffic accumulates request and 5xx counts per UTC day from every
+-- instance; daily_reports keeps each generated report so that
+-- GET /api/v1/admin/reports/daily can return it later unchanged.
+-- This migration is idempotent.
+
+CREATE TABLE IF NOT EXISTS daily_traffic (
+    day            DATE    PRIMARY KEY,
+    requests       BIGINT  NOT NULL DEFAULT 0,
+    server_errors  BIGINT  NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS daily_reports (
+    day           DATE        PRIMARY KEY,
+    report        JSONB       NOT NULL,
+    generated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,17 @@
+-- Migration 013: Audit log.
+-- One row per committed change made through the API (teams, matches,
+-- registrations), written from the events bus before the response is sent.
+-- GET /api/v1/audit/export streams it as CSV.
+-- This migration is idempotent.
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id           BIGSERIAL    PRIMARY KEY,
+    at           TIMESTAMPTZ  NOT NULL,
+    actor        VARCHAR(50)  NOT NULL DEFAULT '',
+    action       VARCHAR(50)  NOT NULL,
+    resource_id  VARCHAR(100) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_at_idx     ON audit_log (at);
+CREATE INDEX IF NOT EXISTS audit_log_actor_idx  ON audit_log (actor, id);
+CREATE INDEX IF NOT EXISTS audit_log_action_idx ON audit_log (action, id);
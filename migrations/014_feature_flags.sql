@@ -0,0 +1,12 @@
+-- Migration 014: Feature flags.
+-- Runtime overrides set through PUT /api/v1/admin/flags/{name}.  Flags
+-- without a row use the value from FEATURE_FLAGS / FEATURE_FLAGS_FILE or
+-- their built-in default.
+-- This migration is idempotent.
+
+CREATE TABLE IF NOT EXISTS feature_flags (
+    name        VARCHAR(50)  PRIMARY KEY,
+    enabled     BOOLEAN      NOT NULL,
+    updated_at  TIMESTAMPTZ  NOT NULL,
+    updated_by  VARCHAR(50)  NOT NULL DEFAULT ''
+);
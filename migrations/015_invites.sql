@@ -0,0 +1,14 @@
+-- Migration 015: Registration invites.
+-- Codes generated through POST /api/v1/admin/invites.  While the
+-- invite-only feature flag is on, POST /auth/register needs a code that
+-- has not expired and has uses left.
+-- This migration is idempotent.
+
+CREATE TABLE IF NOT EXISTS invites (
+    code        VARCHAR(64)  PRIMARY KEY,
+    max_uses    INTEGER      NOT NULL CHECK (max_uses > 0),
+    uses        INTEGER      NOT NULL DEFAULT 0,
+    expires_at  TIMESTAMPTZ  NOT NULL,
+    created_by  VARCHAR(50)  NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,12 @@
+-- Migration 016: Terms-of-service acceptances.
+-- One row per user and terms version accepted, written at registration and
+-- by PUT /api/v1/me/terms.  The server compares a user's latest row with
+-- TOS_VERSION.
+-- This migration is idempotent.
+
+CREATE TABLE IF NOT EXISTS terms_acceptances (
+    username     VARCHAR(50)  NOT NULL REFERENCES users(username) ON DELETE CASCADE,
+    version      VARCHAR(50)  NOT NULL,
+    accepted_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (username, version)
+);
@@ -0,0 +1,10 @@
+-- Migration 017: User preferences.
+-- One JSONB object per user, written by PUT /api/v1/me/preferences.  The
+-- server validates keys and values before storing them.
+-- This migration is idempotent.
+
+CREATE TABLE IF NOT EXISTS user_preferences (
+    username    VARCHAR(50)  PRIMARY KEY REFERENCES users(username) ON DELETE CASCADE,
+    prefs       JSONB        NOT NULL DEFAULT '{}',
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
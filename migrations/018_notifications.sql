@@ -0,0 +1,19 @@
+-- Migration 018: Notification inboxes.
+-- One row per notification delivered to a user, written by the inbox as
+-- events are published and read through /api/v1/me/notifications.
+-- This migration is idempotent.
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id          BIGSERIAL     PRIMARY KEY,
+    username    VARCHAR(50)   NOT NULL REFERENCES users(username) ON DELETE CASCADE,
+    type        VARCHAR(50)   NOT NULL,
+    title       VARCHAR(200)  NOT NULL,
+    body        TEXT          NOT NULL DEFAULT '',
+    href        VARCHAR(500)  NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    read_at     TIMESTAMPTZ
+);
+
+-- Inbox pages, newest first.
+CREATE INDEX IF NOT EXISTS notifications_username_created_idx
+    ON notifications (username, created_at DESC, id DESC);
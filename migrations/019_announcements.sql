@@ -0,0 +1,24 @@
+-- Migration 019: Operator announcements.
+-- Announcements are created through /api/v1/admin/announcements and copied
+-- into every user's inbox; the copies are visible from the publish time
+-- until the expiry and are withdrawn when the announcement is deleted.
+-- This migration is idempotent.
+
+CREATE TABLE IF NOT EXISTS announcements (
+    id          BIGSERIAL     PRIMARY KEY,
+    title       VARCHAR(200)  NOT NULL,
+    body        TEXT          NOT NULL DEFAULT '',
+    banner      BOOLEAN       NOT NULL DEFAULT FALSE,
+    publish_at  TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    expires_at  TIMESTAMPTZ,
+    created_by  VARCHAR(50)   NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS announcement_id BIGINT
+    REFERENCES announcements(id) ON DELETE CASCADE;
+
+-- Withdrawing an announcement deletes its copies.
+CREATE INDEX IF NOT EXISTS notifications_announcement_idx
+    ON notifications (announcement_id) WHERE announcement_id IS NOT NULL;
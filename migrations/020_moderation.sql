@@ -0,0 +1,35 @@
+-- Migration 020: Content reports and moderation.
+-- Users report teams and matches through POST .../report; each reported
+-- resource gets one moderation_items row tracking its state (open,
+-- dismissed or removed) for the admin moderation queue.
+-- This migration is idempotent.
+
+CREATE TABLE IF NOT EXISTS moderation_items (
+    kind               VARCHAR(20)  NOT NULL,
+    resource_id        INTEGER      NOT NULL,
+    state              VARCHAR(20)  NOT NULL DEFAULT 'open',
+    report_count       INTEGER      NOT NULL DEFAULT 0,
+    first_reported_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    last_reported_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    reviewed_by        VARCHAR(50),
+    reviewed_at        TIMESTAMPTZ,
+    resolution         TEXT         NOT NULL DEFAULT '',
+    PRIMARY KEY (kind, resource_id)
+);
+
+-- The moderation queue: one state, most reported first.
+CREATE INDEX IF NOT EXISTS moderation_items_queue_idx
+    ON moderation_items (state, report_count DESC, first_reported_at);
+
+CREATE TABLE IF NOT EXISTS content_reports (
+    id           BIGSERIAL    PRIMARY KEY,
+    kind         VARCHAR(20)  NOT NULL,
+    resource_id  INTEGER      NOT NULL,
+    reporter     VARCHAR(50)  NOT NULL REFERENCES users(username) ON DELETE CASCADE,
+    reason       VARCHAR(20)  NOT NULL,
+    note         TEXT         NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    -- One report per user and resource.
+    UNIQUE (kind, resource_id, reporter),
+    FOREIGN KEY (kind, resource_id) REFERENCES moderation_items (kind, resource_id) ON DELETE CASCADE
+);
@@ -0,0 +1,7 @@
+-- Migration 021: Quarantined content.
+-- The content classifier can accept a team or match write but ask for it to
+-- be reviewed; such content is opened in the moderation queue with the
+-- classifier's reason code, whether or not anyone has reported it.
+-- This migration is idempotent.
+
+ALTER TABLE moderation_items ADD COLUMN IF NOT EXISTS quarantine_reason TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,15 @@
+-- Migration 022: Usage analytics.
+-- When ANALYTICS=true, request counts are aggregated per UTC day, route and
+-- user.  Users are stored as a pseudonym that changes daily ("anonymous"
+-- for unauthenticated requests); rows past the retention period are
+-- deleted by the server.
+-- This migration is idempotent.
+
+CREATE TABLE IF NOT EXISTS usage_analytics (
+    day       DATE          NOT NULL,
+    subject   VARCHAR(40)   NOT NULL,
+    method    VARCHAR(10)   NOT NULL,
+    route     VARCHAR(200)  NOT NULL,
+    requests  BIGINT        NOT NULL DEFAULT 0,
+    PRIMARY KEY (day, subject, method, route)
+);
@@ -0,0 +1,18 @@
+-- Migration 023: API usage metering.
+-- When METERING=true, each authenticated caller's requests and bytes in and
+-- out are added up per UTC day and API key ('' for token and certificate
+-- callers), for billing.  Served by /me/usage and /admin/usage.
+-- This migration is idempotent.
+
+CREATE TABLE IF NOT EXISTS usage_meter (
+    day        DATE          NOT NULL,
+    username   VARCHAR(255)  NOT NULL,
+    api_key    VARCHAR(255)  NOT NULL DEFAULT '',
+    requests   BIGINT        NOT NULL DEFAULT 0,
+    bytes_in   BIGINT        NOT NULL DEFAULT 0,
+    bytes_out  BIGINT        NOT NULL DEFAULT 0,
+    PRIMARY KEY (username, day, api_key)
+);
+
+-- Admin rollups scan a range of days across every caller.
+CREATE INDEX IF NOT EXISTS usage_meter_day_idx ON usage_meter (day);
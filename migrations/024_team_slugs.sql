@@ -0,0 +1,33 @@
+-- Migration 024: Team slugs.
+-- Each team gets a URL-friendly slug derived from its name ("Côte d'Ivoire"
+-- becomes "cote-d-ivoire"), unique across teams by a numeric suffix, so that
+-- clients can use /football/teams/slug/{slug} instead of numeric IDs.  The
+-- API sets slugs on create and rename; this backfill approximates the same
+-- rules for existing rows, numbering duplicates in ID order.
+-- This migration is idempotent.
+
+ALTER TABLE football_teams ADD COLUMN IF NOT EXISTS slug VARCHAR(110);
+
+WITH base AS (
+    SELECT id,
+           COALESCE(NULLIF(left(trim(BOTH '-' FROM regexp_replace(
+               lower(translate(name,
+                   'ÀÁÂÃÄÅÇÈÉÊËÌÍÎÏÑÒÓÔÕÖÙÚÛÜÝàáâãäåçèéêëìíîïñòóôõöùúûüýÿ',
+                   'AAAAAACEEEEIIIINOOOOOUUUUYaaaaaaceeeeiiiinooooouuuuyy')),
+               '[^a-z0-9]+', '-', 'g')), 100), ''), 'team') AS slug
+    FROM football_teams
+    WHERE slug IS NULL
+), numbered AS (
+    SELECT id, slug, row_number() OVER (PARTITION BY slug ORDER BY id) AS n
+    FROM base
+)
+UPDATE football_teams t
+SET slug = CASE WHEN numbered.n = 1 THEN numbered.slug ELSE numbered.slug || '-' || numbered.n END
+FROM numbered
+WHERE t.id = numbered.id;
+
+ALTER TABLE football_teams ALTER COLUMN slug SET NOT NULL;
+
+-- Lookups by slug, and uniqueness: the repository retries a write that
+-- loses a race for a slug when this index refuses it.
+CREATE UNIQUE INDEX IF NOT EXISTS football_teams_slug_key ON football_teams (slug);
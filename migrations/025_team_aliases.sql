@@ -0,0 +1,18 @@
+-- Migration 025: Aliases of merged teams.
+-- POST /football/teams/{id}/merge-into/{target} folds a duplicate team into
+-- another and deletes it; its ID is kept here so that requests for it are
+-- answered with 308 Permanent Redirect to the surviving team.  team_id has
+-- no foreign key, since that team no longer exists; team IDs are never
+-- reused.  Merging the survivor again repoints its aliases, so each alias is
+-- one hop, and deleting it removes them.
+-- This migration is idempotent.
+
+CREATE TABLE IF NOT EXISTS football_team_aliases (
+    team_id    INTEGER       PRIMARY KEY,
+    target_id  INTEGER       NOT NULL REFERENCES football_teams(id) ON DELETE CASCADE,
+    name       VARCHAR(100)  NOT NULL,
+    merged_at  TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+-- Repointing on a later merge, and the cascade, look aliases up by target.
+CREATE INDEX IF NOT EXISTS football_team_aliases_target_idx ON football_team_aliases (target_id);
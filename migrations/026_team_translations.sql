@@ -0,0 +1,10 @@
+-- Migration 026: Translated team names.
+-- Each team's name in other languages, keyed by BCP 47 language tag
+-- ({"fr": "Angleterre", "de": "England"}).  GET requests choose among them
+-- and the team's own name by Accept-Language; they are managed through
+-- /football/teams/{id}/translations.
+-- This migration is idempotent.
+
+ALTER TABLE football_teams
+    ADD COLUMN IF NOT EXISTS translations JSONB NOT NULL DEFAULT '{}'
+    CHECK (jsonb_typeof(translations) = 'object');
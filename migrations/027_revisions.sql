@@ -0,0 +1,85 @@
+-- Migration 027: Revision history of teams and matches.
+-- Triggers record each row as it was before every insert, update and
+-- delete, so that ?asOf= reads can reconstruct the tables at any instant
+-- since the history began: a row's state at T is the old value of its first
+-- revision after T, or the row itself if it has not changed since.  old is
+-- NULL for an insert, as the row did not exist before.
+-- football_revisions_start holds when recording began; earlier instants
+-- cannot be reconstructed.  A restore from backup empties the history and
+-- starts it again.
+-- This migration is idempotent.
+
+CREATE TABLE IF NOT EXISTS football_revisions (
+    id          BIGSERIAL    PRIMARY KEY,
+    table_name  TEXT         NOT NULL,
+    row_id      INTEGER      NOT NULL,
+    at          TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    old         JSONB
+);
+
+-- As-of reads look up the first revision of each row after an instant.
+CREATE INDEX IF NOT EXISTS football_revisions_at_idx ON football_revisions (table_name, at, row_id);
+
+CREATE TABLE IF NOT EXISTS football_revisions_start (
+    started_at  TIMESTAMPTZ  NOT NULL
+);
+
+INSERT INTO football_revisions_start (started_at)
+SELECT NOW() WHERE NOT EXISTS (SELECT 1 FROM football_revisions_start);
+
+CREATE OR REPLACE FUNCTION football_record_revision() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO football_revisions (table_name, row_id, old) VALUES (TG_TABLE_NAME, NEW.id, NULL);
+    ELSE
+        INSERT INTO football_revisions (table_name, row_id, old) VALUES (TG_TABLE_NAME, OLD.id, to_jsonb(OLD));
+    END IF;
+    RETURN NULL;
+END
+$$;
+
+DROP TRIGGER IF EXISTS football_teams_revisions ON football_teams;
+CREATE TRIGGER football_teams_revisions
+    AFTER INSERT OR UPDATE OR DELETE ON football_teams
+    FOR EACH ROW EXECUTE FUNCTION football_record_revision();
+
+DROP TRIGGER IF EXISTS football_matches_revisions ON football_matches;
+CREATE TRIGGER football_matches_revisions
+    AFTER INSERT OR UPDATE OR DELETE ON football_matches
+    FOR EACH ROW EXECUTE FUNCTION football_record_revision();
+
+-- The tables as they were at t.  Rows unchanged since t are read as they
+-- are; the others from their first revision after t, left out if it was
+-- their insert.
+CREATE OR REPLACE FUNCTION football_teams_as_of(t TIMESTAMPTZ) RETURNS SETOF football_teams
+LANGUAGE sql STABLE AS $$
+    SELECT ft.* FROM football_teams ft
+    WHERE NOT EXISTS (
+        SELECT 1 FROM football_revisions r
+        WHERE r.table_name = 'football_teams' AND r.at > t AND r.row_id = ft.id)
+    UNION ALL
+    SELECT (jsonb_populate_record(NULL::football_teams, r.old)).*
+    FROM (
+        SELECT DISTINCT ON (row_id) old FROM football_revisions
+        WHERE table_name = 'football_teams' AND at > t
+        ORDER BY row_id, at, id
+    ) r
+    WHERE r.old IS NOT NULL
+$$;
+
+CREATE OR REPLACE FUNCTION football_matches_as_of(t TIMESTAMPTZ) RETURNS SETOF football_matches
+LANGUAGE sql STABLE AS $$
+    SELECT fm.* FROM football_matches fm
+    WHERE NOT EXISTS (
+        SELECT 1 FROM football_revisions r
+        WHERE r.table_name = 'football_matches' AND r.at > t AND r.row_id = fm.id)
+    UNION ALL
+    SELECT (jsonb_populate_record(NULL::football_matches, r.old)).*
+    FROM (
+        SELECT DISTINCT ON (row_id) old FROM football_revisions
+        WHERE table_name = 'football_matches' AND at > t
+        ORDER BY row_id, at, id
+    ) r
+    WHERE r.old IS NOT NULL
+$$;
@@ -0,0 +1,13 @@
+-- Migration 028: Consumed one-shot credentials.
+-- IDs of signed requests and other credentials that may be used only once,
+-- kept until the credential expires so that a replayed copy is refused.
+-- Expired rows are removed by the replay-purge job.
+-- This migration is idempotent.
+
+CREATE TABLE IF NOT EXISTS consumed_tokens (
+    id          VARCHAR(200) PRIMARY KEY,
+    expires_at  TIMESTAMPTZ  NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS consumed_tokens_expires_at_idx
+    ON consumed_tokens (expires_at);
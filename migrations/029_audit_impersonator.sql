@@ -0,0 +1,10 @@
+-- Migration 029: Impersonation in the audit log.
+-- Records the administrator behind each change made with an impersonation
+-- token from POST /api/v1/admin/impersonate; actor is then the user being
+-- impersonated.  Empty for ordinary changes.
+-- This migration is idempotent.
+
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS impersonator VARCHAR(50) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS audit_log_impersonator_idx
+    ON audit_log (impersonator, id) WHERE impersonator <> '';
@@ -0,0 +1,24 @@
+-- Migration 030: Login activity.
+-- One row per successful login with the device (described from the
+-- User-Agent and fingerprinted without its version) and network it came
+-- from, so that users can review recent sign-ins at /me/logins and be
+-- notified of sign-ins from new devices or locations.  Rows older than 90
+-- days are removed by the login-purge job.
+-- This migration is idempotent.
+
+CREATE TABLE IF NOT EXISTS login_activity (
+    id            BIGSERIAL    PRIMARY KEY,
+    username      VARCHAR(50)  NOT NULL REFERENCES users(username) ON DELETE CASCADE,
+    session_id    VARCHAR(64)  NOT NULL DEFAULT '',
+    at            TIMESTAMPTZ  NOT NULL,
+    ip_address    VARCHAR(45)  NOT NULL DEFAULT '',
+    user_agent    VARCHAR(500) NOT NULL DEFAULT '',
+    device        VARCHAR(100) NOT NULL DEFAULT '',
+    fingerprint   VARCHAR(32)  NOT NULL,
+    network       VARCHAR(64)  NOT NULL DEFAULT '',
+    new_device    BOOLEAN      NOT NULL DEFAULT FALSE,
+    new_location  BOOLEAN      NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX IF NOT EXISTS login_activity_username_at_idx ON login_activity (username, at DESC);
+CREATE INDEX IF NOT EXISTS login_activity_at_idx          ON login_activity (at);
@@ -0,0 +1,29 @@
+-- Migration 031: Delegated authorisation for third-party applications.
+-- oauth_clients holds the applications administrators register through
+-- /api/v1/admin/oauth/clients, with the SHA-256 hash of their secret.
+-- oauth_grants holds each user's consent to an application acting for them
+-- within a set of scopes; the tokens issued to the application name the
+-- grant, and stop working when the user revokes it at /me/apps.
+-- This migration is idempotent.
+
+CREATE TABLE IF NOT EXISTS oauth_clients (
+    id             VARCHAR(64)  PRIMARY KEY,
+    name           VARCHAR(100) NOT NULL,
+    redirect_uris  TEXT[]       NOT NULL,
+    scopes         TEXT[]       NOT NULL,
+    secret_hash    VARCHAR(64)  NOT NULL,
+    created_by     VARCHAR(50)  NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS oauth_grants (
+    id            VARCHAR(64)  PRIMARY KEY,
+    username      VARCHAR(50)  NOT NULL REFERENCES users(username) ON DELETE CASCADE,
+    client_id     VARCHAR(64)  NOT NULL REFERENCES oauth_clients(id) ON DELETE CASCADE,
+    scopes        TEXT[]       NOT NULL,
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    last_used_at  TIMESTAMPTZ,
+    UNIQUE (username, client_id)
+);
+
+CREATE INDEX IF NOT EXISTS oauth_grants_client_idx ON oauth_grants (client_id);
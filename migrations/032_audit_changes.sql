@@ -0,0 +1,7 @@
+-- Migration 032: Field changes in the audit log.
+-- Records, for team.updated and match.updated entries, the fields the
+-- update altered as a JSON array of {"field", "old", "new"} objects.  NULL
+-- for other changes and for entries written before this migration.
+-- This migration is idempotent.
+
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS changes JSONB;
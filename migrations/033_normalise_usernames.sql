@@ -0,0 +1,66 @@
+-- Migration 033: Normalise existing usernames.
+-- Migration 007 made lookups case-insensitive but left accounts created
+-- before it under the spelling they registered with, so "Alice" kept
+-- receiving tokens, sessions and admin checks as "Alice" while new accounts
+-- and ADMIN_USERS are normalised (trimmed, Unicode NFC, lower-cased).  This
+-- rewrites those usernames in normalised form, in users and in every table
+-- that names a user.  Foreign keys on users(username) now cascade updates so
+-- that dependent rows follow.
+--
+-- Fails, changing nothing, if two accounts normalise to the same name; rename
+-- or merge those accounts first:
+--   SELECT LOWER(NORMALIZE(BTRIM(username), NFC)), array_agg(username)
+--   FROM users GROUP BY 1 HAVING COUNT(*) > 1;
+--
+-- This migration is idempotent.
+
+BEGIN;
+
+CREATE TEMP TABLE username_renames ON COMMIT DROP AS
+SELECT username AS old, LOWER(NORMALIZE(BTRIM(username), NFC)) AS new
+FROM users
+WHERE username <> LOWER(NORMALIZE(BTRIM(username), NFC));
+
+ALTER TABLE user_sessions
+    DROP CONSTRAINT IF EXISTS user_sessions_username_fkey,
+    ADD CONSTRAINT user_sessions_username_fkey FOREIGN KEY (username)
+        REFERENCES users(username) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE terms_acceptances
+    DROP CONSTRAINT IF EXISTS terms_acceptances_username_fkey,
+    ADD CONSTRAINT terms_acceptances_username_fkey FOREIGN KEY (username)
+        REFERENCES users(username) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE user_preferences
+    DROP CONSTRAINT IF EXISTS user_preferences_username_fkey,
+    ADD CONSTRAINT user_preferences_username_fkey FOREIGN KEY (username)
+        REFERENCES users(username) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE notifications
+    DROP CONSTRAINT IF EXISTS notifications_username_fkey,
+    ADD CONSTRAINT notifications_username_fkey FOREIGN KEY (username)
+        REFERENCES users(username) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE content_reports
+    DROP CONSTRAINT IF EXISTS content_reports_reporter_fkey,
+    ADD CONSTRAINT content_reports_reporter_fkey FOREIGN KEY (reporter)
+        REFERENCES users(username) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE login_activity
+    DROP CONSTRAINT IF EXISTS login_activity_username_fkey,
+    ADD CONSTRAINT login_activity_username_fkey FOREIGN KEY (username)
+        REFERENCES users(username) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE oauth_grants
+    DROP CONSTRAINT IF EXISTS oauth_grants_username_fkey,
+    ADD CONSTRAINT oauth_grants_username_fkey FOREIGN KEY (username)
+        REFERENCES users(username) ON DELETE CASCADE ON UPDATE CASCADE;
+
+UPDATE users u SET username = r.new FROM username_renames r WHERE u.username = r.old;
+
+-- Columns that record a user without a foreign key.  API keys and
+-- certificate identities are not accounts and keep their spelling.
+UPDATE audit_log a SET actor = r.new FROM username_renames r WHERE a.actor = r.old;
+UPDATE audit_log a SET impersonator = r.new FROM username_renames r WHERE a.impersonator = r.old;
+UPDATE usage_meter m SET username = r.new FROM username_renames r WHERE m.username = r.old;
+UPDATE invites i SET created_by = r.new FROM username_renames r WHERE i.created_by = r.old;
+UPDATE announcements a SET created_by = r.new FROM username_renames r WHERE a.created_by = r.old;
+UPDATE oauth_clients o SET created_by = r.new FROM username_renames r WHERE o.created_by = r.old;
+UPDATE feature_flags f SET updated_by = r.new FROM username_renames r WHERE f.updated_by = r.old;
+UPDATE moderation_items m SET reviewed_by = r.new FROM username_renames r WHERE m.reviewed_by = r.old;
+
+COMMIT;
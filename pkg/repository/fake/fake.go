@@ -0,0 +1,1022 @@
+// Package fake provides hand-written fakes of the repository interfaces
+// for unit tests.  Each fake records every call and delegates to an
+// optional function field named after the method; when that field is nil
+// the method returns zero values and a nil error:
+//
+//	repo := &fake.Football{
+//		GetTeamByIDFunc: func(id int) (models.Team, error) {
+//			return models.Team{ID: id, Name: "England"}, nil
+//		},
+//	}
+//	// … exercise the code under test …
+//	if calls := repo.CallsTo("GetTeamByID"); len(calls) != 1 || calls[0].Args[0] != 7 {
+//		t.Fatalf("unexpected calls: %v", repo.Calls())
+//	}
+package fake
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/elo"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/pkg/repository"
+)
+
+var (
+	_ repository.Football      = (*Football)(nil)
+	_ repository.Users         = (*Users)(nil)
+	_ repository.Sessions      = (*Sessions)(nil)
+	_ repository.Logins        = (*Logins)(nil)
+	_ repository.Invites       = (*Invites)(nil)
+	_ repository.OAuth         = (*OAuth)(nil)
+	_ repository.Terms         = (*Terms)(nil)
+	_ repository.Preferences   = (*Preferences)(nil)
+	_ repository.Notifications = (*Notifications)(nil)
+	_ repository.Announcements = (*Announcements)(nil)
+	_ repository.Moderation    = (*Moderation)(nil)
+	_ repository.Metering      = (*Metering)(nil)
+)
+
+// Call is one recorded method call.
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// Recorder records the calls made to a fake.  It is safe for concurrent use.
+type Recorder struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+func (r *Recorder) record(method string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Method: method, Args: args})
+}
+
+// Calls returns every call made so far, oldest first.
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// CallsTo returns the calls made to method, oldest first.
+func (r *Recorder) CallsTo(method string) []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []Call
+	for _, c := range r.calls {
+		if c.Method == method {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Reset forgets the recorded calls.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
+
+// Football is a fake repository.Football.
+type Football struct {
+	Recorder
+
+	ListTeamsFunc               func(f models.TimeFilter) ([]models.Team, error)
+	GetTeamByIDFunc             func(id int) (models.Team, error)
+	GetTeamByNameFunc           func(name string) (models.Team, error)
+	GetTeamBySlugFunc           func(slug string) (models.Team, error)
+	ResolveTeamAliasFunc        func(id int) (int, error)
+	GetTeamHistoryFunc          func(teamID int) ([]models.FormerName, error)
+	GetTeamAsOfFunc             func(id int, at time.Time) (models.Team, error)
+	GetTournamentByIDFunc       func(id int) (models.Tournament, error)
+	ListTournamentsFunc         func() ([]models.Tournament, error)
+	CreateTeamFunc              func(name string) (models.Team, error)
+	UpdateTeamFunc              func(id int, name string) (models.Team, error)
+	DeleteTeamFunc              func(id int) error
+	TeamTombstoneFunc           func(id int) (models.Tombstone, error)
+	MergeTeamFunc               func(id, target int) (models.Team, error)
+	SetTeamTranslationFunc      func(id int, lang, name string) (models.Team, error)
+	DeleteTeamTranslationFunc   func(id int, lang string) (models.Team, error)
+	ListMatchesFunc             func(limit, offset int, f models.TimeFilter) ([]models.Match, error)
+	GetMatchByIDFunc            func(id int) (models.Match, error)
+	GetHeadToHeadFunc           func(teamA, teamB int) ([]models.Match, error)
+	GetMatchAsOfFunc            func(id int, at time.Time) (models.Match, error)
+	CreateMatchFunc             func(m models.Match) (models.Match, error)
+	UpdateMatchFunc             func(id int, m models.Match) (models.Match, error)
+	DeleteMatchFunc             func(id int) error
+	MatchTombstoneFunc          func(id int) (models.Tombstone, error)
+	MatchChangesSinceFunc       func(since time.Time) (models.MatchChanges, error)
+	GetMatchGoalsFunc           func(matchID int) ([]models.Goal, error)
+	GetMatchShootoutFunc        func(matchID int) (models.Shootout, error)
+	CreateGoalFunc              func(g models.Goal) (models.Goal, error)
+	DeleteGoalFunc              func(id int) error
+	CreateShootoutFunc          func(s models.Shootout) (models.Shootout, error)
+	DeleteShootoutFunc          func(matchID int) error
+	GetPlayerGoalsFunc          func(scorer string) ([]models.Goal, error)
+	GetMatchesChronologicalFunc func(teamID int, endDate time.Time) ([]elo.MatchResult, error)
+	GetEloRankingsFunc          func(asOf time.Time, region string, limit, offset int) ([]elo.RankingEntry, error)
+	GetTeamCachedEloFunc        func(teamID int, asOf time.Time) (rating float64, rank int, matchesPlayed int, err error)
+	GetTeamCachedRankFunc       func(teamID int, asOf time.Time) (int, error)
+	SaveEloSnapshotFunc         func(teamID int, asOf time.Time, rating float64, rank int, matchesPlayed int) error
+}
+
+// ListTeams records the call and delegates to ListTeamsFunc.
+func (r *Football) ListTeams(f models.TimeFilter) ([]models.Team, error) {
+	r.record("ListTeams", f)
+	if r.ListTeamsFunc != nil {
+		return r.ListTeamsFunc(f)
+	}
+	return nil, nil
+}
+
+// GetTeamByID records the call and delegates to GetTeamByIDFunc.
+func (r *Football) GetTeamByID(id int) (models.Team, error) {
+	r.record("GetTeamByID", id)
+	if r.GetTeamByIDFunc != nil {
+		return r.GetTeamByIDFunc(id)
+	}
+	return models.Team{}, nil
+}
+
+// GetTeamByName records the call and delegates to GetTeamByNameFunc.
+func (r *Football) GetTeamByName(name string) (models.Team, error) {
+	r.record("GetTeamByName", name)
+	if r.GetTeamByNameFunc != nil {
+		return r.GetTeamByNameFunc(name)
+	}
+	return models.Team{}, nil
+}
+
+// GetTeamBySlug records the call and delegates to GetTeamBySlugFunc.
+func (r *Football) GetTeamBySlug(slug string) (models.Team, error) {
+	r.record("GetTeamBySlug", slug)
+	if r.GetTeamBySlugFunc != nil {
+		return r.GetTeamBySlugFunc(slug)
+	}
+	return models.Team{}, nil
+}
+
+// GetTeamHistory records the call and delegates to GetTeamHistoryFunc.
+func (r *Football) GetTeamHistory(teamID int) ([]models.FormerName, error) {
+	r.record("GetTeamHistory", teamID)
+	if r.GetTeamHistoryFunc != nil {
+		return r.GetTeamHistoryFunc(teamID)
+	}
+	return nil, nil
+}
+
+// ResolveTeamAlias records the call and delegates to ResolveTeamAliasFunc.
+// Unlike the other methods it defaults to models.ErrNotFound, meaning no
+// team was merged, since a nil error would redirect every missing team to
+// team 0.
+func (r *Football) ResolveTeamAlias(id int) (int, error) {
+	r.record("ResolveTeamAlias", id)
+	if r.ResolveTeamAliasFunc != nil {
+		return r.ResolveTeamAliasFunc(id)
+	}
+	return 0, models.ErrNotFound
+}
+
+// GetTournamentByID records the call and delegates to GetTournamentByIDFunc.
+func (r *Football) GetTournamentByID(id int) (models.Tournament, error) {
+	r.record("GetTournamentByID", id)
+	if r.GetTournamentByIDFunc != nil {
+		return r.GetTournamentByIDFunc(id)
+	}
+	return models.Tournament{}, nil
+}
+
+// ListTournaments records the call and delegates to ListTournamentsFunc.
+func (r *Football) ListTournaments() ([]models.Tournament, error) {
+	r.record("ListTournaments")
+	if r.ListTournamentsFunc != nil {
+		return r.ListTournamentsFunc()
+	}
+	return nil, nil
+}
+
+// CreateTeam records the call and delegates to CreateTeamFunc.
+func (r *Football) CreateTeam(name string) (models.Team, error) {
+	r.record("CreateTeam", name)
+	if r.CreateTeamFunc != nil {
+		return r.CreateTeamFunc(name)
+	}
+	return models.Team{}, nil
+}
+
+// UpdateTeam records the call and delegates to UpdateTeamFunc.
+func (r *Football) UpdateTeam(id int, name string) (models.Team, error) {
+	r.record("UpdateTeam", id, name)
+	if r.UpdateTeamFunc != nil {
+		return r.UpdateTeamFunc(id, name)
+	}
+	return models.Team{}, nil
+}
+
+// DeleteTeam records the call and delegates to DeleteTeamFunc.
+func (r *Football) DeleteTeam(id int) error {
+	r.record("DeleteTeam", id)
+	if r.DeleteTeamFunc != nil {
+		return r.DeleteTeamFunc(id)
+	}
+	return nil
+}
+
+// TeamTombstone records the call and delegates to TeamTombstoneFunc.
+func (r *Football) TeamTombstone(id int) (models.Tombstone, error) {
+	r.record("TeamTombstone", id)
+	if r.TeamTombstoneFunc != nil {
+		return r.TeamTombstoneFunc(id)
+	}
+	return models.Tombstone{}, nil
+}
+
+// MergeTeam records the call and delegates to MergeTeamFunc.
+func (r *Football) MergeTeam(id, target int) (models.Team, error) {
+	r.record("MergeTeam", id, target)
+	if r.MergeTeamFunc != nil {
+		return r.MergeTeamFunc(id, target)
+	}
+	return models.Team{}, nil
+}
+
+// GetTeamAsOf records the call and delegates to GetTeamAsOfFunc.
+func (r *Football) GetTeamAsOf(id int, at time.Time) (models.Team, error) {
+	r.record("GetTeamAsOf", id, at)
+	if r.GetTeamAsOfFunc != nil {
+		return r.GetTeamAsOfFunc(id, at)
+	}
+	return models.Team{}, nil
+}
+
+// SetTeamTranslation records the call and delegates to
+// SetTeamTranslationFunc.
+func (r *Football) SetTeamTranslation(id int, lang, name string) (models.Team, error) {
+	r.record("SetTeamTranslation", id, lang, name)
+	if r.SetTeamTranslationFunc != nil {
+		return r.SetTeamTranslationFunc(id, lang, name)
+	}
+	return models.Team{}, nil
+}
+
+// DeleteTeamTranslation records the call and delegates to
+// DeleteTeamTranslationFunc.
+func (r *Football) DeleteTeamTranslation(id int, lang string) (models.Team, error) {
+	r.record("DeleteTeamTranslation", id, lang)
+	if r.DeleteTeamTranslationFunc != nil {
+		return r.DeleteTeamTranslationFunc(id, lang)
+	}
+	return models.Team{}, nil
+}
+
+// ListMatches records the call and delegates to ListMatchesFunc.
+func (r *Football) ListMatches(limit, offset int, f models.TimeFilter) ([]models.Match, error) {
+	r.record("ListMatches", limit, offset, f)
+	if r.ListMatchesFunc != nil {
+		return r.ListMatchesFunc(limit, offset, f)
+	}
+	return nil, nil
+}
+
+// GetMatchByID records the call and delegates to GetMatchByIDFunc.
+func (r *Football) GetMatchByID(id int) (models.Match, error) {
+	r.record("GetMatchByID", id)
+	if r.GetMatchByIDFunc != nil {
+		return r.GetMatchByIDFunc(id)
+	}
+	return models.Match{}, nil
+}
+
+// GetMatchAsOf records the call and delegates to GetMatchAsOfFunc.
+func (r *Football) GetMatchAsOf(id int, at time.Time) (models.Match, error) {
+	r.record("GetMatchAsOf", id, at)
+	if r.GetMatchAsOfFunc != nil {
+		return r.GetMatchAsOfFunc(id, at)
+	}
+	return models.Match{}, nil
+}
+
+// GetHeadToHead records the call and delegates to GetHeadToHeadFunc.
+func (r *Football) GetHeadToHead(teamA, teamB int) ([]models.Match, error) {
+	r.record("GetHeadToHead", teamA, teamB)
+	if r.GetHeadToHeadFunc != nil {
+		return r.GetHeadToHeadFunc(teamA, teamB)
+	}
+	return nil, nil
+}
+
+// CreateMatch records the call and delegates to CreateMatchFunc.
+func (r *Football) CreateMatch(m models.Match) (models.Match, error) {
+	r.record("CreateMatch", m)
+	if r.CreateMatchFunc != nil {
+		return r.CreateMatchFunc(m)
+	}
+	return models.Match{}, nil
+}
+
+// UpdateMatch records the call and delegates to UpdateMatchFunc.
+func (r *Football) UpdateMatch(id int, m models.Match) (models.Match, error) {
+	r.record("UpdateMatch", id, m)
+	if r.UpdateMatchFunc != nil {
+		return r.UpdateMatchFunc(id, m)
+	}
+	return models.Match{}, nil
+}
+
+// DeleteMatch records the call and delegates to DeleteMatchFunc.
+func (r *Football) DeleteMatch(id int) error {
+	r.record("DeleteMatch", id)
+	if r.DeleteMatchFunc != nil {
+		return r.DeleteMatchFunc(id)
+	}
+	return nil
+}
+
+// MatchTombstone records the call and delegates to MatchTombstoneFunc.
+func (r *Football) MatchTombstone(id int) (models.Tombstone, error) {
+	r.record("MatchTombstone", id)
+	if r.MatchTombstoneFunc != nil {
+		return r.MatchTombstoneFunc(id)
+	}
+	return models.Tombstone{}, nil
+}
+
+// MatchChangesSince records the call and delegates to MatchChangesSinceFunc.
+func (r *Football) MatchChangesSince(since time.Time) (models.MatchChanges, error) {
+	r.record("MatchChangesSince", since)
+	if r.MatchChangesSinceFunc != nil {
+		return r.MatchChangesSinceFunc(since)
+	}
+	return models.MatchChanges{}, nil
+}
+
+// GetMatchGoals records the call and delegates to GetMatchGoalsFunc.
+func (r *Football) GetMatchGoals(matchID int) ([]models.Goal, error) {
+	r.record("GetMatchGoals", matchID)
+	if r.GetMatchGoalsFunc != nil {
+		return r.GetMatchGoalsFunc(matchID)
+	}
+	return nil, nil
+}
+
+// GetMatchShootout records the call and delegates to GetMatchShootoutFunc.
+func (r *Football) GetMatchShootout(matchID int) (models.Shootout, error) {
+	r.record("GetMatchShootout", matchID)
+	if r.GetMatchShootoutFunc != nil {
+		return r.GetMatchShootoutFunc(matchID)
+	}
+	return models.Shootout{}, nil
+}
+
+// CreateGoal records the call and delegates to CreateGoalFunc.
+func (r *Football) CreateGoal(g models.Goal) (models.Goal, error) {
+	r.record("CreateGoal", g)
+	if r.CreateGoalFunc != nil {
+		return r.CreateGoalFunc(g)
+	}
+	return models.Goal{}, nil
+}
+
+// DeleteGoal records the call and delegates to DeleteGoalFunc.
+func (r *Football) DeleteGoal(id int) error {
+	r.record("DeleteGoal", id)
+	if r.DeleteGoalFunc != nil {
+		return r.DeleteGoalFunc(id)
+	}
+	return nil
+}
+
+// CreateShootout records the call and delegates to CreateShootoutFunc.
+func (r *Football) CreateShootout(s models.Shootout) (models.Shootout, error) {
+	r.record("CreateShootout", s)
+	if r.CreateShootoutFunc != nil {
+		return r.CreateShootoutFunc(s)
+	}
+	return models.Shootout{}, nil
+}
+
+// DeleteShootout records the call and delegates to DeleteShootoutFunc.
+func (r *Football) DeleteShootout(matchID int) error {
+	r.record("DeleteShootout", matchID)
+	if r.DeleteShootoutFunc != nil {
+		return r.DeleteShootoutFunc(matchID)
+	}
+	return nil
+}
+
+// GetPlayerGoals records the call and delegates to GetPlayerGoalsFunc.
+func (r *Football) GetPlayerGoals(scorer string) ([]models.Goal, error) {
+	r.record("GetPlayerGoals", scorer)
+	if r.GetPlayerGoalsFunc != nil {
+		return r.GetPlayerGoalsFunc(scorer)
+	}
+	return nil, nil
+}
+
+// GetMatchesChronological records the call and delegates to GetMatchesChronologicalFunc.
+func (r *Football) GetMatchesChronological(teamID int, endDate time.Time) ([]elo.MatchResult, error) {
+	r.record("GetMatchesChronological", teamID, endDate)
+	if r.GetMatchesChronologicalFunc != nil {
+		return r.GetMatchesChronologicalFunc(teamID, endDate)
+	}
+	return nil, nil
+}
+
+// GetEloRankings records the call and delegates to GetEloRankingsFunc.
+func (r *Football) GetEloRankings(asOf time.Time, region string, limit, offset int) ([]elo.RankingEntry, error) {
+	r.record("GetEloRankings", asOf, region, limit, offset)
+	if r.GetEloRankingsFunc != nil {
+		return r.GetEloRankingsFunc(asOf, region, limit, offset)
+	}
+	return nil, nil
+}
+
+// GetTeamCachedElo records the call and delegates to GetTeamCachedEloFunc.
+func (r *Football) GetTeamCachedElo(teamID int, asOf time.Time) (rating float64, rank int, matchesPlayed int, err error) {
+	r.record("GetTeamCachedElo", teamID, asOf)
+	if r.GetTeamCachedEloFunc != nil {
+		return r.GetTeamCachedEloFunc(teamID, asOf)
+	}
+	return 0, 0, 0, nil
+}
+
+// GetTeamCachedRank records the call and delegates to GetTeamCachedRankFunc.
+func (r *Football) GetTeamCachedRank(teamID int, asOf time.Time) (int, error) {
+	r.record("GetTeamCachedRank", teamID, asOf)
+	if r.GetTeamCachedRankFunc != nil {
+		return r.GetTeamCachedRankFunc(teamID, asOf)
+	}
+	return 0, nil
+}
+
+// SaveEloSnapshot records the call and delegates to SaveEloSnapshotFunc.
+func (r *Football) SaveEloSnapshot(teamID int, asOf time.Time, rating float64, rank int, matchesPlayed int) error {
+	r.record("SaveEloSnapshot", teamID, asOf, rating, rank, matchesPlayed)
+	if r.SaveEloSnapshotFunc != nil {
+		return r.SaveEloSnapshotFunc(teamID, asOf, rating, rank, matchesPlayed)
+	}
+	return nil
+}
+
+// Users is a fake repository.Users.
+type Users struct {
+	Recorder
+
+	GetUserFunc            func(username string) (models.User, error)
+	CreateUserFunc         func(username, passwordHash string) (models.User, error)
+	UpdatePasswordHashFunc func(username, passwordHash string) error
+}
+
+// GetUser records the call and delegates to GetUserFunc.
+func (r *Users) GetUser(username string) (models.User, error) {
+	r.record("GetUser", username)
+	if r.GetUserFunc != nil {
+		return r.GetUserFunc(username)
+	}
+	return models.User{}, nil
+}
+
+// CreateUser records the call and delegates to CreateUserFunc.
+func (r *Users) CreateUser(username, passwordHash string) (models.User, error) {
+	r.record("CreateUser", username, passwordHash)
+	if r.CreateUserFunc != nil {
+		return r.CreateUserFunc(username, passwordHash)
+	}
+	return models.User{}, nil
+}
+
+// UpdatePasswordHash records the call and delegates to UpdatePasswordHashFunc.
+func (r *Users) UpdatePasswordHash(username, passwordHash string) error {
+	r.record("UpdatePasswordHash", username, passwordHash)
+	if r.UpdatePasswordHashFunc != nil {
+		return r.UpdatePasswordHashFunc(username, passwordHash)
+	}
+	return nil
+}
+
+// Sessions is a fake repository.Sessions.
+type Sessions struct {
+	Recorder
+
+	CreateSessionFunc func(s models.Session) (models.Session, error)
+	ListSessionsFunc  func(username string) ([]models.Session, error)
+	TouchSessionFunc  func(id string) error
+	CheckSessionFunc  func(id string) error
+	RevokeSessionFunc func(username, id string) error
+}
+
+// CreateSession records the call and delegates to CreateSessionFunc.
+func (r *Sessions) CreateSession(s models.Session) (models.Session, error) {
+	r.record("CreateSession", s)
+	if r.CreateSessionFunc != nil {
+		return r.CreateSessionFunc(s)
+	}
+	return models.Session{}, nil
+}
+
+// ListSessions records the call and delegates to ListSessionsFunc.
+func (r *Sessions) ListSessions(username string) ([]models.Session, error) {
+	r.record("ListSessions", username)
+	if r.ListSessionsFunc != nil {
+		return r.ListSessionsFunc(username)
+	}
+	return nil, nil
+}
+
+// TouchSession records the call and delegates to TouchSessionFunc.
+func (r *Sessions) TouchSession(id string) error {
+	r.record("TouchSession", id)
+	if r.TouchSessionFunc != nil {
+		return r.TouchSessionFunc(id)
+	}
+	return nil
+}
+
+// CheckSession records the call and delegates to CheckSessionFunc.
+func (r *Sessions) CheckSession(id string) error {
+	r.record("CheckSession", id)
+	if r.CheckSessionFunc != nil {
+		return r.CheckSessionFunc(id)
+	}
+	return nil
+}
+
+// RevokeSession records the call and delegates to RevokeSessionFunc.
+func (r *Sessions) RevokeSession(username, id string) error {
+	r.record("RevokeSession", username, id)
+	if r.RevokeSessionFunc != nil {
+		return r.RevokeSessionFunc(username, id)
+	}
+	return nil
+}
+
+// Logins is a fake repository.Logins.
+type Logins struct {
+	Recorder
+
+	RecordLoginFunc func(l models.Login, retain time.Time) (models.Login, error)
+	ListLoginsFunc  func(username string, limit int) ([]models.Login, error)
+}
+
+// RecordLogin records the call and delegates to RecordLoginFunc.
+func (r *Logins) RecordLogin(l models.Login, retain time.Time) (models.Login, error) {
+	r.record("RecordLogin", l, retain)
+	if r.RecordLoginFunc != nil {
+		return r.RecordLoginFunc(l, retain)
+	}
+	return models.Login{}, nil
+}
+
+// ListLogins records the call and delegates to ListLoginsFunc.
+func (r *Logins) ListLogins(username string, limit int) ([]models.Login, error) {
+	r.record("ListLogins", username, limit)
+	if r.ListLoginsFunc != nil {
+		return r.ListLoginsFunc(username, limit)
+	}
+	return nil, nil
+}
+
+// Invites is a fake repository.Invites.
+type Invites struct {
+	Recorder
+
+	CreateInviteFunc  func(inv models.Invite) (models.Invite, error)
+	ListInvitesFunc   func() ([]models.Invite, error)
+	RedeemInviteFunc  func(code string) error
+	ReleaseInviteFunc func(code string) error
+	DeleteInviteFunc  func(code string) error
+}
+
+// CreateInvite records the call and delegates to CreateInviteFunc.
+func (r *Invites) CreateInvite(inv models.Invite) (models.Invite, error) {
+	r.record("CreateInvite", inv)
+	if r.CreateInviteFunc != nil {
+		return r.CreateInviteFunc(inv)
+	}
+	return models.Invite{}, nil
+}
+
+// ListInvites records the call and delegates to ListInvitesFunc.
+func (r *Invites) ListInvites() ([]models.Invite, error) {
+	r.record("ListInvites")
+	if r.ListInvitesFunc != nil {
+		return r.ListInvitesFunc()
+	}
+	return nil, nil
+}
+
+// RedeemInvite records the call and delegates to RedeemInviteFunc.
+func (r *Invites) RedeemInvite(code string) error {
+	r.record("RedeemInvite", code)
+	if r.RedeemInviteFunc != nil {
+		return r.RedeemInviteFunc(code)
+	}
+	return nil
+}
+
+// ReleaseInvite records the call and delegates to ReleaseInviteFunc.
+func (r *Invites) ReleaseInvite(code string) error {
+	r.record("ReleaseInvite", code)
+	if r.ReleaseInviteFunc != nil {
+		return r.ReleaseInviteFunc(code)
+	}
+	return nil
+}
+
+// DeleteInvite records the call and delegates to DeleteInviteFunc.
+func (r *Invites) DeleteInvite(code string) error {
+	r.record("DeleteInvite", code)
+	if r.DeleteInviteFunc != nil {
+		return r.DeleteInviteFunc(code)
+	}
+	return nil
+}
+
+// OAuth is a fake repository.OAuth.
+type OAuth struct {
+	Recorder
+
+	CreateClientFunc func(c models.OAuthClient) (models.OAuthClient, error)
+	GetClientFunc    func(id string) (models.OAuthClient, error)
+	ListClientsFunc  func() ([]models.OAuthClient, error)
+	DeleteClientFunc func(id string) error
+	GrantAccessFunc  func(g models.OAuthGrant) (models.OAuthGrant, error)
+	GetGrantFunc     func(username, clientID string) (models.OAuthGrant, error)
+	ListGrantsFunc   func(username string) ([]models.OAuthGrant, error)
+	TouchGrantFunc   func(id string) error
+	RevokeGrantFunc  func(username, clientID string) error
+}
+
+// CreateClient records the call and delegates to CreateClientFunc.
+func (r *OAuth) CreateClient(c models.OAuthClient) (models.OAuthClient, error) {
+	r.record("CreateClient", c)
+	if r.CreateClientFunc != nil {
+		return r.CreateClientFunc(c)
+	}
+	return models.OAuthClient{}, nil
+}
+
+// GetClient records the call and delegates to GetClientFunc.
+func (r *OAuth) GetClient(id string) (models.OAuthClient, error) {
+	r.record("GetClient", id)
+	if r.GetClientFunc != nil {
+		return r.GetClientFunc(id)
+	}
+	return models.OAuthClient{}, nil
+}
+
+// ListClients records the call and delegates to ListClientsFunc.
+func (r *OAuth) ListClients() ([]models.OAuthClient, error) {
+	r.record("ListClients")
+	if r.ListClientsFunc != nil {
+		return r.ListClientsFunc()
+	}
+	return nil, nil
+}
+
+// DeleteClient records the call and delegates to DeleteClientFunc.
+func (r *OAuth) DeleteClient(id string) error {
+	r.record("DeleteClient", id)
+	if r.DeleteClientFunc != nil {
+		return r.DeleteClientFunc(id)
+	}
+	return nil
+}
+
+// GrantAccess records the call and delegates to GrantAccessFunc.
+func (r *OAuth) GrantAccess(g models.OAuthGrant) (models.OAuthGrant, error) {
+	r.record("GrantAccess", g)
+	if r.GrantAccessFunc != nil {
+		return r.GrantAccessFunc(g)
+	}
+	return models.OAuthGrant{}, nil
+}
+
+// GetGrant records the call and delegates to GetGrantFunc.
+func (r *OAuth) GetGrant(username, clientID string) (models.OAuthGrant, error) {
+	r.record("GetGrant", username, clientID)
+	if r.GetGrantFunc != nil {
+		return r.GetGrantFunc(username, clientID)
+	}
+	return models.OAuthGrant{}, nil
+}
+
+// ListGrants records the call and delegates to ListGrantsFunc.
+func (r *OAuth) ListGrants(username string) ([]models.OAuthGrant, error) {
+	r.record("ListGrants", username)
+	if r.ListGrantsFunc != nil {
+		return r.ListGrantsFunc(username)
+	}
+	return nil, nil
+}
+
+// TouchGrant records the call and delegates to TouchGrantFunc.
+func (r *OAuth) TouchGrant(id string) error {
+	r.record("TouchGrant", id)
+	if r.TouchGrantFunc != nil {
+		return r.TouchGrantFunc(id)
+	}
+	return nil
+}
+
+// RevokeGrant records the call and delegates to RevokeGrantFunc.
+func (r *OAuth) RevokeGrant(username, clientID string) error {
+	r.record("RevokeGrant", username, clientID)
+	if r.RevokeGrantFunc != nil {
+		return r.RevokeGrantFunc(username, clientID)
+	}
+	return nil
+}
+
+// Terms is a fake repository.Terms.
+type Terms struct {
+	Recorder
+
+	AcceptTermsFunc func(username, version string) (models.TermsAcceptance, error)
+	LatestTermsFunc func(username string) (models.TermsAcceptance, error)
+	ListTermsFunc   func(username string) ([]models.TermsAcceptance, error)
+}
+
+// AcceptTerms records the call and delegates to AcceptTermsFunc.
+func (r *Terms) AcceptTerms(username, version string) (models.TermsAcceptance, error) {
+	r.record("AcceptTerms", username, version)
+	if r.AcceptTermsFunc != nil {
+		return r.AcceptTermsFunc(username, version)
+	}
+	return models.TermsAcceptance{}, nil
+}
+
+// LatestTerms records the call and delegates to LatestTermsFunc.
+func (r *Terms) LatestTerms(username string) (models.TermsAcceptance, error) {
+	r.record("LatestTerms", username)
+	if r.LatestTermsFunc != nil {
+		return r.LatestTermsFunc(username)
+	}
+	return models.TermsAcceptance{}, nil
+}
+
+// ListTerms records the call and delegates to ListTermsFunc.
+func (r *Terms) ListTerms(username string) ([]models.TermsAcceptance, error) {
+	r.record("ListTerms", username)
+	if r.ListTermsFunc != nil {
+		return r.ListTermsFunc(username)
+	}
+	return nil, nil
+}
+
+// Preferences is a fake repository.Preferences.
+type Preferences struct {
+	Recorder
+
+	GetPreferencesFunc func(username string) (map[string]any, error)
+	PutPreferencesFunc func(username string, prefs map[string]any) error
+}
+
+// GetPreferences records the call and delegates to GetPreferencesFunc.
+func (r *Preferences) GetPreferences(username string) (map[string]any, error) {
+	r.record("GetPreferences", username)
+	if r.GetPreferencesFunc != nil {
+		return r.GetPreferencesFunc(username)
+	}
+	return map[string]any{}, nil
+}
+
+// PutPreferences records the call and delegates to PutPreferencesFunc.
+func (r *Preferences) PutPreferences(username string, prefs map[string]any) error {
+	r.record("PutPreferences", username, prefs)
+	if r.PutPreferencesFunc != nil {
+		return r.PutPreferencesFunc(username, prefs)
+	}
+	return nil
+}
+
+// Notifications is a fake repository.Notifications.
+type Notifications struct {
+	Recorder
+
+	AddNotificationFunc          func(username string, n models.Notification) (models.Notification, error)
+	BroadcastNotificationFunc    func(n models.Notification) (int, error)
+	ListNotificationsFunc        func(username string, limit, offset int, unreadOnly bool) ([]models.Notification, error)
+	CountUnreadNotificationsFunc func(username string) (int, error)
+	MarkNotificationReadFunc     func(username string, id int) (models.Notification, error)
+	MarkAllNotificationsReadFunc func(username string) (int, error)
+}
+
+// AddNotification records the call and delegates to AddNotificationFunc.
+func (r *Notifications) AddNotification(username string, n models.Notification) (models.Notification, error) {
+	r.record("AddNotification", username, n)
+	if r.AddNotificationFunc != nil {
+		return r.AddNotificationFunc(username, n)
+	}
+	return n, nil
+}
+
+// BroadcastNotification records the call and delegates to
+// BroadcastNotificationFunc.
+func (r *Notifications) BroadcastNotification(n models.Notification) (int, error) {
+	r.record("BroadcastNotification", n)
+	if r.BroadcastNotificationFunc != nil {
+		return r.BroadcastNotificationFunc(n)
+	}
+	return 0, nil
+}
+
+// ListNotifications records the call and delegates to ListNotificationsFunc.
+func (r *Notifications) ListNotifications(username string, limit, offset int, unreadOnly bool) ([]models.Notification, error) {
+	r.record("ListNotifications", username, limit, offset, unreadOnly)
+	if r.ListNotificationsFunc != nil {
+		return r.ListNotificationsFunc(username, limit, offset, unreadOnly)
+	}
+	return nil, nil
+}
+
+// CountUnreadNotifications records the call and delegates to
+// CountUnreadNotificationsFunc.
+func (r *Notifications) CountUnreadNotifications(username string) (int, error) {
+	r.record("CountUnreadNotifications", username)
+	if r.CountUnreadNotificationsFunc != nil {
+		return r.CountUnreadNotificationsFunc(username)
+	}
+	return 0, nil
+}
+
+// MarkNotificationRead records the call and delegates to
+// MarkNotificationReadFunc.
+func (r *Notifications) MarkNotificationRead(username string, id int) (models.Notification, error) {
+	r.record("MarkNotificationRead", username, id)
+	if r.MarkNotificationReadFunc != nil {
+		return r.MarkNotificationReadFunc(username, id)
+	}
+	return models.Notification{}, nil
+}
+
+// MarkAllNotificationsRead records the call and delegates to
+// MarkAllNotificationsReadFunc.
+func (r *Notifications) MarkAllNotificationsRead(username string) (int, error) {
+	r.record("MarkAllNotificationsRead", username)
+	if r.MarkAllNotificationsReadFunc != nil {
+		return r.MarkAllNotificationsReadFunc(username)
+	}
+	return 0, nil
+}
+
+// Announcements is a fake repository.Announcements.
+type Announcements struct {
+	Recorder
+
+	CreateAnnouncementFunc func(a models.Announcement) (models.Announcement, error)
+	ListAnnouncementsFunc  func() ([]models.Announcement, error)
+	ActiveBannersFunc      func(at time.Time) ([]models.Announcement, error)
+	DeleteAnnouncementFunc func(id int) error
+}
+
+// CreateAnnouncement records the call and delegates to
+// CreateAnnouncementFunc.
+func (r *Announcements) CreateAnnouncement(a models.Announcement) (models.Announcement, error) {
+	r.record("CreateAnnouncement", a)
+	if r.CreateAnnouncementFunc != nil {
+		return r.CreateAnnouncementFunc(a)
+	}
+	return a, nil
+}
+
+// ListAnnouncements records the call and delegates to
+// ListAnnouncementsFunc.
+func (r *Announcements) ListAnnouncements() ([]models.Announcement, error) {
+	r.record("ListAnnouncements")
+	if r.ListAnnouncementsFunc != nil {
+		return r.ListAnnouncementsFunc()
+	}
+	return nil, nil
+}
+
+// ActiveBanners records the call and delegates to ActiveBannersFunc.
+func (r *Announcements) ActiveBanners(at time.Time) ([]models.Announcement, error) {
+	r.record("ActiveBanners", at)
+	if r.ActiveBannersFunc != nil {
+		return r.ActiveBannersFunc(at)
+	}
+	return nil, nil
+}
+
+// DeleteAnnouncement records the call and delegates to
+// DeleteAnnouncementFunc.
+func (r *Announcements) DeleteAnnouncement(id int) error {
+	r.record("DeleteAnnouncement", id)
+	if r.DeleteAnnouncementFunc != nil {
+		return r.DeleteAnnouncementFunc(id)
+	}
+	return nil
+}
+
+// Moderation is a fake repository.Moderation.
+type Moderation struct {
+	Recorder
+
+	ReportContentFunc         func(r models.ContentReport) (models.ContentReport, error)
+	ListReportsByFunc         func(reporter string) ([]models.ContentReport, error)
+	QuarantineContentFunc     func(kind string, id int, reason string) error
+	ListModerationItemsFunc   func(state string, limit, offset int) ([]models.ModerationItem, error)
+	GetModerationItemFunc     func(kind string, id int) (models.ModerationItem, error)
+	ResolveModerationItemFunc func(kind string, id int, state, reviewer, note string) (models.ModerationItem, error)
+}
+
+// ReportContent records the call and delegates to ReportContentFunc.
+func (r *Moderation) ReportContent(rep models.ContentReport) (models.ContentReport, error) {
+	r.record("ReportContent", rep)
+	if r.ReportContentFunc != nil {
+		return r.ReportContentFunc(rep)
+	}
+	return rep, nil
+}
+
+// ListReportsBy records the call and delegates to ListReportsByFunc.
+func (r *Moderation) ListReportsBy(reporter string) ([]models.ContentReport, error) {
+	r.record("ListReportsBy", reporter)
+	if r.ListReportsByFunc != nil {
+		return r.ListReportsByFunc(reporter)
+	}
+	return nil, nil
+}
+
+// QuarantineContent records the call and delegates to
+// QuarantineContentFunc.
+func (r *Moderation) QuarantineContent(kind string, id int, reason string) error {
+	r.record("QuarantineContent", kind, id, reason)
+	if r.QuarantineContentFunc != nil {
+		return r.QuarantineContentFunc(kind, id, reason)
+	}
+	return nil
+}
+
+// ListModerationItems records the call and delegates to
+// ListModerationItemsFunc.
+func (r *Moderation) ListModerationItems(state string, limit, offset int) ([]models.ModerationItem, error) {
+	r.record("ListModerationItems", state, limit, offset)
+	if r.ListModerationItemsFunc != nil {
+		return r.ListModerationItemsFunc(state, limit, offset)
+	}
+	return nil, nil
+}
+
+// GetModerationItem records the call and delegates to
+// GetModerationItemFunc.
+func (r *Moderation) GetModerationItem(kind string, id int) (models.ModerationItem, error) {
+	r.record("GetModerationItem", kind, id)
+	if r.GetModerationItemFunc != nil {
+		return r.GetModerationItemFunc(kind, id)
+	}
+	return models.ModerationItem{}, nil
+}
+
+// ResolveModerationItem records the call and delegates to
+// ResolveModerationItemFunc.
+func (r *Moderation) ResolveModerationItem(kind string, id int, state, reviewer, note string) (models.ModerationItem, error) {
+	r.record("ResolveModerationItem", kind, id, state, reviewer, note)
+	if r.ResolveModerationItemFunc != nil {
+		return r.ResolveModerationItemFunc(kind, id, state, reviewer, note)
+	}
+	return models.ModerationItem{}, nil
+}
+
+// Metering is a fake repository.Metering.
+type Metering struct {
+	Recorder
+
+	AddUsageFunc    func(records []models.UsageRecord) error
+	ListUsageFunc   func(username, from, to string) ([]models.UsageRecord, error)
+	UsageTotalsFunc func(from, to string, limit, offset int) ([]models.UsageTotal, error)
+}
+
+// AddUsage records the call and delegates to AddUsageFunc.
+func (r *Metering) AddUsage(records []models.UsageRecord) error {
+	r.record("AddUsage", records)
+	if r.AddUsageFunc != nil {
+		return r.AddUsageFunc(records)
+	}
+	return nil
+}
+
+// ListUsage records the call and delegates to ListUsageFunc.
+func (r *Metering) ListUsage(username, from, to string) ([]models.UsageRecord, error) {
+	r.record("ListUsage", username, from, to)
+	if r.ListUsageFunc != nil {
+		return r.ListUsageFunc(username, from, to)
+	}
+	return nil, nil
+}
+
+// UsageTotals records the call and delegates to UsageTotalsFunc.
+func (r *Metering) UsageTotals(from, to string, limit, offset int) ([]models.UsageTotal, error) {
+	r.record("UsageTotals", from, to, limit, offset)
+	if r.UsageTotalsFunc != nil {
+		return r.UsageTotalsFunc(from, to, limit, offset)
+	}
+	return nil, nil
+}
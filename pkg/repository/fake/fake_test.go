@@ -0,0 +1,64 @@
+package fake_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/router"
+	"github.com/sc23bd/COMP3011_Coursework1/pkg/repository/fake"
+)
+
+func TestFootball_RecordsAndDelegates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	football := &fake.Football{
+		GetTeamByIDFunc: func(id int) (models.Team, error) {
+			if id == 7 {
+				return models.Team{ID: 7, Name: "England"}, nil
+			}
+			return models.Team{}, models.ErrNotFound
+		},
+	}
+	r := router.New(router.Config{
+		JWTSecret:    "secret",
+		Repositories: &db.Repositories{Football: football, Users: &fake.Users{}, Sessions: &fake.Sessions{}},
+	})
+
+	for _, tc := range []struct {
+		path string
+		want int
+	}{
+		{"/api/v1/football/teams/7", http.StatusOK},
+		{"/api/v1/football/teams/8", http.StatusNotFound},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if w.Code != tc.want {
+			t.Fatalf("GET %s: expected %d, got %d", tc.path, tc.want, w.Code)
+		}
+	}
+
+	calls := football.CallsTo("GetTeamByID")
+	if len(calls) != 2 || calls[0].Args[0] != 7 || calls[1].Args[0] != 8 {
+		t.Fatalf("unexpected calls: %+v", football.Calls())
+	}
+	football.Reset()
+	if len(football.Calls()) != 0 {
+		t.Fatal("Reset did not clear the calls")
+	}
+}
+
+func TestFootball_ZeroValues(t *testing.T) {
+	var f fake.Football
+	if teams, err := f.ListTeams(models.TimeFilter{}); teams != nil || err != nil {
+		t.Fatalf("expected zero values, got %v, %v", teams, err)
+	}
+	f.DeleteTeamFunc = func(int) error { return errors.New("boom") }
+	if err := f.DeleteTeam(1); err == nil {
+		t.Fatal("expected the configured error")
+	}
+}
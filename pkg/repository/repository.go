@@ -0,0 +1,297 @@
+// Package repository defines the data-access interfaces the API is served
+// from, so that code outside this module can implement them or substitute
+// the fakes in the fake subpackage.  The PostgreSQL and in-memory stores in
+// internal/db implement them.
+package repository
+
+import (
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/internal/elo"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/models"
+)
+
+// Football abstracts the data-access layer for the football feature.
+// It is implemented by the PostgreSQL and in-memory repositories.
+type Football interface {
+	// Teams - read
+	ListTeams(f models.TimeFilter) ([]models.Team, error)
+	GetTeamByID(id int) (models.Team, error)
+	GetTeamByName(name string) (models.Team, error)
+	GetTeamBySlug(slug string) (models.Team, error)
+	GetTeamHistory(teamID int) ([]models.FormerName, error)
+	// ResolveTeamAlias returns the ID of the team that the team with the
+	// given ID was merged into, or ErrNotFound if it was not merged.
+	ResolveTeamAlias(id int) (int, error)
+
+	// GetTeamAsOf returns team id as it was at the given instant,
+	// reconstructed from the revision history: ErrNotFound if it did not
+	// exist then, or ErrNoHistory if the history does not reach back that
+	// far.  ListTeams does the same for all teams given TimeFilter.AsOf.
+	GetTeamAsOf(id int, at time.Time) (models.Team, error)
+
+	// Tournaments - read
+	GetTournamentByID(id int) (models.Tournament, error)
+	ListTournaments() ([]models.Tournament, error)
+
+	// Teams - write
+	CreateTeam(name string) (models.Team, error)
+	UpdateTeam(id int, name string) (models.Team, error)
+	DeleteTeam(id int) error
+	// TeamTombstone returns when team id was deleted, as recorded in the
+	// revision history, or ErrNotFound if it exists or no deletion of it
+	// is recorded.
+	TeamTombstone(id int) (models.Tombstone, error)
+	// MergeTeam moves the matches, goals, shootouts and former names of
+	// team id to target, records id's name as a former name of target and
+	// id as an alias of it, then deletes id.  It returns the updated target,
+	// ErrNotFound if either team does not exist, or ErrConflict if the teams
+	// played each other or the move would duplicate a match.
+	MergeTeam(id, target int) (models.Team, error)
+	// SetTeamTranslation sets the name of team id in the language with the
+	// canonical BCP 47 tag lang, returning the updated team or ErrNotFound.
+	SetTeamTranslation(id int, lang, name string) (models.Team, error)
+	// DeleteTeamTranslation removes the translation for lang, returning
+	// the updated team, or ErrNotFound if the team or the translation does
+	// not exist.
+	DeleteTeamTranslation(id int, lang string) (models.Team, error)
+
+	// Matches - read
+	ListMatches(limit, offset int, f models.TimeFilter) ([]models.Match, error)
+	GetMatchByID(id int) (models.Match, error)
+	GetHeadToHead(teamA, teamB int) ([]models.Match, error)
+	// GetMatchAsOf returns match id as it was at the given instant, with
+	// the team names of the time; see GetTeamAsOf.
+	GetMatchAsOf(id int, at time.Time) (models.Match, error)
+
+	// Matches - write
+	CreateMatch(m models.Match) (models.Match, error)
+	UpdateMatch(id int, m models.Match) (models.Match, error)
+	// DeleteMatch removes a match and leaves a tombstone for sync clients.
+	DeleteMatch(id int) error
+	// MatchTombstone returns when match id was deleted; see TeamTombstone.
+	MatchTombstone(id int) (models.Tombstone, error)
+
+	// Matches - sync
+	// MatchChangesSince returns the matches created or updated, and the
+	// tombstones of those deleted, at or after since.
+	MatchChangesSince(since time.Time) (models.MatchChanges, error)
+
+	// Goals & Shootouts - read
+	GetMatchGoals(matchID int) ([]models.Goal, error)
+	GetMatchShootout(matchID int) (models.Shootout, error)
+
+	// Goals - write
+	CreateGoal(g models.Goal) (models.Goal, error)
+	DeleteGoal(id int) error
+
+	// Shootouts - write
+	CreateShootout(s models.Shootout) (models.Shootout, error)
+	DeleteShootout(matchID int) error
+
+	// Players
+	GetPlayerGoals(scorer string) ([]models.Goal, error)
+
+	// Elo – read
+	// GetMatchesChronological returns all matches involving teamID up to and
+	// including endDate, ordered oldest-first.  Pass teamID = 0 to fetch all matches.
+	GetMatchesChronological(teamID int, endDate time.Time) ([]elo.MatchResult, error)
+	// GetEloRankings returns a paginated global Elo ranking snapshot.
+	// region is an optional filter (empty = all regions); limit/offset control pagination.
+	GetEloRankings(asOf time.Time, region string, limit, offset int) ([]elo.RankingEntry, error)
+	// GetTeamCachedElo returns the cached Elo rating and rank for a team on or
+	// before asOf. Returns sql.ErrNoRows if no cached entry exists.
+	GetTeamCachedElo(teamID int, asOf time.Time) (rating float64, rank int, matchesPlayed int, err error)
+	// GetTeamCachedRank returns the most-recently cached global rank for a team
+	// on or before asOf. Returns 0 if no cached rank exists.
+	GetTeamCachedRank(teamID int, asOf time.Time) (int, error)
+
+	// Elo – write
+	// SaveEloSnapshot upserts a cached Elo rating for one team on one date.
+	SaveEloSnapshot(teamID int, asOf time.Time, rating float64, rank int, matchesPlayed int) error
+}
+
+// Users abstracts the data-access layer for users.
+type Users interface {
+	GetUser(username string) (models.User, error)
+	CreateUser(username, passwordHash string) (models.User, error)
+	// UpdatePasswordHash replaces the stored hash, e.g. when upgrading a
+	// legacy bcrypt hash to argon2id at login.
+	UpdatePasswordHash(username, passwordHash string) error
+}
+
+// Sessions abstracts storage of login sessions.
+type Sessions interface {
+	CreateSession(s models.Session) (models.Session, error)
+	// ListSessions returns the user's unexpired sessions.
+	ListSessions(username string) ([]models.Session, error)
+	// TouchSession records use of an active session, returning
+	// models.ErrNotFound if it has been revoked or has expired.
+	TouchSession(id string) error
+	// CheckSession is TouchSession without recording use, for callers
+	// that only ask whether a session is active.
+	CheckSession(id string) error
+	// RevokeSession ends one of the user's sessions, returning
+	// models.ErrNotFound if it does not belong to them.
+	RevokeSession(username, id string) error
+}
+
+// Logins abstracts storage of users' sign-in history.
+type Logins interface {
+	// RecordLogin stores a sign-in, first forgetting the user's sign-ins
+	// from before retain, and returns it with its ID.  NewDevice and
+	// NewLocation are set when the user has earlier sign-ins, none of them
+	// with its Fingerprint or from its Network respectively.
+	RecordLogin(l models.Login, retain time.Time) (models.Login, error)
+	// ListLogins returns the user's most recent sign-ins, newest first.
+	ListLogins(username string, limit int) ([]models.Login, error)
+}
+
+// Invites abstracts storage of registration invite codes.
+type Invites interface {
+	CreateInvite(inv models.Invite) (models.Invite, error)
+	// ListInvites returns every invite, newest first, including expired
+	// and used-up ones.
+	ListInvites() ([]models.Invite, error)
+	// RedeemInvite takes one use of the invite, returning
+	// models.ErrNotFound if it does not exist, has expired or has no uses
+	// left.
+	RedeemInvite(code string) error
+	// ReleaseInvite gives back a use taken by RedeemInvite when the
+	// registration it was for fails.
+	ReleaseInvite(code string) error
+	// DeleteInvite revokes an invite, returning models.ErrNotFound if it
+	// does not exist.
+	DeleteInvite(code string) error
+}
+
+// OAuth abstracts storage of third-party applications and of the access
+// users grant them.
+type OAuth interface {
+	// CreateClient registers a client, returning models.ErrConflict if its
+	// ID is taken.
+	CreateClient(c models.OAuthClient) (models.OAuthClient, error)
+	// GetClient returns models.ErrNotFound for an unknown client.
+	GetClient(id string) (models.OAuthClient, error)
+	// ListClients returns every client, newest first.
+	ListClients() ([]models.OAuthClient, error)
+	// DeleteClient removes a client and every grant to it, returning
+	// models.ErrNotFound if it does not exist.
+	DeleteClient(id string) error
+	// GrantAccess records the user's consent to the client using
+	// g.Scopes.  An existing grant keeps its ID and gains the scopes; a
+	// new one takes g.ID.  It returns the grant as stored, or
+	// models.ErrNotFound if the client does not exist.
+	GrantAccess(g models.OAuthGrant) (models.OAuthGrant, error)
+	// GetGrant returns the user's grant to the client, or
+	// models.ErrNotFound.
+	GetGrant(username, clientID string) (models.OAuthGrant, error)
+	// ListGrants returns the user's grants, newest first, with the names
+	// of their clients.
+	ListGrants(username string) ([]models.OAuthGrant, error)
+	// TouchGrant records use of a grant, returning models.ErrNotFound if
+	// it has been revoked.
+	TouchGrant(id string) error
+	// RevokeGrant removes the user's grant to the client, returning
+	// models.ErrNotFound if there is none.
+	RevokeGrant(username, clientID string) error
+}
+
+// Terms abstracts storage of terms-of-service acceptances.
+type Terms interface {
+	// AcceptTerms records that the user accepted version now.  Accepting
+	// the same version again refreshes the time.
+	AcceptTerms(username, version string) (models.TermsAcceptance, error)
+	// LatestTerms returns the user's most recent acceptance, or
+	// models.ErrNotFound if they have never accepted any version.
+	LatestTerms(username string) (models.TermsAcceptance, error)
+	// ListTerms returns every version the user has accepted, most recent
+	// first.
+	ListTerms(username string) ([]models.TermsAcceptance, error)
+}
+
+// Preferences abstracts storage of per-user preferences, which callers
+// validate before storing.
+type Preferences interface {
+	// GetPreferences returns the user's preferences, empty when they have
+	// set none.
+	GetPreferences(username string) (map[string]any, error)
+	// PutPreferences replaces the user's preferences, returning
+	// models.ErrNotFound if the user does not exist.
+	PutPreferences(username string, prefs map[string]any) error
+}
+
+// Notifications abstracts storage of users' notification inboxes.
+type Notifications interface {
+	// AddNotification stores n in the user's inbox, returning
+	// models.ErrNotFound if the user does not exist.
+	AddNotification(username string, n models.Notification) (models.Notification, error)
+	// BroadcastNotification copies n into every user's inbox, visible from
+	// n.CreatedAt until n.ExpiresAt, and returns how many users received
+	// it.
+	BroadcastNotification(n models.Notification) (int, error)
+	// ListNotifications returns a page of the user's visible notifications,
+	// newest first, optionally only the unread ones.  Notifications not yet
+	// visible or expired are left out here and by the methods below.
+	ListNotifications(username string, limit, offset int, unreadOnly bool) ([]models.Notification, error)
+	// CountUnreadNotifications counts the user's unread notifications.
+	CountUnreadNotifications(username string) (int, error)
+	// MarkNotificationRead marks one notification read, returning
+	// models.ErrNotFound if it is not in the user's inbox.  Marking it
+	// again keeps the original time.
+	MarkNotificationRead(username string, id int) (models.Notification, error)
+	// MarkAllNotificationsRead marks every unread notification read and
+	// returns how many there were.
+	MarkAllNotificationsRead(username string) (int, error)
+}
+
+// Announcements abstracts storage of operator announcements.
+type Announcements interface {
+	CreateAnnouncement(a models.Announcement) (models.Announcement, error)
+	// ListAnnouncements returns every announcement, newest first,
+	// including scheduled and expired ones.
+	ListAnnouncements() ([]models.Announcement, error)
+	// ActiveBanners returns the banner announcements live at at, newest
+	// first.
+	ActiveBanners(at time.Time) ([]models.Announcement, error)
+	// DeleteAnnouncement removes an announcement and withdraws its copies
+	// from inboxes, returning models.ErrNotFound if it does not exist.
+	DeleteAnnouncement(id int) error
+}
+
+// Moderation abstracts storage of content reports and the moderation state
+// of the teams and matches they are about.
+type Moderation interface {
+	// ReportContent records r, opening a moderation item for the content
+	// or reopening a dismissed one, and returns the stored report.  It
+	// returns models.ErrConflict if the reporter already reported the
+	// content.
+	ReportContent(r models.ContentReport) (models.ContentReport, error)
+	// ListReportsBy returns the reports reporter filed, oldest first.
+	ListReportsBy(reporter string) ([]models.ContentReport, error)
+	// QuarantineContent opens a moderation item for the content, or
+	// reopens a dismissed one, recording the classifier's reason.
+	QuarantineContent(kind string, id int, reason string) error
+	// ListModerationItems returns a page of items in state, most reported
+	// first, each with its reports.
+	ListModerationItems(state string, limit, offset int) ([]models.ModerationItem, error)
+	// GetModerationItem returns the item for the content, or
+	// models.ErrNotFound if it was never reported.
+	GetModerationItem(kind string, id int) (models.ModerationItem, error)
+	// ResolveModerationItem moves the item to state on behalf of reviewer,
+	// returning models.ErrNotFound if it was never reported.
+	ResolveModerationItem(kind string, id int, state, reviewer, note string) (models.ModerationItem, error)
+}
+
+// Metering abstracts storage of metered API usage per caller, key and day.
+type Metering interface {
+	// AddUsage adds each record's counts to the stored totals for its
+	// day, username and key.
+	AddUsage(records []models.UsageRecord) error
+	// ListUsage returns username's records for the days from from to to
+	// (YYYY-MM-DD, inclusive), oldest first.
+	ListUsage(username, from, to string) ([]models.UsageRecord, error)
+	// UsageTotals sums usage from from to to per username and key, most
+	// requests first.
+	UsageTotals(from, to string, limit, offset int) ([]models.UsageTotal, error)
+}
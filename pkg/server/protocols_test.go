@@ -0,0 +1,155 @@
+package server_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+	"github.com/sc23bd/COMP3011_Coursework1/pkg/server"
+)
+
+// selfSigned writes a certificate for 127.0.0.1 and its key to dir and
+// returns their paths and a pool trusting it.
+func selfSigned(t *testing.T) (certFile, keyFile string, roots *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	roots = x509.NewCertPool()
+	roots.AddCert(cert)
+	return certFile, keyFile, roots
+}
+
+func startServer(t *testing.T, cfg server.Config) *server.Server {
+	t.Helper()
+	cfg.Addr = "127.0.0.1:0"
+	cfg.Router = server.RouterConfig{JWTSecret: "test-secret"}
+	srv, err := server.New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { srv.Shutdown(context.Background()) })
+	return srv
+}
+
+func get(t *testing.T, client *http.Client, url string) *http.Response {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestServer_HTTP2OverTLS(t *testing.T) {
+	certFile, keyFile, roots := selfSigned(t)
+	srv := startServer(t, server.Config{TLSCertFile: certFile, TLSKeyFile: keyFile})
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: roots},
+		ForceAttemptHTTP2: true,
+	}}
+	resp := get(t, client, "https://"+srv.Addr().String()+"/api/v1/version")
+	if resp.ProtoMajor != 2 {
+		t.Fatalf("expected HTTP/2, got %s", resp.Proto)
+	}
+}
+
+func TestServer_DisableHTTP2(t *testing.T) {
+	certFile, keyFile, roots := selfSigned(t)
+	srv := startServer(t, server.Config{TLSCertFile: certFile, TLSKeyFile: keyFile, DisableHTTP2: true})
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: roots},
+		ForceAttemptHTTP2: true,
+	}}
+	resp := get(t, client, "https://"+srv.Addr().String()+"/api/v1/version")
+	if resp.ProtoMajor != 1 {
+		t.Fatalf("expected HTTP/1.1, got %s", resp.Proto)
+	}
+}
+
+func TestServer_H2C(t *testing.T) {
+	srv := startServer(t, server.Config{H2C: true})
+
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+	resp := get(t, client, "http://"+srv.Addr().String()+"/api/v1/version")
+	if resp.ProtoMajor != 2 {
+		t.Fatalf("expected h2c, got %s", resp.Proto)
+	}
+}
+
+func TestServer_HTTP3(t *testing.T) {
+	certFile, keyFile, roots := selfSigned(t)
+	srv := startServer(t, server.Config{TLSCertFile: certFile, TLSKeyFile: keyFile, HTTP3: true})
+	url := "https://" + srv.Addr().String() + "/api/v1/version"
+
+	tcp := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	if alt := get(t, tcp, url).Header.Get("Alt-Svc"); !strings.Contains(alt, "h3=") {
+		t.Fatalf("expected an h3 Alt-Svc advertisement, got %q", alt)
+	}
+
+	tr := &http3.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}
+	defer tr.Close()
+	resp := get(t, &http.Client{Transport: tr, Timeout: 5 * time.Second}, url)
+	if resp.ProtoMajor != 3 || resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 over HTTP/3, got %d over %s", resp.StatusCode, resp.Proto)
+	}
+}
+
+func TestServer_HTTP3RequiresTLS(t *testing.T) {
+	srv, err := server.New(server.Config{Addr: "127.0.0.1:0", HTTP3: true, Router: server.RouterConfig{JWTSecret: "x"}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := srv.Start(); err == nil {
+		t.Fatal("expected Start to refuse HTTP/3 without TLS")
+	}
+}
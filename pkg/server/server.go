@@ -0,0 +1,866 @@
+// Package server constructs and runs the complete Football API — database
+// connection, router and HTTP listeners — from a Config, so that it can be
+// embedded in other binaries or started in-process by integration tests
+// instead of exec-ing cmd/server.
+//
+//	srv, err := server.New(server.Config{Addr: ":8080", DatabaseURL: dsn, Router: server.RouterConfig{JWTSecret: secret}})
+//	if err != nil { … }
+//	if err := srv.Start(); err != nil { … }
+//	defer srv.Shutdown(context.Background())
+//
+// cmd/server is a thin wrapper that fills Config from environment variables.
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/alert"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/analytics"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/app"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/audit"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/backup"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/config"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/crypt"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/db/postgres"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/diagnostics"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/events"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/flags"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/handlers"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/health"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/leader"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/lock"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/metering"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/metrics"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/notify"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/redact"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/replay"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/report"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/router"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/scheduler"
+)
+
+// RouterConfig configures authentication, limits and extensions of the API.
+// See router.Config for the individual settings.
+type RouterConfig = router.Config
+
+// MeteringConfig enables API usage metering.
+type MeteringConfig struct {
+	Enabled bool
+	// ExportURL receives each minute's usage deltas as JSON; see
+	// metering.HTTPExporter.
+	ExportURL string
+	// Exporters receive the deltas as well, for embedders feeding a
+	// billing system directly.
+	Exporters []metering.Exporter
+}
+
+// AnalyticsConfig enables usage analytics; see analytics.Config.
+type AnalyticsConfig = analytics.Config
+
+// TermsConfig sets the terms of service users must accept; see
+// router.TermsConfig.
+type TermsConfig = router.TermsConfig
+
+// CORSConfig sets which browser origins may call the API; see
+// router.CORSConfig.
+type CORSConfig = router.CORSConfig
+
+// DeleteOptions sets how DELETE answers for teams and matches that do not
+// exist; see handlers.DeleteOptions.
+type DeleteOptions = handlers.DeleteOptions
+
+// SlowQueryLog configures logging of slow database queries.
+type SlowQueryLog = postgres.SlowQueryLog
+
+// Config describes a server.
+type Config struct {
+	// Addr is the TCP address to listen on, e.g. ":8080".  Use
+	// "127.0.0.1:0" to pick a free port; Addr reports the one chosen.
+	Addr string
+
+	// DB is used as the database when set; the caller keeps ownership and
+	// Shutdown does not close it.  Otherwise DatabaseURL, when set, is
+	// opened by New and closed by Shutdown.  With neither, the server runs
+	// without a database and registers only the routes that need none.
+	DB          *sql.DB
+	DatabaseURL string
+	SlowQueries SlowQueryLog
+
+	// Router configures the API itself.  Its DB field is filled in by New.
+	Router RouterConfig
+
+	// TLSCertFile and TLSKeyFile enable HTTPS when both are set.  TLSConfig,
+	// when non-nil, supplies further settings such as client-certificate
+	// verification.
+	TLSCertFile string
+	TLSKeyFile  string
+	TLSConfig   *tls.Config
+
+	// DisableHTTP2 turns off HTTP/2, which is otherwise negotiated by ALPN
+	// on TLS connections.
+	DisableHTTP2 bool
+	// H2C accepts HTTP/2 without TLS ("prior knowledge"), for internal
+	// traffic behind a proxy that terminates TLS.  Ignored with TLS.
+	H2C bool
+	// HTTP3 also serves HTTP/3 over QUIC on the UDP port of the same
+	// address, and advertises it with Alt-Svc.  Experimental; requires TLS.
+	HTTP3 bool
+
+	// AdminAddr, when set, moves the administrative routes (/api/v1/admin,
+	// /api/v1/audit and /debug) off Addr onto this second listener, which can then be
+	// firewalled from the public.  It uses the same TLS settings as Addr.
+	AdminAddr string
+
+	// DiagnosticsAddr, when set, serves pprof and expvar on a separate
+	// private listener.
+	DiagnosticsAddr string
+
+	// DrainDelay is how long Shutdown keeps serving with /readyz failing
+	// before it stops accepting connections, so that load balancers and
+	// Kubernetes endpoints stop routing to the instance first.
+	DrainDelay time.Duration
+
+	// MigrateOnStart applies the compiled-in plugins' migrations when the
+	// server starts, under an advisory lock so that replicas starting
+	// together do not race.  The files in migrations/ are still applied
+	// outside the application.
+	MigrateOnStart bool
+
+	// SlowDependency is the latency above which a dependency that answers
+	// /readyz's check is reported as degraded; zero never reports slowness.
+	SlowDependency time.Duration
+
+	// Schedules overrides the cron expressions of the built-in background
+	// jobs by name (see DefaultSchedules); "off" disables a job.  Jobs run
+	// only with a database, on whichever instance is elected leader.
+	Schedules map[string]string
+
+	// FeatureFlags overrides the default values of feature flags (see
+	// flags.Defaults); runtime flips through /admin/flags take precedence
+	// and, with a database, are shared by every instance.  Ignored when
+	// Router.Flags is set.
+	FeatureFlags map[string]bool
+
+	// SMTP, when its Addr is set, enables email.  AlertEmails are told
+	// when a scheduled job fails; ReportEmails receive the daily report.
+	SMTP         notify.SMTPConfig
+	AlertEmails  []string
+	ReportEmails []string
+
+	// Metering, when Enabled, meters authenticated callers' requests per
+	// day and API key for /me/usage and /admin/usage, and posts the
+	// deltas to ExportURL when set.  Requires a database.
+	Metering MeteringConfig
+
+	// Analytics, when Enabled, aggregates API usage per day, route and
+	// pseudonymous user into usage_analytics for /admin/analytics.
+	// Requires a database.  An empty Key is derived from
+	// Router.JWTSecret.
+	Analytics AnalyticsConfig
+
+	// BackupDir, when set with a database, stores the backups taken
+	// through /admin/backups in this directory.
+	BackupDir string
+}
+
+// DefaultSchedules are the built-in background jobs and when they run.
+var DefaultSchedules = map[string]string{
+	"session-cleanup":   "@hourly",
+	"tombstone-purge":   "@daily",
+	"replay-purge":      "@hourly",
+	"login-purge":       "@daily",
+	"daily-report":      "5 0 * * *",
+	"preferences-rekey": "@daily",
+}
+
+// Server is a configured API server.  Create one with New.
+type Server struct {
+	cfg       Config
+	db        *sql.DB
+	ownsDB    bool
+	handler   http.Handler
+	admin     http.Handler
+	probes    *health.Probes
+	sched     *scheduler.Scheduler
+	reports   *report.Reporter
+	analytics *analytics.Analytics
+	meter     *metering.Meter
+	mail      *notify.Queue
+	flags     *flags.Flags
+
+	// stopBackground stops the scheduler, the report traffic, analytics
+	// and metering flushers and the feature flag refresher.
+	stopBackground context.CancelFunc
+	background     sync.WaitGroup
+
+	http    *http.Server
+	h3      *http3.Server
+	adminLn net.Listener
+	adminSv *http.Server
+	diag    *http.Server
+	ln      net.Listener
+
+	// The TCP listeners before any TLS wrapping, in the order handed over
+	// by ListenerFiles: public, then admin and diagnostics when configured.
+	tcp, adminTCP, diagTCP net.Listener
+
+	done     chan struct{}
+	mu       sync.Mutex
+	serveErr error
+}
+
+// New connects to the database (if configured) and builds the router.  It
+// does not listen; call Start for that, or mount Handler yourself.
+// Without a Router.LogPseudonymKey, log pseudonyms are keyed with one
+// derived from the JWT secret, and likewise without Router.FieldKeys for
+// stored values that are encrypted.
+func New(cfg Config) (*Server, error) {
+	if len(cfg.Router.LogPseudonymKey) == 0 {
+		cfg.Router.LogPseudonymKey = config.DeriveKey(cfg.Router.JWTSecret, config.PurposeLogPseudonyms)
+	}
+	if cfg.Router.FieldKeys == nil {
+		keys, err := crypt.NewKeyring("derived", config.DeriveKey(cfg.Router.JWTSecret, config.PurposeFieldEncryption))
+		if err != nil {
+			return nil, err
+		}
+		cfg.Router.FieldKeys = keys
+	}
+	if len(cfg.SlowQueries.Key) == 0 {
+		cfg.SlowQueries.Key = cfg.Router.LogPseudonymKey
+	}
+	s := &Server{cfg: cfg, db: cfg.DB, done: make(chan struct{})}
+
+	switch {
+	case cfg.SMTP.Addr != "" && cfg.SMTP.From == "":
+		return nil, errors.New("server: SMTP requires a From address")
+	case cfg.SMTP.Addr == "" && len(cfg.AlertEmails) > 0:
+		log.Printf("WARNING: alert emails are configured but SMTP is not; no alerts will be sent")
+	}
+
+	if s.db == nil && cfg.DatabaseURL != "" {
+		db, err := postgres.ConnectInstrumented(cfg.DatabaseURL, cfg.SlowQueries)
+		if err != nil {
+			return nil, fmt.Errorf("server: connect to database: %w", err)
+		}
+		s.db, s.ownsDB = db, true
+	}
+	if s.db != nil {
+		// Missing indexes only cost performance, so warn rather than
+		// refuse to start.
+		if missing, err := postgres.MissingIndexes(s.db); err != nil {
+			log.Printf("WARNING: could not check database indexes: %v", err)
+		} else if len(missing) > 0 {
+			log.Printf("WARNING: missing database indexes (apply migrations/): %s", strings.Join(missing, ", "))
+		}
+		if cfg.MigrateOnStart {
+			if err := s.migrate(context.Background()); err != nil {
+				if s.ownsDB {
+					s.db.Close()
+				}
+				return nil, fmt.Errorf("server: %w", err)
+			}
+		}
+		// Missing tables keep /startupz failing until they are created.
+		if err := s.migrated(context.Background()); err != nil {
+			log.Printf("WARNING: %v", err)
+			cfg.Router.Alerts.Send(alert.Migration, "Database migrations not applied", err.Error())
+		}
+	}
+
+	rc := cfg.Router
+	rc.DB = s.db
+	if rc.Flags == nil {
+		var store flags.Store
+		if s.db != nil {
+			store = flags.NewPostgresStore(s.db)
+		}
+		f, err := flags.New(cfg.FeatureFlags, store)
+		if err != nil {
+			if s.ownsDB {
+				s.db.Close()
+			}
+			return nil, fmt.Errorf("server: %w", err)
+		}
+		if err := f.Refresh(context.Background()); err != nil {
+			log.Printf("WARNING: %v", err)
+		}
+		s.flags, rc.Flags = f, f
+	}
+
+	var smtp *notify.SMTPSender
+	if cfg.SMTP.Addr != "" {
+		smtp = notify.NewSMTPSender(cfg.SMTP)
+		s.mail = notify.NewQueue(smtp, 0)
+	}
+	if rc.Mail == nil {
+		rc.Mail = s.mail
+	}
+	// Business metrics are counted from the event bus.
+	if rc.Events == nil {
+		rc.Events = events.NewBus(nil)
+		s.cfg.Router.Events = rc.Events
+	}
+	metrics.Subscribe(rc.Events)
+	if s.db != nil {
+		locks := rc.Locks
+		if locks == nil {
+			locks = lock.NewPostgres(s.db)
+		}
+		s.reports = report.New(s.db)
+		rc.Reports = s.reports
+		if cfg.Analytics.Enabled {
+			ac := cfg.Analytics
+			if len(ac.Key) == 0 {
+				ac.Key = config.DeriveKey(rc.JWTSecret, config.PurposeUsageAnalytics)
+			}
+			s.analytics = analytics.New(s.db, ac)
+			s.analytics.SetPreferences(postgres.NewPreferencesRepo(s.db, cfg.Router.FieldKeys))
+			rc.Analytics = s.analytics
+		}
+		if cfg.Metering.Enabled {
+			exporters := cfg.Metering.Exporters
+			if cfg.Metering.ExportURL != "" {
+				exporters = append(exporters, metering.NewHTTPExporter(cfg.Metering.ExportURL))
+			}
+			s.meter = metering.New(postgres.NewMeteringRepo(s.db), exporters...)
+			rc.Metering = s.meter
+		}
+		if cfg.BackupDir != "" && rc.Backups == nil {
+			rc.Backups = backup.New(s.db, backup.Dir(cfg.BackupDir))
+		}
+		rc.Audit = audit.New(s.db, redact.New(cfg.Router.LogPseudonymKey, cfg.Router.LogRedactFields, cfg.Router.LogPII))
+		rc.Audit.Subscribe(rc.Events)
+		var err error
+		if s.sched, err = s.newScheduler(locks); err != nil {
+			_ = s.mail.Close(context.Background())
+			if s.ownsDB {
+				s.db.Close()
+			}
+			return nil, err
+		}
+		rc.Scheduler = s.sched
+	}
+	if rc.Health == nil {
+		rc.Health = health.New(s.migrated, nil)
+		if s.db != nil {
+			rc.Health.Depend(health.Dependency{Name: "database", Check: s.db.PingContext, Critical: true, Slow: cfg.SlowDependency})
+		}
+		if smtp != nil {
+			// Mail is queued and retried, so an outage only degrades.
+			rc.Health.Depend(health.Dependency{Name: "smtp", Check: smtp.Ping, Slow: cfg.SlowDependency})
+		}
+	}
+	s.probes = rc.Health
+	if cfg.AdminAddr != "" {
+		s.handler, s.admin = router.NewSplit(rc)
+	} else {
+		s.handler = router.New(rc)
+	}
+	return s, nil
+}
+
+// newScheduler registers the built-in jobs on their configured schedules.
+func (s *Server) newScheduler(locks lock.Manager) (*scheduler.Scheduler, error) {
+	sessions := postgres.NewSessionRepo(s.db)
+	football := postgres.NewFootballRepo(s.db, s.cfg.Router.Transactions)
+	jobs := map[string]func(ctx context.Context) error{
+		"session-cleanup": purge("session-cleanup", sessions.DeleteExpired),
+		"tombstone-purge": purge("tombstone-purge", football.PurgeTombstones),
+		"replay-purge":    purge("replay-purge", replay.NewPostgres(s.db).DeleteExpired),
+		"login-purge":     purge("login-purge", postgres.NewLoginRepo(s.db).DeleteExpired),
+		"daily-report":    s.dailyReport,
+		"preferences-rekey": func(ctx context.Context) error {
+			n, err := postgres.NewPreferencesRepo(s.db, s.cfg.Router.FieldKeys).Rekey(ctx)
+			if err == nil && n > 0 {
+				log.Printf("job preferences-rekey: re-encrypted the preferences of %d users", n)
+			}
+			return err
+		},
+	}
+	for name := range s.cfg.Schedules {
+		if _, ok := jobs[name]; !ok {
+			return nil, fmt.Errorf("server: unknown job %q in schedules", name)
+		}
+	}
+
+	sched := scheduler.New(locks, scheduler.NewPostgresHistory(s.db))
+	for name, run := range jobs {
+		spec := DefaultSchedules[name]
+		if override, ok := s.cfg.Schedules[name]; ok {
+			spec = override
+		}
+		if spec == "off" {
+			continue
+		}
+		if err := sched.Add(name, spec, run); err != nil {
+			return nil, fmt.Errorf("server: job %s: %w", name, err)
+		}
+	}
+	if len(s.cfg.AlertEmails) > 0 || s.cfg.Router.Alerts != nil {
+		sched.OnFailure(s.alertJobFailed)
+	}
+	return sched, nil
+}
+
+// purge adapts a cleanup method to a job that logs how much it removed.
+func purge(name string, del func(ctx context.Context) (int64, error)) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		n, err := del(ctx)
+		if err == nil && n > 0 {
+			log.Printf("job %s: removed %d rows", name, n)
+		}
+		return err
+	}
+}
+
+// dailyReport generates and stores yesterday's report and emails it to
+// ReportEmails.
+func (s *Server) dailyReport(ctx context.Context) error {
+	rep, err := s.reports.Generate(ctx, time.Now().UTC().AddDate(0, 0, -1))
+	if err != nil {
+		return err
+	}
+	if len(s.cfg.ReportEmails) == 0 || s.mail == nil {
+		return nil
+	}
+	text, err := report.Text(rep)
+	if err != nil {
+		return err
+	}
+	html, err := report.HTML(rep)
+	if err != nil {
+		return err
+	}
+	return s.mail.Enqueue(notify.Message{
+		To:      s.cfg.ReportEmails,
+		Subject: "[Football API] Daily report for " + rep.Date,
+		Text:    text,
+		HTML:    html,
+	})
+}
+
+// alertJobFailed tells AlertEmails and the alert webhook about a failed job
+// run.
+func (s *Server) alertJobFailed(run scheduler.Run) {
+	s.cfg.Router.Alerts.Send(alert.JobFailed, "Scheduled job "+run.Job+" failed", run.Error)
+	if len(s.cfg.AlertEmails) == 0 {
+		return
+	}
+	m, err := notify.Render("job_failed", run, s.cfg.AlertEmails...)
+	if err == nil {
+		err = s.mail.Enqueue(m)
+	}
+	if err != nil {
+		log.Printf("job %s: alert: %v", run.Job, err)
+	}
+}
+
+// Handler returns the API as an http.Handler, for mounting in another server
+// or serving with httptest.
+func (s *Server) Handler() http.Handler { return s.handler }
+
+// AdminHandler returns the administrative routes when AdminAddr is set, or
+// nil when they are part of Handler.
+func (s *Server) AdminHandler() http.Handler { return s.admin }
+
+// DB returns the server's database, or nil when it has none.
+func (s *Server) DB() *sql.DB { return s.db }
+
+// migrateWait bounds how long startup waits for another instance to finish
+// applying migrations.
+const migrateWait = 5 * time.Minute
+
+// migrate applies the plugins' migrations, named "<plugin>/<file>", and
+// logs which instance applied them and how long it took.
+func (s *Server) migrate(ctx context.Context) error {
+	var ms []postgres.Migration
+	for _, p := range s.cfg.Router.Plugins {
+		own := slices.Clone(p.Migrations)
+		slices.SortFunc(own, func(a, b app.Migration) int { return strings.Compare(a.Name, b.Name) })
+		for _, m := range own {
+			ms = append(ms, postgres.Migration{Name: p.Name + "/" + m.Name, SQL: m.SQL})
+		}
+	}
+	host, _ := os.Hostname()
+	instance := fmt.Sprintf("%s:%d", host, os.Getpid())
+	res, err := postgres.Migrate(ctx, s.db, instance, migrateWait, ms)
+	if err != nil {
+		return err
+	}
+	if len(res.Applied) == 0 {
+		log.Printf("migrate: schema up to date; %s waited %v for the migration lock", instance, res.Waited.Round(time.Millisecond))
+		return nil
+	}
+	log.Printf("migrate: %s applied %d migration(s) in %v after waiting %v for the migration lock",
+		instance, len(res.Applied), res.Took.Round(time.Millisecond), res.Waited.Round(time.Millisecond))
+	return nil
+}
+
+// migrated reports the tables the migrations and plugins need that do not
+// exist yet.
+func (s *Server) migrated(ctx context.Context) error {
+	if s.db == nil {
+		return nil
+	}
+	var tables []string
+	for _, p := range s.cfg.Router.Plugins {
+		for _, m := range p.Migrations {
+			tables = append(tables, m.Tables...)
+		}
+	}
+	missing, err := postgres.MissingTables(s.db, tables...)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("waiting for migrations: missing tables %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Healthy reports whether the server can serve requests: it returns the
+// error from pinging the database, or nil when there is none.
+func (s *Server) Healthy(ctx context.Context) error {
+	if s.db == nil {
+		return nil
+	}
+	if err := s.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("server: database: %w", err)
+	}
+	return nil
+}
+
+// Start binds the listeners and begins serving in the background.  It
+// returns once the server is accepting connections, or with the error that
+// prevented it.
+func (s *Server) Start() error {
+	ln, err := net.Listen("tcp", s.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("server: listen on %s: %w", s.cfg.Addr, err)
+	}
+	return s.Serve(ln)
+}
+
+// Serve is Start on a listener the caller has already bound.
+func (s *Server) Serve(ln net.Listener) error {
+	raw := ln
+	var err error
+	useTLS := s.cfg.TLSCertFile != "" && s.cfg.TLSKeyFile != ""
+	if s.cfg.HTTP3 && !useTLS {
+		ln.Close()
+		return errors.New("server: HTTP/3 requires TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+	s.http = &http.Server{Handler: s.handler, TLSConfig: s.cfg.TLSConfig, Protocols: s.protocols(useTLS)}
+	if useTLS {
+		if s.http.TLSConfig == nil {
+			s.http.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		cert, err := tls.LoadX509KeyPair(s.cfg.TLSCertFile, s.cfg.TLSKeyFile)
+		if err != nil {
+			ln.Close()
+			return fmt.Errorf("server: load TLS certificate: %w", err)
+		}
+		s.http.TLSConfig = s.http.TLSConfig.Clone()
+		s.http.TLSConfig.Certificates = []tls.Certificate{cert}
+		// The listener is wrapped here rather than by ServeTLS, so ALPN
+		// must be configured by hand.
+		if !s.cfg.DisableHTTP2 {
+			s.http.TLSConfig.NextProtos = []string{"h2", "http/1.1"}
+		}
+		if s.cfg.HTTP3 {
+			if err := s.startHTTP3(ln.Addr()); err != nil {
+				ln.Close()
+				return err
+			}
+		}
+		ln = tls.NewListener(ln, s.http.TLSConfig)
+	}
+	s.ln = ln
+	s.tcp = raw
+
+	if s.admin != nil {
+		if err := s.startAdmin(useTLS); err != nil {
+			ln.Close()
+			return err
+		}
+	}
+
+	if addr := s.cfg.DiagnosticsAddr; addr != "" {
+		if s.diagTCP == nil {
+			if s.diagTCP, err = net.Listen("tcp", addr); err != nil {
+				ln.Close()
+				return fmt.Errorf("server: listen on %s: %w", addr, err)
+			}
+		}
+		s.diag = &http.Server{Handler: diagnostics.Handler()}
+		go func() {
+			log.Printf("Serving diagnostics on %s", s.diagTCP.Addr())
+			if err := s.diag.Serve(s.diagTCP); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("diagnostics server error: %v", err)
+			}
+		}()
+	}
+
+	if s.db != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.stopBackground = cancel
+		s.background.Add(2)
+		if s.flags != nil {
+			s.background.Add(1)
+			go func() {
+				defer s.background.Done()
+				s.flags.RunRefresher(ctx)
+			}()
+		}
+		go func() {
+			defer s.background.Done()
+			leader.NewElector(s.db, "scheduler").Run(ctx, s.sched.Run)
+		}()
+		go func() {
+			defer s.background.Done()
+			s.reports.RunFlusher(ctx)
+		}()
+		if s.analytics != nil {
+			s.background.Add(1)
+			go func() {
+				defer s.background.Done()
+				s.analytics.RunFlusher(ctx)
+			}()
+		}
+		if s.meter != nil {
+			s.background.Add(1)
+			go func() {
+				defer s.background.Done()
+				s.meter.RunFlusher(ctx)
+			}()
+		}
+	}
+
+	if useTLS {
+		log.Printf("Starting TLS server on %s", ln.Addr())
+	} else {
+		log.Printf("Starting server on %s", ln.Addr())
+	}
+	go func() {
+		err := s.http.Serve(ln)
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		}
+		s.mu.Lock()
+		s.serveErr = err
+		s.mu.Unlock()
+		close(s.done)
+	}()
+	return nil
+}
+
+// startAdmin serves the administrative routes on AdminAddr.
+func (s *Server) startAdmin(useTLS bool) error {
+	if s.adminTCP == nil {
+		var err error
+		if s.adminTCP, err = net.Listen("tcp", s.cfg.AdminAddr); err != nil {
+			return fmt.Errorf("server: listen on %s: %w", s.cfg.AdminAddr, err)
+		}
+	}
+	ln := s.adminTCP
+	s.adminSv = &http.Server{Handler: s.admin, Protocols: s.protocols(useTLS)}
+	if useTLS {
+		s.adminSv.TLSConfig = s.http.TLSConfig
+		ln = tls.NewListener(ln, s.adminSv.TLSConfig)
+	}
+	s.adminLn = ln
+	go func() {
+		log.Printf("Serving admin API on %s", ln.Addr())
+		if err := s.adminSv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("admin server error: %v", err)
+		}
+	}()
+	return nil
+}
+
+// AdminAddr returns the address of the admin listener, or nil when there
+// is none.
+func (s *Server) AdminAddr() net.Addr {
+	if s.adminLn == nil {
+		return nil
+	}
+	return s.adminLn.Addr()
+}
+
+// protocols returns the HTTP versions served over TCP.
+func (s *Server) protocols(useTLS bool) *http.Protocols {
+	p := new(http.Protocols)
+	p.SetHTTP1(true)
+	if useTLS {
+		p.SetHTTP2(!s.cfg.DisableHTTP2)
+	} else {
+		p.SetUnencryptedHTTP2(s.cfg.H2C)
+	}
+	return p
+}
+
+// startHTTP3 serves HTTP/3 on the UDP port matching the TCP address, and
+// makes TCP responses advertise it.
+func (s *Server) startHTTP3(addr net.Addr) error {
+	udp, err := net.ListenPacket("udp", addr.String())
+	if err != nil {
+		return fmt.Errorf("server: listen for HTTP/3 on %s: %w", addr, err)
+	}
+	s.h3 = &http3.Server{
+		Handler:   s.handler,
+		TLSConfig: http3.ConfigureTLSConfig(s.http.TLSConfig),
+	}
+	h3 := s.h3
+	tcp := s.http.Handler
+	s.http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = h3.SetQUICHeaders(w.Header())
+		tcp.ServeHTTP(w, r)
+	})
+	go func() {
+		log.Printf("Starting experimental HTTP/3 server on udp %s", udp.LocalAddr())
+		if err := h3.Serve(udp); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("HTTP/3 server error: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Addr returns the address the server is listening on, or nil before Start.
+func (s *Server) Addr() net.Addr {
+	if s.ln == nil {
+		return nil
+	}
+	return s.ln.Addr()
+}
+
+// ListenerFiles returns duplicates of the listening sockets — public, then
+// admin and diagnostics when configured — for handing to a new process
+// that passes them to ServeInherited (see internal/upgrade).  The caller
+// closes the files; the listeners keep working.
+func (s *Server) ListenerFiles() ([]*os.File, error) {
+	var files []*os.File
+	for _, ln := range []net.Listener{s.tcp, s.adminTCP, s.diagTCP} {
+		if ln == nil {
+			continue
+		}
+		f, ok := ln.(interface{ File() (*os.File, error) })
+		if !ok {
+			closeFiles(files)
+			return nil, fmt.Errorf("server: listener %T has no file descriptor", ln)
+		}
+		file, err := f.File()
+		if err != nil {
+			closeFiles(files)
+			return nil, fmt.Errorf("server: %w", err)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		f.Close()
+	}
+}
+
+// ServeInherited is Serve on listeners taken over from a previous process,
+// in the order its ListenerFiles returned them.  The server must have the
+// same AdminAddr and DiagnosticsAddr settings as that process.
+func (s *Server) ServeInherited(ls []net.Listener) error {
+	want := 1
+	if s.admin != nil {
+		want++
+	}
+	if s.cfg.DiagnosticsAddr != "" {
+		want++
+	}
+	if len(ls) != want {
+		for _, ln := range ls {
+			ln.Close()
+		}
+		return fmt.Errorf("server: inherited %d listeners, configuration needs %d", len(ls), want)
+	}
+	rest := ls[1:]
+	if s.admin != nil {
+		s.adminTCP, rest = rest[0], rest[1:]
+	}
+	if s.cfg.DiagnosticsAddr != "" {
+		s.diagTCP = rest[0]
+	}
+	return s.Serve(ls[0])
+}
+
+// Wait blocks until the server stops serving and returns the error that
+// stopped it, or nil after Shutdown.
+func (s *Server) Wait() error {
+	<-s.done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.serveErr
+}
+
+// Shutdown fails /readyz and waits DrainDelay, then stops accepting
+// connections, waits for in-flight requests, scheduled jobs, background
+// event deliveries, alerts and queued email to finish or for ctx to expire, and closes the database if
+// New opened it.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.probes.Drain()
+	if s.http != nil && s.cfg.DrainDelay > 0 {
+		log.Printf("Draining for %v before closing listeners", s.cfg.DrainDelay)
+		timer := time.NewTimer(s.cfg.DrainDelay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+
+	var errs []error
+	if s.http != nil {
+		errs = append(errs, s.http.Shutdown(ctx))
+		<-s.done
+	}
+	if s.h3 != nil {
+		errs = append(errs, s.h3.Shutdown(ctx))
+	}
+	if s.adminSv != nil {
+		errs = append(errs, s.adminSv.Shutdown(ctx))
+	}
+	if s.diag != nil {
+		errs = append(errs, s.diag.Shutdown(ctx))
+	}
+	if s.stopBackground != nil {
+		s.stopBackground()
+		stopped := make(chan struct{})
+		go func() {
+			s.background.Wait()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			errs = append(errs, ctx.Err())
+		}
+	}
+	s.cfg.Router.Events.Wait()
+	s.cfg.Router.Alerts.Wait()
+	errs = append(errs, s.mail.Close(ctx))
+	if s.ownsDB {
+		errs = append(errs, s.db.Close())
+	}
+	return errors.Join(errs...)
+}
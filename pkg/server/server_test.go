@@ -0,0 +1,182 @@
+package server_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sc23bd/COMP3011_Coursework1/pkg/server"
+)
+
+func newServer(t *testing.T) *server.Server {
+	t.Helper()
+	srv, err := server.New(server.Config{
+		Addr:   "127.0.0.1:0",
+		Router: server.RouterConfig{JWTSecret: "test-secret"},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return srv
+}
+
+func TestServer_Handler(t *testing.T) {
+	srv := newServer(t)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/version", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestServer_StartAndShutdown(t *testing.T) {
+	srv := newServer(t)
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	resp, err := http.Get("http://" + srv.Addr().String() + "/api/v1/version")
+	if err != nil {
+		t.Fatalf("GET /version: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	if err := srv.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if err := srv.Wait(); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if _, err := http.Get("http://" + srv.Addr().String() + "/api/v1/version"); err == nil {
+		t.Fatal("server still accepting connections after Shutdown")
+	}
+}
+
+func TestServer_AdminAddr(t *testing.T) {
+	srv, err := server.New(server.Config{
+		Addr:      "127.0.0.1:0",
+		AdminAddr: "127.0.0.1:0",
+		Router:    server.RouterConfig{JWTSecret: "test-secret", AdminUsers: []string{"admin"}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer srv.Shutdown(context.Background())
+
+	status := func(addr, path string) int {
+		t.Helper()
+		resp, err := http.Get("http://" + addr + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	public, admin := srv.Addr().String(), srv.AdminAddr().String()
+
+	if got := status(public, "/api/v1/admin/log-level"); got != http.StatusNotFound {
+		t.Errorf("public /admin/log-level: expected 404, got %d", got)
+	}
+	if got := status(admin, "/api/v1/admin/log-level"); got != http.StatusUnauthorized {
+		t.Errorf("admin /admin/log-level: expected 401, got %d", got)
+	}
+	if got := status(public, "/api/v1/version"); got != http.StatusOK {
+		t.Errorf("public /version: expected 200, got %d", got)
+	}
+	if got := status(admin, "/api/v1/version"); got != http.StatusNotFound {
+		t.Errorf("admin /version: expected 404, got %d", got)
+	}
+}
+
+func TestServer_ServeInherited(t *testing.T) {
+	old := newServer(t)
+	if err := old.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	files, err := old.ListenerFiles()
+	if err != nil {
+		t.Fatalf("ListenerFiles: %v", err)
+	}
+	var ls []net.Listener
+	for _, f := range files {
+		ln, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ls = append(ls, ln)
+	}
+
+	next := newServer(t)
+	if err := next.ServeInherited(ls); err != nil {
+		t.Fatalf("ServeInherited: %v", err)
+	}
+	defer next.Shutdown(context.Background())
+	if err := old.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	resp, err := http.Get("http://" + old.Addr().String() + "/api/v1/version")
+	if err != nil {
+		t.Fatalf("GET after handover: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+}
+
+func TestServer_DrainDelay(t *testing.T) {
+	srv, err := server.New(server.Config{
+		Addr:       "127.0.0.1:0",
+		DrainDelay: 200 * time.Millisecond,
+		Router:     server.RouterConfig{JWTSecret: "test-secret"},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	base := "http://" + srv.Addr().String()
+
+	done := make(chan error, 1)
+	go func() { done <- srv.Shutdown(context.Background()) }()
+
+	// During the delay the server still answers, but reports not ready.
+	deadline := time.Now().Add(time.Second)
+	for {
+		resp, err := http.Get(base + "/readyz")
+		if err != nil {
+			t.Fatalf("GET /readyz during drain: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusServiceUnavailable {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("/readyz still %d after Shutdown began", resp.StatusCode)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	resp, err := http.Get(base + "/livez")
+	if err != nil {
+		t.Fatalf("GET /livez during drain: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("/livez: expected 200, got %d", resp.StatusCode)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+}
@@ -17,14 +17,20 @@
 //	go run scripts/import_football_data.go
 //
 // The script is idempotent: running it multiple times will not create duplicates
-// because INSERT … ON CONFLICT DO NOTHING is used throughout.
+// because INSERT … ON CONFLICT DO NOTHING is used throughout.  Matches, goals,
+// shootouts and former names are streamed into temporary staging tables with
+// COPY FROM in chunks and then moved across in a single INSERT … SELECT, so
+// hundred-thousand-row files load in seconds rather than one round-trip per
+// row.
 package main
 
 import (
 	"archive/zip"
 	"bytes"
+	"cmp"
 	"database/sql"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -33,11 +39,16 @@ import (
 	"strings"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	"github.com/sc23bd/COMP3011_Coursework1/internal/slug"
 )
 
 const (
 	localZipPath = "./football_data.zip"
+
+	// copyChunkSize is the number of rows sent per COPY; progress is logged
+	// after each chunk.
+	copyChunkSize = 10000
 )
 
 func main() {
@@ -125,7 +136,7 @@ func run() error {
 	if err != nil {
 		return fmt.Errorf("failed to insert matches: %w", err)
 	}
-	log.Printf("Matches imported: %d rows", len(matchIDs))
+	log.Printf("Matches resolved: %d rows", len(matchIDs))
 
 	// Step 3: Import goalscorers.csv (optional — file may be absent).
 	if data, ok := csvFiles["goalscorers.csv"]; ok {
@@ -133,7 +144,7 @@ func run() error {
 		if err != nil {
 			return fmt.Errorf("failed to insert goalscorers: %w", err)
 		}
-		log.Printf("Goals imported: %d rows", count)
+		log.Printf("Goals imported: %d new rows", count)
 	}
 
 	// Step 4: Import shootouts.csv (optional — file may be absent).
@@ -142,7 +153,7 @@ func run() error {
 		if err != nil {
 			return fmt.Errorf("failed to insert shootouts: %w", err)
 		}
-		log.Printf("Shootouts imported: %d rows", count)
+		log.Printf("Shootouts imported: %d new rows", count)
 	}
 
 	// Step 5: Import former_names.csv (optional — file may be absent).
@@ -156,7 +167,7 @@ func run() error {
 		if err != nil {
 			return fmt.Errorf("failed to insert former names: %w", err)
 		}
-		log.Printf("Former names imported: %d rows", count)
+		log.Printf("Former names imported: %d new rows", count)
 	}
 
 	// Commit the transaction.
@@ -222,6 +233,63 @@ type matchKey struct {
 	awayTeam string
 }
 
+// --- bulk loading ------------------------------------------------------------
+
+// copyInto bulk-loads rows into table.  COPY cannot skip conflicting rows, so
+// the rows are first copied, copyChunkSize at a time, into a temporary
+// staging table with the same columns, then moved into table with
+// INSERT … SELECT … ON CONFLICT DO NOTHING to keep re-runs idempotent.
+// Returns the number of rows newly inserted into table.
+func copyInto(tx *sql.Tx, table string, columns []string, rows [][]interface{}) (int64, error) {
+	staging := "staging_" + table
+	cols := strings.Join(columns, ", ")
+
+	if _, err := tx.Exec(fmt.Sprintf(
+		`CREATE TEMP TABLE %s ON COMMIT DROP AS SELECT %s FROM %s WITH NO DATA`,
+		staging, cols, table)); err != nil {
+		return 0, fmt.Errorf("creating %s: %w", staging, err)
+	}
+
+	for start := 0; start < len(rows); start += copyChunkSize {
+		end := min(start+copyChunkSize, len(rows))
+		if err := copyChunk(tx, staging, columns, rows[start:end]); err != nil {
+			return 0, fmt.Errorf("copying rows %d-%d into %s: %w", start, end, staging, err)
+		}
+		log.Printf("  %s: staged %d/%d rows", table, end, len(rows))
+	}
+
+	result, err := tx.Exec(fmt.Sprintf(
+		`INSERT INTO %s (%s) SELECT %s FROM %s ON CONFLICT DO NOTHING`,
+		table, cols, cols, staging))
+	if err != nil {
+		return 0, fmt.Errorf("moving rows into %s: %w", table, err)
+	}
+	if _, err := tx.Exec(`DROP TABLE ` + staging); err != nil {
+		return 0, fmt.Errorf("dropping %s: %w", staging, err)
+	}
+	return result.RowsAffected()
+}
+
+// copyChunk streams one chunk of rows into table with COPY FROM STDIN.
+func copyChunk(tx *sql.Tx, table string, columns []string, rows [][]interface{}) error {
+	stmt, err := tx.Prepare(pq.CopyIn(table, columns...))
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if _, err := stmt.Exec(row...); err != nil {
+			stmt.Close()
+			return err
+		}
+	}
+	// An Exec with no arguments flushes the buffered rows.
+	if _, err := stmt.Exec(); err != nil {
+		stmt.Close()
+		return err
+	}
+	return stmt.Close()
+}
+
 // --- insertion functions -----------------------------------------------------
 
 // insertTeamsAndTournaments inserts unique team and tournament names from
@@ -257,11 +325,7 @@ func insertTeamsAndTournaments(tx *sql.Tx, data []byte) (map[string]int, map[str
 		if name == "" {
 			continue
 		}
-		var id int
-		err := tx.QueryRow(
-			`INSERT INTO football_teams (name) VALUES ($1)
-			 ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
-			 RETURNING id`, name).Scan(&id)
+		id, err := insertTeam(tx, name)
 		if err != nil {
 			return nil, nil, fmt.Errorf("inserting team %q: %w", name, err)
 		}
@@ -288,6 +352,37 @@ func insertTeamsAndTournaments(tx *sql.Tx, data []byte) (map[string]int, map[str
 	return teamIDs, tournamentIDs, nil
 }
 
+// insertTeam returns the ID of the team called name, inserting it with a
+// slug no other team has if it does not exist yet.
+func insertTeam(tx *sql.Tx, name string) (int, error) {
+	var id int
+	err := tx.QueryRow(`SELECT id FROM football_teams WHERE name = $1`, name).Scan(&id)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return id, err
+	}
+	base := cmp.Or(slug.Make(name), "team")
+	rows, err := tx.Query(`SELECT slug FROM football_teams WHERE slug = $1 OR slug LIKE $1 || '-%'`, base)
+	if err != nil {
+		return 0, err
+	}
+	taken := make(map[string]bool)
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		taken[s] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	sl := slug.Unique(base, func(s string) bool { return taken[s] })
+	err = tx.QueryRow(`INSERT INTO football_teams (name, slug) VALUES ($1, $2) RETURNING id`, name, sl).Scan(&id)
+	return id, err
+}
+
 // insertMatches inserts match records from results.csv.
 // Returns a map of matchKey → database match ID.
 func insertMatches(tx *sql.Tx, data []byte, teamIDs, tournamentIDs map[string]int) (map[matchKey]int, error) {
@@ -306,19 +401,15 @@ func insertMatches(tx *sql.Tx, data []byte, teamIDs, tournamentIDs map[string]in
 	countryCol := colIndex(header, "country")
 	neutralCol := colIndex(header, "neutral")
 
-	matchIDs := make(map[matchKey]int, len(records))
-
-	const q = `
-		INSERT INTO football_matches
-			(match_date, home_team_id, away_team_id, home_score, away_score,
-			 tournament_id, city, country, neutral)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		ON CONFLICT (match_date, home_team_id, away_team_id) DO NOTHING
-		RETURNING id`
-
-	const selectID = `
-		SELECT id FROM football_matches
-		WHERE match_date = $1 AND home_team_id = $2 AND away_team_id = $3`
+	// matchRef ties a CSV match key to the natural key of its database row.
+	type matchRef struct {
+		key    matchKey
+		date   string
+		homeID int
+		awayID int
+	}
+	refs := make([]matchRef, 0, len(records))
+	rows := make([][]interface{}, 0, len(records))
 
 	for _, row := range records {
 		get := func(col int) string {
@@ -359,28 +450,59 @@ func insertMatches(tx *sql.Tx, data []byte, teamIDs, tournamentIDs map[string]in
 		awayScore, _ := strconv.Atoi(get(awayScoreCol))
 		neutral := parseBool(get(neutralCol))
 
-		var id int
-		err = tx.QueryRow(q,
-			date.Format("2006-01-02"),
+		day := date.Format("2006-01-02")
+		rows = append(rows, []interface{}{
+			day,
 			homeTeamID, awayTeamID,
 			homeScore, awayScore,
 			tournamentID,
 			get(cityCol), get(countryCol),
 			neutral,
-		).Scan(&id)
-		if err == sql.ErrNoRows {
-			// Row already existed; fetch its ID so downstream inserts can reference it.
-			err = tx.QueryRow(selectID,
-				date.Format("2006-01-02"), homeTeamID, awayTeamID,
-			).Scan(&id)
-		}
-		if err != nil {
-			return nil, fmt.Errorf("inserting match %s %s vs %s: %w", dateStr, homeTeam, awayTeam, err)
-		}
+		})
+		refs = append(refs, matchRef{matchKey{dateStr, homeTeam, awayTeam}, day, homeTeamID, awayTeamID})
+	}
+
+	inserted, err := copyInto(tx, "football_matches", []string{
+		"match_date", "home_team_id", "away_team_id", "home_score", "away_score",
+		"tournament_id", "city", "country", "neutral",
+	}, rows)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Matches: %d new rows", inserted)
 
-		matchIDs[matchKey{dateStr, homeTeam, awayTeam}] = id
+	// Resolve the ID of every match, new or pre-existing, so that the child
+	// tables can reference it.
+	type naturalKey struct {
+		date           string
+		homeID, awayID int
+	}
+	ids := make(map[naturalKey]int, len(refs))
+	dbRows, err := tx.Query(`SELECT id, match_date, home_team_id, away_team_id FROM football_matches`)
+	if err != nil {
+		return nil, fmt.Errorf("reading match IDs: %w", err)
+	}
+	defer dbRows.Close()
+	for dbRows.Next() {
+		var (
+			id, homeID, awayID int
+			date               time.Time
+		)
+		if err := dbRows.Scan(&id, &date, &homeID, &awayID); err != nil {
+			return nil, fmt.Errorf("reading match IDs: %w", err)
+		}
+		ids[naturalKey{date.Format("2006-01-02"), homeID, awayID}] = id
+	}
+	if err := dbRows.Err(); err != nil {
+		return nil, fmt.Errorf("reading match IDs: %w", err)
 	}
 
+	matchIDs := make(map[matchKey]int, len(refs))
+	for _, ref := range refs {
+		if id, ok := ids[naturalKey{ref.date, ref.homeID, ref.awayID}]; ok {
+			matchIDs[ref.key] = id
+		}
+	}
 	return matchIDs, nil
 }
 
@@ -399,12 +521,7 @@ func insertGoalscorers(tx *sql.Tx, data []byte, matchIDs map[matchKey]int, teamI
 	ownGoalCol := colIndex(header, "own_goal")
 	penaltyCol := colIndex(header, "penalty")
 
-	const q = `
-		INSERT INTO football_goalscorers (match_id, team_id, scorer, own_goal, penalty)
-		VALUES ($1, $2, $3, $4, $5)
-		ON CONFLICT DO NOTHING`
-
-	count := 0
+	var rows [][]interface{}
 	for _, row := range records {
 		get := func(col int) string {
 			if col < 0 || col >= len(row) {
@@ -431,13 +548,12 @@ func insertGoalscorers(tx *sql.Tx, data []byte, matchIDs map[matchKey]int, teamI
 		ownGoal := parseBool(get(ownGoalCol))
 		penalty := parseBool(get(penaltyCol))
 
-		_, err := tx.Exec(q, matchID, teamID, scorer, ownGoal, penalty)
-		if err != nil {
-			return count, fmt.Errorf("inserting goal (scorer=%q): %w", scorer, err)
-		}
-		count++
+		rows = append(rows, []interface{}{matchID, teamID, scorer, ownGoal, penalty})
 	}
-	return count, nil
+
+	n, err := copyInto(tx, "football_goalscorers",
+		[]string{"match_id", "team_id", "scorer", "own_goal", "penalty"}, rows)
+	return int(n), err
 }
 
 // insertShootouts inserts penalty-shootout records from shootouts.csv.
@@ -452,12 +568,7 @@ func insertShootouts(tx *sql.Tx, data []byte, matchIDs map[matchKey]int, teamIDs
 	awayCol := colIndex(header, "away_team")
 	winnerCol := colIndex(header, "winner")
 
-	const q = `
-		INSERT INTO football_shootouts (match_id, winner_id)
-		VALUES ($1, $2)
-		ON CONFLICT (match_id) DO NOTHING`
-
-	count := 0
+	var rows [][]interface{}
 	for _, row := range records {
 		get := func(col int) string {
 			if col < 0 || col >= len(row) {
@@ -480,13 +591,11 @@ func insertShootouts(tx *sql.Tx, data []byte, matchIDs map[matchKey]int, teamIDs
 			continue
 		}
 
-		_, err := tx.Exec(q, matchID, winnerID)
-		if err != nil {
-			return count, fmt.Errorf("inserting shootout (matchID=%d): %w", matchID, err)
-		}
-		count++
+		rows = append(rows, []interface{}{matchID, winnerID})
 	}
-	return count, nil
+
+	n, err := copyInto(tx, "football_shootouts", []string{"match_id", "winner_id"}, rows)
+	return int(n), err
 }
 
 // ensureFormerNameTeams inserts any teams from former_names.csv that are not
@@ -511,11 +620,7 @@ func ensureFormerNameTeams(tx *sql.Tx, data []byte, teamIDs map[string]int) erro
 		if _, exists := teamIDs[name]; exists {
 			continue
 		}
-		var id int
-		err := tx.QueryRow(
-			`INSERT INTO football_teams (name) VALUES ($1)
-			 ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
-			 RETURNING id`, name).Scan(&id)
+		id, err := insertTeam(tx, name)
 		if err != nil {
 			return fmt.Errorf("inserting team %q: %w", name, err)
 		}
@@ -536,12 +641,7 @@ func insertFormerNames(tx *sql.Tx, data []byte, teamIDs map[string]int) (int, er
 	startCol := colIndex(header, "start_date")
 	endCol := colIndex(header, "end_date")
 
-	const q = `
-		INSERT INTO football_former_names (team_id, former_name, start_date, end_date)
-		VALUES ($1, $2, $3, $4)
-		ON CONFLICT DO NOTHING`
-
-	count := 0
+	var rows [][]interface{}
 	for _, row := range records {
 		get := func(col int) string {
 			if col < 0 || col >= len(row) {
@@ -575,11 +675,10 @@ func insertFormerNames(tx *sql.Tx, data []byte, teamIDs map[string]int) (int, er
 			}
 		}
 
-		_, err := tx.Exec(q, teamID, formerName, startDate, endDate)
-		if err != nil {
-			return count, fmt.Errorf("inserting former name %q for team %q: %w", formerName, currentName, err)
-		}
-		count++
+		rows = append(rows, []interface{}{teamID, formerName, startDate, endDate})
 	}
-	return count, nil
+
+	n, err := copyInto(tx, "football_former_names",
+		[]string{"team_id", "former_name", "start_date", "end_date"}, rows)
+	return int(n), err
 }